@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/approval"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/dns"
+)
+
+// runApprovals prints the changes waiting for approval.
+// Usage: companion approvals [-state path]
+func runApprovals(args []string) int {
+	fs := flag.NewFlagSet("approvals", flag.ContinueOnError)
+	statePath := fs.String("state", defaultStateFilePath(), "path to the state file, the approval queue is kept next to it")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	queue, err := approval.Load(approval.Path(*statePath))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read approval queue: %v\n", err)
+		return 1
+	}
+	if len(queue.Changes) == 0 {
+		fmt.Println("No changes waiting for approval")
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCHANGE\tREQUESTED\tAPPROVED")
+	for _, change := range queue.Changes {
+		approved := "-"
+		if change.Approved() {
+			approved = change.ApprovedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", change.ID, change, change.RequestedAt.Format(time.RFC3339), approved)
+	}
+	w.Flush()
+	return 0
+}
+
+// runApprove approves a queued change; the running companion applies it within
+// dns.ApprovalCheckInterval.
+// Usage: companion approve [-state path] id
+func runApprove(args []string) int {
+	return updateApprovalQueue("approve", args, func(queue *approval.Queue, id string) error {
+		change, err := queue.Approve(id, time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Approved %s: %s\n", change.ID, change)
+		return nil
+	})
+}
+
+// runReject drops a queued change. It is queued again, and notified, the next time the
+// companion tries to apply it.
+// Usage: companion reject [-state path] id
+func runReject(args []string) int {
+	return updateApprovalQueue("reject", args, func(queue *approval.Queue, id string) error {
+		if !queue.Remove(id) {
+			return fmt.Errorf("no change %s waiting for approval", id)
+		}
+		fmt.Printf("Rejected %s\n", id)
+		return nil
+	})
+}
+
+// updateApprovalQueue applies update to the change named by the only argument of a
+// subcommand and saves the queue
+func updateApprovalQueue(name string, args []string, update func(queue *approval.Queue, id string) error) int {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	statePath := fs.String("state", defaultStateFilePath(), "path to the state file, the approval queue is kept next to it")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: companion %s [-state path] id\n", name)
+		return 2
+	}
+
+	path := approval.Path(*statePath)
+	queue, err := approval.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read approval queue: %v\n", err)
+		return 1
+	}
+	if err := update(queue, fs.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := queue.Save(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save approval queue: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// defaultStateFilePath returns STATE_FILE_PATH, or its default
+func defaultStateFilePath() string {
+	if path := os.Getenv("STATE_FILE_PATH"); path != "" {
+		return path
+	}
+	return "/data/state.json"
+}
+
+// runApprovalMonitor applies approved changes as they are approved
+func runApprovalMonitor(ctx context.Context, dnsManager *dns.Manager) {
+	ticker := time.NewTicker(dns.ApprovalCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		applyApproved(ctx, dnsManager)
+	}
+}
+
+// applyApproved applies the approved changes and reports whether all succeeded
+func applyApproved(ctx context.Context, dnsManager *dns.Manager) bool {
+	applied, err := dnsManager.ApplyApproved(ctx)
+	if applied > 0 {
+		log.Printf("Applied %d approved changes", applied)
+	}
+	if err != nil && !errors.Is(err, dns.ErrPaused) {
+		log.Printf("Warning: Failed to apply approved changes: %v", err)
+		return false
+	}
+	return true
+}
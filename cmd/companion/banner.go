@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/dns"
+)
+
+// buildInfo describes the running binary, e.g. "v1.4.0 (commit 1a2b3c4, built 2026-01-02T03:04:05Z, go1.25.1 linux/amd64)".
+// Without a commit set at build time, the VCS revision recorded by the Go toolchain is used.
+func buildInfo() string {
+	rev, date := commit, buildDate
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && rev == "":
+				rev = setting.Value
+			case setting.Key == "vcs.time" && date == "":
+				date = setting.Value
+			}
+		}
+	}
+	if len(rev) > 7 {
+		rev = rev[:7]
+	}
+	if rev == "" {
+		rev = "unknown"
+	}
+	if date == "" {
+		date = "unknown"
+	}
+	return fmt.Sprintf("%s (commit %s, built %s, %s %s/%s)", version, rev, date, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
+
+// logStartupSummary logs the build, the mode, the enabled features, the published
+// address and the zones in scope in one block, for support requests and audits
+func logStartupSummary(cfg *config.Config, dnsManager *dns.Manager, statePersisted bool) {
+	mode := cfg.Mode
+	if cfg.DryRun {
+		mode += ", dry run"
+	}
+
+	var enabled, disabled []string
+	feature := func(on bool, name, detail string) {
+		if !on {
+			disabled = append(disabled, name)
+			return
+		}
+		if detail != "" {
+			name += " (" + detail + ")"
+		}
+		enabled = append(enabled, name)
+	}
+	reconciliation := "startup"
+	if cfg.ReconciliationInterval > 0 {
+		reconciliation = fmt.Sprintf("startup, every %ds", cfg.ReconciliationInterval)
+	}
+	feature(statePersisted, "state persistence", cfg.StateFilePath)
+	feature(statePersisted && cfg.StateS3Bucket != "", "state copy", "s3://"+cfg.StateS3Bucket+"/"+cfg.StateS3Key)
+	feature(statePersisted && cfg.ReconciliationEnabled, "reconciliation", reconciliation)
+	feature(len(cfg.NotificationURLs) > 0, "notifications", strings.Join(notificationServices(cfg.NotificationURLs), ", "))
+	feature(cfg.MetricsTextfilePath != "", "metrics textfile", cfg.MetricsTextfilePath)
+	feature(cfg.AuditLogPath != "", "audit log", cfg.AuditLogPath)
+	feature(cfg.ApprovalRequired, "approval", "")
+	feature(cfg.FailoverEnabled(), "failover", cfg.FailoverPrimaryIP+" -> "+cfg.FailoverSecondaryIP)
+	feature(cfg.StandbyEnabled(), "standby", "heartbeat in "+cfg.StandbyHeartbeatDomain)
+	feature(cfg.HostIPMonitorEnabled(), "host IP monitor", "")
+	feature(len(cfg.OverlayDomains) > 0, "overlay domains", strings.Join(cfg.OverlayDomains, ", "))
+	feature(cfg.SecondaryProvider != "", "secondary provider", cfg.SecondaryProvider)
+	feature(cfg.InternalProvider != "", "split-horizon", cfg.InternalProvider)
+	feature(cfg.ACMEAPIAddr != "", "ACME API", cfg.ACMEAPIAddr)
+	feature(cfg.ForwardURL != "" || cfg.ForwardAPIAddr != "", "forwarding", "")
+	feature(cfg.UpdateCheck, "update check", "")
+
+	address := publishedAddress(cfg, dnsManager)
+
+	zones := "all zones of the routed hosts"
+	if len(cfg.OwnedZones) > 0 {
+		zones = strings.Join(cfg.OwnedZones, ", ")
+	}
+
+	log.Printf("Startup summary:")
+	log.Printf("  Version:  %s", buildInfo())
+	log.Printf("  Mode:     %s", mode)
+	log.Printf("  Enabled:  %s", orNone(enabled))
+	log.Printf("  Disabled: %s", orNone(disabled))
+	log.Printf("  Address:  %s", address)
+	log.Printf("  Zones:    %s", zones)
+}
+
+// publishedAddress describes the address records point at and where it comes from
+func publishedAddress(cfg *config.Config, dnsManager *dns.Manager) string {
+	if cfg.PublishContainerIP() {
+		return "container addresses"
+	}
+
+	source := "auto-detected"
+	switch {
+	case cfg.FailoverEnabled():
+		source = "active failover destination"
+	case cfg.HostIP != "":
+		source = "HOST_IP"
+	case cfg.IPSource != config.IPSourceHost:
+		source = "IP_SOURCE=" + cfg.IPSource
+	}
+	ip, err := dnsManager.HostIP()
+	if err != nil {
+		return fmt.Sprintf("unresolved, %s: %v", source, err)
+	}
+	return fmt.Sprintf("%s, %s", ip, source)
+}
+
+// notificationServices returns the services of the notification URLs, so the summary
+// never contains their tokens
+func notificationServices(urls []string) []string {
+	services := make([]string, 0, len(urls))
+	for _, url := range urls {
+		service, _, _ := strings.Cut(url, "://")
+		services = append(services, service)
+	}
+	return services
+}
+
+// orNone joins items, or returns "none" for an empty list
+func orNone(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"log"
+	"os"
+)
+
+// Exit codes, so supervisors and scripts can tell why the companion stopped
+const (
+	exitRuntimeError      = 1 // A single pass or check failed, or any other error
+	exitConfigError       = 2 // The configuration is invalid
+	exitDockerUnavailable = 3 // No Docker daemon answered within DOCKER_STARTUP_TIMEOUT_SEC
+	exitNetcupAuthFailed  = 4 // Netcup rejected the login
+)
+
+// fatal logs the message and exits with code
+func fatal(code int, format string, v ...any) {
+	log.Printf(format, v...)
+	os.Exit(code)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/export"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+// runExport renders the persisted state in an external-dns or octoDNS compatible format.
+// Usage: companion export [-format external-dns|octodns] [-state path] [-ttl seconds] [-output path] [-project name] [-tag tag]
+func runExport(args []string) int {
+	defaultStatePath := os.Getenv("STATE_FILE_PATH")
+	if defaultStatePath == "" {
+		defaultStatePath = "/data/state.json"
+	}
+	defaultTTL := 300
+	if ttl, err := strconv.Atoi(os.Getenv("NC_DEFAULT_TTL")); err == nil {
+		defaultTTL = ttl
+	}
+
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	formatName := fs.String("format", string(export.FormatExternalDNS), "export format: external-dns or octodns")
+	statePath := fs.String("state", defaultStatePath, "path to the state file")
+	ttl := fs.Int("ttl", defaultTTL, "TTL written for each record")
+	output := fs.String("output", "", "output file (external-dns) or directory (octodns); defaults to stdout")
+	project := fs.String("project", "", "only export records owned by this Compose project")
+	tag := fs.String("tag", "", "only export records carrying this tag")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if _, err := os.Stat(*statePath); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read state file: %v\n", err)
+		return 1
+	}
+
+	stateManager, err := state.NewManager(*statePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load state: %v\n", err)
+		return 1
+	}
+	records := filterRecords(stateManager.GetRecordsForReconciliation(), *tag, *project)
+
+	zones := stateManager.Zones()
+	signed := make([]string, 0, len(zones))
+	for domain, status := range zones {
+		if status.DNSSEC {
+			signed = append(signed, domain)
+		}
+	}
+	sort.Strings(signed)
+	for _, domain := range signed {
+		fmt.Fprintf(os.Stderr, "Note: zone %s is DNSSEC-signed at Netcup, sign it at the new provider as well\n", domain)
+	}
+
+	switch format {
+	case export.FormatExternalDNS:
+		data := export.ExternalDNS(records, *ttl)
+		if *output == "" {
+			os.Stdout.Write(data)
+			return 0
+		}
+		if err := os.WriteFile(*output, data, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write export: %v\n", err)
+			return 1
+		}
+
+	case export.FormatOctoDNS:
+		files := export.OctoDNS(records, *ttl)
+		domains := make([]string, 0, len(files))
+		for domain := range files {
+			domains = append(domains, domain)
+		}
+		sort.Strings(domains)
+
+		if *output == "" {
+			for _, domain := range domains {
+				fmt.Printf("# %s.yaml\n", domain)
+				os.Stdout.Write(files[domain])
+			}
+			return 0
+		}
+		if err := os.MkdirAll(*output, 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create output directory: %v\n", err)
+			return 1
+		}
+		for _, domain := range domains {
+			if err := os.WriteFile(filepath.Join(*output, domain+".yaml"), files[domain], 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write export for %s: %v\n", domain, err)
+				return 1
+			}
+		}
+	}
+
+	return 0
+}
@@ -2,52 +2,215 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/alex289/docker-traefik-netcup-companion/internal/acme"
 	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/diagnostics"
 	"github.com/alex289/docker-traefik-netcup-companion/internal/dns"
 	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/events"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/forward"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/httpserver"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/metrics"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/migration"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/notification"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/objectstore"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/scheduler"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/standby"
 	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/tracing"
+)
+
+// Build info set at build time with -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=..."
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
 )
 
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	log.Println("Starting Docker Traefik Netcup Companion...")
+
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "prepare-migration":
+			os.Exit(runPrepareMigration(os.Args[2:]))
+		case "export-zone":
+			os.Exit(runExportZone(os.Args[2:]))
+		case "import-zone":
+			os.Exit(runImportZone(os.Args[2:]))
+		case "list":
+			os.Exit(runList(os.Args[2:]))
+		case "reconcile":
+			os.Exit(runReconcileTag(os.Args[2:]))
+		case "delete":
+			os.Exit(runDeleteTag(os.Args[2:]))
+		case "approvals":
+			os.Exit(runApprovals(os.Args[2:]))
+		case "approve":
+			os.Exit(runApprove(os.Args[2:]))
+		case "reject":
+			os.Exit(runReject(os.Args[2:]))
+		}
+	}
+
+	preflight := flag.Bool("preflight", false, "validate credentials, zones, Docker access and host IP, then exit")
+	adopt := flag.Bool("adopt", false, "on first run, seed the empty state with the existing records of running containers")
+	once := flag.Bool("once", false, "scan containers, sync DNS and reconcile once, then exit non-zero if anything failed")
+	flag.Parse()
+
+	// Deferred first, so the exit code of a single pass is set after all other cleanup ran
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	log.Printf("Starting Docker Traefik Netcup Companion %s...", version)
 
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatal(exitConfigError, "Failed to load configuration: %v", err)
+	}
+
+	if cfg.EnvFile != "" {
+		logEnvFileConfig(cfg)
+	}
+
+	if *preflight {
+		os.Exit(runPreflight(context.Background(), cfg))
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		log.Printf("Warning: Failed to set up tracing: %v", err)
+	} else {
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(shutdownCtx); err != nil {
+				log.Printf("Warning: Failed to flush traces: %v", err)
+			}
+		}()
 	}
 
 	if cfg.DryRun {
 		log.Println("DRY RUN MODE ENABLED - No actual DNS changes will be made")
 	}
 
+	if cfg.ObserveMode() {
+		log.Printf("OBSERVE MODE ENABLED - DNS is never written, drift is checked every %ds", cfg.DriftCheckInterval)
+	}
+
+	if cfg.PublishContainerIP() {
+		log.Println("Publishing container IPs instead of the host IP")
+	}
+
+	if cfg.NetcupProxyURL != nil {
+		log.Printf("Using proxy for Netcup API requests: %s", cfg.NetcupProxyURL.Redacted())
+	}
+
+	if cfg.DebugHTTP {
+		log.Println("Logging Netcup API requests and responses, credentials and session ids redacted")
+	}
+
+	if cfg.HealthCheckGatingEnabled {
+		log.Printf("Healthcheck gating enabled, unhealthy grace period: %ds", cfg.HealthCheckGracePeriod)
+	}
+	if cfg.ProbeMode != "" {
+		log.Printf("Probing services of started containers before publishing (%s, timeout %ds)", cfg.ProbeMode, cfg.ProbeTimeout)
+	}
+
 	// Initialize state manager if persistence is enabled
 	var stateManager *state.Manager
 	if cfg.StatePersistenceEnabled {
-		stateManager, err = state.NewManager(cfg.StateFilePath)
+		stateManager, err = newStateManager(cfg)
 		if err != nil {
 			log.Printf("Warning: Failed to initialize state manager: %v", err)
 			log.Println("Continuing without state persistence")
 		} else {
 			log.Printf("State persistence enabled, using file: %s", cfg.StateFilePath)
+			defer flushState(cfg, stateManager)
+			if err := stateManager.Migrate(); err != nil {
+				log.Printf("Warning: Failed to save migrated state file: %v", err)
+			}
 		}
 	} else {
 		log.Println("State persistence disabled")
 	}
 
 	// Create DNS manager
-	dnsManager := dns.NewManager(cfg, stateManager)
+	notifier := dns.NewNotifier(cfg)
+	netcupClient := netcup.NewKeepAliveAPI(dns.NewNetcupClient(cfg, notifier), time.Duration(cfg.SessionKeepAlive)*time.Second)
+	bus := events.NewBus()
+	dnsManager := dns.NewManagerWithOptions(cfg, netcupClient, stateManager, &dns.ManagerOptions{
+		Events:    bus,
+		Secondary: dns.NewSecondaryProvider(cfg),
+		Internal:  dns.NewInternalProvider(cfg),
+		Notifier:  notifier,
+	})
+
+	// Refuse to start with a private host IP under PRIVATE_IP_POLICY=fail. Degraded, the
+	// changes fail processing until the host IP is public.
+	if cfg.PrivateIPPolicy == config.PrivateIPFail && !cfg.PublishContainerIP() {
+		if _, err := dnsManager.HostIP(); errors.Is(err, dns.ErrPrivateHostIP) {
+			if cfg.FailOnStartupErrors {
+				fatal(exitConfigError, "Refusing to start: %v", err)
+			}
+			log.Printf("Warning: %v, continuing as FAIL_ON_STARTUP_ERRORS=false", err)
+			notifier.SendEventError(notification.EventLifecycle, fmt.Sprintf("Started with a private host IP: %v", err))
+		}
+	}
+
+	// Check the credentials before relying on them. Only a rejected login is fatal; Netcup
+	// being unreachable is retried like any later outage.
+	if err := checkNetcupLogin(context.Background(), netcupClient); err != nil {
+		if errors.Is(err, netcup.ErrLoginRejected) && cfg.FailOnStartupErrors {
+			fatal(exitNetcupAuthFailed, "Netcup login failed: %v", err)
+		}
+		log.Printf("Warning: Netcup login failed, continuing: %v", err)
+		notifier.SendEventError(notification.EventLifecycle, fmt.Sprintf("Netcup login failed at startup: %v", err))
+	}
 
-	// Create Docker watcher
-	watcher, err := docker.NewWatcher(cfg.DockerFilterLabel)
+	logStartupSummary(cfg, dnsManager, stateManager != nil)
+
+	// Create Docker watcher, withdrawing hosts dropped from recreated containers when
+	// the hosts of each container can be persisted
+	watcherOptions := dockerWatcherOptions(cfg)
+	watcherOptions.CrashLoop.OnCrashLoop = func(containerName string, restartCount int) {
+		notifier.SendEventError(notification.EventDocker, fmt.Sprintf("Container %s is in a crash loop (restart count %d), holding back its DNS changes until it stays up for %ds", containerName, restartCount, cfg.CrashLoopCooldown))
+	}
+	if stateManager != nil && !cfg.ObserveMode() {
+		watcherOptions.HostTracker = stateManager
+	}
+	// Docker may still be starting when the companion is started during boot
+	watcher, err := docker.NewWatcherWhenReady(context.Background(), cfg.DockerFilterLabel, watcherOptions, time.Duration(cfg.DockerStartupTimeout)*time.Second)
+	if err != nil && !cfg.FailOnStartupErrors {
+		log.Printf("Warning: Docker is unavailable, waiting for it as FAIL_ON_STARTUP_ERRORS=false: %v", err)
+		notifier.SendEventError(notification.EventLifecycle, fmt.Sprintf("Docker is unavailable, waiting for it: %v", err))
+		watcher, err = docker.NewWatcherWhenReady(context.Background(), cfg.DockerFilterLabel, watcherOptions, docker.WaitIndefinitely)
+	}
 	if err != nil {
-		log.Fatalf("Failed to create Docker watcher: %v", err)
+		fatal(exitDockerUnavailable, "Failed to create Docker watcher: %v", err)
+	}
+	if len(cfg.DockerHosts) > 0 {
+		log.Printf("Watching Docker daemons: %s", strings.Join(watcher.Hosts(), ", "))
 	}
 	defer watcher.Close()
 
@@ -64,6 +227,65 @@ func main() {
 		cancel()
 	}()
 
+	if *once {
+		exitCode = runOnce(ctx, cfg, watcher, dnsManager, stateManager, notifier, bus, *adopt)
+		return
+	}
+
+	// Keep the shared Netcup session alive while idle
+	if keepAlive, ok := netcupClient.(netcup.KeepAliveRunner); ok {
+		go keepAlive.RunKeepAlive(ctx)
+	}
+
+	// Retry notifications that could not be delivered
+	go notifier.RunSpool(ctx)
+
+	// Queue of the hosts found by the watcher, started along with the workers
+	hostQueue := events.NewHostQueue(cfg.HostQueueSize)
+
+	// Write a diagnostics bundle on SIGUSR2
+	go runDiagnosticsDump(ctx, cfg, dnsManager, hostQueue)
+
+	// Close the Netcup circuit breaker on SIGHUP
+	go runCircuitBreakerReset(ctx, dnsManager)
+
+	// Pause and resume DNS writes via SIGUSR1 or the pause file. A pause file present
+	// at startup pauses writes before the initial sync.
+	if pauseFileExists(cfg.PauseFile) {
+		pauseWrites(dnsManager, notifier, "pause file present")
+	}
+	go runPauseControl(ctx, cfg.PauseFile, dnsManager, notifier)
+
+	// Stand by for the primary companion, keeping writes paused while its heartbeat is fresh
+	if cfg.StandbyEnabled() {
+		pauseWrites(dnsManager, notifier, "standing by for the primary companion")
+		go runStandby(ctx, cfg, dnsManager, notifier)
+	}
+
+	// Queue DNS changes while the Netcup API is in maintenance and apply them once it is over
+	if !cfg.ObserveMode() {
+		go dnsManager.RunMaintenanceMonitor(ctx)
+	}
+
+	// Keep and eventually restore the zone TTLs of a prepared migration. The first check
+	// runs before reconciliation so zone settings do not undo the lowered TTLs.
+	if !cfg.ObserveMode() {
+		migrationPath := migration.Path(cfg.StateFilePath)
+		checkMigration(ctx, migrationPath, dnsManager, notifier)
+		go runMigrationMonitor(ctx, migrationPath, dnsManager, notifier)
+	}
+
+	// Take over records created before the companion managed DNS
+	if *adopt && !cfg.ObserveMode() {
+		adoptExistingRecords(ctx, watcher, dnsManager, stateManager, notifier)
+	}
+
+	// Apply changes to foreign records once they are approved
+	if cfg.ApprovalRequired && !cfg.ObserveMode() {
+		applyApproved(ctx, dnsManager)
+		go runApprovalMonitor(ctx, dnsManager)
+	}
+
 	// Perform startup reconciliation if enabled
 	if cfg.ReconciliationEnabled && stateManager != nil && stateManager.HasRecords() {
 		log.Println("Performing startup reconciliation...")
@@ -72,44 +294,655 @@ func main() {
 		}
 	}
 
+	// Start drift monitor in observe mode
+	if cfg.ObserveMode() {
+		go dnsManager.RunDriftMonitor(ctx, time.Duration(cfg.DriftCheckInterval)*time.Second)
+	}
+
+	// Start failover monitor if primary and secondary destinations are configured
+	if cfg.FailoverEnabled() {
+		log.Printf("Failover enabled: primary %s, secondary %s", cfg.FailoverPrimaryIP, cfg.FailoverSecondaryIP)
+		go dnsManager.RunFailoverMonitor(ctx)
+	}
+
+	// Re-point records when the auto-detected host IP or the overlay address changes, e.g.
+	// after a PPPoE reconnect
+	if cfg.HostIPMonitorEnabled() {
+		go dnsManager.RunHostIPMonitor(ctx)
+	}
+	if cfg.OverlayMonitorEnabled() {
+		go dnsManager.RunOverlayMonitor(ctx)
+	}
+
+	// Handle modifications of the state file by other processes, e.g. the tag commands
+	if stateManager != nil && cfg.StateExternalChanges != config.StateChangesIgnore {
+		go stateManager.WatchFile(ctx, stateChangePolicy(cfg.StateExternalChanges))
+	}
+
+	// Delete records of containers with the netcup.expires-in label once their lifetime ends
+	if stateManager != nil && !cfg.ObserveMode() {
+		go dnsManager.RunExpirySweeper(ctx)
+	}
+
+	// Solve ACME DNS-01 challenges for Traefik and other ACME clients
+	if cfg.ACMEAPIAddr != "" && !cfg.ObserveMode() {
+		go runACMEAPI(ctx, cfg, dnsManager)
+	}
+
+	// Delete _acme-challenge records left behind by ACME clients
+	if cfg.ACMEChallengeMaxAge > 0 && !cfg.ObserveMode() {
+		if stateManager != nil {
+			log.Printf("ACME challenge cleanup enabled, max age: %s", cfg.ACMEChallengeMaxAge)
+			go dnsManager.RunChallengeSweeper(ctx, cfg.ACMEChallengeMaxAge)
+		} else {
+			log.Println("Warning: ACME_CHALLENGE_MAX_AGE requires state persistence, challenge cleanup disabled")
+		}
+	}
+
+	// Publish hosts forwarded by companions not owning their zones
+	if cfg.ForwardAPIAddr != "" && !cfg.ObserveMode() {
+		go runForwardAPI(ctx, cfg, dnsManager)
+	}
+	if len(cfg.OwnedZones) > 0 && cfg.ForwardURL != "" {
+		log.Printf("Owning zones %s, forwarding other hosts to %s", strings.Join(cfg.OwnedZones, ", "), cfg.ForwardURL)
+	} else if len(cfg.OwnedZones) > 0 {
+		log.Printf("Owning zones %s, skipping other hosts", strings.Join(cfg.OwnedZones, ", "))
+	}
+
+	// Publish a TXT heartbeat record for monitoring via DNS
+	if len(cfg.HeartbeatDomains) > 0 && !cfg.ObserveMode() {
+		go dnsManager.RunHeartbeat(ctx, version)
+	}
+
+	// Notify about newer releases
+	if cfg.UpdateCheck {
+		go runUpdateCheck(ctx, notifier)
+	}
+
+	// Send stats summaries on SUMMARY_SCHEDULE
+	if cfg.SummarySchedule != nil {
+		summary := notification.NewSummary()
+		bus.Subscribe(summary.HandleEvent)
+		go runSummary(ctx, cfg, dnsManager, stateManager, notifier, summary)
+	}
+
+	// Write metrics for the node_exporter textfile collector
+	if cfg.MetricsTextfilePath != "" {
+		collector := metrics.NewCollector()
+		bus.Subscribe(collector.HandleEvent)
+		go runMetricsTextfile(ctx, cfg, dnsManager, collector)
+	}
+
 	// Scan existing containers first
 	log.Println("Scanning existing containers...")
-	existingHosts, err := watcher.ScanExistingContainers(ctx)
+	existingHosts, err := watcher.ScanContainerChanges(ctx)
 	if err != nil {
 		log.Printf("Warning: Failed to scan existing containers: %v", err)
 	} else {
 		log.Printf("Found %d existing hosts with Traefik labels", len(existingHosts))
-		for _, host := range existingHosts {
-			if err := dnsManager.ProcessHostInfo(ctx, host); err != nil {
-				log.Printf("Error processing existing host %s: %v", host.Hostname, err)
-			}
+		resumePendingHosts(ctx, dnsManager, existingHosts)
+		if err := dnsManager.SyncHosts(ctx, existingHosts); err != nil {
+			log.Printf("Error during initial sync: %v", err)
+		}
+	}
+
+	// Consolidate the records of busy domains into wildcard records, or restore them
+	if stateManager != nil {
+		if err := dnsManager.ConsolidateWildcards(ctx); err != nil {
+			log.Printf("Warning: Wildcard consolidation failed: %v", err)
 		}
 	}
 
-	// Create channel for host info
-	hostChan := make(chan docker.HostInfo, 100)
+	// Prune stale state records if a max age is configured
+	if cfg.StateMaxAge > 0 && stateManager != nil {
+		log.Printf("State pruning enabled, max age: %s (delete from DNS: %v)", cfg.StateMaxAge, cfg.StatePruneDeleteDNS)
+		go runStatePruner(ctx, watcher, dnsManager)
+	}
+
+	// Re-apply the persisted records periodically, e.g. to revert changes made by hand
+	if cfg.ReconciliationInterval > 0 && cfg.ReconciliationEnabled && stateManager != nil {
+		log.Printf("Periodic reconciliation enabled, interval: %ds", cfg.ReconciliationInterval)
+		go runPeriodicReconciliation(ctx, cfg, dnsManager)
+	}
 
-	// Start goroutine to process host info
+	// Report records whose containers have not been seen for a while
+	if cfg.LivenessThreshold > 0 && stateManager != nil {
+		log.Printf("Liveness check enabled, threshold: %s", cfg.LivenessThreshold)
+		go runLivenessCheck(ctx, watcher, dnsManager)
+	}
+
+	// Queue host info between the watcher and the workers, so stalled processing never
+	// blocks the Docker event stream
+	queueDone := make(chan struct{})
+	go func() {
+		defer close(queueDone)
+		hostQueue.Run(ctx)
+	}()
+
+	// Start workers publishing host info to the event bus. Hosts are processed with their
+	// own context, so the hosts in flight at shutdown complete within the drain deadline.
+	processCtx, cancelProcessing := context.WithCancel(context.Background())
+	defer cancelProcessing()
+	processorDone := make(chan struct{})
+	var undispatched []docker.HostInfo
 	go func() {
+		defer close(processorDone)
+		undispatched = events.NewDispatcher(bus, cfg.HostWorkers).Run(ctx, processCtx, hostQueue.Out())
+	}()
+
+	notifier.SendEvent(notification.EventLifecycle, fmt.Sprintf("Companion started in %s mode, %d hosts found", cfg.Mode, len(existingHosts)))
+
+	// Watch for Docker events, reconnecting when the connection is lost
+	log.Println("Watching for Docker container events...")
+	watchDockerEvents(ctx, watcher, dnsManager, notifier, hostQueue.In())
+
+	// No new events are accepted anymore; apply what is still queued
+	time.AfterFunc(time.Duration(cfg.ShutdownTimeout)*time.Second, cancelProcessing)
+	<-processorDone
+	<-queueDone
+	drainHostQueue(processCtx, bus, dnsManager, append(undispatched, hostQueue.Drain()...))
+
+	notifier.SendEvent(notification.EventLifecycle, "Companion stopped")
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout)*time.Second)
+	dnsManager.Close(flushCtx)
+	cancelFlush()
+	log.Println("Shutdown complete")
+}
+
+// newStateManager loads the state file, restoring it from object storage when a bucket
+// is configured and the file is missing
+func newStateManager(cfg *config.Config) (*state.Manager, error) {
+	var store state.Store
+	if cfg.StateS3Bucket != "" {
+		object, err := objectstore.NewS3Object(cfg.StateS3Bucket, cfg.StateS3Key, cfg.StateS3AccessKeyID, cfg.StateS3SecretAccessKey, &objectstore.S3Options{
+			Endpoint:  cfg.StateS3Endpoint,
+			Region:    cfg.StateS3Region,
+			PathStyle: cfg.StateS3PathStyle,
+		})
+		if err != nil {
+			return nil, err
+		}
+		store = object
+		log.Printf("Keeping a copy of the state file in %s", object)
+	}
+	return state.NewManagerWithOptions(cfg.StateFilePath, &state.ManagerOptions{Store: store})
+}
+
+// stateChangePolicy maps STATE_EXTERNAL_CHANGES to the reaction of the state file watcher
+func stateChangePolicy(value string) state.ChangePolicy {
+	switch value {
+	case config.StateChangesOverwrite:
+		return state.ChangeOverwrite
+	case config.StateChangesWarn:
+		return state.ChangeWarn
+	default:
+		return state.ChangeReload
+	}
+}
+
+// flushState uploads state changes not yet in object storage before the companion exits
+func flushState(cfg *config.Config, stateManager *state.Manager) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout)*time.Second)
+	defer cancel()
+	if err := stateManager.Flush(ctx); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// adoptExistingRecords seeds an empty state with the existing records of running
+// containers. A state that already has records is left alone.
+func adoptExistingRecords(ctx context.Context, watcher *docker.Watcher, dnsManager *dns.Manager, stateManager *state.Manager, notifier *notification.Notifier) {
+	if stateManager == nil {
+		log.Println("Warning: Adopting records requires state persistence, skipping")
+		return
+	}
+	if stateManager.HasRecords() {
+		log.Println("State already has records, skipping adoption")
+		return
+	}
+
+	log.Println("Adopting existing DNS records of running containers...")
+	hosts, err := watcher.ScanExistingContainers(ctx)
+	if err != nil {
+		log.Printf("Warning: Failed to scan containers for adoption: %v", err)
+		return
+	}
+
+	adopted, err := dnsManager.Adopt(ctx, hosts)
+	if err != nil {
+		log.Printf("Warning: Adoption incomplete: %v", err)
+		notifier.SendEventError(notification.EventLifecycle, fmt.Sprintf("Adopted %d existing DNS records, some failed: %v", adopted, err))
+		return
+	}
+	log.Printf("Adopted %d existing DNS records", adopted)
+	notifier.SendEvent(notification.EventLifecycle, fmt.Sprintf("Adopted %d existing DNS records", adopted))
+}
+
+// drainHostQueue publishes the hosts the workers left behind and those left in the host
+// queue at shutdown until ctx expires and persists the rest, along with hosts queued
+// while paused, as pending for the next start. A host interrupted by the deadline is
+// kept as well; applying it again is harmless.
+func drainHostQueue(ctx context.Context, bus *events.Bus, dnsManager *dns.Manager, queued []docker.HostInfo) {
+	var unprocessed []docker.HostInfo
+	for _, info := range queued {
+		if ctx.Err() == nil {
+			bus.Publish(ctx, events.ForHost(info))
+		}
+		if ctx.Err() != nil {
+			unprocessed = append(unprocessed, info)
+		}
+	}
+
+	if len(unprocessed) > 0 {
+		log.Printf("Shutdown deadline reached with %d hosts unprocessed", len(unprocessed))
+	}
+	if err := dnsManager.SavePending(unprocessed); err != nil {
+		log.Printf("Warning: Failed to persist pending hosts: %v", err)
+	}
+}
+
+// checkNetcupLogin logs in to Netcup once to verify the credentials
+func checkNetcupLogin(ctx context.Context, api netcup.NetcupAPI) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	session, err := api.Login(ctx)
+	if err != nil {
+		return err
+	}
+	return session.Logout()
+}
+
+// resumePendingHosts applies the removals left pending at the last shutdown
+func resumePendingHosts(ctx context.Context, dnsManager *dns.Manager, running []docker.HostInfo) {
+	applied, err := dnsManager.ResumePending(ctx, running)
+	if err != nil {
+		log.Printf("Warning: Failed to apply pending hosts: %v", err)
+	}
+	if applied > 0 {
+		log.Printf("Applied %d host removals pending since the last shutdown", applied)
+	}
+}
+
+// watchDockerEvents watches Docker events until ctx is cancelled. When the event stream
+// fails it reconnects with exponential backoff and re-syncs containers started meanwhile.
+func watchDockerEvents(ctx context.Context, watcher *docker.Watcher, dnsManager *dns.Manager, notifier *notification.Notifier, hostChan chan<- docker.HostInfo) {
+	const (
+		initialBackoff = 5 * time.Second
+		maxBackoff     = time.Minute
+	)
+
+	for {
+		err := watcher.WatchEvents(ctx, hostChan)
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("Error watching Docker events: %v", err)
+		notifier.SendEventError(notification.EventDocker, fmt.Sprintf("Lost connection to Docker: %v", err))
+
+		backoff := initialBackoff
 		for {
+			log.Printf("Reconnecting to Docker in %s...", backoff)
 			select {
 			case <-ctx.Done():
 				return
-			case info := <-hostChan:
-				if err := dnsManager.ProcessHostInfo(ctx, info); err != nil {
-					log.Printf("Error processing host %s: %v", info.Hostname, err)
-				}
+			case <-time.After(backoff):
+			}
+
+			err := watcher.Ping(ctx)
+			if err == nil {
+				break
 			}
+			log.Printf("Docker still unreachable: %v", err)
+			backoff = min(backoff*2, maxBackoff)
 		}
-	}()
 
-	// Watch for Docker events
-	log.Println("Watching for Docker container start events...")
-	if err := watcher.WatchEvents(ctx, hostChan); err != nil {
-		if ctx.Err() == nil {
-			log.Fatalf("Error watching Docker events: %v", err)
+		log.Println("Reconnected to Docker")
+		notifier.SendEvent(notification.EventDocker, "Reconnected to Docker")
+
+		// Containers may have started or changed while disconnected
+		hosts, err := watcher.ScanContainerChanges(ctx)
+		if err != nil {
+			log.Printf("Warning: Failed to scan containers after reconnect: %v", err)
+		} else if err := dnsManager.SyncHosts(ctx, hosts); err != nil {
+			log.Printf("Error during sync after reconnect: %v", err)
+		}
+	}
+}
+
+// runStatePruner periodically re-confirms running hosts and prunes stale state records.
+// Pruning is skipped when the container scan fails so records are never pruned blindly.
+func runStatePruner(ctx context.Context, watcher *docker.Watcher, dnsManager *dns.Manager) {
+	ticker := time.NewTicker(dns.PruneInterval)
+	defer ticker.Stop()
+
+	for {
+		hosts, err := watcher.ScanExistingContainers(ctx)
+		if err != nil {
+			log.Printf("Warning: Skipping state pruning, failed to scan containers: %v", err)
+		} else if _, err := dnsManager.PruneState(ctx, hosts); err != nil {
+			log.Printf("Warning: State pruning failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
 	}
+}
 
-	log.Println("Shutdown complete")
+// runPeriodicReconciliation reconciles the persisted records every RECONCILIATION_INTERVAL_SEC.
+// Zones unchanged since they were last found in sync are skipped by their serial.
+func runPeriodicReconciliation(ctx context.Context, cfg *config.Config, dnsManager *dns.Manager) {
+	ticker := time.NewTicker(time.Duration(cfg.ReconciliationInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := dnsManager.ReconcileFromState(ctx); err != nil {
+			log.Printf("Warning: Periodic reconciliation failed: %v", err)
+		}
+	}
+}
+
+// runLivenessCheck periodically records the running hosts and reports records whose
+// containers were not seen within LIVENESS_THRESHOLD. Nothing is reported when the
+// container scan fails, so records are never flagged blindly.
+func runLivenessCheck(ctx context.Context, watcher *docker.Watcher, dnsManager *dns.Manager) {
+	ticker := time.NewTicker(dns.LivenessInterval)
+	defer ticker.Stop()
+
+	for {
+		hosts, err := watcher.ScanExistingContainers(ctx)
+		if err != nil {
+			log.Printf("Warning: Skipping liveness check, failed to scan containers: %v", err)
+		} else {
+			dnsManager.MarkSeen(hosts)
+			dnsManager.CheckLiveness()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runSummary sends a summary notification with the managed records, the changes and
+// errors since the previous summary, the public IP and the uptime on every firing of
+// SUMMARY_SCHEDULE
+func runSummary(ctx context.Context, cfg *config.Config, dnsManager *dns.Manager, stateManager *state.Manager, notifier *notification.Notifier, summary *notification.Summary) {
+	log.Printf("Summary notifications scheduled: %s", cfg.SummarySchedule)
+	started := time.Now()
+	periodStart := started
+
+	scheduler.Run(ctx, cfg.SummarySchedule, func(_ context.Context, now time.Time) {
+		report := notification.SummaryReport{
+			Uptime: now.Sub(started),
+			Period: now.Sub(periodStart),
+		}
+		periodStart = now
+		report.Changes, report.Errors = summary.Take()
+
+		if stateManager != nil {
+			report.Records = len(stateManager.GetAllRecords())
+		} else {
+			report.Records = len(dnsManager.Diagnostics().KnownHosts)
+		}
+		if !cfg.PublishContainerIP() {
+			if ip, err := dnsManager.HostIP(); err == nil {
+				report.PublicIP = ip
+			}
+		}
+		notifier.SendSummary(report)
+	})
+}
+
+// runMetricsTextfile writes the metrics to cfg.MetricsTextfilePath at the start and
+// every cfg.MetricsTextfileInterval seconds until ctx is done
+func runMetricsTextfile(ctx context.Context, cfg *config.Config, dnsManager *dns.Manager, collector *metrics.Collector) {
+	interval := time.Duration(cfg.MetricsTextfileInterval) * time.Second
+	log.Printf("Writing metrics to %s every %s", cfg.MetricsTextfilePath, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := collector.WriteTextfile(cfg.MetricsTextfilePath, dnsManager.Diagnostics()); err != nil {
+			log.Printf("Warning: Failed to write metrics textfile: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDiagnosticsDump writes a diagnostics bundle to cfg.DiagnosticsDir on every SIGUSR2
+func runDiagnosticsDump(ctx context.Context, cfg *config.Config, dnsManager *dns.Manager, hostQueue *events.HostQueue) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGUSR2)
+	defer signal.Stop(sigChan)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigChan:
+			path, err := diagnostics.Write(cfg.DiagnosticsDir, diagnostics.NewBundle(cfg, dnsManager, hostQueue))
+			if err != nil {
+				log.Printf("Failed to write diagnostics bundle: %v", err)
+				continue
+			}
+			log.Printf("Diagnostics bundle written to %s", path)
+		}
+	}
+}
+
+// runACMEAPI serves the ACME DNS-01 challenge API until ctx is done
+func runACMEAPI(ctx context.Context, cfg *config.Config, dnsManager *dns.Manager) {
+	handler := acme.NewHandler(dnsManager, &acme.HandlerOptions{
+		Username: cfg.ACMEAPIUsername,
+		Password: cfg.ACMEAPIPassword,
+		BadRequest: func(err error) bool {
+			return errors.Is(err, dns.ErrNotChallenge)
+		},
+	})
+	log.Printf("ACME challenge API listening on %s", cfg.ACMEAPIAddr)
+	if err := httpserver.Serve(ctx, cfg.ACMEAPIAddr, handler); err != nil {
+		log.Printf("Error serving ACME challenge API: %v", err)
+	}
+}
+
+// runForwardAPI serves the API receiving hosts forwarded by other companions until ctx
+// is done
+func runForwardAPI(ctx context.Context, cfg *config.Config, dnsManager *dns.Manager) {
+	log.Printf("Forward API listening on %s", cfg.ForwardAPIAddr)
+	if err := httpserver.Serve(ctx, cfg.ForwardAPIAddr, forward.NewHandler(dnsManager, cfg.ForwardToken)); err != nil {
+		log.Printf("Error serving forward API: %v", err)
+	}
+}
+
+// runCircuitBreakerReset closes the Netcup circuit breaker on every SIGHUP, so requests
+// resume without waiting for the breaker timeout once the API is known to be back
+func runCircuitBreakerReset(ctx context.Context, dnsManager *dns.Manager) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+	defer signal.Stop(sigChan)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigChan:
+			if dnsManager.ResetCircuitBreaker() {
+				log.Println("Netcup API circuit breaker reset manually via SIGHUP")
+			} else {
+				log.Println("Received SIGHUP, Netcup API circuit breaker is already closed")
+			}
+		}
+	}
+}
+
+// pauseFilePollInterval is how often the pause file is checked
+const pauseFilePollInterval = 5 * time.Second
+
+// runPauseControl pauses and resumes DNS writes. SIGUSR1 toggles the pause; if pauseFile
+// is set, writes are paused when the file appears and resumed when it is removed.
+func runPauseControl(ctx context.Context, pauseFile string, dnsManager *dns.Manager, notifier *notification.Notifier) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGUSR1)
+	defer signal.Stop(sigChan)
+
+	var poll <-chan time.Time
+	if pauseFile != "" {
+		log.Printf("Pause file enabled: %s", pauseFile)
+		ticker := time.NewTicker(pauseFilePollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	filePresent := pauseFileExists(pauseFile)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigChan:
+			if dnsManager.Paused() {
+				resumeWrites(ctx, dnsManager, notifier, "SIGUSR1")
+			} else {
+				pauseWrites(dnsManager, notifier, "SIGUSR1")
+			}
+		case <-poll:
+			present := pauseFileExists(pauseFile)
+			if present == filePresent {
+				continue
+			}
+			filePresent = present
+			if present {
+				pauseWrites(dnsManager, notifier, "pause file created")
+			} else {
+				resumeWrites(ctx, dnsManager, notifier, "pause file removed")
+			}
+		}
+	}
+}
+
+// pauseFileExists reports whether the pause file is configured and exists
+func pauseFileExists(pauseFile string) bool {
+	if pauseFile == "" {
+		return false
+	}
+	_, err := os.Stat(pauseFile)
+	return err == nil
+}
+
+func pauseWrites(dnsManager *dns.Manager, notifier *notification.Notifier, reason string) {
+	if dnsManager.Pause() {
+		notifier.SendEvent(notification.EventLifecycle, fmt.Sprintf("DNS writes paused (%s)", reason))
+	}
+}
+
+func resumeWrites(ctx context.Context, dnsManager *dns.Manager, notifier *notification.Notifier, reason string) {
+	if !dnsManager.Paused() {
+		return
+	}
+	applied, err := dnsManager.Resume(ctx)
+	if err != nil {
+		log.Printf("Error applying queued changes: %v", err)
+		notifier.SendEventError(notification.EventLifecycle, fmt.Sprintf("DNS writes resumed (%s), failed to apply some of %d queued changes: %v", reason, applied, err))
+		return
+	}
+	notifier.SendEvent(notification.EventLifecycle, fmt.Sprintf("DNS writes resumed (%s), applied %d queued changes", reason, applied))
+}
+
+// runStandby watches the heartbeat of the primary companion, resuming DNS writes, and
+// with them pointing the records at this host, once it is stale and pausing them again
+// once the primary is back
+func runStandby(ctx context.Context, cfg *config.Config, dnsManager *dns.Manager, notifier *notification.Notifier) {
+	monitor := standby.NewMonitor(
+		func(ctx context.Context) (time.Time, error) {
+			return dnsManager.ReadHeartbeat(ctx, cfg.StandbyHeartbeatDomain)
+		},
+		time.Duration(cfg.StandbyCheckInterval)*time.Second,
+		time.Duration(cfg.StandbyTakeoverAfter)*time.Minute,
+	)
+	monitor.OnTakeover(func(lastSeen time.Time) {
+		notifier.SendError(fmt.Sprintf("Primary companion heartbeat in %s stale since %s, taking over DNS management",
+			cfg.StandbyHeartbeatDomain, lastSeen.UTC().Format(time.RFC3339)))
+		// The primary may have pointed back records of an earlier takeover, so reconcile
+		// them on resume; while paused the reconciliation is only deferred
+		if err := dnsManager.ReconcileFromState(ctx); err != nil {
+			log.Printf("Warning: Failed to request reconciliation for the takeover: %v", err)
+		}
+		resumeWrites(ctx, dnsManager, notifier, "standby takeover")
+	})
+	monitor.OnStepDown(func() {
+		notifier.SendInfo(fmt.Sprintf("Primary companion heartbeat in %s is back, standing by again", cfg.StandbyHeartbeatDomain))
+		pauseWrites(dnsManager, notifier, "primary companion is back")
+	})
+	monitor.Run(ctx)
+}
+
+// dockerWatcherOptions maps the settings selecting and publishing containers from the
+// config, shared by the companion and preflight so both see the same hosts
+func dockerWatcherOptions(cfg *config.Config) *docker.WatcherOptions {
+	return &docker.WatcherOptions{
+		HealthCheckGating:    cfg.HealthCheckGatingEnabled,
+		UnhealthyGracePeriod: time.Duration(cfg.HealthCheckGracePeriod) * time.Second,
+		Hosts:                cfg.DockerHosts,
+		Connection:           dockerConnectionOptions(cfg),
+		PublishContainerIP:   cfg.PublishContainerIP(),
+		ContainerNetwork:     cfg.ContainerNetwork,
+		PublicEntrypoints:    cfg.PublicEntrypoints,
+		CertResolvers:        cfg.CertResolverFilter,
+		Projects:             cfg.DockerFilterProjects,
+		Networks:             cfg.DockerFilterNetworks,
+		HostEnvVars:          cfg.HostEnvVars,
+		HostnameTemplate:     cfg.AutoHostnameTemplate,
+		Probe: docker.ProbeOptions{
+			Mode:     cfg.ProbeMode,
+			Timeout:  time.Duration(cfg.ProbeTimeout) * time.Second,
+			HTTPPath: cfg.ProbeHTTPPath,
+		},
+		CrashLoop: docker.CrashLoopOptions{
+			Restarts: cfg.CrashLoopRestarts,
+			Window:   time.Duration(cfg.CrashLoopWindow) * time.Second,
+			Cooldown: time.Duration(cfg.CrashLoopCooldown) * time.Second,
+		},
+	}
+}
+
+// dockerConnectionOptions maps the Docker connection settings from the config
+func dockerConnectionOptions(cfg *config.Config) docker.ConnectionOptions {
+	return docker.ConnectionOptions{
+		TLSCACert:                cfg.DockerTLSCACert,
+		TLSCert:                  cfg.DockerTLSCert,
+		TLSKey:                   cfg.DockerTLSKey,
+		TLSSkipVerify:            cfg.DockerTLSSkipVerify,
+		SSHIdentityFile:          cfg.DockerSSHIdentityFile,
+		SSHInsecureIgnoreHostKey: cfg.DockerSSHInsecureIgnoreHostKey,
+	}
+}
+
+// logEnvFileConfig reports which variables came from ENV_FILE and the resulting
+// configuration, with credentials and notification URLs redacted
+func logEnvFileConfig(cfg *config.Config) {
+	log.Printf("Loaded %d variable(s) from %s: %s", len(cfg.EnvFileVars), cfg.EnvFile, strings.Join(cfg.EnvFileVars, ", "))
+	redacted, err := json.Marshal(cfg.Redacted())
+	if err != nil {
+		log.Printf("Warning: Failed to encode configuration: %v", err)
+		return
+	}
+	log.Printf("Effective configuration: %s", redacted)
 }
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/dns"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/migration"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/notification"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+// migrationCheckInterval is how often a running companion checks a prepared migration
+const migrationCheckInterval = time.Minute
+
+// runPrepareMigration lowers the zone TTLs of all managed domains ahead of a planned IP
+// change. The running companion restores them once the new IP has been active for the
+// restore period; -restore restores them immediately.
+// Usage: companion prepare-migration [-ttl seconds] [-restore-after duration] [-restore]
+func runPrepareMigration(args []string) int {
+	fs := flag.NewFlagSet("prepare-migration", flag.ContinueOnError)
+	ttl := fs.Int("ttl", 60, "zone TTL in seconds while the migration is prepared")
+	restoreAfter := fs.Duration("restore-after", 24*time.Hour, "how long the new IP must be active before the original TTLs are restored")
+	restore := fs.Bool("restore", false, "restore the original TTLs now and cancel the migration")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *ttl <= 0 || *restoreAfter <= 0 {
+		fmt.Fprintln(os.Stderr, "-ttl and -restore-after must be positive")
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	var stateManager *state.Manager
+	if cfg.StatePersistenceEnabled {
+		if stateManager, err = state.NewManager(cfg.StateFilePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load state: %v\n", err)
+			return 1
+		}
+	}
+
+	ctx := context.Background()
+	dnsManager := dns.NewManager(cfg, dns.NewNetcupClient(cfg, nil), stateManager)
+	defer dnsManager.Close(ctx)
+	path := migration.Path(cfg.StateFilePath)
+
+	existing, err := migration.Load(path)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Failed to read migration: %v\n", err)
+		return 1
+	}
+
+	if *restore {
+		if existing == nil {
+			fmt.Fprintln(os.Stderr, "No migration prepared")
+			return 1
+		}
+		if err := dnsManager.RestoreTTLs(ctx, existing.OriginalTTLs); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to restore TTLs: %v\n", err)
+			return 1
+		}
+		if !cfg.DryRun {
+			if err := migration.Remove(path); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to remove migration file: %v\n", err)
+				return 1
+			}
+		}
+		fmt.Printf("Restored original TTLs for %d domains\n", len(existing.OriginalTTLs))
+		return 0
+	}
+
+	if existing != nil {
+		fmt.Fprintf(os.Stderr, "A migration was already prepared at %s, run with -restore to cancel it\n", existing.PreparedAt.Format(time.RFC3339))
+		return 1
+	}
+
+	domains := dnsManager.ManagedDomains()
+	if len(domains) == 0 {
+		fmt.Fprintln(os.Stderr, "No managed domains found in state or ZONE_SETTINGS")
+		return 1
+	}
+
+	oldIPs, err := dnsManager.DomainIPs(domains)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to resolve current IPs: %v\n", err)
+		return 1
+	}
+
+	original, lowerErr := dnsManager.LowerTTLs(ctx, domains, fmt.Sprint(*ttl))
+	if len(original) > 0 && !cfg.DryRun {
+		// Persist even after a partial failure so lowered zones are restored
+		m := &migration.Migration{
+			PreparedAt:      time.Now(),
+			OldIPs:          oldIPs,
+			TTL:             fmt.Sprint(*ttl),
+			OriginalTTLs:    original,
+			RestoreAfterSec: int(restoreAfter.Seconds()),
+		}
+		if err := m.Save(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save migration: %v\n", err)
+			return 1
+		}
+	}
+	if lowerErr != nil {
+		fmt.Fprintf(os.Stderr, "Failed to lower TTLs: %v\n", lowerErr)
+		return 1
+	}
+
+	lowered := make([]string, 0, len(original))
+	for domain, previous := range original {
+		lowered = append(lowered, fmt.Sprintf("  %s: %s -> %d", domain, previous, *ttl))
+	}
+	sort.Strings(lowered)
+	fmt.Printf("Lowered zone TTLs, original TTLs are restored once a new IP (currently %s) has been active for %s:\n", formatIPs(oldIPs), *restoreAfter)
+	for _, line := range lowered {
+		fmt.Println(line)
+	}
+	return 0
+}
+
+// runMigrationMonitor keeps the TTLs of a prepared migration and restores the original
+// TTLs once the published IP has differed from the old IP for the restore period
+func runMigrationMonitor(ctx context.Context, path string, dnsManager *dns.Manager, notifier *notification.Notifier) {
+	ticker := time.NewTicker(migrationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		checkMigration(ctx, path, dnsManager, notifier)
+	}
+}
+
+func checkMigration(ctx context.Context, path string, dnsManager *dns.Manager, notifier *notification.Notifier) {
+	m, err := migration.Load(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to read migration: %v", err)
+		}
+		dnsManager.SetTTLOverrides(nil)
+		return
+	}
+
+	overrides := make(map[string]string, len(m.OriginalTTLs))
+	for domain := range m.OriginalTTLs {
+		overrides[domain] = m.TTL
+	}
+	dnsManager.SetTTLOverrides(overrides)
+
+	domains := make([]string, 0, len(m.OriginalTTLs))
+	for domain := range m.OriginalTTLs {
+		domains = append(domains, domain)
+	}
+	ips, err := dnsManager.DomainIPs(domains)
+	if err != nil {
+		log.Printf("Warning: Failed to resolve IPs for migration check: %v", err)
+		return
+	}
+	ip := formatIPs(ips)
+
+	changed, due := m.ObserveIPs(ips, time.Now())
+	if !due {
+		if changed {
+			if m.NewIPSince != nil {
+				log.Printf("Migration: new IP %s active, restoring original TTLs after %s", ip, m.RestoreAfter())
+			}
+			if err := m.Save(path); err != nil {
+				log.Printf("Warning: Failed to save migration: %v", err)
+			}
+		}
+		return
+	}
+
+	err = dnsManager.RestoreTTLs(ctx, m.OriginalTTLs)
+	if errors.Is(err, dns.ErrPaused) {
+		// The restore is due, so the next check after resume applies it
+		log.Println("Migration: DNS writes paused, deferring the TTL restore until resume")
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to restore TTLs after migration: %v", err)
+		notifier.SendEventError(notification.EventLifecycle, fmt.Sprintf("Failed to restore zone TTLs after migration to %s: %v", ip, err))
+		return
+	}
+	if err := migration.Remove(path); err != nil {
+		log.Printf("Warning: Failed to remove migration file: %v", err)
+	}
+	dnsManager.SetTTLOverrides(nil)
+
+	log.Printf("Migration to %s complete, restored original TTLs for %d domains", ip, len(m.OriginalTTLs))
+	notifier.SendEvent(notification.EventLifecycle, fmt.Sprintf("Migration to %s complete, restored original zone TTLs for %d domains", ip, len(m.OriginalTTLs)))
+}
+
+// formatIPs lists the distinct IPs of a domain -> IP map, e.g. "203.0.113.1, 198.51.100.1"
+func formatIPs(ips map[string]string) string {
+	var distinct []string
+	for _, ip := range ips {
+		if !slices.Contains(distinct, ip) {
+			distinct = append(distinct, ip)
+		}
+	}
+	sort.Strings(distinct)
+	return strings.Join(distinct, ", ")
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/dns"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/events"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/metrics"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/migration"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/notification"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+// runOnce scans the running containers, syncs their records and reconciles the state
+// in a single pass without watching Docker events, for runs from cron. It returns a
+// non-zero exit code if a step or any record failed.
+func runOnce(ctx context.Context, cfg *config.Config, watcher *docker.Watcher, dnsManager *dns.Manager, stateManager *state.Manager, notifier *notification.Notifier, bus *events.Bus, adopt bool) int {
+	failures := 0
+	bus.Subscribe(func(_ context.Context, event events.Event) {
+		if e, ok := event.(events.ReconcileCompleted); ok {
+			failures += e.Errored
+		}
+	})
+	var collector *metrics.Collector
+	if cfg.MetricsTextfilePath != "" {
+		collector = metrics.NewCollector()
+		bus.Subscribe(collector.HandleEvent)
+	}
+
+	if pauseFileExists(cfg.PauseFile) {
+		pauseWrites(dnsManager, notifier, "pause file present")
+	}
+	if !cfg.ObserveMode() {
+		checkMigration(ctx, migration.Path(cfg.StateFilePath), dnsManager, notifier)
+	}
+	if adopt && !cfg.ObserveMode() {
+		adoptExistingRecords(ctx, watcher, dnsManager, stateManager, notifier)
+	}
+	if cfg.ApprovalRequired && !cfg.ObserveMode() && !applyApproved(ctx, dnsManager) {
+		failures++
+	}
+
+	if cfg.ReconciliationEnabled && stateManager != nil && stateManager.HasRecords() {
+		log.Println("Performing reconciliation...")
+		if err := dnsManager.ReconcileFromState(ctx); err != nil {
+			log.Printf("Warning: Reconciliation failed: %v", err)
+			failures++
+		}
+	}
+
+	log.Println("Scanning containers...")
+	hosts, err := watcher.ScanContainerChanges(ctx)
+	if err != nil {
+		log.Printf("Failed to scan containers: %v", err)
+		failures++
+	} else {
+		log.Printf("Found %d hosts with Traefik labels", len(hosts))
+		resumePendingHosts(ctx, dnsManager, hosts)
+		if err := dnsManager.SyncHosts(ctx, hosts); err != nil {
+			log.Printf("Error during sync: %v", err)
+			failures++
+		}
+
+		// Without events, records of removed containers are only withdrawn by pruning
+		running := slices.DeleteFunc(slices.Clone(hosts), func(info docker.HostInfo) bool { return info.Remove })
+		if _, err := dnsManager.PruneState(ctx, running); err != nil {
+			log.Printf("Warning: State pruning failed: %v", err)
+			failures++
+		}
+	}
+
+	// Hosts held back while paused are applied by the next run
+	if err := dnsManager.SavePending(nil); err != nil {
+		log.Printf("Warning: Failed to persist pending hosts: %v", err)
+	}
+
+	if collector != nil {
+		if err := collector.WriteTextfile(cfg.MetricsTextfilePath, dnsManager.Diagnostics()); err != nil {
+			log.Printf("Warning: Failed to write metrics textfile: %v", err)
+		}
+	}
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout)*time.Second)
+	dnsManager.Close(flushCtx)
+	cancelFlush()
+
+	if failures > 0 {
+		log.Printf("Single pass finished with %d failures", failures)
+		return exitRuntimeError
+	}
+	log.Println("Single pass finished")
+	return 0
+}
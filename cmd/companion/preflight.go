@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/dns"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+// preflightCheck is a single line of the preflight report
+type preflightCheck struct {
+	name   string
+	err    error
+	detail string
+}
+
+// runPreflight validates credentials, zones, Docker access and the public IP,
+// prints a report and returns a non-zero exit code if any check failed
+func runPreflight(ctx context.Context, cfg *config.Config) int {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	var checks []preflightCheck
+	dnsManager := dns.NewManager(cfg, dns.NewNetcupClient(cfg, nil), nil)
+	defer dnsManager.Close(ctx)
+
+	// Public IP, unless container addresses are published
+	if !cfg.PublishContainerIP() {
+		ip, err := dnsManager.HostIP()
+		ipCheck := preflightCheck{name: "Resolve host IP", err: err, detail: ip}
+		if err == nil {
+			if parsed := net.ParseIP(ip); parsed == nil {
+				ipCheck.err = fmt.Errorf("%q is not a valid IP address", ip)
+			} else if parsed.IsPrivate() || parsed.IsLoopback() {
+				ipCheck.detail = ip + " (private, set HOST_IP to your public IP)"
+			}
+		}
+		checks = append(checks, ipCheck)
+	}
+
+	// Domains to verify: configured zone settings plus domains of running containers
+	domainSet := make(map[string]bool)
+	for domain := range cfg.ZoneSettings {
+		domainSet[domain] = true
+	}
+
+	// Docker socket access
+	watcher, err := docker.NewWatcherWithOptions(cfg.DockerFilterLabel, dockerWatcherOptions(cfg))
+	if err == nil {
+		defer watcher.Close()
+		err = watcher.Ping(ctx)
+	}
+	checks = append(checks, preflightCheck{name: "Docker daemon access", err: err})
+
+	if err == nil {
+		hosts, err := watcher.ScanExistingContainers(ctx)
+		checks = append(checks, preflightCheck{
+			name:   "Scan running containers",
+			err:    err,
+			detail: fmt.Sprintf("%d hosts with Traefik labels", len(hosts)),
+		})
+		for _, host := range hosts {
+			domainSet[host.Domain] = true
+		}
+	}
+
+	domains := make([]string, 0, len(domainSet))
+	for domain := range domainSet {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	// Netcup credentials and zones
+	zoneErrs, err := dnsManager.VerifyZones(ctx, domains)
+	checks = append(checks, preflightCheck{name: "Netcup API login", err: err})
+	if err == nil {
+		for _, domain := range domains {
+			checks = append(checks, preflightCheck{name: "DNS zone " + domain, err: zoneErrs[domain]})
+		}
+	}
+
+	failed := 0
+	fmt.Println("Preflight report:")
+	for _, check := range checks {
+		status := "OK  "
+		if check.err != nil {
+			status = "FAIL"
+			failed++
+		}
+		line := fmt.Sprintf("  [%s] %s", status, check.name)
+		if check.detail != "" {
+			line += ": " + check.detail
+		}
+		if check.err != nil {
+			line += ": " + check.err.Error()
+		}
+		fmt.Println(line)
+	}
+
+	if failed > 0 {
+		fmt.Printf("Preflight failed: %d of %d checks failed\n", failed, len(checks))
+		return 1
+	}
+
+	fmt.Printf("Preflight passed: %d checks\n", len(checks))
+	return 0
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/dns"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+// runList prints the managed records of the persisted state.
+// Usage: companion list [-state path] [-tag tag] [-project name]
+func runList(args []string) int {
+	defaultStatePath := os.Getenv("STATE_FILE_PATH")
+	if defaultStatePath == "" {
+		defaultStatePath = "/data/state.json"
+	}
+
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	statePath := fs.String("state", defaultStatePath, "path to the state file")
+	tag := fs.String("tag", "", "only list records carrying this tag")
+	project := fs.String("project", "", "only list records owned by this Compose project")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if _, err := os.Stat(*statePath); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read state file: %v\n", err)
+		return 1
+	}
+	stateManager, err := state.NewManager(*statePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load state: %v\n", err)
+		return 1
+	}
+
+	records := filterRecords(stateManager.GetRecordsForReconciliation(), *tag, *project)
+	sort.Slice(records, func(i, j int) bool { return records[i].Hostname < records[j].Hostname })
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HOSTNAME\tIP\tOWNER\tTAGS")
+	for _, record := range records {
+		owner := record.Origin().Owner()
+		if owner == "" {
+			owner = "-"
+		}
+		tags := strings.Join(record.Tags, ",")
+		if tags == "" {
+			tags = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", record.Hostname, record.IP, owner, tags)
+	}
+	w.Flush()
+	return 0
+}
+
+// filterRecords keeps the records carrying tag and owned by project; empty filters
+// keep all records
+func filterRecords(records []state.DNSRecord, tag, project string) []state.DNSRecord {
+	var filtered []state.DNSRecord
+	for _, record := range records {
+		if tag != "" && !record.HasTag(tag) {
+			continue
+		}
+		if project != "" && record.ComposeProject != project {
+			continue
+		}
+		filtered = append(filtered, record)
+	}
+	return filtered
+}
+
+// runReconcileTag re-applies the persisted records carrying a tag.
+// Usage: companion reconcile -tag tag
+func runReconcileTag(args []string) int {
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	tag := fs.String("tag", "", "reconcile the records carrying this tag (required)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *tag == "" {
+		fmt.Fprintln(os.Stderr, "Usage: companion reconcile -tag tag")
+		return 2
+	}
+
+	dnsManager, code := newTagManager()
+	if dnsManager == nil {
+		return code
+	}
+	defer dnsManager.Close(context.Background())
+
+	if err := dnsManager.ReconcileTag(context.Background(), *tag); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to reconcile records tagged %s: %v\n", *tag, err)
+		return 1
+	}
+	return 0
+}
+
+// runDeleteTag deletes the records carrying a tag from DNS and the state.
+// Usage: companion delete -tag tag
+func runDeleteTag(args []string) int {
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	tag := fs.String("tag", "", "delete the records carrying this tag (required)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *tag == "" {
+		fmt.Fprintln(os.Stderr, "Usage: companion delete -tag tag")
+		return 2
+	}
+
+	dnsManager, code := newTagManager()
+	if dnsManager == nil {
+		return code
+	}
+	defer dnsManager.Close(context.Background())
+
+	removed, err := dnsManager.RemoveTag(context.Background(), *tag)
+	if err != nil {
+		if errors.Is(err, dns.ErrPaused) {
+			fmt.Fprintln(os.Stderr, "DNS writes are paused, resume them before deleting")
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Deleted %d records tagged %s, some failed: %v\n", removed, *tag, err)
+		return 1
+	}
+	fmt.Printf("Deleted %d records tagged %s\n", removed, *tag)
+	return 0
+}
+
+// newTagManager creates a DNS manager on the persisted state for the tag commands. On
+// failure it returns nil and the exit code.
+func newTagManager() (*dns.Manager, int) {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return nil, 1
+	}
+	if !cfg.StatePersistenceEnabled {
+		fmt.Fprintln(os.Stderr, "Tags are kept in the state file, enable STATE_PERSISTENCE_ENABLED")
+		return nil, 1
+	}
+	if _, err := os.Stat(cfg.StateFilePath); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read state file: %v\n", err)
+		return nil, 1
+	}
+	stateManager, err := state.NewManager(cfg.StateFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load state: %v\n", err)
+		return nil, 1
+	}
+	return dns.NewManager(cfg, dns.NewNetcupClient(cfg, nil), stateManager), 0
+}
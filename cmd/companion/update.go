@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/notification"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/update"
+)
+
+// runUpdateCheck checks for a newer release on startup and every update.CheckInterval,
+// and notifies about each newer version once
+func runUpdateCheck(ctx context.Context, notifier *notification.Notifier) {
+	if !update.IsRelease(version) {
+		log.Printf("Skipping update checks, version %q is not a release", version)
+		return
+	}
+
+	checker := &update.Checker{Client: &http.Client{Timeout: 30 * time.Second}}
+	ticker := time.NewTicker(update.CheckInterval)
+	defer ticker.Stop()
+
+	notified := ""
+	for {
+		release, err := checker.Latest(ctx)
+		switch {
+		case err != nil:
+			log.Printf("Warning: Update check failed: %v", err)
+		case release.Version != notified && update.Newer(release.Version, version):
+			log.Printf("Companion %s is available, running %s: %s", release.Version, version, release.URL)
+			notifier.SendEvent(notification.EventUpdate, updateMessage(release))
+			notified = release.Version
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// updateMessage announces release along with its changelog highlights
+func updateMessage(release update.Release) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Companion %s is available, running %s: %s", release.Version, version, release.URL)
+	for _, highlight := range release.Highlights() {
+		fmt.Fprintf(&b, "\n- %s", highlight)
+	}
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/dns"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/zonefile"
+)
+
+// runExportZone writes all records of a Netcup zone as an RFC 1035 zone file.
+// Usage: companion export-zone [-output path] <domain>
+func runExportZone(args []string) int {
+	fs := flag.NewFlagSet("export-zone", flag.ContinueOnError)
+	output := fs.String("output", "", "output file; defaults to stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: companion export-zone [-output path] <domain>")
+		return 2
+	}
+	domain := fs.Arg(0)
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	dnsManager := dns.NewManager(cfg, dns.NewNetcupClient(cfg, nil), nil)
+	defer dnsManager.Close(context.Background())
+	zoneData, records, err := dnsManager.ExportZone(context.Background(), domain)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export zone: %v\n", err)
+		return 1
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create output file: %v\n", err)
+			return 1
+		}
+		defer out.Close()
+	}
+
+	zone := &zonefile.Zone{Domain: domain, TTL: zoneData.Ttl, Records: records}
+	if err := zonefile.Write(out, zone, zoneData); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write zone file: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runImportZone creates the records of an RFC 1035 zone file in a Netcup zone. SOA and
+// apex NS records are skipped, since Netcup manages them.
+// Usage: companion import-zone [-replace] <domain> <file>
+func runImportZone(args []string) int {
+	fs := flag.NewFlagSet("import-zone", flag.ContinueOnError)
+	replace := fs.Bool("replace", false, "delete existing records missing from the zone file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: companion import-zone [-replace] <domain> <file>")
+		return 2
+	}
+	domain, path := fs.Arg(0), fs.Arg(1)
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read zone file: %v\n", err)
+		return 1
+	}
+	defer file.Close()
+
+	zone, err := zonefile.Parse(file, domain)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse zone file: %v\n", err)
+		return 1
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	dnsManager := dns.NewManager(cfg, dns.NewNetcupClient(cfg, nil), nil)
+	defer dnsManager.Close(context.Background())
+	result, err := dnsManager.ImportZone(context.Background(), zone.Domain, zone.Records, *replace)
+	if err != nil {
+		if errors.Is(err, dns.ErrPaused) {
+			fmt.Fprintln(os.Stderr, "DNS writes are paused, resume them before importing")
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Failed to import zone: %v\n", err)
+		return 1
+	}
+
+	prefix := ""
+	if cfg.DryRun {
+		prefix = "[DRY RUN] "
+	}
+	fmt.Printf("%sImported %s: %d records created, %d deleted, %d unchanged\n", prefix, zone.Domain, result.Created, result.Deleted, result.Unchanged)
+	if zone.TTL != "" {
+		fmt.Printf("Note: the zone file TTL %s was not applied, set it with ZONE_SETTINGS if needed\n", zone.TTL)
+	}
+	return 0
+}
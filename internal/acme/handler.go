@@ -0,0 +1,116 @@
+// Package acme serves an HTTP API for ACME DNS-01 challenges compatible with lego's
+// httpreq provider, so ACME clients such as Traefik can solve challenges through the
+// companion's Netcup client instead of their own credentials.
+package acme
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// maxRequestSize limits the body of challenge requests
+const maxRequestSize = 4 << 10
+
+// Challenges creates and deletes challenge TXT records
+type Challenges interface {
+	PresentChallenge(ctx context.Context, fqdn, value string) error
+	CleanupChallenge(ctx context.Context, fqdn, value string) error
+}
+
+// HandlerOptions holds optional settings for the challenge handler
+type HandlerOptions struct {
+	Username string // Basic auth user name; requests are not authenticated without a password
+	Password string // Basic auth password
+
+	// BadRequest reports whether an error of Challenges is caused by the request, which
+	// is answered with 400 instead of 500
+	BadRequest func(err error) bool
+}
+
+// challengeRequest is the body of httpreq requests, in the default mode with fqdn and
+// value or in RAW mode with domain, token and keyAuth
+type challengeRequest struct {
+	FQDN    string `json:"fqdn"`
+	Value   string `json:"value"`
+	Domain  string `json:"domain"`
+	Token   string `json:"token"`
+	KeyAuth string `json:"keyAuth"`
+}
+
+// record returns the FQDN and value of the challenge record
+func (r challengeRequest) record() (fqdn, value string) {
+	if r.FQDN != "" {
+		return r.FQDN, r.Value
+	}
+	if r.Domain == "" || r.KeyAuth == "" {
+		return "", ""
+	}
+	// The record value of a DNS-01 challenge is the digest of the key authorization
+	digest := sha256.Sum256([]byte(r.KeyAuth))
+	return "_acme-challenge." + strings.TrimPrefix(r.Domain, "*.") + ".", base64.RawURLEncoding.EncodeToString(digest[:])
+}
+
+type handler struct {
+	opts HandlerOptions
+}
+
+// NewHandler returns a handler serving POST /present and POST /cleanup
+func NewHandler(challenges Challenges, opts *HandlerOptions) http.Handler {
+	h := &handler{opts: *opts}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /present", h.serve(challenges.PresentChallenge))
+	mux.HandleFunc("POST /cleanup", h.serve(challenges.CleanupChallenge))
+	return mux
+}
+
+func (h *handler) serve(apply func(ctx context.Context, fqdn, value string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="acme"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req challengeRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		fqdn, value := req.record()
+		if fqdn == "" || value == "" {
+			http.Error(w, "fqdn and value, or domain and keyAuth, are required", http.StatusBadRequest)
+			return
+		}
+
+		if err := apply(r.Context(), fqdn, value); err != nil {
+			log.Printf("ACME challenge request %s for %s failed: %v", r.URL.Path, fqdn, err)
+			status := http.StatusInternalServerError
+			if h.opts.BadRequest != nil && h.opts.BadRequest(err) {
+				status = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// authorized checks the basic auth credentials of r, if a password is configured
+func (h *handler) authorized(r *http.Request) bool {
+	if h.opts.Password == "" {
+		return true
+	}
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(username), []byte(h.opts.Username)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(h.opts.Password)) == 1
+	return userOK && passwordOK
+}
@@ -0,0 +1,154 @@
+package acme
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var errNotChallenge = errors.New("not a challenge")
+
+type fakeChallenges struct {
+	records map[string]string
+	err     error
+}
+
+func (f *fakeChallenges) PresentChallenge(ctx context.Context, fqdn, value string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.records[fqdn] = value
+	return nil
+}
+
+func (f *fakeChallenges) CleanupChallenge(ctx context.Context, fqdn, value string) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.records[fqdn] == value {
+		delete(f.records, fqdn)
+	}
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+	digest := sha256.Sum256([]byte("token.thumbprint"))
+	rawValue := base64.RawURLEncoding.EncodeToString(digest[:])
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		body        string
+		noAuth      bool
+		err         error
+		wantStatus  int
+		wantRecords map[string]string
+	}{
+		{
+			name:        "present",
+			path:        "/present",
+			body:        `{"fqdn":"_acme-challenge.app.example.com.","value":"abc"}`,
+			wantStatus:  http.StatusOK,
+			wantRecords: map[string]string{"_acme-challenge.old.example.com.": "old", "_acme-challenge.app.example.com.": "abc"},
+		},
+		{
+			name:        "present raw mode",
+			path:        "/present",
+			body:        `{"domain":"*.example.com","token":"token","keyAuth":"token.thumbprint"}`,
+			wantStatus:  http.StatusOK,
+			wantRecords: map[string]string{"_acme-challenge.old.example.com.": "old", "_acme-challenge.example.com.": rawValue},
+		},
+		{
+			name:        "cleanup",
+			path:        "/cleanup",
+			body:        `{"fqdn":"_acme-challenge.old.example.com.","value":"old"}`,
+			wantStatus:  http.StatusOK,
+			wantRecords: map[string]string{},
+		},
+		{
+			name:        "unauthorized",
+			path:        "/present",
+			body:        `{"fqdn":"_acme-challenge.app.example.com.","value":"abc"}`,
+			noAuth:      true,
+			wantStatus:  http.StatusUnauthorized,
+			wantRecords: map[string]string{"_acme-challenge.old.example.com.": "old"},
+		},
+		{
+			name:        "wrong method",
+			method:      http.MethodGet,
+			path:        "/present",
+			wantStatus:  http.StatusMethodNotAllowed,
+			wantRecords: map[string]string{"_acme-challenge.old.example.com.": "old"},
+		},
+		{
+			name:        "missing value",
+			path:        "/present",
+			body:        `{"fqdn":"_acme-challenge.app.example.com."}`,
+			wantStatus:  http.StatusBadRequest,
+			wantRecords: map[string]string{"_acme-challenge.old.example.com.": "old"},
+		},
+		{
+			name:        "invalid body",
+			path:        "/present",
+			body:        `fqdn=app`,
+			wantStatus:  http.StatusBadRequest,
+			wantRecords: map[string]string{"_acme-challenge.old.example.com.": "old"},
+		},
+		{
+			name:        "rejected record",
+			path:        "/present",
+			body:        `{"fqdn":"app.example.com.","value":"abc"}`,
+			err:         errNotChallenge,
+			wantStatus:  http.StatusBadRequest,
+			wantRecords: map[string]string{"_acme-challenge.old.example.com.": "old"},
+		},
+		{
+			name:        "netcup failure",
+			path:        "/present",
+			body:        `{"fqdn":"_acme-challenge.app.example.com.","value":"abc"}`,
+			err:         errors.New("netcup unavailable"),
+			wantStatus:  http.StatusInternalServerError,
+			wantRecords: map[string]string{"_acme-challenge.old.example.com.": "old"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			challenges := &fakeChallenges{records: map[string]string{"_acme-challenge.old.example.com.": "old"}, err: tt.err}
+			handler := NewHandler(challenges, &HandlerOptions{
+				Username:   "traefik",
+				Password:   "secret",
+				BadRequest: func(err error) bool { return errors.Is(err, errNotChallenge) },
+			})
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, tt.path, strings.NewReader(tt.body))
+			if !tt.noAuth {
+				req.SetBasicAuth("traefik", "secret")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if len(challenges.records) != len(tt.wantRecords) {
+				t.Fatalf("records = %v, want %v", challenges.records, tt.wantRecords)
+			}
+			for fqdn, value := range tt.wantRecords {
+				if challenges.records[fqdn] != value {
+					t.Errorf("records[%s] = %q, want %q", fqdn, challenges.records[fqdn], value)
+				}
+			}
+		})
+	}
+}
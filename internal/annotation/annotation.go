@@ -0,0 +1,176 @@
+// Package annotation maintains a file mapping each container to the hostnames whose
+// records it owns, so other tooling can discover the DNS names of a container
+package annotation
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Annotation lists the hostnames a container owns records for
+type Annotation struct {
+	ContainerName string    `json:"container_name,omitempty"`
+	DockerHost    string    `json:"docker_host,omitempty"`
+	Hostnames     []string  `json:"hostnames"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// file is the JSON document written to the annotations file
+type file struct {
+	Containers map[string]Annotation `json:"containers"` // Keyed by container ID
+}
+
+// Store keeps the annotations of all containers and rewrites the file on every change
+type Store struct {
+	mu         sync.Mutex
+	filePath   string
+	enabled    bool
+	containers map[string]Annotation
+}
+
+// NewStore returns a store writing to filePath, starting from the annotations already in
+// it. An empty filePath returns a disabled store that ignores all changes.
+func NewStore(filePath string) *Store {
+	if filePath == "" {
+		return &Store{enabled: false}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		log.Printf("Failed to create annotations directory: %v", err)
+		return &Store{enabled: false}
+	}
+
+	s := &Store{
+		filePath:   filePath,
+		enabled:    true,
+		containers: make(map[string]Annotation),
+	}
+	data, err := os.ReadFile(filePath)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		log.Printf("Warning: Failed to read annotations file, starting empty: %v", err)
+	default:
+		var existing file
+		if err := json.Unmarshal(data, &existing); err != nil {
+			log.Printf("Warning: Failed to parse annotations file, starting empty: %v", err)
+		} else if existing.Containers != nil {
+			s.containers = existing.Containers
+		}
+	}
+	return s
+}
+
+// Enabled reports whether annotations are written anywhere
+func (s *Store) Enabled() bool {
+	return s.enabled
+}
+
+// Add records that the container owns hostname. A hostname owned by another container,
+// e.g. the predecessor of a recreated container, moves to this one.
+func (s *Store) Add(containerID, containerName, dockerHost, hostname string) error {
+	if !s.enabled || containerID == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.containers[containerID]
+	if ok && slices.Contains(current.Hostnames, hostname) && current.ContainerName == containerName && current.DockerHost == dockerHost {
+		return nil
+	}
+
+	previous := maps.Clone(s.containers)
+	s.drop(hostname)
+	current = s.containers[containerID]
+	current.ContainerName = containerName
+	current.DockerHost = dockerHost
+	if !slices.Contains(current.Hostnames, hostname) {
+		current.Hostnames = append(slices.Clone(current.Hostnames), hostname)
+		slices.Sort(current.Hostnames)
+	}
+	current.UpdatedAt = time.Now().UTC()
+	s.containers[containerID] = current
+
+	if err := s.save(); err != nil {
+		s.containers = previous
+		return err
+	}
+	return nil
+}
+
+// Remove records that no container owns hostname any longer
+func (s *Store) Remove(hostname string) error {
+	if !s.enabled {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := maps.Clone(s.containers)
+	if !s.drop(hostname) {
+		return nil
+	}
+	if err := s.save(); err != nil {
+		s.containers = previous
+		return err
+	}
+	return nil
+}
+
+// Get returns the annotation of a container
+func (s *Store) Get(containerID string) (Annotation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	annotation, ok := s.containers[containerID]
+	return annotation, ok
+}
+
+// drop removes hostname from every container, deleting containers left without
+// hostnames, and reports whether anything changed. Callers must hold s.mu.
+func (s *Store) drop(hostname string) bool {
+	changed := false
+	for id, annotation := range s.containers {
+		i := slices.Index(annotation.Hostnames, hostname)
+		if i < 0 {
+			continue
+		}
+		changed = true
+		if len(annotation.Hostnames) == 1 {
+			delete(s.containers, id)
+			continue
+		}
+		annotation.Hostnames = slices.Delete(slices.Clone(annotation.Hostnames), i, i+1)
+		annotation.UpdatedAt = time.Now().UTC()
+		s.containers[id] = annotation
+	}
+	return changed
+}
+
+// save writes the annotations atomically. Callers must hold s.mu.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(file{Containers: s.containers}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize annotations: %w", err)
+	}
+
+	tempFile := s.filePath + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp annotations file: %w", err)
+	}
+	if err := os.Rename(tempFile, s.filePath); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename temp annotations file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package annotation
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "annotations.json")
+	store := NewStore(path)
+
+	for _, hostname := range []string{"www.example.com", "app.example.com"} {
+		if err := store.Add("abc123", "web", "", hostname); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if got, _ := store.Get("abc123"); !slices.Equal(got.Hostnames, []string{"app.example.com", "www.example.com"}) || got.ContainerName != "web" {
+		t.Errorf("Get() = %+v, want both hostnames of web", got)
+	}
+
+	// A recreated container takes over the hostname
+	if err := store.Add("def456", "web", "", "app.example.com"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if got, _ := store.Get("abc123"); !slices.Equal(got.Hostnames, []string{"www.example.com"}) {
+		t.Errorf("Hostnames of the old container = %v, want [www.example.com]", got.Hostnames)
+	}
+
+	// Containers without hostnames are dropped
+	if err := store.Remove("www.example.com"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, ok := store.Get("abc123"); ok {
+		t.Error("Get() found the old container, want it dropped")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written file
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("Annotations file is invalid: %v", err)
+	}
+	if len(written.Containers) != 1 || !slices.Equal(written.Containers["def456"].Hostnames, []string{"app.example.com"}) {
+		t.Errorf("Annotations file = %s, want only def456 with app.example.com", data)
+	}
+
+	// Annotations survive restarts
+	if got, ok := NewStore(path).Get("def456"); !ok || got.Hostnames[0] != "app.example.com" {
+		t.Errorf("Get() after reload = %+v, %v, want app.example.com", got, ok)
+	}
+}
+
+func TestStore_Disabled(t *testing.T) {
+	store := NewStore("")
+	if store.Enabled() {
+		t.Error("Enabled() = true, want false without a path")
+	}
+	if err := store.Add("abc123", "web", "", "app.example.com"); err != nil {
+		t.Errorf("Add() error = %v", err)
+	}
+	if _, ok := store.Get("abc123"); ok {
+		t.Error("Get() found an annotation of a disabled store")
+	}
+}
@@ -0,0 +1,170 @@
+// Package approval keeps the destructive changes to records the companion did not
+// create, which wait for a human to approve them before they are applied
+package approval
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// FileName is the name of the approval queue, stored next to the state file
+const FileName = "approvals.json"
+
+const (
+	ActionUpdate = "update" // Point an existing record at a new address
+	ActionDelete = "delete" // Delete an existing record
+)
+
+// Change is a change waiting for approval
+type Change struct {
+	ID        string `json:"id"`
+	Action    string `json:"action"` // ActionUpdate or ActionDelete
+	Hostname  string `json:"hostname"`
+	Domain    string `json:"domain"`
+	Subdomain string `json:"subdomain"`
+	Before    string `json:"before"`          // Current address of the record
+	After     string `json:"after,omitempty"` // Address to publish, empty for deletions
+
+	// Origin of the change
+	DockerHost     string `json:"docker_host,omitempty"`
+	Container      string `json:"container,omitempty"`
+	ComposeProject string `json:"compose_project,omitempty"`
+	ComposeService string `json:"compose_service,omitempty"`
+
+	RequestedAt time.Time  `json:"requested_at"`
+	ApprovedAt  *time.Time `json:"approved_at,omitempty"`
+}
+
+// Approved reports whether the change was approved
+func (c Change) Approved() bool {
+	return c.ApprovedAt != nil
+}
+
+// String describes the change, e.g. "update app.example.com (198.51.100.1 -> 203.0.113.7)"
+func (c Change) String() string {
+	if c.Action == ActionDelete {
+		return fmt.Sprintf("delete %s (%s)", c.Hostname, c.Before)
+	}
+	return fmt.Sprintf("update %s (%s -> %s)", c.Hostname, c.Before, c.After)
+}
+
+// changeID derives the ID from what the change does, so the same change queued again
+// keeps its ID and its approval
+func changeID(c Change) string {
+	sum := sha256.Sum256([]byte(c.Action + "\x00" + c.Hostname + "\x00" + c.Before + "\x00" + c.After))
+	return hex.EncodeToString(sum[:4])
+}
+
+// Queue is the approval queue file
+type Queue struct {
+	Changes []Change `json:"changes"` // Oldest first
+}
+
+// Path returns the approval queue location for the given state file
+func Path(stateFile string) string {
+	return filepath.Join(filepath.Dir(stateFile), FileName)
+}
+
+// Load reads the approval queue. A missing file is an empty queue.
+func Load(path string) (*Queue, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Queue{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var q Queue
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, fmt.Errorf("failed to parse approval queue: %w", err)
+	}
+	return &q, nil
+}
+
+// Save writes the approval queue atomically
+func (q *Queue) Save(path string) error {
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize approval queue: %w", err)
+	}
+
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp approval queue: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename temp approval queue: %w", err)
+	}
+	return nil
+}
+
+// Add queues change and returns it with its ID. A queued change of the same record and
+// action is replaced, as it is outdated, unless it is the same change. added reports
+// whether the queue was modified.
+func (q *Queue) Add(change Change, now time.Time) (queued Change, added bool) {
+	change.ID = changeID(change)
+	i := slices.IndexFunc(q.Changes, func(c Change) bool {
+		return c.Hostname == change.Hostname && c.Action == change.Action
+	})
+	if i >= 0 && q.Changes[i].ID == change.ID {
+		return q.Changes[i], false
+	}
+
+	change.RequestedAt = now
+	change.ApprovedAt = nil
+	if i >= 0 {
+		q.Changes = slices.Delete(q.Changes, i, i+1)
+	}
+	q.Changes = append(q.Changes, change)
+	return change, true
+}
+
+// Get returns the queued change with the ID of change
+func (q *Queue) Get(change Change) (Change, bool) {
+	i := slices.IndexFunc(q.Changes, func(c Change) bool { return c.ID == changeID(change) })
+	if i < 0 {
+		return Change{}, false
+	}
+	return q.Changes[i], true
+}
+
+// Approve approves the change with the given ID
+func (q *Queue) Approve(id string, now time.Time) (Change, error) {
+	i := slices.IndexFunc(q.Changes, func(c Change) bool { return c.ID == id })
+	if i < 0 {
+		return Change{}, fmt.Errorf("no change %s waiting for approval", id)
+	}
+	if q.Changes[i].ApprovedAt == nil {
+		q.Changes[i].ApprovedAt = &now
+	}
+	return q.Changes[i], nil
+}
+
+// Remove drops the change with the given ID and reports whether it was queued
+func (q *Queue) Remove(id string) bool {
+	i := slices.IndexFunc(q.Changes, func(c Change) bool { return c.ID == id })
+	if i < 0 {
+		return false
+	}
+	q.Changes = slices.Delete(q.Changes, i, i+1)
+	return true
+}
+
+// Approved returns the approved changes
+func (q *Queue) Approved() []Change {
+	var approved []Change
+	for _, change := range q.Changes {
+		if change.Approved() {
+			approved = append(approved, change)
+		}
+	}
+	return approved
+}
@@ -0,0 +1,90 @@
+package approval
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueueAdd(t *testing.T) {
+	now := time.Now()
+	q := &Queue{}
+
+	update := Change{Action: ActionUpdate, Hostname: "app.example.com", Before: "198.51.100.1", After: "203.0.113.7"}
+	queued, added := q.Add(update, now)
+	if !added || queued.ID == "" {
+		t.Fatalf("Add() = %+v, %v, want a new change with an ID", queued, added)
+	}
+
+	if _, err := q.Approve(queued.ID, now); err != nil {
+		t.Fatalf("Approve() error = %v", err)
+	}
+
+	// Queuing the same change again keeps the approval
+	again, added := q.Add(update, now.Add(time.Minute))
+	if added || again.ID != queued.ID || !again.Approved() {
+		t.Errorf("Add() of the same change = %+v, %v, want the approved change", again, added)
+	}
+
+	// A different address replaces the outdated change and needs a new approval
+	moved := update
+	moved.After = "203.0.113.8"
+	replaced, added := q.Add(moved, now)
+	if !added || replaced.ID == queued.ID || replaced.Approved() {
+		t.Errorf("Add() of a changed address = %+v, %v, want a new unapproved change", replaced, added)
+	}
+	if len(q.Changes) != 1 {
+		t.Errorf("Queue has %d changes, want 1", len(q.Changes))
+	}
+
+	// Deletions of the same record are queued separately
+	if _, added := q.Add(Change{Action: ActionDelete, Hostname: "app.example.com", Before: "198.51.100.1"}, now); !added || len(q.Changes) != 2 {
+		t.Errorf("Add() of a deletion = %v with %d changes, want 2 queued changes", added, len(q.Changes))
+	}
+}
+
+func TestQueueApproveRemove(t *testing.T) {
+	now := time.Now()
+	q := &Queue{}
+	queued, _ := q.Add(Change{Action: ActionDelete, Hostname: "old.example.com", Before: "198.51.100.1"}, now)
+
+	if _, err := q.Approve("unknown", now); err == nil {
+		t.Error("Approve() of an unknown ID error = nil, want error")
+	}
+	if len(q.Approved()) != 0 {
+		t.Error("Approved() returned a change before its approval")
+	}
+	if _, err := q.Approve(queued.ID, now); err != nil {
+		t.Fatalf("Approve() error = %v", err)
+	}
+	if approved := q.Approved(); len(approved) != 1 || approved[0].ID != queued.ID {
+		t.Errorf("Approved() = %+v, want the approved change", approved)
+	}
+
+	if !q.Remove(queued.ID) || q.Remove(queued.ID) {
+		t.Error("Remove() did not remove the change exactly once")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := Path(filepath.Join(t.TempDir(), "state.json"))
+
+	empty, err := Load(path)
+	if err != nil || len(empty.Changes) != 0 {
+		t.Fatalf("Load() without file = %+v, %v, want an empty queue", empty, err)
+	}
+
+	q := &Queue{}
+	queued, _ := q.Add(Change{Action: ActionUpdate, Hostname: "app.example.com", Before: "198.51.100.1", After: "203.0.113.7"}, time.Now())
+	if err := q.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if change, ok := loaded.Get(queued); !ok || change.After != "203.0.113.7" {
+		t.Errorf("Get() = %+v, %v, want the saved change", change, ok)
+	}
+}
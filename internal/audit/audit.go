@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Action describes the kind of DNS change that was audited
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Entry represents a single audited DNS change
+type Entry struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Action        Action    `json:"action"`
+	Source        string    `json:"source"` // "event", "reconciliation", ...
+	Hostname      string    `json:"hostname"`
+	Domain        string    `json:"domain"`
+	Subdomain     string    `json:"subdomain"`
+	RecordType    string    `json:"record_type"`
+	Before        string    `json:"before,omitempty"`
+	After         string    `json:"after,omitempty"`
+	ContainerID   string    `json:"container_id,omitempty"`
+	ContainerName string    `json:"container_name,omitempty"`
+	DryRun        bool      `json:"dry_run"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// Logger appends audit entries to a JSONL file
+type Logger struct {
+	mu       sync.Mutex
+	filePath string
+	enabled  bool
+}
+
+func NewLogger(filePath string) *Logger {
+	if filePath == "" {
+		return &Logger{
+			enabled: false,
+		}
+	}
+
+	// Ensure directory exists
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		log.Printf("Failed to create audit log directory: %v", err)
+		return &Logger{
+			enabled: false,
+		}
+	}
+
+	return &Logger{
+		filePath: filePath,
+		enabled:  true,
+	}
+}
+
+// Enabled reports whether entries are written anywhere
+func (l *Logger) Enabled() bool {
+	return l.enabled
+}
+
+// Record appends an entry to the audit log. The file is opened in append mode
+// for every write so external log rotation is picked up automatically.
+func (l *Logger) Record(entry Entry) error {
+	if !l.enabled {
+		return nil
+	}
+
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to serialize audit entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// ReadEntries reads all entries from the audit log
+func (l *Logger) ReadEntries() ([]Entry, error) {
+	if !l.enabled {
+		return nil, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var entry Entry
+		if err := dec.Decode(&entry); err != nil {
+			return entries, fmt.Errorf("failed to parse audit log: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
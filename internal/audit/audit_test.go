@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewLogger_Disabled(t *testing.T) {
+	logger := NewLogger("")
+	if logger.Enabled() {
+		t.Error("Logger with empty path should be disabled")
+	}
+
+	// Recording on a disabled logger should be a no-op
+	if err := logger.Record(Entry{Action: ActionCreate, Hostname: "app.example.com"}); err != nil {
+		t.Errorf("Record() on disabled logger error = %v, want nil", err)
+	}
+
+	entries, err := logger.ReadEntries()
+	if err != nil {
+		t.Errorf("ReadEntries() on disabled logger error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("ReadEntries() on disabled logger returned %d entries, want 0", len(entries))
+	}
+}
+
+func TestRecord_AppendsEntries(t *testing.T) {
+	tempDir := t.TempDir()
+	logPath := filepath.Join(tempDir, "subdir", "audit.jsonl")
+
+	logger := NewLogger(logPath)
+	if !logger.Enabled() {
+		t.Fatal("Logger should be enabled")
+	}
+
+	entries := []Entry{
+		{Action: ActionCreate, Source: "event", Hostname: "app.example.com", After: "1.2.3.4", ContainerName: "app"},
+		{Action: ActionUpdate, Source: "reconciliation", Hostname: "api.example.com", Before: "1.2.3.4", After: "5.6.7.8", DryRun: true},
+		{Action: ActionDelete, Source: "event", Hostname: "old.example.com", Before: "1.2.3.4", Error: "boom"},
+	}
+
+	for _, entry := range entries {
+		if err := logger.Record(entry); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != len(entries) {
+		t.Fatalf("Audit log has %d lines, want %d", len(lines), len(entries))
+	}
+
+	got, err := logger.ReadEntries()
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	if len(got) != len(entries) {
+		t.Fatalf("ReadEntries() returned %d entries, want %d", len(got), len(entries))
+	}
+
+	for i, entry := range got {
+		if entry.Timestamp.IsZero() {
+			t.Errorf("Entry %d has zero timestamp", i)
+		}
+		if entry.Action != entries[i].Action {
+			t.Errorf("Entry %d Action = %v, want %v", i, entry.Action, entries[i].Action)
+		}
+		if entry.Hostname != entries[i].Hostname {
+			t.Errorf("Entry %d Hostname = %v, want %v", i, entry.Hostname, entries[i].Hostname)
+		}
+		if entry.Before != entries[i].Before || entry.After != entries[i].After {
+			t.Errorf("Entry %d Before/After = %v/%v, want %v/%v", i, entry.Before, entry.After, entries[i].Before, entries[i].After)
+		}
+		if entry.DryRun != entries[i].DryRun {
+			t.Errorf("Entry %d DryRun = %v, want %v", i, entry.DryRun, entries[i].DryRun)
+		}
+	}
+}
+
+func TestReadEntries_MissingFile(t *testing.T) {
+	logger := NewLogger(filepath.Join(t.TempDir(), "audit.jsonl"))
+
+	entries, err := logger.ReadEntries()
+	if err != nil {
+		t.Errorf("ReadEntries() on missing file error = %v, want nil", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("ReadEntries() on missing file returned %d entries, want 0", len(entries))
+	}
+}
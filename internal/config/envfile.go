@@ -0,0 +1,58 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// loadEnvFile reads KEY=VALUE pairs from a .env file into the process environment.
+// Variables that are already set take precedence and are left untouched. It returns
+// the names of the variables it set.
+func loadEnvFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ENV_FILE: %w", err)
+	}
+	defer file.Close()
+
+	var loaded []string
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("ENV_FILE %s:%d: expected KEY=VALUE", path, lineNo)
+		}
+
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		if err := os.Setenv(key, unquoteEnvValue(strings.TrimSpace(value))); err != nil {
+			return nil, fmt.Errorf("ENV_FILE %s:%d: %w", path, lineNo, err)
+		}
+		loaded = append(loaded, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ENV_FILE: %w", err)
+	}
+	return loaded, nil
+}
+
+// unquoteEnvValue strips one pair of matching single or double quotes
+func unquoteEnvValue(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' || first == '\'') && first == last {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeEnvFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	return path
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("NC_API_KEY", "from-env")
+
+	path := writeEnvFile(t, `# Netcup credentials
+NC_CUSTOMER_NUMBER=12345
+NC_API_KEY=from-file
+export NC_API_PASSWORD="secret value"
+
+NC_DEFAULT_TTL='600'
+`)
+
+	loaded, err := loadEnvFile(path)
+	if err != nil {
+		t.Fatalf("loadEnvFile() error = %v", err)
+	}
+
+	wantLoaded := []string{"NC_CUSTOMER_NUMBER", "NC_API_PASSWORD", "NC_DEFAULT_TTL"}
+	if !reflect.DeepEqual(loaded, wantLoaded) {
+		t.Errorf("loaded = %v, want %v", loaded, wantLoaded)
+	}
+
+	want := map[string]string{
+		"NC_CUSTOMER_NUMBER": "12345",
+		"NC_API_KEY":         "from-env",
+		"NC_API_PASSWORD":    "secret value",
+		"NC_DEFAULT_TTL":     "600",
+	}
+	for key, value := range want {
+		if got := os.Getenv(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestLoadEnvFile_Errors(t *testing.T) {
+	testCases := []struct {
+		name string
+		path func(t *testing.T) string
+	}{
+		{"missing file", func(t *testing.T) string { return filepath.Join(t.TempDir(), "missing.env") }},
+		{"line without equals", func(t *testing.T) string { return writeEnvFile(t, "NC_API_KEY\n") }},
+		{"empty key", func(t *testing.T) string { return writeEnvFile(t, "=value\n") }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Clearenv()
+			if _, err := loadEnvFile(tc.path(t)); err == nil {
+				t.Error("loadEnvFile() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestLoadWithEnvFile(t *testing.T) {
+	os.Clearenv()
+	path := writeEnvFile(t, "NC_CUSTOMER_NUMBER=12345\nNC_API_KEY=test-key\nNC_API_PASSWORD=test-password\n")
+	os.Setenv("ENV_FILE", path)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.CustomerNumber != 12345 || cfg.APIKey != "test-key" {
+		t.Errorf("CustomerNumber = %d, APIKey = %q, want credentials from ENV_FILE", cfg.CustomerNumber, cfg.APIKey)
+	}
+	if cfg.EnvFile != path || len(cfg.EnvFileVars) != 3 {
+		t.Errorf("EnvFile = %q, EnvFileVars = %v", cfg.EnvFile, cfg.EnvFileVars)
+	}
+
+	os.Setenv("ENV_FILE", filepath.Join(t.TempDir(), "missing.env"))
+	if _, err := Load(); err == nil {
+		t.Error("Load() with missing ENV_FILE error = nil, want error")
+	}
+}
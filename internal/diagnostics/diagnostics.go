@@ -0,0 +1,54 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/dns"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/events"
+)
+
+// Bundle is a snapshot of the companion for attaching to bug reports
+type Bundle struct {
+	CreatedAt time.Time         `json:"created_at"`
+	Config    *config.Config    `json:"config"` // Secrets redacted
+	DNS       dns.Diagnostics   `json:"dns"`
+	HostQueue events.QueueStats `json:"host_queue"`
+}
+
+// NewBundle collects the redacted configuration, the DNS manager's state and the
+// statistics of the host queue, if there is one
+func NewBundle(cfg *config.Config, dnsManager *dns.Manager, hostQueue *events.HostQueue) *Bundle {
+	bundle := &Bundle{
+		CreatedAt: time.Now().UTC(),
+		Config:    cfg.Redacted(),
+		DNS:       dnsManager.Diagnostics(),
+	}
+	if hostQueue != nil {
+		bundle.HostQueue = hostQueue.Stats()
+	}
+	return bundle
+}
+
+// Write stores the bundle as diagnostics-<timestamp>.json in dir and returns its path.
+// The file is only readable by the owner as it lists all managed records.
+func Write(dir string, bundle *Bundle) (string, error) {
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize diagnostics: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create diagnostics directory: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("diagnostics-%s.json", bundle.CreatedAt.Format("20060102T150405Z")))
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write diagnostics: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,60 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/dns"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestWrite(t *testing.T) {
+	cfg := &config.Config{
+		CustomerNumber:   12345,
+		APIKey:           "secret-key",
+		APIPassword:      "secret-password",
+		NotificationURLs: []string{"discord://token@id"},
+		HostIP:           "203.0.113.7",
+	}
+	dnsManager := dns.NewManager(cfg, netcup.NewFakeAPI(), nil)
+
+	dir := filepath.Join(t.TempDir(), "diagnostics")
+	path, err := Write(dir, NewBundle(cfg, dnsManager, nil))
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if filepath.Dir(path) != dir || !strings.HasPrefix(filepath.Base(path), "diagnostics-") {
+		t.Errorf("Write() path = %q", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read bundle: %v", err)
+	}
+	for _, secret := range []string{"secret-key", "secret-password", "token@id"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("bundle contains secret %q", secret)
+		}
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		t.Fatalf("bundle is not valid JSON: %v", err)
+	}
+	if bundle.Config.HostIP != "203.0.113.7" {
+		t.Errorf("Config.HostIP = %q, want 203.0.113.7", bundle.Config.HostIP)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("bundle permissions = %v, want 0600", perm)
+	}
+}
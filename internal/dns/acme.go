@@ -0,0 +1,147 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/audit"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+// acmeChallengeLabel is the label of ACME DNS-01 challenge records
+const acmeChallengeLabel = "_acme-challenge"
+
+// ErrNotChallenge is returned for challenge requests naming another record than an
+// _acme-challenge TXT record
+var ErrNotChallenge = errors.New("not an _acme-challenge record")
+
+// PresentChallenge creates the ACME DNS-01 TXT record fqdn with value, e.g.
+// _acme-challenge.app.example.com. Other values of the same record are kept, so
+// challenges for a domain and its wildcard can be solved at the same time.
+func (m *Manager) PresentChallenge(ctx context.Context, fqdn, value string) (err error) {
+	ctx, span := tracer.Start(ctx, "dns.PresentChallenge")
+	defer func() { endSpan(span, err) }()
+
+	return m.updateChallenge(ctx, fqdn, value, false)
+}
+
+// CleanupChallenge deletes the ACME DNS-01 TXT record fqdn with value. A missing record
+// is not an error.
+func (m *Manager) CleanupChallenge(ctx context.Context, fqdn, value string) (err error) {
+	ctx, span := tracer.Start(ctx, "dns.CleanupChallenge")
+	defer func() { endSpan(span, err) }()
+
+	return m.updateChallenge(ctx, fqdn, value, true)
+}
+
+func (m *Manager) updateChallenge(ctx context.Context, fqdn, value string, remove bool) error {
+	hostname, domain, subdomain, err := challengeRecord(fqdn)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("empty challenge value for %s", hostname)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.paused {
+		return ErrPaused
+	}
+	lock := m.hostLock(hostname)
+	lock.Lock()
+	defer lock.Unlock()
+
+	session, err := m.client.Login(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to login to Netcup: %w", err)
+	}
+	defer session.Logout()
+
+	records, err := session.InfoDnsRecords(domain)
+	if err != nil {
+		return fmt.Errorf("failed to get DNS records for %s: %w", domain, err)
+	}
+	var existing []netcup.DnsRecord
+	for _, record := range netcup.FindRecords(*records, subdomain, "TXT") {
+		if record.Destination == value {
+			existing = append(existing, record)
+		}
+	}
+
+	auditEntry := audit.Entry{
+		Action:     audit.ActionCreate,
+		Source:     "acme",
+		Hostname:   hostname,
+		Domain:     domain,
+		Subdomain:  subdomain,
+		RecordType: "TXT",
+		After:      value,
+		DryRun:     m.config.DryRun,
+	}
+	if remove {
+		auditEntry.Action = audit.ActionDelete
+		auditEntry.Before, auditEntry.After = value, ""
+	}
+
+	switch {
+	case remove && len(existing) == 0:
+		log.Printf("No ACME challenge record found for %s, nothing to remove", hostname)
+		m.forgetChallenge(hostname, value)
+		return nil
+	case !remove && len(existing) > 0:
+		log.Printf("ACME challenge record for %s already present", hostname)
+		m.trackChallenge(hostname, domain, subdomain, value)
+		return nil
+	case m.config.DryRun:
+		if remove {
+			log.Printf("[DRY RUN] Would delete ACME challenge record %s", hostname)
+		} else {
+			log.Printf("[DRY RUN] Would create ACME challenge record %s", hostname)
+		}
+		m.recordAudit(auditEntry)
+		return nil
+	}
+
+	if remove {
+		err = netcup.DeleteDnsRecords(session, domain, existing)
+	} else {
+		var record netcup.DnsRecord
+		if record, err = netcup.NewTXTRecord(subdomain, value); err == nil {
+			change := []netcup.DnsRecord{record}
+			_, err = session.UpdateDnsRecords(domain, &change)
+		}
+	}
+	if err != nil {
+		auditEntry.Error = err.Error()
+		m.recordAudit(auditEntry)
+		return fmt.Errorf("failed to update ACME challenge record %s: %w", hostname, err)
+	}
+
+	m.recordAudit(auditEntry)
+	if remove {
+		log.Printf("Deleted ACME challenge record %s", hostname)
+		m.forgetChallenge(hostname, value)
+	} else {
+		log.Printf("Created ACME challenge record %s", hostname)
+		m.trackChallenge(hostname, domain, subdomain, value)
+	}
+	return nil
+}
+
+// challengeRecord splits the FQDN of a challenge record, with or without the trailing
+// dot, into its hostname, domain and subdomain, e.g. _acme-challenge.app.example.com.
+// into example.com and _acme-challenge.app
+func challengeRecord(fqdn string) (hostname, domain, subdomain string, err error) {
+	hostname = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(fqdn)), ".")
+	labels := strings.Split(hostname, ".")
+	if len(labels) < 3 || labels[0] != acmeChallengeLabel {
+		return "", "", "", fmt.Errorf("%w: %q", ErrNotChallenge, fqdn)
+	}
+	domain = strings.Join(labels[len(labels)-2:], ".")
+	subdomain = strings.Join(labels[:len(labels)-2], ".")
+	return hostname, domain, subdomain, nil
+}
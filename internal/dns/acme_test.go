@@ -0,0 +1,105 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestChallengeRecord(t *testing.T) {
+	tests := []struct {
+		fqdn          string
+		wantDomain    string
+		wantSubdomain string
+		wantErr       bool
+	}{
+		{fqdn: "_acme-challenge.example.com.", wantDomain: "example.com", wantSubdomain: "_acme-challenge"},
+		{fqdn: "_acme-challenge.App.example.com", wantDomain: "example.com", wantSubdomain: "_acme-challenge.app"},
+		{fqdn: "app.example.com.", wantErr: true},
+		{fqdn: "_acme-challenge.com.", wantErr: true},
+		{fqdn: "www._acme-challenge.example.com.", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fqdn, func(t *testing.T) {
+			_, domain, subdomain, err := challengeRecord(tt.fqdn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("challengeRecord() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrNotChallenge) {
+				t.Errorf("challengeRecord() error = %v, want ErrNotChallenge", err)
+			}
+			if domain != tt.wantDomain || subdomain != tt.wantSubdomain {
+				t.Errorf("challengeRecord() = %s, %s, want %s, %s", domain, subdomain, tt.wantDomain, tt.wantSubdomain)
+			}
+		})
+	}
+}
+
+func TestChallenges(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com", netcup.DnsRecord{Hostname: "app", Type: "A", Destination: "203.0.113.1"})
+	manager := NewManager(testConfig(), api, nil)
+	ctx := context.Background()
+	fqdn := "_acme-challenge.app.example.com."
+
+	// Challenges for a domain and its wildcard are solved side by side
+	for _, value := range []string{"first", "second", "second"} {
+		if err := manager.PresentChallenge(ctx, fqdn, value); err != nil {
+			t.Fatalf("PresentChallenge(%s) error = %v", value, err)
+		}
+	}
+	if got := challengeValues(api); len(got) != 2 || got[0] != "first" || got[1] != "second" {
+		t.Errorf("challenge records = %v, want [first second]", got)
+	}
+
+	if err := manager.CleanupChallenge(ctx, fqdn, "first"); err != nil {
+		t.Fatalf("CleanupChallenge() error = %v", err)
+	}
+	if err := manager.CleanupChallenge(ctx, fqdn, "missing"); err != nil {
+		t.Fatalf("CleanupChallenge() of missing record error = %v", err)
+	}
+	if got := challengeValues(api); len(got) != 1 || got[0] != "second" {
+		t.Errorf("challenge records = %v, want [second]", got)
+	}
+	if got := len(api.Records("example.com")); got != 2 {
+		t.Errorf("zone has %d records, want the A record and one challenge", got)
+	}
+
+	// Only challenge records can be written
+	if err := manager.PresentChallenge(ctx, "app.example.com.", "value"); !errors.Is(err, ErrNotChallenge) {
+		t.Errorf("PresentChallenge() error = %v, want ErrNotChallenge", err)
+	}
+
+	manager.Pause()
+	if err := manager.PresentChallenge(ctx, fqdn, "third"); !errors.Is(err, ErrPaused) {
+		t.Errorf("PresentChallenge() while paused error = %v, want ErrPaused", err)
+	}
+}
+
+func TestChallenges_DryRun(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	cfg := testConfig()
+	cfg.DryRun = true
+	manager := NewManager(cfg, api, nil)
+
+	if err := manager.PresentChallenge(context.Background(), "_acme-challenge.example.com.", "value"); err != nil {
+		t.Fatalf("PresentChallenge() error = %v", err)
+	}
+	if got := challengeValues(api); len(got) != 0 {
+		t.Errorf("challenge records = %v, want none in dry run", got)
+	}
+}
+
+func challengeValues(api *netcup.FakeAPI) []string {
+	var values []string
+	for _, record := range api.Records("example.com") {
+		if record.Type == "TXT" {
+			values = append(values, record.Destination)
+		}
+	}
+	return values
+}
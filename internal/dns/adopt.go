@@ -0,0 +1,97 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+// Adopt seeds the state with the existing A records of hosts, so records created
+// before the companion managed DNS are reconciled and removed like its own. Hosts
+// without a record or already in the state are skipped. DNS is never written.
+func (m *Manager) Adopt(ctx context.Context, hosts []docker.HostInfo) (adopted int, err error) {
+	ctx, span := tracer.Start(ctx, "dns.Adopt")
+	defer func() { endSpan(span, err) }()
+
+	if m.stateManager == nil {
+		return 0, fmt.Errorf("adopting records requires state persistence")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hostsByDomain := make(map[string][]docker.HostInfo)
+	for _, info := range hosts {
+		if info.Remove {
+			continue
+		}
+		if _, exists := m.stateManager.GetRecord(info.Hostname); exists {
+			continue
+		}
+		hostsByDomain[info.Domain] = append(hostsByDomain[info.Domain], info)
+	}
+	if len(hostsByDomain) == 0 {
+		return 0, nil
+	}
+
+	session, err := m.client.Login(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to login to Netcup: %w", err)
+	}
+	defer session.Logout()
+
+	domains := make([]string, 0, len(hostsByDomain))
+	for domain := range hostsByDomain {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	var errorCount int
+	for _, domain := range domains {
+		select {
+		case <-ctx.Done():
+			return adopted, ctx.Err()
+		default:
+		}
+
+		records, err := session.InfoDnsRecords(domain)
+		if err != nil {
+			log.Printf("Warning: Failed to get DNS records for %s during adoption: %v", domain, err)
+			errorCount++
+			continue
+		}
+		index := indexARecords(*records)
+
+		for _, info := range hostsByDomain[domain] {
+			existing := index[info.Subdomain]
+			if len(existing) == 0 {
+				log.Printf("Adopt: no existing record for %s", info.Hostname)
+				continue
+			}
+			ip := existing[0].Destination
+
+			if m.config.DryRun {
+				log.Printf("[DRY RUN] Would adopt: %s -> %s%s", info.Hostname, ip, info.StackSuffix())
+				adopted++
+				continue
+			}
+
+			if err := m.stateManager.UpdateRecordWithOrigin(info.Hostname, info.Domain, info.Subdomain, ip, "A", recordOrigin(info)); err != nil {
+				log.Printf("Warning: Failed to adopt %s: %v", info.Hostname, err)
+				errorCount++
+				continue
+			}
+			m.learnRecordID(info.Hostname, info.Subdomain, ip, records)
+			log.Printf("Adopted existing record: %s -> %s%s", info.Hostname, ip, info.StackSuffix())
+			adopted++
+		}
+	}
+
+	if errorCount > 0 {
+		return adopted, fmt.Errorf("adoption failed for %d domains or records", errorCount)
+	}
+	return adopted, nil
+}
@@ -0,0 +1,110 @@
+package dns
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestAdopt(t *testing.T) {
+	tests := []struct {
+		name        string
+		dryRun      bool
+		wantAdopted int
+		wantState   map[string]string // hostname -> persisted IP
+	}{
+		{
+			name:        "adopts existing records",
+			wantAdopted: 2,
+			wantState: map[string]string{
+				"app.example.com":     "198.51.100.1",
+				"shop.example.com":    "198.51.100.2",
+				"known.example.com":   "203.0.113.1",
+				"missing.example.com": "",
+			},
+		},
+		{
+			name:        "dry run persists nothing",
+			dryRun:      true,
+			wantAdopted: 2,
+			wantState: map[string]string{
+				"app.example.com":   "",
+				"shop.example.com":  "",
+				"known.example.com": "203.0.113.1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := netcup.NewFakeAPI()
+			api.AddZone("example.com",
+				netcup.DnsRecord{Hostname: "app", Type: "A", Destination: "198.51.100.1"},
+				netcup.DnsRecord{Hostname: "shop", Type: "A", Destination: "198.51.100.2"},
+				netcup.DnsRecord{Hostname: "known", Type: "A", Destination: "198.51.100.3"},
+			)
+
+			stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+			if err != nil {
+				t.Fatalf("Failed to create state manager: %v", err)
+			}
+			if err := stateManager.UpdateRecord("known.example.com", "example.com", "known", "203.0.113.1", "A"); err != nil {
+				t.Fatalf("UpdateRecord() error = %v", err)
+			}
+
+			cfg := testConfig()
+			cfg.DryRun = tt.dryRun
+			manager := NewManager(cfg, api, stateManager)
+
+			adopted, err := manager.Adopt(context.Background(), []docker.HostInfo{
+				{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"},
+				{Hostname: "shop.example.com", Domain: "example.com", Subdomain: "shop", ComposeProject: "shop", ComposeService: "web"},
+				{Hostname: "known.example.com", Domain: "example.com", Subdomain: "known"},
+				{Hostname: "missing.example.com", Domain: "example.com", Subdomain: "missing"},
+			})
+			if err != nil {
+				t.Fatalf("Adopt() error = %v", err)
+			}
+			if adopted != tt.wantAdopted {
+				t.Errorf("Adopt() = %d, want %d", adopted, tt.wantAdopted)
+			}
+
+			for hostname, wantIP := range tt.wantState {
+				record, ok := stateManager.GetRecord(hostname)
+				if wantIP == "" {
+					if ok {
+						t.Errorf("%s persisted, want not adopted", hostname)
+					}
+					continue
+				}
+				if !ok || record.IP != wantIP {
+					t.Errorf("%s persisted IP = %q, want %q", hostname, record.IP, wantIP)
+				}
+			}
+
+			if !tt.dryRun {
+				if record, _ := stateManager.GetRecord("shop.example.com"); record.ComposeProject != "shop" {
+					t.Errorf("ComposeProject = %q, want shop", record.ComposeProject)
+				}
+			}
+
+			// Adoption never writes DNS
+			for _, record := range api.Records("example.com") {
+				if record.Hostname == "known" && record.Destination != "198.51.100.3" {
+					t.Errorf("known record changed to %s", record.Destination)
+				}
+			}
+		})
+	}
+}
+
+func TestAdopt_RequiresState(t *testing.T) {
+	manager := NewManager(testConfig(), netcup.NewFakeAPI(), nil)
+	if _, err := manager.Adopt(context.Background(), nil); err == nil {
+		t.Error("Adopt() without state succeeded")
+	}
+}
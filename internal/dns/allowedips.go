@@ -0,0 +1,18 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrIPNotAllowed is returned for an address outside ALLOWED_PUBLISH_IPS and
+// ALLOWED_PUBLISH_CIDRS, e.g. a misdetected VPN egress or Docker bridge address
+var ErrIPNotAllowed = errors.New("address is outside ALLOWED_PUBLISH_IPS and ALLOWED_PUBLISH_CIDRS")
+
+// checkAllowedIP refuses to publish ip unless the allow-list contains it
+func (m *Manager) checkAllowedIP(ip string) error {
+	if m.config.PublishAllowed(ip) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrIPNotAllowed, ip)
+}
@@ -0,0 +1,91 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func allowedNets(t *testing.T, cidr string) []*net.IPNet {
+	t.Helper()
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []*net.IPNet{ipNet}
+}
+
+func TestProcessHostInfo_AllowedIPs(t *testing.T) {
+	tests := []struct {
+		name        string
+		detected    string
+		info        docker.HostInfo
+		wantRecords int
+	}{
+		{name: "detected address inside", detected: "203.0.113.10", info: docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}, wantRecords: 1},
+		{name: "detected VPN egress", detected: "198.51.100.20", info: docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}},
+		{name: "container address", detected: "203.0.113.10", info: docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app", IP: "172.17.0.2"}},
+		{name: "custom destination", detected: "203.0.113.10", info: docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app", Destination: "192.0.2.1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := detectHostIP
+			detectHostIP = func() (string, error) { return tt.detected, nil }
+			defer func() { detectHostIP = original }()
+
+			api := netcup.NewFakeAPI()
+			api.AddZone("example.com")
+			cfg := testConfig()
+			cfg.HostIP = ""
+			cfg.AllowedPublishNets = allowedNets(t, "203.0.113.8/29")
+			manager := NewManager(cfg, api, nil)
+
+			err := manager.ProcessHostInfo(context.Background(), tt.info)
+			if tt.wantRecords == 0 && !errors.Is(err, ErrIPNotAllowed) {
+				t.Errorf("ProcessHostInfo() error = %v, want ErrIPNotAllowed", err)
+			}
+			if tt.wantRecords > 0 && err != nil {
+				t.Fatalf("ProcessHostInfo() error = %v", err)
+			}
+			if got := len(api.Records("example.com")); got != tt.wantRecords {
+				t.Errorf("Zone has %d records, want %d", got, tt.wantRecords)
+			}
+		})
+	}
+}
+
+func TestReconcile_AllowedIPs(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stateManager.UpdateRecord("app.example.com", "example.com", "app", "203.0.113.1", "A"); err != nil {
+		t.Fatal(err)
+	}
+	if err := stateManager.UpdateRecordWithOrigin("vpn.example.com", "example.com", "vpn", "203.0.113.1", "A", state.Origin{Destination: "192.0.2.1"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testConfig()
+	cfg.AllowedPublishNets = allowedNets(t, "203.0.113.0/29")
+	manager := NewManager(cfg, api, stateManager)
+
+	if err := manager.ReconcileFromState(context.Background()); err != nil {
+		t.Fatalf("ReconcileFromState() error = %v", err)
+	}
+	records := api.Records("example.com")
+	if len(netcup.FindRecords(records, "app", "A")) != 1 {
+		t.Errorf("records = %v, want a record for app", records)
+	}
+	if len(netcup.FindRecords(records, "vpn", "A")) != 0 {
+		t.Errorf("records = %v, want no record for a destination outside the allow-list", records)
+	}
+}
@@ -0,0 +1,23 @@
+package dns
+
+import (
+	"log"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+// annotate records in the annotations file that the container of info owns the record
+// of its hostname. Failures are only logged.
+func (m *Manager) annotate(info docker.HostInfo) {
+	if err := m.annotations.Add(info.ContainerID, info.ContainerName, info.DockerHost, info.Hostname); err != nil {
+		log.Printf("Warning: Failed to annotate container %s with %s: %v", info.ContainerName, info.Hostname, err)
+	}
+}
+
+// unannotate drops a hostname whose record was removed from the annotations file.
+// Failures are only logged.
+func (m *Manager) unannotate(hostname string) {
+	if err := m.annotations.Remove(hostname); err != nil {
+		log.Printf("Warning: Failed to remove %s from the annotations file: %v", hostname, err)
+	}
+}
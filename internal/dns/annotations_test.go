@@ -0,0 +1,36 @@
+package dns
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/annotation"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestProcessHostInfo_Annotates(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+
+	cfg := testConfig()
+	cfg.AnnotationsFile = filepath.Join(t.TempDir(), "annotations.json")
+	manager := NewManager(cfg, api, nil)
+
+	info := docker.HostInfo{ContainerID: "abc123", ContainerName: "web", Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if got, ok := annotation.NewStore(cfg.AnnotationsFile).Get("abc123"); !ok || len(got.Hostnames) != 1 || got.Hostnames[0] != "app.example.com" {
+		t.Fatalf("Annotation = %+v, %v, want app.example.com", got, ok)
+	}
+
+	info.Remove = true
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if got, ok := annotation.NewStore(cfg.AnnotationsFile).Get("abc123"); ok {
+		t.Errorf("Annotation after removal = %+v, want none", got)
+	}
+}
@@ -0,0 +1,144 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/approval"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+// ApprovalCheckInterval is how often the approval queue is checked for approved changes
+const ApprovalCheckInterval = 10 * time.Second
+
+// needsApproval reports whether changing the record of hostname waits for approval:
+// with APPROVAL_REQUIRED, records the companion did not create, i.e. those missing from
+// the state, are only updated or deleted once approved
+func (m *Manager) needsApproval(hostname string) bool {
+	if m.approvalPath == "" {
+		return false
+	}
+	if m.stateManager == nil {
+		return true
+	}
+	_, ok := m.stateManager.GetRecord(hostname)
+	return !ok
+}
+
+// approvalChange describes the change of the record of info from before to after for
+// the approval queue; an empty after deletes the record
+func approvalChange(info docker.HostInfo, before, after string) approval.Change {
+	action := approval.ActionUpdate
+	if after == "" {
+		action = approval.ActionDelete
+	}
+	return approval.Change{
+		Action:         action,
+		Hostname:       info.Hostname,
+		Domain:         info.Domain,
+		Subdomain:      info.Subdomain,
+		Before:         before,
+		After:          after,
+		DockerHost:     info.DockerHost,
+		Container:      info.ContainerName,
+		ComposeProject: info.ComposeProject,
+		ComposeService: info.ComposeService,
+	}
+}
+
+// approved reports whether change was approved. A change that was not is queued and
+// notified once, and skipped until it is approved. Failures to access the queue are
+// logged and keep the record unchanged.
+func (m *Manager) approved(change approval.Change) bool {
+	m.approvalMu.Lock()
+	defer m.approvalMu.Unlock()
+
+	queue, err := approval.Load(m.approvalPath)
+	if err != nil {
+		log.Printf("Warning: Failed to read approval queue, not applying %s: %v", change, err)
+		return false
+	}
+	queued, added := queue.Add(change, time.Now())
+	if queued.Approved() {
+		log.Printf("Applying approved change %s: %s", queued.ID, queued)
+		return true
+	}
+	if !added {
+		log.Printf("Change %s is still waiting for approval: %s", queued.ID, queued)
+		return false
+	}
+	if err := queue.Save(m.approvalPath); err != nil {
+		log.Printf("Warning: Failed to queue %s for approval: %v", change, err)
+		return false
+	}
+
+	log.Printf("Record of %s was not created by the companion, queued change %s for approval: %s", change.Hostname, queued.ID, queued)
+	m.notifier.SendInfo(fmt.Sprintf("Approval required to %s, the record was not created by the companion. Approve with: companion approve %s", queued, queued.ID))
+	return false
+}
+
+// resolveApproval drops an applied change from the approval queue
+func (m *Manager) resolveApproval(change approval.Change) {
+	m.approvalMu.Lock()
+	defer m.approvalMu.Unlock()
+
+	queue, err := approval.Load(m.approvalPath)
+	if err != nil {
+		log.Printf("Warning: Failed to read approval queue: %v", err)
+		return
+	}
+	queued, ok := queue.Get(change)
+	if !ok || !queue.Remove(queued.ID) {
+		return
+	}
+	if err := queue.Save(m.approvalPath); err != nil {
+		log.Printf("Warning: Failed to remove applied change %s from the approval queue: %v", queued.ID, err)
+	}
+}
+
+// ApplyApproved applies the approved changes of the approval queue and returns how many
+// were applied. Changes whose record changed meanwhile are queued again for approval.
+func (m *Manager) ApplyApproved(ctx context.Context) (int, error) {
+	if m.approvalPath == "" {
+		return 0, nil
+	}
+	if m.Paused() {
+		return 0, ErrPaused
+	}
+
+	m.approvalMu.Lock()
+	queue, err := approval.Load(m.approvalPath)
+	m.approvalMu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	applied := 0
+	for _, change := range queue.Approved() {
+		info := docker.HostInfo{
+			Hostname:       change.Hostname,
+			Domain:         change.Domain,
+			Subdomain:      change.Subdomain,
+			IP:             change.After,
+			Remove:         change.Action == approval.ActionDelete,
+			DockerHost:     change.DockerHost,
+			ContainerName:  change.Container,
+			ComposeProject: change.ComposeProject,
+			ComposeService: change.ComposeService,
+		}
+		// The host may have been processed while the change waited
+		m.setKnown(change.Hostname, false)
+		if err := m.ProcessHostInfo(ctx, info); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", change.Hostname, err))
+			continue
+		}
+		// Also drops changes that turned out to be unnecessary
+		m.resolveApproval(change)
+		applied++
+	}
+	return applied, errors.Join(errs...)
+}
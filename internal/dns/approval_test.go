@@ -0,0 +1,92 @@
+package dns
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/approval"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestApprovalRequired(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com",
+		netcup.DnsRecord{Hostname: "app", Type: "A", Destination: "198.51.100.1"},
+		netcup.DnsRecord{Hostname: "old", Type: "A", Destination: "198.51.100.2"},
+	)
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	stateManager, err := state.NewManager(statePath)
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	cfg := testConfig()
+	cfg.StateFilePath = statePath
+	cfg.ApprovalRequired = true
+	manager := NewManager(cfg, api, stateManager)
+	ctx := context.Background()
+
+	hosts := []docker.HostInfo{
+		{ContainerName: "app", Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"},
+		{ContainerName: "new", Hostname: "new.example.com", Domain: "example.com", Subdomain: "new"},
+		{ContainerName: "old", Hostname: "old.example.com", Domain: "example.com", Subdomain: "old", Remove: true},
+	}
+	for _, info := range hosts {
+		if err := manager.ProcessHostInfo(ctx, info); err != nil {
+			t.Fatalf("ProcessHostInfo(%s) error = %v", info.Hostname, err)
+		}
+	}
+
+	// Only the new record is created, the foreign ones wait for approval
+	if got := zoneDestinations(api); got["app"] != "198.51.100.1" || got["old"] != "198.51.100.2" || got["new"] != "203.0.113.1" {
+		t.Fatalf("Zone records = %v, want foreign records unchanged and new created", got)
+	}
+	queue, err := approval.Load(approval.Path(statePath))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(queue.Changes) != 2 {
+		t.Fatalf("Approval queue has %d changes, want 2", len(queue.Changes))
+	}
+
+	for _, change := range queue.Changes {
+		if _, err := queue.Approve(change.ID, time.Now()); err != nil {
+			t.Fatalf("Approve() error = %v", err)
+		}
+	}
+	if err := queue.Save(approval.Path(statePath)); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	applied, err := manager.ApplyApproved(ctx)
+	if err != nil {
+		t.Fatalf("ApplyApproved() error = %v", err)
+	}
+	if applied != 2 {
+		t.Errorf("ApplyApproved() applied %d changes, want 2", applied)
+	}
+	got := zoneDestinations(api)
+	if got["app"] != "203.0.113.1" {
+		t.Errorf("Approved update was not applied, app -> %s", got["app"])
+	}
+	if _, ok := got["old"]; ok {
+		t.Error("Approved deletion was not applied")
+	}
+	if queue, _ := approval.Load(approval.Path(statePath)); len(queue.Changes) != 0 {
+		t.Errorf("Approval queue still has %d changes after applying them", len(queue.Changes))
+	}
+}
+
+// zoneDestinations maps the A records of example.com to their addresses
+func zoneDestinations(api *netcup.FakeAPI) map[string]string {
+	destinations := make(map[string]string)
+	for _, record := range api.Records("example.com") {
+		if record.Type == "A" {
+			destinations[record.Hostname] = record.Destination
+		}
+	}
+	return destinations
+}
@@ -0,0 +1,207 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/audit"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+// ChallengeSweepInterval is how often the managed zones are checked for stale ACME
+// challenge records
+const ChallengeSweepInterval = 10 * time.Minute
+
+// trackChallenge persists a challenge record presented through the challenge API, so the
+// sweeper knows its age. Failures are only logged.
+func (m *Manager) trackChallenge(hostname, domain, subdomain, value string) {
+	if m.stateManager == nil {
+		return
+	}
+	challenge := state.Challenge{Hostname: hostname, Domain: domain, Subdomain: subdomain, Value: value, CreatedAt: time.Now()}
+	if err := m.stateManager.TrackChallenge(challenge); err != nil {
+		log.Printf("Warning: Failed to persist ACME challenge record %s: %v", hostname, err)
+	}
+}
+
+// forgetChallenge stops tracking a challenge record that is gone. Failures are only
+// logged.
+func (m *Manager) forgetChallenge(hostname, value string) {
+	if m.stateManager == nil {
+		return
+	}
+	if err := m.stateManager.ForgetChallenge(hostname, value); err != nil {
+		log.Printf("Warning: Failed to forget ACME challenge record %s: %v", hostname, err)
+	}
+}
+
+// isChallengeName reports whether a record name relative to the zone is that of an ACME
+// challenge, for the apex or a subdomain
+func isChallengeName(name string) bool {
+	return name == acmeChallengeLabel || strings.HasPrefix(name, acmeChallengeLabel+".")
+}
+
+// SweepChallenges deletes _acme-challenge TXT records older than maxAge from the zones
+// of the managed records and tracked challenges, and returns how many were deleted.
+// Records not presented through the challenge API, e.g. left behind by an ACME client
+// that crashed, are aged from when the sweeper first found them.
+func (m *Manager) SweepChallenges(ctx context.Context, maxAge time.Duration) (int, error) {
+	if m.stateManager == nil || m.config.ObserveMode() {
+		return 0, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Sweeping deletes DNS records, skip it until writes are resumed
+	if m.paused {
+		return 0, nil
+	}
+
+	tracked := m.stateManager.Challenges()
+	var domains []string
+	for _, record := range m.stateManager.GetAllRecords() {
+		domains = append(domains, record.Domain)
+	}
+	for _, challenge := range tracked {
+		domains = append(domains, challenge.Domain)
+	}
+	slices.Sort(domains)
+	domains = slices.Compact(domains)
+	if len(domains) == 0 {
+		return 0, nil
+	}
+
+	session, err := m.client.Login(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to login to Netcup: %w", err)
+	}
+	defer session.Logout()
+
+	var errs []error
+	swept := 0
+	for _, domain := range domains {
+		select {
+		case <-ctx.Done():
+			return swept, ctx.Err()
+		default:
+		}
+
+		n, err := m.sweepZoneChallenges(session, domain, tracked, maxAge)
+		swept += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", domain, err))
+		}
+	}
+	return swept, errors.Join(errs...)
+}
+
+// sweepZoneChallenges deletes the stale challenge records of one zone. The caller holds
+// m.mu.
+func (m *Manager) sweepZoneChallenges(session netcup.DnsSession, domain string, tracked []state.Challenge, maxAge time.Duration) (int, error) {
+	records, err := session.InfoDnsRecords(domain)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get DNS records: %w", err)
+	}
+
+	now := time.Now()
+	var stale []netcup.DnsRecord
+	var found []state.Challenge
+	for _, record := range *records {
+		if record.Type != "TXT" || !isChallengeName(record.Hostname) {
+			continue
+		}
+		hostname := record.Hostname + "." + domain
+		i := slices.IndexFunc(tracked, func(c state.Challenge) bool {
+			return c.Hostname == hostname && c.Value == record.Destination
+		})
+
+		challenge := state.Challenge{Hostname: hostname, Domain: domain, Subdomain: record.Hostname, Value: record.Destination, CreatedAt: now}
+		if i >= 0 {
+			challenge = tracked[i]
+		} else {
+			log.Printf("Found ACME challenge record %s, deleting it once it is older than %s", hostname, maxAge)
+			if err := m.stateManager.TrackChallenge(challenge); err != nil {
+				log.Printf("Warning: Failed to persist ACME challenge record %s: %v", hostname, err)
+			}
+		}
+		found = append(found, challenge)
+		if now.Sub(challenge.CreatedAt) >= maxAge {
+			stale = append(stale, record)
+		}
+	}
+
+	// Challenges cleaned up by other means are no longer tracked
+	for _, challenge := range tracked {
+		if challenge.Domain == domain && !slices.Contains(found, challenge) {
+			m.forgetChallenge(challenge.Hostname, challenge.Value)
+		}
+	}
+	if len(stale) == 0 {
+		return 0, nil
+	}
+
+	dryRun := m.config.DryRun
+	auditEntries := make([]audit.Entry, 0, len(stale))
+	for _, record := range stale {
+		auditEntries = append(auditEntries, audit.Entry{
+			Action:     audit.ActionDelete,
+			Source:     "acme_cleanup",
+			Hostname:   record.Hostname + "." + domain,
+			Domain:     domain,
+			Subdomain:  record.Hostname,
+			RecordType: "TXT",
+			Before:     record.Destination,
+			DryRun:     dryRun,
+		})
+	}
+
+	if dryRun {
+		log.Printf("[DRY RUN] Would delete %d stale ACME challenge records from %s", len(stale), domain)
+		for _, entry := range auditEntries {
+			m.recordAudit(entry)
+		}
+		return 0, nil
+	}
+
+	log.Printf("Deleting %d ACME challenge records older than %s from %s", len(stale), maxAge, domain)
+	if err := netcup.DeleteDnsRecords(session, domain, stale); err != nil {
+		for _, entry := range auditEntries {
+			entry.Error = err.Error()
+			m.recordAudit(entry)
+		}
+		return 0, fmt.Errorf("failed to delete stale ACME challenge records: %w", err)
+	}
+	for _, entry := range auditEntries {
+		m.recordAudit(entry)
+		m.forgetChallenge(entry.Hostname, entry.Before)
+	}
+	return len(stale), nil
+}
+
+// RunChallengeSweeper deletes stale ACME challenge records every ChallengeSweepInterval.
+// It blocks until ctx is done.
+func (m *Manager) RunChallengeSweeper(ctx context.Context, maxAge time.Duration) {
+	ticker := time.NewTicker(ChallengeSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if swept, err := m.SweepChallenges(ctx, maxAge); err != nil {
+			log.Printf("Warning: Deleting stale ACME challenge records failed: %v", err)
+		} else if swept > 0 {
+			log.Printf("Deleted %d stale ACME challenge records", swept)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package dns
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestSweepChallenges(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com",
+		netcup.DnsRecord{Hostname: "app", Type: "A", Destination: "203.0.113.1"},
+		netcup.DnsRecord{Hostname: "_acme-challenge.app", Type: "TXT", Destination: "old"},
+		netcup.DnsRecord{Hostname: "_acme-challenge.app", Type: "TXT", Destination: "recent"},
+		netcup.DnsRecord{Hostname: "_acme-challenge", Type: "TXT", Destination: "untracked"},
+		netcup.DnsRecord{Hostname: "app", Type: "TXT", Destination: "v=spf1 -all"},
+	)
+
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	if err := stateManager.UpdateRecord("app.example.com", "example.com", "app", "203.0.113.1", "A"); err != nil {
+		t.Fatal(err)
+	}
+	for _, challenge := range []state.Challenge{
+		{Hostname: "_acme-challenge.app.example.com", Domain: "example.com", Subdomain: "_acme-challenge.app", Value: "old", CreatedAt: time.Now().Add(-2 * time.Hour)},
+		{Hostname: "_acme-challenge.app.example.com", Domain: "example.com", Subdomain: "_acme-challenge.app", Value: "recent", CreatedAt: time.Now()},
+		{Hostname: "_acme-challenge.app.example.com", Domain: "example.com", Subdomain: "_acme-challenge.app", Value: "gone", CreatedAt: time.Now()},
+	} {
+		if err := stateManager.TrackChallenge(challenge); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manager := NewManager(testConfig(), api, stateManager)
+
+	swept, err := manager.SweepChallenges(context.Background(), time.Hour)
+	if err != nil {
+		t.Fatalf("SweepChallenges() error = %v", err)
+	}
+	if swept != 1 {
+		t.Errorf("SweepChallenges() = %d, want 1", swept)
+	}
+
+	var values []string
+	for _, record := range api.Records("example.com") {
+		values = append(values, record.Destination)
+	}
+	if slices.Contains(values, "old") || !slices.Contains(values, "recent") || !slices.Contains(values, "untracked") || len(values) != 4 {
+		t.Errorf("Zone after sweep = %v, want only the old challenge deleted", values)
+	}
+
+	// The untracked record is aged from now on, deleted challenges are forgotten
+	var tracked []string
+	for _, challenge := range stateManager.Challenges() {
+		tracked = append(tracked, challenge.Value)
+	}
+	slices.Sort(tracked)
+	if !slices.Equal(tracked, []string{"recent", "untracked"}) {
+		t.Errorf("Tracked challenges = %v, want [recent untracked]", tracked)
+	}
+}
+
+func TestPresentChallenge_TracksRecord(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	manager := NewManager(testConfig(), api, stateManager)
+
+	fqdn := "_acme-challenge.app.example.com."
+	if err := manager.PresentChallenge(context.Background(), fqdn, "token"); err != nil {
+		t.Fatalf("PresentChallenge() error = %v", err)
+	}
+	if challenges := stateManager.Challenges(); len(challenges) != 1 || challenges[0].Value != "token" {
+		t.Fatalf("Tracked challenges = %+v, want token", challenges)
+	}
+
+	if err := manager.CleanupChallenge(context.Background(), fqdn, "token"); err != nil {
+		t.Fatalf("CleanupChallenge() error = %v", err)
+	}
+	if challenges := stateManager.Challenges(); len(challenges) != 0 {
+		t.Errorf("Tracked challenges after cleanup = %+v, want none", challenges)
+	}
+}
@@ -0,0 +1,101 @@
+package dns
+
+import (
+	"slices"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/events"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+// recordDiff compares the records of a zone fetched before an update with the zone
+// after recordSet is applied, for the hostnames recordSet touches. A record replaced by
+// a single other of its name and type is an update, anything else a creation or
+// deletion.
+func recordDiff(before, recordSet []netcup.DnsRecord) []events.Change {
+	after := applyRecordSet(before, recordSet)
+
+	type key struct{ name, recordType string }
+	var keys []key
+	destinations := func(records []netcup.DnsRecord, k key) []string {
+		var values []string
+		for _, record := range records {
+			if record.Hostname == k.name && record.Type == k.recordType {
+				values = append(values, record.Destination)
+			}
+		}
+		return values
+	}
+	for _, record := range recordSet {
+		if k := (key{record.Hostname, record.Type}); !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+
+	var changes []events.Change
+	for _, k := range keys {
+		removed, added := difference(destinations(before, k), destinations(after, k))
+		if len(removed) == 1 && len(added) == 1 {
+			changes = append(changes, events.Change{Name: k.name, Type: k.recordType, Before: removed[0], After: added[0]})
+			continue
+		}
+		for _, destination := range removed {
+			changes = append(changes, events.Change{Name: k.name, Type: k.recordType, Before: destination})
+		}
+		for _, destination := range added {
+			changes = append(changes, events.Change{Name: k.name, Type: k.recordType, After: destination})
+		}
+	}
+	return changes
+}
+
+// applyRecordSet returns the records of a zone after an update with recordSet: records
+// with an Id replace or delete the record of that Id, records without one are created
+func applyRecordSet(records, recordSet []netcup.DnsRecord) []netcup.DnsRecord {
+	result := slices.Clone(records)
+	for _, record := range recordSet {
+		i := -1
+		if record.Id != "" {
+			i = slices.IndexFunc(result, func(r netcup.DnsRecord) bool { return r.Id == record.Id })
+		}
+		switch {
+		case i >= 0 && record.DeleteRecord:
+			result = slices.Delete(result, i, i+1)
+		case i >= 0:
+			result[i] = record
+		case !record.DeleteRecord:
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+// deletions marks records for deletion in a record set
+func deletions(records []netcup.DnsRecord) []netcup.DnsRecord {
+	recordSet := make([]netcup.DnsRecord, len(records))
+	for i, record := range records {
+		record.DeleteRecord = true
+		recordSet[i] = record
+	}
+	return recordSet
+}
+
+// difference returns the values only in before and the values only in after
+func difference(before, after []string) (removed, added []string) {
+	remaining := slices.Clone(after)
+	for _, value := range before {
+		if i := slices.Index(remaining, value); i >= 0 {
+			remaining = slices.Delete(remaining, i, i+1)
+		} else {
+			removed = append(removed, value)
+		}
+	}
+	return removed, remaining
+}
+
+// ttlChange returns the change of the zone TTL between two fetches of a zone, if any
+func ttlChange(before, after *netcup.DnsZoneData) []events.Change {
+	if before == nil || after == nil || before.Ttl == after.Ttl {
+		return nil
+	}
+	return []events.Change{{Type: "TTL", Before: before.Ttl, After: after.Ttl}}
+}
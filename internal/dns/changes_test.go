@@ -0,0 +1,61 @@
+package dns
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/events"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestRecordDiff(t *testing.T) {
+	before := []netcup.DnsRecord{
+		{Id: "1", Hostname: "app", Type: "A", Destination: "203.0.113.1"},
+		{Id: "2", Hostname: "app", Type: "AAAA", Destination: "2001:db8::1"},
+		{Id: "3", Hostname: "_meta.app", Type: "TXT", Destination: "container=old"},
+		{Id: "4", Hostname: "web", Type: "A", Destination: "203.0.113.1"},
+	}
+
+	tests := []struct {
+		name      string
+		recordSet []netcup.DnsRecord
+		want      []events.Change
+	}{
+		{
+			name: "update and created metadata",
+			recordSet: []netcup.DnsRecord{
+				{Id: "1", Hostname: "app", Type: "A", Destination: "203.0.113.2"},
+				{Hostname: "_meta.web", Type: "TXT", Destination: "container=web"},
+			},
+			want: []events.Change{
+				{Name: "app", Type: "A", Before: "203.0.113.1", After: "203.0.113.2"},
+				{Name: "_meta.web", Type: "TXT", After: "container=web"},
+			},
+		},
+		{
+			name:      "deleted records",
+			recordSet: deletions(before[:3]),
+			want: []events.Change{
+				{Name: "app", Type: "A", Before: "203.0.113.1"},
+				{Name: "app", Type: "AAAA", Before: "2001:db8::1"},
+				{Name: "_meta.app", Type: "TXT", Before: "container=old"},
+			},
+		},
+		{
+			name:      "unchanged record",
+			recordSet: []netcup.DnsRecord{before[3]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recordDiff(before, tt.recordSet); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("recordDiff() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if got := ttlChange(&netcup.DnsZoneData{Ttl: "86400"}, &netcup.DnsZoneData{Ttl: "300"}); len(got) != 1 || got[0].Before != "86400" || got[0].After != "300" {
+		t.Errorf("ttlChange() = %+v, want 86400 -> 300", got)
+	}
+}
@@ -0,0 +1,424 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/audit"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/events"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+// wildcardPlan is a change to the wildcard consolidation of one domain
+type wildcardPlan struct {
+	domain       string
+	ip           string
+	wildcard     *state.Wildcard   // Current wildcard of the domain, nil if not consolidated
+	consolidated []state.DNSRecord // Records already served by the wildcard
+	candidates   []state.DNSRecord // Records with a record of their own that the wildcard can serve
+	revert       bool              // Restore the records and delete the wildcard
+}
+
+// ConsolidateWildcards replaces the records of each domain with one wildcard record
+// once more than WILDCARD_CONSOLIDATE_THRESHOLD of them point at the host IP, and
+// restores the records of consolidated domains that dropped to the threshold or below,
+// or all of them when consolidation is disabled
+func (m *Manager) ConsolidateWildcards(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stateManager == nil || m.config.ObserveMode() || m.paused || m.InMaintenance() || m.config.PublishContainerIP() {
+		return nil
+	}
+	if m.config.WildcardConsolidateThreshold == 0 && len(m.stateManager.Wildcards()) == 0 {
+		return nil
+	}
+
+	hostIP, err := m.resolveHostIP()
+	if err != nil {
+		if m.skipsPrivateIP(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get host IP for wildcard consolidation: %w", err)
+	}
+	plans := m.planWildcards(hostIP)
+	if len(plans) == 0 {
+		return nil
+	}
+
+	session, err := m.client.Login(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to login to Netcup for wildcard consolidation: %w", err)
+	}
+	defer session.Logout()
+
+	m.applyWildcards(session, plans)
+	return nil
+}
+
+// planWildcards returns the domains whose wildcard consolidation has to change. Only
+// records of a single label pointing at the host IP of their domain take part, as
+// records with a custom destination, a lifetime, SRV records or a deeper name need one
+// of their own.
+func (m *Manager) planWildcards(hostIP string) []wildcardPlan {
+	if m.stateManager == nil || m.config.PublishContainerIP() {
+		return nil
+	}
+	threshold := m.config.WildcardConsolidateThreshold
+
+	plans := make(map[string]*wildcardPlan)
+	plan := func(domain string) *wildcardPlan {
+		if plans[domain] == nil {
+			ip, err := m.domainHostIP(domain, hostIP)
+			if err != nil {
+				log.Printf("Warning: Not consolidating records of %s: %v", domain, err)
+			}
+			plans[domain] = &wildcardPlan{domain: domain, ip: ip}
+		}
+		return plans[domain]
+	}
+	for _, wildcard := range m.stateManager.Wildcards() {
+		plan(wildcard.Domain).wildcard = &wildcard
+	}
+	for _, record := range m.stateManager.GetAllRecords() {
+		switch {
+		case record.Consolidated:
+			plan(record.Domain).consolidated = append(plan(record.Domain).consolidated, record)
+		case threshold > 0 && record.RecordType == "A" && record.Destination == "" && record.ExpiresAt == nil &&
+			!strings.ContainsAny(record.Subdomain, ".*@") && record.IP == plan(record.Domain).ip &&
+			len(m.stateManager.SRVRecords(record.Hostname)) == 0 && m.checkManaged(record.Subdomain) == nil:
+			plan(record.Domain).candidates = append(plan(record.Domain).candidates, record)
+		}
+	}
+
+	var changes []wildcardPlan
+	for _, p := range plans {
+		switch {
+		case p.ip == "":
+			// The address of the domain is unknown, so leave its records as they are
+			continue
+		case p.wildcard != nil && (threshold == 0 || len(p.consolidated)+len(p.candidates) <= threshold):
+			p.revert = true
+		case p.wildcard != nil && p.wildcard.IP == p.ip && len(p.candidates) == 0:
+			continue
+		case p.wildcard == nil && (threshold == 0 || len(p.candidates) <= threshold):
+			continue
+		}
+		changes = append(changes, *p)
+	}
+	slices.SortFunc(changes, func(a, b wildcardPlan) int { return strings.Compare(a.domain, b.domain) })
+	return changes
+}
+
+// applyWildcards consolidates or restores the records of each planned domain, reporting
+// failures without stopping. The caller holds m.mu.
+func (m *Manager) applyWildcards(session netcup.DnsSession, plans []wildcardPlan) {
+	for _, plan := range plans {
+		apply := m.consolidate
+		if plan.revert {
+			apply = m.deconsolidate
+		}
+		if err := apply(session, plan); err != nil {
+			log.Printf("Warning: %v", err)
+			m.notifier.SendError(err.Error())
+		}
+	}
+}
+
+// consolidate creates or re-points the wildcard record of a domain and deletes the
+// records of the candidates, along with their metadata records, in a single update.
+// Candidates with other records at or below their name are left alone, as such names
+// exist and a wildcard would not answer for them.
+func (m *Manager) consolidate(session netcup.DnsSession, plan wildcardPlan) error {
+	records, err := session.InfoDnsRecords(plan.domain)
+	if err != nil {
+		return fmt.Errorf("failed to get DNS records of %s for wildcard consolidation: %w", plan.domain, err)
+	}
+
+	var recordSet []netcup.DnsRecord
+	var previousIP string
+	if existing := m.ownWildcard(*records, plan.wildcard); existing != nil {
+		previousIP = existing.Destination
+		if existing.Destination != plan.ip {
+			change := *existing
+			change.Destination = plan.ip
+			recordSet = append(recordSet, change)
+		}
+	} else {
+		if plan.wildcard == nil && nameExists(*records, "*") {
+			log.Printf("Not consolidating the records of %s, the zone already has a wildcard record", plan.domain)
+			return nil
+		}
+		wildcard, err := netcup.NewARecord("*", plan.ip)
+		if err != nil {
+			return err
+		}
+		recordSet = append(recordSet, wildcard)
+	}
+
+	var hostnames []string
+	for _, candidate := range plan.candidates {
+		own := netcup.FindRecords(*records, candidate.Subdomain, "A")
+		if slices.ContainsFunc(own, func(r netcup.DnsRecord) bool { return r.Destination != plan.ip }) {
+			continue
+		}
+		if m.config.MetadataRecords {
+			own = append(own, m.metadataRecords(*records, candidate.Subdomain)...)
+		}
+		if nameExists(applyRecordSet(*records, deletions(own)), candidate.Subdomain) {
+			log.Printf("Keeping the record of %s, other records exist at or below it", candidate.Hostname)
+			continue
+		}
+		recordSet = append(recordSet, deletions(own)...)
+		hostnames = append(hostnames, candidate.Hostname)
+	}
+	if plan.wildcard == nil && len(hostnames) <= m.config.WildcardConsolidateThreshold {
+		log.Printf("Not consolidating the records of %s, only %d of them can be served by a wildcard", plan.domain, len(hostnames))
+		return nil
+	}
+	if len(recordSet) == 0 {
+		return nil
+	}
+
+	summary := fmt.Sprintf("*.%s -> %s", plan.domain, plan.ip)
+	if len(hostnames) > 0 {
+		summary += fmt.Sprintf(", replacing %d records (%s)", len(hostnames), strings.Join(hostnames, ", "))
+	}
+	if m.config.DryRun {
+		log.Printf("[DRY RUN] Would consolidate DNS: %s", summary)
+		m.notifier.SendInfo(fmt.Sprintf("[DRY RUN] Would consolidate DNS: %s", summary))
+		return nil
+	}
+
+	auditEntries := []audit.Entry{{
+		Action:     audit.ActionCreate,
+		Source:     "wildcard_consolidation",
+		Hostname:   "*." + plan.domain,
+		Domain:     plan.domain,
+		Subdomain:  "*",
+		RecordType: "A",
+		Before:     previousIP,
+		After:      plan.ip,
+	}}
+	if previousIP != "" {
+		auditEntries[0].Action = audit.ActionUpdate
+	}
+	for _, candidate := range plan.candidates {
+		if slices.Contains(hostnames, candidate.Hostname) {
+			auditEntries = append(auditEntries, audit.Entry{
+				Action:     audit.ActionDelete,
+				Source:     "wildcard_consolidation",
+				Hostname:   candidate.Hostname,
+				Domain:     plan.domain,
+				Subdomain:  candidate.Subdomain,
+				RecordType: "A",
+				Before:     candidate.IP,
+			})
+		}
+	}
+
+	log.Printf("Consolidating DNS: %s", summary)
+	updated, err := session.UpdateDnsRecords(plan.domain, &recordSet)
+	for _, entry := range auditEntries {
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		m.recordAudit(entry)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to consolidate the records of %s into a wildcard: %w", plan.domain, err)
+	}
+
+	wildcard := state.Wildcard{Domain: plan.domain, IP: plan.ip, CreatedAt: time.Now()}
+	if plan.wildcard != nil {
+		wildcard.CreatedAt = plan.wildcard.CreatedAt
+	}
+	if own := m.ownWildcard(*updated, &wildcard); own != nil {
+		wildcard.RecordID = own.Id
+	}
+	for _, record := range plan.consolidated {
+		hostnames = append(hostnames, record.Hostname)
+	}
+	if err := m.stateManager.Consolidate(wildcard, hostnames); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	m.notifier.SendSuccess(fmt.Sprintf("Consolidated DNS: %s", summary))
+	return nil
+}
+
+// deconsolidate recreates the records served by the wildcard of a domain and deletes the
+// wildcard record in a single update
+func (m *Manager) deconsolidate(session netcup.DnsSession, plan wildcardPlan) error {
+	records, err := session.InfoDnsRecords(plan.domain)
+	if err != nil {
+		return fmt.Errorf("failed to get DNS records of %s to restore its records: %w", plan.domain, err)
+	}
+
+	var recordSet []netcup.DnsRecord
+	var auditEntries []audit.Entry
+	for _, record := range plan.consolidated {
+		if len(netcup.FindRecords(*records, record.Subdomain, "A")) > 0 {
+			continue
+		}
+		restored, err := netcup.NewARecord(record.Subdomain, record.IP)
+		if err != nil {
+			log.Printf("Warning: Not restoring the record of %s: %v", record.Hostname, err)
+			continue
+		}
+		recordSet = append(recordSet, restored)
+		auditEntries = append(auditEntries, audit.Entry{
+			Action:     audit.ActionCreate,
+			Source:     "wildcard_consolidation",
+			Hostname:   record.Hostname,
+			Domain:     plan.domain,
+			Subdomain:  record.Subdomain,
+			RecordType: "A",
+			After:      record.IP,
+		})
+	}
+	if wildcard := m.ownWildcard(*records, plan.wildcard); wildcard != nil {
+		recordSet = append(recordSet, deletions([]netcup.DnsRecord{*wildcard})...)
+		auditEntries = append(auditEntries, audit.Entry{
+			Action:     audit.ActionDelete,
+			Source:     "wildcard_consolidation",
+			Hostname:   "*." + plan.domain,
+			Domain:     plan.domain,
+			Subdomain:  "*",
+			RecordType: "A",
+			Before:     wildcard.Destination,
+		})
+	}
+
+	summary := fmt.Sprintf("*.%s, restoring %d records", plan.domain, len(plan.consolidated))
+	if m.config.DryRun {
+		log.Printf("[DRY RUN] Would remove wildcard DNS: %s", summary)
+		m.notifier.SendInfo(fmt.Sprintf("[DRY RUN] Would remove wildcard DNS: %s", summary))
+		return nil
+	}
+
+	if len(recordSet) > 0 {
+		log.Printf("Removing wildcard DNS: %s", summary)
+		updated, err := session.UpdateDnsRecords(plan.domain, &recordSet)
+		for _, entry := range auditEntries {
+			if err != nil {
+				entry.Error = err.Error()
+			}
+			m.recordAudit(entry)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to restore the records of wildcard *.%s: %w", plan.domain, err)
+		}
+		for _, record := range plan.consolidated {
+			m.learnRecordID(record.Hostname, record.Subdomain, record.IP, updated)
+		}
+	}
+
+	if err := m.stateManager.Deconsolidate(plan.domain); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	m.notifier.SendSuccess(fmt.Sprintf("Removed wildcard DNS: %s", summary))
+	return nil
+}
+
+// ownWildcard returns the wildcard record the companion created, by its Id or address
+func (m *Manager) ownWildcard(records []netcup.DnsRecord, wildcard *state.Wildcard) *netcup.DnsRecord {
+	if wildcard == nil {
+		return nil
+	}
+	for _, record := range netcup.FindRecords(records, "*", "A") {
+		if wildcard.RecordID != "" && record.Id == wildcard.RecordID || wildcard.RecordID == "" && record.Destination == wildcard.IP {
+			return &record
+		}
+	}
+	return nil
+}
+
+// consolidatedDomain reports whether the companion consolidated the records of domain
+// into a wildcard
+func (m *Manager) consolidatedDomain(domain string) bool {
+	if m.stateManager == nil {
+		return false
+	}
+	_, ok := m.stateManager.Wildcard(domain)
+	return ok
+}
+
+// joinWildcard persists a host the wildcard of its consolidated domain serves
+func (m *Manager) joinWildcard(info docker.HostInfo, ip string) {
+	if m.stateManager == nil || m.dryRun(info) {
+		return
+	}
+	m.wildcardMu.Lock()
+	defer m.wildcardMu.Unlock()
+
+	wildcard, ok := m.stateManager.Wildcard(info.Domain)
+	if !ok {
+		return
+	}
+	if err := m.stateManager.UpdateRecordWithOrigin(info.Hostname, info.Domain, info.Subdomain, ip, "A", recordOrigin(info)); err != nil {
+		log.Printf("Warning: Failed to persist DNS state for %s: %v", info.Hostname, err)
+		return
+	}
+	if err := m.stateManager.Consolidate(wildcard, []string{info.Hostname}); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// leaveWildcard forgets a removed host the wildcard of its domain serves. The name keeps
+// resolving through the wildcard; once the domain drops to the consolidation threshold,
+// the remaining records are restored and the wildcard deleted. It reports whether the
+// host was served by a wildcard.
+func (m *Manager) leaveWildcard(ctx context.Context, session netcup.DnsSession, info docker.HostInfo) bool {
+	if m.stateManager == nil {
+		return false
+	}
+	m.wildcardMu.Lock()
+	defer m.wildcardMu.Unlock()
+
+	record, ok := m.stateManager.GetRecord(info.Hostname)
+	if !ok || !record.Consolidated {
+		return false
+	}
+	if m.dryRun(info) {
+		log.Printf("[DRY RUN] Would stop serving %s from the wildcard of %s", info.Hostname, info.Domain)
+		return true
+	}
+
+	if err := m.stateManager.RemoveRecord(info.Hostname); err != nil {
+		log.Printf("Warning: Failed to remove persisted DNS state for %s: %v", info.Hostname, err)
+	}
+	m.setKnown(info.Hostname, false)
+	m.unannotate(info.Hostname)
+	log.Printf("Host %s is no longer served by the wildcard of %s", info.Hostname, info.Domain)
+	m.bus.Publish(ctx, events.RecordRemoved{Host: info})
+
+	for _, plan := range m.planWildcards(record.IP) {
+		if plan.domain == info.Domain && plan.revert {
+			if err := m.deconsolidate(session, plan); err != nil {
+				log.Printf("Warning: %v", err)
+				m.notifier.SendError(err.Error())
+			}
+		}
+	}
+	return true
+}
+
+// withoutConsolidated leaves out the records a wildcard serves, which have no record of
+// their own to reconcile, and marks them as known
+func (m *Manager) withoutConsolidated(records []state.DNSRecord) []state.DNSRecord {
+	own := records[:0:0]
+	for _, record := range records {
+		if record.Consolidated {
+			m.setKnown(record.Hostname, true)
+			continue
+		}
+		own = append(own, record)
+	}
+	return own
+}
@@ -0,0 +1,114 @@
+package dns
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestConsolidateWildcards(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com", netcup.DnsRecord{Hostname: "www", Type: "CNAME", Destination: "example.com."})
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	cfg := testConfig()
+	cfg.WildcardConsolidateThreshold = 2
+	manager := NewManager(cfg, api, stateManager)
+	ctx := context.Background()
+
+	hosts := []docker.HostInfo{
+		{Hostname: "a.example.com", Domain: "example.com", Subdomain: "a"},
+		{Hostname: "b.example.com", Domain: "example.com", Subdomain: "b"},
+		{Hostname: "c.example.com", Domain: "example.com", Subdomain: "c"},
+	}
+	for _, info := range hosts {
+		if err := manager.ProcessHostInfo(ctx, info); err != nil {
+			t.Fatalf("ProcessHostInfo(%s) error = %v", info.Hostname, err)
+		}
+	}
+
+	if err := manager.ConsolidateWildcards(ctx); err != nil {
+		t.Fatalf("ConsolidateWildcards() error = %v", err)
+	}
+	records := api.Records("example.com")
+	if len(records) != 2 || len(netcup.FindRecords(records, "*", "A")) != 1 {
+		t.Fatalf("records = %v, want the wildcard and the CNAME", records)
+	}
+	if _, ok := stateManager.Wildcard("example.com"); !ok {
+		t.Error("Wildcard(example.com) not persisted")
+	}
+	if record, _ := stateManager.GetRecord("b.example.com"); !record.Consolidated {
+		t.Error("record of b.example.com not marked as consolidated")
+	}
+
+	// A new host joins the wildcard
+	info := docker.HostInfo{Hostname: "d.example.com", Domain: "example.com", Subdomain: "d"}
+	if err := manager.ProcessHostInfo(ctx, info); err != nil {
+		t.Fatalf("ProcessHostInfo(%s) error = %v", info.Hostname, err)
+	}
+	if len(api.Records("example.com")) != 2 {
+		t.Errorf("records = %v, want no record for d", api.Records("example.com"))
+	}
+	if record, _ := stateManager.GetRecord(info.Hostname); !record.Consolidated {
+		t.Error("record of d.example.com not marked as consolidated")
+	}
+
+	// Dropping to the threshold restores the records of the remaining hosts
+	for _, info := range hosts[:2] {
+		info.Remove = true
+		if err := manager.ProcessHostInfo(ctx, info); err != nil {
+			t.Fatalf("ProcessHostInfo(%s) error = %v", info.Hostname, err)
+		}
+	}
+	records = api.Records("example.com")
+	if len(netcup.FindRecords(records, "*", "A")) != 0 {
+		t.Errorf("records = %v, want the wildcard deleted", records)
+	}
+	for _, subdomain := range []string{"c", "d"} {
+		if len(netcup.FindRecords(records, subdomain, "A")) != 1 {
+			t.Errorf("records = %v, want a record for %s", records, subdomain)
+		}
+	}
+	if _, ok := stateManager.Wildcard("example.com"); ok {
+		t.Error("Wildcard(example.com) still persisted")
+	}
+	if record, _ := stateManager.GetRecord("c.example.com"); record.Consolidated || record.RecordID == "" {
+		t.Errorf("record of c.example.com = %+v, want a record of its own", record)
+	}
+}
+
+func TestConsolidateWildcards_DryRun(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com",
+		netcup.DnsRecord{Hostname: "a", Type: "A", Destination: "203.0.113.1"},
+		netcup.DnsRecord{Hostname: "b", Type: "A", Destination: "203.0.113.1"})
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	for _, subdomain := range []string{"a", "b"} {
+		if err := stateManager.UpdateRecord(subdomain+".example.com", "example.com", subdomain, "203.0.113.1", "A"); err != nil {
+			t.Fatalf("UpdateRecord() error = %v", err)
+		}
+	}
+	cfg := testConfig()
+	cfg.WildcardConsolidateThreshold = 1
+	cfg.DryRun = true
+	manager := NewManager(cfg, api, stateManager)
+
+	if err := manager.ConsolidateWildcards(context.Background()); err != nil {
+		t.Fatalf("ConsolidateWildcards() error = %v", err)
+	}
+	if records := api.Records("example.com"); len(records) != 2 {
+		t.Errorf("records = %v, want them untouched", records)
+	}
+	if _, ok := stateManager.Wildcard("example.com"); ok {
+		t.Error("Wildcard(example.com) persisted in dry run")
+	}
+}
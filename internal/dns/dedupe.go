@@ -0,0 +1,64 @@
+package dns
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/audit"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+// handleDuplicates flags duplicate A records for a hostname and, with DEDUPE_RECORDS,
+// deletes all of them except the one pointing at ip (or the one that gets updated).
+// With dryRun, the deletions are only logged.
+func (m *Manager) handleDuplicates(session netcup.DnsSession, index aRecordIndex, hostname, domain, subdomain, ip, source string, dryRun bool) error {
+	duplicates := index.duplicates(subdomain, ip, m.recordID(hostname))
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	if !m.config.DedupeRecords {
+		log.Printf("Warning: Found %d duplicate A records for %s, set DEDUPE_RECORDS=true to remove them", len(duplicates), hostname)
+		m.notifier.SendError(fmt.Sprintf("Found %d duplicate A records for %s", len(duplicates), hostname))
+		return nil
+	}
+
+	auditEntries := make([]audit.Entry, 0, len(duplicates))
+	for _, record := range duplicates {
+		auditEntries = append(auditEntries, audit.Entry{
+			Action:     audit.ActionDelete,
+			Source:     source,
+			Hostname:   hostname,
+			Domain:     domain,
+			Subdomain:  subdomain,
+			RecordType: "A",
+			Before:     record.Destination,
+			DryRun:     dryRun,
+		})
+	}
+
+	if dryRun {
+		log.Printf("[DRY RUN] Would delete %d duplicate A records for %s", len(duplicates), hostname)
+		m.notifier.SendInfo(fmt.Sprintf("[DRY RUN] Would delete %d duplicate DNS records for %s", len(duplicates), hostname))
+		for _, entry := range auditEntries {
+			m.recordAudit(entry)
+		}
+		return nil
+	}
+
+	log.Printf("Deleting %d duplicate A records for %s", len(duplicates), hostname)
+	if err := netcup.DeleteDnsRecords(session, domain, duplicates); err != nil {
+		for _, entry := range auditEntries {
+			entry.Error = err.Error()
+			m.recordAudit(entry)
+		}
+		m.notifier.SendError(fmt.Sprintf("Failed to delete duplicate DNS records for %s: %v", hostname, err))
+		return fmt.Errorf("failed to delete duplicate records for %s: %w", hostname, err)
+	}
+
+	for _, entry := range auditEntries {
+		m.recordAudit(entry)
+	}
+	m.notifier.SendSuccess(fmt.Sprintf("Deleted %d duplicate DNS records for %s", len(duplicates), hostname))
+	return nil
+}
@@ -0,0 +1,100 @@
+package dns
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestProcessHostInfo_Duplicates(t *testing.T) {
+	tests := []struct {
+		name        string
+		dedupe      bool
+		dryRun      bool
+		wantRecords []string // remaining destinations for app
+	}{
+		{
+			name:        "duplicates are only flagged by default",
+			wantRecords: []string{"198.51.100.1", "203.0.113.1", "198.51.100.2"},
+		},
+		{
+			name:        "dedupe keeps the record matching the expected IP",
+			dedupe:      true,
+			wantRecords: []string{"203.0.113.1"},
+		},
+		{
+			name:        "dry run leaves duplicates in place",
+			dedupe:      true,
+			dryRun:      true,
+			wantRecords: []string{"198.51.100.1", "203.0.113.1", "198.51.100.2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := netcup.NewFakeAPI()
+			api.AddZone("example.com",
+				netcup.DnsRecord{Hostname: "app", Type: "A", Destination: "198.51.100.1"},
+				netcup.DnsRecord{Hostname: "app", Type: "A", Destination: "203.0.113.1"},
+				netcup.DnsRecord{Hostname: "app", Type: "A", Destination: "198.51.100.2"},
+			)
+
+			cfg := testConfig()
+			cfg.DedupeRecords = tt.dedupe
+			cfg.DryRun = tt.dryRun
+			manager := NewManager(cfg, api, nil)
+
+			err := manager.ProcessHostInfo(context.Background(), docker.HostInfo{
+				Hostname:  "app.example.com",
+				Domain:    "example.com",
+				Subdomain: "app",
+			})
+			if err != nil {
+				t.Fatalf("ProcessHostInfo() error = %v", err)
+			}
+
+			records := api.Records("example.com")
+			if len(records) != len(tt.wantRecords) {
+				t.Fatalf("Zone has %d records, want %d: %+v", len(records), len(tt.wantRecords), records)
+			}
+			for i, want := range tt.wantRecords {
+				if records[i].Destination != want {
+					t.Errorf("records[%d].Destination = %v, want %v", i, records[i].Destination, want)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessHostInfo_DuplicatesWithoutMatchingIP(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com",
+		netcup.DnsRecord{Hostname: "app", Type: "A", Destination: "198.51.100.1"},
+		netcup.DnsRecord{Hostname: "app", Type: "A", Destination: "198.51.100.2"},
+	)
+	first := api.Records("example.com")[0]
+
+	cfg := testConfig()
+	cfg.DedupeRecords = true
+	manager := NewManager(cfg, api, nil)
+
+	err := manager.ProcessHostInfo(context.Background(), docker.HostInfo{
+		Hostname:  "app.example.com",
+		Domain:    "example.com",
+		Subdomain: "app",
+	})
+	if err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+
+	// The first record is kept and updated, the other one deleted
+	records := api.Records("example.com")
+	if len(records) != 1 {
+		t.Fatalf("Zone has %d records, want 1: %+v", len(records), records)
+	}
+	if records[0].Id != first.Id || records[0].Destination != "203.0.113.1" {
+		t.Errorf("Record = %+v, want Id %s pointing to 203.0.113.1", records[0], first.Id)
+	}
+}
@@ -0,0 +1,108 @@
+package dns
+
+import (
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+// maxRecentEvents is the number of processed host events kept for diagnostics
+const maxRecentEvents = 100
+
+// Event is a processed host event kept for diagnostics
+type Event struct {
+	Time     time.Time `json:"time"`
+	Hostname string    `json:"hostname"`
+	Action   string    `json:"action"` // "add" or "remove"
+	Stack    string    `json:"stack,omitempty"`
+	Outcome  string    `json:"outcome"` // "applied", "queued", "observed" or the error
+}
+
+// Diagnostics is a snapshot of the manager's internal state for bug reports
+type Diagnostics struct {
+	Paused     bool                       `json:"paused"`
+	KnownHosts []string                   `json:"known_hosts"`
+	Queued     []string                   `json:"queued,omitempty"` // Hostnames queued while paused
+	Records    map[string]state.DNSRecord `json:"records,omitempty"`
+	Unseen     []string                   `json:"unseen,omitempty"` // Records not seen on a running container within LIVENESS_THRESHOLD
+	State      *state.Metrics             `json:"state,omitempty"`
+	Netcup     *netcup.Diagnostics        `json:"netcup,omitempty"`
+	DNSSEC     map[string]bool            `json:"dnssec,omitempty"` // DNSSEC status of the zones seen
+	Events     []Event                    `json:"events"`           // Oldest first
+}
+
+// Diagnostics returns the known hosts, persisted records, state file metrics, Netcup
+// client health and recently processed events
+func (m *Manager) Diagnostics() Diagnostics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	diagnostics := Diagnostics{
+		Paused:     m.paused,
+		KnownHosts: make([]string, 0, len(m.knownHosts)),
+		Events:     append([]Event(nil), m.events...),
+	}
+	for hostname := range m.knownHosts {
+		diagnostics.KnownHosts = append(diagnostics.KnownHosts, hostname)
+	}
+	sort.Strings(diagnostics.KnownHosts)
+	for _, info := range m.queued {
+		diagnostics.Queued = append(diagnostics.Queued, info.Hostname)
+	}
+	if len(m.dnssec) > 0 {
+		diagnostics.DNSSEC = maps.Clone(m.dnssec)
+	}
+
+	if m.stateManager != nil {
+		diagnostics.Records = m.stateManager.GetAllRecords()
+		if m.config.LivenessThreshold > 0 {
+			for _, record := range m.stateManager.NotSeenFor(m.config.LivenessThreshold) {
+				diagnostics.Unseen = append(diagnostics.Unseen, record.Hostname)
+			}
+		}
+		stateMetrics := m.stateManager.Metrics()
+		diagnostics.State = &stateMetrics
+	}
+	if provider, ok := m.client.(netcup.DiagnosticsProvider); ok {
+		netcupDiagnostics := provider.Diagnostics()
+		diagnostics.Netcup = &netcupDiagnostics
+	}
+
+	return diagnostics
+}
+
+// recordEvent remembers a processed host event, dropping the oldest beyond
+// maxRecentEvents. The caller holds m.mu, at least shared.
+func (m *Manager) recordEvent(info docker.HostInfo, outcome string) {
+	action := "add"
+	if info.Remove {
+		action = "remove"
+	}
+
+	m.bookMu.Lock()
+	defer m.bookMu.Unlock()
+
+	m.events = append(m.events, Event{
+		Time:     time.Now(),
+		Hostname: info.Hostname,
+		Action:   action,
+		Stack:    info.Stack(),
+		Outcome:  outcome,
+	})
+	if dropped := len(m.events) - maxRecentEvents; dropped > 0 {
+		m.events = m.events[dropped:]
+	}
+}
+
+// ResetCircuitBreaker closes the Netcup client's circuit breaker so requests resume
+// immediately. It reports whether the circuit was open or half-open.
+func (m *Manager) ResetCircuitBreaker() bool {
+	if resetter, ok := m.client.(netcup.CircuitBreakerResetter); ok {
+		return resetter.ResetCircuitBreaker()
+	}
+	return false
+}
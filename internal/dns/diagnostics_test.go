@@ -0,0 +1,108 @@
+package dns
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestDiagnostics(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	manager := NewManager(testConfig(), api, nil)
+	ctx := context.Background()
+
+	app := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app", ComposeProject: "shop", ComposeService: "web"}
+	if err := manager.ProcessHostInfo(ctx, app); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if err := manager.ProcessHostInfo(ctx, docker.HostInfo{Hostname: "app.missing.com", Domain: "missing.com", Subdomain: "app"}); err == nil {
+		t.Fatal("ProcessHostInfo() for a missing zone succeeded")
+	}
+	manager.Pause()
+	if err := manager.ProcessHostInfo(ctx, docker.HostInfo{Hostname: "web.example.com", Domain: "example.com", Subdomain: "web"}); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+
+	diagnostics := manager.Diagnostics()
+
+	if !diagnostics.Paused {
+		t.Error("Paused = false, want true")
+	}
+	if len(diagnostics.KnownHosts) != 1 || diagnostics.KnownHosts[0] != "app.example.com" {
+		t.Errorf("KnownHosts = %v, want [app.example.com]", diagnostics.KnownHosts)
+	}
+	if len(diagnostics.Queued) != 1 || diagnostics.Queued[0] != "web.example.com" {
+		t.Errorf("Queued = %v, want [web.example.com]", diagnostics.Queued)
+	}
+
+	wantOutcomes := []struct {
+		hostname string
+		stack    string
+		failed   bool
+		outcome  string
+	}{
+		{hostname: "app.example.com", stack: "shop/web", outcome: "applied"},
+		{hostname: "app.missing.com", failed: true},
+		{hostname: "web.example.com", outcome: "queued"},
+	}
+	if len(diagnostics.Events) != len(wantOutcomes) {
+		t.Fatalf("Events = %+v, want %d events", diagnostics.Events, len(wantOutcomes))
+	}
+	for i, want := range wantOutcomes {
+		event := diagnostics.Events[i]
+		if event.Hostname != want.hostname || event.Stack != want.stack || event.Action != "add" {
+			t.Errorf("Events[%d] = %+v, want add of %s", i, event, want.hostname)
+		}
+		if want.failed {
+			if event.Outcome == "applied" || event.Outcome == "" {
+				t.Errorf("Events[%d].Outcome = %q, want the error", i, event.Outcome)
+			}
+		} else if event.Outcome != want.outcome {
+			t.Errorf("Events[%d].Outcome = %q, want %q", i, event.Outcome, want.outcome)
+		}
+	}
+}
+
+func TestRecordEvent_DropsOldest(t *testing.T) {
+	manager := NewManager(testConfig(), netcup.NewFakeAPI(), nil)
+	for i := 0; i < maxRecentEvents+5; i++ {
+		manager.recordEvent(docker.HostInfo{Hostname: "app.example.com"}, "applied")
+	}
+	if len(manager.events) != maxRecentEvents {
+		t.Errorf("len(events) = %d, want %d", len(manager.events), maxRecentEvents)
+	}
+}
+
+func TestResetCircuitBreaker(t *testing.T) {
+	manager := NewManager(testConfig(), netcup.NewFakeAPI(), nil)
+	if manager.ResetCircuitBreaker() {
+		t.Error("ResetCircuitBreaker() without a circuit breaker = true, want false")
+	}
+}
+
+func TestDiagnostics_StateMetrics(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	manager := NewManager(testConfig(), api, stateManager)
+
+	if diagnostics := NewManager(testConfig(), api, nil).Diagnostics(); diagnostics.State != nil {
+		t.Errorf("State = %+v without state persistence, want nil", diagnostics.State)
+	}
+
+	if err := manager.ProcessHostInfo(context.Background(), docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	metrics := manager.Diagnostics().State
+	if metrics == nil || metrics.Records != 1 || metrics.Saves == 0 || metrics.FileSizeBytes == 0 {
+		t.Errorf("State = %+v, want metrics of the saved record", metrics)
+	}
+}
@@ -0,0 +1,92 @@
+package dns
+
+import (
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+// aRecordIndex indexes the A records of a zone by subdomain. A subdomain
+// maps to several records when the zone contains duplicates.
+type aRecordIndex map[string][]netcup.DnsRecord
+
+func indexARecords(records []netcup.DnsRecord) aRecordIndex {
+	index := make(aRecordIndex)
+	for _, record := range records {
+		if record.Type == "A" {
+			index[record.Hostname] = append(index[record.Hostname], record)
+		}
+	}
+	return index
+}
+
+// keep returns the position of the record that is kept for subdomain: the one with the
+// persisted Id, the first one pointing at ip, or the first one overall. It returns -1 if
+// there is no record.
+func (idx aRecordIndex) keep(subdomain, ip, id string) int {
+	records := idx[subdomain]
+	if id != "" {
+		for i, record := range records {
+			if record.Id == id {
+				return i
+			}
+		}
+	}
+	for i, record := range records {
+		if record.Destination == ip {
+			return i
+		}
+	}
+	if len(records) == 0 {
+		return -1
+	}
+	return 0
+}
+
+// diff returns the record to send so that subdomain points at ip. An existing record
+// is modified in place by referencing its Id, so an update never creates a duplicate.
+// needed is false when the record is already in sync; existing is nil for new records.
+// id is the Id persisted for the record, if known.
+func (idx aRecordIndex) diff(subdomain, ip, id string) (change netcup.DnsRecord, existing *netcup.DnsRecord, needed bool) {
+	if i := idx.keep(subdomain, ip, id); i >= 0 {
+		current := idx[subdomain][i]
+		if current.Destination == ip {
+			return netcup.DnsRecord{}, &current, false
+		}
+		change = current
+		change.Destination = ip
+		return change, &current, true
+	}
+
+	return netcup.DnsRecord{
+		Hostname:    subdomain,
+		Type:        "A",
+		Destination: ip,
+		Priority:    "0",
+	}, nil, true
+}
+
+// duplicates returns the A records for subdomain besides the one kept by diff
+func (idx aRecordIndex) duplicates(subdomain, ip, id string) []netcup.DnsRecord {
+	records := idx[subdomain]
+	if len(records) < 2 {
+		return nil
+	}
+
+	kept := idx.keep(subdomain, ip, id)
+	extras := make([]netcup.DnsRecord, 0, len(records)-1)
+	for i, record := range records {
+		if i != kept {
+			extras = append(extras, record)
+		}
+	}
+	return extras
+}
+
+// id returns the Id of the record diff keeps for subdomain if it points at ip, e.g. in
+// the zone returned by an update, or "" if there is none
+func (idx aRecordIndex) id(subdomain, ip, known string) string {
+	i := idx.keep(subdomain, ip, known)
+	if i < 0 || idx[subdomain][i].Destination != ip {
+		return ""
+	}
+	return idx[subdomain][i].Id
+}
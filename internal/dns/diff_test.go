@@ -0,0 +1,115 @@
+package dns
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestARecordIndexDiff(t *testing.T) {
+	records := []netcup.DnsRecord{
+		{Id: "1", Hostname: "app", Type: "A", Destination: "203.0.113.1", Priority: "0"},
+		{Id: "2", Hostname: "www", Type: "A", Destination: "198.51.100.1", Priority: "0"},
+		{Id: "3", Hostname: "mail", Type: "MX", Destination: "mx.example.com", Priority: "10"},
+	}
+
+	tests := []struct {
+		name         string
+		subdomain    string
+		wantNeeded   bool
+		wantExisting bool
+		wantID       string
+	}{
+		{name: "in sync", subdomain: "app", wantNeeded: false, wantExisting: true},
+		{name: "update references existing Id", subdomain: "www", wantNeeded: true, wantExisting: true, wantID: "2"},
+		{name: "new record has no Id", subdomain: "api", wantNeeded: true, wantExisting: false, wantID: ""},
+		{name: "non-A record is ignored", subdomain: "mail", wantNeeded: true, wantExisting: false, wantID: ""},
+	}
+
+	index := indexARecords(records)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change, existing, needed := index.diff(tt.subdomain, "203.0.113.1", "")
+			if needed != tt.wantNeeded {
+				t.Errorf("needed = %v, want %v", needed, tt.wantNeeded)
+			}
+			if (existing != nil) != tt.wantExisting {
+				t.Errorf("existing = %v, want existing %v", existing, tt.wantExisting)
+			}
+			if !needed {
+				return
+			}
+			if change.Id != tt.wantID {
+				t.Errorf("change.Id = %q, want %q", change.Id, tt.wantID)
+			}
+			if change.Hostname != tt.subdomain || change.Type != "A" || change.Destination != "203.0.113.1" {
+				t.Errorf("change = %+v, want A record %s -> 203.0.113.1", change, tt.subdomain)
+			}
+		})
+	}
+}
+
+func TestProcessHostInfo_UpdateModifiesExistingRecord(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com", netcup.DnsRecord{Hostname: "app", Type: "A", Destination: "198.51.100.1"})
+	before := api.Records("example.com")[0]
+
+	manager := NewManager(testConfig(), api, nil)
+	err := manager.ProcessHostInfo(context.Background(), docker.HostInfo{
+		Hostname:  "app.example.com",
+		Domain:    "example.com",
+		Subdomain: "app",
+	})
+	if err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+
+	records := api.Records("example.com")
+	if len(records) != 1 {
+		t.Fatalf("Zone has %d records after update, want 1 (no duplicate)", len(records))
+	}
+	if records[0].Id != before.Id || records[0].Destination != "203.0.113.1" {
+		t.Errorf("Record = %+v, want Id %s pointing to 203.0.113.1", records[0], before.Id)
+	}
+}
+
+func TestARecordIndexDuplicates(t *testing.T) {
+	records := []netcup.DnsRecord{
+		{Id: "1", Hostname: "app", Type: "A", Destination: "198.51.100.1"},
+		{Id: "2", Hostname: "app", Type: "A", Destination: "203.0.113.1"},
+		{Id: "3", Hostname: "app", Type: "A", Destination: "198.51.100.2"},
+		{Id: "4", Hostname: "www", Type: "A", Destination: "198.51.100.1"},
+	}
+
+	tests := []struct {
+		name      string
+		subdomain string
+		ip        string
+		id        string
+		wantIDs   []string
+	}{
+		{name: "keeps record matching IP", subdomain: "app", ip: "203.0.113.1", wantIDs: []string{"1", "3"}},
+		{name: "keeps first record without match", subdomain: "app", ip: "192.0.2.1", wantIDs: []string{"2", "3"}},
+		{name: "keeps record with persisted Id", subdomain: "app", ip: "203.0.113.1", id: "3", wantIDs: []string{"1", "2"}},
+		{name: "ignores stale persisted Id", subdomain: "app", ip: "203.0.113.1", id: "9", wantIDs: []string{"1", "3"}},
+		{name: "single record has no duplicates", subdomain: "www", ip: "203.0.113.1", wantIDs: nil},
+		{name: "unknown subdomain", subdomain: "api", ip: "203.0.113.1", wantIDs: nil},
+	}
+
+	index := indexARecords(records)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := index.duplicates(tt.subdomain, tt.ip, tt.id)
+			if len(got) != len(tt.wantIDs) {
+				t.Fatalf("duplicates() = %+v, want Ids %v", got, tt.wantIDs)
+			}
+			for i, id := range tt.wantIDs {
+				if got[i].Id != id {
+					t.Errorf("duplicates()[%d].Id = %v, want %v", i, got[i].Id, id)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+// ErrDNSSECZone is returned for changes to a DNSSEC-signed zone under DNSSEC_POLICY=refuse
+var ErrDNSSECZone = errors.New("zone is DNSSEC-signed")
+
+// checkDNSSEC tracks the DNSSEC status of a zone and applies DNSSEC_POLICY to a change
+// in it. Under "warn", a zone is warned about once when it is first seen signed; under
+// "refuse", the change is refused. Safe while m.mu is held shared.
+func (m *Manager) checkDNSSEC(domain string, zone *netcup.DnsZoneData) error {
+	m.bookMu.Lock()
+	wasSigned, seen := m.dnssec[domain]
+	m.dnssec[domain] = zone.DnsSecStatus
+	m.bookMu.Unlock()
+
+	if m.stateManager != nil {
+		if err := m.stateManager.SetZoneDNSSEC(domain, zone.DnsSecStatus); err != nil {
+			log.Printf("Warning: Failed to persist DNSSEC status of %s: %v", domain, err)
+		}
+	}
+
+	if !zone.DnsSecStatus {
+		if wasSigned {
+			log.Printf("Zone %s is no longer DNSSEC-signed", domain)
+		}
+		return nil
+	}
+
+	if m.config.DNSSECPolicy == config.DNSSECRefuse {
+		m.notifier.SendError(fmt.Sprintf("Refused DNS changes in %s: zone is DNSSEC-signed (DNSSEC_POLICY=refuse)", domain))
+		return fmt.Errorf("%w: %s", ErrDNSSECZone, domain)
+	}
+	if !seen || !wasSigned {
+		log.Printf("Warning: Zone %s is DNSSEC-signed; frequent record changes may briefly fail validation while signatures are renewed", domain)
+		m.notifier.SendInfo(fmt.Sprintf("Zone %s is DNSSEC-signed, record changes may briefly fail validation", domain))
+	}
+	return nil
+}
+
+// checkZoneDNSSEC fetches a zone to apply DNSSEC_POLICY=refuse to a change in it, for
+// code paths that do not fetch the zone anyway. Under "warn" nothing is fetched.
+func (m *Manager) checkZoneDNSSEC(session netcup.DnsSession, domain string) error {
+	if m.config.DNSSECPolicy != config.DNSSECRefuse {
+		return nil
+	}
+	zone, err := session.InfoDnsZone(domain)
+	if err != nil {
+		return fmt.Errorf("failed to get DNS zone for %s: %w", domain, err)
+	}
+	return m.checkDNSSEC(domain, zone)
+}
@@ -0,0 +1,90 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestDNSSECPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      string
+		signed      bool
+		wantRecords int
+		wantErr     bool
+	}{
+		{name: "unsigned zone", policy: config.DNSSECRefuse, wantRecords: 1},
+		{name: "warn on signed zone", policy: config.DNSSECWarn, signed: true, wantRecords: 1},
+		{name: "default warns", policy: "", signed: true, wantRecords: 1},
+		{name: "refuse signed zone", policy: config.DNSSECRefuse, signed: true, wantRecords: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := netcup.NewFakeAPI()
+			api.AddZone("example.com")
+			api.SetDNSSEC("example.com", tt.signed)
+			cfg := testConfig()
+			cfg.DNSSECPolicy = tt.policy
+			manager := NewManager(cfg, api, nil)
+
+			info := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+			err := manager.ProcessHostInfo(context.Background(), info)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessHostInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrDNSSECZone) {
+				t.Errorf("ProcessHostInfo() error = %v, want ErrDNSSECZone", err)
+			}
+			if got := len(api.Records("example.com")); got != tt.wantRecords {
+				t.Errorf("Zone has %d records, want %d", got, tt.wantRecords)
+			}
+			if got := manager.Diagnostics().DNSSEC["example.com"]; got != tt.signed {
+				t.Errorf("Diagnostics().DNSSEC[example.com] = %v, want %v", got, tt.signed)
+			}
+		})
+	}
+}
+
+func TestDNSSECPolicy_RefuseRemoval(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com", netcup.DnsRecord{Hostname: "app", Type: "A", Destination: "203.0.113.1"})
+	api.SetDNSSEC("example.com", true)
+	cfg := testConfig()
+	cfg.DNSSECPolicy = config.DNSSECRefuse
+	manager := NewManager(cfg, api, nil)
+
+	info := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app", Remove: true}
+	if err := manager.ProcessHostInfo(context.Background(), info); !errors.Is(err, ErrDNSSECZone) {
+		t.Errorf("ProcessHostInfo() error = %v, want ErrDNSSECZone", err)
+	}
+	if got := len(api.Records("example.com")); got != 1 {
+		t.Errorf("Zone has %d records, want 1", got)
+	}
+}
+
+func TestDNSSECStatusPersisted(t *testing.T) {
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	api.SetDNSSEC("example.com", true)
+	manager := NewManager(testConfig(), api, stateManager)
+
+	info := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if status, ok := stateManager.Zones()["example.com"]; !ok || !status.DNSSEC {
+		t.Errorf("Zones()[example.com] = %+v, want DNSSEC-signed", status)
+	}
+}
@@ -0,0 +1,188 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+// Drift describes a managed hostname whose actual DNS record differs from the expected one
+type Drift struct {
+	Hostname   string
+	Domain     string
+	Subdomain  string
+	ExpectedIP string
+	ActualIP   string // empty if the record is missing
+}
+
+func (d Drift) String() string {
+	if d.ActualIP == "" {
+		return fmt.Sprintf("%s missing (expected %s)", d.Hostname, d.ExpectedIP)
+	}
+	return fmt.Sprintf("%s points to %s (expected %s)", d.Hostname, d.ActualIP, d.ExpectedIP)
+}
+
+// RunDriftMonitor periodically compares expected and actual records in observe mode.
+// It blocks until ctx is done.
+func (m *Manager) RunDriftMonitor(ctx context.Context, interval time.Duration) {
+	log.Printf("Observe mode: checking for DNS drift every %s", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.mu.Lock()
+			if _, err := m.checkDrift(ctx); err != nil {
+				log.Printf("Warning: Drift check failed: %v", err)
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+// observeHost registers (or unregisters) an expected host in observe mode and
+// immediately checks it for drift. It never writes DNS. Callers must hold m.mu.
+func (m *Manager) observeHost(ctx context.Context, info docker.HostInfo) error {
+	if info.Remove {
+		delete(m.observed, info.Hostname)
+		delete(m.drifted, info.Hostname)
+		log.Printf("Observe mode: no longer expecting DNS for %s", info.Hostname)
+		return nil
+	}
+
+	if _, exists := m.observed[info.Hostname]; exists {
+		return nil
+	}
+	m.observed[info.Hostname] = info
+	log.Printf("Observe mode: expecting DNS for %s", info.Hostname)
+
+	_, err := m.checkDrift(ctx)
+	return err
+}
+
+// checkDrift compares all expected hosts (observed containers and persisted state)
+// with the actual records, notifying about new and resolved drift. Callers must hold m.mu.
+func (m *Manager) checkDrift(ctx context.Context) (drifts []Drift, err error) {
+	ctx, span := tracer.Start(ctx, "dns.checkDrift")
+	defer func() { endSpan(span, err) }()
+
+	expected := make(map[string]docker.HostInfo, len(m.observed))
+	for hostname, info := range m.observed {
+		expected[hostname] = info
+	}
+	if m.stateManager != nil {
+		for hostname, record := range m.stateManager.GetAllRecords() {
+			if _, exists := expected[hostname]; !exists {
+				info := docker.HostInfo{Hostname: hostname, Domain: record.Domain, Subdomain: record.Subdomain, Destination: record.Destination}
+				if m.config.PublishContainerIP() {
+					info.IP = record.IP
+				}
+				expected[hostname] = info
+			}
+		}
+	}
+
+	if len(expected) == 0 {
+		return nil, nil
+	}
+
+	var hostIP string
+	if !m.config.PublishContainerIP() {
+		hostIP, err = m.resolveHostIP()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get host IP: %w", err)
+		}
+	}
+
+	session, err := m.client.Login(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to login to Netcup: %w", err)
+	}
+	defer session.Logout()
+
+	hostsByDomain := make(map[string][]docker.HostInfo)
+	for _, info := range expected {
+		hostsByDomain[info.Domain] = append(hostsByDomain[info.Domain], info)
+	}
+
+	current := make(map[string]string) // hostname -> actual IP
+	for domain, hosts := range hostsByDomain {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		records, err := session.InfoDnsRecords(domain)
+		if err != nil {
+			log.Printf("Warning: Failed to get DNS records for %s during drift check: %v", domain, err)
+			continue
+		}
+
+		actual := make(map[string]string)
+		for _, record := range *records {
+			if record.Type == "A" {
+				actual[record.Hostname] = record.Destination
+			}
+		}
+
+		for _, info := range hosts {
+			expectedIP, err := m.hostAddress(info, hostIP)
+			if err != nil {
+				log.Printf("Warning: Skipping drift check of %s: %v", info.Hostname, err)
+				continue
+			}
+
+			actualIP := actual[info.Subdomain]
+			if actualIP == expectedIP {
+				continue
+			}
+			drifts = append(drifts, Drift{
+				Hostname:   info.Hostname,
+				Domain:     info.Domain,
+				Subdomain:  info.Subdomain,
+				ExpectedIP: expectedIP,
+				ActualIP:   actualIP,
+			})
+			current[info.Hostname] = actualIP
+		}
+	}
+
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].Hostname < drifts[j].Hostname })
+
+	// Notify only about changes since the last check to avoid repeating the same alert
+	var newDrift, resolved []string
+	for _, drift := range drifts {
+		if previous, known := m.drifted[drift.Hostname]; !known || previous != drift.ActualIP {
+			newDrift = append(newDrift, drift.String())
+		}
+	}
+	for hostname := range m.drifted {
+		if _, stillDrifted := current[hostname]; !stillDrifted {
+			resolved = append(resolved, hostname)
+		}
+	}
+	sort.Strings(resolved)
+	m.drifted = current
+
+	if len(newDrift) > 0 {
+		log.Printf("Drift detected: %s", strings.Join(newDrift, "; "))
+		m.notifier.SendError(fmt.Sprintf("DNS drift detected: %s", strings.Join(newDrift, "; ")))
+	}
+	if len(resolved) > 0 {
+		log.Printf("Drift resolved: %s", strings.Join(resolved, ", "))
+		m.notifier.SendSuccess(fmt.Sprintf("DNS drift resolved: %s", strings.Join(resolved, ", ")))
+	}
+	log.Printf("Drift check complete: %d expected records, %d drifted", len(expected), len(drifts))
+
+	return drifts, nil
+}
@@ -0,0 +1,127 @@
+package dns
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func observeConfig() *config.Config {
+	cfg := testConfig()
+	cfg.Mode = config.ModeObserve
+	return cfg
+}
+
+func TestObserveMode_NeverWrites(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com", netcup.DnsRecord{Hostname: "www", Type: "A", Destination: "198.51.100.1"})
+	manager := NewManager(observeConfig(), api, nil)
+
+	hosts := []docker.HostInfo{
+		{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"},
+		{Hostname: "www.example.com", Domain: "example.com", Subdomain: "www"},
+	}
+
+	ctx := context.Background()
+	if err := manager.SyncHosts(ctx, hosts); err != nil {
+		t.Fatalf("SyncHosts() error = %v", err)
+	}
+	if err := manager.ProcessHostInfo(ctx, docker.HostInfo{Hostname: "api.example.com", Domain: "example.com", Subdomain: "api"}); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if err := manager.ReconcileFromState(ctx); err != nil {
+		t.Fatalf("ReconcileFromState() error = %v", err)
+	}
+
+	if api.CallCount("updateDnsRecords") != 0 || api.CallCount("updateDnsZone") != 0 {
+		t.Error("Observe mode must never write DNS")
+	}
+}
+
+func TestCheckDrift(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com",
+		netcup.DnsRecord{Hostname: "www", Type: "A", Destination: "198.51.100.1"},
+		netcup.DnsRecord{Hostname: "ok", Type: "A", Destination: "203.0.113.1"},
+	)
+	manager := NewManager(observeConfig(), api, nil)
+	manager.observed["app.example.com"] = docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	manager.observed["www.example.com"] = docker.HostInfo{Hostname: "www.example.com", Domain: "example.com", Subdomain: "www"}
+	manager.observed["ok.example.com"] = docker.HostInfo{Hostname: "ok.example.com", Domain: "example.com", Subdomain: "ok"}
+
+	drifts, err := manager.checkDrift(context.Background())
+	if err != nil {
+		t.Fatalf("checkDrift() error = %v", err)
+	}
+
+	if len(drifts) != 2 {
+		t.Fatalf("checkDrift() returned %d drifts, want 2: %v", len(drifts), drifts)
+	}
+	if drifts[0].Hostname != "app.example.com" || drifts[0].ActualIP != "" {
+		t.Errorf("drifts[0] = %v, want missing app.example.com", drifts[0])
+	}
+	if drifts[1].Hostname != "www.example.com" || drifts[1].ActualIP != "198.51.100.1" {
+		t.Errorf("drifts[1] = %v, want www.example.com pointing to 198.51.100.1", drifts[1])
+	}
+	if len(manager.drifted) != 2 {
+		t.Errorf("drifted = %v, want 2 entries", manager.drifted)
+	}
+
+	// Once the record is fixed externally, the drift is resolved
+	session, _ := api.Login(context.Background())
+	records, _ := session.InfoDnsRecords("example.com")
+	www := (*records)[0]
+	www.Destination = "203.0.113.1"
+	session.UpdateDnsRecords("example.com", &[]netcup.DnsRecord{www})
+
+	drifts, err = manager.checkDrift(context.Background())
+	if err != nil {
+		t.Fatalf("checkDrift() error = %v", err)
+	}
+	if len(drifts) != 1 {
+		t.Errorf("checkDrift() after fix returned %d drifts, want 1", len(drifts))
+	}
+	if _, stillDrifted := manager.drifted["www.example.com"]; stillDrifted {
+		t.Error("www.example.com still marked as drifted after fix")
+	}
+}
+
+func TestObserveHost_Remove(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	manager := NewManager(observeConfig(), api, nil)
+
+	info := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if _, ok := manager.drifted["app.example.com"]; !ok {
+		t.Error("Missing record not reported as drift")
+	}
+
+	info.Remove = true
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() remove error = %v", err)
+	}
+	if _, ok := manager.observed["app.example.com"]; ok {
+		t.Error("Host still observed after removal")
+	}
+	if _, ok := manager.drifted["app.example.com"]; ok {
+		t.Error("Host still drifted after removal")
+	}
+}
+
+func TestDrift_String(t *testing.T) {
+	missing := Drift{Hostname: "app.example.com", ExpectedIP: "203.0.113.1"}
+	if got := missing.String(); got != "app.example.com missing (expected 203.0.113.1)" {
+		t.Errorf("String() = %v", got)
+	}
+
+	wrong := Drift{Hostname: "app.example.com", ExpectedIP: "203.0.113.1", ActualIP: "198.51.100.1"}
+	if got := wrong.String(); got != "app.example.com points to 198.51.100.1 (expected 203.0.113.1)" {
+		t.Errorf("String() = %v", got)
+	}
+}
@@ -0,0 +1,111 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+// ExpirySweepInterval is how often records are checked for an ended lifetime
+const ExpirySweepInterval = time.Minute
+
+// trackExpiry starts or ends the lifetime of a published record according to the
+// netcup.expires-in label of its container. A record with a lifetime that is not
+// persisted yet, e.g. one already in sync, is persisted first so the sweeper finds it.
+func (m *Manager) trackExpiry(info docker.HostInfo, ip string) {
+	if m.stateManager == nil {
+		return
+	}
+	if _, ok := m.stateManager.GetRecord(info.Hostname); !ok && info.ExpiresIn > 0 {
+		if err := m.stateManager.UpdateRecordWithOrigin(info.Hostname, info.Domain, info.Subdomain, ip, "A", recordOrigin(info)); err != nil {
+			log.Printf("Warning: Failed to persist DNS state for %s: %v", info.Hostname, err)
+			return
+		}
+	}
+	if err := m.stateManager.SetExpiry(info.Hostname, info.ContainerID, info.ExpiresIn); err != nil {
+		log.Printf("Warning: Failed to persist expiry of %s: %v", info.Hostname, err)
+	}
+}
+
+// expiredFor reports whether the record of info expired for its container, which may
+// not publish it again. A new container, e.g. of a new deployment, starts a new lifetime.
+func (m *Manager) expiredFor(info docker.HostInfo) bool {
+	if m.stateManager == nil || !m.stateManager.IsExpired(info.Hostname, info.ContainerID) {
+		return false
+	}
+	log.Printf("DNS record for %s expired, not publishing it again for container %s", info.Hostname, info.ContainerName)
+	return true
+}
+
+// SweepExpired deletes the records whose lifetime ended and returns how many were deleted
+func (m *Manager) SweepExpired(ctx context.Context) (int, error) {
+	if m.stateManager == nil || m.config.ObserveMode() {
+		return 0, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Sweeping deletes DNS records, skip it until writes are resumed
+	if m.paused {
+		return 0, nil
+	}
+
+	var errs []error
+	swept := 0
+	for _, record := range m.stateManager.ExpiredRecords(time.Now()) {
+		select {
+		case <-ctx.Done():
+			return swept, ctx.Err()
+		default:
+		}
+
+		log.Printf("DNS record for %s expired at %s, deleting it", record.Hostname, record.ExpiresAt.Format(time.RFC3339))
+		info := docker.HostInfo{
+			ContainerID:    record.ContainerID,
+			ContainerName:  record.Container,
+			Hostname:       record.Hostname,
+			Domain:         record.Domain,
+			Subdomain:      record.Subdomain,
+			DockerHost:     record.DockerHost,
+			ComposeProject: record.ComposeProject,
+			ComposeService: record.ComposeService,
+			Tags:           record.Tags,
+		}
+		if err := m.removeHost(ctx, info, "expiry"); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", record.Hostname, err))
+			continue
+		}
+		if err := m.stateManager.MarkExpired(record.Hostname, record.ContainerID); err != nil {
+			log.Printf("Warning: Failed to persist expiry of %s: %v", record.Hostname, err)
+		}
+		swept++
+	}
+
+	return swept, errors.Join(errs...)
+}
+
+// RunExpirySweeper deletes records whose lifetime ended every ExpirySweepInterval. It
+// blocks until ctx is done.
+func (m *Manager) RunExpirySweeper(ctx context.Context) {
+	ticker := time.NewTicker(ExpirySweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if swept, err := m.SweepExpired(ctx); err != nil {
+			log.Printf("Warning: Deleting expired records failed: %v", err)
+		} else if swept > 0 {
+			log.Printf("Deleted %d expired DNS records", swept)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package dns
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestSweepExpired(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	manager := NewManager(testConfig(), api, stateManager)
+	ctx := context.Background()
+
+	preview := docker.HostInfo{
+		ContainerID:   "abc123",
+		ContainerName: "preview",
+		Hostname:      "pr-1.example.com",
+		Domain:        "example.com",
+		Subdomain:     "pr-1",
+		ExpiresIn:     time.Nanosecond,
+	}
+	if err := manager.ProcessHostInfo(ctx, preview); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if record, _ := stateManager.GetRecord("pr-1.example.com"); record.ExpiresAt == nil || record.ContainerID != "abc123" {
+		t.Fatalf("Persisted record = %+v, want an expiry for abc123", record)
+	}
+
+	swept, err := manager.SweepExpired(ctx)
+	if err != nil {
+		t.Fatalf("SweepExpired() error = %v", err)
+	}
+	if swept != 1 || len(api.Records("example.com")) != 0 {
+		t.Fatalf("SweepExpired() swept %d, zone has %d records, want the record deleted", swept, len(api.Records("example.com")))
+	}
+
+	// The lingering container must not publish the record again
+	if err := manager.ProcessHostInfo(ctx, preview); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if len(api.Records("example.com")) != 0 {
+		t.Error("Expired record was published again for the same container")
+	}
+
+	// A new deployment starts a new lifetime
+	redeployed := preview
+	redeployed.ContainerID = "def456"
+	redeployed.ExpiresIn = time.Hour
+	if err := manager.ProcessHostInfo(ctx, redeployed); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if len(api.Records("example.com")) != 1 {
+		t.Error("Record was not published for the new container")
+	}
+	if swept, _ := manager.SweepExpired(ctx); swept != 0 {
+		t.Errorf("SweepExpired() swept %d records before their lifetime ended", swept)
+	}
+}
+
+func TestSweepExpired_Paused(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	manager := NewManager(testConfig(), api, stateManager)
+
+	info := docker.HostInfo{ContainerID: "abc123", Hostname: "pr-1.example.com", Domain: "example.com", Subdomain: "pr-1", ExpiresIn: time.Nanosecond}
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+
+	manager.Pause()
+	if swept, err := manager.SweepExpired(context.Background()); err != nil || swept != 0 {
+		t.Errorf("SweepExpired() = %d, %v while paused, want nothing swept", swept, err)
+	}
+	if len(api.Records("example.com")) != 1 {
+		t.Error("Record deleted while paused")
+	}
+}
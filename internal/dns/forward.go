@@ -0,0 +1,79 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/forward"
+)
+
+// forwardTimeout bounds a single request to the owning instance
+const forwardTimeout = 30 * time.Second
+
+// newForwardClient returns the client for ZONE_FORWARD_URL, or nil if hosts outside
+// OWNED_ZONES are skipped
+func newForwardClient(url, token string) *forward.Client {
+	if url == "" {
+		return nil
+	}
+	return &forward.Client{URL: url, Token: token, Client: &http.Client{Timeout: forwardTimeout}}
+}
+
+// OwnsZone reports whether this instance writes the records of domain
+func (m *Manager) OwnsZone(domain string) bool {
+	return m.config.OwnsZone(domain)
+}
+
+// forwardHost hands a host outside OWNED_ZONES to the instance owning its zone, pointing
+// at this instance's destination, or skips it without ZONE_FORWARD_URL
+func (m *Manager) forwardHost(ctx context.Context, info docker.HostInfo) error {
+	if m.forward == nil {
+		log.Printf("Skipping %s, %s is not in OWNED_ZONES", info.Hostname, info.Domain)
+		return nil
+	}
+
+	var ip string
+	if !info.Remove {
+		var err error
+		if ip, err = m.destinationFor(info); err != nil {
+			if m.skipsPrivateIP(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to get destination: %w", err)
+		}
+	}
+
+	if m.config.DryRun {
+		log.Printf("[DRY RUN] Would forward %s to the instance owning %s", info.Hostname, info.Domain)
+		return nil
+	}
+	if err := m.forward.Send(ctx, forward.NewHost(m.config.ForwardInstance, info, ip)); err != nil {
+		m.notifier.SendError(fmt.Sprintf("Failed to forward %s to the instance owning %s: %v", info.Hostname, info.Domain, err))
+		return err
+	}
+	log.Printf("Forwarded %s to the instance owning %s", info.Hostname, info.Domain)
+	return nil
+}
+
+// forwardForeign forwards the hosts outside OWNED_ZONES and returns the others
+func (m *Manager) forwardForeign(ctx context.Context, hosts []docker.HostInfo) []docker.HostInfo {
+	if len(m.config.OwnedZones) == 0 {
+		return hosts
+	}
+
+	owned := make([]docker.HostInfo, 0, len(hosts))
+	for _, info := range hosts {
+		if m.config.OwnsZone(info.Domain) {
+			owned = append(owned, info)
+			continue
+		}
+		if err := m.forwardHost(ctx, info); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	return owned
+}
@@ -0,0 +1,78 @@
+package dns
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/forward"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestOwnedZones_Forward(t *testing.T) {
+	// Instance A owns example.com, instance B owns other.org and forwards the rest to A
+	apiA := netcup.NewFakeAPI()
+	apiA.AddZone("example.com")
+	cfgA := testConfig()
+	cfgA.OwnedZones = []string{"example.com"}
+	ownerA := NewManager(cfgA, apiA, nil)
+	server := httptest.NewServer(forward.NewHandler(ownerA, "secret"))
+	t.Cleanup(server.Close)
+
+	apiB := netcup.NewFakeAPI()
+	apiB.AddZone("example.com")
+	apiB.AddZone("other.org")
+	cfgB := testConfig()
+	cfgB.HostIP = "198.51.100.2"
+	cfgB.OwnedZones = []string{"other.org"}
+	cfgB.ForwardURL = server.URL
+	cfgB.ForwardToken = "secret"
+	cfgB.ForwardInstance = "host-b"
+	instanceB := NewManager(cfgB, apiB, nil)
+
+	hosts := []docker.HostInfo{
+		{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app", ContainerName: "app"},
+		{Hostname: "www.other.org", Domain: "other.org", Subdomain: "www", ContainerName: "www"},
+	}
+	if err := instanceB.SyncHosts(context.Background(), hosts); err != nil {
+		t.Fatalf("SyncHosts() error = %v", err)
+	}
+
+	if records := apiB.Records("example.com"); len(records) != 0 {
+		t.Errorf("Instance B wrote %v to example.com, which it does not own", records)
+	}
+	if records := apiB.Records("other.org"); len(records) != 1 {
+		t.Errorf("Instance B has %d records in other.org, want 1", len(records))
+	}
+	records := apiA.Records("example.com")
+	if len(records) != 1 || records[0].Hostname != "app" || records[0].Destination != "198.51.100.2" {
+		t.Errorf("Instance A records = %+v, want app -> instance B's address", records)
+	}
+
+	// Removals are forwarded as well, and instance A recognizes the forwarding container
+	removal := hosts[0]
+	removal.Remove = true
+	if err := instanceB.ProcessHostInfo(context.Background(), removal); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if records := apiA.Records("example.com"); len(records) != 0 {
+		t.Errorf("Instance A records = %+v after forwarded removal, want none", records)
+	}
+}
+
+func TestOwnedZones_SkipWithoutForward(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	cfg := testConfig()
+	cfg.OwnedZones = []string{"other.org"}
+	manager := NewManager(cfg, api, nil)
+
+	info := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if records := api.Records("example.com"); len(records) != 0 {
+		t.Errorf("Records = %+v, want none outside OWNED_ZONES", records)
+	}
+}
@@ -0,0 +1,116 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+// HeartbeatRecordName is the name of the TXT heartbeat record in each heartbeat domain
+const HeartbeatRecordName = "_companion-heartbeat"
+
+// RunHeartbeat publishes the heartbeat record every HEARTBEAT_INTERVAL_MIN minutes,
+// starting immediately, until ctx is done
+func (m *Manager) RunHeartbeat(ctx context.Context, version string) {
+	ticker := time.NewTicker(time.Duration(m.config.HeartbeatInterval) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		if err := m.UpdateHeartbeat(ctx, version, time.Now()); err != nil {
+			log.Printf("Warning: Updating heartbeat record failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// UpdateHeartbeat sets the heartbeat record of every heartbeat domain to the time now and
+// the companion version, e.g. "ts=2024-05-01T12:00:00Z version=v1.4.0". Heartbeats are
+// not audited and stop while DNS writes are paused, so a stale heartbeat also reveals a
+// paused companion.
+func (m *Manager) UpdateHeartbeat(ctx context.Context, version string, now time.Time) (err error) {
+	ctx, span := tracer.Start(ctx, "dns.UpdateHeartbeat")
+	defer func() { endSpan(span, err) }()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.paused {
+		return nil
+	}
+
+	value := fmt.Sprintf("ts=%s version=%s", now.UTC().Format(time.RFC3339), version)
+	var errs []error
+	for _, domain := range m.config.HeartbeatDomains {
+		if err := m.updateHeartbeat(ctx, domain, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", domain, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) updateHeartbeat(ctx context.Context, domain, value string) error {
+	hostname := HeartbeatRecordName + "." + domain
+	lock := m.hostLock(hostname)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if m.config.DryRun {
+		log.Printf("[DRY RUN] Would set heartbeat record %s to %q", hostname, value)
+		return nil
+	}
+
+	session, err := m.client.Login(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to login to Netcup: %w", err)
+	}
+	defer session.Logout()
+
+	record, err := netcup.NewTXTRecord(HeartbeatRecordName, value)
+	if err != nil {
+		return fmt.Errorf("invalid heartbeat record %s: %w", hostname, err)
+	}
+	// The existing record is updated in place so its ID stays stable
+	if _, _, err := netcup.EnsureDnsRecord(session, domain, record); err != nil {
+		return fmt.Errorf("failed to update heartbeat record %s: %w", hostname, err)
+	}
+	return nil
+}
+
+// ReadHeartbeat returns the time of the heartbeat record of domain, e.g. the one of the
+// primary companion watched in standby mode, or the zero time without one
+func (m *Manager) ReadHeartbeat(ctx context.Context, domain string) (time.Time, error) {
+	session, err := m.client.Login(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to login to Netcup: %w", err)
+	}
+	defer session.Logout()
+
+	records, err := session.InfoDnsRecords(domain)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get DNS records: %w", err)
+	}
+	heartbeats := netcup.FindRecords(*records, HeartbeatRecordName, "TXT")
+	if len(heartbeats) == 0 {
+		return time.Time{}, nil
+	}
+	return parseHeartbeat(heartbeats[0].Destination)
+}
+
+// parseHeartbeat returns the time of a heartbeat record value written by UpdateHeartbeat
+func parseHeartbeat(value string) (time.Time, error) {
+	for _, field := range strings.Fields(strings.Trim(value, `"`)) {
+		if ts, ok := strings.CutPrefix(field, "ts="); ok {
+			return time.Parse(time.RFC3339, ts)
+		}
+	}
+	return time.Time{}, fmt.Errorf("heartbeat %q has no timestamp", value)
+}
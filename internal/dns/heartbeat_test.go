@@ -0,0 +1,80 @@
+package dns
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestUpdateHeartbeat(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	cfg := testConfig()
+	cfg.HeartbeatDomains = []string{"example.com"}
+	manager := NewManager(cfg, api, nil)
+	ctx := context.Background()
+
+	first := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, now := range []time.Time{first, first.Add(5 * time.Minute)} {
+		if err := manager.UpdateHeartbeat(ctx, "v1.4.0", now); err != nil {
+			t.Fatalf("UpdateHeartbeat() error = %v", err)
+		}
+	}
+
+	var heartbeats []netcup.DnsRecord
+	for _, record := range api.Records("example.com") {
+		if record.Hostname == HeartbeatRecordName {
+			heartbeats = append(heartbeats, record)
+		}
+	}
+	if len(heartbeats) != 1 {
+		t.Fatalf("heartbeat records = %d, want 1", len(heartbeats))
+	}
+	if want := "ts=2024-05-01T12:05:00Z version=v1.4.0"; heartbeats[0].Destination != want {
+		t.Errorf("heartbeat = %q, want %q", heartbeats[0].Destination, want)
+	}
+}
+
+func TestUpdateHeartbeat_Paused(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	cfg := testConfig()
+	cfg.HeartbeatDomains = []string{"example.com"}
+	manager := NewManager(cfg, api, nil)
+	manager.Pause()
+
+	if err := manager.UpdateHeartbeat(context.Background(), "dev", time.Now()); err != nil {
+		t.Fatalf("UpdateHeartbeat() error = %v", err)
+	}
+	if got := len(api.Records("example.com")); got != 0 {
+		t.Errorf("records while paused = %d, want 0", got)
+	}
+}
+
+func TestReadHeartbeat(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	api.AddZone("example.org")
+	cfg := testConfig()
+	cfg.HeartbeatDomains = []string{"example.com"}
+	manager := NewManager(cfg, api, nil)
+	ctx := context.Background()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := manager.UpdateHeartbeat(ctx, "v1.4.0", now); err != nil {
+		t.Fatalf("UpdateHeartbeat() error = %v", err)
+	}
+
+	got, err := manager.ReadHeartbeat(ctx, "example.com")
+	if err != nil {
+		t.Fatalf("ReadHeartbeat() error = %v", err)
+	}
+	if !got.Equal(now) {
+		t.Errorf("ReadHeartbeat() = %s, want %s", got, now)
+	}
+	if got, err := manager.ReadHeartbeat(ctx, "example.org"); err != nil || !got.IsZero() {
+		t.Errorf("ReadHeartbeat() without heartbeat = %s, %v, want zero time", got, err)
+	}
+}
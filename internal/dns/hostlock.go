@@ -0,0 +1,35 @@
+package dns
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// hostLockPartitions is the number of locks hostnames are hashed onto
+const hostLockPartitions = 64
+
+// hostLock returns the lock serializing the processing of hostname. Hostnames hashed
+// onto the same partition are serialized as well, which costs concurrency only.
+func (m *Manager) hostLock(hostname string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(hostname))
+	return &m.hostLocks[h.Sum32()%hostLockPartitions]
+}
+
+// isKnown reports whether hostname was processed already. Safe while m.mu is held shared.
+func (m *Manager) isKnown(hostname string) bool {
+	m.bookMu.Lock()
+	defer m.bookMu.Unlock()
+	return m.knownHosts[hostname]
+}
+
+// setKnown marks hostname as processed or forgets it. Safe while m.mu is held shared.
+func (m *Manager) setKnown(hostname string, known bool) {
+	m.bookMu.Lock()
+	defer m.bookMu.Unlock()
+	if known {
+		m.knownHosts[hostname] = true
+	} else {
+		delete(m.knownHosts, hostname)
+	}
+}
@@ -0,0 +1,69 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestProcessHostInfo_ConcurrentHosts(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	manager := NewManager(testConfig(), api, nil)
+
+	// Every host is processed twice at once; the second run must wait for the first
+	// and find the host known, so no record is created twice
+	const numHosts = 20
+	var wg sync.WaitGroup
+	for i := 0; i < numHosts; i++ {
+		info := docker.HostInfo{
+			Hostname:  fmt.Sprintf("app%d.example.com", i),
+			Domain:    "example.com",
+			Subdomain: fmt.Sprintf("app%d", i),
+		}
+		for j := 0; j < 2; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+					t.Errorf("ProcessHostInfo(%s) error = %v", info.Hostname, err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for _, record := range api.Records("example.com") {
+		if seen[record.Hostname] {
+			t.Errorf("Record %s created twice", record.Hostname)
+		}
+		seen[record.Hostname] = true
+	}
+	if len(seen) != numHosts {
+		t.Errorf("Zone has %d hosts, want %d", len(seen), numHosts)
+	}
+	if got := api.CallCount("login"); got != numHosts {
+		t.Errorf("login calls = %d, want %d", got, numHosts)
+	}
+}
+
+func TestHostLock(t *testing.T) {
+	manager := NewManager(testConfig(), netcup.NewFakeAPI(), nil)
+
+	if manager.hostLock("app.example.com") != manager.hostLock("app.example.com") {
+		t.Error("hostLock() returned different locks for the same hostname")
+	}
+
+	locks := make(map[*sync.Mutex]bool)
+	for i := 0; i < 100; i++ {
+		locks[manager.hostLock(fmt.Sprintf("app%d.example.com", i))] = true
+	}
+	if len(locks) < 2 {
+		t.Errorf("hostLock() spread 100 hostnames over %d locks, want several", len(locks))
+	}
+}
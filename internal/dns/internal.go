@@ -0,0 +1,62 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/provider"
+)
+
+// NewInternalProvider creates the provider configured by INTERNAL_PROVIDER, or nil if
+// no internal resolver is kept in sync
+func NewInternalProvider(cfg *config.Config) provider.Provider {
+	switch cfg.InternalProvider {
+	case config.InternalProviderHosts:
+		return provider.NewHostsFile(cfg.InternalHostsFile)
+	default:
+		return nil
+	}
+}
+
+// syncInternal points the hostname at INTERNAL_IP at the internal provider, so it
+// resolves to the LAN address inside the network while Netcup carries the public one.
+// Like mirroring, it is independent of the Netcup update and never fails it.
+func (m *Manager) syncInternal(ctx context.Context, hostname, domain, subdomain string, dryRun bool) {
+	if m.internal == nil {
+		return
+	}
+	if dryRun {
+		log.Printf("[DRY RUN] Would set internal DNS record in %s: %s -> %s", m.internal.Name(), hostname, m.config.InternalIP)
+		return
+	}
+
+	if err := m.internal.SetRecord(ctx, domain, subdomain, m.config.InternalIP); err != nil {
+		m.reportInternalError(hostname, err)
+		return
+	}
+	log.Printf("Set internal DNS record in %s: %s -> %s", m.internal.Name(), hostname, m.config.InternalIP)
+}
+
+// removeInternal deletes the hostname at the internal provider, like syncInternal
+func (m *Manager) removeInternal(ctx context.Context, hostname, domain, subdomain string, dryRun bool) {
+	if m.internal == nil {
+		return
+	}
+	if dryRun {
+		log.Printf("[DRY RUN] Would remove internal DNS record from %s: %s", m.internal.Name(), hostname)
+		return
+	}
+
+	if err := m.internal.DeleteRecord(ctx, domain, subdomain); err != nil {
+		m.reportInternalError(hostname, err)
+		return
+	}
+	log.Printf("Removed internal DNS record from %s: %s", m.internal.Name(), hostname)
+}
+
+func (m *Manager) reportInternalError(hostname string, err error) {
+	log.Printf("Warning: Failed to update internal DNS for %s in %s: %v", hostname, m.internal.Name(), err)
+	m.notifier.SendError(fmt.Sprintf("Failed to update internal DNS for %s in %s: %v", hostname, m.internal.Name(), err))
+}
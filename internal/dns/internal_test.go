@@ -0,0 +1,75 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/provider"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestNewInternalProvider(t *testing.T) {
+	if p := NewInternalProvider(&config.Config{}); p != nil {
+		t.Errorf("NewInternalProvider() = %v, want nil when disabled", p)
+	}
+	cfg := &config.Config{InternalProvider: config.InternalProviderHosts, InternalHostsFile: "/etc/pihole/custom.list", InternalIP: "192.168.1.10"}
+	if p := NewInternalProvider(cfg); p == nil || p.Name() != "hosts file /etc/pihole/custom.list" {
+		t.Errorf("NewInternalProvider() = %v, want hosts file", p)
+	}
+}
+
+func TestInternalProvider(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	internal := provider.NewFake()
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	cfg := testConfig()
+	cfg.InternalIP = "192.168.1.10"
+	manager := NewManagerWithOptions(cfg, api, stateManager, &ManagerOptions{Internal: internal})
+	ctx := context.Background()
+
+	app := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	if err := manager.ProcessHostInfo(ctx, app); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if err := manager.SyncHosts(ctx, []docker.HostInfo{{Hostname: "example.com", Domain: "example.com", Subdomain: "@"}}); err != nil {
+		t.Fatalf("SyncHosts() error = %v", err)
+	}
+
+	// Netcup carries the public address, the internal provider the LAN address
+	assertSecondary(t, internal, map[string]string{"app.example.com": cfg.InternalIP, "example.com": cfg.InternalIP})
+	for _, record := range api.Records("example.com") {
+		if record.Destination != cfg.HostIP {
+			t.Errorf("Netcup record %s -> %s, want %s", record.Hostname, record.Destination, cfg.HostIP)
+		}
+	}
+
+	// Reconciliation restores lost internal records
+	internal.DeleteRecord(ctx, "example.com", "app")
+	if err := manager.ReconcileFromState(ctx); err != nil {
+		t.Fatalf("ReconcileFromState() error = %v", err)
+	}
+	assertSecondary(t, internal, map[string]string{"app.example.com": cfg.InternalIP, "example.com": cfg.InternalIP})
+
+	// Failures of the internal provider do not fail the Netcup update
+	internal.Err = errors.New("internal resolver unavailable")
+	web := docker.HostInfo{Hostname: "web.example.com", Domain: "example.com", Subdomain: "web"}
+	if err := manager.ProcessHostInfo(ctx, web); err != nil {
+		t.Fatalf("ProcessHostInfo() with failing internal provider error = %v", err)
+	}
+	internal.Err = nil
+
+	app.Remove = true
+	if err := manager.ProcessHostInfo(ctx, app); err != nil {
+		t.Fatalf("ProcessHostInfo() removal error = %v", err)
+	}
+	assertSecondary(t, internal, map[string]string{"example.com": cfg.InternalIP})
+}
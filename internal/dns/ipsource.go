@@ -0,0 +1,55 @@
+package dns
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/ipsource"
+)
+
+// ipSourceTimeout bounds a single query of the configured IP source
+const ipSourceTimeout = 30 * time.Second
+
+// NewIPSource returns the source of the host IP configured with IP_SOURCE, or nil if
+// the address of the outbound interface is detected
+func NewIPSource(cfg *config.Config) ipsource.Source {
+	switch cfg.IPSource {
+	case config.IPSourceInterface:
+		return &ipsource.Interface{Name: cfg.IPSourceInterface}
+	case config.IPSourceHTTP:
+		return &ipsource.HTTP{URL: cfg.IPSourceURL, Client: &http.Client{Timeout: ipSourceTimeout}}
+	case config.IPSourceCommand:
+		return &ipsource.Command{Command: cfg.IPSourceCommand}
+	case config.IPSourceGateway:
+		return &ipsource.Gateway{Address: cfg.IPSourceGateway, Client: &http.Client{Timeout: ipSourceTimeout}}
+	default:
+		return nil
+	}
+}
+
+// NewOverlaySource returns the source of the overlay address published for
+// OVERLAY_DOMAINS, or nil without overlay domains
+func NewOverlaySource(cfg *config.Config) ipsource.Source {
+	if len(cfg.OverlayDomains) == 0 {
+		return nil
+	}
+	if cfg.OverlayIPSource == config.OverlaySourceInterface {
+		return &ipsource.Interface{Name: cfg.OverlayInterface}
+	}
+	return &ipsource.Tailscale{Socket: cfg.TailscaleSocket}
+}
+
+// hostIPDetector returns the function detecting the host IP from source, or from the
+// outbound interface without one
+func hostIPDetector(source ipsource.Source) func() (string, error) {
+	if source == nil {
+		return func() (string, error) { return detectHostIP() }
+	}
+	return func() (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), ipSourceTimeout)
+		defer cancel()
+		return source.IP(ctx)
+	}
+}
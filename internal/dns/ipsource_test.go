@@ -0,0 +1,77 @@
+package dns
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestIPSource_Command(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	cfg := testConfig()
+	cfg.HostIP = ""
+	cfg.IPSource = config.IPSourceCommand
+	cfg.IPSourceCommand = "echo 203.0.113.7"
+	manager := NewManager(cfg, api, nil)
+
+	info := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	records := api.Records("example.com")
+	if len(records) != 1 || records[0].Destination != "203.0.113.7" {
+		t.Errorf("Records = %+v, want app -> 203.0.113.7", records)
+	}
+}
+
+func TestIPSource_PrivateIPPolicy(t *testing.T) {
+	// Addresses from a source are checked like auto-detected ones
+	cfg := testConfig()
+	cfg.HostIP = ""
+	cfg.IPSource = config.IPSourceCommand
+	cfg.IPSourceCommand = "echo 192.168.1.10"
+	cfg.PrivateIPPolicy = config.PrivateIPSkip
+	manager := NewManager(cfg, netcup.NewFakeAPI(), nil)
+
+	if _, err := manager.HostIP(); err == nil {
+		t.Error("HostIP() succeeded for a private address with PRIVATE_IP_POLICY=skip")
+	}
+}
+
+func TestNewIPSource(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{source: config.IPSourceHost, want: ""},
+		{source: config.IPSourceStatic, want: ""},
+		{source: config.IPSourceInterface, want: "interface eth0"},
+		{source: config.IPSourceHTTP, want: "http https://ip.example"},
+		{source: config.IPSourceCommand, want: "command echo 1.2.3.4"},
+		{source: config.IPSourceGateway, want: "gateway 192.168.178.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.IPSource = tt.source
+			cfg.IPSourceInterface = "eth0"
+			cfg.IPSourceURL = "https://ip.example"
+			cfg.IPSourceCommand = "echo 1.2.3.4"
+			cfg.IPSourceGateway = "192.168.178.1"
+
+			source := NewIPSource(cfg)
+			got := ""
+			if source != nil {
+				got = source.String()
+			}
+			if got != tt.want {
+				t.Errorf("NewIPSource() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
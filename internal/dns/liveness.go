@@ -0,0 +1,65 @@
+package dns
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+// LivenessInterval is how often running containers are scanned for LIVENESS_THRESHOLD
+const LivenessInterval = 15 * time.Minute
+
+// MarkSeen records that the records of the given running hosts are still published by
+// a container. Hosts being removed and hostnames without a state record are ignored.
+func (m *Manager) MarkSeen(hosts []docker.HostInfo) {
+	if m.stateManager == nil {
+		return
+	}
+
+	hostnames := make([]string, 0, len(hosts))
+	for _, info := range hosts {
+		if !info.Remove {
+			hostnames = append(hostnames, info.Hostname)
+		}
+	}
+	if len(hostnames) == 0 {
+		return
+	}
+	if err := m.stateManager.MarkSeen(hostnames...); err != nil {
+		log.Printf("Warning: Failed to record running hosts: %v", err)
+	}
+}
+
+// CheckLiveness returns the records no running container confirmed within
+// LIVENESS_THRESHOLD and notifies about the ones that newly crossed it. Records seen
+// again are notified anew when they cross it the next time.
+func (m *Manager) CheckLiveness() []state.DNSRecord {
+	if m.stateManager == nil || m.config.LivenessThreshold <= 0 {
+		return nil
+	}
+
+	unseen := m.stateManager.NotSeenFor(m.config.LivenessThreshold)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := make(map[string]bool, len(unseen))
+	var newlyUnseen []string
+	for _, record := range unseen {
+		current[record.Hostname] = true
+		if !m.unseen[record.Hostname] {
+			log.Printf("Warning: %s was last seen on a running container %s ago", record.Hostname, time.Since(record.Seen()).Round(time.Minute))
+			newlyUnseen = append(newlyUnseen, record.Hostname)
+		}
+	}
+	m.unseen = current
+
+	if len(newlyUnseen) > 0 {
+		m.notifier.SendError(fmt.Sprintf("No running container published these records within %s: %s", m.config.LivenessThreshold, strings.Join(newlyUnseen, ", ")))
+	}
+	return unseen
+}
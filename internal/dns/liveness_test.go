@@ -0,0 +1,80 @@
+package dns
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestProcessHostInfo_MarksSeen(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	manager := NewManager(testConfig(), api, stateManager)
+
+	info := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	record, _ := stateManager.GetRecord("app.example.com")
+	if record.LastSeen == nil {
+		t.Fatal("LastSeen not set for a published record")
+	}
+	first := *record.LastSeen
+
+	// Events for known hosts are sightings as well
+	time.Sleep(time.Millisecond)
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	record, _ = stateManager.GetRecord("app.example.com")
+	if !record.LastSeen.After(first) {
+		t.Errorf("LastSeen = %v, want a sighting after %v", record.LastSeen, first)
+	}
+}
+
+func TestCheckLiveness(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	cfg := testConfig()
+	cfg.LivenessThreshold = time.Hour
+	manager := NewManager(cfg, api, stateManager)
+
+	hosts := []docker.HostInfo{
+		{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"},
+		{Hostname: "old.example.com", Domain: "example.com", Subdomain: "old"},
+	}
+	if err := manager.SyncHosts(context.Background(), hosts); err != nil {
+		t.Fatalf("SyncHosts() error = %v", err)
+	}
+	if unseen := manager.CheckLiveness(); len(unseen) != 0 {
+		t.Errorf("CheckLiveness() = %v right after the scan, want none", unseen)
+	}
+
+	// Only app is seen again once old is past the threshold
+	manager.config.LivenessThreshold = 50 * time.Millisecond
+	time.Sleep(100 * time.Millisecond)
+	manager.MarkSeen(hosts[:1])
+	unseen := manager.CheckLiveness()
+	if len(unseen) != 1 || unseen[0].Hostname != "old.example.com" {
+		t.Fatalf("CheckLiveness() = %v, want old.example.com", unseen)
+	}
+	if got := manager.Diagnostics().Unseen; len(got) != 1 || got[0] != "old.example.com" {
+		t.Errorf("Diagnostics().Unseen = %v, want old.example.com", got)
+	}
+	if !manager.unseen["old.example.com"] {
+		t.Error("old.example.com not remembered as notified")
+	}
+}
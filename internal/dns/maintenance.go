@@ -0,0 +1,121 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/notification"
+)
+
+// MaintenanceRetryInterval is how long to wait before checking again whether a Netcup
+// maintenance window is over. It doubles with every check up to MaintenanceMaxRetryInterval.
+const MaintenanceRetryInterval = 5 * time.Minute
+
+// MaintenanceMaxRetryInterval caps the wait between checks during long maintenance windows
+const MaintenanceMaxRetryInterval = 30 * time.Minute
+
+// InMaintenance reports whether the Netcup API was found in maintenance and DNS changes
+// are queued until it is over
+func (m *Manager) InMaintenance() bool {
+	m.bookMu.Lock()
+	defer m.bookMu.Unlock()
+	return m.maintenance
+}
+
+// maintenanceError reports whether err is caused by Netcup maintenance. The first such
+// error enters maintenance mode, which is notified once and watched by
+// RunMaintenanceMonitor.
+func (m *Manager) maintenanceError(err error) bool {
+	if !errors.Is(err, netcup.ErrMaintenance) {
+		return false
+	}
+
+	m.bookMu.Lock()
+	entered := !m.maintenance
+	m.maintenance = true
+	m.bookMu.Unlock()
+
+	if entered {
+		log.Printf("Netcup API is in maintenance, queueing DNS changes until it is over: %v", err)
+		m.notifier.SendEventError(notification.EventMaintenance, "Netcup API is in maintenance, DNS changes are queued until it is over")
+		select {
+		case m.maintenanceStarted <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+// RunMaintenanceMonitor checks whether a maintenance window is over, starting
+// MaintenanceRetryInterval after the API was found in maintenance and backing off up to
+// MaintenanceMaxRetryInterval, then applies the changes queued meanwhile.
+func (m *Manager) RunMaintenanceMonitor(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.maintenanceStarted:
+		}
+
+		interval := MaintenanceRetryInterval
+		for m.InMaintenance() {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+			if !m.checkMaintenance(ctx) {
+				break
+			}
+			interval = min(interval*2, MaintenanceMaxRetryInterval)
+		}
+	}
+}
+
+// checkMaintenance logs in to find out whether the maintenance window is over and, if
+// so, ends maintenance mode. It returns true while the API is still unavailable.
+func (m *Manager) checkMaintenance(ctx context.Context) bool {
+	session, err := m.client.Login(ctx)
+	if err != nil {
+		if !errors.Is(err, netcup.ErrMaintenance) {
+			log.Printf("Warning: Failed to check whether Netcup maintenance is over: %v", err)
+		}
+		return true
+	}
+	session.Logout()
+
+	m.endMaintenance(ctx)
+	return false
+}
+
+// endMaintenance leaves maintenance mode and applies the changes queued meanwhile, unless
+// DNS writes are paused, in which case they are applied on Resume
+func (m *Manager) endMaintenance(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.bookMu.Lock()
+	m.maintenance = false
+	m.bookMu.Unlock()
+
+	if m.paused {
+		log.Printf("Netcup API maintenance is over, keeping %d queued hosts until DNS writes resume", len(m.queued))
+		m.notifier.SendEvent(notification.EventMaintenance, "Netcup API maintenance is over, queued DNS changes are applied on resume")
+		return
+	}
+
+	log.Printf("Netcup API maintenance is over, applying %d queued hosts", len(m.queued))
+	applied, err := m.applyQueued(ctx)
+	if err != nil {
+		log.Printf("Warning: Failed to apply DNS changes queued during maintenance: %v", err)
+	}
+	if m.InMaintenance() {
+		// The API went back into maintenance, which keeps the rest queued
+		return
+	}
+	m.notifier.SendEvent(notification.EventMaintenance, fmt.Sprintf("Netcup API maintenance is over, applied %d queued DNS changes", applied))
+}
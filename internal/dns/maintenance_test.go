@@ -0,0 +1,58 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestMaintenanceQueuesChanges(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	manager := NewManager(testConfig(), api, nil)
+	ctx := context.Background()
+
+	api.LoginErr = fmt.Errorf("%w: Login failed: (4001) 'error' 'Maintenance.' ''", netcup.ErrMaintenance)
+	app := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	if err := manager.ProcessHostInfo(ctx, app); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v, want the host queued", err)
+	}
+	if !manager.InMaintenance() {
+		t.Fatal("InMaintenance() = false after a maintenance error")
+	}
+
+	// Further hosts are queued without trying the API
+	logins := api.CallCount("login")
+	web := docker.HostInfo{Hostname: "web.example.com", Domain: "example.com", Subdomain: "web"}
+	if err := manager.SyncHosts(ctx, []docker.HostInfo{web}); err != nil {
+		t.Fatalf("SyncHosts() error = %v", err)
+	}
+	if got := api.CallCount("login"); got != logins {
+		t.Errorf("login calls = %d during maintenance, want %d", got, logins)
+	}
+
+	if !manager.checkMaintenance(ctx) {
+		t.Error("checkMaintenance() = false while the API is in maintenance")
+	}
+	if got := len(api.Records("example.com")); got != 0 {
+		t.Fatalf("%d records during maintenance, want 0", got)
+	}
+
+	api.LoginErr = nil
+	if manager.checkMaintenance(ctx) {
+		t.Fatal("checkMaintenance() = true after maintenance is over")
+	}
+	if manager.InMaintenance() {
+		t.Error("InMaintenance() = true after maintenance is over")
+	}
+	records := api.Records("example.com")
+	if len(records) != 2 {
+		t.Errorf("records = %v after maintenance, want app and web", records)
+	}
+	if queued := manager.Diagnostics().Queued; len(queued) != 0 {
+		t.Errorf("Queued = %v after maintenance, want none", queued)
+	}
+}
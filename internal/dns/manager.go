@@ -2,81 +2,634 @@ package dns
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/alex289/docker-traefik-netcup-companion/internal/annotation"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/approval"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/audit"
 	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
 	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/events"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/failover"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/forward"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/hostip"
 	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
 	"github.com/alex289/docker-traefik-netcup-companion/internal/notification"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/provider"
 	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Manager struct {
 	config       *config.Config
-	client       *netcup.NetcupDnsClient
+	client       netcup.NetcupAPI
 	notifier     *notification.Notifier
+	auditLogger  *audit.Logger
+	annotations  *annotation.Store // Hostnames owned by each container, disabled without ANNOTATIONS_FILE
+	failover     *failover.Monitor
+	hostIP       *hostip.Monitor
+	detectIP     func() (string, error) // Detects the host IP from IP_SOURCE or the outbound interface
+	overlay      *hostip.Monitor        // Monitors the address of OVERLAY_DOMAINS, nil if disabled
+	readOverlay  func() (string, error) // Detects the address of OVERLAY_DOMAINS, nil without them
 	stateManager *state.Manager
-	mu           sync.Mutex
-	knownHosts   map[string]bool // Track hosts we've already processed
+	bus          *events.Bus
+	secondary    provider.Provider // Mirrors record changes, nil if disabled
+	internal     provider.Provider // Serves hostnames to the LAN at INTERNAL_IP, nil if disabled
+	forward      *forward.Client   // Receives hosts outside OWNED_ZONES, nil to skip them
+	knownHosts   map[string]bool   // Track hosts we've already processed
+	dnssec       map[string]bool   // DNSSEC status of the zones seen, keyed by domain
+
+	// mu is held exclusively by batch operations such as SyncHosts and reconciliation.
+	// ProcessHostInfo holds it shared along with the lock of its hostname, so independent
+	// hosts are processed concurrently while events for the same hostname stay ordered.
+	mu        sync.RWMutex
+	hostLocks [hostLockPartitions]sync.Mutex
+	bookMu    sync.Mutex // Guards knownHosts, dnssec, events and queued while mu is held shared
+
+	// Observe mode bookkeeping
+	observed map[string]docker.HostInfo // Hosts expected to have records
+	drifted  map[string]string          // Drifted hostnames -> actual IP at last check
+
+	// Pause bookkeeping
+	paused           bool
+	queued           []docker.HostInfo // Hosts received while paused, applied on resume
+	reconcilePending bool              // Reconciliation requested while paused
+
+	// Recently processed host events for diagnostics, oldest first
+	events []Event
+
+	// Hostnames past LIVENESS_THRESHOLD at the last check, so each is notified once
+	unseen map[string]bool
+
+	// Zone TTLs kept while a migration is prepared, keyed by domain
+	ttlOverrides map[string]string
+
+	// Zones found in sync by the last reconciliation, keyed by domain
+	zoneSerials map[string]zoneSnapshot
+
+	// Approval queue of changes to records the companion did not create, empty without
+	// APPROVAL_REQUIRED
+	approvalPath string
+	approvalMu   sync.Mutex // Serializes updates of the approval queue
+
+	// Serializes hosts joining and leaving consolidated wildcards while mu is held shared
+	wildcardMu sync.Mutex
+
+	// Netcup maintenance bookkeeping, changes are queued like while paused
+	maintenance        bool          // Guarded by bookMu
+	maintenanceStarted chan struct{} // Wakes RunMaintenanceMonitor
 }
 
-func NewManager(cfg *config.Config, stateManager *state.Manager) *Manager {
-	client := netcup.NewNetcupDnsClient(cfg.CustomerNumber, cfg.APIKey, cfg.APIPassword)
-	notifier := notification.NewNotifier(cfg.NotificationURLs)
+// NewNotifier creates a notifier for the configured URLs, event types and throttling
+func NewNotifier(cfg *config.Config) *notification.Notifier {
+	return notification.NewNotifierWithOptions(cfg.NotificationURLs, &notification.NotifierOptions{
+		Events:       cfg.NotificationEvents,
+		FallbackURLs: cfg.NotificationFallbackURLs,
+		SpoolPath:    cfg.NotificationSpoolPath,
+		Throttle:     cfg.NotificationThrottle,
+
+		QueueSize:     cfg.NotificationQueueSize,
+		Timeout:       time.Duration(cfg.NotificationTimeout) * time.Second,
+		SpoolOverflow: cfg.NotificationOverflow == config.OverflowSpool,
+	})
+}
+
+// NewNetcupClient creates a Netcup API client using the credentials, retry, circuit
+// breaker and zone cache settings from the configuration. If notifier is not nil,
+// circuit breaker transitions are reported to it.
+func NewNetcupClient(cfg *config.Config, notifier *notification.Notifier) netcup.NetcupAPI {
+	circuitBreaker := netcup.NewCircuitBreaker(
+		cfg.CircuitBreakerThreshold,
+		time.Duration(cfg.CircuitBreakerTimeout)*time.Second,
+		cfg.CircuitBreakerHalfOpenReqs,
+	)
+	if notifier != nil {
+		circuitBreaker.OnStateChange(func(from, to netcup.CircuitBreakerState) {
+			log.Printf("Netcup circuit breaker %s -> %s", from, to)
+			switch {
+			case from == netcup.StateClosed && to == netcup.StateOpen:
+				notifier.SendEventError(notification.EventCircuitBreaker, fmt.Sprintf("Netcup API circuit breaker opened after %d consecutive failures, pausing requests for %ds", cfg.CircuitBreakerThreshold, cfg.CircuitBreakerTimeout))
+			case to == netcup.StateClosed:
+				notifier.SendEvent(notification.EventCircuitBreaker, "Netcup API circuit breaker closed, requests resumed")
+			}
+		})
+	}
+
+	opts := &netcup.NetcupDnsClientOptions{
+		RetryConfig: &netcup.RetryConfig{
+			MaxRetries:        cfg.MaxRetries,
+			InitialBackoff:    time.Duration(cfg.InitialBackoff) * time.Millisecond,
+			MaxBackoff:        time.Duration(cfg.MaxBackoff) * time.Millisecond,
+			BackoffMultiplier: cfg.BackoffMultiplier,
+		},
+		CircuitBreaker: circuitBreaker,
+		ProxyURL:       cfg.NetcupProxyURL,
+		DebugHTTP:      cfg.DebugHTTP,
+		DebugHistory:   cfg.DebugHTTPHistory,
+		PollInterval:   time.Duration(cfg.PollInterval) * time.Second,
+		PollTimeout:    time.Duration(cfg.PollTimeout) * time.Second,
+	}
+	client := netcup.NewNetcupDnsClientWithOptions(cfg.CustomerNumber, cfg.APIKey, cfg.APIPassword, opts)
+
+	// Domains with their own credentials get a client per distinct credentials, sharing
+	// the circuit breaker as they talk to the same API
+	clients := make(map[config.Credentials]netcup.NetcupAPI)
+	domains := make(map[string]netcup.NetcupAPI, len(cfg.DomainCredentials))
+	for domain, credentials := range cfg.DomainCredentials {
+		api, ok := clients[credentials]
+		if !ok {
+			api = netcup.NewNetcupAPI(netcup.NewNetcupDnsClientWithOptions(credentials.CustomerNumber, credentials.APIKey, credentials.APIPassword, opts))
+			clients[credentials] = api
+		}
+		domains[domain] = api
+	}
+
+	api := netcup.NewDomainAPI(netcup.NewNetcupAPI(client), domains)
+	return netcup.NewCachingAPI(api, time.Duration(cfg.ZoneCacheTTL)*time.Second)
+}
+
+// ManagerOptions holds optional settings for the DNS manager
+type ManagerOptions struct {
+	Events    *events.Bus       // Bus to consume container events from and publish record events on; nil uses a private bus
+	Secondary provider.Provider // Provider receiving the same record changes as Netcup; nil disables mirroring
+	Internal  provider.Provider // Provider pointing the hostnames at INTERNAL_IP for the LAN; nil disables split-horizon
+
+	// Notifier sending the record notifications, shared with the caller so one queue is
+	// flushed on shutdown; nil creates one from the configuration
+	Notifier *notification.Notifier
+}
+
+func NewManager(cfg *config.Config, client netcup.NetcupAPI, stateManager *state.Manager) *Manager {
+	return NewManagerWithOptions(cfg, client, stateManager, &ManagerOptions{})
+}
+
+// NewManagerWithOptions creates a manager that applies the container events published
+// on the bus and notifies about the record events it publishes in turn
+func NewManagerWithOptions(cfg *config.Config, client netcup.NetcupAPI, stateManager *state.Manager, opts *ManagerOptions) *Manager {
+	notifier := opts.Notifier
+	if notifier == nil {
+		notifier = NewNotifier(cfg)
+	}
+	auditLogger := audit.NewLogger(cfg.AuditLogPath)
+
+	var failoverMonitor *failover.Monitor
+	if cfg.FailoverEnabled() {
+		failoverMonitor = failover.NewMonitor(
+			cfg.FailoverPrimaryIP,
+			cfg.FailoverSecondaryIP,
+			cfg.FailoverProbePort,
+			time.Duration(cfg.FailoverProbeInterval)*time.Second,
+			cfg.FailoverFailureThreshold,
+		)
+	}
+
+	ipSource := NewIPSource(cfg)
+	if ipSource != nil {
+		log.Printf("Reading the host IP from %s", ipSource)
+	}
+	detectIP := hostIPDetector(ipSource)
+	var hostIPMonitor *hostip.Monitor
+	if cfg.HostIPMonitorEnabled() {
+		hostIPMonitor = hostip.NewMonitor(
+			detectIP,
+			time.Duration(cfg.HostIPCheckInterval)*time.Second,
+			cfg.HostIPWatchInterfaces,
+		)
+	}
+
+	var readOverlay func() (string, error)
+	var overlayMonitor *hostip.Monitor
+	if overlaySource := NewOverlaySource(cfg); overlaySource != nil {
+		log.Printf("Publishing the address from %s for %s", overlaySource, strings.Join(cfg.OverlayDomains, ", "))
+		readOverlay = hostIPDetector(overlaySource)
+		if cfg.OverlayMonitorEnabled() {
+			overlayMonitor = hostip.NewMonitor(
+				readOverlay,
+				time.Duration(cfg.HostIPCheckInterval)*time.Second,
+				cfg.HostIPWatchInterfaces,
+			)
+		}
+	}
 
-	return &Manager{
+	bus := opts.Events
+	if bus == nil {
+		bus = events.NewBus()
+	}
+
+	m := &Manager{
 		config:       cfg,
 		client:       client,
 		notifier:     notifier,
+		auditLogger:  auditLogger,
+		annotations:  annotation.NewStore(cfg.AnnotationsFile),
+		failover:     failoverMonitor,
+		hostIP:       hostIPMonitor,
+		detectIP:     detectIP,
+		overlay:      overlayMonitor,
+		readOverlay:  readOverlay,
 		stateManager: stateManager,
+		bus:          bus,
+		secondary:    opts.Secondary,
+		internal:     opts.Internal,
+		forward:      newForwardClient(cfg.ForwardURL, cfg.ForwardToken),
 		knownHosts:   make(map[string]bool),
+		dnssec:       make(map[string]bool),
+		observed:     make(map[string]docker.HostInfo),
+		drifted:      make(map[string]string),
+		zoneSerials:  make(map[string]zoneSnapshot),
 	}
+	m.maintenanceStarted = make(chan struct{}, 1)
+	if cfg.ApprovalRequired {
+		m.approvalPath = approval.Path(cfg.StateFilePath)
+	}
+	bus.Subscribe(m.HandleEvent)
+	bus.Subscribe(notifier.HandleEvent)
+
+	if stateManager != nil {
+		stateManager.OnSaveFailures(cfg.StateSaveFailureThreshold, func(failures int, err error) {
+			notifier.SendError(fmt.Sprintf("State file %s could not be saved %d times in a row, DNS changes are not persisted: %v", cfg.StateFilePath, failures, err))
+		})
+		stateManager.OnExternalChange(func(message string, conflict bool) {
+			if conflict {
+				notifier.SendError(message)
+			} else {
+				notifier.SendInfo(message)
+			}
+		})
+	}
+	return m
 }
 
-func (m *Manager) ProcessHostInfo(ctx context.Context, info docker.HostInfo) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// Close delivers the notifications still queued, giving up once ctx is done
+func (m *Manager) Close(ctx context.Context) {
+	m.notifier.Close(ctx)
+}
 
-	// Check if we've already processed this host
-	if m.knownHosts[info.Hostname] {
-		log.Printf("Host %s already processed, skipping", info.Hostname)
-		return nil
+// HandleEvent applies the container events published on the bus
+func (m *Manager) HandleEvent(ctx context.Context, event events.Event) {
+	var info docker.HostInfo
+	switch e := event.(type) {
+	case events.ContainerStarted:
+		info = e.Host
+	case events.HostRemoved:
+		info = e.Host
+		info.Remove = true
+	default:
+		return
+	}
+
+	if err := m.ProcessHostInfo(ctx, info); err != nil {
+		log.Printf("Error processing host %s: %v", info.Hostname, err)
+	}
+}
+
+// RunFailoverMonitor probes the primary destination and re-points all managed
+// records whenever the active destination changes. It blocks until ctx is done.
+func (m *Manager) RunFailoverMonitor(ctx context.Context) {
+	if m.failover == nil {
+		return
 	}
 
-	// Get the host's IP address
+	if m.stateManager == nil {
+		log.Println("Warning: Failover without state persistence can only update hosts processed after a switch")
+	}
+
+	m.failover.OnSwitch(func(from, to string) {
+		if m.failover.IsFailedOver() {
+			m.notifier.SendError(fmt.Sprintf("Primary destination %s unreachable, failing over to %s", from, to))
+		} else {
+			m.notifier.SendSuccess(fmt.Sprintf("Primary destination %s reachable again, failing back from %s", to, from))
+		}
+
+		m.mu.Lock()
+		m.knownHosts = make(map[string]bool)
+		m.mu.Unlock()
+
+		if err := m.ReconcileFromState(ctx); err != nil {
+			log.Printf("Warning: Failed to re-point records after failover: %v", err)
+			m.notifier.SendError(fmt.Sprintf("Failed to re-point records to %s: %v", to, err))
+		}
+	})
+
+	m.failover.Run(ctx)
+}
+
+// RunHostIPMonitor re-detects the host IP and re-points all managed records whenever
+// the detected address changes. It blocks until ctx is done.
+func (m *Manager) RunHostIPMonitor(ctx context.Context) {
+	if m.hostIP == nil {
+		return
+	}
+
+	if m.stateManager == nil {
+		log.Println("Warning: Host IP monitoring without state persistence can only update hosts processed after a change")
+	}
+
+	m.hostIP.OnChange(func(from, to string) {
+		m.notifier.SendInfo(fmt.Sprintf("Host IP changed from %s to %s, updating records", from, to))
+
+		m.mu.Lock()
+		m.knownHosts = make(map[string]bool)
+		m.mu.Unlock()
+
+		if err := m.ReconcileFromState(ctx); err != nil {
+			log.Printf("Warning: Failed to re-point records after host IP change: %v", err)
+			m.notifier.SendError(fmt.Sprintf("Failed to re-point records to %s: %v", to, err))
+		}
+	})
+
+	m.hostIP.Run(ctx)
+}
+
+// HostIP returns the destination A records are currently pointed at
+func (m *Manager) HostIP() (string, error) {
+	return m.resolveHostIP()
+}
+
+// VerifyZones logs in to Netcup and checks that a DNS zone exists for each domain.
+// A login failure is returned as error; per-domain failures are returned in the map.
+func (m *Manager) VerifyZones(ctx context.Context, domains []string) (map[string]error, error) {
+	session, err := m.client.Login(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to login to Netcup: %w", err)
+	}
+	defer session.Logout()
+
+	results := make(map[string]error, len(domains))
+	for _, domain := range domains {
+		_, err := session.InfoDnsZone(domain)
+		results[domain] = err
+	}
+	return results, nil
+}
+
+// dryRun reports whether the changes of info are only logged, in dry-run mode or for a
+// container labeled netcup.companion/dry-run
+func (m *Manager) dryRun(info docker.HostInfo) bool {
+	return m.config.DryRun || info.DryRun
+}
+
+// destinationFor returns the address published for info: its custom destination, its
+// container IP when publishing container addresses, or the host IP of its domain
+func (m *Manager) destinationFor(info docker.HostInfo) (string, error) {
+	if info.Destination == "" && info.IP == "" && !m.hasDomainIP(info.Domain) {
+		return m.resolveHostIP()
+	}
+	return m.hostAddress(info, "")
+}
+
+// hasDomainIP reports whether the records of domain point at an address of their own,
+// from DOMAIN_IP_MAP or OVERLAY_DOMAINS, rather than the host IP
+func (m *Manager) hasDomainIP(domain string) bool {
+	_, mapped := m.config.DomainIPs[domain]
+	return mapped || m.config.OverlayDomain(domain)
+}
+
+// domainHostIP returns the DOMAIN_IP_MAP override for domain, the overlay address for
+// OVERLAY_DOMAINS, or the resolved hostIP
+func (m *Manager) domainHostIP(domain, hostIP string) (string, error) {
+	if ip, ok := m.config.DomainIPs[domain]; ok {
+		return ip, nil
+	}
+	if m.config.OverlayDomain(domain) {
+		return m.overlayIP()
+	}
+	return hostIP, nil
+}
+
+// hostAddress returns the address published for info given the already resolved host
+// IP: its custom destination or container address if allowed, or the address of its
+// domain
+func (m *Manager) hostAddress(info docker.HostInfo, hostIP string) (string, error) {
+	if info.Destination == "" && info.IP == "" {
+		return m.domainHostIP(info.Domain, hostIP)
+	}
+	ip, err := addressFor(info, "")
+	if err != nil {
+		return "", err
+	}
+	if err := m.checkAllowedIP(ip); err != nil {
+		return "", err
+	}
+	return ip, nil
+}
+
+// DomainIPs returns the address the records of each domain point at, following
+// DOMAIN_IP_MAP like published records do. Container addresses do not follow a single
+// IP per domain, so it fails with IP_SOURCE=container.
+func (m *Manager) DomainIPs(domains []string) (map[string]string, error) {
+	if m.config.PublishContainerIP() {
+		return nil, fmt.Errorf("records point at container addresses with IP_SOURCE=container")
+	}
+
+	ips := make(map[string]string, len(domains))
 	var hostIP string
+	for _, domain := range domains {
+		if !m.hasDomainIP(domain) && hostIP == "" {
+			ip, err := m.resolveHostIP()
+			if err != nil {
+				return nil, err
+			}
+			hostIP = ip
+		}
+		ip, err := m.domainHostIP(domain, hostIP)
+		if err != nil {
+			return nil, err
+		}
+		ips[domain] = ip
+	}
+	return ips, nil
+}
+
+// addressFor returns the address published for info given the already resolved host IP
+func addressFor(info docker.HostInfo, hostIP string) (string, error) {
+	if info.Destination != "" {
+		return resolveDestination(info.Destination)
+	}
+	if info.IP != "" {
+		return info.IP, nil
+	}
+	return hostIP, nil
+}
+
+// lookupIP resolves hostnames, replaced in tests
+var lookupIP = net.LookupIP
+
+// detectHostIP auto-detects the host IP, replaced in tests
+var detectHostIP = getHostIP
+
+// resolveDestination returns the IPv4 address of a custom destination, resolving
+// hostnames on every call so records follow changes of the target
+func resolveDestination(destination string) (string, error) {
+	if ip := net.ParseIP(destination); ip != nil {
+		if ip.To4() == nil {
+			return "", fmt.Errorf("destination %s is not an IPv4 address", destination)
+		}
+		return ip.String(), nil
+	}
+
+	ips, err := lookupIP(destination)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve destination %s: %w", destination, err)
+	}
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			return ip4.String(), nil
+		}
+	}
+	return "", fmt.Errorf("destination %s has no IPv4 address", destination)
+}
+
+// resolveHostIP returns the destination for A records: the active failover
+// destination, the configured HOST_IP, or the auto-detected IP, in that order
+func (m *Manager) resolveHostIP() (string, error) {
+	if m.failover != nil {
+		return m.failover.ActiveIP(), nil
+	}
 	if m.config.HostIP != "" {
-		// Use configured IP
-		hostIP = m.config.HostIP
-		log.Printf("Using configured HOST_IP: %s", hostIP)
+		return m.config.HostIP, nil
+	}
+	// Use the monitored address, so records match what changes are reported against
+	if m.hostIP != nil {
+		if ip := m.hostIP.CurrentIP(); ip != "" {
+			return m.checkDetectedIP(ip)
+		}
+	}
+	ip, err := m.detectIP()
+	if err != nil {
+		return "", err
+	}
+	return m.checkDetectedIP(ip)
+}
+
+func (m *Manager) ProcessHostInfo(ctx context.Context, info docker.HostInfo) (err error) {
+	// Continue the trace started by the Docker event
+	ctx = trace.ContextWithRemoteSpanContext(ctx, info.SpanContext)
+	ctx, span := tracer.Start(ctx, "dns.ProcessHostInfo", hostAttributes(info))
+	defer func() { endSpan(span, err) }()
+
+	if !m.config.OwnsZone(info.Domain) {
+		return m.forwardHost(ctx, info)
+	}
+
+	if m.config.ObserveMode() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.recordEvent(info, "observed")
+		return m.observeHost(ctx, info)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	lock := m.hostLock(info.Hostname)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if m.paused || m.InMaintenance() {
+		m.recordEvent(info, "queued")
+		m.queueHost(info)
+		return nil
+	}
+
+	err = m.processHost(ctx, info)
+	if m.maintenanceError(err) {
+		m.recordEvent(info, "queued")
+		m.queueHost(info)
+		return nil
+	}
+	if err != nil {
+		m.recordEvent(info, err.Error())
 	} else {
-		// Auto-detect IP
-		var err error
-		hostIP, err = getHostIP()
-		if err != nil {
-			return fmt.Errorf("failed to get host IP: %w", err)
+		m.recordEvent(info, "applied")
+	}
+	m.MarkSeen([]docker.HostInfo{info})
+	return err
+}
+
+// processHost publishes or removes the DNS record of a single host. The caller holds
+// m.mu, at least shared along with the lock of the hostname.
+func (m *Manager) processHost(ctx context.Context, info docker.HostInfo) error {
+	if info.Remove {
+		if !m.ownsHost(info) {
+			log.Printf("Host %s is owned by another container, not removing it", info.Hostname)
+			return nil
+		}
+		return m.removeHost(ctx, info, "event")
+	}
+	dryRun := m.dryRun(info)
+
+	if ok, err := m.claimHost(info); !ok {
+		return err
+	}
+	if m.expiredFor(info) {
+		return nil
+	}
+
+	// Check if we've already processed this host
+	if m.isKnown(info.Hostname) && !m.srvChanged(info) {
+		log.Printf("Host %s already processed, skipping", info.Hostname)
+		return nil
+	}
+
+	if err := m.checkManaged(info.Subdomain); err != nil {
+		m.notifier.SendError(fmt.Sprintf("Refused DNS record for %s: %v", info.Hostname, err))
+		return fmt.Errorf("refused DNS record for %s: %w", info.Hostname, err)
+	}
+
+	m.syncInternal(ctx, info.Hostname, info.Domain, info.Subdomain, dryRun)
+
+	// Get the address to publish
+	hostIP, err := m.destinationFor(info)
+	if err != nil {
+		if m.skipsPrivateIP(err) {
+			return nil
+		}
+		if errors.Is(err, ErrIPNotAllowed) {
+			// Refusals of the host IP are notified once detected
+			if info.Destination != "" || info.IP != "" || m.config.OverlayDomain(info.Domain) {
+				m.notifier.SendError(fmt.Sprintf("Refused DNS record for %s: %v", info.Hostname, err))
+			}
+			return fmt.Errorf("refused DNS record for %s: %w", info.Hostname, err)
 		}
+		return fmt.Errorf("failed to get destination: %w", err)
 	}
 
-	log.Printf("Processing DNS for %s -> %s", info.Hostname, hostIP)
+	log.Printf("Processing DNS for %s -> %s%s", info.Hostname, hostIP, info.StackSuffix())
+	m.mirrorRecord(ctx, info.Hostname, info.Domain, info.Subdomain, hostIP, dryRun)
 
 	// Login to Netcup
-	session, err := m.client.Login()
+	session, err := m.client.Login(ctx)
 	if err != nil {
-		m.notifier.SendError(fmt.Sprintf("Failed to login to Netcup for %s: %v", info.Hostname, err))
+		if !errors.Is(err, netcup.ErrMaintenance) {
+			m.notifier.SendError(fmt.Sprintf("Failed to login to Netcup for %s: %v", info.Hostname, err))
+		}
 		return fmt.Errorf("failed to login to Netcup: %w", err)
 	}
 	defer session.Logout()
 
 	// Check if DNS zone exists
-	_, err = session.InfoDnsZone(info.Domain)
+	zone, err := session.InfoDnsZone(info.Domain)
 	if err != nil {
 		m.notifier.SendError(fmt.Sprintf("Failed to get DNS zone for %s: %v", info.Domain, err))
 		return fmt.Errorf("failed to get DNS zone for %s: %w", info.Domain, err)
 	}
+	if err := m.checkDNSSEC(info.Domain, zone); err != nil {
+		return fmt.Errorf("refused DNS record for %s: %w", info.Hostname, err)
+	}
+
+	// Apply configured zone settings; failures here must not block record publishing
+	applied, err := m.applyZoneSettings(session, info.Domain, zone)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		m.notifier.SendError(err.Error())
+	}
 
 	// Get existing DNS records
 	records, err := session.InfoDnsRecords(info.Domain)
@@ -85,43 +638,82 @@ func (m *Manager) ProcessHostInfo(ctx context.Context, info docker.HostInfo) err
 		return fmt.Errorf("failed to get DNS records for %s: %w", info.Domain, err)
 	}
 
-	// Check if record already exists
-	recordExists := false
-	var existingIP string
-	for _, record := range *records {
-		if record.Hostname == info.Subdomain && record.Type == "A" {
-			existingIP = record.Destination
-			if record.Destination == hostIP {
-				log.Printf("DNS record for %s already exists with correct IP", info.Hostname)
-				m.knownHosts[info.Hostname] = true
-				return nil
-			}
-			recordExists = true
-			log.Printf("DNS record for %s exists but with different IP (%s), will update", info.Hostname, record.Destination)
-			break
+	// Compute the change against the live zone
+	index := indexARecords(*records)
+	if err := m.handleDuplicates(session, index, info.Hostname, info.Domain, info.Subdomain, hostIP, "event", dryRun); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	newRecord, existing, needed := index.diff(info.Subdomain, hostIP, m.recordID(info.Hostname))
+	if needed && existing == nil && m.coveredByWildcard(*records, info.Domain, info.Subdomain, hostIP) {
+		log.Printf("DNS record for %s is not needed, a wildcard record of %s already points at %s", info.Hostname, info.Domain, hostIP)
+		m.setKnown(info.Hostname, true)
+		m.joinWildcard(info, hostIP)
+		return nil
+	}
+	metadata, metadataNeeded := m.metadataChange(*records, info)
+	if !needed {
+		log.Printf("DNS record for %s already exists with correct IP", info.Hostname)
+		m.setKnown(info.Hostname, true)
+		if metadataNeeded {
+			m.writeMetadata(session, info.Domain, []netcup.DnsRecord{metadata}, dryRun)
 		}
+		m.syncSRV(session, info.Domain, *records, []docker.HostInfo{info}, "event", dryRun)
+		if !dryRun {
+			m.trackExpiry(info, hostIP)
+			m.learnRecordID(info.Hostname, info.Subdomain, hostIP, records)
+			m.annotate(info)
+		}
+		return nil
+	}
+	if err := netcup.ValidateRecord(newRecord); err != nil {
+		m.notifier.SendError(fmt.Sprintf("Rejected DNS record for %s: %v", info.Hostname, err))
+		return fmt.Errorf("invalid DNS record for %s: %w", info.Hostname, err)
 	}
 
-	if m.config.DryRun {
+	recordExists := existing != nil
+	var existingIP string
+	if recordExists {
+		existingIP = existing.Destination
+		log.Printf("DNS record for %s exists but with different IP (%s), will update record %s", info.Hostname, existingIP, existing.Id)
+	}
+	gated := recordExists && !dryRun && m.needsApproval(info.Hostname)
+	if gated && !m.approved(approvalChange(info, existingIP, hostIP)) {
+		return nil
+	}
+
+	action := audit.ActionCreate
+	if recordExists {
+		action = audit.ActionUpdate
+	}
+	auditEntry := audit.Entry{
+		Action:        action,
+		Source:        "event",
+		Hostname:      info.Hostname,
+		Domain:        info.Domain,
+		Subdomain:     info.Subdomain,
+		RecordType:    "A",
+		Before:        existingIP,
+		After:         hostIP,
+		ContainerID:   info.ContainerID,
+		ContainerName: info.ContainerName,
+		DryRun:        dryRun,
+	}
+
+	if dryRun {
 		if recordExists {
 			log.Printf("[DRY RUN] Would update DNS record: %s.%s (%s -> %s)", info.Subdomain, info.Domain, existingIP, hostIP)
-			m.notifier.SendInfo(fmt.Sprintf("[DRY RUN] Would update DNS: %s (%s -> %s)", info.Hostname, existingIP, hostIP))
+			m.notifier.SendInfo(fmt.Sprintf("[DRY RUN] Would update DNS: %s (%s -> %s)%s", info.Hostname, existingIP, hostIP, info.StackSuffix()))
 		} else {
 			log.Printf("[DRY RUN] Would create DNS record: %s.%s -> %s", info.Subdomain, info.Domain, hostIP)
-			m.notifier.SendInfo(fmt.Sprintf("[DRY RUN] Would create DNS: %s -> %s", info.Hostname, hostIP))
+			m.notifier.SendInfo(fmt.Sprintf("[DRY RUN] Would create DNS: %s -> %s%s", info.Hostname, hostIP, info.StackSuffix()))
 		}
-		m.knownHosts[info.Hostname] = true
+		m.recordAudit(auditEntry)
+		m.setKnown(info.Hostname, true)
+		m.syncSRV(session, info.Domain, *records, []docker.HostInfo{info}, "event", dryRun)
 		return nil
 	}
 
-	// Create or update the DNS record
-	newRecord := netcup.DnsRecord{
-		Hostname:    info.Subdomain,
-		Type:        "A",
-		Destination: hostIP,
-		Priority:    "0",
-	}
-
 	if recordExists {
 		log.Printf("Updating DNS record: %s.%s -> %s", info.Subdomain, info.Domain, hostIP)
 	} else {
@@ -129,56 +721,579 @@ func (m *Manager) ProcessHostInfo(ctx context.Context, info docker.HostInfo) err
 	}
 
 	recordSet := []netcup.DnsRecord{newRecord}
-	_, err = session.UpdateDnsRecords(info.Domain, &recordSet)
+	if metadataNeeded {
+		recordSet = append(recordSet, metadata)
+	}
+	updated, err := session.UpdateDnsRecords(info.Domain, &recordSet)
 	if err != nil {
-		m.notifier.SendError(fmt.Sprintf("Failed to update DNS for %s: %v", info.Hostname, err))
+		auditEntry.Error = err.Error()
+		m.recordAudit(auditEntry)
+		m.bus.Publish(ctx, events.RecordFailed{Host: info, Err: err})
 		return fmt.Errorf("failed to update DNS records: %w", err)
 	}
 
-	m.knownHosts[info.Hostname] = true
+	m.recordAudit(auditEntry)
+	m.setKnown(info.Hostname, true)
 	log.Printf("Successfully configured DNS for %s", info.Hostname)
+	if gated {
+		m.resolveApproval(approvalChange(info, existingIP, hostIP))
+	}
+	m.syncSRV(session, info.Domain, *records, []docker.HostInfo{info}, "event", dryRun)
 
 	// Persist state to disk
 	if m.stateManager != nil {
-		if err := m.stateManager.UpdateRecord(info.Hostname, info.Domain, info.Subdomain, hostIP, "A"); err != nil {
+		if err := m.stateManager.UpdateRecordWithOrigin(info.Hostname, info.Domain, info.Subdomain, hostIP, "A", recordOrigin(info)); err != nil {
 			log.Printf("Warning: Failed to persist DNS state for %s: %v", info.Hostname, err)
 		}
 	}
+	m.learnRecordID(info.Hostname, info.Subdomain, hostIP, updated)
+	m.trackExpiry(info, hostIP)
+	m.annotate(info)
 
+	diff := append(recordDiff(*records, recordSet), ttlChange(zone, applied)...)
 	if recordExists {
-		m.notifier.SendSuccess(fmt.Sprintf("Updated DNS: %s -> %s", info.Hostname, hostIP))
+		m.bus.Publish(ctx, events.RecordUpdated{Host: info, PreviousIP: existingIP, IP: hostIP, Diff: diff})
+	} else {
+		m.bus.Publish(ctx, events.RecordCreated{Host: info, IP: hostIP, Diff: diff})
+	}
+
+	return nil
+}
+
+// SyncHosts processes a batch of hosts (e.g. the initial container scan) with a single
+// login, fetching each zone once and applying at most one record update per domain.
+// Removals in the batch, e.g. for hosts dropped from a container's labels while the
+// companion was not running, are applied one by one before.
+func (m *Manager) SyncHosts(ctx context.Context, hosts []docker.HostInfo) (err error) {
+	ctx, span := tracer.Start(ctx, "dns.SyncHosts", trace.WithAttributes(attribute.Int("dns.hosts", len(hosts))))
+	defer func() { endSpan(span, err) }()
+
+	hosts = m.forwardForeign(ctx, hosts)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.config.ObserveMode() {
+		for _, info := range hosts {
+			if !info.Remove {
+				m.observed[info.Hostname] = info
+			}
+		}
+		_, err := m.checkDrift(ctx)
+		return err
+	}
+
+	// Scanned hosts are running, whatever happens to their records
+	defer m.MarkSeen(hosts)
+
+	if m.paused || m.InMaintenance() {
+		m.queueHosts(hosts)
+		return nil
+	}
+
+	var removeErrors int
+	for _, info := range hosts {
+		if !info.Remove {
+			continue
+		}
+		if err := m.processHost(ctx, info); m.maintenanceError(err) {
+			m.queueHost(info)
+		} else if err != nil {
+			log.Printf("Warning: Failed to remove dropped host %s: %v", info.Hostname, err)
+			removeErrors++
+		}
+	}
+
+	// Hosts labeled for dry runs are kept out of the batch, which is written as a whole
+	if !m.config.DryRun {
+		for _, info := range hosts {
+			if info.Remove || !info.DryRun {
+				continue
+			}
+			if err := m.processHost(ctx, info); err != nil {
+				log.Printf("Warning: Dry run of %s failed: %v", info.Hostname, err)
+			}
+		}
+	}
+
+	// Group pending hosts by domain, skipping known hosts and duplicates
+	hostsByDomain := make(map[string][]docker.HostInfo)
+	seen := make(map[string]bool)
+	for _, info := range hosts {
+		if info.Remove || info.DryRun && !m.config.DryRun || seen[info.Hostname] {
+			continue
+		}
+		if ok, _ := m.claimHost(info); !ok || m.knownHosts[info.Hostname] && !m.srvChanged(info) || m.expiredFor(info) {
+			continue
+		}
+		if err := m.checkManaged(info.Subdomain); err != nil {
+			log.Printf("Warning: Skipping %s: %v", info.Hostname, err)
+			m.notifier.SendError(fmt.Sprintf("Refused DNS record for %s: %v", info.Hostname, err))
+			continue
+		}
+		seen[info.Hostname] = true
+		hostsByDomain[info.Domain] = append(hostsByDomain[info.Domain], info)
+	}
+
+	if len(hostsByDomain) == 0 {
+		log.Println("Initial sync: no new hosts to process")
+		if removeErrors > 0 {
+			return fmt.Errorf("initial sync failed to remove %d dropped hosts", removeErrors)
+		}
+		return nil
+	}
+
+	for domain, domainHosts := range hostsByDomain {
+		for _, info := range domainHosts {
+			m.syncInternal(ctx, info.Hostname, domain, info.Subdomain, m.config.DryRun)
+		}
+	}
+
+	// Container addresses are carried by the hosts themselves
+	var hostIP string
+	if m.config.PublishContainerIP() {
+		log.Printf("Initial sync: %d hosts across %d domains -> container IPs", len(seen), len(hostsByDomain))
 	} else {
-		m.notifier.SendSuccess(fmt.Sprintf("Created DNS: %s -> %s", info.Hostname, hostIP))
+		hostIP, err = m.resolveHostIP()
+		if err != nil {
+			if m.skipsPrivateIP(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to get host IP: %w", err)
+		}
+		log.Printf("Initial sync: %d hosts across %d domains -> %s", len(seen), len(hostsByDomain), hostIP)
+	}
+
+	if m.secondary != nil {
+		for domain, domainHosts := range hostsByDomain {
+			for _, info := range domainHosts {
+				if ip, err := m.hostAddress(info, hostIP); err == nil {
+					m.mirrorRecord(ctx, info.Hostname, domain, info.Subdomain, ip, m.config.DryRun)
+				}
+			}
+		}
+	}
+
+	// Login to Netcup once for the whole batch
+	session, err := m.client.Login(ctx)
+	if m.maintenanceError(err) {
+		for _, domainHosts := range hostsByDomain {
+			m.queueHosts(domainHosts)
+		}
+		return nil
+	}
+	if err != nil {
+		m.notifier.SendError(fmt.Sprintf("Failed to login to Netcup for initial sync: %v", err))
+		return fmt.Errorf("failed to login to Netcup: %w", err)
+	}
+	defer session.Logout()
+
+	var errorCount int
+	for domain, domainHosts := range hostsByDomain {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if err := m.syncDomain(session, domain, domainHosts, hostIP); m.maintenanceError(err) {
+			m.queueHosts(domainHosts)
+		} else if err != nil {
+			log.Printf("Warning: Initial sync failed for %s: %v", domain, err)
+			errorCount++
+		}
+	}
+
+	if errorCount > 0 {
+		return fmt.Errorf("initial sync failed for %d of %d domains", errorCount, len(hostsByDomain))
+	}
+	if removeErrors > 0 {
+		return fmt.Errorf("initial sync failed to remove %d dropped hosts", removeErrors)
+	}
+	return nil
+}
+
+// syncDomain computes the diff for all hosts of one domain and applies it in a single update.
+// Hosts carrying a container IP are pointed at it instead of hostIP, hosts of a domain in
+// DOMAIN_IP_MAP at its override.
+func (m *Manager) syncDomain(session netcup.DnsSession, domain string, hosts []docker.HostInfo, hostIP string) error {
+	zone, err := session.InfoDnsZone(domain)
+	if err != nil {
+		m.notifier.SendError(fmt.Sprintf("Failed to get DNS zone for %s: %v", domain, err))
+		return fmt.Errorf("failed to get DNS zone for %s: %w", domain, err)
+	}
+	if err := m.checkDNSSEC(domain, zone); err != nil {
+		return err
+	}
+
+	if _, err := m.applyZoneSettings(session, domain, zone); err != nil {
+		log.Printf("Warning: %v", err)
+		m.notifier.SendError(err.Error())
+	}
+
+	records, err := session.InfoDnsRecords(domain)
+	if err != nil {
+		m.notifier.SendError(fmt.Sprintf("Failed to get DNS records for %s: %v", domain, err))
+		return fmt.Errorf("failed to get DNS records for %s: %w", domain, err)
+	}
+
+	index := indexARecords(*records)
+
+	var recordSet, metadataSet []netcup.DnsRecord
+	var changed []docker.HostInfo
+	var auditEntries []audit.Entry
+	var approvedChanges []approval.Change
+	for _, info := range hosts {
+		ip, err := m.hostAddress(info, hostIP)
+		if err != nil {
+			log.Printf("Warning: Skipping %s: %v", info.Hostname, err)
+			m.notifier.SendError(fmt.Sprintf("Failed to get destination for %s: %v", info.Hostname, err))
+			continue
+		}
+
+		if err := m.handleDuplicates(session, index, info.Hostname, domain, info.Subdomain, ip, "initial_sync", m.config.DryRun); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+
+		change, existing, needed := index.diff(info.Subdomain, ip, m.recordID(info.Hostname))
+		if needed && existing == nil && m.coveredByWildcard(*records, domain, info.Subdomain, ip) {
+			log.Printf("DNS record for %s is not needed, a wildcard record of %s already points at %s", info.Hostname, domain, ip)
+			m.knownHosts[info.Hostname] = true
+			m.joinWildcard(info, ip)
+			continue
+		}
+		metadata, metadataNeeded := m.metadataChange(*records, info)
+		if !needed {
+			log.Printf("DNS record for %s already exists with correct IP", info.Hostname)
+			m.knownHosts[info.Hostname] = true
+			if metadataNeeded {
+				metadataSet = append(metadataSet, metadata)
+			}
+			if !m.config.DryRun {
+				m.trackExpiry(info, ip)
+				m.learnRecordID(info.Hostname, info.Subdomain, ip, records)
+				m.annotate(info)
+			}
+			continue
+		}
+		if err := netcup.ValidateRecord(change); err != nil {
+			log.Printf("Warning: Skipping %s: %v", info.Hostname, err)
+			m.notifier.SendError(fmt.Sprintf("Rejected DNS record for %s: %v", info.Hostname, err))
+			continue
+		}
+		if existing != nil && !m.config.DryRun && m.needsApproval(info.Hostname) {
+			pending := approvalChange(info, existing.Destination, ip)
+			if !m.approved(pending) {
+				continue
+			}
+			approvedChanges = append(approvedChanges, pending)
+		}
+		if metadataNeeded {
+			metadataSet = append(metadataSet, metadata)
+		}
+
+		action := audit.ActionCreate
+		var existingIP string
+		if existing != nil {
+			action = audit.ActionUpdate
+			existingIP = existing.Destination
+		}
+		auditEntries = append(auditEntries, audit.Entry{
+			Action:        action,
+			Source:        "initial_sync",
+			Hostname:      info.Hostname,
+			Domain:        info.Domain,
+			Subdomain:     info.Subdomain,
+			RecordType:    "A",
+			Before:        existingIP,
+			After:         ip,
+			ContainerID:   info.ContainerID,
+			ContainerName: info.ContainerName,
+			DryRun:        m.config.DryRun,
+		})
+		recordSet = append(recordSet, change)
+		changed = append(changed, info)
+	}
+
+	if len(recordSet) == 0 {
+		log.Printf("Initial sync: all %d records for %s are in sync", len(hosts), domain)
+		m.writeMetadata(session, domain, metadataSet, m.config.DryRun)
+		m.syncSRV(session, domain, *records, hosts, "initial_sync", m.config.DryRun)
+		return nil
+	}
+
+	summary := summarizeChanges(auditEntries)
+
+	if m.config.DryRun {
+		log.Printf("[DRY RUN] Initial sync would apply %d changes to %s: %s", len(recordSet), domain, summary)
+		m.notifier.SendInfo(fmt.Sprintf("[DRY RUN] Would apply DNS changes for %s: %s", domain, summary))
+		for i, info := range changed {
+			m.recordAudit(auditEntries[i])
+			m.knownHosts[info.Hostname] = true
+		}
+		m.writeMetadata(session, domain, metadataSet, m.config.DryRun)
+		m.syncSRV(session, domain, *records, hosts, "initial_sync", m.config.DryRun)
+		return nil
+	}
+
+	log.Printf("Initial sync: applying %d changes to %s in one update: %s", len(recordSet), domain, summary)
+	recordSet = append(recordSet, metadataSet...)
+	updated, err := session.UpdateDnsRecords(domain, &recordSet)
+	if err != nil {
+		for _, entry := range auditEntries {
+			entry.Error = err.Error()
+			m.recordAudit(entry)
+		}
+		m.notifier.SendError(fmt.Sprintf("Failed to update DNS for %s: %v", domain, err))
+		return fmt.Errorf("failed to update DNS records: %w", err)
+	}
+
+	for i, info := range changed {
+		m.recordAudit(auditEntries[i])
+		m.knownHosts[info.Hostname] = true
+
+		if m.stateManager != nil {
+			if err := m.stateManager.UpdateRecordWithOrigin(info.Hostname, info.Domain, info.Subdomain, auditEntries[i].After, "A", recordOrigin(info)); err != nil {
+				log.Printf("Warning: Failed to persist DNS state for %s: %v", info.Hostname, err)
+			}
+		}
+		m.learnRecordID(info.Hostname, info.Subdomain, auditEntries[i].After, updated)
+		m.trackExpiry(info, auditEntries[i].After)
+		m.annotate(info)
+	}
+	for _, change := range approvedChanges {
+		m.resolveApproval(change)
+	}
+	m.syncSRV(session, domain, *records, hosts, "initial_sync", m.config.DryRun)
+
+	m.notifier.SendSuccess(fmt.Sprintf("Configured DNS for %s: %s", domain, summary))
+	return nil
+}
+
+// recordOrigin maps the container a host belongs to onto its persisted origin
+func recordOrigin(info docker.HostInfo) state.Origin {
+	return state.Origin{
+		DockerHost:     info.DockerHost,
+		Container:      info.ContainerName,
+		ComposeProject: info.ComposeProject,
+		ComposeService: info.ComposeService,
+		Destination:    info.Destination,
+		Tags:           info.Tags,
+	}
+}
+
+// summarizeChanges renders a compact list of changes, e.g. "created app, api; updated www (1.2.3.4 -> 5.6.7.8)"
+func summarizeChanges(entries []audit.Entry) string {
+	var created, updated []string
+	for _, entry := range entries {
+		switch entry.Action {
+		case audit.ActionCreate:
+			created = append(created, fmt.Sprintf("%s -> %s", entry.Hostname, entry.After))
+		case audit.ActionUpdate:
+			updated = append(updated, fmt.Sprintf("%s (%s -> %s)", entry.Hostname, entry.Before, entry.After))
+		}
+	}
+
+	var parts []string
+	if len(created) > 0 {
+		parts = append(parts, "created "+strings.Join(created, ", "))
+	}
+	if len(updated) > 0 {
+		parts = append(parts, "updated "+strings.Join(updated, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// removeHost deletes the A record of a host that should no longer be published, along
+// with its metadata and SRV records
+func (m *Manager) removeHost(ctx context.Context, info docker.HostInfo, source string) error {
+	if err := m.checkManaged(info.Subdomain); err != nil {
+		m.notifier.SendError(fmt.Sprintf("Refused to remove DNS record for %s: %v", info.Hostname, err))
+		return fmt.Errorf("refused to remove DNS record for %s: %w", info.Hostname, err)
+	}
+
+	dryRun := m.dryRun(info)
+	log.Printf("Removing DNS for %s%s", info.Hostname, info.StackSuffix())
+	m.mirrorRemoval(ctx, info.Hostname, info.Domain, info.Subdomain, dryRun)
+	m.removeInternal(ctx, info.Hostname, info.Domain, info.Subdomain, dryRun)
+
+	// Login to Netcup
+	session, err := m.client.Login(ctx)
+	if err != nil {
+		if !errors.Is(err, netcup.ErrMaintenance) {
+			m.notifier.SendError(fmt.Sprintf("Failed to login to Netcup for %s: %v", info.Hostname, err))
+		}
+		return fmt.Errorf("failed to login to Netcup: %w", err)
+	}
+	defer session.Logout()
+
+	if err := m.checkZoneDNSSEC(session, info.Domain); err != nil {
+		return fmt.Errorf("refused to remove DNS record for %s: %w", info.Hostname, err)
+	}
+
+	// Get existing DNS records
+	records, err := session.InfoDnsRecords(info.Domain)
+	if err != nil {
+		m.notifier.SendError(fmt.Sprintf("Failed to get DNS records for %s: %v", info.Domain, err))
+		return fmt.Errorf("failed to get DNS records for %s: %w", info.Domain, err)
+	}
+	if m.leaveWildcard(ctx, session, info) {
+		return nil
+	}
+
+	matched := netcup.FindRecords(*records, info.Subdomain, "A")
+	if len(matched) == 0 {
+		log.Printf("No DNS record found for %s, nothing to remove", info.Hostname)
+		m.setKnown(info.Hostname, false)
+		if !dryRun {
+			m.unannotate(info.Hostname)
+		}
+		return nil
+	}
+	matched = m.trackedRecords(info.Hostname, matched)
+	existingIP := matched[len(matched)-1].Destination
+
+	if m.config.MetadataRecords {
+		metadata := m.metadataRecords(*records, info.Subdomain)
+		// Only records carrying metadata are known to be created by the companion
+		if len(metadata) == 0 && source == "prune" {
+			log.Printf("DNS record for %s has no metadata record, not deleting it", info.Hostname)
+			m.setKnown(info.Hostname, false)
+			return nil
+		}
+		matched = append(matched, metadata...)
+	}
+	matched = append(matched, m.hostSRVRecords(*records, info)...)
+	gated := !dryRun && m.needsApproval(info.Hostname)
+	if gated && !m.approved(approvalChange(info, existingIP, "")) {
+		return nil
 	}
 
+	auditEntry := audit.Entry{
+		Action:        audit.ActionDelete,
+		Source:        source,
+		Hostname:      info.Hostname,
+		Domain:        info.Domain,
+		Subdomain:     info.Subdomain,
+		RecordType:    "A",
+		Before:        existingIP,
+		ContainerID:   info.ContainerID,
+		ContainerName: info.ContainerName,
+		DryRun:        dryRun,
+	}
+
+	if dryRun {
+		log.Printf("[DRY RUN] Would delete DNS record: %s.%s (%s)", info.Subdomain, info.Domain, existingIP)
+		m.notifier.SendInfo(fmt.Sprintf("[DRY RUN] Would delete DNS: %s (%s)%s", info.Hostname, existingIP, info.StackSuffix()))
+		m.recordAudit(auditEntry)
+		m.setKnown(info.Hostname, false)
+		return nil
+	}
+
+	log.Printf("Deleting DNS record: %s.%s (%s)", info.Subdomain, info.Domain, existingIP)
+	if err := netcup.DeleteDnsRecords(session, info.Domain, matched); err != nil {
+		auditEntry.Error = err.Error()
+		m.recordAudit(auditEntry)
+		m.bus.Publish(ctx, events.RecordFailed{Host: info, Err: err})
+		return fmt.Errorf("failed to delete DNS records: %w", err)
+	}
+
+	m.recordAudit(auditEntry)
+	m.setKnown(info.Hostname, false)
+	log.Printf("Successfully removed DNS for %s", info.Hostname)
+	if gated {
+		m.resolveApproval(approvalChange(info, existingIP, ""))
+	}
+
+	if m.stateManager != nil {
+		if err := m.stateManager.RemoveRecord(info.Hostname); err != nil {
+			log.Printf("Warning: Failed to remove persisted DNS state for %s: %v", info.Hostname, err)
+		}
+		if err := m.stateManager.SetSRVRecords(info.Hostname, nil); err != nil {
+			log.Printf("Warning: Failed to remove persisted SRV records of %s: %v", info.Hostname, err)
+		}
+	}
+	m.unannotate(info.Hostname)
+
+	m.bus.Publish(ctx, events.RecordRemoved{Host: info, Diff: recordDiff(*records, deletions(matched))})
+
 	return nil
 }
 
 // ReconcileFromState performs startup reconciliation by comparing persisted state
 // with actual DNS records and syncing any drift
-func (m *Manager) ReconcileFromState(ctx context.Context) error {
+func (m *Manager) ReconcileFromState(ctx context.Context) (err error) {
+	ctx, span := tracer.Start(ctx, "dns.ReconcileFromState")
+	defer func() { endSpan(span, err) }()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.config.ObserveMode() {
+		_, err := m.checkDrift(ctx)
+		return err
+	}
+
+	if m.paused {
+		log.Println("DNS writes paused, deferring reconciliation until resume")
+		m.reconcilePending = true
+		return nil
+	}
+	if m.InMaintenance() {
+		log.Println("Netcup API is in maintenance, deferring reconciliation until it is over")
+		m.reconcilePending = true
+		return nil
+	}
+
+	if err := m.reconcile(ctx); m.maintenanceError(err) {
+		m.reconcilePending = true
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// reconcile re-applies all persisted records. The caller holds m.mu.
+func (m *Manager) reconcile(ctx context.Context) error {
 	if m.stateManager == nil || !m.stateManager.HasRecords() {
 		log.Println("No persisted state to reconcile")
 		return nil
 	}
+	return m.reconcileRecords(ctx, m.stateManager.GetRecordsForReconciliation())
+}
 
-	records := m.stateManager.GetRecordsForReconciliation()
+// reconcileRecords re-applies the given persisted records. The caller holds m.mu.
+func (m *Manager) reconcileRecords(ctx context.Context, records []state.DNSRecord) error {
+	records = m.withoutConsolidated(records)
 	log.Printf("Starting reconciliation for %d persisted DNS records", len(records))
 
-	// Get the host's IP address
+	for _, record := range records {
+		m.syncInternal(ctx, record.Hostname, record.Domain, record.Subdomain, m.config.DryRun)
+	}
+
+	// Get the host's IP address; container addresses are taken from the state
 	var hostIP string
-	if m.config.HostIP != "" {
-		hostIP = m.config.HostIP
-	} else {
+	if !m.config.PublishContainerIP() {
 		var err error
-		hostIP, err = getHostIP()
+		hostIP, err = m.resolveHostIP()
 		if err != nil {
+			if m.skipsPrivateIP(err) {
+				return nil
+			}
 			return fmt.Errorf("failed to get host IP for reconciliation: %w", err)
 		}
 	}
 
+	if m.secondary != nil {
+		for _, record := range records {
+			if ip, err := m.expectedIP(record, hostIP); err == nil {
+				m.mirrorRecord(ctx, record.Hostname, record.Domain, record.Subdomain, ip, m.config.DryRun)
+			}
+		}
+	}
+
 	// Login to Netcup
-	session, err := m.client.Login()
+	session, err := m.client.Login(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to login to Netcup for reconciliation: %w", err)
 	}
@@ -193,92 +1308,125 @@ func (m *Manager) ReconcileFromState(ctx context.Context) error {
 	var syncedCount, skippedCount, errorCount int
 
 	for domain, domainRecords := range recordsByDomain {
-		// Get existing DNS records for this domain
-		existingRecords, err := session.InfoDnsRecords(domain)
+		result, err := m.reconcileDomain(ctx, session, domain, domainRecords, hostIP)
 		if err != nil {
-			log.Printf("Warning: Failed to get DNS records for %s during reconciliation: %v", domain, err)
-			errorCount += len(domainRecords)
-			continue
-		}
-
-		// Build a map of existing records
-		existingMap := make(map[string]string) // subdomain -> IP
-		for _, er := range *existingRecords {
-			if er.Type == "A" {
-				existingMap[er.Hostname] = er.Destination
-			}
+			return err
 		}
+		syncedCount += result.synced
+		skippedCount += result.skipped
+		errorCount += result.errored
+	}
 
-		// Check each persisted record
-		for _, record := range domainRecords {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-			}
+	m.applyWildcards(session, m.planWildcards(hostIP))
 
-			existingIP, exists := existingMap[record.Subdomain]
+	log.Printf("Reconciliation complete: %d synced, %d already in sync, %d errors", syncedCount, skippedCount, errorCount)
+	m.bus.Publish(ctx, events.ReconcileCompleted{Synced: syncedCount, InSync: skippedCount, Errored: errorCount})
+	return nil
+}
 
-			// Determine expected IP (use current host IP, not persisted IP, to handle IP changes)
-			expectedIP := hostIP
+// expectedIP returns the address a persisted record should point at: the current host
+// IP rather than the persisted one to follow IP changes, the persisted container address,
+// or its custom destination resolved again
+func (m *Manager) expectedIP(record state.DNSRecord, hostIP string) (string, error) {
+	var ip string
+	switch {
+	case record.Destination != "":
+		resolved, err := resolveDestination(record.Destination)
+		if err != nil {
+			return "", err
+		}
+		ip = resolved
+	case m.config.PublishContainerIP():
+		ip = record.IP
+	default:
+		return m.domainHostIP(record.Domain, hostIP)
+	}
+	if err := m.checkAllowedIP(ip); err != nil {
+		return "", err
+	}
+	return ip, nil
+}
 
-			if exists && existingIP == expectedIP {
-				log.Printf("Reconciliation: %s is in sync (IP: %s)", record.Hostname, existingIP)
-				skippedCount++
-				m.knownHosts[record.Hostname] = true
-				continue
-			}
+// applyZoneSettings updates the zone when the configured settings for the domain
+// diverge from the actual ones and returns the zone as it is afterwards. Domains without
+// configured settings are left alone.
+func (m *Manager) applyZoneSettings(session netcup.DnsSession, domain string, zone *netcup.DnsZoneData) (*netcup.DnsZoneData, error) {
+	settings, ok := m.config.ZoneSettings[domain]
+	if !ok || zone == nil {
+		return zone, nil
+	}
+	if ttl, ok := m.ttlOverrides[domain]; ok {
+		settings.TTL = ttl
+	}
+	if settings.TTL != "" {
+		if err := validateTTL(settings.TTL); err != nil {
+			return zone, fmt.Errorf("invalid zone settings for %s: %w", domain, err)
+		}
+	}
 
-			if m.config.DryRun {
-				if exists {
-					log.Printf("[DRY RUN] Reconciliation would update: %s (%s -> %s)", record.Hostname, existingIP, expectedIP)
-				} else {
-					log.Printf("[DRY RUN] Reconciliation would create: %s -> %s", record.Hostname, expectedIP)
-				}
-				m.knownHosts[record.Hostname] = true
-				skippedCount++
-				continue
-			}
+	updated := *zone
+	changed := false
+	for _, field := range []struct {
+		want    string
+		current *string
+	}{
+		{settings.TTL, &updated.Ttl},
+		{settings.Refresh, &updated.Refresh},
+		{settings.Retry, &updated.Retry},
+		{settings.Expire, &updated.Expire},
+	} {
+		if field.want != "" && field.want != *field.current {
+			*field.current = field.want
+			changed = true
+		}
+	}
 
-			// Need to sync this record
-			action := "create"
-			if exists {
-				action = "update"
-			}
+	if !changed {
+		return zone, nil
+	}
 
-			log.Printf("Reconciliation: %s needs %s (%s -> %s)", record.Hostname, action, existingIP, expectedIP)
+	before, after := formatZoneSettings(zone), formatZoneSettings(&updated)
+	auditEntry := audit.Entry{
+		Action:     audit.ActionUpdate,
+		Source:     "zone_settings",
+		Hostname:   domain,
+		Domain:     domain,
+		Subdomain:  "@",
+		RecordType: "ZONE",
+		Before:     before,
+		After:      after,
+		DryRun:     m.config.DryRun,
+	}
 
-			newRecord := netcup.DnsRecord{
-				Hostname:    record.Subdomain,
-				Type:        "A",
-				Destination: expectedIP,
-				Priority:    "0",
-			}
+	if m.config.DryRun {
+		log.Printf("[DRY RUN] Would update zone settings for %s (%s -> %s)", domain, before, after)
+		m.notifier.SendInfo(fmt.Sprintf("[DRY RUN] Would update zone %s (%s -> %s)", domain, before, after))
+		m.recordAudit(auditEntry)
+		return zone, nil
+	}
 
-			recordSet := []netcup.DnsRecord{newRecord}
-			_, err = session.UpdateDnsRecords(domain, &recordSet)
-			if err != nil {
-				log.Printf("Warning: Failed to reconcile DNS for %s: %v", record.Hostname, err)
-				m.notifier.SendError(fmt.Sprintf("Reconciliation failed for %s: %v", record.Hostname, err))
-				errorCount++
-				continue
-			}
+	log.Printf("Updating zone settings for %s (%s -> %s)", domain, before, after)
+	if _, err := session.UpdateDnsZone(domain, &updated); err != nil {
+		auditEntry.Error = err.Error()
+		m.recordAudit(auditEntry)
+		return zone, fmt.Errorf("failed to update zone settings for %s: %w", domain, err)
+	}
 
-			// Update persisted state with new IP
-			if err := m.stateManager.UpdateRecord(record.Hostname, record.Domain, record.Subdomain, expectedIP, "A"); err != nil {
-				log.Printf("Warning: Failed to update persisted state for %s: %v", record.Hostname, err)
-			}
+	m.recordAudit(auditEntry)
+	m.notifier.SendSuccess(fmt.Sprintf("Updated zone %s (%s -> %s)", domain, before, after))
+	return &updated, nil
+}
 
-			m.knownHosts[record.Hostname] = true
-			syncedCount++
+// formatZoneSettings renders the tunable zone parameters for logs and audit entries
+func formatZoneSettings(zone *netcup.DnsZoneData) string {
+	return fmt.Sprintf("ttl=%s refresh=%s retry=%s expire=%s", zone.Ttl, zone.Refresh, zone.Retry, zone.Expire)
+}
 
-			m.notifier.SendSuccess(fmt.Sprintf("Reconciled DNS: %s -> %s", record.Hostname, expectedIP))
-			log.Printf("Reconciliation: Successfully synced %s", record.Hostname)
-		}
+// recordAudit writes an entry to the audit log, logging (but not propagating) failures
+func (m *Manager) recordAudit(entry audit.Entry) {
+	if err := m.auditLogger.Record(entry); err != nil {
+		log.Printf("Warning: Failed to write audit log entry for %s: %v", entry.Hostname, err)
 	}
-
-	log.Printf("Reconciliation complete: %d synced, %d already in sync, %d errors", syncedCount, skippedCount, errorCount)
-	return nil
 }
 
 func getHostIP() (string, error) {
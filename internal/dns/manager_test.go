@@ -2,10 +2,22 @@ package dns
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/alex289/docker-traefik-netcup-companion/internal/audit"
 	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
 	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/events"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/netcup/netcuptest"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
 )
 
 func TestNewManager(t *testing.T) {
@@ -17,7 +29,7 @@ func TestNewManager(t *testing.T) {
 		DryRun:         false,
 	}
 
-	manager := NewManager(cfg, nil)
+	manager := NewManager(cfg, netcup.NewFakeAPI(), nil)
 
 	if manager == nil {
 		t.Fatal("NewManager() returned nil")
@@ -45,7 +57,8 @@ func TestProcessHostInfo_DryRun(t *testing.T) {
 		DryRun:         true, // Enable dry run mode
 	}
 
-	manager := NewManager(cfg, nil)
+	api := newFailingLoginAPI()
+	manager := NewManager(cfg, api, nil)
 	ctx := context.Background()
 
 	info := docker.HostInfo{
@@ -67,6 +80,31 @@ func TestProcessHostInfo_DryRun(t *testing.T) {
 	if err != nil && !contains(err.Error(), "failed to login") {
 		t.Errorf("Expected login failure error, got: %v", err)
 	}
+
+	// With valid credentials, dry run reads the zone but never writes
+	api = netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	manager = NewManager(cfg, api, nil)
+
+	if err := manager.ProcessHostInfo(ctx, info); err != nil {
+		t.Fatalf("ProcessHostInfo() in dry run error = %v", err)
+	}
+	if api.CallCount("infoDnsRecords") != 1 {
+		t.Errorf("infoDnsRecords calls = %d, want 1", api.CallCount("infoDnsRecords"))
+	}
+	if api.CallCount("updateDnsRecords") != 0 {
+		t.Errorf("updateDnsRecords calls = %d, want 0 in dry run", api.CallCount("updateDnsRecords"))
+	}
+	if !manager.knownHosts[info.Hostname] {
+		t.Error("Host not marked as known after dry run")
+	}
+}
+
+// newFailingLoginAPI returns a fake whose login is rejected like invalid credentials
+func newFailingLoginAPI() *netcup.FakeAPI {
+	api := netcup.NewFakeAPI()
+	api.LoginErr = errors.New("Login failed: (4013) 'error' 'Validation Error.' 'Api key missing'")
+	return api
 }
 
 func contains(s, substr string) bool {
@@ -91,7 +129,7 @@ func TestProcessHostInfo_DuplicateHost(t *testing.T) {
 		DryRun:         false, // Disable dry run to test duplicate logic
 	}
 
-	manager := NewManager(cfg, nil)
+	manager := NewManager(cfg, netcup.NewFakeAPI(), nil)
 
 	// Manually add host to knownHosts
 	info := docker.HostInfo{
@@ -127,7 +165,7 @@ func TestProcessHostInfo_MultipleHosts(t *testing.T) {
 		DryRun:         false, // Disable dry run
 	}
 
-	manager := NewManager(cfg, nil)
+	manager := NewManager(cfg, netcup.NewFakeAPI(), nil)
 
 	hosts := []docker.HostInfo{
 		{
@@ -198,7 +236,7 @@ func TestManager_ConcurrentAccess(t *testing.T) {
 		DryRun:         false, // Disable dry run
 	}
 
-	manager := NewManager(cfg, nil)
+	manager := NewManager(cfg, netcup.NewFakeAPI(), nil)
 
 	// Pre-populate knownHosts to avoid API calls
 	manager.knownHosts["app.example.com"] = true
@@ -243,7 +281,7 @@ func TestManager_ContextCancellation(t *testing.T) {
 		DryRun:         false, // Disable dry run
 	}
 
-	manager := NewManager(cfg, nil)
+	manager := NewManager(cfg, netcup.NewFakeAPI(), nil)
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // Cancel immediately
 
@@ -255,10 +293,638 @@ func TestManager_ContextCancellation(t *testing.T) {
 		Subdomain:     "app",
 	}
 
-	// With cancelled context and invalid credentials, this should fail
+	// With cancelled context and an unknown zone, this should fail
 	err := manager.ProcessHostInfo(ctx, info)
 	if err == nil {
-		t.Error("ProcessHostInfo() with cancelled context and invalid credentials should fail")
+		t.Error("ProcessHostInfo() with cancelled context and unknown zone should fail")
 	}
 	t.Logf("ProcessHostInfo() with cancelled context returned error (expected): %v", err)
 }
+
+func TestProcessHostInfo_RemoveKnownHost(t *testing.T) {
+	cfg := &config.Config{
+		CustomerNumber: 12345,
+		APIKey:         "test-key",
+		APIPassword:    "test-password",
+		DefaultTTL:     "300",
+		DryRun:         false,
+	}
+
+	manager := NewManager(cfg, newFailingLoginAPI(), nil)
+	manager.knownHosts["app.example.com"] = true
+
+	info := docker.HostInfo{
+		ContainerID:   "test123",
+		ContainerName: "test-container",
+		Hostname:      "app.example.com",
+		Domain:        "example.com",
+		Subdomain:     "app",
+		Remove:        true,
+	}
+
+	// Removals must not be short-circuited by knownHosts; with invalid credentials the login fails
+	err := manager.ProcessHostInfo(context.Background(), info)
+	if err == nil {
+		t.Error("ProcessHostInfo() removal with invalid credentials should fail")
+	}
+	if err != nil && !contains(err.Error(), "failed to login") {
+		t.Errorf("Expected login failure error, got: %v", err)
+	}
+}
+
+func TestApplyZoneSettings(t *testing.T) {
+	zone := &netcup.DnsZoneData{
+		DomainName: "example.com",
+		Ttl:        "86400",
+		Refresh:    "28800",
+		Retry:      "7200",
+		Expire:     "1209600",
+	}
+
+	cfg := &config.Config{
+		CustomerNumber: 12345,
+		APIKey:         "test-key",
+		APIPassword:    "test-password",
+		DryRun:         true,
+		ZoneSettings: map[string]config.ZoneSettings{
+			"example.com": {TTL: "300"},
+			"in-sync.com": {TTL: "86400"},
+		},
+	}
+
+	manager := NewManager(cfg, netcup.NewFakeAPI(), nil)
+
+	// Domains without settings, in-sync zones and dry-run changes must not touch the API
+	for _, domain := range []string{"unconfigured.com", "in-sync.com", "example.com"} {
+		if _, err := manager.applyZoneSettings(nil, domain, zone); err != nil {
+			t.Errorf("applyZoneSettings(%s) error = %v", domain, err)
+		}
+	}
+
+	if zone.Ttl != "86400" {
+		t.Errorf("applyZoneSettings() mutated the fetched zone, Ttl = %v", zone.Ttl)
+	}
+}
+
+func TestFormatZoneSettings(t *testing.T) {
+	zone := &netcup.DnsZoneData{Ttl: "300", Refresh: "28800", Retry: "7200", Expire: "1209600"}
+
+	want := "ttl=300 refresh=28800 retry=7200 expire=1209600"
+	if got := formatZoneSettings(zone); got != want {
+		t.Errorf("formatZoneSettings() = %v, want %v", got, want)
+	}
+}
+
+func TestResolveHostIP(t *testing.T) {
+	cfg := &config.Config{
+		CustomerNumber: 12345,
+		APIKey:         "test-key",
+		APIPassword:    "test-password",
+		HostIP:         "192.0.2.10",
+	}
+
+	manager := NewManager(cfg, netcup.NewFakeAPI(), nil)
+	ip, err := manager.resolveHostIP()
+	if err != nil {
+		t.Fatalf("resolveHostIP() error = %v", err)
+	}
+	if ip != "192.0.2.10" {
+		t.Errorf("resolveHostIP() = %v, want configured HOST_IP", ip)
+	}
+
+	// Failover destinations take precedence over HOST_IP
+	cfg.FailoverPrimaryIP = "203.0.113.1"
+	cfg.FailoverSecondaryIP = "198.51.100.1"
+	manager = NewManager(cfg, netcup.NewFakeAPI(), nil)
+	ip, err = manager.resolveHostIP()
+	if err != nil {
+		t.Fatalf("resolveHostIP() error = %v", err)
+	}
+	if ip != "203.0.113.1" {
+		t.Errorf("resolveHostIP() = %v, want failover primary", ip)
+	}
+}
+
+func TestVerifyZones_InvalidCredentials(t *testing.T) {
+	cfg := &config.Config{
+		CustomerNumber: 12345,
+		APIKey:         "test-key",
+		APIPassword:    "test-password",
+	}
+
+	manager := NewManager(cfg, newFailingLoginAPI(), nil)
+
+	results, err := manager.VerifyZones(context.Background(), []string{"example.com"})
+	if err == nil {
+		t.Error("VerifyZones() with invalid credentials should fail")
+	}
+	if results != nil {
+		t.Errorf("VerifyZones() results = %v, want nil on login failure", results)
+	}
+}
+
+func TestSyncHosts_SkipsKnownAndDuplicateHosts(t *testing.T) {
+	cfg := &config.Config{
+		CustomerNumber: 12345,
+		APIKey:         "test-key",
+		APIPassword:    "test-password",
+		HostIP:         "203.0.113.1",
+	}
+
+	api := newFailingLoginAPI()
+	manager := NewManager(cfg, api, nil)
+	manager.knownHosts["app.example.com"] = true
+	manager.knownHosts["api.example.com"] = true
+
+	hosts := []docker.HostInfo{
+		{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"},
+		{Hostname: "api.example.com", Domain: "example.com", Subdomain: "api"},
+		{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"},
+	}
+
+	// All hosts are known, so no login must be attempted
+	if err := manager.SyncHosts(context.Background(), hosts); err != nil {
+		t.Errorf("SyncHosts() with only known hosts error = %v, want nil", err)
+	}
+	if api.CallCount("login") != 0 {
+		t.Errorf("login calls = %d, want 0", api.CallCount("login"))
+	}
+
+	// A new host triggers a single login, which fails with invalid credentials
+	hosts = append(hosts, docker.HostInfo{Hostname: "new.example.com", Domain: "example.com", Subdomain: "new"})
+	err := manager.SyncHosts(context.Background(), hosts)
+	if err == nil || !contains(err.Error(), "failed to login") {
+		t.Errorf("SyncHosts() error = %v, want login failure", err)
+	}
+}
+
+func TestSyncHosts_RemovesDroppedHosts(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com",
+		netcup.DnsRecord{Hostname: "old", Type: "A", Destination: "203.0.113.1"},
+		netcup.DnsRecord{Hostname: "app", Type: "A", Destination: "203.0.113.1"},
+	)
+	manager := NewManager(testConfig(), api, nil)
+
+	hosts := []docker.HostInfo{
+		{Hostname: "old.example.com", Domain: "example.com", Subdomain: "old", ContainerName: "web", Remove: true},
+		{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app", ContainerName: "web"},
+	}
+	if err := manager.SyncHosts(context.Background(), hosts); err != nil {
+		t.Fatalf("SyncHosts() error = %v", err)
+	}
+
+	records := api.Records("example.com")
+	if len(records) != 1 || records[0].Hostname != "app" {
+		t.Errorf("records = %+v, want only app", records)
+	}
+}
+
+func TestSummarizeChanges(t *testing.T) {
+	entries := []audit.Entry{
+		{Action: audit.ActionCreate, Hostname: "app.example.com", After: "203.0.113.1"},
+		{Action: audit.ActionCreate, Hostname: "api.example.com", After: "203.0.113.1"},
+		{Action: audit.ActionUpdate, Hostname: "www.example.com", Before: "198.51.100.1", After: "203.0.113.1"},
+	}
+
+	want := "created app.example.com -> 203.0.113.1, api.example.com -> 203.0.113.1; updated www.example.com (198.51.100.1 -> 203.0.113.1)"
+	if got := summarizeChanges(entries); got != want {
+		t.Errorf("summarizeChanges() = %v, want %v", got, want)
+	}
+}
+
+func testConfig() *config.Config {
+	return &config.Config{
+		CustomerNumber: 12345,
+		APIKey:         "test-key",
+		APIPassword:    "test-password",
+		DefaultTTL:     "300",
+		HostIP:         "203.0.113.1",
+	}
+}
+
+func TestProcessHostInfo_CreateRecord(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	manager := NewManager(testConfig(), api, nil)
+
+	info := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+
+	records := api.Records("example.com")
+	if len(records) != 1 {
+		t.Fatalf("Zone has %d records, want 1", len(records))
+	}
+	if records[0].Hostname != "app" || records[0].Type != "A" || records[0].Destination != "203.0.113.1" {
+		t.Errorf("Created record = %v, want app A 203.0.113.1", records[0].String())
+	}
+	if api.CallCount("logout") != 1 {
+		t.Errorf("logout calls = %d, want 1", api.CallCount("logout"))
+	}
+
+	// Processing the same host again must not hit the API
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() second call error = %v", err)
+	}
+	if api.CallCount("login") != 1 {
+		t.Errorf("login calls = %d, want 1", api.CallCount("login"))
+	}
+}
+
+func TestProcessHostInfo_RecordInSync(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com", netcup.DnsRecord{Hostname: "app", Type: "A", Destination: "203.0.113.1"})
+	manager := NewManager(testConfig(), api, nil)
+
+	info := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+
+	if api.CallCount("updateDnsRecords") != 0 {
+		t.Errorf("updateDnsRecords calls = %d, want 0 for in-sync record", api.CallCount("updateDnsRecords"))
+	}
+}
+
+func TestProcessHostInfo_UpdateFailure(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	api.Errors["updateDnsRecords"] = errors.New("UpdateDnsRecords failed: (5028) 'error' 'Invalid record'")
+	manager := NewManager(testConfig(), api, nil)
+
+	info := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	err := manager.ProcessHostInfo(context.Background(), info)
+	if err == nil || !contains(err.Error(), "failed to update DNS records") {
+		t.Errorf("ProcessHostInfo() error = %v, want update failure", err)
+	}
+	if manager.knownHosts[info.Hostname] {
+		t.Error("Host marked as known after failed update")
+	}
+}
+
+func TestSyncHosts_OneUpdatePerZone(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com", netcup.DnsRecord{Hostname: "www", Type: "A", Destination: "203.0.113.1"})
+	api.AddZone("other.de")
+	manager := NewManager(testConfig(), api, nil)
+
+	hosts := []docker.HostInfo{
+		{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"},
+		{Hostname: "api.example.com", Domain: "example.com", Subdomain: "api"},
+		{Hostname: "www.example.com", Domain: "example.com", Subdomain: "www"},
+		{Hostname: "shop.other.de", Domain: "other.de", Subdomain: "shop"},
+	}
+
+	if err := manager.SyncHosts(context.Background(), hosts); err != nil {
+		t.Fatalf("SyncHosts() error = %v", err)
+	}
+
+	if api.CallCount("login") != 1 {
+		t.Errorf("login calls = %d, want 1", api.CallCount("login"))
+	}
+	if api.CallCount("infoDnsRecords") != 2 {
+		t.Errorf("infoDnsRecords calls = %d, want 2", api.CallCount("infoDnsRecords"))
+	}
+	if api.CallCount("updateDnsRecords") != 2 {
+		t.Errorf("updateDnsRecords calls = %d, want 2", api.CallCount("updateDnsRecords"))
+	}
+	if len(api.Records("example.com")) != 3 {
+		t.Errorf("example.com has %d records, want 3", len(api.Records("example.com")))
+	}
+	for _, host := range hosts {
+		if !manager.knownHosts[host.Hostname] {
+			t.Errorf("Host %s not marked as known", host.Hostname)
+		}
+	}
+}
+
+func TestSyncHosts_DryRunLabel(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com", netcup.DnsRecord{Id: "1", Hostname: "old", Type: "A", Destination: "198.51.100.1"})
+	manager := NewManager(testConfig(), api, nil)
+	ctx := context.Background()
+
+	hosts := []docker.HostInfo{
+		{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"},
+		{Hostname: "new.example.com", Domain: "example.com", Subdomain: "new", DryRun: true},
+	}
+	if err := manager.SyncHosts(ctx, hosts); err != nil {
+		t.Fatalf("SyncHosts() error = %v", err)
+	}
+
+	// Only the host without the label is written, both count as processed
+	records := api.Records("example.com")
+	if len(records) != 2 || slices.ContainsFunc(records, func(r netcup.DnsRecord) bool { return r.Hostname == "new" }) {
+		t.Errorf("Records = %+v, want old and app only", records)
+	}
+	for _, host := range hosts {
+		if !manager.knownHosts[host.Hostname] {
+			t.Errorf("Host %s not marked as known", host.Hostname)
+		}
+	}
+
+	// Removals of labeled containers are not written either
+	removal := docker.HostInfo{Hostname: "old.example.com", Domain: "example.com", Subdomain: "old", Remove: true, DryRun: true}
+	if err := manager.ProcessHostInfo(ctx, removal); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if got := len(api.Records("example.com")); got != 2 {
+		t.Errorf("example.com has %d records after a dry-run removal, want 2", got)
+	}
+}
+
+func TestApplyZoneSettings_UpdatesZone(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+
+	cfg := testConfig()
+	cfg.ZoneSettings = map[string]config.ZoneSettings{"example.com": {TTL: "300", Retry: "3600"}}
+	manager := NewManager(cfg, api, nil)
+
+	info := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+
+	zone := api.Zone("example.com")
+	if zone.Ttl != "300" || zone.Retry != "3600" {
+		t.Errorf("Zone settings = %s, want ttl=300 retry=3600", formatZoneSettings(zone))
+	}
+	if zone.Refresh != "28800" {
+		t.Errorf("Refresh = %v, want unchanged 28800", zone.Refresh)
+	}
+}
+
+func TestContainerIPSource(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com", netcup.DnsRecord{Hostname: "api", Type: "A", Destination: "203.0.113.1"})
+
+	cfg := testConfig()
+	cfg.HostIP = ""
+	cfg.IPSource = config.IPSourceContainer
+	manager := NewManager(cfg, api, nil)
+	ctx := context.Background()
+
+	if err := manager.ProcessHostInfo(ctx, docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app", IP: "192.168.1.50"}); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if err := manager.SyncHosts(ctx, []docker.HostInfo{
+		{Hostname: "api.example.com", Domain: "example.com", Subdomain: "api", IP: "192.168.1.51"},
+		{Hostname: "web.example.com", Domain: "example.com", Subdomain: "web", IP: "192.168.1.52"},
+	}); err != nil {
+		t.Fatalf("SyncHosts() error = %v", err)
+	}
+
+	want := map[string]string{"app": "192.168.1.50", "api": "192.168.1.51", "web": "192.168.1.52"}
+	records := api.Records("example.com")
+	if len(records) != len(want) {
+		t.Fatalf("Zone has %d records, want %d", len(records), len(want))
+	}
+	for _, record := range records {
+		if record.Destination != want[record.Hostname] {
+			t.Errorf("%s -> %s, want %s", record.Hostname, record.Destination, want[record.Hostname])
+		}
+	}
+}
+
+func TestProcessHostInfo_PersistsComposeProject(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	manager := NewManager(testConfig(), api, stateManager)
+
+	info := docker.HostInfo{
+		Hostname:       "shop.example.com",
+		Domain:         "example.com",
+		Subdomain:      "shop",
+		DockerHost:     "unix:///var/run/docker.sock",
+		ComposeProject: "shop",
+		ComposeService: "web",
+	}
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+
+	record, ok := stateManager.GetRecord("shop.example.com")
+	if !ok {
+		t.Fatal("Record not persisted")
+	}
+	if record.ComposeProject != "shop" || record.ComposeService != "web" || record.DockerHost != info.DockerHost {
+		t.Errorf("Persisted origin = %q %q/%q, want %q shop/web", record.DockerHost, record.ComposeProject, record.ComposeService, info.DockerHost)
+	}
+}
+
+func TestResolveDestination(t *testing.T) {
+	original := lookupIP
+	defer func() { lookupIP = original }()
+	lookupIP = func(host string) ([]net.IP, error) {
+		switch host {
+		case "cdn.example.net":
+			return []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("198.51.100.10")}, nil
+		case "v6only.example.net":
+			return []net.IP{net.ParseIP("2001:db8::1")}, nil
+		}
+		return nil, fmt.Errorf("no such host")
+	}
+
+	tests := []struct {
+		destination string
+		want        string
+		wantErr     bool
+	}{
+		{destination: "203.0.113.7", want: "203.0.113.7"},
+		{destination: "cdn.example.net", want: "198.51.100.10"},
+		{destination: "2001:db8::1", wantErr: true},
+		{destination: "v6only.example.net", wantErr: true},
+		{destination: "unknown.example.net", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.destination, func(t *testing.T) {
+			got, err := resolveDestination(tt.destination)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveDestination() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveDestination() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomDestination(t *testing.T) {
+	original := lookupIP
+	defer func() { lookupIP = original }()
+	cdnIP := "198.51.100.10"
+	lookupIP = func(host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP(cdnIP)}, nil
+	}
+
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	manager := NewManager(testConfig(), api, stateManager)
+	ctx := context.Background()
+
+	if err := manager.ProcessHostInfo(ctx, docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app", Destination: "203.0.113.7"}); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if err := manager.SyncHosts(ctx, []docker.HostInfo{
+		{Hostname: "cdn.example.com", Domain: "example.com", Subdomain: "cdn", Destination: "cdn.example.net"},
+		{Hostname: "web.example.com", Domain: "example.com", Subdomain: "web"},
+	}); err != nil {
+		t.Fatalf("SyncHosts() error = %v", err)
+	}
+
+	assertRecords := func(want map[string]string) {
+		t.Helper()
+		for _, record := range api.Records("example.com") {
+			if record.Destination != want[record.Hostname] {
+				t.Errorf("%s -> %s, want %s", record.Hostname, record.Destination, want[record.Hostname])
+			}
+		}
+	}
+	assertRecords(map[string]string{"app": "203.0.113.7", "cdn": cdnIP, "web": testConfig().HostIP})
+
+	record, _ := stateManager.GetRecord("cdn.example.com")
+	if record.Destination != "cdn.example.net" {
+		t.Errorf("Persisted destination = %q, want cdn.example.net", record.Destination)
+	}
+
+	// Reconciliation keeps custom destinations and follows changes of a hostname target
+	cdnIP = "198.51.100.20"
+	manager.knownHosts = make(map[string]bool)
+	if err := manager.ReconcileFromState(ctx); err != nil {
+		t.Fatalf("ReconcileFromState() error = %v", err)
+	}
+	assertRecords(map[string]string{"app": "203.0.113.7", "cdn": cdnIP, "web": testConfig().HostIP})
+}
+
+func TestDomainIPs(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	api.AddZone("other.de")
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+
+	cfg := testConfig()
+	cfg.DomainIPs = map[string]string{"other.de": "198.51.100.5"}
+	manager := NewManager(cfg, api, stateManager)
+	ctx := context.Background()
+
+	if err := manager.ProcessHostInfo(ctx, docker.HostInfo{Hostname: "app.other.de", Domain: "other.de", Subdomain: "app"}); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if err := manager.SyncHosts(ctx, []docker.HostInfo{
+		{Hostname: "web.example.com", Domain: "example.com", Subdomain: "web"},
+		{Hostname: "web.other.de", Domain: "other.de", Subdomain: "web"},
+		{Hostname: "cdn.other.de", Domain: "other.de", Subdomain: "cdn", Destination: "203.0.113.7"},
+	}); err != nil {
+		t.Fatalf("SyncHosts() error = %v", err)
+	}
+
+	assertRecords := func(domain string, want map[string]string) {
+		t.Helper()
+		records := api.Records(domain)
+		if len(records) != len(want) {
+			t.Fatalf("%s has %d records, want %d", domain, len(records), len(want))
+		}
+		for _, record := range records {
+			if record.Destination != want[record.Hostname] {
+				t.Errorf("%s.%s -> %s, want %s", record.Hostname, domain, record.Destination, want[record.Hostname])
+			}
+		}
+	}
+	wantOther := map[string]string{"app": "198.51.100.5", "web": "198.51.100.5", "cdn": "203.0.113.7"}
+	assertRecords("example.com", map[string]string{"web": testConfig().HostIP})
+	assertRecords("other.de", wantOther)
+
+	// Reconciliation keeps the override when the host IP changes
+	manager.config.HostIP = "203.0.113.2"
+	manager.knownHosts = make(map[string]bool)
+	if err := manager.ReconcileFromState(ctx); err != nil {
+		t.Fatalf("ReconcileFromState() error = %v", err)
+	}
+	assertRecords("example.com", map[string]string{"web": "203.0.113.2"})
+	assertRecords("other.de", wantOther)
+}
+
+func TestProcessHostInfo_NetcupServer(t *testing.T) {
+	server := netcuptest.NewServer(nil)
+	defer server.Close()
+	server.API.AddZone("example.com")
+	manager := NewManager(testConfig(), server.NewClient(nil), nil)
+	ctx := context.Background()
+
+	// A transient outage is retried by the client
+	server.FailNext("updateDnsRecords", netcuptest.Fault{HTTPStatus: http.StatusServiceUnavailable, Message: "maintenance"})
+	if err := manager.ProcessHostInfo(ctx, docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if records := server.API.Records("example.com"); len(records) != 1 || records[0].Destination != testConfig().HostIP {
+		t.Errorf("Records = %+v, want app -> %s", records, testConfig().HostIP)
+	}
+	if got := server.Requests("updateDnsRecords"); got != 2 {
+		t.Errorf("Requests(updateDnsRecords) = %d, want 2", got)
+	}
+
+	// A Netcup error fails the host
+	server.FailNext("updateDnsRecords", netcuptest.Fault{StatusCode: 4013, Message: "Validation error"})
+	err := manager.ProcessHostInfo(ctx, docker.HostInfo{Hostname: "web.example.com", Domain: "example.com", Subdomain: "web"})
+	if err == nil || !strings.Contains(err.Error(), "Validation error") {
+		t.Errorf("ProcessHostInfo() error = %v, want the Netcup error", err)
+	}
+}
+
+func TestEventBus(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	bus := events.NewBus()
+	manager := NewManagerWithOptions(testConfig(), api, nil, &ManagerOptions{Events: bus})
+	ctx := context.Background()
+
+	var published []string
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		published = append(published, fmt.Sprintf("%T", event))
+	})
+
+	app := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	bus.Publish(ctx, events.ForHost(app))
+	if records := api.Records("example.com"); len(records) != 1 {
+		t.Fatalf("Records = %+v, want app", records)
+	}
+
+	api.Errors["updateDnsRecords"] = errors.New("update failed")
+	bus.Publish(ctx, events.ContainerStarted{Host: docker.HostInfo{Hostname: "web.example.com", Domain: "example.com", Subdomain: "web"}})
+	delete(api.Errors, "updateDnsRecords")
+
+	app.Remove = true
+	bus.Publish(ctx, events.ForHost(app))
+	if records := api.Records("example.com"); len(records) != 0 {
+		t.Errorf("Records = %+v, want none", records)
+	}
+	if manager.knownHosts["app.example.com"] {
+		t.Error("app.example.com still known after removal")
+	}
+
+	// Record events are delivered before the container event reaches later subscribers
+	want := []string{
+		"events.RecordCreated", "events.ContainerStarted",
+		"events.RecordFailed", "events.ContainerStarted",
+		"events.RecordRemoved", "events.HostRemoved",
+	}
+	if fmt.Sprint(published) != fmt.Sprint(want) {
+		t.Errorf("published = %v, want %v", published, want)
+	}
+}
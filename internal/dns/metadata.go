@@ -0,0 +1,114 @@
+package dns
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+// recordMetadata is the JSON content of the TXT record accompanying a managed A record
+type recordMetadata struct {
+	Container      string    `json:"container,omitempty"`
+	ComposeProject string    `json:"compose_project,omitempty"`
+	ComposeService string    `json:"compose_service,omitempty"`
+	DockerHost     string    `json:"docker_host,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// sameOrigin reports whether both describe the same container, ignoring the creation time
+func (r recordMetadata) sameOrigin(other recordMetadata) bool {
+	r.CreatedAt, other.CreatedAt = time.Time{}, time.Time{}
+	return r == other
+}
+
+// metadataHostname returns the name of the metadata record of subdomain, e.g. _meta.app
+// for app and _meta for the zone apex. Wildcards have no metadata record, because a
+// wildcard label is only allowed in front.
+func (m *Manager) metadataHostname(subdomain string) (string, bool) {
+	if subdomain == "*" || strings.HasPrefix(subdomain, "*.") {
+		return "", false
+	}
+	if subdomain == "@" {
+		return m.config.MetadataRecordPrefix, true
+	}
+	return m.config.MetadataRecordPrefix + "." + subdomain, true
+}
+
+// metadataRecords returns the metadata records of subdomain among records
+func (m *Manager) metadataRecords(records []netcup.DnsRecord, subdomain string) []netcup.DnsRecord {
+	name, ok := m.metadataHostname(subdomain)
+	if !ok {
+		return nil
+	}
+	return netcup.FindRecords(records, name, "TXT")
+}
+
+// metadataChange returns the metadata record to send for the A record of info. An
+// existing record describing the same container is left alone; otherwise it is updated
+// in place, keeping its creation time. needed is false when nothing has to be written.
+func (m *Manager) metadataChange(records []netcup.DnsRecord, info docker.HostInfo) (change netcup.DnsRecord, needed bool) {
+	if !m.config.MetadataRecords {
+		return netcup.DnsRecord{}, false
+	}
+	name, ok := m.metadataHostname(info.Subdomain)
+	if !ok {
+		return netcup.DnsRecord{}, false
+	}
+
+	want := recordMetadata{
+		Container:      info.ContainerName,
+		ComposeProject: info.ComposeProject,
+		ComposeService: info.ComposeService,
+		DockerHost:     info.DockerHost,
+		CreatedAt:      time.Now().UTC().Truncate(time.Second),
+	}
+	change = netcup.DnsRecord{Hostname: name, Type: "TXT", Priority: "0"}
+
+	if existing := m.metadataRecords(records, info.Subdomain); len(existing) > 0 {
+		change = existing[0]
+		var current recordMetadata
+		if err := json.Unmarshal([]byte(change.Destination), &current); err == nil {
+			if current.sameOrigin(want) {
+				return netcup.DnsRecord{}, false
+			}
+			if !current.CreatedAt.IsZero() {
+				want.CreatedAt = current.CreatedAt
+			}
+		}
+	}
+
+	content, err := json.Marshal(want)
+	if err != nil {
+		log.Printf("Warning: Failed to encode metadata of %s: %v", info.Hostname, err)
+		return netcup.DnsRecord{}, false
+	}
+	change.Destination = string(content)
+
+	if err := netcup.ValidateRecord(change); err != nil {
+		log.Printf("Warning: Skipping metadata record of %s: %v", info.Hostname, err)
+		return netcup.DnsRecord{}, false
+	}
+	return change, true
+}
+
+// writeMetadata applies metadata records on their own, for A records that are already
+// in sync. Failures are logged only, metadata must never block record publishing.
+func (m *Manager) writeMetadata(session netcup.DnsSession, domain string, records []netcup.DnsRecord, dryRun bool) {
+	if len(records) == 0 {
+		return
+	}
+
+	if dryRun {
+		log.Printf("[DRY RUN] Would write %d metadata records to %s", len(records), domain)
+		return
+	}
+
+	log.Printf("Writing %d metadata records to %s", len(records), domain)
+	if _, err := session.UpdateDnsRecords(domain, &records); err != nil {
+		log.Printf("Warning: Failed to write metadata records to %s: %v", domain, err)
+	}
+}
@@ -0,0 +1,132 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestMetadataHostname(t *testing.T) {
+	manager := NewManager(&config.Config{MetadataRecordPrefix: "_meta"}, netcup.NewFakeAPI(), nil)
+
+	tests := []struct {
+		subdomain string
+		want      string
+		wantOK    bool
+	}{
+		{subdomain: "app", want: "_meta.app", wantOK: true},
+		{subdomain: "api.v2", want: "_meta.api.v2", wantOK: true},
+		{subdomain: "@", want: "_meta", wantOK: true},
+		{subdomain: "*", wantOK: false},
+		{subdomain: "*.apps", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subdomain, func(t *testing.T) {
+			got, ok := manager.metadataHostname(tt.subdomain)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("metadataHostname(%q) = %q, %v, want %q, %v", tt.subdomain, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// metadataOf returns the decoded metadata record of name in the zone
+func metadataOf(t *testing.T, api *netcup.FakeAPI, name string) (recordMetadata, bool) {
+	t.Helper()
+
+	for _, record := range api.Records("example.com") {
+		if record.Type == "TXT" && record.Hostname == name {
+			var metadata recordMetadata
+			if err := json.Unmarshal([]byte(record.Destination), &metadata); err != nil {
+				t.Fatalf("metadata record %s is not valid JSON: %v", name, err)
+			}
+			return metadata, true
+		}
+	}
+	return recordMetadata{}, false
+}
+
+func TestMetadataRecords(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com", netcup.DnsRecord{Id: "1", Hostname: "www", Type: "A", Destination: "203.0.113.1"})
+	cfg := testConfig()
+	cfg.MetadataRecords = true
+	cfg.MetadataRecordPrefix = "_meta"
+	manager := NewManager(cfg, api, nil)
+	ctx := context.Background()
+
+	app := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app", ContainerName: "app-1", ComposeProject: "shop", ComposeService: "app"}
+	if err := manager.ProcessHostInfo(ctx, app); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	metadata, ok := metadataOf(t, api, "_meta.app")
+	if !ok {
+		t.Fatal("no metadata record for app")
+	}
+	if metadata.Container != "app-1" || metadata.ComposeProject != "shop" || metadata.CreatedAt.IsZero() {
+		t.Errorf("metadata = %+v, want container app-1 of project shop with a creation time", metadata)
+	}
+
+	// An A record already in sync still gets its metadata record
+	www := docker.HostInfo{Hostname: "www.example.com", Domain: "example.com", Subdomain: "www", ContainerName: "web"}
+	if err := manager.SyncHosts(ctx, []docker.HostInfo{www}); err != nil {
+		t.Fatalf("SyncHosts() error = %v", err)
+	}
+	if metadata, ok := metadataOf(t, api, "_meta.www"); !ok || metadata.Container != "web" {
+		t.Errorf("metadata of www = %+v, %v, want container web", metadata, ok)
+	}
+
+	// A new container takes the record over, keeping the creation time
+	createdAt := metadata.CreatedAt
+	app.ContainerName = "app-2"
+	app.ComposeProject = ""
+	app.ComposeService = ""
+	manager.knownHosts = make(map[string]bool)
+	if err := manager.ProcessHostInfo(ctx, app); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if metadata, _ := metadataOf(t, api, "_meta.app"); metadata.Container != "app-2" || !metadata.CreatedAt.Equal(createdAt) {
+		t.Errorf("metadata = %+v, want container app-2 created at %s", metadata, createdAt.Format(time.RFC3339))
+	}
+	var txt int
+	for _, record := range api.Records("example.com") {
+		if record.Type == "TXT" && record.Hostname == "_meta.app" {
+			txt++
+		}
+	}
+	if txt != 1 {
+		t.Errorf("got %d metadata records for app, want 1", txt)
+	}
+
+	// Removing the host removes its metadata record too
+	app.Remove = true
+	if err := manager.ProcessHostInfo(ctx, app); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if _, ok := metadataOf(t, api, "_meta.app"); ok {
+		t.Error("metadata record of app was not removed")
+	}
+}
+
+func TestMetadataRecords_PruneKeepsForeignRecords(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com", netcup.DnsRecord{Id: "1", Hostname: "old", Type: "A", Destination: "203.0.113.1"})
+	cfg := testConfig()
+	cfg.MetadataRecords = true
+	cfg.MetadataRecordPrefix = "_meta"
+	manager := NewManager(cfg, api, nil)
+
+	info := docker.HostInfo{Hostname: "old.example.com", Domain: "example.com", Subdomain: "old"}
+	if err := manager.removeHost(context.Background(), info, "prune"); err != nil {
+		t.Fatalf("removeHost() error = %v", err)
+	}
+	if records := api.Records("example.com"); len(records) != 1 {
+		t.Errorf("records = %+v, want the A record without metadata to be kept", records)
+	}
+}
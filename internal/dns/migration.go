@@ -0,0 +1,136 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/audit"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+// ManagedDomains returns the domains with persisted records or configured zone settings
+func (m *Manager) ManagedDomains() []string {
+	domainSet := make(map[string]bool)
+	for domain := range m.config.ZoneSettings {
+		domainSet[domain] = true
+	}
+	if m.stateManager != nil {
+		for _, record := range m.stateManager.GetAllRecords() {
+			domainSet[record.Domain] = true
+		}
+	}
+
+	domains := make([]string, 0, len(domainSet))
+	for domain := range domainSet {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// LowerTTLs sets the zone TTL of the given domains to ttl ahead of a planned IP change
+// and returns the previous TTL of every domain that was lowered
+func (m *Manager) LowerTTLs(ctx context.Context, domains []string, ttl string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, err := m.client.Login(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to login to Netcup: %w", err)
+	}
+	defer session.Logout()
+
+	original := make(map[string]string, len(domains))
+	for _, domain := range domains {
+		previous, err := m.setZoneTTL(session, domain, ttl, "prepare_migration")
+		if err != nil {
+			return original, err
+		}
+		original[domain] = previous
+	}
+	return original, nil
+}
+
+// RestoreTTLs sets the zone TTL of each domain back to the given value. It returns
+// ErrPaused while DNS writes are paused, so the caller retries after resume.
+func (m *Manager) RestoreTTLs(ctx context.Context, ttls map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.paused {
+		return ErrPaused
+	}
+
+	session, err := m.client.Login(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to login to Netcup: %w", err)
+	}
+	defer session.Logout()
+
+	var errs []error
+	for domain, ttl := range ttls {
+		if _, err := m.setZoneTTL(session, domain, ttl, "restore_migration"); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SetTTLOverrides makes zone settings enforcement keep the given zone TTLs, so a
+// configured TTL does not undo a prepared migration. nil clears the overrides.
+func (m *Manager) SetTTLOverrides(ttls map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ttlOverrides = ttls
+}
+
+// setZoneTTL updates the TTL of a zone and returns the previous TTL. The caller holds m.mu.
+func (m *Manager) setZoneTTL(session netcup.DnsSession, domain, ttl, source string) (string, error) {
+	if err := validateTTL(ttl); err != nil {
+		return "", fmt.Errorf("invalid TTL for %s: %w", domain, err)
+	}
+
+	zone, err := session.InfoDnsZone(domain)
+	if err != nil {
+		return "", fmt.Errorf("failed to get DNS zone for %s: %w", domain, err)
+	}
+
+	previous := zone.Ttl
+	if previous == ttl {
+		log.Printf("Zone TTL of %s is already %s", domain, ttl)
+		return previous, nil
+	}
+
+	auditEntry := audit.Entry{
+		Action:     audit.ActionUpdate,
+		Source:     source,
+		Hostname:   domain,
+		Domain:     domain,
+		Subdomain:  "@",
+		RecordType: "ZONE",
+		Before:     "ttl=" + previous,
+		After:      "ttl=" + ttl,
+		DryRun:     m.config.DryRun,
+	}
+
+	if m.config.DryRun {
+		log.Printf("[DRY RUN] Would change zone TTL of %s (%s -> %s)", domain, previous, ttl)
+		m.recordAudit(auditEntry)
+		return previous, nil
+	}
+
+	updated := *zone
+	updated.Ttl = ttl
+	log.Printf("Changing zone TTL of %s (%s -> %s)", domain, previous, ttl)
+	if _, err := session.UpdateDnsZone(domain, &updated); err != nil {
+		auditEntry.Error = err.Error()
+		m.recordAudit(auditEntry)
+		return "", fmt.Errorf("failed to update zone TTL for %s: %w", domain, err)
+	}
+
+	m.recordAudit(auditEntry)
+	return previous, nil
+}
@@ -0,0 +1,147 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestLowerAndRestoreTTLs(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	api.AddZone("other.de")
+	manager := NewManager(testConfig(), api, nil)
+	ctx := context.Background()
+
+	original, err := manager.LowerTTLs(ctx, []string{"example.com", "other.de"}, "60")
+	if err != nil {
+		t.Fatalf("LowerTTLs() error = %v", err)
+	}
+	for _, domain := range []string{"example.com", "other.de"} {
+		if original[domain] != "86400" {
+			t.Errorf("original TTL of %s = %q, want 86400", domain, original[domain])
+		}
+		if ttl := api.Zone(domain).Ttl; ttl != "60" {
+			t.Errorf("TTL of %s after lowering = %s, want 60", domain, ttl)
+		}
+	}
+
+	if err := manager.RestoreTTLs(ctx, original); err != nil {
+		t.Fatalf("RestoreTTLs() error = %v", err)
+	}
+	for _, domain := range []string{"example.com", "other.de"} {
+		if ttl := api.Zone(domain).Ttl; ttl != "86400" {
+			t.Errorf("TTL of %s after restoring = %s, want 86400", domain, ttl)
+		}
+	}
+}
+
+func TestRestoreTTLs_Paused(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	manager := NewManager(testConfig(), api, nil)
+	ctx := context.Background()
+
+	manager.Pause()
+	if err := manager.RestoreTTLs(ctx, map[string]string{"example.com": "3600"}); !errors.Is(err, ErrPaused) {
+		t.Fatalf("RestoreTTLs() while paused error = %v, want ErrPaused", err)
+	}
+	if ttl := api.Zone("example.com").Ttl; ttl != "86400" {
+		t.Errorf("TTL while paused = %s, want 86400", ttl)
+	}
+
+	if _, err := manager.Resume(ctx); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if err := manager.RestoreTTLs(ctx, map[string]string{"example.com": "3600"}); err != nil {
+		t.Fatalf("RestoreTTLs() after resume error = %v", err)
+	}
+	if ttl := api.Zone("example.com").Ttl; ttl != "3600" {
+		t.Errorf("TTL after resume = %s, want 3600", ttl)
+	}
+}
+
+func TestLowerTTLs_DryRun(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	cfg := testConfig()
+	cfg.DryRun = true
+	manager := NewManager(cfg, api, nil)
+
+	original, err := manager.LowerTTLs(context.Background(), []string{"example.com"}, "60")
+	if err != nil {
+		t.Fatalf("LowerTTLs() error = %v", err)
+	}
+	if original["example.com"] != "86400" {
+		t.Errorf("original TTL = %q, want 86400", original["example.com"])
+	}
+	if api.CallCount("updateDnsZone") != 0 {
+		t.Errorf("updateDnsZone calls = %d, want 0 in dry run", api.CallCount("updateDnsZone"))
+	}
+}
+
+func TestLowerTTLs_UnknownZone(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	manager := NewManager(testConfig(), api, nil)
+
+	original, err := manager.LowerTTLs(context.Background(), []string{"example.com", "missing.org"}, "60")
+	if err == nil {
+		t.Fatal("LowerTTLs() error = nil, want error for unknown zone")
+	}
+	if _, ok := original["example.com"]; !ok {
+		t.Error("LowerTTLs() should return the zones lowered before the failure")
+	}
+}
+
+func TestTTLOverrides_KeepLoweredTTL(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+
+	cfg := testConfig()
+	cfg.ZoneSettings = map[string]config.ZoneSettings{"example.com": {TTL: "3600"}}
+	manager := NewManager(cfg, api, nil)
+	manager.SetTTLOverrides(map[string]string{"example.com": "60"})
+
+	info := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if ttl := api.Zone("example.com").Ttl; ttl != "60" {
+		t.Errorf("TTL = %s, want overridden 60", ttl)
+	}
+}
+
+func TestManagerDomainIPs(t *testing.T) {
+	cfg := testConfig()
+	cfg.DomainIPs = map[string]string{"other.de": "198.51.100.7"}
+	manager := NewManager(cfg, netcup.NewFakeAPI(), nil)
+
+	ips, err := manager.DomainIPs([]string{"example.com", "other.de"})
+	if err != nil {
+		t.Fatalf("DomainIPs() error = %v", err)
+	}
+	if ips["example.com"] != "203.0.113.1" || ips["other.de"] != "198.51.100.7" {
+		t.Errorf("DomainIPs() = %v, want host IP for example.com and the mapped IP for other.de", ips)
+	}
+
+	cfg.IPSource = config.IPSourceContainer
+	if _, err := manager.DomainIPs([]string{"example.com"}); err == nil {
+		t.Error("DomainIPs() with container IPs error = nil, want error")
+	}
+}
+
+func TestManagedDomains(t *testing.T) {
+	cfg := testConfig()
+	cfg.ZoneSettings = map[string]config.ZoneSettings{"zone.org": {TTL: "300"}}
+	manager := NewManager(cfg, netcup.NewFakeAPI(), newStaleStateManager(t))
+
+	domains := manager.ManagedDomains()
+	if len(domains) != 2 || domains[0] != "example.com" || domains[1] != "zone.org" {
+		t.Errorf("ManagedDomains() = %v, want [example.com zone.org]", domains)
+	}
+}
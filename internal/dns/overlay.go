@@ -0,0 +1,62 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// overlayIP returns the address on the VPN overlay the records of OVERLAY_DOMAINS point
+// at, e.g. the Tailscale address of this host, if the allow-list contains it
+func (m *Manager) overlayIP() (string, error) {
+	ip, err := m.readOverlayIP()
+	if err != nil {
+		return "", err
+	}
+	if err := m.checkAllowedIP(ip); err != nil {
+		return "", err
+	}
+	return ip, nil
+}
+
+func (m *Manager) readOverlayIP() (string, error) {
+	// Use the monitored address, so records match what changes are reported against
+	if m.overlay != nil {
+		if ip := m.overlay.CurrentIP(); ip != "" {
+			return ip, nil
+		}
+	}
+	if m.readOverlay == nil {
+		return "", errors.New("no overlay address source configured")
+	}
+	ip, err := m.readOverlay()
+	if err != nil {
+		return "", fmt.Errorf("failed to get overlay address: %w", err)
+	}
+	return ip, nil
+}
+
+// RunOverlayMonitor re-detects the overlay address and re-points all managed records
+// whenever it changes, e.g. after the node was re-registered with Tailscale. It blocks
+// until ctx is done.
+func (m *Manager) RunOverlayMonitor(ctx context.Context) {
+	if m.overlay == nil {
+		return
+	}
+
+	m.overlay.OnChange(func(from, to string) {
+		m.notifier.SendInfo(fmt.Sprintf("Overlay address changed from %s to %s, updating records", from, to))
+
+		m.mu.Lock()
+		m.knownHosts = make(map[string]bool)
+		m.mu.Unlock()
+
+		if err := m.ReconcileFromState(ctx); err != nil {
+			log.Printf("Warning: Failed to re-point records after overlay address change: %v", err)
+			m.notifier.SendError(fmt.Sprintf("Failed to re-point records to %s: %v", to, err))
+		}
+	})
+
+	m.overlay.Run(ctx)
+}
@@ -0,0 +1,105 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestProcessHostInfo_OverlayDomains(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	api.AddZone("internal.example.net")
+	cfg := testConfig()
+	cfg.OverlayDomains = []string{"internal.example.net"}
+	cfg.AllowedPublishNets = append(allowedNets(t, "203.0.113.0/29"), allowedNets(t, "100.64.0.0/10")...)
+	manager := NewManager(cfg, api, nil)
+	manager.readOverlay = func() (string, error) { return "100.101.102.103", nil }
+
+	ctx := context.Background()
+	if err := manager.ProcessHostInfo(ctx, docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if err := manager.ProcessHostInfo(ctx, docker.HostInfo{Hostname: "nas.internal.example.net", Domain: "internal.example.net", Subdomain: "nas"}); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+
+	if records := netcup.FindRecords(api.Records("example.com"), "app", "A"); len(records) != 1 || records[0].Destination != cfg.HostIP {
+		t.Errorf("public records = %v, want app pointing at %s", records, cfg.HostIP)
+	}
+	if records := netcup.FindRecords(api.Records("internal.example.net"), "nas", "A"); len(records) != 1 || records[0].Destination != "100.101.102.103" {
+		t.Errorf("overlay records = %v, want nas pointing at 100.101.102.103", records)
+	}
+}
+
+func TestProcessHostInfo_OverlayNotAllowed(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("internal.example.net")
+	cfg := testConfig()
+	cfg.OverlayDomains = []string{"internal.example.net"}
+	cfg.AllowedPublishNets = allowedNets(t, "203.0.113.0/29")
+	manager := NewManager(cfg, api, nil)
+	manager.readOverlay = func() (string, error) { return "100.101.102.103", nil }
+
+	err := manager.ProcessHostInfo(context.Background(), docker.HostInfo{Hostname: "nas.internal.example.net", Domain: "internal.example.net", Subdomain: "nas"})
+	if !errors.Is(err, ErrIPNotAllowed) {
+		t.Fatalf("ProcessHostInfo() error = %v, want ErrIPNotAllowed", err)
+	}
+	if records := api.Records("internal.example.net"); len(records) != 0 {
+		t.Errorf("records = %v, want none for an overlay address outside the allow-list", records)
+	}
+}
+
+func TestProcessHostInfo_OverlayUnavailable(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("internal.example.net")
+	cfg := testConfig()
+	cfg.OverlayDomains = []string{"internal.example.net"}
+	manager := NewManager(cfg, api, nil)
+	manager.readOverlay = func() (string, error) { return "", errors.New("tailscaled not running") }
+
+	err := manager.ProcessHostInfo(context.Background(), docker.HostInfo{Hostname: "nas.internal.example.net", Domain: "internal.example.net", Subdomain: "nas"})
+	if err == nil {
+		t.Fatal("ProcessHostInfo() succeeded without an overlay address")
+	}
+	if records := api.Records("internal.example.net"); len(records) != 0 {
+		t.Errorf("records = %v, want none without an overlay address", records)
+	}
+}
+
+func TestReconcile_OverlayAddressChanged(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("internal.example.net")
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stateManager.UpdateRecord("nas.internal.example.net", "internal.example.net", "nas", "100.64.0.1", "A"); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testConfig()
+	cfg.OverlayDomains = []string{"internal.example.net"}
+	cfg.AllowedPublishNets = allowedNets(t, "100.64.0.0/10")
+	manager := NewManager(cfg, api, stateManager)
+	overlayIP := "100.101.102.103"
+	manager.readOverlay = func() (string, error) { return overlayIP, nil }
+
+	if err := manager.ReconcileFromState(context.Background()); err != nil {
+		t.Fatalf("ReconcileFromState() error = %v", err)
+	}
+	if records := netcup.FindRecords(api.Records("internal.example.net"), "nas", "A"); len(records) != 1 || records[0].Destination != "100.101.102.103" {
+		t.Errorf("records = %v, want nas re-pointed at 100.101.102.103", records)
+	}
+
+	// An overlay address outside the allow-list is not published on reconciliation
+	overlayIP = "192.0.2.1"
+	manager.ReconcileFromState(context.Background())
+	if records := netcup.FindRecords(api.Records("internal.example.net"), "nas", "A"); len(records) != 1 || records[0].Destination != "100.101.102.103" {
+		t.Errorf("records = %v, want nas left at 100.101.102.103", records)
+	}
+}
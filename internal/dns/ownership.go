@@ -0,0 +1,65 @@
+package dns
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+// claimHost applies HOST_CONFLICT_POLICY when info comes from another container than
+// the one owning the persisted record of its hostname. It reports whether info may be
+// published; refusals under the "error" policy are returned as error. Records of
+// unknown origin are taken over by the first container claiming them. The caller
+// holds m.mu.
+func (m *Manager) claimHost(info docker.HostInfo) (bool, error) {
+	if m.stateManager == nil {
+		return true, nil
+	}
+	record, ok := m.stateManager.GetRecord(info.Hostname)
+	if !ok {
+		return true, nil
+	}
+
+	claim := recordOrigin(info)
+	owner, claimant := record.Origin().Owner(), claim.Owner()
+	if claimant == "" || owner == claimant {
+		return true, nil
+	}
+
+	if owner != "" {
+		switch m.config.HostConflictPolicy {
+		case config.HostConflictFirstWins:
+			log.Printf("Host %s is owned by %s, ignoring the claim of %s", info.Hostname, owner, claimant)
+			return false, nil
+		case config.HostConflictError:
+			err := fmt.Errorf("host %s is claimed by %s but owned by %s", info.Hostname, claimant, owner)
+			m.notifier.SendError(fmt.Sprintf("Hostname conflict: %v", err))
+			return false, err
+		}
+		log.Printf("Host %s moves from %s to %s", info.Hostname, owner, claimant)
+		m.notifier.SendInfo(fmt.Sprintf("Hostname %s taken over by %s from %s", info.Hostname, claimant, owner))
+	}
+
+	if err := m.stateManager.SetOwner(info.Hostname, claim); err != nil {
+		log.Printf("Warning: Failed to persist owner of %s: %v", info.Hostname, err)
+	}
+	// The new owner may point the record elsewhere
+	m.setKnown(info.Hostname, false)
+	return true, nil
+}
+
+// ownsHost reports whether the removal of info comes from the container owning its
+// record, so a container stopping does not withdraw a hostname another one took over
+func (m *Manager) ownsHost(info docker.HostInfo) bool {
+	if m.stateManager == nil {
+		return true
+	}
+	record, ok := m.stateManager.GetRecord(info.Hostname)
+	if !ok {
+		return true
+	}
+	owner, claimant := record.Origin().Owner(), recordOrigin(info).Owner()
+	return owner == "" || claimant == "" || owner == claimant
+}
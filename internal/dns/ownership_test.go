@@ -0,0 +1,88 @@
+package dns
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestHostConflictPolicy(t *testing.T) {
+	tests := []struct {
+		policy    string
+		wantErr   bool
+		wantIP    string
+		wantOwner string
+	}{
+		{policy: config.HostConflictLastWins, wantIP: "203.0.113.9", wantOwner: "b"},
+		{policy: config.HostConflictFirstWins, wantIP: "203.0.113.1", wantOwner: "a"},
+		{policy: config.HostConflictError, wantErr: true, wantIP: "203.0.113.1", wantOwner: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			api := netcup.NewFakeAPI()
+			api.AddZone("example.com")
+			stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+			if err != nil {
+				t.Fatalf("Failed to create state manager: %v", err)
+			}
+			cfg := testConfig()
+			cfg.HostConflictPolicy = tt.policy
+			manager := NewManager(cfg, api, stateManager)
+			ctx := context.Background()
+
+			first := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app", ContainerName: "a"}
+			second := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app", ContainerName: "b", Destination: "203.0.113.9"}
+			if err := manager.ProcessHostInfo(ctx, first); err != nil {
+				t.Fatalf("ProcessHostInfo() first error = %v", err)
+			}
+			if err := manager.ProcessHostInfo(ctx, second); (err != nil) != tt.wantErr {
+				t.Errorf("ProcessHostInfo() second error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// Only the owner's removal withdraws the record
+			loser := first
+			if tt.wantOwner == "a" {
+				loser = second
+			}
+			loser.Remove = true
+			if err := manager.ProcessHostInfo(ctx, loser); err != nil {
+				t.Fatalf("ProcessHostInfo() removal error = %v", err)
+			}
+
+			records := api.Records("example.com")
+			if len(records) != 1 || records[0].Destination != tt.wantIP {
+				t.Errorf("records = %+v, want app -> %s", records, tt.wantIP)
+			}
+			record, _ := stateManager.GetRecord("app.example.com")
+			if record.Container != tt.wantOwner {
+				t.Errorf("owner = %q, want %q", record.Container, tt.wantOwner)
+			}
+		})
+	}
+}
+
+func TestHostConflictPolicy_ComposeReplicas(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	cfg := testConfig()
+	cfg.HostConflictPolicy = config.HostConflictError
+	manager := NewManager(cfg, api, stateManager)
+	ctx := context.Background()
+
+	for _, name := range []string{"shop-web-1", "shop-web-2"} {
+		info := docker.HostInfo{Hostname: "shop.example.com", Domain: "example.com", Subdomain: "shop", ContainerName: name, ComposeProject: "shop", ComposeService: "web"}
+		if err := manager.ProcessHostInfo(ctx, info); err != nil {
+			t.Errorf("ProcessHostInfo(%s) error = %v, want replicas to share the hostname", name, err)
+		}
+	}
+}
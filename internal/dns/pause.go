@@ -0,0 +1,121 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+// ErrPaused is returned for writes that cannot be queued while DNS writes are paused
+var ErrPaused = errors.New("DNS writes are paused")
+
+// Pause stops all DNS writes, e.g. during a maintenance window. Hosts processed while
+// paused are queued and applied on Resume. It returns false if already paused.
+func (m *Manager) Pause() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.paused {
+		return false
+	}
+	m.paused = true
+	log.Println("DNS writes paused, changes are queued until resume")
+	return true
+}
+
+// Paused reports whether DNS writes are paused
+func (m *Manager) Paused() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.paused
+}
+
+// Resume re-enables DNS writes, runs a reconciliation deferred while paused and applies
+// the queued hosts in the order they were received. It returns the number of applied
+// hosts; resuming when not paused is a no-op.
+func (m *Manager) Resume(ctx context.Context) (applied int, err error) {
+	ctx, span := tracer.Start(ctx, "dns.Resume")
+	defer func() { endSpan(span, err) }()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.paused {
+		return 0, nil
+	}
+
+	m.paused = false
+	log.Printf("DNS writes resumed, applying %d queued hosts", len(m.queued))
+	return m.applyQueued(ctx)
+}
+
+// applyQueued runs a deferred reconciliation and applies the queued hosts in the order
+// they were received, returning the number of applied hosts. While the Netcup API is in
+// maintenance, they stay queued until it is over. The caller holds m.mu.
+func (m *Manager) applyQueued(ctx context.Context) (applied int, err error) {
+	if m.InMaintenance() {
+		log.Printf("Netcup API is in maintenance, keeping %d queued hosts until it is over", len(m.queued))
+		return 0, nil
+	}
+
+	queued := m.queued
+	reconcilePending := m.reconcilePending
+	m.queued = nil
+	m.reconcilePending = false
+
+	var errs []error
+	if reconcilePending {
+		if err := m.reconcile(ctx); m.maintenanceError(err) {
+			m.reconcilePending = true
+		} else if err != nil {
+			errs = append(errs, fmt.Errorf("reconciliation: %w", err))
+		}
+	}
+
+	for _, info := range queued {
+		if m.InMaintenance() {
+			m.queueHost(info)
+			continue
+		}
+		err := m.processHost(ctx, info)
+		if m.maintenanceError(err) {
+			m.queueHost(info)
+			continue
+		}
+		applied++
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", info.Hostname, err))
+		}
+	}
+
+	return applied, errors.Join(errs...)
+}
+
+// queueHost queues a host for Resume or the end of maintenance, replacing an earlier entry for the same
+// hostname so only the latest change is applied. The caller holds m.mu, at least shared.
+func (m *Manager) queueHost(info docker.HostInfo) {
+	m.bookMu.Lock()
+	defer m.bookMu.Unlock()
+
+	for i, queued := range m.queued {
+		if queued.Hostname == info.Hostname {
+			m.queued = append(m.queued[:i], m.queued[i+1:]...)
+			break
+		}
+	}
+	m.queued = append(m.queued, info)
+	log.Printf("DNS writes deferred, queued %s", info.Hostname)
+}
+
+// queueHosts queues the hosts of a batch that still need a DNS change. The caller holds
+// m.mu, at least shared.
+func (m *Manager) queueHosts(hosts []docker.HostInfo) {
+	for _, info := range hosts {
+		if info.Remove || !m.isKnown(info.Hostname) {
+			m.queueHost(info)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package dns
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestPauseResume(t *testing.T) {
+	tests := []struct {
+		name        string
+		events      []docker.HostInfo
+		sync        []docker.HostInfo
+		wantApplied int
+		wantRecords map[string]string
+	}{
+		{
+			name:        "queued event is applied on resume",
+			events:      []docker.HostInfo{{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}},
+			wantApplied: 1,
+			wantRecords: map[string]string{"old": "203.0.113.1", "app": "203.0.113.1"},
+		},
+		{
+			name: "latest event per hostname wins",
+			events: []docker.HostInfo{
+				{Hostname: "old.example.com", Domain: "example.com", Subdomain: "old", Remove: true},
+				{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"},
+				{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app", Remove: true},
+			},
+			wantApplied: 2,
+			wantRecords: map[string]string{},
+		},
+		{
+			name:        "synced hosts are queued",
+			sync:        []docker.HostInfo{{Hostname: "web.example.com", Domain: "example.com", Subdomain: "web"}},
+			wantApplied: 1,
+			wantRecords: map[string]string{"old": "203.0.113.1", "web": "203.0.113.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := netcup.NewFakeAPI()
+			api.AddZone("example.com", netcup.DnsRecord{Hostname: "old", Type: "A", Destination: "203.0.113.1"})
+			manager := NewManager(testConfig(), api, nil)
+			ctx := context.Background()
+
+			if !manager.Pause() {
+				t.Fatal("Pause() = false, want true")
+			}
+			if manager.Pause() {
+				t.Error("Pause() when already paused = true, want false")
+			}
+
+			for _, info := range tt.events {
+				if err := manager.ProcessHostInfo(ctx, info); err != nil {
+					t.Fatalf("ProcessHostInfo() error = %v", err)
+				}
+			}
+			if err := manager.SyncHosts(ctx, tt.sync); err != nil {
+				t.Fatalf("SyncHosts() error = %v", err)
+			}
+
+			if got := api.CallCount("updateDnsRecords"); got != 0 {
+				t.Fatalf("updateDnsRecords called %d times while paused", got)
+			}
+
+			applied, err := manager.Resume(ctx)
+			if err != nil {
+				t.Fatalf("Resume() error = %v", err)
+			}
+			if applied != tt.wantApplied {
+				t.Errorf("Resume() applied = %d, want %d", applied, tt.wantApplied)
+			}
+			if manager.Paused() {
+				t.Error("Paused() after Resume() = true")
+			}
+
+			records := api.Records("example.com")
+			if len(records) != len(tt.wantRecords) {
+				t.Errorf("records = %v, want %v", records, tt.wantRecords)
+			}
+			for _, record := range records {
+				if want, ok := tt.wantRecords[record.Hostname]; !ok || record.Destination != want {
+					t.Errorf("unexpected record %s -> %s", record.Hostname, record.Destination)
+				}
+			}
+		})
+	}
+}
+
+func TestResume_NotPaused(t *testing.T) {
+	manager := NewManager(testConfig(), netcup.NewFakeAPI(), nil)
+
+	applied, err := manager.Resume(context.Background())
+	if err != nil || applied != 0 {
+		t.Errorf("Resume() = %d, %v, want 0, nil", applied, err)
+	}
+}
@@ -0,0 +1,105 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+// SavePending persists the hosts left unprocessed at shutdown, together with the hosts
+// queued while paused, so ResumePending can apply them on the next start
+func (m *Manager) SavePending(unprocessed []docker.HostInfo) error {
+	m.mu.Lock()
+	hosts := append(slices.Clone(m.queued), unprocessed...)
+	m.mu.Unlock()
+
+	if m.stateManager == nil {
+		if len(hosts) > 0 {
+			log.Printf("Warning: Dropping %d unprocessed hosts, state persistence is disabled", len(hosts))
+		}
+		return nil
+	}
+
+	now := time.Now()
+	pending := make([]state.PendingHost, 0, len(hosts))
+	for _, info := range hosts {
+		pending = append(pending, state.PendingHost{
+			Hostname:       info.Hostname,
+			Domain:         info.Domain,
+			Subdomain:      info.Subdomain,
+			IP:             info.IP,
+			Remove:         info.Remove,
+			DryRun:         info.DryRun,
+			QueuedAt:       now,
+			DockerHost:     info.DockerHost,
+			Container:      info.ContainerName,
+			ComposeProject: info.ComposeProject,
+			ComposeService: info.ComposeService,
+			Destination:    info.Destination,
+			Tags:           info.Tags,
+		})
+	}
+	return m.stateManager.AddPending(pending)
+}
+
+// ResumePending applies the removals left pending at the last shutdown whose container
+// is not among the running hosts and returns how many were applied. Pending additions
+// are covered by the container scan at startup and are dropped.
+func (m *Manager) ResumePending(ctx context.Context, running []docker.HostInfo) (int, error) {
+	if m.stateManager == nil {
+		return 0, nil
+	}
+
+	pending, err := m.stateManager.TakePending()
+	if err != nil {
+		return 0, err
+	}
+
+	runningHosts := make(map[string]bool, len(running))
+	for _, info := range running {
+		if !info.Remove {
+			runningHosts[info.Hostname] = true
+		}
+	}
+
+	var applied int
+	var errs []error
+	for _, host := range pending {
+		if !host.Remove {
+			log.Printf("Pending change of %s is covered by the container scan", host.Hostname)
+			continue
+		}
+		if runningHosts[host.Hostname] {
+			log.Printf("Skipping pending removal of %s, its container is running again", host.Hostname)
+			continue
+		}
+
+		info := docker.HostInfo{
+			Hostname:       host.Hostname,
+			Domain:         host.Domain,
+			Subdomain:      host.Subdomain,
+			IP:             host.IP,
+			Remove:         true,
+			DryRun:         host.DryRun,
+			DockerHost:     host.DockerHost,
+			ContainerName:  host.Container,
+			ComposeProject: host.ComposeProject,
+			ComposeService: host.ComposeService,
+			Destination:    host.Destination,
+			Tags:           host.Tags,
+		}
+		if err := m.ProcessHostInfo(ctx, info); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", host.Hostname, err))
+			continue
+		}
+		applied++
+	}
+
+	return applied, errors.Join(errs...)
+}
@@ -0,0 +1,75 @@
+package dns
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestSaveAndResumePending(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com",
+		netcup.DnsRecord{Hostname: "old", Type: "A", Destination: "203.0.113.1"},
+		netcup.DnsRecord{Hostname: "back", Type: "A", Destination: "203.0.113.1"},
+		netcup.DnsRecord{Hostname: "gone", Type: "A", Destination: "203.0.113.1"},
+	)
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	stateManager, err := state.NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	manager := NewManager(testConfig(), api, stateManager)
+	ctx := context.Background()
+
+	// A removal queued while paused and two left in the host channel at shutdown
+	manager.Pause()
+	if err := manager.ProcessHostInfo(ctx, docker.HostInfo{Hostname: "old.example.com", Domain: "example.com", Subdomain: "old", Remove: true}); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if err := manager.SavePending([]docker.HostInfo{
+		{Hostname: "back.example.com", Domain: "example.com", Subdomain: "back", Remove: true},
+		{Hostname: "new.example.com", Domain: "example.com", Subdomain: "new"},
+		{Hostname: "gone.example.com", Domain: "example.com", Subdomain: "gone", Remove: true, ComposeProject: "shop"},
+	}); err != nil {
+		t.Fatalf("SavePending() error = %v", err)
+	}
+
+	// Restart with back.example.com running again
+	stateManager, err = state.NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to reload state manager: %v", err)
+	}
+	manager = NewManager(testConfig(), api, stateManager)
+	running := []docker.HostInfo{{Hostname: "back.example.com", Domain: "example.com", Subdomain: "back"}}
+
+	applied, err := manager.ResumePending(ctx, running)
+	if err != nil {
+		t.Fatalf("ResumePending() error = %v", err)
+	}
+	if applied != 2 {
+		t.Errorf("ResumePending() applied = %d, want 2", applied)
+	}
+
+	records := api.Records("example.com")
+	if len(records) != 1 || records[0].Hostname != "back" {
+		t.Errorf("Records = %+v, want only back", records)
+	}
+
+	if applied, err := manager.ResumePending(ctx, running); err != nil || applied != 0 {
+		t.Errorf("Second ResumePending() = %d, %v, want nothing pending", applied, err)
+	}
+}
+
+func TestSavePending_WithoutState(t *testing.T) {
+	manager := NewManager(testConfig(), netcup.NewFakeAPI(), nil)
+	if err := manager.SavePending([]docker.HostInfo{{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}}); err != nil {
+		t.Errorf("SavePending() error = %v", err)
+	}
+	if applied, err := manager.ResumePending(context.Background(), nil); err != nil || applied != 0 {
+		t.Errorf("ResumePending() = %d, %v, want nothing pending", applied, err)
+	}
+}
@@ -0,0 +1,42 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+)
+
+// ErrPrivateHostIP is returned for an auto-detected host IP that is private when
+// PRIVATE_IP_POLICY refuses to publish it
+var ErrPrivateHostIP = errors.New("detected host IP is private")
+
+// checkDetectedIP applies PRIVATE_IP_POLICY and the allow-list to an auto-detected host
+// IP. Refusals are notified; identical notifications are merged by the notification
+// throttle.
+func (m *Manager) checkDetectedIP(ip string) (string, error) {
+	if err := m.checkAllowedIP(ip); err != nil {
+		m.notifier.SendError(fmt.Sprintf("Not publishing DNS records: detected host IP %s is outside ALLOWED_PUBLISH_IPS and ALLOWED_PUBLISH_CIDRS", ip))
+		return "", err
+	}
+
+	parsed := net.ParseIP(ip)
+	if m.config.PrivateIPPolicy == "" || m.config.PrivateIPPolicy == config.PrivateIPPublish || parsed == nil || !isPrivateIP(parsed) {
+		return ip, nil
+	}
+
+	m.notifier.SendError(fmt.Sprintf("Not publishing DNS records: detected host IP %s is private (PRIVATE_IP_POLICY=%s), set HOST_IP to your public IP", ip, m.config.PrivateIPPolicy))
+	return "", fmt.Errorf("%w: %s", ErrPrivateHostIP, ip)
+}
+
+// skipsPrivateIP reports whether err refuses a private host IP under the "skip" policy,
+// in which case the affected records are left alone instead of failing
+func (m *Manager) skipsPrivateIP(err error) bool {
+	if m.config.PrivateIPPolicy != config.PrivateIPSkip || !errors.Is(err, ErrPrivateHostIP) {
+		return false
+	}
+	log.Printf("Skipping DNS changes: %v", err)
+	return true
+}
@@ -0,0 +1,87 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestPrivateIPPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      string
+		detected    string
+		wantRecords int
+		wantErr     bool
+	}{
+		{name: "public address", policy: config.PrivateIPSkip, detected: "203.0.113.5", wantRecords: 1},
+		{name: "publish private address", policy: config.PrivateIPPublish, detected: "192.168.1.10", wantRecords: 1},
+		{name: "default publishes", policy: "", detected: "10.0.0.5", wantRecords: 1},
+		{name: "skip private address", policy: config.PrivateIPSkip, detected: "192.168.1.10", wantRecords: 0},
+		{name: "fail on private address", policy: config.PrivateIPFail, detected: "172.16.0.3", wantRecords: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := detectHostIP
+			detectHostIP = func() (string, error) { return tt.detected, nil }
+			defer func() { detectHostIP = original }()
+
+			api := netcup.NewFakeAPI()
+			api.AddZone("example.com")
+			cfg := testConfig()
+			cfg.HostIP = ""
+			cfg.PrivateIPPolicy = tt.policy
+			manager := NewManager(cfg, api, nil)
+
+			info := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+			err := manager.ProcessHostInfo(context.Background(), info)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessHostInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrPrivateHostIP) {
+				t.Errorf("ProcessHostInfo() error = %v, want ErrPrivateHostIP", err)
+			}
+			if got := len(api.Records("example.com")); got != tt.wantRecords {
+				t.Errorf("Zone has %d records, want %d", got, tt.wantRecords)
+			}
+		})
+	}
+}
+
+func TestPrivateIPPolicy_ConfiguredHostIP(t *testing.T) {
+	// An explicit HOST_IP is published as given
+	cfg := testConfig()
+	cfg.HostIP = "192.168.1.10"
+	cfg.PrivateIPPolicy = config.PrivateIPFail
+	manager := NewManager(cfg, netcup.NewFakeAPI(), nil)
+
+	if ip, err := manager.HostIP(); err != nil || ip != "192.168.1.10" {
+		t.Errorf("HostIP() = %s, %v, want the configured address", ip, err)
+	}
+}
+
+func TestSyncHosts_SkipsPrivateIP(t *testing.T) {
+	original := detectHostIP
+	detectHostIP = func() (string, error) { return "192.168.1.10", nil }
+	defer func() { detectHostIP = original }()
+
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	cfg := testConfig()
+	cfg.HostIP = ""
+	cfg.PrivateIPPolicy = config.PrivateIPSkip
+	manager := NewManager(cfg, api, nil)
+
+	hosts := []docker.HostInfo{{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}}
+	if err := manager.SyncHosts(context.Background(), hosts); err != nil {
+		t.Fatalf("SyncHosts() error = %v, want the sync skipped", err)
+	}
+	if api.CallCount("login") != 0 {
+		t.Error("SyncHosts() logged in to Netcup although the host IP is skipped")
+	}
+}
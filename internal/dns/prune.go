@@ -0,0 +1,85 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+// PruneInterval is how often stale state records are pruned
+const PruneInterval = time.Hour
+
+// PruneState re-confirms the records of the given running hosts and prunes state
+// records that were not re-confirmed within STATE_MAX_AGE. With STATE_PRUNE_DELETE_DNS
+// the pruned records are also deleted from DNS. It returns the number of pruned records.
+func (m *Manager) PruneState(ctx context.Context, active []docker.HostInfo) (int, error) {
+	if m.stateManager == nil || m.config.StateMaxAge <= 0 {
+		return 0, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Pruning may delete DNS records, skip it until writes are resumed
+	if m.paused {
+		return 0, nil
+	}
+
+	hostnames := make([]string, 0, len(active))
+	for _, info := range active {
+		hostnames = append(hostnames, info.Hostname)
+	}
+	if err := m.stateManager.Touch(hostnames...); err != nil {
+		return 0, fmt.Errorf("failed to refresh active records: %w", err)
+	}
+
+	if !m.config.StatePruneDeleteDNS || m.config.ObserveMode() {
+		pruned, err := m.stateManager.PruneOlderThan(m.config.StateMaxAge)
+		if err != nil {
+			return 0, err
+		}
+		for _, record := range pruned {
+			log.Printf("Pruned stale state record %s (last confirmed %s)", record.Hostname, record.LastUpdated.Format(time.RFC3339))
+			delete(m.knownHosts, record.Hostname)
+		}
+		return len(pruned), nil
+	}
+
+	// Records are only dropped from state once they are gone from DNS, so a failed
+	// deletion is retried on the next run instead of leaving an orphaned record
+	pruned := 0
+	for _, record := range m.stateManager.OlderThan(m.config.StateMaxAge) {
+		select {
+		case <-ctx.Done():
+			return pruned, ctx.Err()
+		default:
+		}
+
+		info := docker.HostInfo{
+			Hostname:       record.Hostname,
+			Domain:         record.Domain,
+			Subdomain:      record.Subdomain,
+			DockerHost:     record.DockerHost,
+			ComposeProject: record.ComposeProject,
+			ComposeService: record.ComposeService,
+			Tags:           record.Tags,
+		}
+		if err := m.removeHost(ctx, info, "prune"); err != nil {
+			log.Printf("Warning: Failed to delete DNS for stale record %s, keeping it in state: %v", record.Hostname, err)
+			continue
+		}
+		if err := m.stateManager.RemoveRecord(record.Hostname); err != nil {
+			log.Printf("Warning: Failed to prune state record %s: %v", record.Hostname, err)
+			continue
+		}
+
+		log.Printf("Pruned stale state record %s (last confirmed %s)", record.Hostname, record.LastUpdated.Format(time.RFC3339))
+		delete(m.knownHosts, record.Hostname)
+		pruned++
+	}
+
+	return pruned, nil
+}
@@ -0,0 +1,155 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+// newStaleStateManager writes a state file with one stale and one fresh record
+func newStaleStateManager(t *testing.T) *state.Manager {
+	t.Helper()
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	data, err := json.Marshal(state.State{
+		Version: 1,
+		Records: map[string]state.DNSRecord{
+			"old.example.com":   {Hostname: "old.example.com", Domain: "example.com", Subdomain: "old", IP: "203.0.113.1", RecordType: "A", LastUpdated: time.Now().Add(-48 * time.Hour)},
+			"fresh.example.com": {Hostname: "fresh.example.com", Domain: "example.com", Subdomain: "fresh", IP: "203.0.113.1", RecordType: "A", LastUpdated: time.Now()},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stateFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stateManager, err := state.NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	return stateManager
+}
+
+func TestPruneState(t *testing.T) {
+	tests := []struct {
+		name          string
+		deleteDNS     bool
+		active        []docker.HostInfo
+		wantPruned    int
+		wantDNSDelete bool
+	}{
+		{
+			name:       "prunes stale record from state only",
+			wantPruned: 1,
+		},
+		{
+			name:          "deletes pruned record from DNS",
+			deleteDNS:     true,
+			wantPruned:    1,
+			wantDNSDelete: true,
+		},
+		{
+			name:       "running container re-confirms record",
+			active:     []docker.HostInfo{{Hostname: "old.example.com", Domain: "example.com", Subdomain: "old"}},
+			wantPruned: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := netcup.NewFakeAPI()
+			api.AddZone("example.com",
+				netcup.DnsRecord{Hostname: "old", Type: "A", Destination: "203.0.113.1"},
+				netcup.DnsRecord{Hostname: "fresh", Type: "A", Destination: "203.0.113.1"},
+			)
+
+			cfg := testConfig()
+			cfg.StateMaxAge = 24 * time.Hour
+			cfg.StatePruneDeleteDNS = tt.deleteDNS
+			stateManager := newStaleStateManager(t)
+			manager := NewManager(cfg, api, stateManager)
+			manager.knownHosts["old.example.com"] = true
+
+			pruned, err := manager.PruneState(context.Background(), tt.active)
+			if err != nil {
+				t.Fatalf("PruneState() error = %v", err)
+			}
+			if pruned != tt.wantPruned {
+				t.Errorf("PruneState() pruned %d records, want %d", pruned, tt.wantPruned)
+			}
+
+			_, exists := stateManager.GetRecord("old.example.com")
+			if exists != (tt.wantPruned == 0) {
+				t.Errorf("old.example.com in state = %v, want %v", exists, tt.wantPruned == 0)
+			}
+			if _, exists := stateManager.GetRecord("fresh.example.com"); !exists {
+				t.Error("Fresh record must not be pruned")
+			}
+			if tt.wantPruned > 0 && manager.knownHosts["old.example.com"] {
+				t.Error("Pruned host still marked as known")
+			}
+
+			deleted := len(api.Records("example.com")) == 1
+			if deleted != tt.wantDNSDelete {
+				t.Errorf("DNS record deleted = %v, want %v", deleted, tt.wantDNSDelete)
+			}
+		})
+	}
+}
+
+func TestPruneState_DeleteFails(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com", netcup.DnsRecord{Hostname: "old", Type: "A", Destination: "203.0.113.1"})
+	api.Errors["updateDnsRecords"] = errors.New("netcup unavailable")
+
+	cfg := testConfig()
+	cfg.StateMaxAge = 24 * time.Hour
+	cfg.StatePruneDeleteDNS = true
+	stateManager := newStaleStateManager(t)
+	manager := NewManager(cfg, api, stateManager)
+
+	pruned, err := manager.PruneState(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("PruneState() error = %v", err)
+	}
+	if pruned != 0 {
+		t.Errorf("PruneState() pruned %d records, want 0", pruned)
+	}
+	if _, exists := stateManager.GetRecord("old.example.com"); !exists {
+		t.Error("Record whose DNS deletion failed must stay in state")
+	}
+
+	// The deletion is retried on the next run
+	delete(api.Errors, "updateDnsRecords")
+	if pruned, err := manager.PruneState(context.Background(), nil); err != nil || pruned != 1 {
+		t.Fatalf("PruneState() = %d, %v, want 1, nil", pruned, err)
+	}
+	if _, exists := stateManager.GetRecord("old.example.com"); exists {
+		t.Error("Record still in state after its DNS deletion succeeded")
+	}
+	if len(api.Records("example.com")) != 0 {
+		t.Error("Stale record still in DNS")
+	}
+}
+
+func TestPruneState_Disabled(t *testing.T) {
+	manager := NewManager(testConfig(), netcup.NewFakeAPI(), newStaleStateManager(t))
+
+	pruned, err := manager.PruneState(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("PruneState() error = %v", err)
+	}
+	if pruned != 0 {
+		t.Errorf("PruneState() pruned %d records without STATE_MAX_AGE, want 0", pruned)
+	}
+}
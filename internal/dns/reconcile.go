@@ -0,0 +1,276 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/audit"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+// reconcileResult counts the outcome of reconciling the records of one domain
+type reconcileResult struct {
+	synced, skipped, errored int
+}
+
+// appliedChange is a record update made while reconciling a domain, kept until the
+// domain is committed so the update can be undone
+type appliedChange struct {
+	record   state.DNSRecord     // Persisted record being reconciled
+	previous *netcup.DnsRecord   // Live record before the update, nil if it was created
+	ip       string              // Address the record was pointed at
+	zone     *[]netcup.DnsRecord // Records returned by the update, to learn the record Id
+}
+
+// reconcileDomain re-applies the persisted records of one domain as a unit. An update
+// that still fails after one retry restores the records already changed in the domain
+// from the snapshot taken before the first update, and the rest of the domain is left
+// for the next reconciliation. State, known hosts and success notifications are only
+// updated once all updates of the domain succeeded. The caller holds m.mu.
+func (m *Manager) reconcileDomain(ctx context.Context, session netcup.DnsSession, domain string, records []state.DNSRecord, hostIP string) (reconcileResult, error) {
+	var result reconcileResult
+
+	// The zone serial tells whether the records changed since the last reconciliation
+	var serial string
+	if _, ok := m.config.ZoneSettings[domain]; ok {
+		zone, err := session.InfoDnsZone(domain)
+		if err != nil {
+			log.Printf("Warning: Failed to get DNS zone for %s during reconciliation: %v", domain, err)
+		} else if err := m.checkDNSSEC(domain, zone); err != nil {
+			result.errored = len(records)
+			return result, nil
+		} else if _, err := m.applyZoneSettings(session, domain, zone); err != nil {
+			log.Printf("Warning: %v", err)
+			m.notifier.SendError(err.Error())
+		} else {
+			serial = zone.Serial
+		}
+	} else if m.config.ReconciliationInterval > 0 {
+		var err error
+		if serial, err = m.zoneSerial(session, domain); err != nil {
+			log.Printf("Warning: Skipping reconciliation of %s: %v", domain, err)
+			result.errored = len(records)
+			return result, nil
+		}
+	} else if err := m.checkZoneDNSSEC(session, domain); err != nil {
+		log.Printf("Warning: Skipping reconciliation of %s: %v", domain, err)
+		result.errored = len(records)
+		return result, nil
+	}
+
+	fingerprint := m.reconcileFingerprint(records, hostIP)
+	if m.zoneUnchanged(domain, serial, fingerprint) {
+		log.Printf("Reconciliation: %s unchanged since the last reconciliation (serial %s), %d records in sync", domain, serial, len(records))
+		for _, record := range records {
+			m.knownHosts[record.Hostname] = true
+		}
+		result.skipped = len(records)
+		return result, nil
+	}
+
+	// Get existing DNS records for this domain; they are the snapshot restored on failure
+	existingRecords, err := session.InfoDnsRecords(domain)
+	if err != nil {
+		log.Printf("Warning: Failed to get DNS records for %s during reconciliation: %v", domain, err)
+		result.errored = len(records)
+		return result, nil
+	}
+
+	index := indexARecords(*existingRecords)
+	var applied []appliedChange
+
+	for i, record := range records {
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		default:
+		}
+
+		if err := m.checkManaged(record.Subdomain); err != nil {
+			log.Printf("Warning: Skipping reconciliation of %s: %v", record.Hostname, err)
+			result.skipped++
+			continue
+		}
+
+		expectedIP, err := m.expectedIP(record, hostIP)
+		if err != nil {
+			log.Printf("Warning: Failed to reconcile DNS for %s: %v", record.Hostname, err)
+			result.errored++
+			continue
+		}
+
+		if err := m.handleDuplicates(session, index, record.Hostname, domain, record.Subdomain, expectedIP, "reconciliation", m.config.DryRun); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+
+		change, existing, needed := index.diff(record.Subdomain, expectedIP, record.RecordID)
+		if !needed {
+			log.Printf("Reconciliation: %s is in sync (IP: %s)", record.Hostname, expectedIP)
+			result.skipped++
+			m.knownHosts[record.Hostname] = true
+			if !m.config.DryRun {
+				m.learnRecordID(record.Hostname, record.Subdomain, expectedIP, existingRecords)
+			}
+			continue
+		}
+
+		if err := netcup.ValidateRecord(change); err != nil {
+			log.Printf("Warning: Failed to reconcile DNS for %s: %v", record.Hostname, err)
+			result.errored++
+			continue
+		}
+
+		var existingIP string
+		if existing != nil {
+			existingIP = existing.Destination
+		}
+
+		// Need to sync this record
+		action := audit.ActionCreate
+		if existing != nil {
+			action = audit.ActionUpdate
+		}
+		auditEntry := audit.Entry{
+			Action:     action,
+			Source:     "reconciliation",
+			Hostname:   record.Hostname,
+			Domain:     record.Domain,
+			Subdomain:  record.Subdomain,
+			RecordType: "A",
+			Before:     existingIP,
+			After:      expectedIP,
+			DryRun:     m.config.DryRun,
+		}
+
+		if m.config.DryRun {
+			if existing != nil {
+				log.Printf("[DRY RUN] Reconciliation would update: %s (%s -> %s)", record.Hostname, existingIP, expectedIP)
+			} else {
+				log.Printf("[DRY RUN] Reconciliation would create: %s -> %s", record.Hostname, expectedIP)
+			}
+			m.recordAudit(auditEntry)
+			m.knownHosts[record.Hostname] = true
+			result.skipped++
+			continue
+		}
+
+		log.Printf("Reconciliation: %s needs %s (%s -> %s)", record.Hostname, action, existingIP, expectedIP)
+
+		recordSet := []netcup.DnsRecord{change}
+		updated, err := session.UpdateDnsRecords(domain, &recordSet)
+		if err != nil {
+			log.Printf("Warning: Failed to reconcile DNS for %s, retrying once: %v", record.Hostname, err)
+			updated, err = session.UpdateDnsRecords(domain, &recordSet)
+		}
+		if err != nil {
+			auditEntry.Error = err.Error()
+			m.recordAudit(auditEntry)
+			log.Printf("Warning: Failed to reconcile DNS for %s: %v", record.Hostname, err)
+
+			// The failed record, the ones after it and the undone ones stay out of sync
+			result.errored += len(records) - i + len(applied)
+			m.abortDomain(session, domain, record.Hostname, err, applied)
+			return result, nil
+		}
+
+		m.recordAudit(auditEntry)
+		applied = append(applied, appliedChange{record: record, previous: existing, ip: expectedIP, zone: updated})
+	}
+
+	// Commit: persist the new addresses and report them
+	for _, change := range applied {
+		if err := m.stateManager.UpdateRecord(change.record.Hostname, change.record.Domain, change.record.Subdomain, change.ip, "A"); err != nil {
+			log.Printf("Warning: Failed to update persisted state for %s: %v", change.record.Hostname, err)
+		}
+		m.learnRecordID(change.record.Hostname, change.record.Subdomain, change.ip, change.zone)
+
+		m.knownHosts[change.record.Hostname] = true
+		result.synced++
+
+		m.notifier.SendSuccess(fmt.Sprintf("Reconciled DNS: %s -> %s", change.record.Hostname, change.ip))
+		log.Printf("Reconciliation: Successfully synced %s", change.record.Hostname)
+	}
+
+	// Updates change the serial, so only a zone found in sync is remembered
+	m.rememberZone(domain, serial, fingerprint, len(applied) == 0 && result.errored == 0 && !m.config.DryRun)
+	return result, nil
+}
+
+// abortDomain undoes the changes applied to domain before the update of hostname
+// failed and reports the outcome
+func (m *Manager) abortDomain(session netcup.DnsSession, domain, hostname string, cause error, applied []appliedChange) {
+	if len(applied) == 0 {
+		m.notifier.SendError(fmt.Sprintf("Reconciliation failed for %s: %v", hostname, cause))
+		return
+	}
+
+	log.Printf("Reconciliation of %s failed at %s, restoring %d changed records", domain, hostname, len(applied))
+	if err := m.restoreSnapshot(session, domain, applied); err != nil {
+		log.Printf("Warning: Failed to restore DNS records of %s: %v", domain, err)
+		m.notifier.SendError(fmt.Sprintf("Reconciliation of %s failed at %s (%v) and restoring %d changed records failed: %v", domain, hostname, cause, len(applied), err))
+		return
+	}
+	m.notifier.SendError(fmt.Sprintf("Reconciliation of %s failed at %s (%v), restored %d changed records", domain, hostname, cause, len(applied)))
+}
+
+// restoreSnapshot points updated records back at their previous destination and
+// deletes created records
+func (m *Manager) restoreSnapshot(session netcup.DnsSession, domain string, applied []appliedChange) (err error) {
+	var restore []netcup.DnsRecord
+	var entries []audit.Entry
+	created := make(map[string]bool)
+	defer func() {
+		for _, entry := range entries {
+			if err != nil {
+				entry.Error = err.Error()
+			}
+			m.recordAudit(entry)
+		}
+	}()
+
+	for _, change := range applied {
+		entry := audit.Entry{
+			Action:     audit.ActionUpdate,
+			Source:     "reconciliation_rollback",
+			Hostname:   change.record.Hostname,
+			Domain:     domain,
+			Subdomain:  change.record.Subdomain,
+			RecordType: "A",
+			Before:     change.ip,
+		}
+		if change.previous != nil {
+			entry.After = change.previous.Destination
+			restore = append(restore, *change.previous)
+		} else {
+			entry.Action = audit.ActionDelete
+			created[change.record.Subdomain] = true
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(restore) > 0 {
+		if _, err := session.UpdateDnsRecords(domain, &restore); err != nil {
+			return fmt.Errorf("failed to restore updated records: %w", err)
+		}
+	}
+
+	if len(created) == 0 {
+		return nil
+	}
+	current, err := session.InfoDnsRecords(domain)
+	if err != nil {
+		return fmt.Errorf("failed to look up created records: %w", err)
+	}
+	var remove []netcup.DnsRecord
+	for _, record := range *current {
+		if record.Type == "A" && created[record.Hostname] {
+			remove = append(remove, record)
+		}
+	}
+	if err := netcup.DeleteDnsRecords(session, domain, remove); err != nil {
+		return fmt.Errorf("failed to delete created records: %w", err)
+	}
+	return nil
+}
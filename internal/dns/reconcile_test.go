@@ -0,0 +1,125 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+// flakyAPI fails record updates touching failHost; failures counts down, negative fails forever
+type flakyAPI struct {
+	netcup.NetcupAPI
+	failHost string
+	failures int
+}
+
+func (a *flakyAPI) Login(ctx context.Context) (netcup.DnsSession, error) {
+	session, err := a.NetcupAPI.Login(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &flakySession{DnsSession: session, api: a}, nil
+}
+
+type flakySession struct {
+	netcup.DnsSession
+	api *flakyAPI
+}
+
+func (s *flakySession) UpdateDnsRecords(domainName string, dnsRecordSet *[]netcup.DnsRecord) (*[]netcup.DnsRecord, error) {
+	for _, record := range *dnsRecordSet {
+		if record.Hostname == s.api.failHost && s.api.failures != 0 {
+			s.api.failures--
+			return nil, errors.New("update failed")
+		}
+	}
+	return s.DnsSession.UpdateDnsRecords(domainName, dnsRecordSet)
+}
+
+func TestReconcileDomain(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int
+		want        map[string]string
+		wantResult  reconcileResult
+		wantStateIP string
+	}{
+		{
+			name:        "retry succeeds",
+			failures:    1,
+			want:        map[string]string{"app": "203.0.113.1", "new": "203.0.113.1", "web": "203.0.113.1"},
+			wantResult:  reconcileResult{synced: 3},
+			wantStateIP: "203.0.113.1",
+		},
+		{
+			name:        "rollback",
+			failures:    -1,
+			want:        map[string]string{"app": "198.51.100.1", "web": "198.51.100.1"},
+			wantResult:  reconcileResult{errored: 3},
+			wantStateIP: "198.51.100.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := netcup.NewFakeAPI()
+			fake.AddZone("example.com",
+				netcup.DnsRecord{Hostname: "app", Type: "A", Destination: "198.51.100.1"},
+				netcup.DnsRecord{Hostname: "web", Type: "A", Destination: "198.51.100.1"},
+			)
+			stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+			if err != nil {
+				t.Fatalf("Failed to create state manager: %v", err)
+			}
+			var records []state.DNSRecord
+			for _, subdomain := range []string{"app", "new", "web"} {
+				hostname := subdomain + ".example.com"
+				if err := stateManager.UpdateRecord(hostname, "example.com", subdomain, "198.51.100.1", "A"); err != nil {
+					t.Fatalf("UpdateRecord() error = %v", err)
+				}
+				record, _ := stateManager.GetRecord(hostname)
+				records = append(records, record)
+			}
+
+			api := &flakyAPI{NetcupAPI: fake, failHost: "web", failures: tt.failures}
+			manager := NewManager(testConfig(), api, stateManager)
+			session, err := api.Login(context.Background())
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+
+			result, err := manager.reconcileDomain(context.Background(), session, "example.com", records, testConfig().HostIP)
+			if err != nil {
+				t.Fatalf("reconcileDomain() error = %v", err)
+			}
+			if result != tt.wantResult {
+				t.Errorf("result = %+v, want %+v", result, tt.wantResult)
+			}
+
+			got := make(map[string]string)
+			for _, record := range fake.Records("example.com") {
+				got[record.Hostname] = record.Destination
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("records = %v, want %v", got, tt.want)
+			}
+			for hostname, ip := range tt.want {
+				if got[hostname] != ip {
+					t.Errorf("%s -> %q, want %q", hostname, got[hostname], ip)
+				}
+			}
+
+			record, _ := stateManager.GetRecord("app.example.com")
+			if record.IP != tt.wantStateIP {
+				t.Errorf("persisted app IP = %q, want %q", record.IP, tt.wantStateIP)
+			}
+			if manager.knownHosts["app.example.com"] != (tt.wantResult.synced > 0) {
+				t.Errorf("app.example.com known = %v", manager.knownHosts["app.example.com"])
+			}
+		})
+	}
+}
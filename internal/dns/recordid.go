@@ -0,0 +1,53 @@
+package dns
+
+import (
+	"log"
+
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+// recordID returns the Netcup Id persisted for the record of hostname, or "" if it is
+// not known, e.g. for records persisted before Ids were tracked
+func (m *Manager) recordID(hostname string) string {
+	if m.stateManager == nil {
+		return ""
+	}
+	record, _ := m.stateManager.GetRecord(hostname)
+	return record.RecordID
+}
+
+// learnRecordID persists the Id of the A record of hostname pointing at ip, looked up in
+// records, e.g. the zone returned by UpdateDnsRecords. Failures are only logged, the
+// record is matched by name until the Id is known.
+func (m *Manager) learnRecordID(hostname, subdomain, ip string, records *[]netcup.DnsRecord) {
+	if m.stateManager == nil || records == nil {
+		return
+	}
+	id := indexARecords(*records).id(subdomain, ip, m.recordID(hostname))
+	if id == "" {
+		return
+	}
+	if err := m.stateManager.SetRecordID(hostname, id); err != nil {
+		log.Printf("Warning: Failed to persist the record Id of %s: %v", hostname, err)
+	}
+}
+
+// trackedRecords narrows the A records named like hostname to the one with the persisted
+// Id, so that records of the same name the companion did not create survive a removal.
+// Without a known Id, or once that record is gone, all of them are returned.
+func (m *Manager) trackedRecords(hostname string, matched []netcup.DnsRecord) []netcup.DnsRecord {
+	id := m.recordID(hostname)
+	if id == "" {
+		return matched
+	}
+	for _, record := range matched {
+		if record.Id == id {
+			if len(matched) > 1 {
+				log.Printf("Keeping %d other A records named like %s, only record %s was created for it", len(matched)-1, hostname, id)
+			}
+			return []netcup.DnsRecord{record}
+		}
+	}
+	log.Printf("Record %s of %s no longer exists, matching by name", id, hostname)
+	return matched
+}
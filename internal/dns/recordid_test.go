@@ -0,0 +1,63 @@
+package dns
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestRecordID_UpdatesAndRemovesTrackedRecord(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	manager := NewManager(testConfig(), api, stateManager)
+
+	info := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	record, _ := stateManager.GetRecord("app.example.com")
+	created := api.Records("example.com")[0]
+	if record.RecordID == "" || record.RecordID != created.Id {
+		t.Fatalf("Persisted record Id = %q, want %q", record.RecordID, created.Id)
+	}
+
+	// A record of the same name added by hand, listed before the tracked one
+	api.AddZone("example.com",
+		netcup.DnsRecord{Hostname: "app", Type: "A", Destination: "198.51.100.9"},
+		netcup.DnsRecord{Id: created.Id, Hostname: "app", Type: "A", Destination: "198.51.100.1"},
+	)
+
+	// The tracked record is re-pointed, not the first one named app
+	manager.setKnown("app.example.com", false)
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	for _, r := range api.Records("example.com") {
+		want := "198.51.100.9"
+		if r.Id == created.Id {
+			want = "203.0.113.1"
+		}
+		if r.Destination != want {
+			t.Errorf("Record %s points at %s, want %s", r.Id, r.Destination, want)
+		}
+	}
+
+	// Only the tracked record is deleted
+	info.Remove = true
+	if err := manager.ProcessHostInfo(context.Background(), info); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	records := api.Records("example.com")
+	if len(records) != 1 || records[0].Destination != "198.51.100.9" {
+		t.Errorf("Zone after removal = %+v, want only the record added by hand", records)
+	}
+}
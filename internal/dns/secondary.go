@@ -0,0 +1,69 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/provider"
+)
+
+// NewSecondaryProvider creates the provider configured by SECONDARY_PROVIDER, or nil
+// if records are not mirrored
+func NewSecondaryProvider(cfg *config.Config) provider.Provider {
+	switch cfg.SecondaryProvider {
+	case config.SecondaryProviderCloudflare:
+		return provider.NewCloudflareWithOptions(cfg.SecondaryAPIToken, &provider.CloudflareOptions{TTL: cfg.SecondaryTTL})
+	default:
+		return nil
+	}
+}
+
+// mirrorRecord points the record at the secondary provider at ip. It runs before and
+// independently of the Netcup update, so the secondary keeps up while Netcup is down;
+// failures are reported but never fail the primary update. With dryRun, it is only logged.
+func (m *Manager) mirrorRecord(ctx context.Context, hostname, domain, subdomain, ip string, dryRun bool) {
+	if m.secondary == nil {
+		return
+	}
+	if err := netcup.ValidateRecord(netcup.DnsRecord{Hostname: subdomain, Type: "A", Destination: ip}); err != nil {
+		return
+	}
+	if err := m.checkManaged(subdomain); err != nil {
+		return
+	}
+	if dryRun {
+		log.Printf("[DRY RUN] Would mirror DNS record to %s: %s -> %s", m.secondary.Name(), hostname, ip)
+		return
+	}
+
+	if err := m.secondary.SetRecord(ctx, domain, subdomain, ip); err != nil {
+		m.reportMirrorError(hostname, err)
+		return
+	}
+	log.Printf("Mirrored DNS record to %s: %s -> %s", m.secondary.Name(), hostname, ip)
+}
+
+// mirrorRemoval deletes the record at the secondary provider, like mirrorRecord
+func (m *Manager) mirrorRemoval(ctx context.Context, hostname, domain, subdomain string, dryRun bool) {
+	if m.secondary == nil {
+		return
+	}
+	if dryRun {
+		log.Printf("[DRY RUN] Would remove mirrored DNS record from %s: %s", m.secondary.Name(), hostname)
+		return
+	}
+
+	if err := m.secondary.DeleteRecord(ctx, domain, subdomain); err != nil {
+		m.reportMirrorError(hostname, err)
+		return
+	}
+	log.Printf("Removed mirrored DNS record from %s: %s", m.secondary.Name(), hostname)
+}
+
+func (m *Manager) reportMirrorError(hostname string, err error) {
+	log.Printf("Warning: Failed to mirror DNS for %s to %s: %v", hostname, m.secondary.Name(), err)
+	m.notifier.SendError(fmt.Sprintf("Failed to mirror DNS for %s to secondary provider %s: %v", hostname, m.secondary.Name(), err))
+}
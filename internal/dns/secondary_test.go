@@ -0,0 +1,104 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/provider"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestNewSecondaryProvider(t *testing.T) {
+	if p := NewSecondaryProvider(&config.Config{}); p != nil {
+		t.Errorf("NewSecondaryProvider() = %v, want nil when disabled", p)
+	}
+	cfg := &config.Config{SecondaryProvider: config.SecondaryProviderCloudflare, SecondaryAPIToken: "token", SecondaryTTL: 60}
+	if p := NewSecondaryProvider(cfg); p == nil || p.Name() != "cloudflare" {
+		t.Errorf("NewSecondaryProvider() = %v, want cloudflare", p)
+	}
+}
+
+func TestSecondaryProvider(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	secondary := provider.NewFake()
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	cfg := testConfig()
+	manager := NewManagerWithOptions(cfg, api, stateManager, &ManagerOptions{Secondary: secondary})
+	ctx := context.Background()
+
+	app := docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}
+	if err := manager.ProcessHostInfo(ctx, app); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if err := manager.SyncHosts(ctx, []docker.HostInfo{{Hostname: "example.com", Domain: "example.com", Subdomain: "@"}}); err != nil {
+		t.Fatalf("SyncHosts() error = %v", err)
+	}
+	assertSecondary(t, secondary, map[string]string{"app.example.com": cfg.HostIP, "example.com": cfg.HostIP})
+
+	// Netcup being down does not keep the secondary from following changes
+	api.LoginErr = errors.New("netcup unavailable")
+	cfg.HostIP = "203.0.113.2"
+	manager.knownHosts = make(map[string]bool)
+	if err := manager.ReconcileFromState(ctx); err == nil {
+		t.Error("ReconcileFromState() error = nil, want Netcup login error")
+	}
+	web := docker.HostInfo{Hostname: "web.example.com", Domain: "example.com", Subdomain: "web"}
+	if err := manager.ProcessHostInfo(ctx, web); err == nil {
+		t.Error("ProcessHostInfo() error = nil, want Netcup login error")
+	}
+	assertSecondary(t, secondary, map[string]string{"app.example.com": cfg.HostIP, "example.com": cfg.HostIP, "web.example.com": cfg.HostIP})
+	api.LoginErr = nil
+
+	// Failures at the secondary do not fail the Netcup update
+	secondary.Err = errors.New("secondary unavailable")
+	if err := manager.ProcessHostInfo(ctx, web); err != nil {
+		t.Fatalf("ProcessHostInfo() with failing secondary error = %v", err)
+	}
+	if records := api.Records("example.com"); len(records) != 3 {
+		t.Errorf("Netcup records = %+v, want app, apex and web", records)
+	}
+	secondary.Err = nil
+
+	app.Remove = true
+	if err := manager.ProcessHostInfo(ctx, app); err != nil {
+		t.Fatalf("ProcessHostInfo() removal error = %v", err)
+	}
+	assertSecondary(t, secondary, map[string]string{"example.com": cfg.HostIP, "web.example.com": cfg.HostIP})
+}
+
+func TestSecondaryProvider_DryRun(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	secondary := provider.NewFake()
+	cfg := testConfig()
+	cfg.DryRun = true
+	manager := NewManagerWithOptions(cfg, api, nil, &ManagerOptions{Secondary: secondary})
+
+	if err := manager.ProcessHostInfo(context.Background(), docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	assertSecondary(t, secondary, map[string]string{})
+}
+
+func assertSecondary(t *testing.T, secondary *provider.Fake, want map[string]string) {
+	t.Helper()
+	got := secondary.Records()
+	if len(got) != len(want) {
+		t.Errorf("secondary records = %v, want %v", got, want)
+		return
+	}
+	for name, ip := range want {
+		if got[name] != ip {
+			t.Errorf("secondary %s -> %q, want %q", name, got[name], ip)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package dns
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+// zoneSnapshot is a zone found in sync by the last reconciliation
+type zoneSnapshot struct {
+	serial      string // Zone serial, changed by Netcup on every record update
+	fingerprint string // The persisted records reconciled and their expected addresses
+}
+
+// zoneSerial fetches a zone for its serial and applies DNSSEC_POLICY to it. A zone that
+// cannot be fetched has no serial, which is only an error under DNSSEC_POLICY=refuse.
+func (m *Manager) zoneSerial(session netcup.DnsSession, domain string) (string, error) {
+	zone, err := session.InfoDnsZone(domain)
+	if err != nil {
+		if m.config.DNSSECPolicy == config.DNSSECRefuse {
+			return "", fmt.Errorf("failed to get DNS zone for %s: %w", domain, err)
+		}
+		log.Printf("Warning: Failed to get DNS zone for %s during reconciliation: %v", domain, err)
+		return "", nil
+	}
+	if err := m.checkDNSSEC(domain, zone); err != nil {
+		return "", err
+	}
+	return zone.Serial, nil
+}
+
+// reconcileFingerprint identifies the records to reconcile along with the addresses
+// they are expected at. It is empty if an address cannot be determined.
+func (m *Manager) reconcileFingerprint(records []state.DNSRecord, hostIP string) string {
+	lines := make([]string, 0, len(records))
+	for _, record := range records {
+		ip, err := m.expectedIP(record, hostIP)
+		if err != nil {
+			return ""
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %s %s", record.Hostname, record.Subdomain, ip, record.RecordID))
+	}
+	sort.Strings(lines)
+
+	hash := sha256.New()
+	for _, line := range lines {
+		fmt.Fprintln(hash, line)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// zoneUnchanged reports whether the zone and the records to reconcile are unchanged
+// since the last reconciliation found them in sync. The caller holds m.mu.
+func (m *Manager) zoneUnchanged(domain, serial, fingerprint string) bool {
+	snapshot, ok := m.zoneSerials[domain]
+	return ok && serial != "" && fingerprint != "" && snapshot == zoneSnapshot{serial: serial, fingerprint: fingerprint}
+}
+
+// rememberZone keeps the serial of a zone found in sync, or forgets it when the zone was
+// changed or could not be reconciled. The caller holds m.mu.
+func (m *Manager) rememberZone(domain, serial, fingerprint string, inSync bool) {
+	if !inSync || serial == "" || fingerprint == "" {
+		delete(m.zoneSerials, domain)
+		return
+	}
+	m.zoneSerials[domain] = zoneSnapshot{serial: serial, fingerprint: fingerprint}
+}
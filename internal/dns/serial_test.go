@@ -0,0 +1,64 @@
+package dns
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestReconcileSkipsUnchangedZone(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com", netcup.DnsRecord{Hostname: "app", Type: "A", Destination: "198.51.100.1"})
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	stateManager.UpdateRecord("app.example.com", "example.com", "app", "198.51.100.1", "A")
+	cfg := testConfig()
+	cfg.ReconciliationInterval = 3600
+	manager := NewManager(cfg, api, stateManager)
+	ctx := context.Background()
+
+	reconcile := func() {
+		t.Helper()
+		if err := manager.ReconcileFromState(ctx); err != nil {
+			t.Fatalf("ReconcileFromState() error = %v", err)
+		}
+	}
+
+	// The first run updates the drifted record, the second finds the zone in sync
+	reconcile()
+	reconcile()
+	fetches := api.CallCount("infoDnsRecords")
+
+	reconcile()
+	if got := api.CallCount("infoDnsRecords"); got != fetches {
+		t.Errorf("infoDnsRecords calls = %d after reconciling an unchanged zone, want %d", got, fetches)
+	}
+
+	// A change made outside the companion bumps the serial
+	session, _ := api.Login(ctx)
+	records := api.Records("example.com")
+	records[0].Destination = "198.51.100.9"
+	if _, err := session.UpdateDnsRecords("example.com", &records); err != nil {
+		t.Fatalf("UpdateDnsRecords() error = %v", err)
+	}
+
+	reconcile()
+	if got := api.CallCount("infoDnsRecords"); got != fetches+1 {
+		t.Errorf("infoDnsRecords calls = %d after the zone changed, want %d", got, fetches+1)
+	}
+	if got := api.Records("example.com")[0].Destination; got != "203.0.113.1" {
+		t.Errorf("Record points at %s after reconciliation, want 203.0.113.1", got)
+	}
+
+	// So does a change of the persisted records
+	stateManager.UpdateRecord("www.example.com", "example.com", "www", "203.0.113.1", "A")
+	reconcile()
+	if got := api.CallCount("infoDnsRecords"); got != fetches+2 {
+		t.Errorf("infoDnsRecords calls = %d after the state changed, want %d", got, fetches+2)
+	}
+}
@@ -0,0 +1,188 @@
+package dns
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/audit"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+// srvRecords returns the SRV records declared for info in Netcup's model
+func srvRecords(info docker.HostInfo) []netcup.DnsRecord {
+	records := make([]netcup.DnsRecord, 0, len(info.SRV))
+	for _, srv := range info.SRV {
+		records = append(records, netcup.DnsRecord{
+			Hostname:    srv.Name(info.Subdomain),
+			Type:        "SRV",
+			Priority:    strconv.Itoa(srv.Priority),
+			Destination: srv.Data(),
+		})
+	}
+	return records
+}
+
+// sameSRV reports whether two SRV records are equal. Netcup may return the target
+// without the trailing dot it was created with.
+func sameSRV(a, b netcup.DnsRecord) bool {
+	if a.Type != "SRV" || b.Type != "SRV" || !strings.EqualFold(a.Hostname, b.Hostname) || a.Priority != b.Priority {
+		return false
+	}
+	normalize := func(destination string) string {
+		return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(destination), "."))
+	}
+	return strings.Join(strings.Fields(normalize(a.Destination)), " ") == strings.Join(strings.Fields(normalize(b.Destination)), " ")
+}
+
+// srvChanges returns the SRV records of info missing from the zone records, and the
+// zone records of SRV records the host published before but no longer declares
+func (m *Manager) srvChanges(records []netcup.DnsRecord, info docker.HostInfo) (create, remove []netcup.DnsRecord) {
+	wanted := srvRecords(info)
+	for _, want := range wanted {
+		if err := netcup.ValidateRecord(want); err != nil {
+			log.Printf("Warning: Skipping SRV record of %s: %v", info.Hostname, err)
+			continue
+		}
+		if !slices.ContainsFunc(records, func(record netcup.DnsRecord) bool { return sameSRV(record, want) }) {
+			create = append(create, want)
+		}
+	}
+
+	for _, published := range m.publishedSRV(info.Hostname) {
+		if slices.ContainsFunc(wanted, func(want netcup.DnsRecord) bool { return sameSRV(want, published) }) {
+			continue
+		}
+		for _, record := range records {
+			if sameSRV(record, published) {
+				remove = append(remove, record)
+			}
+		}
+	}
+	return create, remove
+}
+
+// publishedSRV returns the SRV records persisted for hostname
+func (m *Manager) publishedSRV(hostname string) []netcup.DnsRecord {
+	if m.stateManager == nil {
+		return nil
+	}
+	var records []netcup.DnsRecord
+	for _, srv := range m.stateManager.SRVRecords(hostname) {
+		records = append(records, netcup.DnsRecord{Hostname: srv.Name, Type: "SRV", Priority: srv.Priority, Destination: srv.Destination})
+	}
+	return records
+}
+
+// srvChanged reports whether the SRV records declared for info differ from those
+// persisted, so that a known host is synced again after its label changed
+func (m *Manager) srvChanged(info docker.HostInfo) bool {
+	if m.stateManager == nil {
+		return false
+	}
+	return !slices.EqualFunc(srvRecords(info), m.publishedSRV(info.Hostname), sameSRV)
+}
+
+// hostSRVRecords returns the zone records of all SRV records of info, those it declares
+// and those persisted for it, for deleting them along with its A record
+func (m *Manager) hostSRVRecords(records []netcup.DnsRecord, info docker.HostInfo) []netcup.DnsRecord {
+	known := append(srvRecords(info), m.publishedSRV(info.Hostname)...)
+	var matched []netcup.DnsRecord
+	for _, record := range records {
+		if slices.ContainsFunc(known, func(srv netcup.DnsRecord) bool { return sameSRV(record, srv) }) {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
+
+// syncSRV brings the SRV records of hosts in domain in line with their labels. Failures
+// are logged only, SRV records must never block record publishing.
+func (m *Manager) syncSRV(session netcup.DnsSession, domain string, records []netcup.DnsRecord, hosts []docker.HostInfo, source string, dryRun bool) {
+	var create, remove []netcup.DnsRecord
+	var synced []docker.HostInfo
+	for _, info := range hosts {
+		c, r := m.srvChanges(records, info)
+		create, remove = append(create, c...), append(remove, r...)
+		if len(info.SRV) > 0 || len(m.publishedSRV(info.Hostname)) > 0 {
+			synced = append(synced, info)
+		}
+	}
+	if len(create) == 0 && len(remove) == 0 {
+		m.persistSRV(synced)
+		return
+	}
+
+	if dryRun {
+		log.Printf("[DRY RUN] Would create %d and delete %d SRV records in %s", len(create), len(remove), domain)
+		m.auditSRV(domain, remove, audit.ActionDelete, source, true, nil)
+		m.auditSRV(domain, create, audit.ActionCreate, source, true, nil)
+		return
+	}
+
+	log.Printf("Creating %d and deleting %d SRV records in %s", len(create), len(remove), domain)
+	if len(remove) > 0 {
+		err := netcup.DeleteDnsRecords(session, domain, remove)
+		m.auditSRV(domain, remove, audit.ActionDelete, source, false, err)
+		if err != nil {
+			log.Printf("Warning: Failed to delete SRV records of %s: %v", domain, err)
+			return
+		}
+	}
+	if len(create) > 0 {
+		_, err := session.UpdateDnsRecords(domain, &create)
+		m.auditSRV(domain, create, audit.ActionCreate, source, false, err)
+		if err != nil {
+			log.Printf("Warning: Failed to create SRV records in %s: %v", domain, err)
+			m.notifier.SendError(fmt.Sprintf("Failed to create SRV records in %s: %v", domain, err))
+			return
+		}
+	}
+	m.persistSRV(synced)
+}
+
+// persistSRV stores the SRV records declared by hosts, so that records dropped from the
+// label are deleted even across restarts
+func (m *Manager) persistSRV(hosts []docker.HostInfo) {
+	if m.stateManager == nil {
+		return
+	}
+	for _, info := range hosts {
+		var records []state.SRVRecord
+		for _, record := range srvRecords(info) {
+			records = append(records, state.SRVRecord{Name: record.Hostname, Priority: record.Priority, Destination: record.Destination})
+		}
+		if err := m.stateManager.SetSRVRecords(info.Hostname, records); err != nil {
+			log.Printf("Warning: Failed to persist SRV records of %s: %v", info.Hostname, err)
+		}
+	}
+}
+
+// auditSRV records an audit entry for each created or deleted SRV record
+func (m *Manager) auditSRV(domain string, records []netcup.DnsRecord, action audit.Action, source string, dryRun bool, err error) {
+	for _, record := range records {
+		entry := audit.Entry{
+			Action:     action,
+			Source:     source,
+			Hostname:   record.Hostname + "." + domain,
+			Domain:     domain,
+			Subdomain:  record.Hostname,
+			RecordType: "SRV",
+			DryRun:     dryRun,
+		}
+		value := record.Priority + " " + record.Destination
+		if action == audit.ActionDelete {
+			entry.Before = value
+		} else {
+			entry.After = value
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		m.recordAudit(entry)
+	}
+}
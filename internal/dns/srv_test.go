@@ -0,0 +1,97 @@
+package dns
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+// srvOf returns the SRV records of the zone as priority and destination, keyed by name
+func srvOf(api *netcup.FakeAPI) map[string]string {
+	records := make(map[string]string)
+	for _, record := range api.Records("example.com") {
+		if record.Type == "SRV" {
+			records[record.Hostname] = record.Priority + " " + record.Destination
+		}
+	}
+	return records
+}
+
+func TestSRVRecords(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("state.NewManager() error = %v", err)
+	}
+	manager := NewManager(testConfig(), api, stateManager)
+	ctx := context.Background()
+
+	mc := docker.HostInfo{
+		Hostname:      "mc.example.com",
+		Domain:        "example.com",
+		Subdomain:     "mc",
+		ContainerName: "minecraft",
+		SRV:           []docker.SRVRecord{{Service: "_minecraft._tcp", Priority: 0, Weight: 5, Port: 25565, Target: "mc.example.com"}},
+	}
+	if err := manager.ProcessHostInfo(ctx, mc); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if got := srvOf(api); len(got) != 1 || got["_minecraft._tcp.mc"] != "0 5 25565 mc.example.com." {
+		t.Fatalf("SRV records = %v, want _minecraft._tcp.mc -> 0 5 25565 mc.example.com.", got)
+	}
+	if persisted := stateManager.SRVRecords("mc.example.com"); len(persisted) != 1 {
+		t.Errorf("Persisted SRV records = %v, want 1", persisted)
+	}
+
+	// Changing the label replaces the record, even on a host already in sync
+	mc.SRV = []docker.SRVRecord{{Service: "_minecraft._udp", Priority: 10, Weight: 0, Port: 19132, Target: "mc.example.com"}}
+	if err := manager.SyncHosts(ctx, []docker.HostInfo{mc}); err != nil {
+		t.Fatalf("SyncHosts() error = %v", err)
+	}
+	if got := srvOf(api); len(got) != 1 || got["_minecraft._udp.mc"] != "10 0 19132 mc.example.com." {
+		t.Fatalf("SRV records = %v, want only _minecraft._udp.mc -> 10 0 19132 mc.example.com.", got)
+	}
+
+	// Removing the host deletes its SRV records, even when the label is gone
+	removal := mc
+	removal.SRV = nil
+	removal.Remove = true
+	if err := manager.ProcessHostInfo(ctx, removal); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if got := srvOf(api); len(got) != 0 {
+		t.Errorf("SRV records = %v, want none after removal", got)
+	}
+	if persisted := stateManager.SRVRecords("mc.example.com"); len(persisted) != 0 {
+		t.Errorf("Persisted SRV records = %v, want none after removal", persisted)
+	}
+}
+
+func TestSameSRV(t *testing.T) {
+	record := netcup.DnsRecord{Hostname: "_sip._udp", Type: "SRV", Priority: "10", Destination: "5 5060 sip.example.com."}
+
+	tests := []struct {
+		name  string
+		other netcup.DnsRecord
+		want  bool
+	}{
+		{name: "equal", other: record, want: true},
+		{name: "without trailing dot", other: netcup.DnsRecord{Hostname: "_sip._udp", Type: "SRV", Priority: "10", Destination: "5 5060 sip.example.com"}, want: true},
+		{name: "other priority", other: netcup.DnsRecord{Hostname: "_sip._udp", Type: "SRV", Priority: "20", Destination: "5 5060 sip.example.com."}, want: false},
+		{name: "other port", other: netcup.DnsRecord{Hostname: "_sip._udp", Type: "SRV", Priority: "10", Destination: "5 5061 sip.example.com."}, want: false},
+		{name: "other type", other: netcup.DnsRecord{Hostname: "_sip._udp", Type: "TXT", Priority: "10", Destination: "5 5060 sip.example.com."}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameSRV(record, tt.other); got != tt.want {
+				t.Errorf("sameSRV() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
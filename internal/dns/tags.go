@@ -0,0 +1,91 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+// errTagsWithoutState is returned by tag operations, as tags are only kept in the state
+var errTagsWithoutState = errors.New("tags require state persistence")
+
+// ReconcileTag re-applies the persisted records carrying tag, like the reconciliation of
+// all records on startup
+func (m *Manager) ReconcileTag(ctx context.Context, tag string) error {
+	if m.stateManager == nil {
+		return errTagsWithoutState
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.config.ObserveMode() {
+		return fmt.Errorf("reconciliation writes DNS, which observe mode never does")
+	}
+	if m.paused {
+		return ErrPaused
+	}
+
+	records := m.stateManager.GetRecordsWithTag(tag)
+	if len(records) == 0 {
+		log.Printf("No persisted records tagged %s", tag)
+		return nil
+	}
+	return m.reconcileRecords(ctx, records)
+}
+
+// RemoveTag deletes the records carrying tag from DNS and the state and returns how many
+// were deleted. Records whose container still runs are published again by its next event
+// or scan.
+func (m *Manager) RemoveTag(ctx context.Context, tag string) (int, error) {
+	if m.stateManager == nil {
+		return 0, errTagsWithoutState
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.config.ObserveMode() {
+		return 0, fmt.Errorf("deleting records writes DNS, which observe mode never does")
+	}
+	if m.paused {
+		return 0, ErrPaused
+	}
+
+	var errs []error
+	removed := 0
+	for _, record := range m.stateManager.GetRecordsWithTag(tag) {
+		select {
+		case <-ctx.Done():
+			return removed, ctx.Err()
+		default:
+		}
+
+		info := docker.HostInfo{
+			ContainerName:  record.Container,
+			Hostname:       record.Hostname,
+			Domain:         record.Domain,
+			Subdomain:      record.Subdomain,
+			DockerHost:     record.DockerHost,
+			ComposeProject: record.ComposeProject,
+			ComposeService: record.ComposeService,
+			Tags:           record.Tags,
+		}
+		if err := m.removeHost(ctx, info, "tag"); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", record.Hostname, err))
+			continue
+		}
+		// Also drops records that were already gone from DNS
+		if !m.config.DryRun {
+			if err := m.stateManager.RemoveRecord(record.Hostname); err != nil {
+				log.Printf("Warning: Failed to remove persisted DNS state for %s: %v", record.Hostname, err)
+			}
+		}
+		removed++
+	}
+
+	return removed, errors.Join(errs...)
+}
@@ -0,0 +1,70 @@
+package dns
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestReconcileTag(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	stateManager.UpdateRecordWithOrigin("shop.example.com", "example.com", "shop", "203.0.113.1", "A", state.Origin{Container: "shop", Tags: []string{"prod"}})
+	stateManager.UpdateRecordWithOrigin("blog.example.com", "example.com", "blog", "203.0.113.1", "A", state.Origin{Container: "blog", Tags: []string{"staging"}})
+	manager := NewManager(testConfig(), api, stateManager)
+
+	if err := manager.ReconcileTag(context.Background(), "prod"); err != nil {
+		t.Fatalf("ReconcileTag() error = %v", err)
+	}
+	records := api.Records("example.com")
+	if len(records) != 1 || records[0].Hostname != "shop" {
+		t.Errorf("Zone records = %+v, want only the record tagged prod", records)
+	}
+}
+
+func TestRemoveTag(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	stateManager, err := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	manager := NewManager(testConfig(), api, stateManager)
+	ctx := context.Background()
+
+	for _, info := range []docker.HostInfo{
+		{ContainerName: "shop", Hostname: "shop.example.com", Domain: "example.com", Subdomain: "shop", Tags: []string{"prod", "web"}},
+		{ContainerName: "api", Hostname: "api.example.com", Domain: "example.com", Subdomain: "api", Tags: []string{"prod"}},
+		{ContainerName: "blog", Hostname: "blog.example.com", Domain: "example.com", Subdomain: "blog", Tags: []string{"web"}},
+	} {
+		if err := manager.ProcessHostInfo(ctx, info); err != nil {
+			t.Fatalf("ProcessHostInfo(%s) error = %v", info.Hostname, err)
+		}
+	}
+
+	removed, err := manager.RemoveTag(ctx, "prod")
+	if err != nil {
+		t.Fatalf("RemoveTag() error = %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("RemoveTag() removed %d records, want 2", removed)
+	}
+	records := api.Records("example.com")
+	if len(records) != 1 || records[0].Hostname != "blog" {
+		t.Errorf("Zone records = %+v, want only the blog record", records)
+	}
+	if _, ok := stateManager.GetRecord("shop.example.com"); ok {
+		t.Error("Removed record is still in the state")
+	}
+	if _, ok := stateManager.GetRecord("blog.example.com"); !ok {
+		t.Error("Record without the tag was removed from the state")
+	}
+}
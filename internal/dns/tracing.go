@@ -0,0 +1,31 @@
+package dns
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+var tracer = otel.Tracer("github.com/alex289/docker-traefik-netcup-companion/internal/dns")
+
+// hostAttributes describes a host on a span
+func hostAttributes(info docker.HostInfo) trace.SpanStartOption {
+	return trace.WithAttributes(
+		attribute.String("dns.hostname", info.Hostname),
+		attribute.String("dns.domain", info.Domain),
+		attribute.String("docker.container.name", info.ContainerName),
+		attribute.Bool("dns.remove", info.Remove),
+	)
+}
+
+// endSpan records err on the span, if any, and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,33 @@
+package dns
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Zone TTL bounds in seconds. Values outside are rejected before reaching the API.
+const (
+	minZoneTTL = 60
+	maxZoneTTL = 2147483647 // RFC 2181
+)
+
+// checkManaged refuses subdomains outside MANAGED_SUBDOMAIN_PATTERN, so unexpected
+// hostnames from badly written labels cannot touch records managed elsewhere
+func (m *Manager) checkManaged(subdomain string) error {
+	if pattern := m.config.ManagedSubdomainPattern; pattern != nil && !pattern.MatchString(subdomain) {
+		return fmt.Errorf("subdomain %q does not match MANAGED_SUBDOMAIN_PATTERN %s", subdomain, pattern)
+	}
+	return nil
+}
+
+// validateTTL checks a zone TTL in seconds
+func validateTTL(ttl string) error {
+	seconds, err := strconv.Atoi(ttl)
+	if err != nil {
+		return fmt.Errorf("TTL %q is not a number", ttl)
+	}
+	if seconds < minZoneTTL || seconds > maxZoneTTL {
+		return fmt.Errorf("TTL %d is outside the allowed range of %d to %d seconds", seconds, minZoneTTL, maxZoneTTL)
+	}
+	return nil
+}
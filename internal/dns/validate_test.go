@@ -0,0 +1,88 @@
+package dns
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestValidateTTL(t *testing.T) {
+	tests := []struct {
+		ttl     string
+		wantErr bool
+	}{
+		{ttl: "60"},
+		{ttl: "86400"},
+		{ttl: "59", wantErr: true},
+		{ttl: "2147483648", wantErr: true},
+		{ttl: "1h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := validateTTL(tt.ttl); (err != nil) != tt.wantErr {
+			t.Errorf("validateTTL(%q) error = %v, wantErr %v", tt.ttl, err, tt.wantErr)
+		}
+	}
+}
+
+func TestInvalidDataNeverReachesAPI(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+
+	cfg := testConfig()
+	cfg.ZoneSettings = map[string]config.ZoneSettings{"example.com": {TTL: "5"}}
+	manager := NewManager(cfg, api, nil)
+	ctx := context.Background()
+
+	if err := manager.ProcessHostInfo(ctx, docker.HostInfo{Hostname: "my_app!.example.com", Domain: "example.com", Subdomain: "my_app!"}); err == nil {
+		t.Error("ProcessHostInfo() with an invalid subdomain succeeded")
+	}
+	if _, err := manager.LowerTTLs(ctx, []string{"example.com"}, "0"); err == nil {
+		t.Error("LowerTTLs() with an invalid TTL succeeded")
+	}
+
+	for _, action := range []string{"updateDnsRecords", "updateDnsZone"} {
+		if got := api.CallCount(action); got != 0 {
+			t.Errorf("%s called %d times, want 0", action, got)
+		}
+	}
+}
+
+func TestManagedSubdomainPattern(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com", netcup.DnsRecord{Hostname: "www", Type: "A", Destination: "198.51.100.1"})
+	cfg := testConfig()
+	cfg.ManagedSubdomainPattern = regexp.MustCompile(`.*\.apps$`)
+	manager := NewManager(cfg, api, nil)
+	ctx := context.Background()
+
+	err := manager.ProcessHostInfo(ctx, docker.HostInfo{Hostname: "www.example.com", Domain: "example.com", Subdomain: "www"})
+	if err == nil || !strings.Contains(err.Error(), "MANAGED_SUBDOMAIN_PATTERN") {
+		t.Errorf("ProcessHostInfo() error = %v, want refusal", err)
+	}
+	err = manager.ProcessHostInfo(ctx, docker.HostInfo{Hostname: "www.example.com", Domain: "example.com", Subdomain: "www", Remove: true})
+	if err == nil {
+		t.Error("ProcessHostInfo() removal error = nil, want refusal")
+	}
+
+	if err := manager.SyncHosts(ctx, []docker.HostInfo{
+		{Hostname: "example.com", Domain: "example.com", Subdomain: "@"},
+		{Hostname: "shop.apps.example.com", Domain: "example.com", Subdomain: "shop.apps"},
+	}); err != nil {
+		t.Fatalf("SyncHosts() error = %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, record := range api.Records("example.com") {
+		got[record.Hostname] = record.Destination
+	}
+	want := map[string]string{"www": "198.51.100.1", "shop.apps": cfg.HostIP}
+	if len(got) != len(want) || got["www"] != want["www"] || got["shop.apps"] != want["shop.apps"] {
+		t.Errorf("records = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,57 @@
+package dns
+
+import (
+	"strings"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+// coveredByWildcard reports whether subdomain is left without a record of its own, as
+// a wildcard record of the zone already resolves it to ip. This applies with
+// WILDCARD_POLICY=skip and to domains the companion consolidated into a wildcard.
+func (m *Manager) coveredByWildcard(records []netcup.DnsRecord, domain, subdomain, ip string) bool {
+	if m.config.WildcardPolicy != config.WildcardSkip && !m.consolidatedDomain(domain) {
+		return false
+	}
+	wildcard := coveringWildcard(records, subdomain)
+	if wildcard == "" {
+		return false
+	}
+
+	matched := netcup.FindRecords(records, wildcard, "A")
+	for _, record := range matched {
+		if record.Destination != ip {
+			return false
+		}
+	}
+	return len(matched) > 0
+}
+
+// coveringWildcard returns the wildcard name ("*" or e.g. "*.eu") answering queries for
+// subdomain as described in RFC 4592: subdomain itself must not exist, and the wildcard
+// is the one below its closest existing ancestor. It returns "" for the zone apex and
+// names that exist, e.g. as they hold a record or one below them.
+func coveringWildcard(records []netcup.DnsRecord, subdomain string) string {
+	if subdomain == "@" || strings.HasPrefix(subdomain, "*") || nameExists(records, subdomain) {
+		return ""
+	}
+
+	labels := strings.Split(subdomain, ".")
+	for i := 1; i < len(labels); i++ {
+		if ancestor := strings.Join(labels[i:], "."); nameExists(records, ancestor) {
+			return "*." + ancestor
+		}
+	}
+	return "*"
+}
+
+// nameExists reports whether the zone holds a record named name or below it
+func nameExists(records []netcup.DnsRecord, name string) bool {
+	for _, record := range records {
+		if record.Hostname == name || strings.HasSuffix(record.Hostname, "."+name) {
+			return true
+		}
+	}
+	return false
+}
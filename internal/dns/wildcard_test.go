@@ -0,0 +1,63 @@
+package dns
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestCoveringWildcard(t *testing.T) {
+	records := []netcup.DnsRecord{
+		{Hostname: "*", Type: "A", Destination: "203.0.113.1"},
+		{Hostname: "*.eu", Type: "A", Destination: "203.0.113.2"},
+		{Hostname: "www", Type: "CNAME", Destination: "example.com."},
+		{Hostname: "_meta.shop", Type: "TXT", Destination: "{}"},
+	}
+
+	tests := []struct {
+		subdomain string
+		want      string
+	}{
+		{subdomain: "app", want: "*"},
+		{subdomain: "app.eu", want: "*.eu"},
+		{subdomain: "app.us", want: "*"},
+		{subdomain: "www", want: ""},
+		{subdomain: "shop", want: ""}, // exists as the parent of its metadata record
+		{subdomain: "@", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := coveringWildcard(records, tt.subdomain); got != tt.want {
+			t.Errorf("coveringWildcard(%q) = %q, want %q", tt.subdomain, got, tt.want)
+		}
+	}
+}
+
+func TestProcessHostInfo_WildcardSkip(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com", netcup.DnsRecord{Hostname: "*", Type: "A", Destination: "203.0.113.1"})
+	api.AddZone("example.org", netcup.DnsRecord{Hostname: "*", Type: "A", Destination: "198.51.100.1"})
+	cfg := testConfig()
+	cfg.WildcardPolicy = config.WildcardSkip
+	manager := NewManager(cfg, api, nil)
+	ctx := context.Background()
+
+	// The wildcard already points at the host
+	if err := manager.ProcessHostInfo(ctx, docker.HostInfo{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app"}); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if records := api.Records("example.com"); len(records) != 1 {
+		t.Errorf("records = %v, want only the wildcard", records)
+	}
+
+	// A wildcard pointing elsewhere does not cover the host
+	if err := manager.ProcessHostInfo(ctx, docker.HostInfo{Hostname: "app.example.org", Domain: "example.org", Subdomain: "app"}); err != nil {
+		t.Fatalf("ProcessHostInfo() error = %v", err)
+	}
+	if records := netcup.FindRecords(api.Records("example.org"), "app", "A"); len(records) != 1 {
+		t.Errorf("records of app = %v, want a record of its own", records)
+	}
+}
@@ -0,0 +1,175 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/audit"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+// ZoneImport summarizes the changes of ImportZone
+type ZoneImport struct {
+	Created   int
+	Deleted   int
+	Unchanged int
+}
+
+// ExportZone returns the zone settings and all records of domain
+func (m *Manager) ExportZone(ctx context.Context, domain string) (_ *netcup.DnsZoneData, _ []netcup.DnsRecord, err error) {
+	ctx, span := tracer.Start(ctx, "dns.ExportZone")
+	defer func() { endSpan(span, err) }()
+
+	session, err := m.client.Login(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to login to Netcup: %w", err)
+	}
+	defer session.Logout()
+
+	zone, err := session.InfoDnsZone(domain)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get DNS zone for %s: %w", domain, err)
+	}
+	records, err := session.InfoDnsRecords(domain)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get DNS records for %s: %w", domain, err)
+	}
+	return zone, *records, nil
+}
+
+// ImportZone creates the given records in domain, skipping records that already exist
+// with the same host, type, priority and destination. With replace, existing records
+// missing from records are deleted, making the zone match records exactly. All records
+// are validated before anything is changed.
+func (m *Manager) ImportZone(ctx context.Context, domain string, records []netcup.DnsRecord, replace bool) (result ZoneImport, err error) {
+	ctx, span := tracer.Start(ctx, "dns.ImportZone")
+	defer func() { endSpan(span, err) }()
+
+	for _, record := range records {
+		if err := netcup.ValidateRecord(record); err != nil {
+			return result, fmt.Errorf("invalid %s record: %w", record.Type, err)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.paused {
+		return result, ErrPaused
+	}
+
+	session, err := m.client.Login(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to login to Netcup: %w", err)
+	}
+	defer session.Logout()
+
+	if err := m.checkZoneDNSSEC(session, domain); err != nil {
+		return result, err
+	}
+
+	existing, err := session.InfoDnsRecords(domain)
+	if err != nil {
+		return result, fmt.Errorf("failed to get DNS records for %s: %w", domain, err)
+	}
+
+	present := make(map[zoneRecordKey]bool, len(*existing))
+	for _, record := range *existing {
+		present[recordKey(record)] = true
+	}
+	wanted := make(map[zoneRecordKey]bool, len(records))
+	var create []netcup.DnsRecord
+	for _, record := range records {
+		key := recordKey(record)
+		if wanted[key] {
+			continue
+		}
+		wanted[key] = true
+		if present[key] {
+			result.Unchanged++
+			continue
+		}
+		create = append(create, netcup.DnsRecord{
+			Hostname:    record.Hostname,
+			Type:        record.Type,
+			Priority:    record.Priority,
+			Destination: record.Destination,
+		})
+	}
+	var remove []netcup.DnsRecord
+	if replace {
+		for _, record := range *existing {
+			if !wanted[recordKey(record)] {
+				remove = append(remove, record)
+			}
+		}
+	}
+	result.Created, result.Deleted = len(create), len(remove)
+
+	if m.config.DryRun {
+		log.Printf("[DRY RUN] Would import %s: %d records created, %d deleted, %d unchanged", domain, result.Created, result.Deleted, result.Unchanged)
+		m.auditZoneRecords(domain, remove, audit.ActionDelete, nil)
+		m.auditZoneRecords(domain, create, audit.ActionCreate, nil)
+		return result, nil
+	}
+
+	if len(remove) > 0 {
+		err := netcup.DeleteDnsRecords(session, domain, remove)
+		m.auditZoneRecords(domain, remove, audit.ActionDelete, err)
+		if err != nil {
+			return result, fmt.Errorf("failed to delete DNS records of %s: %w", domain, err)
+		}
+	}
+	if len(create) > 0 {
+		_, err := session.UpdateDnsRecords(domain, &create)
+		m.auditZoneRecords(domain, create, audit.ActionCreate, err)
+		if err != nil {
+			return result, fmt.Errorf("failed to create DNS records in %s: %w", domain, err)
+		}
+	}
+
+	log.Printf("Imported %s: %d records created, %d deleted, %d unchanged", domain, result.Created, result.Deleted, result.Unchanged)
+	return result, nil
+}
+
+// zoneRecordKey identifies a record by its content, ignoring its Netcup ID
+type zoneRecordKey struct {
+	hostname, recordType, priority, destination string
+}
+
+func recordKey(record netcup.DnsRecord) zoneRecordKey {
+	key := zoneRecordKey{hostname: record.Hostname, recordType: record.Type, destination: record.Destination}
+	// Netcup reports priority 0 for record types without one
+	if record.Type == "MX" || record.Type == "SRV" {
+		key.priority = record.Priority
+	}
+	return key
+}
+
+// auditZoneRecords records an audit entry for each imported or deleted record
+func (m *Manager) auditZoneRecords(domain string, records []netcup.DnsRecord, action audit.Action, err error) {
+	for _, record := range records {
+		hostname := record.Hostname + "." + domain
+		if record.Hostname == "@" {
+			hostname = domain
+		}
+		entry := audit.Entry{
+			Action:     action,
+			Source:     "import",
+			Hostname:   hostname,
+			Domain:     domain,
+			Subdomain:  record.Hostname,
+			RecordType: record.Type,
+			DryRun:     m.config.DryRun,
+		}
+		if action == audit.ActionDelete {
+			entry.Before = record.Destination
+		} else {
+			entry.After = record.Destination
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		m.recordAudit(entry)
+	}
+}
@@ -0,0 +1,86 @@
+package dns
+
+import (
+	"context"
+	"testing"
+
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestImportZone(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com",
+		netcup.DnsRecord{Hostname: "app", Type: "A", Priority: "0", Destination: "203.0.113.1"},
+		netcup.DnsRecord{Hostname: "old", Type: "A", Priority: "0", Destination: "203.0.113.9"},
+	)
+	manager := NewManager(testConfig(), api, nil)
+	ctx := context.Background()
+
+	records := []netcup.DnsRecord{
+		{Hostname: "app", Type: "A", Priority: "0", Destination: "203.0.113.1"},
+		{Hostname: "@", Type: "MX", Priority: "10", Destination: "mail.example.com."},
+		{Hostname: "@", Type: "TXT", Priority: "0", Destination: "v=spf1 -all"},
+	}
+
+	result, err := manager.ImportZone(ctx, "example.com", records, false)
+	if err != nil {
+		t.Fatalf("ImportZone() error = %v", err)
+	}
+	if result != (ZoneImport{Created: 2, Unchanged: 1}) {
+		t.Errorf("ImportZone() = %+v, want 2 created, 1 unchanged", result)
+	}
+	if got := len(api.Records("example.com")); got != 4 {
+		t.Errorf("records after import = %d, want 4", got)
+	}
+
+	// Replacing deletes the record missing from the file; importing again changes nothing else
+	result, err = manager.ImportZone(ctx, "example.com", records, true)
+	if err != nil {
+		t.Fatalf("ImportZone(replace) error = %v", err)
+	}
+	if result != (ZoneImport{Deleted: 1, Unchanged: 3}) {
+		t.Errorf("ImportZone(replace) = %+v, want 1 deleted, 3 unchanged", result)
+	}
+	for _, record := range api.Records("example.com") {
+		if record.Hostname == "old" {
+			t.Errorf("record old was not deleted")
+		}
+	}
+}
+
+func TestImportZone_Invalid(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	manager := NewManager(testConfig(), api, nil)
+
+	records := []netcup.DnsRecord{
+		{Hostname: "app", Type: "A", Priority: "0", Destination: "203.0.113.1"},
+		{Hostname: "bad", Type: "A", Priority: "0", Destination: "not-an-ip"},
+	}
+	if _, err := manager.ImportZone(context.Background(), "example.com", records, false); err == nil {
+		t.Fatal("ImportZone() error = nil, want validation error")
+	}
+	if got := len(api.Records("example.com")); got != 0 {
+		t.Errorf("records after failed import = %d, want 0", got)
+	}
+}
+
+func TestImportZone_DryRun(t *testing.T) {
+	api := netcup.NewFakeAPI()
+	api.AddZone("example.com")
+	cfg := testConfig()
+	cfg.DryRun = true
+	manager := NewManager(cfg, api, nil)
+
+	records := []netcup.DnsRecord{{Hostname: "app", Type: "A", Priority: "0", Destination: "203.0.113.1"}}
+	result, err := manager.ImportZone(context.Background(), "example.com", records, false)
+	if err != nil {
+		t.Fatalf("ImportZone() error = %v", err)
+	}
+	if result.Created != 1 {
+		t.Errorf("ImportZone() created = %d, want 1", result.Created)
+	}
+	if got := len(api.Records("example.com")); got != 0 {
+		t.Errorf("records after dry run = %d, want 0", got)
+	}
+}
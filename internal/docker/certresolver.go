@@ -0,0 +1,26 @@
+package docker
+
+import "log"
+
+// withCertResolvers drops hosts of routers not using one of the configured ACME
+// certificate resolvers. Routers without TLS often serve internal-only names, so
+// routers without a certresolver label are dropped as well.
+func (w *Watcher) withCertResolvers(hosts []HostInfo) []HostInfo {
+	if w.certResolvers == nil {
+		return hosts
+	}
+
+	kept := hosts[:0]
+	for _, info := range hosts {
+		if w.certResolvers[info.CertResolver] {
+			kept = append(kept, info)
+			continue
+		}
+		if info.CertResolver == "" {
+			log.Printf("Skipping host %s of router %s (no certresolver)", info.Hostname, info.Router)
+		} else {
+			log.Printf("Skipping host %s of router %s (certresolver %s is not managed)", info.Hostname, info.Router, info.CertResolver)
+		}
+	}
+	return kept
+}
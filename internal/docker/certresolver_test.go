@@ -0,0 +1,56 @@
+package docker
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWithCertResolvers(t *testing.T) {
+	labels := map[string]string{
+		"traefik.http.routers.public.rule":              "Host(`app.example.com`)",
+		"traefik.http.routers.public.tls.certresolver":  "letsencrypt",
+		"traefik.http.routers.staging.rule":             "Host(`staging.example.com`)",
+		"traefik.http.routers.staging.tls.certresolver": " le-staging ",
+		"traefik.http.routers.internal.rule":            "Host(`app.internal.example.com`)",
+		"traefik.http.routers.internal.tls":             "true",
+		"traefik.http.routers.plain.rule":               "Host(`plain.example.com`)",
+	}
+
+	tests := []struct {
+		name          string
+		certResolvers map[string]bool
+		want          []string
+	}{
+		{
+			name: "all routers by default",
+			want: []string{"app.example.com", "app.internal.example.com", "plain.example.com", "staging.example.com"},
+		},
+		{
+			name:          "only routers with managed certresolvers",
+			certResolvers: map[string]bool{"letsencrypt": true},
+			want:          []string{"app.example.com"},
+		},
+		{
+			name:          "several certresolvers",
+			certResolvers: map[string]bool{"letsencrypt": true, "le-staging": true},
+			want:          []string{"app.example.com", "staging.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Watcher{certResolvers: tt.certResolvers}
+			hosts := w.withCertResolvers(extractHostsFromLabels("container123", "/app", labels))
+
+			var got []string
+			for _, host := range hosts {
+				got = append(got, host.Hostname)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("hosts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
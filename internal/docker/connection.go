@@ -0,0 +1,174 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+// ConnectionOptions holds explicit settings for connecting to remote Docker daemons
+type ConnectionOptions struct {
+	TLSCACert     string // Path to the CA certificate used to verify the daemon
+	TLSCert       string // Path to the client certificate
+	TLSKey        string // Path to the client key
+	TLSSkipVerify bool   // Skip verification of the daemon certificate
+
+	SSHIdentityFile          string // Private key used for ssh:// hosts
+	SSHInsecureIgnoreHostKey bool   // Disable host key checking for ssh:// hosts
+}
+
+// tlsEnabled reports whether explicit TLS settings were given
+func (o *ConnectionOptions) tlsEnabled() bool {
+	return o.TLSCACert != "" || o.TLSCert != "" || o.TLSKey != ""
+}
+
+// newClient creates a Docker client for a single daemon
+func newClient(host string, conn *ConnectionOptions) (*client.Client, error) {
+	opts, err := clientOpts(host, conn)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewClientWithOpts(opts...)
+}
+
+// clientOpts builds the Docker client options for a single daemon.
+// An empty host falls back to DOCKER_HOST or the local socket.
+func clientOpts(host string, conn *ConnectionOptions) ([]client.Opt, error) {
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+
+	if host == "" {
+		host = os.Getenv(client.EnvOverrideHost)
+	}
+
+	if u, err := url.Parse(host); err == nil && u.Scheme == "ssh" {
+		args, err := sshArgs(u, conn)
+		if err != nil {
+			return nil, err
+		}
+		dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialCommand(ctx, "ssh", args...)
+		}
+		// The host is only used to build request URLs, the dialer tunnels through ssh
+		return append(opts,
+			client.WithHost("http://docker.example.com"),
+			client.WithDialContext(dialer),
+		), nil
+	}
+
+	if conn.tlsEnabled() {
+		tlsc, err := tlsconfig.Client(tlsconfig.Options{
+			CAFile:             conn.TLSCACert,
+			CertFile:           conn.TLSCert,
+			KeyFile:            conn.TLSKey,
+			InsecureSkipVerify: conn.TLSSkipVerify,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Docker TLS config: %w", err)
+		}
+		opts = append(opts, client.WithHTTPClient(&http.Client{
+			Transport:     &http.Transport{TLSClientConfig: tlsc},
+			CheckRedirect: client.CheckRedirect,
+		}))
+		// Replacing the HTTP client drops the host configured by FromEnv, so apply it again
+		if host == "" {
+			host = client.DefaultDockerHost
+		}
+	}
+
+	if host != "" {
+		opts = append(opts, client.WithHost(host))
+	}
+
+	return opts, nil
+}
+
+// sshArgs builds the ssh command line that runs `docker system dial-stdio` on the remote host
+func sshArgs(u *url.URL, conn *ConnectionOptions) ([]string, error) {
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("ssh Docker host %q has no hostname", u.String())
+	}
+	if u.Path != "" && u.Path != "/" {
+		return nil, fmt.Errorf("ssh Docker host %q must not contain a path", u.String())
+	}
+
+	args := []string{"-o", "ConnectTimeout=30", "-o", "BatchMode=yes"}
+	if u.User != nil {
+		args = append(args, "-l", u.User.Username())
+	}
+	if port := u.Port(); port != "" {
+		args = append(args, "-p", port)
+	}
+	if conn.SSHIdentityFile != "" {
+		args = append(args, "-i", conn.SSHIdentityFile)
+	}
+	if conn.SSHInsecureIgnoreHostKey {
+		args = append(args, "-o", "StrictHostKeyChecking=no", "-o", "UserKnownHostsFile=/dev/null")
+	}
+
+	return append(args, "--", u.Hostname(), "docker", "system", "dial-stdio"), nil
+}
+
+// commandConn is a net.Conn backed by the stdin and stdout of a command
+type commandConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+
+	closeOnce sync.Once
+}
+
+func dialCommand(ctx context.Context, name string, args ...string) (net.Conn, error) {
+	// The connection outlives the dial context, so the command is not bound to it
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", name, err)
+	}
+
+	return &commandConn{cmd: cmd, stdin: stdin, stdout: stdout}, nil
+}
+
+func (c *commandConn) Read(p []byte) (int, error)  { return c.stdout.Read(p) }
+func (c *commandConn) Write(p []byte) (int, error) { return c.stdin.Write(p) }
+
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		if c.cmd.Process != nil {
+			c.cmd.Process.Kill()
+		}
+		c.cmd.Wait()
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr                { return commandAddr{} }
+func (c *commandConn) RemoteAddr() net.Addr               { return commandAddr{} }
+func (c *commandConn) SetDeadline(t time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// commandAddr is the placeholder address of a commandConn
+type commandAddr struct{}
+
+func (commandAddr) Network() string { return "command" }
+func (commandAddr) String() string  { return "command" }
@@ -0,0 +1,115 @@
+package docker
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSSHArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		conn    ConnectionOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "host only",
+			host: "ssh://docker.example.com",
+			want: "-o ConnectTimeout=30 -o BatchMode=yes -- docker.example.com docker system dial-stdio",
+		},
+		{
+			name: "user, port and identity",
+			host: "ssh://deploy@docker.example.com:2222",
+			conn: ConnectionOptions{SSHIdentityFile: "/keys/id_ed25519"},
+			want: "-o ConnectTimeout=30 -o BatchMode=yes -l deploy -p 2222 -i /keys/id_ed25519 -- docker.example.com docker system dial-stdio",
+		},
+		{
+			name: "host key checking disabled",
+			host: "ssh://docker.example.com",
+			conn: ConnectionOptions{SSHInsecureIgnoreHostKey: true},
+			want: "-o ConnectTimeout=30 -o BatchMode=yes -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -- docker.example.com docker system dial-stdio",
+		},
+		{
+			name:    "path is rejected",
+			host:    "ssh://docker.example.com/var/run/docker.sock",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.host)
+			if err != nil {
+				t.Fatalf("url.Parse() error = %v", err)
+			}
+
+			args, err := sshArgs(u, &tt.conn)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("sshArgs() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sshArgs() error = %v", err)
+			}
+			if got := strings.Join(args, " "); got != tt.want {
+				t.Errorf("sshArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClient_SSHHost(t *testing.T) {
+	cli, err := newClient("ssh://deploy@docker.example.com", &ConnectionOptions{})
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+	defer cli.Close()
+
+	if cli.DaemonHost() != "http://docker.example.com" {
+		t.Errorf("DaemonHost() = %v, want placeholder http host", cli.DaemonHost())
+	}
+}
+
+func TestNewClient_TLS(t *testing.T) {
+	t.Run("missing certificate", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := newClient("tcp://docker.example.com:2376", &ConnectionOptions{
+			TLSCACert: filepath.Join(dir, "ca.pem"),
+		})
+		if err == nil {
+			t.Error("newClient() error = nil, want error for missing CA file")
+		}
+	})
+
+	t.Run("invalid certificate", func(t *testing.T) {
+		dir := t.TempDir()
+		caFile := filepath.Join(dir, "ca.pem")
+		if err := os.WriteFile(caFile, []byte("not a certificate"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := newClient("tcp://docker.example.com:2376", &ConnectionOptions{TLSCACert: caFile})
+		if err == nil {
+			t.Error("newClient() error = nil, want error for invalid CA file")
+		}
+	})
+}
+
+func TestNewClient_DefaultHost(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "tcp://docker.example.com:2375")
+
+	cli, err := newClient("", &ConnectionOptions{})
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+	defer cli.Close()
+
+	if cli.DaemonHost() != "tcp://docker.example.com:2375" {
+		t.Errorf("DaemonHost() = %v, want DOCKER_HOST", cli.DaemonHost())
+	}
+}
@@ -0,0 +1,93 @@
+package docker
+
+import (
+	"log"
+	"time"
+)
+
+// CrashLoopOptions configures how containers caught in a restart loop are published
+type CrashLoopOptions struct {
+	Restarts int           // Restarts within Window that make a crash loop; zero disables detection
+	Window   time.Duration // Period in which the restarts of a container are counted
+	Cooldown time.Duration // How long a looping container must stay up before it is published
+
+	// OnCrashLoop is called once when a container is found in a crash loop
+	OnCrashLoop func(containerName string, restartCount int)
+}
+
+// crashLoop tracks the restarts of a container by Docker's restart count
+type crashLoop struct {
+	restartCount int
+	restarts     []time.Time // Restarts within the window, oldest first
+	looping      bool
+	generation   int // Identifies the latest start held back while looping
+}
+
+// holdCrashLoop reports whether the start of a container is held back as the container
+// is in a crash loop. publish is called once the container stayed up for the cooldown;
+// every further start restarts the cooldown and replaces publish, so only the latest
+// start of a looping container touches DNS.
+func (w *Watcher) holdCrashLoop(containerID, containerName string, restartCount int, publish func()) bool {
+	if w.crashLoop.Restarts <= 0 {
+		return false
+	}
+
+	now := time.Now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pruneCrashLoops(now)
+
+	loop := w.crashLoops[containerID]
+	// Docker resets the restart count when a container is started by hand
+	if loop == nil || restartCount < loop.restartCount {
+		loop = &crashLoop{}
+		w.crashLoops[containerID] = loop
+	}
+	if restartCount > loop.restartCount {
+		loop.restarts = append(loop.restarts, now)
+	}
+	loop.restartCount = restartCount
+
+	if !loop.looping {
+		if len(loop.restarts) < w.crashLoop.Restarts {
+			return false
+		}
+		loop.looping = true
+		log.Printf("Container %s restarted %d times within %s, holding back its DNS changes until it stays up for %s", containerName, len(loop.restarts), w.crashLoop.Window, w.crashLoop.Cooldown)
+		if w.crashLoop.OnCrashLoop != nil {
+			go w.crashLoop.OnCrashLoop(containerName, restartCount)
+		}
+	}
+
+	loop.generation++
+	generation := loop.generation
+	time.AfterFunc(w.crashLoop.Cooldown, func() {
+		w.mu.Lock()
+		if w.crashLoops[containerID] != loop || loop.generation != generation {
+			w.mu.Unlock()
+			return
+		}
+		loop.looping = false
+		loop.restarts = nil
+		w.mu.Unlock()
+
+		log.Printf("Container %s stayed up for %s, publishing its latest start", containerName, w.crashLoop.Cooldown)
+		publish()
+	})
+	return true
+}
+
+// pruneCrashLoops forgets restarts outside the window and containers without any. The
+// caller holds w.mu.
+func (w *Watcher) pruneCrashLoops(now time.Time) {
+	for id, loop := range w.crashLoops {
+		i := 0
+		for i < len(loop.restarts) && now.Sub(loop.restarts[i]) > w.crashLoop.Window {
+			i++
+		}
+		loop.restarts = loop.restarts[i:]
+		if len(loop.restarts) == 0 && !loop.looping {
+			delete(w.crashLoops, id)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package docker
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHoldCrashLoop(t *testing.T) {
+	var notified atomic.Int32
+	w := &Watcher{
+		crashLoop: CrashLoopOptions{
+			Restarts: 2,
+			Window:   time.Hour,
+			Cooldown: 50 * time.Millisecond,
+			OnCrashLoop: func(containerName string, restartCount int) {
+				notified.Add(1)
+			},
+		},
+		crashLoops: make(map[string]*crashLoop),
+	}
+
+	published := make(chan int, 10)
+	start := func(restartCount int) bool {
+		return w.holdCrashLoop("abc123", "app", restartCount, func() { published <- restartCount })
+	}
+
+	// The first start and a single restart are published right away
+	if start(0) || start(1) {
+		t.Fatal("holdCrashLoop() = true before the container loops")
+	}
+	// Further restarts within the window are held back, the latest is published
+	for restartCount := 2; restartCount <= 4; restartCount++ {
+		if !start(restartCount) {
+			t.Fatalf("holdCrashLoop() = false for restart %d of a looping container", restartCount)
+		}
+	}
+
+	select {
+	case got := <-published:
+		if got != 4 {
+			t.Errorf("Published restart %d, want the latest, 4", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Container was not published after the cooldown")
+	}
+	select {
+	case got := <-published:
+		t.Errorf("Published restart %d, want a single publication", got)
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	if got := notified.Load(); got != 1 {
+		t.Errorf("OnCrashLoop called %d times, want 1", got)
+	}
+
+	// After the cooldown the loop is over, a manual start resets the count
+	if start(0) {
+		t.Error("holdCrashLoop() = true after the container stayed up")
+	}
+}
+
+func TestHoldCrashLoop_Disabled(t *testing.T) {
+	w := &Watcher{crashLoops: make(map[string]*crashLoop)}
+	for restartCount := range 10 {
+		if w.holdCrashLoop("abc123", "app", restartCount, func() {}) {
+			t.Fatal("holdCrashLoop() = true with crash loop detection disabled")
+		}
+	}
+}
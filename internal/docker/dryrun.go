@@ -0,0 +1,29 @@
+package docker
+
+import (
+	"log"
+	"strconv"
+	"strings"
+)
+
+// dryRunLabel keeps the records of a single container in dry-run mode while the rest
+// are written, e.g. netcup.companion/dry-run=true to validate a new stack
+const dryRunLabel = "netcup.companion/dry-run"
+
+// withDryRun marks all hosts of a container labeled for dry runs. An invalid value is
+// treated as true, so a typo never leads to records being written.
+func withDryRun(hosts []HostInfo, containerName string, labels map[string]string) []HostInfo {
+	raw, ok := labels[dryRunLabel]
+	if !ok || len(hosts) == 0 {
+		return hosts
+	}
+	dryRun, err := strconv.ParseBool(strings.TrimSpace(raw))
+	if err != nil {
+		log.Printf("Invalid %s label %q of container %s, treating it as true", dryRunLabel, raw, containerName)
+		dryRun = true
+	}
+	for i := range hosts {
+		hosts[i].DryRun = dryRun
+	}
+	return hosts
+}
@@ -0,0 +1,30 @@
+package docker
+
+import "testing"
+
+func TestWatcherExtractHosts_DryRun(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		set   bool
+		want  bool
+	}{
+		{name: "missing", want: false},
+		{name: "true", value: "true", set: true, want: true},
+		{name: "false", value: "false", set: true, want: false},
+		{name: "invalid", value: "yes please", set: true, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := map[string]string{"traefik.http.routers.app.rule": "Host(`app.example.com`)"}
+			if tt.set {
+				labels[dryRunLabel] = tt.value
+			}
+			hosts := (&Watcher{}).extractHosts("container123", "/app", labels, nil)
+			if len(hosts) != 1 || hosts[0].DryRun != tt.want {
+				t.Errorf("extractHosts() = %+v, want one host with DryRun %v", hosts, tt.want)
+			}
+		})
+	}
+}
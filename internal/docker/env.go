@@ -0,0 +1,118 @@
+package docker
+
+import (
+	"log"
+	"strings"
+)
+
+// extractHostsFromEnv returns the hosts listed in the given environment variables of a
+// container, e.g. VIRTUAL_HOST=app.example.com,www.example.com. Hosts already found
+// in the container's labels are skipped, so a container can use both sources.
+func extractHostsFromEnv(containerID, containerName string, env []string, labels map[string]string, vars []string, known []HostInfo) []HostInfo {
+	if len(vars) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string, len(env))
+	for _, entry := range env {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			values[key] = value
+		}
+	}
+
+	seen := make(map[string]bool, len(known))
+	for _, info := range known {
+		seen[info.Hostname] = true
+	}
+	excluded := excludedHosts(labels)
+
+	var hosts []HostInfo
+	for _, name := range vars {
+		for _, entry := range strings.Split(values[name], ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+
+			hostname, err := normalizeHostname(entry)
+			if err != nil {
+				log.Printf("Skipping invalid host %s from %s of container %s: %v", entry, name, containerName, err)
+				continue
+			}
+			if seen[hostname] {
+				continue
+			}
+			seen[hostname] = true
+
+			domain, subdomain := splitHostname(hostname)
+			if excluded[hostname] || excluded[domain] {
+				log.Printf("Skipping excluded host %s for container %s", hostname, containerName)
+				continue
+			}
+
+			info := HostInfo{
+				ContainerID:    containerID,
+				ContainerName:  strings.TrimPrefix(containerName, "/"),
+				Hostname:       hostname,
+				Domain:         domain,
+				Subdomain:      subdomain,
+				Router:         name,
+				Destination:    strings.TrimSpace(labels[destinationLabel]),
+				ComposeProject: labels[composeProjectLabel],
+				ComposeService: labels[composeServiceLabel],
+			}
+			hosts = append(hosts, info)
+
+			log.Printf("Found host: %s (domain: %s, subdomain: %s) in %s of container %s%s",
+				hostname, domain, subdomain, name, containerName, info.StackSuffix())
+		}
+	}
+
+	return hosts
+}
+
+// extractHosts returns the hosts of a container from its Traefik labels and, if
+// configured, its environment variables, along with their lifetime, SRV records and
+// dry-run flag. Variables name no router, so their hosts are bound to the entrypoints
+// of the container's routers and go through the same entrypoint and certresolver
+// filters as the labels. Containers without any such host get one from
+// AUTO_HOSTNAME_TEMPLATE if they opted in with the netcup.companion/auto label; these
+// are not served by Traefik, so the filters do not apply.
+func (w *Watcher) extractHosts(containerID, containerName string, labels map[string]string, env []string) []HostInfo {
+	hosts := extractHostsFromLabels(containerID, containerName, labels)
+	envHosts := extractHostsFromEnv(containerID, containerName, env, labels, w.hostEnvVars, hosts)
+	entrypoints := routerEntrypoints(hosts)
+	for i := range envHosts {
+		envHosts[i].Entrypoints = entrypoints
+	}
+
+	hosts = append(hosts, envHosts...)
+	if len(hosts) == 0 {
+		hosts = extractHostFromTemplate(w.hostnameTemplate, containerID, containerName, labels)
+	} else {
+		hosts = w.withCertResolvers(w.withPublicEntrypoints(hosts))
+	}
+	hosts = withExpiry(hosts, containerName, labels)
+	hosts = withSRV(hosts, containerName, labels)
+	hosts = withTags(hosts, labels)
+	return withDryRun(hosts, containerName, labels)
+}
+
+// routerEntrypoints returns the entrypoints the routers of hosts are bound to, or nil
+// if one of them is bound to all entrypoints
+func routerEntrypoints(hosts []HostInfo) []string {
+	var entrypoints []string
+	seen := make(map[string]bool)
+	for _, info := range hosts {
+		if len(info.Entrypoints) == 0 {
+			return nil
+		}
+		for _, entrypoint := range info.Entrypoints {
+			if !seen[entrypoint] {
+				seen[entrypoint] = true
+				entrypoints = append(entrypoints, entrypoint)
+			}
+		}
+	}
+	return entrypoints
+}
@@ -0,0 +1,217 @@
+package docker
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestExtractHostsFromEnv(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    []string
+		labels map[string]string
+		vars   []string
+		want   []string
+	}{
+		{
+			name: "disabled without variables",
+			env:  []string{"VIRTUAL_HOST=app.example.com"},
+			want: nil,
+		},
+		{
+			name: "comma-separated hosts",
+			env:  []string{"PATH=/usr/bin", "VIRTUAL_HOST=App.example.com, www.example.com,"},
+			vars: []string{"VIRTUAL_HOST"},
+			want: []string{"app.example.com", "www.example.com"},
+		},
+		{
+			name: "several variables without duplicates",
+			env:  []string{"VIRTUAL_HOST=app.example.com", "LETSENCRYPT_HOST=app.example.com,api.example.com"},
+			vars: []string{"VIRTUAL_HOST", "LETSENCRYPT_HOST"},
+			want: []string{"app.example.com", "api.example.com"},
+		},
+		{
+			name: "unset variable",
+			env:  []string{"OTHER=app.example.com"},
+			vars: []string{"VIRTUAL_HOST"},
+			want: nil,
+		},
+		{
+			name: "invalid hosts skipped",
+			env:  []string{"VIRTUAL_HOST=-app.example.com,app.example.com"},
+			vars: []string{"VIRTUAL_HOST"},
+			want: []string{"app.example.com"},
+		},
+		{
+			name:   "excluded hosts skipped",
+			env:    []string{"VIRTUAL_HOST=app.example.com,www.example.org"},
+			labels: map[string]string{"netcup.exclude": "example.org"},
+			vars:   []string{"VIRTUAL_HOST"},
+			want:   []string{"app.example.com"},
+		},
+		{
+			name: "hosts from labels skipped",
+			env:  []string{"VIRTUAL_HOST=app.example.com,www.example.com"},
+			labels: map[string]string{
+				"traefik.http.routers.app.rule": "Host(`app.example.com`)",
+			},
+			vars: []string{"VIRTUAL_HOST"},
+			want: []string{"www.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			known := extractHostsFromLabels("container123", "/app", tt.labels)
+			hosts := extractHostsFromEnv("container123", "/app", tt.env, tt.labels, tt.vars, known)
+
+			var got []string
+			for _, host := range hosts {
+				got = append(got, host.Hostname)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("hosts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractHostsFromEnv_HostInfo(t *testing.T) {
+	labels := map[string]string{
+		"com.docker.compose.project": "shop",
+		"com.docker.compose.service": "web",
+		"netcup.destination":         "203.0.113.7",
+	}
+	hosts := extractHostsFromEnv("container123", "/shop-web-1", []string{"VIRTUAL_HOST=shop.example.com"}, labels, []string{"VIRTUAL_HOST"}, nil)
+	if len(hosts) != 1 {
+		t.Fatalf("extractHostsFromEnv() returned %d hosts, want 1", len(hosts))
+	}
+
+	want := HostInfo{
+		ContainerID:    "container123",
+		ContainerName:  "shop-web-1",
+		Hostname:       "shop.example.com",
+		Domain:         "example.com",
+		Subdomain:      "shop",
+		Router:         "VIRTUAL_HOST",
+		Destination:    "203.0.113.7",
+		ComposeProject: "shop",
+		ComposeService: "web",
+	}
+	if got := hosts[0]; got.ContainerID != want.ContainerID || got.ContainerName != want.ContainerName ||
+		got.Hostname != want.Hostname || got.Domain != want.Domain || got.Subdomain != want.Subdomain ||
+		got.Router != want.Router || got.Destination != want.Destination ||
+		got.ComposeProject != want.ComposeProject || got.ComposeService != want.ComposeService {
+		t.Errorf("host = %+v, want %+v", got, want)
+	}
+}
+
+func TestWatcherExtractHosts(t *testing.T) {
+	autoTemplate, err := ParseHostnameTemplate("{{.ComposeService}}.{{.Project}}.example.com")
+	if err != nil {
+		t.Fatalf("ParseHostnameTemplate() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		watcher *Watcher
+		labels  map[string]string
+		env     []string
+		want    []string
+	}{
+		{
+			name:    "variables without routers",
+			watcher: &Watcher{publicEntrypoints: map[string]bool{"websecure": true}, hostEnvVars: []string{"VIRTUAL_HOST"}},
+			env:     []string{"VIRTUAL_HOST=app.example.com"},
+			want:    []string{"app.example.com"},
+		},
+		{
+			name:    "variables inherit private entrypoints",
+			watcher: &Watcher{publicEntrypoints: map[string]bool{"websecure": true}, hostEnvVars: []string{"VIRTUAL_HOST"}},
+			labels: map[string]string{
+				"traefik.http.routers.internal.rule":        "Host(`app.internal.example.com`)",
+				"traefik.http.routers.internal.entrypoints": "lan",
+			},
+			env: []string{"VIRTUAL_HOST=app.example.com"},
+		},
+		{
+			name:    "variables inherit public entrypoints",
+			watcher: &Watcher{publicEntrypoints: map[string]bool{"websecure": true}, hostEnvVars: []string{"VIRTUAL_HOST"}},
+			labels: map[string]string{
+				"traefik.http.routers.internal.rule":        "Host(`app.internal.example.com`)",
+				"traefik.http.routers.internal.entrypoints": "lan",
+				"traefik.http.routers.public.rule":          "Host(`www.example.com`)",
+				"traefik.http.routers.public.entrypoints":   "websecure",
+			},
+			env:  []string{"VIRTUAL_HOST=app.example.com"},
+			want: []string{"app.example.com", "www.example.com"},
+		},
+		{
+			name:    "label host filtered by entrypoint is not restored by a variable",
+			watcher: &Watcher{publicEntrypoints: map[string]bool{"websecure": true}, hostEnvVars: []string{"VIRTUAL_HOST"}},
+			labels: map[string]string{
+				"traefik.http.routers.internal.rule":        "Host(`app.example.com`)",
+				"traefik.http.routers.internal.entrypoints": "lan",
+				"traefik.http.routers.public.rule":          "Host(`www.example.com`)",
+				"traefik.http.routers.public.entrypoints":   "websecure",
+			},
+			env:  []string{"VIRTUAL_HOST=app.example.com"},
+			want: []string{"www.example.com"},
+		},
+		{
+			name:    "label host filtered by certresolver is not restored by a variable",
+			watcher: &Watcher{certResolvers: map[string]bool{"letsencrypt": true}, hostEnvVars: []string{"VIRTUAL_HOST"}},
+			labels: map[string]string{
+				"traefik.http.routers.app.rule": "Host(`app.example.com`)",
+			},
+			env: []string{"VIRTUAL_HOST=app.example.com"},
+		},
+		{
+			name:    "certresolver filter drops variable hosts",
+			watcher: &Watcher{certResolvers: map[string]bool{"letsencrypt": true}, hostEnvVars: []string{"VIRTUAL_HOST"}},
+			labels: map[string]string{
+				"traefik.http.routers.app.rule":             "Host(`app.example.com`)",
+				"traefik.http.routers.app.tls.certresolver": "letsencrypt",
+			},
+			env:  []string{"VIRTUAL_HOST=other.example.com"},
+			want: []string{"app.example.com"},
+		},
+		{
+			name:    "template for auto containers bypasses router filters",
+			watcher: &Watcher{certResolvers: map[string]bool{"letsencrypt": true}, hostnameTemplate: autoTemplate},
+			labels: map[string]string{
+				autoLabel:           "true",
+				composeProjectLabel: "shop",
+				composeServiceLabel: "db",
+			},
+			want: []string{"db.shop.example.com"},
+		},
+		{
+			name:    "template ignored for containers with routers",
+			watcher: &Watcher{publicEntrypoints: map[string]bool{"websecure": true}, hostnameTemplate: autoTemplate},
+			labels: map[string]string{
+				autoLabel:                                   "true",
+				composeProjectLabel:                         "shop",
+				composeServiceLabel:                         "web",
+				"traefik.http.routers.internal.rule":        "Host(`app.internal.example.com`)",
+				"traefik.http.routers.internal.entrypoints": "lan",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts := tt.watcher.extractHosts("container123", "/app", tt.labels, tt.env)
+
+			var got []string
+			for _, host := range hosts {
+				got = append(got, host.Hostname)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("extractHosts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
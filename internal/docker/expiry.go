@@ -0,0 +1,40 @@
+package docker
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+)
+
+// expiresInLabel limits the lifetime of the container's records, e.g.
+// netcup.expires-in=24h or netcup.expires-in=7d for preview deployments
+const expiresInLabel = "netcup.expires-in"
+
+// expiresIn returns the lifetime set by the expires-in label, or zero if the label is
+// missing or invalid
+func expiresIn(containerName string, labels map[string]string) time.Duration {
+	raw := strings.TrimSpace(labels[expiresInLabel])
+	if raw == "" {
+		return 0
+	}
+	lifetime, err := config.ParseDuration(raw)
+	if err != nil || lifetime <= 0 {
+		log.Printf("Ignoring invalid %s label %q of container %s, expected a duration like 24h or 7d", expiresInLabel, raw, containerName)
+		return 0
+	}
+	return lifetime
+}
+
+// withExpiry sets the lifetime from the expires-in label on all hosts of a container
+func withExpiry(hosts []HostInfo, containerName string, labels map[string]string) []HostInfo {
+	if len(hosts) == 0 {
+		return hosts
+	}
+	lifetime := expiresIn(containerName, labels)
+	for i := range hosts {
+		hosts[i].ExpiresIn = lifetime
+	}
+	return hosts
+}
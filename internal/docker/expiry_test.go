@@ -0,0 +1,51 @@
+package docker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpiresIn(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "missing", value: "", want: 0},
+		{name: "hours", value: "24h", want: 24 * time.Hour},
+		{name: "whitespace", value: " 90m ", want: 90 * time.Minute},
+		{name: "days", value: "7d", want: 7 * 24 * time.Hour},
+		{name: "invalid days", value: "weekd", want: 0},
+		{name: "invalid", value: "tomorrow", want: 0},
+		{name: "negative", value: "-1h", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := map[string]string{}
+			if tt.value != "" {
+				labels[expiresInLabel] = tt.value
+			}
+			if got := expiresIn("preview", labels); got != tt.want {
+				t.Errorf("expiresIn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatcherExtractHosts_Expiry(t *testing.T) {
+	labels := map[string]string{
+		"traefik.http.routers.app.rule": "Host(`pr-1.example.com`)",
+		"netcup.expires-in":             "24h",
+	}
+	w := &Watcher{hostEnvVars: []string{"VIRTUAL_HOST"}}
+	hosts := w.extractHosts("container123", "/preview", labels, []string{"VIRTUAL_HOST=pr-1-api.example.com"})
+	if len(hosts) != 2 {
+		t.Fatalf("extractHosts() returned %d hosts, want 2", len(hosts))
+	}
+	for _, host := range hosts {
+		if host.ExpiresIn != 24*time.Hour {
+			t.Errorf("ExpiresIn of %s = %v, want 24h", host.Hostname, host.ExpiresIn)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package docker
+
+import (
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// hostnameProfile lowercases hostnames and converts internationalized labels to
+// punycode. STD3 rules are off so wildcard and underscore labels pass through.
+var hostnameProfile = idna.New(idna.MapForLookup(), idna.StrictDomainName(false))
+
+// normalizeHostname returns hostname in the form Netcup stores it,
+// e.g. "Shop.Bücher.de" -> "shop.xn--bcher-kva.de"
+func normalizeHostname(hostname string) (string, error) {
+	return hostnameProfile.ToASCII(strings.TrimSuffix(strings.TrimSpace(hostname), "."))
+}
@@ -0,0 +1,32 @@
+package docker
+
+import "testing"
+
+func TestNormalizeHostname(t *testing.T) {
+	tests := []struct {
+		name     string
+		hostname string
+		want     string
+		wantErr  bool
+	}{
+		{name: "already normalized", hostname: "app.example.com", want: "app.example.com"},
+		{name: "uppercase", hostname: "App.Example.COM", want: "app.example.com"},
+		{name: "internationalized", hostname: "shop.bücher.de", want: "shop.xn--bcher-kva.de"},
+		{name: "punycode kept", hostname: "shop.xn--bcher-kva.de", want: "shop.xn--bcher-kva.de"},
+		{name: "trailing dot", hostname: "app.example.com.", want: "app.example.com"},
+		{name: "wildcard", hostname: "*.Example.com", want: "*.example.com"},
+		{name: "leading hyphen", hostname: "-app.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeHostname(tt.hostname)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalizeHostname(%q) error = %v, wantErr %v", tt.hostname, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("normalizeHostname(%q) = %q, want %q", tt.hostname, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package docker
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/network"
+)
+
+// networkLabel selects the network whose address is published when publishing container IPs
+const networkLabel = "netcup.network"
+
+// containerIP returns the IPv4 address of a container on the network named by the
+// netcup.network label, falling back to defaultNetwork. Without either, the container
+// must be attached to exactly one network.
+func containerIP(networks map[string]*network.EndpointSettings, labels map[string]string, defaultNetwork string) (string, error) {
+	name := strings.TrimSpace(labels[networkLabel])
+	if name == "" {
+		name = defaultNetwork
+	}
+
+	if name == "" {
+		if len(networks) != 1 {
+			names := make([]string, 0, len(networks))
+			for n := range networks {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return "", fmt.Errorf("container is attached to %d networks (%s), set the %s label or CONTAINER_NETWORK", len(networks), strings.Join(names, ", "), networkLabel)
+		}
+		for n := range networks {
+			name = n
+		}
+	}
+
+	endpoint, ok := networks[name]
+	if !ok || endpoint == nil {
+		return "", fmt.Errorf("container is not attached to network %s", name)
+	}
+	if endpoint.IPAddress == "" {
+		return "", fmt.Errorf("container has no IPv4 address on network %s", name)
+	}
+	return endpoint.IPAddress, nil
+}
+
+// withContainerIP sets the container's address on all hosts when publishing container
+// IPs. It returns nil if the address cannot be determined, so the host IP is never
+// published by mistake. Containers with a destination label keep their destination.
+func (w *Watcher) withContainerIP(hosts []HostInfo, containerName string, networks map[string]*network.EndpointSettings, labels map[string]string) []HostInfo {
+	if !w.publishContainerIP || len(hosts) == 0 || strings.TrimSpace(labels[destinationLabel]) != "" {
+		return hosts
+	}
+
+	ip, err := containerIP(networks, labels, w.containerNetwork)
+	if err != nil {
+		log.Printf("Skipping container %s: %v", strings.TrimPrefix(containerName, "/"), err)
+		return nil
+	}
+
+	for i := range hosts {
+		hosts[i].IP = ip
+	}
+	return hosts
+}
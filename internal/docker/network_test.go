@@ -0,0 +1,112 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/network"
+)
+
+func TestContainerIP(t *testing.T) {
+	networks := map[string]*network.EndpointSettings{
+		"macvlan": {IPAddress: "192.168.1.50"},
+		"traefik": {IPAddress: "172.18.0.5"},
+	}
+
+	tests := []struct {
+		name           string
+		networks       map[string]*network.EndpointSettings
+		labels         map[string]string
+		defaultNetwork string
+		want           string
+		wantErr        bool
+	}{
+		{
+			name:     "single network",
+			networks: map[string]*network.EndpointSettings{"macvlan": {IPAddress: "192.168.1.50"}},
+			want:     "192.168.1.50",
+		},
+		{
+			name:           "default network",
+			networks:       networks,
+			defaultNetwork: "traefik",
+			want:           "172.18.0.5",
+		},
+		{
+			name:           "label overrides default network",
+			networks:       networks,
+			labels:         map[string]string{"netcup.network": "macvlan"},
+			defaultNetwork: "traefik",
+			want:           "192.168.1.50",
+		},
+		{
+			name:     "several networks without a choice",
+			networks: networks,
+			wantErr:  true,
+		},
+		{
+			name:           "not attached to network",
+			networks:       networks,
+			defaultNetwork: "other",
+			wantErr:        true,
+		},
+		{
+			name:     "no IPv4 address",
+			networks: map[string]*network.EndpointSettings{"host": {}},
+			wantErr:  true,
+		},
+		{
+			name:    "no networks",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := containerIP(tt.networks, tt.labels, tt.defaultNetwork)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("containerIP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("containerIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithContainerIP(t *testing.T) {
+	networks := map[string]*network.EndpointSettings{"macvlan": {IPAddress: "192.168.1.50"}}
+	newHosts := func() []HostInfo {
+		return []HostInfo{{Hostname: "app.example.com"}, {Hostname: "api.example.com"}}
+	}
+
+	// Host IP mode leaves the hosts untouched
+	w := &Watcher{}
+	for _, info := range w.withContainerIP(newHosts(), "/app", networks, nil) {
+		if info.IP != "" {
+			t.Errorf("IP = %q in host IP mode, want empty", info.IP)
+		}
+	}
+
+	w = &Watcher{publishContainerIP: true}
+	hosts := w.withContainerIP(newHosts(), "/app", networks, nil)
+	if len(hosts) != 2 {
+		t.Fatalf("withContainerIP() returned %d hosts, want 2", len(hosts))
+	}
+	for _, info := range hosts {
+		if info.IP != "192.168.1.50" {
+			t.Errorf("IP = %q, want 192.168.1.50", info.IP)
+		}
+	}
+
+	// Containers without a resolvable address are skipped
+	w = &Watcher{publishContainerIP: true, containerNetwork: "other"}
+	if hosts := w.withContainerIP(newHosts(), "/app", networks, nil); hosts != nil {
+		t.Errorf("withContainerIP() = %v, want nil", hosts)
+	}
+
+	// A custom destination takes precedence over the container address
+	hosts = w.withContainerIP(newHosts(), "/app", networks, map[string]string{"netcup.destination": "203.0.113.7"})
+	if len(hosts) != 2 || hosts[0].IP != "" {
+		t.Errorf("withContainerIP() with destination = %v, want hosts without container IP", hosts)
+	}
+}
@@ -0,0 +1,212 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+)
+
+const (
+	// probeInterval is the pause between probes of a service that is not reachable yet
+	probeInterval = 2 * time.Second
+	// probeAttemptTimeout bounds a single connection attempt or request
+	probeAttemptTimeout = 5 * time.Second
+)
+
+// ProbeOptions configures the probe of the service of a started container, which has to
+// answer before the container's records are published
+type ProbeOptions struct {
+	Mode     string        // "tcp" or "http"; empty publishes without probing
+	Timeout  time.Duration // How long to wait for the service to become reachable
+	HTTPPath string        // Path requested by the http probe
+}
+
+// probeClient requests the http probe without following redirects, e.g. to HTTPS
+var probeClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// check probes the service at address once. The http probe accepts any status below 500.
+func (p ProbeOptions) check(ctx context.Context, address string) error {
+	ctx, cancel := context.WithTimeout(ctx, probeAttemptTimeout)
+	defer cancel()
+
+	if p.Mode == "http" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+address+p.HTTPPath, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := probeClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("GET %s returned %s", p.HTTPPath, resp.Status)
+		}
+		return nil
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// wait probes the service at address every probeInterval until it answers or the
+// timeout ends
+func (p ProbeOptions) wait(ctx context.Context, address string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+
+	for {
+		err := p.check(ctx, address)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("not reachable after %s: %w", p.Timeout, err)
+		case <-time.After(probeInterval):
+		}
+	}
+}
+
+// servicePort returns the port Traefik forwards the router's requests to: the
+// loadbalancer port of the router's service, of the container's only service, or the
+// only port the container exposes, as Traefik falls back to it without a label
+func servicePort(labels map[string]string, router string, exposed []string) (string, error) {
+	ports := make(map[string]string)
+	for key, value := range labels {
+		name, ok := strings.CutPrefix(key, "traefik.http.services.")
+		if !ok {
+			continue
+		}
+		if name, ok = strings.CutSuffix(name, ".loadbalancer.server.port"); ok {
+			ports[name] = strings.TrimSpace(value)
+		}
+	}
+
+	if service := labels["traefik.http.routers."+router+".service"]; service != "" && ports[service] != "" {
+		return ports[service], nil
+	}
+	distinct := slices.Compact(slices.Sorted(maps.Values(ports)))
+	switch {
+	case len(distinct) == 1:
+		return distinct[0], nil
+	case len(distinct) == 0 && len(exposed) == 1:
+		return exposed[0], nil
+	default:
+		return "", fmt.Errorf("cannot tell the service port, set traefik.http.services.<name>.loadbalancer.server.port")
+	}
+}
+
+// exposedTCPPorts returns the TCP ports a container exposes, e.g. "80"
+func exposedTCPPorts(ports nat.PortSet) []string {
+	var tcp []string
+	for port := range ports {
+		if port.Proto() == "tcp" {
+			tcp = append(tcp, port.Port())
+		}
+	}
+	slices.Sort(tcp)
+	return tcp
+}
+
+// publishWhenReachable sends the hosts of a started container once the probe reaches
+// their service on the container's address, and drops them if it does not answer within
+// the timeout. The probes run in the background, so the event stream is not blocked; a
+// restart of the container supersedes them. Hosts whose service cannot be located are
+// published right away.
+func (w *Watcher) publishWhenReachable(ctx context.Context, containerID, containerName string, hosts []HostInfo, networks map[string]*network.EndpointSettings, labels map[string]string, exposed []string, hostChan chan<- HostInfo) {
+	containerName = strings.TrimPrefix(containerName, "/")
+	ip, err := containerIP(networks, labels, w.containerNetwork)
+	if err != nil {
+		log.Printf("Cannot probe container %s, publishing without probe: %v", containerName, err)
+		for _, info := range hosts {
+			hostChan <- info
+		}
+		return
+	}
+
+	// Hosts routed to the same port share a probe
+	var addresses []string
+	targets := make(map[string][]HostInfo)
+	for _, info := range hosts {
+		port, err := servicePort(labels, info.Router, exposed)
+		if err != nil {
+			log.Printf("Cannot probe %s of container %s, publishing without probe: %v", info.Hostname, containerName, err)
+			hostChan <- info
+			continue
+		}
+		address := net.JoinHostPort(ip, port)
+		if targets[address] == nil {
+			addresses = append(addresses, address)
+		}
+		targets[address] = append(targets[address], info)
+	}
+	if len(addresses) == 0 {
+		return
+	}
+
+	probeCtx, cancel := context.WithCancel(ctx)
+	w.mu.Lock()
+	if previous, ok := w.pendingProbes[containerID]; ok {
+		previous()
+	}
+	w.pendingProbes[containerID] = cancel
+	w.mu.Unlock()
+
+	go func() {
+		defer func() {
+			w.mu.Lock()
+			// A restart cancels the probe when replacing it, so only a probe that was not
+			// cancelled is still registered
+			if probeCtx.Err() == nil {
+				delete(w.pendingProbes, containerID)
+			}
+			w.mu.Unlock()
+			cancel()
+		}()
+
+		for _, address := range addresses {
+			if err := w.probe.wait(probeCtx, address); err != nil {
+				if probeCtx.Err() != nil {
+					return
+				}
+				log.Printf("Warning: Service of container %s at %s is %v, not publishing %s", containerName, address, err, joinHostnames(targets[address]))
+				continue
+			}
+			log.Printf("Service of container %s at %s is reachable, publishing %s", containerName, address, joinHostnames(targets[address]))
+			for _, info := range targets[address] {
+				select {
+				case <-probeCtx.Done():
+					return
+				case hostChan <- info:
+				}
+			}
+		}
+	}()
+}
+
+// joinHostnames lists the hostnames of hosts for logging
+func joinHostnames(hosts []HostInfo) string {
+	names := make([]string, 0, len(hosts))
+	for _, info := range hosts {
+		names = append(names, info.Hostname)
+	}
+	return strings.Join(names, ", ")
+}
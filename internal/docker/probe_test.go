@@ -0,0 +1,162 @@
+package docker
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+)
+
+func TestServicePort(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		router  string
+		exposed []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "single service",
+			labels: map[string]string{"traefik.http.services.app.loadbalancer.server.port": "8080"},
+			router: "app",
+			want:   "8080",
+		},
+		{
+			name: "service of the router",
+			labels: map[string]string{
+				"traefik.http.routers.api.service":                   "api",
+				"traefik.http.services.web.loadbalancer.server.port": "80",
+				"traefik.http.services.api.loadbalancer.server.port": "3000",
+			},
+			router: "api",
+			want:   "3000",
+		},
+		{
+			name: "several services without router service",
+			labels: map[string]string{
+				"traefik.http.services.web.loadbalancer.server.port": "80",
+				"traefik.http.services.api.loadbalancer.server.port": "3000",
+			},
+			router:  "web",
+			wantErr: true,
+		},
+		{name: "single exposed port", labels: map[string]string{}, exposed: []string{"80"}, want: "80"},
+		{name: "several exposed ports", labels: map[string]string{}, exposed: []string{"80", "443"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := servicePort(tt.labels, tt.router, tt.exposed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("servicePort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("servicePort() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExposedTCPPorts(t *testing.T) {
+	ports := nat.PortSet{"443/tcp": {}, "53/udp": {}, "80/tcp": {}}
+	got := exposedTCPPorts(ports)
+	if len(got) != 2 || got[0] != "443" || got[1] != "80" {
+		t.Errorf("exposedTCPPorts() = %v, want [443 80]", got)
+	}
+}
+
+func TestProbeCheck(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			http.Redirect(w, r, "https://app.example.com/", http.StatusMovedPermanently)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(healthy.Close)
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(failing.Close)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := listener.Addr().String()
+	listener.Close()
+
+	tests := []struct {
+		name    string
+		probe   ProbeOptions
+		address string
+		wantErr bool
+	}{
+		{name: "tcp open", probe: ProbeOptions{Mode: "tcp"}, address: healthy.Listener.Addr().String()},
+		{name: "tcp closed", probe: ProbeOptions{Mode: "tcp"}, address: closed, wantErr: true},
+		{name: "http ok", probe: ProbeOptions{Mode: "http", HTTPPath: "/healthz"}, address: healthy.Listener.Addr().String()},
+		{name: "http redirect", probe: ProbeOptions{Mode: "http", HTTPPath: "/"}, address: healthy.Listener.Addr().String()},
+		{name: "http server error", probe: ProbeOptions{Mode: "http", HTTPPath: "/"}, address: failing.Listener.Addr().String(), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.probe.check(context.Background(), tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPublishWhenReachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, closedPort, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	networks := map[string]*network.EndpointSettings{"web": {IPAddress: "127.0.0.1"}}
+	labels := map[string]string{
+		"traefik.http.routers.app.service":                      "app",
+		"traefik.http.services.app.loadbalancer.server.port":    port,
+		"traefik.http.routers.broken.service":                   "broken",
+		"traefik.http.services.broken.loadbalancer.server.port": closedPort,
+	}
+	hosts := []HostInfo{
+		{Hostname: "app.example.com", Router: "app"},
+		{Hostname: "broken.example.com", Router: "broken"},
+	}
+
+	w := &Watcher{
+		probe:         ProbeOptions{Mode: "http", Timeout: 300 * time.Millisecond, HTTPPath: "/"},
+		pendingProbes: make(map[string]context.CancelFunc),
+	}
+	hostChan := make(chan HostInfo, len(hosts))
+	w.publishWhenReachable(context.Background(), "container123", "/app", hosts, networks, labels, nil, hostChan)
+
+	select {
+	case info := <-hostChan:
+		if info.Hostname != "app.example.com" {
+			t.Errorf("Published %s, want app.example.com", info.Hostname)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Reachable host was not published")
+	}
+	select {
+	case info := <-hostChan:
+		t.Errorf("Published %s, whose service is not reachable", info.Hostname)
+	case <-time.After(3 * time.Second):
+	}
+}
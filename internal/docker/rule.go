@@ -0,0 +1,102 @@
+package docker
+
+import (
+	"strings"
+)
+
+// hostMatcher is the Traefik matcher whose arguments are published
+const hostMatcher = "Host"
+
+// parseRule extracts the hostnames of the Host matchers in a Traefik router rule, e.g.
+// "Host(`app.example.com:8443`) && PathPrefix(`/api`)". Ports are stripped from the
+// hostnames. ignored describes what was left out: other matchers such as PathPrefix or
+// HostRegexp, negated Host matchers and stripped ports.
+func parseRule(rule string) (hosts, ignored []string) {
+	for i := 0; i < len(rule); {
+		if !isIdentifierStart(rule[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(rule) && isIdentifier(rule[i]) {
+			i++
+		}
+		name := rule[start:i]
+
+		open := i
+		for open < len(rule) && rule[open] == ' ' {
+			open++
+		}
+		if open == len(rule) || rule[open] != '(' {
+			continue
+		}
+		args, end, ok := parseArgs(rule, open+1)
+		if !ok {
+			ignored = append(ignored, strings.TrimSpace(rule[start:]))
+			break
+		}
+		matcher := rule[start:end]
+		i = end
+
+		if strings.HasSuffix(strings.TrimRight(rule[:start], " "), "!") {
+			ignored = append(ignored, "!"+matcher)
+			continue
+		}
+		if name != hostMatcher {
+			ignored = append(ignored, matcher)
+			continue
+		}
+		for _, arg := range args {
+			host, port := splitPort(strings.TrimSpace(arg))
+			if port != "" {
+				ignored = append(ignored, "port "+port+" of "+host)
+			}
+			if host != "" {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+	return hosts, ignored
+}
+
+// parseArgs reads the quoted, comma-separated arguments of a matcher starting at pos,
+// right after its opening parenthesis. It returns the position after the closing
+// parenthesis, or false if the arguments are malformed.
+func parseArgs(rule string, pos int) (args []string, end int, ok bool) {
+	for pos < len(rule) {
+		switch c := rule[pos]; c {
+		case ' ', ',':
+			pos++
+		case ')':
+			return args, pos + 1, true
+		case '`', '"':
+			closing := strings.IndexByte(rule[pos+1:], c)
+			if closing < 0 {
+				return nil, 0, false
+			}
+			args = append(args, rule[pos+1:pos+1+closing])
+			pos += closing + 2
+		default:
+			return nil, 0, false
+		}
+	}
+	return nil, 0, false
+}
+
+// splitPort strips a port from a hostname, e.g. "app.example.com:8443"
+func splitPort(host string) (hostname, port string) {
+	i := strings.LastIndexByte(host, ':')
+	if i < 0 || i == len(host)-1 || strings.Trim(host[i+1:], "0123456789") != "" {
+		return host, ""
+	}
+	return host[:i], host[i+1:]
+}
+
+func isIdentifierStart(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+}
+
+func isIdentifier(c byte) bool {
+	return isIdentifierStart(c) || c >= '0' && c <= '9'
+}
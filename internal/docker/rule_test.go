@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		name        string
+		rule        string
+		wantHosts   []string
+		wantIgnored []string
+	}{
+		{name: "single host", rule: "Host(`app.example.com`)", wantHosts: []string{"app.example.com"}},
+		{name: "port", rule: "Host(`app.example.com:8443`)", wantHosts: []string{"app.example.com"}, wantIgnored: []string{"port 8443 of app.example.com"}},
+		{name: "several arguments", rule: "Host(`a.example.com`, \"b.example.com\")", wantHosts: []string{"a.example.com", "b.example.com"}},
+		{
+			name:        "combined matchers",
+			rule:        "(Host(`app.example.com`) || Host(`www.example.com`)) && PathPrefix(`/api`) && !Host(`admin.example.com`)",
+			wantHosts:   []string{"app.example.com", "www.example.com"},
+			wantIgnored: []string{"PathPrefix(`/api`)", "!Host(`admin.example.com`)"},
+		},
+		{
+			name:        "parentheses in arguments",
+			rule:        "PathRegexp(`^/(v1|v2)/`) && Host(`api.example.com`)",
+			wantHosts:   []string{"api.example.com"},
+			wantIgnored: []string{"PathRegexp(`^/(v1|v2)/`)"},
+		},
+		{name: "host regexp", rule: "HostRegexp(`^.+\\.example\\.com$`)", wantIgnored: []string{"HostRegexp(`^.+\\.example\\.com$`)"}},
+		{name: "unterminated", rule: "Host(`app.example.com", wantIgnored: []string{"Host(`app.example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts, ignored := parseRule(tt.rule)
+			if !reflect.DeepEqual(hosts, tt.wantHosts) {
+				t.Errorf("parseRule() hosts = %q, want %q", hosts, tt.wantHosts)
+			}
+			if !reflect.DeepEqual(ignored, tt.wantIgnored) {
+				t.Errorf("parseRule() ignored = %q, want %q", ignored, tt.wantIgnored)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package docker
+
+import (
+	"slices"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+)
+
+// scanFilters returns the container list filters selecting running containers in
+// scope. Docker ANDs repeated label filters, so each Compose project gets a query of
+// its own, while networks are ORed by Docker and share one query.
+func (w *Watcher) scanFilters() []filters.Args {
+	base := func() filters.Args {
+		args := filters.NewArgs(filters.Arg("status", "running"))
+		for _, name := range w.networks {
+			args.Add("network", name)
+		}
+		return args
+	}
+
+	if len(w.projects) == 0 {
+		return []filters.Args{base()}
+	}
+
+	queries := make([]filters.Args, 0, len(w.projects))
+	for _, project := range w.projects {
+		args := base()
+		args.Add("label", composeProjectLabel+"="+project)
+		queries = append(queries, args)
+	}
+	return queries
+}
+
+// eventFilters returns the filters for container events. A single Compose project is
+// filtered by Docker; several projects and networks are checked by inScope instead, as
+// events cannot be filtered by either.
+func (w *Watcher) eventFilters() filters.Args {
+	args := filters.NewArgs(
+		filters.Arg("type", "container"),
+		filters.Arg("event", "start"),
+	)
+	if w.healthCheckGating {
+		args.Add("event", string(events.ActionHealthStatus))
+	}
+	if len(w.projects) == 1 {
+		args.Add("label", composeProjectLabel+"="+w.projects[0])
+	}
+	return args
+}
+
+// inScope reports whether a container belongs to one of the watched Compose projects
+// and is attached to one of the watched networks
+func (w *Watcher) inScope(labels map[string]string, networks map[string]*network.EndpointSettings) bool {
+	if len(w.projects) > 0 && !slices.Contains(w.projects, labels[composeProjectLabel]) {
+		return false
+	}
+	if len(w.networks) == 0 {
+		return true
+	}
+	for name := range networks {
+		if slices.Contains(w.networks, name) {
+			return true
+		}
+	}
+	return false
+}
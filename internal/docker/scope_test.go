@@ -0,0 +1,113 @@
+package docker
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+)
+
+func TestScanFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		projects []string
+		networks []string
+		want     []map[string][]string
+	}{
+		{
+			name: "unrestricted",
+			want: []map[string][]string{{"status": {"running"}}},
+		},
+		{
+			name:     "networks share one query",
+			networks: []string{"proxy", "public"},
+			want:     []map[string][]string{{"status": {"running"}, "network": {"proxy", "public"}}},
+		},
+		{
+			name:     "one query per project",
+			projects: []string{"shop", "blog"},
+			networks: []string{"proxy"},
+			want: []map[string][]string{
+				{"status": {"running"}, "network": {"proxy"}, "label": {"com.docker.compose.project=shop"}},
+				{"status": {"running"}, "network": {"proxy"}, "label": {"com.docker.compose.project=blog"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Watcher{projects: tt.projects, networks: tt.networks}
+			got := w.scanFilters()
+			if len(got) != len(tt.want) {
+				t.Fatalf("scanFilters() returned %d queries, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				assertFilters(t, got[i], want)
+			}
+		})
+	}
+}
+
+func TestEventFilters(t *testing.T) {
+	w := &Watcher{projects: []string{"shop"}, networks: []string{"proxy"}, healthCheckGating: true}
+	assertFilters(t, w.eventFilters(), map[string][]string{
+		"type":  {"container"},
+		"event": {"start", "health_status"},
+		"label": {"com.docker.compose.project=shop"},
+	})
+
+	// Docker would AND several project labels, so they are checked by inScope instead
+	w = &Watcher{projects: []string{"shop", "blog"}}
+	assertFilters(t, w.eventFilters(), map[string][]string{
+		"type":  {"container"},
+		"event": {"start"},
+	})
+}
+
+func assertFilters(t *testing.T, args filters.Args, want map[string][]string) {
+	t.Helper()
+
+	if args.Len() != len(want) {
+		t.Errorf("filters have %d keys, want %v", args.Len(), want)
+	}
+	for key, values := range want {
+		got := args.Get(key)
+		slices.Sort(got)
+		values = slices.Sorted(slices.Values(values))
+		if !slices.Equal(got, values) {
+			t.Errorf("filter %s = %v, want %v", key, got, values)
+		}
+	}
+}
+
+func TestInScope(t *testing.T) {
+	shop := map[string]string{composeProjectLabel: "shop"}
+	proxy := map[string]*network.EndpointSettings{"proxy": {}, "shop_default": {}}
+
+	tests := []struct {
+		name     string
+		projects []string
+		networks []string
+		labels   map[string]string
+		attached map[string]*network.EndpointSettings
+		want     bool
+	}{
+		{name: "unrestricted", labels: map[string]string{}, want: true},
+		{name: "matching project", projects: []string{"blog", "shop"}, labels: shop, want: true},
+		{name: "other project", projects: []string{"blog"}, labels: shop, want: false},
+		{name: "not a Compose container", projects: []string{"shop"}, labels: map[string]string{}, want: false},
+		{name: "attached network", networks: []string{"proxy"}, labels: shop, attached: proxy, want: true},
+		{name: "other network", networks: []string{"public"}, labels: shop, attached: proxy, want: false},
+		{name: "project and network", projects: []string{"shop"}, networks: []string{"proxy"}, labels: shop, attached: proxy, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Watcher{projects: tt.projects, networks: tt.networks}
+			if got := w.inScope(tt.labels, tt.attached); got != tt.want {
+				t.Errorf("inScope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// desktopHost returns the address of a local daemon to use when neither DOCKER_HOSTS nor
+// DOCKER_HOST is set and the default socket is missing, e.g. the socket of Docker
+// Desktop on a developer machine. It is empty if no other daemon was found.
+func desktopHost() string {
+	if os.Getenv("DOCKER_HOST") != "" {
+		return ""
+	}
+	candidates := localHostCandidates()
+	if len(candidates) == 0 || hostExists(candidates[0]) {
+		return ""
+	}
+	for _, candidate := range candidates[1:] {
+		if hostExists(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// socketPath returns the path of a unix:// or npipe:// host
+func socketPath(host string) (scheme, path string, ok bool) {
+	u, err := url.Parse(host)
+	if err != nil || u.Scheme != "unix" && u.Scheme != "npipe" {
+		return "", "", false
+	}
+	if u.Scheme == "npipe" {
+		// npipe:////./pipe/docker_engine names \\.\pipe\docker_engine
+		return u.Scheme, strings.ReplaceAll(strings.TrimPrefix(host, "npipe://"), "/", `\`), true
+	}
+	return u.Scheme, u.Path, true
+}
+
+// explainError adds a hint on how to fix the connection to host to err, for the
+// common mistakes around local sockets
+func explainError(host string, err error) error {
+	if hint := connectionHint(host); hint != "" {
+		return fmt.Errorf("%w (%s)", err, hint)
+	}
+	return err
+}
+
+// connectionHint returns a hint why host cannot be reached, or "" if none applies
+func connectionHint(host string) string {
+	if host == "" {
+		host = defaultHost()
+	}
+	scheme, path, ok := socketPath(host)
+	if !ok {
+		return ""
+	}
+	if scheme == "npipe" {
+		return pipeHint(path)
+	}
+
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		hint := fmt.Sprintf("socket %s does not exist, mount it with -v /var/run/docker.sock:/var/run/docker.sock:ro or set DOCKER_HOST", path)
+		if found := localHostCandidatesFound(); len(found) > 0 {
+			hint += ", a daemon socket was found at " + strings.Join(found, ", ")
+		}
+		return hint
+	case err != nil:
+		return fmt.Sprintf("cannot access %s: %v", path, err)
+	case info.Mode()&os.ModeSocket == 0:
+		return fmt.Sprintf("%s is not a socket, check the volume mount of the Docker socket", path)
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Sprintf("permission denied on %s, run the companion as root or add the group owning the socket, e.g. with group_add in Compose", path)
+		}
+		return fmt.Sprintf("no daemon is listening on %s, is Docker running?", path)
+	}
+	conn.Close()
+	return ""
+}
+
+// localHostCandidatesFound returns the candidate daemon addresses that exist
+func localHostCandidatesFound() []string {
+	var found []string
+	for _, candidate := range localHostCandidates() {
+		if hostExists(candidate) {
+			found = append(found, candidate)
+		}
+	}
+	return found
+}
+
+// defaultHost returns the first address of localHostCandidates, the default of the
+// Docker client
+func defaultHost() string {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return host
+	}
+	return localHostCandidates()[0]
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+
+package docker
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// localHostCandidates returns the addresses local daemons listen on, the default socket
+// first, followed by those of Docker Desktop on macOS and Linux
+func localHostCandidates() []string {
+	candidates := []string{"unix:///var/run/docker.sock"}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates,
+			"unix://"+filepath.Join(home, ".docker", "run", "docker.sock"),
+			"unix://"+filepath.Join(home, ".docker", "desktop", "docker.sock"),
+		)
+	}
+	return candidates
+}
+
+// hostExists reports whether the socket of a unix:// host exists
+func hostExists(host string) bool {
+	scheme, path, ok := socketPath(host)
+	if !ok || scheme != "unix" {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&os.ModeSocket != 0
+}
+
+// pipeHint explains that named pipes only exist on Windows
+func pipeHint(path string) string {
+	return "npipe:// hosts are only available on Windows, use a unix:// socket on Linux and macOS"
+}
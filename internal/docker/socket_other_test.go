@@ -0,0 +1,91 @@
+//go:build !windows
+
+package docker
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConnectionHint(t *testing.T) {
+	dir := t.TempDir()
+
+	listening := filepath.Join(dir, "listening.sock")
+	listener, err := net.Listen("unix", listening)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	stale := filepath.Join(dir, "stale.sock")
+	staleListener, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	// Keep the socket file while nobody listens on it
+	staleListener.(*net.UnixListener).SetUnlinkOnClose(false)
+	staleListener.Close()
+
+	file := filepath.Join(dir, "docker.sock")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		host string
+		want string
+	}{
+		{name: "listening", host: "unix://" + listening, want: ""},
+		{name: "missing socket", host: "unix://" + filepath.Join(dir, "missing.sock"), want: "does not exist"},
+		{name: "no daemon", host: "unix://" + stale, want: "no daemon is listening"},
+		{name: "directory mounted as file", host: "unix://" + file, want: "is not a socket"},
+		{name: "named pipe", host: "npipe:////./pipe/docker_engine", want: "only available on Windows"},
+		{name: "tcp", host: "tcp://docker.example.com:2376", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := connectionHint(tt.host)
+			if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+				t.Errorf("connectionHint(%q) = %q, want it to contain %q", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDesktopHost(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("DOCKER_HOST", "")
+	if _, err := os.Stat("/var/run/docker.sock"); err == nil {
+		t.Skip("The default Docker socket exists")
+	}
+
+	if got := desktopHost(); got != "" {
+		t.Errorf("desktopHost() = %q without Docker Desktop, want none", got)
+	}
+
+	socket := filepath.Join(home, ".docker", "run", "docker.sock")
+	if err := os.MkdirAll(filepath.Dir(socket), 0755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	if got := desktopHost(); got != "unix://"+socket {
+		t.Errorf("desktopHost() = %q, want unix://%s", got, socket)
+	}
+
+	// An explicit DOCKER_HOST always wins
+	t.Setenv("DOCKER_HOST", "tcp://docker.example.com:2376")
+	if got := desktopHost(); got != "" {
+		t.Errorf("desktopHost() = %q with DOCKER_HOST set, want none", got)
+	}
+}
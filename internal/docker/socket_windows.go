@@ -0,0 +1,35 @@
+//go:build windows
+
+package docker
+
+import (
+	"fmt"
+	"os"
+)
+
+// localHostCandidates returns the named pipes local daemons listen on, the default
+// pipe of Docker first, followed by the one of Docker Desktop's Linux engine
+func localHostCandidates() []string {
+	return []string{
+		"npipe:////./pipe/docker_engine",
+		"npipe:////./pipe/dockerDesktopLinuxEngine",
+	}
+}
+
+// hostExists reports whether the named pipe of an npipe:// host exists
+func hostExists(host string) bool {
+	scheme, path, ok := socketPath(host)
+	if !ok || scheme != "npipe" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// pipeHint explains why a named pipe cannot be opened
+func pipeHint(path string) string {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Sprintf("named pipe %s does not exist, is Docker Desktop running?", path)
+	}
+	return fmt.Sprintf("cannot open %s, the companion may have to run elevated or as a member of the docker-users group", path)
+}
@@ -0,0 +1,118 @@
+package docker
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// srvLabel declares SRV records next to the container's hosts, as a comma-separated list
+// of service and protocol followed by priority, weight, port and target, e.g.
+// netcup.companion/srv=_minecraft._tcp:0 5 25565 mc.example.com
+const srvLabel = "netcup.companion/srv"
+
+// SRVRecord is an SRV record published below the hostname of a container (RFC 2782)
+type SRVRecord struct {
+	Service  string `json:"service"` // Service and protocol labels, e.g. _minecraft._tcp
+	Priority int    `json:"priority"`
+	Weight   int    `json:"weight"`
+	Port     int    `json:"port"`
+	Target   string `json:"target"` // Hostname serving the service, without the trailing dot
+}
+
+// Name returns the record name below subdomain, e.g. _minecraft._tcp.mc for mc and
+// _minecraft._tcp for the zone apex
+func (r SRVRecord) Name(subdomain string) string {
+	if subdomain == "@" {
+		return r.Service
+	}
+	return r.Service + "." + subdomain
+}
+
+// Data returns the weight, port and absolute target, the record data next to the
+// priority
+func (r SRVRecord) Data() string {
+	return fmt.Sprintf("%d %d %s.", r.Weight, r.Port, r.Target)
+}
+
+func (r SRVRecord) String() string {
+	return fmt.Sprintf("%s:%d %d %d %s", r.Service, r.Priority, r.Weight, r.Port, r.Target)
+}
+
+// ParseSRV parses the value of the srv label. Empty entries are ignored.
+func ParseSRV(value string) ([]SRVRecord, error) {
+	var records []SRVRecord
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		record, err := parseSRVRecord(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SRV record %q: %w", entry, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func parseSRVRecord(entry string) (SRVRecord, error) {
+	service, data, ok := strings.Cut(entry, ":")
+	if !ok {
+		return SRVRecord{}, fmt.Errorf("expected _service._proto:priority weight port target")
+	}
+	var record SRVRecord
+
+	record.Service = strings.ToLower(strings.TrimSpace(service))
+	labels := strings.Split(record.Service, ".")
+	if len(labels) != 2 || len(labels[0]) < 2 || labels[0][0] != '_' || labels[1] != "_tcp" && labels[1] != "_udp" && labels[1] != "_tls" {
+		return SRVRecord{}, fmt.Errorf("service %q must be _service._tcp, _service._udp or _service._tls", service)
+	}
+	for _, r := range labels[0][1:] {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return SRVRecord{}, fmt.Errorf("service %q contains invalid character %q", service, r)
+		}
+	}
+
+	fields := strings.Fields(data)
+	if len(fields) != 4 {
+		return SRVRecord{}, fmt.Errorf("expected priority, weight, port and target, got %d fields", len(fields))
+	}
+	numbers := []*int{&record.Priority, &record.Weight, &record.Port}
+	for i, name := range []string{"priority", "weight", "port"} {
+		n, err := strconv.ParseUint(fields[i], 10, 16)
+		if err != nil {
+			return SRVRecord{}, fmt.Errorf("%s %q must be a number from 0 to 65535", name, fields[i])
+		}
+		*numbers[i] = int(n)
+	}
+
+	target, err := normalizeHostname(fields[3])
+	if err != nil {
+		return SRVRecord{}, fmt.Errorf("invalid target %q: %w", fields[3], err)
+	}
+	if target == "" || strings.HasPrefix(target, ".") || strings.Contains(target, "..") || !strings.Contains(target, ".") {
+		return SRVRecord{}, fmt.Errorf("target %q must be a fully qualified hostname", fields[3])
+	}
+	record.Target = target
+	return record, nil
+}
+
+// withSRV sets the SRV records declared by the srv label on all hosts of a container.
+// An invalid label is logged and ignored, so it never blocks the hosts themselves.
+func withSRV(hosts []HostInfo, containerName string, labels map[string]string) []HostInfo {
+	value := strings.TrimSpace(labels[srvLabel])
+	if len(hosts) == 0 || value == "" {
+		return hosts
+	}
+	records, err := ParseSRV(value)
+	if err != nil {
+		log.Printf("Ignoring %s label of container %s: %v", srvLabel, containerName, err)
+		return hosts
+	}
+	for i := range hosts {
+		hosts[i].SRV = records
+	}
+	return hosts
+}
@@ -0,0 +1,86 @@
+package docker
+
+import (
+	"testing"
+)
+
+func TestParseSRV(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []SRVRecord
+		wantErr bool
+	}{
+		{
+			name:  "single",
+			value: "_minecraft._tcp:0 5 25565 mc.example.com",
+			want:  []SRVRecord{{Service: "_minecraft._tcp", Priority: 0, Weight: 5, Port: 25565, Target: "mc.example.com"}},
+		},
+		{
+			name:  "several with trailing dot",
+			value: "_sip._udp:10 60 5060 sip.example.com., _sips._tcp:10 20 5061 SIP.example.com",
+			want: []SRVRecord{
+				{Service: "_sip._udp", Priority: 10, Weight: 60, Port: 5060, Target: "sip.example.com"},
+				{Service: "_sips._tcp", Priority: 10, Weight: 20, Port: 5061, Target: "sip.example.com"},
+			},
+		},
+		{name: "empty", value: " , ", want: nil},
+		{name: "missing service", value: "0 5 25565 mc.example.com", wantErr: true},
+		{name: "missing protocol", value: "_minecraft:0 5 25565 mc.example.com", wantErr: true},
+		{name: "unknown protocol", value: "_minecraft._sctp:0 5 25565 mc.example.com", wantErr: true},
+		{name: "missing underscore", value: "minecraft._tcp:0 5 25565 mc.example.com", wantErr: true},
+		{name: "missing field", value: "_minecraft._tcp:0 25565 mc.example.com", wantErr: true},
+		{name: "port out of range", value: "_minecraft._tcp:0 5 70000 mc.example.com", wantErr: true},
+		{name: "negative weight", value: "_minecraft._tcp:0 -5 25565 mc.example.com", wantErr: true},
+		{name: "unqualified target", value: "_minecraft._tcp:0 5 25565 mc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSRV(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSRV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseSRV() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ParseSRV()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSRVRecordName(t *testing.T) {
+	record := SRVRecord{Service: "_minecraft._tcp", Weight: 5, Port: 25565, Target: "mc.example.com"}
+	if got := record.Name("mc"); got != "_minecraft._tcp.mc" {
+		t.Errorf("Name(mc) = %q, want _minecraft._tcp.mc", got)
+	}
+	if got := record.Name("@"); got != "_minecraft._tcp" {
+		t.Errorf("Name(@) = %q, want _minecraft._tcp", got)
+	}
+	if got := record.Data(); got != "5 25565 mc.example.com." {
+		t.Errorf("Data() = %q, want 5 25565 mc.example.com.", got)
+	}
+}
+
+func TestWatcherExtractHosts_SRV(t *testing.T) {
+	w := &Watcher{}
+	labels := map[string]string{
+		"traefik.http.routers.mc.rule": "Host(`mc.example.com`)",
+		srvLabel:                       "_minecraft._tcp:0 5 25565 mc.example.com",
+	}
+	hosts := w.extractHosts("container123", "/minecraft", labels, nil)
+	if len(hosts) != 1 || len(hosts[0].SRV) != 1 || hosts[0].SRV[0].Port != 25565 {
+		t.Fatalf("extractHosts() = %+v, want mc.example.com with one SRV record", hosts)
+	}
+
+	// An invalid label is ignored without dropping the host
+	labels[srvLabel] = "_minecraft._tcp:0 5 mc.example.com"
+	hosts = w.extractHosts("container123", "/minecraft", labels, nil)
+	if len(hosts) != 1 || len(hosts[0].SRV) != 0 {
+		t.Errorf("extractHosts() = %+v, want mc.example.com without SRV records", hosts)
+	}
+}
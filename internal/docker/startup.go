@@ -0,0 +1,55 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+// Backoff between attempts to reach Docker at startup; variables for tests
+var (
+	startupInitialBackoff = time.Second
+	startupMaxBackoff     = 30 * time.Second
+)
+
+// WaitIndefinitely makes NewWatcherWhenReady retry until Docker answers or ctx is done
+const WaitIndefinitely time.Duration = math.MaxInt64
+
+// NewWatcherWhenReady creates a watcher once every Docker daemon answers a ping,
+// retrying with exponential backoff for up to timeout, e.g. while dockerd is still
+// starting during boot. A non-positive timeout makes a single attempt.
+func NewWatcherWhenReady(ctx context.Context, filterLabel string, opts *WatcherOptions, timeout time.Duration) (*Watcher, error) {
+	deadline := time.Now().Add(timeout)
+	backoff := startupInitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		watcher, err := NewWatcherWithOptions(filterLabel, opts)
+		if err == nil {
+			if err = watcher.Ping(ctx); err == nil {
+				if attempt > 1 {
+					log.Printf("Docker became available after %d attempts", attempt)
+				}
+				return watcher, nil
+			}
+			watcher.Close()
+			err = fmt.Errorf("docker daemon unreachable: %w", err)
+		}
+
+		if timeout != WaitIndefinitely && time.Now().Add(backoff).After(deadline) {
+			if attempt > 1 {
+				return nil, fmt.Errorf("%w (gave up after %d attempts)", err, attempt)
+			}
+			return nil, err
+		}
+
+		log.Printf("Waiting for Docker, retrying in %s: %v", backoff, err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, startupMaxBackoff)
+	}
+}
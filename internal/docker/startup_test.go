@@ -0,0 +1,89 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newPingServer serves Docker pings, failing the first failures of them
+func newPingServer(t *testing.T, failures int32) (string, *atomic.Int32) {
+	t.Helper()
+
+	var pings atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/_ping") {
+			http.NotFound(w, r)
+			return
+		}
+		if pings.Add(1) <= failures {
+			http.Error(w, "daemon starting", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("API-Version", "1.44")
+		w.Write([]byte("OK"))
+	}))
+	t.Cleanup(server.Close)
+	return "tcp://" + strings.TrimPrefix(server.URL, "http://"), &pings
+}
+
+func TestNewWatcherWhenReady(t *testing.T) {
+	startupInitialBackoff, startupMaxBackoff = time.Millisecond, 5*time.Millisecond
+	t.Cleanup(func() { startupInitialBackoff, startupMaxBackoff = time.Second, 30*time.Second })
+
+	t.Run("waits for Docker", func(t *testing.T) {
+		host, pings := newPingServer(t, 3)
+		watcher, err := NewWatcherWhenReady(context.Background(), "", &WatcherOptions{Hosts: []string{host}}, time.Minute)
+		if err != nil {
+			t.Fatalf("NewWatcherWhenReady() error = %v", err)
+		}
+		defer watcher.Close()
+		if pings.Load() < 4 {
+			t.Errorf("got %d pings, want at least 4", pings.Load())
+		}
+	})
+
+	t.Run("waits indefinitely", func(t *testing.T) {
+		host, pings := newPingServer(t, 10)
+		watcher, err := NewWatcherWhenReady(context.Background(), "", &WatcherOptions{Hosts: []string{host}}, WaitIndefinitely)
+		if err != nil {
+			t.Fatalf("NewWatcherWhenReady() error = %v", err)
+		}
+		defer watcher.Close()
+		if pings.Load() < 11 {
+			t.Errorf("got %d pings, want at least 11", pings.Load())
+		}
+	})
+
+	t.Run("gives up after the timeout", func(t *testing.T) {
+		host, _ := newPingServer(t, 1000)
+		_, err := NewWatcherWhenReady(context.Background(), "", &WatcherOptions{Hosts: []string{host}}, 20*time.Millisecond)
+		if err == nil || !strings.Contains(err.Error(), "gave up after") {
+			t.Errorf("NewWatcherWhenReady() error = %v, want give up error", err)
+		}
+	})
+
+	t.Run("single attempt without timeout", func(t *testing.T) {
+		host, pings := newPingServer(t, 1000)
+		if _, err := NewWatcherWhenReady(context.Background(), "", &WatcherOptions{Hosts: []string{host}}, 0); err == nil {
+			t.Error("NewWatcherWhenReady() error = nil, want error")
+		}
+		// A single ping may fall back from HEAD to GET
+		if pings.Load() > 2 {
+			t.Errorf("got %d pings, want a single attempt", pings.Load())
+		}
+	})
+
+	t.Run("stops on cancellation", func(t *testing.T) {
+		host, _ := newPingServer(t, 1000)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := NewWatcherWhenReady(ctx, "", &WatcherOptions{Hosts: []string{host}}, time.Minute); err == nil {
+			t.Error("NewWatcherWhenReady() error = nil, want cancellation error")
+		}
+	})
+}
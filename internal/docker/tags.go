@@ -0,0 +1,31 @@
+package docker
+
+import (
+	"slices"
+	"strings"
+)
+
+// tagsLabel attaches tags to the container's records for bulk maintenance, e.g.
+// netcup.companion/tags=prod,web
+const tagsLabel = "netcup.companion/tags"
+
+// parseTags splits a comma-separated tag list into lowercase, sorted and unique tags
+func parseTags(raw string) []string {
+	var tags []string
+	for _, tag := range strings.Split(raw, ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+// withTags sets the tags from the tags label on all hosts of a container
+func withTags(hosts []HostInfo, labels map[string]string) []HostInfo {
+	tags := parseTags(labels[tagsLabel])
+	for i := range hosts {
+		hosts[i].Tags = tags
+	}
+	return hosts
+}
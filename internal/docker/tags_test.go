@@ -0,0 +1,41 @@
+package docker
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []string
+	}{
+		{raw: "", want: nil},
+		{raw: "prod", want: []string{"prod"}},
+		{raw: "web, Prod,,prod ", want: []string{"prod", "web"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			if got := parseTags(tt.raw); !slices.Equal(got, tt.want) {
+				t.Errorf("parseTags(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatcherExtractHosts_Tags(t *testing.T) {
+	labels := map[string]string{
+		"traefik.http.routers.app.rule": "Host(`app.example.com`) || Host(`www.example.com`)",
+		tagsLabel:                       "prod,web",
+	}
+	hosts := (&Watcher{}).extractHosts("container123", "/app", labels, nil)
+	if len(hosts) != 2 {
+		t.Fatalf("extractHosts() returned %d hosts, want 2", len(hosts))
+	}
+	for _, info := range hosts {
+		if !slices.Equal(info.Tags, []string{"prod", "web"}) {
+			t.Errorf("Tags of %s = %v, want [prod web]", info.Hostname, info.Tags)
+		}
+	}
+}
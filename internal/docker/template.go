@@ -0,0 +1,101 @@
+package docker
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// autoLabel opts a container without Traefik Host() rules into a hostname generated from
+// AUTO_HOSTNAME_TEMPLATE, e.g. netcup.companion/auto=true
+const autoLabel = "netcup.companion/auto"
+
+// autoRouter is the Router of hosts generated from the hostname template
+const autoRouter = "auto"
+
+// HostnameTemplateData is the data AUTO_HOSTNAME_TEMPLATE is executed with, e.g.
+// {{.ComposeService}}.{{.Project}}.example.com
+type HostnameTemplateData struct {
+	Name           string            // Container name without the leading slash
+	ComposeProject string            // Compose project, empty outside of Compose
+	ComposeService string            // Compose service, empty outside of Compose
+	Project        string            // Alias of ComposeProject
+	Service        string            // Alias of ComposeService
+	Labels         map[string]string // All container labels
+}
+
+// ParseHostnameTemplate parses a hostname template. Missing label keys are errors, so
+// a template never yields a hostname with an empty label.
+func ParseHostnameTemplate(text string) (*template.Template, error) {
+	return template.New("hostname").Option("missingkey=error").Parse(text)
+}
+
+// extractHostFromTemplate returns the host generated from tmpl for a container with the
+// netcup.companion/auto label, or nil without the label or template
+func extractHostFromTemplate(tmpl *template.Template, containerID, containerName string, labels map[string]string) []HostInfo {
+	if tmpl == nil {
+		return nil
+	}
+	if auto, _ := strconv.ParseBool(labels[autoLabel]); !auto {
+		return nil
+	}
+
+	containerName = strings.TrimPrefix(containerName, "/")
+	data := HostnameTemplateData{
+		Name:           containerName,
+		ComposeProject: labels[composeProjectLabel],
+		ComposeService: labels[composeServiceLabel],
+		Project:        labels[composeProjectLabel],
+		Service:        labels[composeServiceLabel],
+		Labels:         labels,
+	}
+
+	hostname, err := executeHostnameTemplate(tmpl, data)
+	if err != nil {
+		log.Printf("Skipping generated hostname of container %s: %v", containerName, err)
+		return nil
+	}
+
+	domain, subdomain := splitHostname(hostname)
+	excluded := excludedHosts(labels)
+	if excluded[hostname] || excluded[domain] {
+		log.Printf("Skipping excluded host %s for container %s", hostname, containerName)
+		return nil
+	}
+
+	info := HostInfo{
+		ContainerID:    containerID,
+		ContainerName:  containerName,
+		Hostname:       hostname,
+		Domain:         domain,
+		Subdomain:      subdomain,
+		Router:         autoRouter,
+		Destination:    strings.TrimSpace(labels[destinationLabel]),
+		ComposeProject: data.ComposeProject,
+		ComposeService: data.ComposeService,
+	}
+	log.Printf("Generated host: %s (domain: %s, subdomain: %s) for container %s%s",
+		hostname, domain, subdomain, containerName, info.StackSuffix())
+	return []HostInfo{info}
+}
+
+// executeHostnameTemplate renders tmpl and checks the result is a hostname with a
+// non-empty label on either side of every dot
+func executeHostnameTemplate(tmpl *template.Template, data HostnameTemplateData) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+
+	raw := strings.TrimSpace(b.String())
+	hostname, err := normalizeHostname(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid hostname %q: %w", raw, err)
+	}
+	if hostname == "" || strings.HasPrefix(hostname, ".") || strings.Contains(hostname, "..") || !strings.Contains(hostname, ".") {
+		return "", fmt.Errorf("invalid hostname %q", raw)
+	}
+	return hostname, nil
+}
@@ -0,0 +1,70 @@
+package docker
+
+import (
+	"testing"
+)
+
+func TestExtractHostFromTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		labels   map[string]string
+		want     string
+	}{
+		{
+			name:     "compose service and project",
+			template: "{{.ComposeService}}.{{.Project}}.example.com",
+			labels:   map[string]string{autoLabel: "true", composeProjectLabel: "Shop", composeServiceLabel: "db"},
+			want:     "db.shop.example.com",
+		},
+		{
+			name:     "container name and label",
+			template: `{{.Name}}.{{index .Labels "env"}}.example.com`,
+			labels:   map[string]string{autoLabel: "1", "env": "staging"},
+			want:     "app.staging.example.com",
+		},
+		{
+			name:     "not opted in",
+			template: "{{.Name}}.example.com",
+			labels:   map[string]string{},
+		},
+		{
+			name:     "empty project outside of compose",
+			template: "{{.ComposeService}}.{{.Project}}.example.com",
+			labels:   map[string]string{autoLabel: "true"},
+		},
+		{
+			name:     "missing label",
+			template: `{{.Labels.env}}.example.com`,
+			labels:   map[string]string{autoLabel: "true"},
+		},
+		{
+			name:     "excluded",
+			template: "{{.Name}}.example.com",
+			labels:   map[string]string{autoLabel: "true", excludeLabel: "example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseHostnameTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("ParseHostnameTemplate() error = %v", err)
+			}
+
+			hosts := extractHostFromTemplate(tmpl, "container123", "/app", tt.labels)
+			if tt.want == "" {
+				if len(hosts) != 0 {
+					t.Errorf("extractHostFromTemplate() = %+v, want none", hosts)
+				}
+				return
+			}
+			if len(hosts) != 1 {
+				t.Fatalf("extractHostFromTemplate() = %+v, want %s", hosts, tt.want)
+			}
+			if hosts[0].Hostname != tt.want || hosts[0].Router != autoRouter {
+				t.Errorf("extractHostFromTemplate() = %s (router %s), want %s", hosts[0].Hostname, hosts[0].Router, tt.want)
+			}
+		})
+	}
+}
@@ -2,14 +2,22 @@ package docker
 
 import (
 	"context"
+	"fmt"
 	"log"
-	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/events"
-	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type HostInfo struct {
@@ -18,36 +26,240 @@ type HostInfo struct {
 	Hostname      string
 	Domain        string
 	Subdomain     string
+	Router        string // Router is the Traefik router the hostname was found on
+	DockerHost    string // DockerHost is the daemon the container runs on
+	IP            string // IP is the container address to publish; empty publishes the host IP
+	Remove        bool   // Remove indicates the record should be withdrawn instead of published
+
+	// Entrypoints the router is bound to; empty binds it to all entrypoints
+	Entrypoints []string
+
+	// CertResolver is the ACME certificate resolver of the router, empty without one
+	CertResolver string
+
+	// Destination overrides the published address with an IPv4 address or a hostname
+	// resolved to one, set by the netcup.destination label
+	Destination string
+
+	// Compose project and service owning the container, empty outside of Compose
+	ComposeProject string
+	ComposeService string
+
+	// ExpiresIn is the lifetime of the records set by the netcup.expires-in label, after
+	// which they are deleted even if the container keeps running; zero keeps them
+	ExpiresIn time.Duration
+
+	// SRV records published below the hostname, set by the netcup.companion/srv label
+	SRV []SRVRecord
+
+	// DryRun logs and notifies the changes of the host without writing them, set by the
+	// netcup.companion/dry-run label
+	DryRun bool
+
+	// Tags of the records for bulk maintenance, set by the netcup.companion/tags label
+	Tags []string
+
+	// SpanContext links DNS processing to the trace of the originating Docker event
+	SpanContext trace.SpanContext
 }
 
 type Watcher struct {
-	client      *client.Client
+	daemons     []*daemon
 	filterLabel string
+
+	healthCheckGating    bool
+	unhealthyGracePeriod time.Duration
+
+	publishContainerIP bool
+	containerNetwork   string
+	publicEntrypoints  map[string]bool // nil publishes routers of all entrypoints
+	certResolvers      map[string]bool // nil publishes routers regardless of their certresolver
+	hostTracker        HostTracker
+	projects           []string           // Compose projects in scope; empty means all
+	networks           []string           // Networks in scope; empty means all
+	hostEnvVars        []string           // Environment variables listing hostnames
+	hostnameTemplate   *template.Template // Hostname of containers labeled netcup.companion/auto=true; nil disables
+
+	probe     ProbeOptions
+	crashLoop CrashLoopOptions
+
+	mu              sync.Mutex
+	pendingRemovals map[string]*pendingRemoval    // keyed by container ID
+	pendingProbes   map[string]context.CancelFunc // keyed by container ID
+	crashLoops      map[string]*crashLoop         // keyed by container ID
+}
+
+// WatcherOptions holds optional settings for the Docker watcher
+type WatcherOptions struct {
+	HealthCheckGating    bool          // Only publish containers with a healthcheck once they report healthy
+	UnhealthyGracePeriod time.Duration // How long a container may stay unhealthy before its records are removed
+	Hosts                []string      // Docker daemons to watch; empty uses DOCKER_HOST or the local socket
+	Connection           ConnectionOptions
+	PublishContainerIP   bool        // Publish the container's address instead of the host IP
+	ContainerNetwork     string      // Network to read the container address from unless overridden by the netcup.network label
+	PublicEntrypoints    []string    // Only publish routers bound to one of these entrypoints; empty publishes all
+	CertResolvers        []string    // Only publish routers using one of these certresolvers; empty publishes all
+	HostTracker          HostTracker // Remembers the hosts of each container to withdraw hosts dropped on recreate; nil disables
+	Projects             []string    // Only watch containers of these Compose projects; empty watches all
+	Networks             []string    // Only watch containers attached to one of these networks; empty watches all
+	HostEnvVars          []string    // Container environment variables listing hostnames, e.g. VIRTUAL_HOST; empty disables
+	HostnameTemplate     string      // Template of the hostname of containers labeled netcup.companion/auto=true; empty disables
+
+	// Probe checks the service of started containers before they are published; a zero
+	// value publishes right away
+	Probe ProbeOptions
+
+	// CrashLoop holds back the starts of containers in a restart loop; a zero value
+	// publishes every start
+	CrashLoop CrashLoopOptions
+}
+
+// HostTracker remembers the hostnames published per container across restarts
+type HostTracker interface {
+	// SwapContainerHosts stores the hostnames a container publishes and returns those
+	// it published before but no longer does
+	SwapContainerHosts(container string, hostnames []string) ([]string, error)
+}
+
+// daemon is a single Docker daemon watched by the Watcher
+type daemon struct {
+	host   string
+	client *client.Client
+}
+
+// pendingRemoval tracks a scheduled removal for an unhealthy container
+type pendingRemoval struct {
+	cancel context.CancelFunc
 }
 
 func NewWatcher(filterLabel string) (*Watcher, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	if err != nil {
-		return nil, err
+	return NewWatcherWithOptions(filterLabel, &WatcherOptions{})
+}
+
+func NewWatcherWithOptions(filterLabel string, opts *WatcherOptions) (*Watcher, error) {
+	var hostnameTemplate *template.Template
+	if opts.HostnameTemplate != "" {
+		var err error
+		if hostnameTemplate, err = ParseHostnameTemplate(opts.HostnameTemplate); err != nil {
+			return nil, fmt.Errorf("invalid hostname template: %w", err)
+		}
+	}
+
+	hosts := opts.Hosts
+	if len(hosts) == 0 {
+		host := desktopHost()
+		if host != "" {
+			log.Printf("Default Docker socket not found, using %s", host)
+		}
+		hosts = []string{host}
+	}
+
+	var daemons []*daemon
+	for _, host := range hosts {
+		cli, err := newClient(host, &opts.Connection)
+		if err != nil {
+			for _, d := range daemons {
+				d.client.Close()
+			}
+			return nil, fmt.Errorf("failed to create Docker client for %s: %w", host, explainError(host, err))
+		}
+
+		// Keep the configured address for ssh:// hosts, the client only sees a placeholder
+		if host == "" {
+			host = cli.DaemonHost()
+		}
+		daemons = append(daemons, &daemon{host: host, client: cli})
+	}
+
+	var publicEntrypoints map[string]bool
+	if len(opts.PublicEntrypoints) > 0 {
+		publicEntrypoints = make(map[string]bool, len(opts.PublicEntrypoints))
+		for _, entrypoint := range opts.PublicEntrypoints {
+			publicEntrypoints[entrypoint] = true
+		}
+	}
+
+	var certResolvers map[string]bool
+	if len(opts.CertResolvers) > 0 {
+		certResolvers = make(map[string]bool, len(opts.CertResolvers))
+		for _, resolver := range opts.CertResolvers {
+			certResolvers[resolver] = true
+		}
 	}
 
 	return &Watcher{
-		client:      cli,
-		filterLabel: filterLabel,
+		daemons:              daemons,
+		filterLabel:          filterLabel,
+		healthCheckGating:    opts.HealthCheckGating,
+		unhealthyGracePeriod: opts.UnhealthyGracePeriod,
+		publishContainerIP:   opts.PublishContainerIP,
+		containerNetwork:     opts.ContainerNetwork,
+		publicEntrypoints:    publicEntrypoints,
+		certResolvers:        certResolvers,
+		hostTracker:          opts.HostTracker,
+		projects:             opts.Projects,
+		networks:             opts.Networks,
+		hostEnvVars:          opts.HostEnvVars,
+		hostnameTemplate:     hostnameTemplate,
+		pendingRemovals:      make(map[string]*pendingRemoval),
+		pendingProbes:        make(map[string]context.CancelFunc),
+		crashLoops:           make(map[string]*crashLoop),
+		probe:                opts.Probe,
+		crashLoop:            opts.CrashLoop,
 	}, nil
 }
 
 func (w *Watcher) Close() error {
-	return w.client.Close()
+	var firstErr error
+	for _, d := range w.daemons {
+		if err := d.client.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Hosts returns the addresses of all watched Docker daemons
+func (w *Watcher) Hosts() []string {
+	hosts := make([]string, 0, len(w.daemons))
+	for _, d := range w.daemons {
+		hosts = append(hosts, d.host)
+	}
+	return hosts
+}
+
+// Ping checks that every Docker daemon is reachable
+func (w *Watcher) Ping(ctx context.Context) error {
+	for _, d := range w.daemons {
+		if _, err := d.client.Ping(ctx); err != nil {
+			return fmt.Errorf("%s: %w", d.host, explainError(d.host, err))
+		}
+	}
+	return nil
 }
 
+// WatchEvents watches all Docker daemons and returns when any of them fails or ctx is cancelled
 func (w *Watcher) WatchEvents(ctx context.Context, hostChan chan<- HostInfo) error {
-	filterArgs := filters.NewArgs()
-	filterArgs.Add("type", "container")
-	filterArgs.Add("event", "start")
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	eventsChan, errChan := w.client.Events(ctx, events.ListOptions{
-		Filters: filterArgs,
+	errChan := make(chan error, len(w.daemons))
+	for _, d := range w.daemons {
+		go func(d *daemon) {
+			err := w.watchDaemon(ctx, d, hostChan)
+			if err != nil && ctx.Err() == nil {
+				err = fmt.Errorf("%s: %w", d.host, err)
+			}
+			errChan <- err
+		}(d)
+	}
+
+	return <-errChan
+}
+
+func (w *Watcher) watchDaemon(ctx context.Context, d *daemon, hostChan chan<- HostInfo) error {
+	eventsChan, errChan := d.client.Events(ctx, events.ListOptions{
+		Filters: w.eventFilters(),
 	})
 
 	for {
@@ -57,22 +269,56 @@ func (w *Watcher) WatchEvents(ctx context.Context, hostChan chan<- HostInfo) err
 		case err := <-errChan:
 			return err
 		case event := <-eventsChan:
-			w.handleEvent(ctx, event, hostChan)
+			w.handleEvent(ctx, d, event, hostChan)
 		}
 	}
 }
 
+// ScanExistingContainers returns host info for running containers on all Docker daemons
 func (w *Watcher) ScanExistingContainers(ctx context.Context) ([]HostInfo, error) {
+	return w.scan(ctx, false)
+}
+
+// ScanContainerChanges scans running containers like ScanExistingContainers and also
+// returns removals for the hosts a container published before but no longer carries,
+// e.g. after its labels changed while the companion was not running. It records the
+// current hosts of every container, so it is meant for the startup and reconnect scans.
+func (w *Watcher) ScanContainerChanges(ctx context.Context) ([]HostInfo, error) {
+	return w.scan(ctx, true)
+}
+
+func (w *Watcher) scan(ctx context.Context, withDropped bool) ([]HostInfo, error) {
 	var hosts []HostInfo
 
-	filterArgs := filters.NewArgs()
-	filterArgs.Add("status", "running")
+	for _, d := range w.daemons {
+		daemonHosts, err := w.scanDaemon(ctx, d, withDropped)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", d.host, err)
+		}
+		hosts = append(hosts, daemonHosts...)
+	}
 
-	containers, err := w.client.ContainerList(ctx, container.ListOptions{
-		Filters: filterArgs,
-	})
-	if err != nil {
-		return nil, err
+	return hosts, nil
+}
+
+func (w *Watcher) scanDaemon(ctx context.Context, d *daemon, withDropped bool) ([]HostInfo, error) {
+	var hosts []HostInfo
+
+	var containers []container.Summary
+	seen := make(map[string]bool)
+	for _, filterArgs := range w.scanFilters() {
+		matched, err := d.client.ContainerList(ctx, container.ListOptions{
+			Filters: filterArgs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range matched {
+			if !seen[c.ID] {
+				seen[c.ID] = true
+				containers = append(containers, c)
+			}
+		}
 	}
 
 	for _, c := range containers {
@@ -86,23 +332,66 @@ func (w *Watcher) ScanExistingContainers(ctx context.Context) ([]HostInfo, error
 			}
 		}
 
-		hostInfos := extractHostsFromLabels(c.ID, strings.TrimPrefix(c.Names[0], "/"), c.Labels)
-		hosts = append(hosts, hostInfos...)
+		// Skip containers that have a healthcheck but are not healthy yet
+		if w.healthCheckGating {
+			if health := healthFromStatus(c.Status); health != container.NoHealthcheck && health != container.Healthy {
+				log.Printf("Container %s is %s, deferring DNS until it reports healthy", strings.TrimPrefix(c.Names[0], "/"), health)
+				continue
+			}
+		}
+
+		// The container list carries no environment, it has to be inspected
+		var env []string
+		if len(w.hostEnvVars) > 0 {
+			if inspected, err := d.client.ContainerInspect(ctx, c.ID); err != nil {
+				log.Printf("Warning: Failed to read environment of container %s: %v", strings.TrimPrefix(c.Names[0], "/"), err)
+			} else if inspected.Config != nil {
+				env = inspected.Config.Env
+			}
+		}
+
+		hostInfos := w.extractHosts(c.ID, strings.TrimPrefix(c.Names[0], "/"), c.Labels, env)
+		var networks map[string]*network.EndpointSettings
+		if c.NetworkSettings != nil {
+			networks = c.NetworkSettings.Networks
+		}
+		hostInfos = w.withContainerIP(hostInfos, c.Names[0], networks, c.Labels)
+		if withDropped {
+			hosts = append(hosts, w.droppedHosts(d.host, c.Names[0], c.Labels, hostInfos)...)
+		}
+		hosts = append(hosts, tagDockerHost(hostInfos, d.host)...)
 	}
 
 	return hosts, nil
 }
 
-func (w *Watcher) handleEvent(ctx context.Context, event events.Message, hostChan chan<- HostInfo) {
+func (w *Watcher) handleEvent(ctx context.Context, d *daemon, event events.Message, hostChan chan<- HostInfo) {
+	ctx, span := tracer.Start(ctx, "docker.handleEvent", trace.WithAttributes(
+		attribute.String("docker.host", d.host),
+		attribute.String("docker.container.id", event.Actor.ID),
+		attribute.String("docker.event.action", string(event.Action)),
+	))
+	defer span.End()
+
 	// Get container details
-	containerJSON, err := w.client.ContainerInspect(ctx, event.Actor.ID)
+	containerJSON, err := d.client.ContainerInspect(ctx, event.Actor.ID)
 	if err != nil {
 		log.Printf("Error inspecting container %s: %v", event.Actor.ID, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return
 	}
 
 	labels := containerJSON.Config.Labels
 
+	var networks map[string]*network.EndpointSettings
+	if containerJSON.NetworkSettings != nil {
+		networks = containerJSON.NetworkSettings.Networks
+	}
+	if !w.inScope(labels, networks) {
+		return
+	}
+
 	// Check filter label if specified
 	if w.filterLabel != "" {
 		parts := strings.SplitN(w.filterLabel, "=", 2)
@@ -113,46 +402,337 @@ func (w *Watcher) handleEvent(ctx context.Context, event events.Message, hostCha
 		}
 	}
 
-	hostInfos := extractHostsFromLabels(event.Actor.ID, containerJSON.Name, labels)
-	for _, info := range hostInfos {
-		hostChan <- info
+	_, extractSpan := tracer.Start(ctx, "docker.extractHosts")
+	hostInfos := tagDockerHost(w.extractHosts(event.Actor.ID, containerJSON.Name, labels, containerJSON.Config.Env), d.host)
+	extractSpan.SetAttributes(attribute.Int("docker.hosts", len(hostInfos)))
+	extractSpan.End()
+
+	for i := range hostInfos {
+		hostInfos[i].SpanContext = span.SpanContext()
+	}
+
+	if w.healthCheckGating && containerJSON.State != nil && containerJSON.State.Health != nil {
+		switch event.Action {
+		case events.ActionStart:
+			log.Printf("Container %s has a healthcheck, waiting for it to report healthy", containerJSON.Name)
+			return
+		case events.ActionHealthStatusHealthy:
+			w.cancelPendingRemoval(event.Actor.ID)
+		case events.ActionHealthStatusUnhealthy:
+			w.scheduleRemoval(ctx, d, event.Actor.ID, hostInfos, hostChan)
+			return
+		default:
+			return
+		}
+	} else if event.Action != events.ActionStart {
+		return
+	}
+
+	hostInfos = w.withContainerIP(hostInfos, containerJSON.Name, networks, labels)
+
+	publish := func() {
+		for _, info := range w.droppedHosts(d.host, containerJSON.Name, labels, hostInfos) {
+			info.SpanContext = span.SpanContext()
+			hostChan <- info
+		}
+		if w.probe.Mode != "" && len(hostInfos) > 0 {
+			w.publishWhenReachable(ctx, event.Actor.ID, containerJSON.Name, hostInfos, networks, labels, exposedTCPPorts(containerJSON.Config.ExposedPorts), hostChan)
+			return
+		}
+		for _, info := range hostInfos {
+			hostChan <- info
+		}
+	}
+	if w.holdCrashLoop(event.Actor.ID, strings.TrimPrefix(containerJSON.Name, "/"), containerJSON.RestartCount, publish) {
+		return
+	}
+	publish()
+}
+
+// droppedHosts returns removals for the hosts the container published before but no
+// longer carries, e.g. after it was recreated with a changed Host rule
+func (w *Watcher) droppedHosts(dockerHost, containerName string, labels map[string]string, hosts []HostInfo) []HostInfo {
+	if w.hostTracker == nil {
+		return nil
+	}
+
+	containerName = strings.TrimPrefix(containerName, "/")
+	hostnames := make([]string, 0, len(hosts))
+	for _, info := range hosts {
+		hostnames = append(hostnames, info.Hostname)
+	}
+
+	dropped, err := w.hostTracker.SwapContainerHosts(dockerHost+"/"+containerName, hostnames)
+	if err != nil {
+		log.Printf("Warning: Failed to track hosts of container %s: %v", containerName, err)
+		return nil
+	}
+
+	removals := make([]HostInfo, 0, len(dropped))
+	for _, hostname := range dropped {
+		domain, subdomain := splitHostname(hostname)
+		log.Printf("Container %s no longer publishes %s, removing it", containerName, hostname)
+		removals = append(removals, HostInfo{
+			ContainerName:  containerName,
+			Hostname:       hostname,
+			Domain:         domain,
+			Subdomain:      subdomain,
+			DockerHost:     dockerHost,
+			Remove:         true,
+			ComposeProject: labels[composeProjectLabel],
+			ComposeService: labels[composeServiceLabel],
+			Tags:           parseTags(labels[tagsLabel]),
+		})
+	}
+	return removals
+}
+
+// scheduleRemoval withdraws the container's records if it is still unhealthy after the grace period
+func (w *Watcher) scheduleRemoval(ctx context.Context, d *daemon, containerID string, hostInfos []HostInfo, hostChan chan<- HostInfo) {
+	if len(hostInfos) == 0 {
+		return
+	}
+
+	w.mu.Lock()
+	if _, exists := w.pendingRemovals[containerID]; exists {
+		w.mu.Unlock()
+		return
+	}
+	removalCtx, cancel := context.WithCancel(ctx)
+	pending := &pendingRemoval{cancel: cancel}
+	w.pendingRemovals[containerID] = pending
+	w.mu.Unlock()
+
+	log.Printf("Container %s is unhealthy, removing DNS records in %s unless it recovers", hostInfos[0].ContainerName, w.unhealthyGracePeriod)
+
+	go func() {
+		defer func() {
+			w.mu.Lock()
+			if w.pendingRemovals[containerID] == pending {
+				delete(w.pendingRemovals, containerID)
+			}
+			w.mu.Unlock()
+			cancel()
+		}()
+
+		select {
+		case <-removalCtx.Done():
+			return
+		case <-time.After(w.unhealthyGracePeriod):
+		}
+
+		// Re-check health before removing; the container may have recovered or stopped reporting
+		containerJSON, err := d.client.ContainerInspect(removalCtx, containerID)
+		if err == nil && containerJSON.State != nil && containerJSON.State.Health != nil &&
+			containerJSON.State.Health.Status != container.Unhealthy {
+			return
+		}
+
+		for _, info := range hostInfos {
+			info.Remove = true
+			select {
+			case <-removalCtx.Done():
+				return
+			case hostChan <- info:
+			}
+		}
+	}()
+}
+
+// cancelPendingRemoval aborts a scheduled removal for a container that recovered
+func (w *Watcher) cancelPendingRemoval(containerID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if pending, exists := w.pendingRemovals[containerID]; exists {
+		pending.cancel()
+		delete(w.pendingRemovals, containerID)
+		log.Printf("Container %s recovered, cancelled pending DNS removal", containerID)
 	}
 }
 
+// tracer creates spans for Docker event handling
+var tracer = otel.Tracer("github.com/alex289/docker-traefik-netcup-companion/internal/docker")
+
+// Stack returns the Compose project and service owning the container, e.g. "shop/web",
+// or an empty string for containers not managed by Compose
+func (h HostInfo) Stack() string {
+	switch {
+	case h.ComposeProject == "":
+		return ""
+	case h.ComposeService == "":
+		return h.ComposeProject
+	default:
+		return h.ComposeProject + "/" + h.ComposeService
+	}
+}
+
+// StackSuffix formats Stack for appending to log and notification messages, e.g. " [shop/web]"
+func (h HostInfo) StackSuffix() string {
+	if stack := h.Stack(); stack != "" {
+		return " [" + stack + "]"
+	}
+	return ""
+}
+
+// tagDockerHost records the originating Docker daemon on each host
+func tagDockerHost(hosts []HostInfo, dockerHost string) []HostInfo {
+	for i := range hosts {
+		hosts[i].DockerHost = dockerHost
+	}
+	return hosts
+}
+
+// healthFromStatus extracts the health state from a container list status string,
+// e.g. "Up 5 minutes (healthy)" -> "healthy", "Up 3 seconds (health: starting)" -> "starting"
+func healthFromStatus(status string) string {
+	switch {
+	case strings.Contains(status, "(health: starting)"):
+		return container.Starting
+	case strings.Contains(status, "(unhealthy)"):
+		return container.Unhealthy
+	case strings.Contains(status, "(healthy)"):
+		return container.Healthy
+	default:
+		return container.NoHealthcheck
+	}
+}
+
+const (
+	// routerSkipLabel is appended to a router prefix to opt the router out,
+	// e.g. traefik.http.routers.internal.netcup.skip=true
+	routerSkipLabel = ".netcup.skip"
+
+	// entrypointsLabel is appended to a router prefix to list the entrypoints it is bound to,
+	// e.g. traefik.http.routers.app.entrypoints=web,websecure
+	entrypointsLabel = ".entrypoints"
+
+	// certResolverLabel is appended to a router prefix to name its ACME certificate resolver,
+	// e.g. traefik.http.routers.app.tls.certresolver=letsencrypt
+	certResolverLabel = ".tls.certresolver"
+
+	// excludeLabel lists hostnames or domains of the container that never get DNS records,
+	// e.g. netcup.exclude=internal.example.com,example.org
+	excludeLabel = "netcup.exclude"
+
+	// destinationLabel points the container's records at another address or hostname,
+	// e.g. netcup.destination=203.0.113.7 or netcup.destination=cdn.example.net
+	destinationLabel = "netcup.destination"
+
+	// Labels set by Docker Compose on every container of a project
+	composeProjectLabel = "com.docker.compose.project"
+	composeServiceLabel = "com.docker.compose.service"
+)
+
 func extractHostsFromLabels(containerID, containerName string, labels map[string]string) []HostInfo {
 	var hosts []HostInfo
 
-	// Regex to match Host rule in Traefik labels
-	// Matches patterns like: Host(`example.com`) or Host(`sub.example.com`)
-	hostRegex := regexp.MustCompile(`Host\(` + "`" + `([^` + "`" + `]+)` + "`" + `\)`)
+	excluded := excludedHosts(labels)
 
 	for key, value := range labels {
 		// Look for traefik router rule labels
-		if strings.Contains(key, "traefik") && strings.Contains(key, ".rule") {
-			matches := hostRegex.FindAllStringSubmatch(value, -1)
-			for _, match := range matches {
-				if len(match) >= 2 {
-					hostname := match[1]
-					domain, subdomain := splitHostname(hostname)
-
-					hosts = append(hosts, HostInfo{
-						ContainerID:   containerID,
-						ContainerName: strings.TrimPrefix(containerName, "/"),
-						Hostname:      hostname,
-						Domain:        domain,
-						Subdomain:     subdomain,
-					})
-
-					log.Printf("Found host: %s (domain: %s, subdomain: %s) for container %s",
-						hostname, domain, subdomain, containerName)
+		if strings.Contains(key, "traefik") && strings.HasSuffix(key, ".rule") {
+			routerPrefix := strings.TrimSuffix(key, ".rule")
+			router := routerPrefix[strings.LastIndex(routerPrefix, ".")+1:]
+
+			if skip, _ := strconv.ParseBool(labels[routerPrefix+routerSkipLabel]); skip {
+				log.Printf("Skipping router %s of container %s (opted out)", router, containerName)
+				continue
+			}
+
+			var entrypoints []string
+			for _, entrypoint := range strings.Split(labels[routerPrefix+entrypointsLabel], ",") {
+				if trimmed := strings.TrimSpace(entrypoint); trimmed != "" {
+					entrypoints = append(entrypoints, trimmed)
 				}
 			}
+
+			ruleHosts, ignored := parseRule(value)
+			if len(ignored) > 0 {
+				log.Printf("Router %s of container %s: using hosts [%s] of rule, ignoring %s", router, containerName, strings.Join(ruleHosts, ", "), strings.Join(ignored, ", "))
+			}
+			for _, ruleHost := range ruleHosts {
+				hostname, err := normalizeHostname(ruleHost)
+				if err != nil {
+					log.Printf("Skipping invalid host %s for container %s: %v", ruleHost, containerName, err)
+					continue
+				}
+				domain, subdomain := splitHostname(hostname)
+
+				if excluded[hostname] || excluded[domain] {
+					log.Printf("Skipping excluded host %s for container %s", hostname, containerName)
+					continue
+				}
+
+				info := HostInfo{
+					ContainerID:    containerID,
+					ContainerName:  strings.TrimPrefix(containerName, "/"),
+					Hostname:       hostname,
+					Domain:         domain,
+					Subdomain:      subdomain,
+					Router:         router,
+					Entrypoints:    entrypoints,
+					CertResolver:   strings.TrimSpace(labels[routerPrefix+certResolverLabel]),
+					Destination:    strings.TrimSpace(labels[destinationLabel]),
+					ComposeProject: labels[composeProjectLabel],
+					ComposeService: labels[composeServiceLabel],
+				}
+				hosts = append(hosts, info)
+
+				log.Printf("Found host: %s (domain: %s, subdomain: %s) for container %s%s",
+					hostname, domain, subdomain, containerName, info.StackSuffix())
+			}
 		}
 	}
 
 	return hosts
 }
 
+// excludedHosts returns the hostnames and domains opted out via the exclude label
+func excludedHosts(labels map[string]string) map[string]bool {
+	excluded := make(map[string]bool)
+	for _, entry := range strings.Split(labels[excludeLabel], ",") {
+		if trimmed := strings.TrimSpace(entry); trimmed != "" {
+			if normalized, err := normalizeHostname(trimmed); err == nil {
+				trimmed = normalized
+			}
+			excluded[strings.ToLower(trimmed)] = true
+		}
+	}
+	return excluded
+}
+
+// withPublicEntrypoints drops hosts of routers that are only bound to non-public
+// entrypoints. Routers without entrypoints listen on all of them and are kept.
+func (w *Watcher) withPublicEntrypoints(hosts []HostInfo) []HostInfo {
+	if w.publicEntrypoints == nil {
+		return hosts
+	}
+
+	public := hosts[:0]
+	for _, info := range hosts {
+		if w.publiclyRouted(info) {
+			public = append(public, info)
+			continue
+		}
+		log.Printf("Skipping host %s of router %s (entrypoints %s are not public)", info.Hostname, info.Router, strings.Join(info.Entrypoints, ", "))
+	}
+	return public
+}
+
+// publiclyRouted reports whether the router of info listens on a public entrypoint
+func (w *Watcher) publiclyRouted(info HostInfo) bool {
+	if len(info.Entrypoints) == 0 {
+		return true
+	}
+	for _, entrypoint := range info.Entrypoints {
+		if w.publicEntrypoints[entrypoint] {
+			return true
+		}
+	}
+	return false
+}
+
 // splitHostname splits a hostname into domain and subdomain parts
 // e.g., "app.example.com" -> domain: "example.com", subdomain: "app"
 // e.g., "example.com" -> domain: "example.com", subdomain: "@"
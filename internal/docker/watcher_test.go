@@ -1,7 +1,17 @@
 package docker
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
 )
 
 func TestSplitHostname(t *testing.T) {
@@ -150,6 +160,22 @@ func TestExtractHostsFromLabels(t *testing.T) {
 				Subdomain:     "@",
 			},
 		},
+		{
+			name:          "host with port",
+			containerID:   "stu902",
+			containerName: "/port-container",
+			labels: map[string]string{
+				"traefik.http.routers.main.rule": "Host(`app.example.com:8443`) && Path(`/`)",
+			},
+			wantHosts: 1,
+			checkHost: &HostInfo{
+				ContainerID:   "stu902",
+				ContainerName: "port-container",
+				Hostname:      "app.example.com",
+				Domain:        "example.com",
+				Subdomain:     "app",
+			},
+		},
 		{
 			name:          "deep nested subdomain",
 			containerID:   "vwx234",
@@ -166,6 +192,70 @@ func TestExtractHostsFromLabels(t *testing.T) {
 				Subdomain:     "v1.api.app",
 			},
 		},
+		{
+			name:          "router opted out",
+			containerID:   "yza567",
+			containerName: "/opt-out-container",
+			labels: map[string]string{
+				"traefik.http.routers.public.rule":          "Host(`app.example.com`)",
+				"traefik.http.routers.internal.rule":        "Host(`internal.example.com`)",
+				"traefik.http.routers.internal.netcup.skip": "true",
+			},
+			wantHosts: 1,
+			checkHost: &HostInfo{
+				ContainerID:   "yza567",
+				ContainerName: "opt-out-container",
+				Hostname:      "app.example.com",
+				Domain:        "example.com",
+				Subdomain:     "app",
+				Router:        "public",
+			},
+		},
+		{
+			name:          "router opt-out disabled",
+			containerID:   "bcd890",
+			containerName: "/opt-in-container",
+			labels: map[string]string{
+				"traefik.http.routers.internal.rule":        "Host(`internal.example.com`)",
+				"traefik.http.routers.internal.netcup.skip": "false",
+			},
+			wantHosts: 1,
+		},
+		{
+			name:          "excluded hostnames and domains",
+			containerID:   "efg123",
+			containerName: "/excluded-container",
+			labels: map[string]string{
+				"traefik.http.routers.multi.rule": "Host(`app.example.com`) || Host(`internal.example.com`) || Host(`app.example.org`)",
+				"netcup.exclude":                  "internal.example.com, EXAMPLE.ORG",
+			},
+			wantHosts: 1,
+			checkHost: &HostInfo{
+				ContainerID:   "efg123",
+				ContainerName: "excluded-container",
+				Hostname:      "app.example.com",
+				Domain:        "example.com",
+				Subdomain:     "app",
+				Router:        "multi",
+			},
+		},
+		{
+			name:          "uppercase and internationalized hosts are normalized",
+			containerID:   "hij456",
+			containerName: "/idn-container",
+			labels: map[string]string{
+				"traefik.http.routers.shop.rule": "Host(`Shop.Bücher.de`) || Host(`-invalid.example.com`)",
+			},
+			wantHosts: 1,
+			checkHost: &HostInfo{
+				ContainerID:   "hij456",
+				ContainerName: "idn-container",
+				Hostname:      "shop.xn--bcher-kva.de",
+				Domain:        "xn--bcher-kva.de",
+				Subdomain:     "shop",
+				Router:        "shop",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -193,6 +283,9 @@ func TestExtractHostsFromLabels(t *testing.T) {
 						if host.Subdomain != tt.checkHost.Subdomain {
 							t.Errorf("Subdomain = %v, want %v", host.Subdomain, tt.checkHost.Subdomain)
 						}
+						if tt.checkHost.Router != "" && host.Router != tt.checkHost.Router {
+							t.Errorf("Router = %v, want %v", host.Router, tt.checkHost.Router)
+						}
 						break
 					}
 				}
@@ -228,6 +321,149 @@ func TestExtractHostsFromLabels_ContainerNameTrimming(t *testing.T) {
 	}
 }
 
+func TestExtractHostsFromLabels_ComposeProject(t *testing.T) {
+	labels := map[string]string{
+		"traefik.http.routers.shop.rule": "Host(`shop.example.com`)",
+		"com.docker.compose.project":     "shop",
+		"com.docker.compose.service":     "web",
+	}
+
+	hosts := extractHostsFromLabels("container123", "/shop-web-1", labels)
+	if len(hosts) != 1 {
+		t.Fatalf("Expected 1 host, got %d", len(hosts))
+	}
+	if hosts[0].ComposeProject != "shop" || hosts[0].ComposeService != "web" {
+		t.Errorf("Compose project/service = %q/%q, want shop/web", hosts[0].ComposeProject, hosts[0].ComposeService)
+	}
+}
+
+func TestExtractHostsFromLabels_Destination(t *testing.T) {
+	labels := map[string]string{
+		"traefik.http.routers.app.rule": "Host(`app.example.com`) || Host(`www.example.com`)",
+		"netcup.destination":            " cdn.example.net ",
+	}
+
+	hosts := extractHostsFromLabels("container123", "/app", labels)
+	if len(hosts) != 2 {
+		t.Fatalf("Expected 2 hosts, got %d", len(hosts))
+	}
+	for _, host := range hosts {
+		if host.Destination != "cdn.example.net" {
+			t.Errorf("Destination of %s = %q, want cdn.example.net", host.Hostname, host.Destination)
+		}
+	}
+}
+
+func TestWithPublicEntrypoints(t *testing.T) {
+	labels := map[string]string{
+		"traefik.http.routers.public.rule":          "Host(`app.example.com`)",
+		"traefik.http.routers.public.entrypoints":   "web, websecure",
+		"traefik.http.routers.internal.rule":        "Host(`app.internal.example.com`)",
+		"traefik.http.routers.internal.entrypoints": "lan",
+		"traefik.http.routers.default.rule":         "Host(`www.example.com`)",
+	}
+
+	tests := []struct {
+		name              string
+		publicEntrypoints map[string]bool
+		want              []string
+	}{
+		{
+			name: "all entrypoints public by default",
+			want: []string{"app.example.com", "app.internal.example.com", "www.example.com"},
+		},
+		{
+			name:              "only routers on public entrypoints",
+			publicEntrypoints: map[string]bool{"websecure": true},
+			want:              []string{"app.example.com", "www.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Watcher{publicEntrypoints: tt.publicEntrypoints}
+			hosts := w.withPublicEntrypoints(extractHostsFromLabels("container123", "/app", labels))
+
+			var got []string
+			for _, host := range hosts {
+				got = append(got, host.Hostname)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("hosts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeHostTracker keeps the hosts of each container in memory
+type fakeHostTracker map[string][]string
+
+func (f fakeHostTracker) SwapContainerHosts(container string, hostnames []string) ([]string, error) {
+	var dropped []string
+	for _, hostname := range f[container] {
+		if !slices.Contains(hostnames, hostname) {
+			dropped = append(dropped, hostname)
+		}
+	}
+	f[container] = hostnames
+	return dropped, nil
+}
+
+func TestDroppedHosts(t *testing.T) {
+	tracker := fakeHostTracker{}
+	w := &Watcher{hostTracker: tracker}
+	labels := map[string]string{"com.docker.compose.project": "shop"}
+
+	before := []HostInfo{{Hostname: "app.example.com"}, {Hostname: "old.example.com"}}
+	if removals := w.droppedHosts("tcp://a:2376", "/web", labels, before); len(removals) != 0 {
+		t.Errorf("droppedHosts() on first start = %+v, want none", removals)
+	}
+
+	after := []HostInfo{{Hostname: "app.example.com"}, {Hostname: "new.example.com"}}
+	removals := w.droppedHosts("tcp://a:2376", "/web", labels, after)
+	if len(removals) != 1 {
+		t.Fatalf("droppedHosts() after recreate = %+v, want one removal", removals)
+	}
+	want := HostInfo{ContainerName: "web", Hostname: "old.example.com", Domain: "example.com", Subdomain: "old", DockerHost: "tcp://a:2376", Remove: true, ComposeProject: "shop"}
+	if removals[0].Hostname != want.Hostname || removals[0].Domain != want.Domain || removals[0].Subdomain != want.Subdomain ||
+		!removals[0].Remove || removals[0].ContainerName != want.ContainerName || removals[0].DockerHost != want.DockerHost || removals[0].ComposeProject != want.ComposeProject {
+		t.Errorf("droppedHosts() = %+v, want %+v", removals[0], want)
+	}
+	if _, ok := tracker["tcp://a:2376/web"]; !ok {
+		t.Errorf("tracked containers = %v, want tcp://a:2376/web", tracker)
+	}
+
+	// Without a tracker nothing is withdrawn
+	if removals := (&Watcher{}).droppedHosts("", "/web", labels, nil); removals != nil {
+		t.Errorf("droppedHosts() without tracker = %+v, want nil", removals)
+	}
+}
+
+func TestHostInfo_Stack(t *testing.T) {
+	tests := []struct {
+		name       string
+		info       HostInfo
+		wantStack  string
+		wantSuffix string
+	}{
+		{name: "no compose", info: HostInfo{}, wantStack: "", wantSuffix: ""},
+		{name: "project only", info: HostInfo{ComposeProject: "shop"}, wantStack: "shop", wantSuffix: " [shop]"},
+		{name: "project and service", info: HostInfo{ComposeProject: "shop", ComposeService: "web"}, wantStack: "shop/web", wantSuffix: " [shop/web]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.Stack(); got != tt.wantStack {
+				t.Errorf("Stack() = %q, want %q", got, tt.wantStack)
+			}
+			if got := tt.info.StackSuffix(); got != tt.wantSuffix {
+				t.Errorf("StackSuffix() = %q, want %q", got, tt.wantSuffix)
+			}
+		})
+	}
+}
+
 func TestHostInfo(t *testing.T) {
 	// Test HostInfo struct creation
 	info := HostInfo{
@@ -254,3 +490,168 @@ func TestHostInfo(t *testing.T) {
 		t.Errorf("Subdomain = %v, want app", info.Subdomain)
 	}
 }
+
+func TestHealthFromStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{"Up 5 minutes (healthy)", "healthy"},
+		{"Up 3 seconds (health: starting)", "starting"},
+		{"Up 10 minutes (unhealthy)", "unhealthy"},
+		{"Up 2 hours", "none"},
+		{"", "none"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			if got := healthFromStatus(tt.status); got != tt.want {
+				t.Errorf("healthFromStatus(%q) = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleRemoval_CancelledOnRecovery(t *testing.T) {
+	w := &Watcher{
+		healthCheckGating:    true,
+		unhealthyGracePeriod: time.Hour,
+		pendingRemovals:      make(map[string]*pendingRemoval),
+	}
+
+	hostChan := make(chan HostInfo, 1)
+	hosts := []HostInfo{{ContainerID: "abc123", ContainerName: "app", Hostname: "app.example.com"}}
+
+	w.scheduleRemoval(context.Background(), &daemon{}, "abc123", hosts, hostChan)
+	// Scheduling twice must not create a second pending removal
+	w.scheduleRemoval(context.Background(), &daemon{}, "abc123", hosts, hostChan)
+
+	w.mu.Lock()
+	pending := len(w.pendingRemovals)
+	w.mu.Unlock()
+	if pending != 1 {
+		t.Fatalf("pendingRemovals = %d, want 1", pending)
+	}
+
+	w.cancelPendingRemoval("abc123")
+
+	w.mu.Lock()
+	pending = len(w.pendingRemovals)
+	w.mu.Unlock()
+	if pending != 0 {
+		t.Errorf("pendingRemovals after cancel = %d, want 0", pending)
+	}
+
+	select {
+	case info := <-hostChan:
+		t.Errorf("Unexpected removal emitted for %s", info.Hostname)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestNewWatcherWithOptions_MultipleHosts(t *testing.T) {
+	w, err := NewWatcherWithOptions("", &WatcherOptions{
+		Hosts: []string{"tcp://a.example.com:2376", "tcp://b.example.com:2376"},
+	})
+	if err != nil {
+		t.Fatalf("NewWatcherWithOptions() error = %v", err)
+	}
+	defer w.Close()
+
+	hosts := w.Hosts()
+	if len(hosts) != 2 || hosts[0] != "tcp://a.example.com:2376" || hosts[1] != "tcp://b.example.com:2376" {
+		t.Errorf("Hosts() = %v, want both configured daemons", hosts)
+	}
+}
+
+func TestNewWatcherWithOptions_InvalidHost(t *testing.T) {
+	if _, err := NewWatcherWithOptions("", &WatcherOptions{Hosts: []string{"not a host"}}); err == nil {
+		t.Error("NewWatcherWithOptions() error = nil, want error for invalid host")
+	}
+}
+
+func TestTagDockerHost(t *testing.T) {
+	hosts := tagDockerHost([]HostInfo{{Hostname: "a.example.com"}, {Hostname: "b.example.com"}}, "tcp://a:2376")
+	for _, host := range hosts {
+		if host.DockerHost != "tcp://a:2376" {
+			t.Errorf("DockerHost for %s = %q, want tcp://a:2376", host.Hostname, host.DockerHost)
+		}
+	}
+}
+
+// newContainerListServer serves a fake Docker API listing a single running container
+// with the labels returned by labels
+func newContainerListServer(t *testing.T, labels func() map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			w.Header().Set("Api-Version", "1.45")
+			w.Write([]byte("OK"))
+		case strings.HasSuffix(r.URL.Path, "/containers/json"):
+			json.NewEncoder(w).Encode([]container.Summary{{ID: "container123", Names: []string{"/web"}, Labels: labels()}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestScanContainerChanges_LabelChangedAcrossRestart(t *testing.T) {
+	labels := map[string]string{
+		"traefik.http.routers.web.rule": "Host(`app.example.com`) || Host(`old.example.com`)",
+	}
+	server := newContainerListServer(t, func() map[string]string { return labels })
+	host := "tcp://" + strings.TrimPrefix(server.URL, "http://")
+
+	// The tracker is persisted in state, so it outlives the watcher
+	tracker := fakeHostTracker{}
+	newWatcher := func() *Watcher {
+		w, err := NewWatcherWithOptions("", &WatcherOptions{Hosts: []string{host}, HostTracker: tracker})
+		if err != nil {
+			t.Fatalf("NewWatcherWithOptions() error = %v", err)
+		}
+		t.Cleanup(func() { w.Close() })
+		return w
+	}
+
+	hosts, err := newWatcher().ScanContainerChanges(context.Background())
+	if err != nil {
+		t.Fatalf("ScanContainerChanges() error = %v", err)
+	}
+	if len(hosts) != 2 {
+		t.Fatalf("ScanContainerChanges() on first start = %+v, want two hosts", hosts)
+	}
+
+	// The container is recreated without old.example.com while the companion is down
+	labels = map[string]string{"traefik.http.routers.web.rule": "Host(`app.example.com`)"}
+
+	hosts, err = newWatcher().ScanContainerChanges(context.Background())
+	if err != nil {
+		t.Fatalf("ScanContainerChanges() error = %v", err)
+	}
+	var removed, published []string
+	for _, info := range hosts {
+		if info.Remove {
+			removed = append(removed, info.Hostname)
+		} else {
+			published = append(published, info.Hostname)
+		}
+	}
+	if strings.Join(removed, ",") != "old.example.com" || strings.Join(published, ",") != "app.example.com" {
+		t.Errorf("ScanContainerChanges() removed %v and published %v, want old.example.com and app.example.com", removed, published)
+	}
+
+	// Plain scans neither report nor record changes
+	labels = map[string]string{}
+	hosts, err = newWatcher().ScanExistingContainers(context.Background())
+	if err != nil || len(hosts) != 0 {
+		t.Errorf("ScanExistingContainers() = %+v, %v, want no hosts", hosts, err)
+	}
+	if got := tracker[host+"/web"]; len(got) != 1 || got[0] != "app.example.com" {
+		t.Errorf("tracked hosts = %v, want [app.example.com]", got)
+	}
+}
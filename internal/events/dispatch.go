@@ -0,0 +1,81 @@
+package events
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+// workerQueueSize is the number of hosts buffered per worker
+const workerQueueSize = 16
+
+// Dispatcher publishes the hosts found by the watcher on the bus from a pool of workers.
+// Hosts are partitioned by hostname, so the events of a hostname are published in order
+// by a single worker while independent hosts are handled concurrently.
+type Dispatcher struct {
+	bus     *Bus
+	workers int
+}
+
+// NewDispatcher creates a dispatcher with the given number of workers, at least one
+func NewDispatcher(bus *Bus, workers int) *Dispatcher {
+	return &Dispatcher{bus: bus, workers: max(workers, 1)}
+}
+
+// Run publishes the hosts received on hosts with processCtx until ctx is done, then
+// waits for the hosts in flight. Hosts handed to a worker but not yet published are
+// returned, oldest first per hostname, so they can be drained or persisted.
+func (d *Dispatcher) Run(ctx, processCtx context.Context, hosts <-chan docker.HostInfo) []docker.HostInfo {
+	queues := make([]chan docker.HostInfo, d.workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan docker.HostInfo, workerQueueSize)
+		wg.Add(1)
+		go func(queue <-chan docker.HostInfo) {
+			defer wg.Done()
+			// Stop before the next host once ctx is done, leaving it for Run to return
+			for ctx.Err() == nil {
+				select {
+				case <-ctx.Done():
+					return
+				case info := <-queue:
+					d.bus.Publish(processCtx, ForHost(info))
+				}
+			}
+		}(queues[i])
+	}
+
+	var blocked []docker.HostInfo
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case info := <-hosts:
+			select {
+			case queues[partition(info.Hostname, len(queues))] <- info:
+			case <-ctx.Done():
+				blocked = append(blocked, info)
+				break loop
+			}
+		}
+	}
+	wg.Wait()
+
+	var unpublished []docker.HostInfo
+	for _, queue := range queues {
+		for len(queue) > 0 {
+			unpublished = append(unpublished, <-queue)
+		}
+	}
+	return append(unpublished, blocked...)
+}
+
+// partition returns the worker handling hostname
+func partition(hostname string, workers int) int {
+	h := fnv.New32a()
+	h.Write([]byte(hostname))
+	return int(h.Sum32() % uint32(workers))
+}
@@ -0,0 +1,119 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+func TestDispatcher_OrderPerHostname(t *testing.T) {
+	bus := NewBus()
+	var mu sync.Mutex
+	got := make(map[string][]string)
+	bus.Subscribe(func(ctx context.Context, event Event) {
+		var action string
+		var info docker.HostInfo
+		switch e := event.(type) {
+		case ContainerStarted:
+			action, info = "start", e.Host
+		case HostRemoved:
+			action, info = "remove", e.Host
+		}
+		mu.Lock()
+		got[info.Hostname] = append(got[info.Hostname], fmt.Sprintf("%s %s", action, info.ContainerID))
+		mu.Unlock()
+	})
+
+	hosts := make(chan docker.HostInfo)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan []docker.HostInfo)
+	go func() {
+		done <- NewDispatcher(bus, 4).Run(ctx, context.Background(), hosts)
+	}()
+
+	want := make(map[string][]string)
+	for i := 0; i < 10; i++ {
+		for _, hostname := range []string{"app.example.com", "www.example.com", "api.example.com"} {
+			info := docker.HostInfo{Hostname: hostname, ContainerID: fmt.Sprint(i), Remove: i%2 == 1}
+			action := "start"
+			if info.Remove {
+				action = "remove"
+			}
+			want[hostname] = append(want[hostname], fmt.Sprintf("%s %d", action, i))
+			hosts <- info
+		}
+	}
+
+	// Wait until every host was published before stopping
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		total := 0
+		for _, deliveries := range got {
+			total += len(deliveries)
+		}
+		mu.Unlock()
+		if total == 30 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if unpublished := <-done; len(unpublished) != 0 {
+		t.Errorf("Run() returned %d unpublished hosts, want none", len(unpublished))
+	}
+
+	for hostname, deliveries := range want {
+		if !slices.Equal(got[hostname], deliveries) {
+			t.Errorf("deliveries for %s = %v, want %v", hostname, got[hostname], deliveries)
+		}
+	}
+}
+
+func TestDispatcher_ReturnsUnpublished(t *testing.T) {
+	bus := NewBus()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	bus.Subscribe(func(ctx context.Context, event Event) {
+		close(started)
+		<-release
+	})
+
+	hosts := make(chan docker.HostInfo, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan []docker.HostInfo)
+	go func() {
+		done <- NewDispatcher(bus, 1).Run(ctx, context.Background(), hosts)
+	}()
+
+	// The first host blocks the only worker, the others wait in its queue
+	hosts <- docker.HostInfo{Hostname: "app.example.com"}
+	<-started
+	hosts <- docker.HostInfo{Hostname: "www.example.com"}
+	hosts <- docker.HostInfo{Hostname: "api.example.com"}
+	for len(hosts) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	close(release)
+
+	var hostnames []string
+	for _, info := range <-done {
+		hostnames = append(hostnames, info.Hostname)
+	}
+	if !slices.Equal(hostnames, []string{"www.example.com", "api.example.com"}) {
+		t.Errorf("Run() returned %v, want the queued hosts in order", hostnames)
+	}
+}
+
+func TestNewDispatcher_AtLeastOneWorker(t *testing.T) {
+	if d := NewDispatcher(NewBus(), 0); d.workers != 1 {
+		t.Errorf("workers = %d, want 1", d.workers)
+	}
+}
@@ -0,0 +1,118 @@
+// Package events provides an in-process event bus decoupling the Docker watcher, DNS
+// management and consumers such as notifications
+package events
+
+import (
+	"context"
+	"sync"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+// Event is one of the event types below
+type Event interface {
+	isEvent()
+}
+
+// ContainerStarted is published for every host of a container that should be published
+type ContainerStarted struct {
+	Host docker.HostInfo
+}
+
+// HostRemoved is published for a host whose records should be withdrawn, e.g. of an
+// unhealthy container or a hostname dropped from a recreated container
+type HostRemoved struct {
+	Host docker.HostInfo
+}
+
+// RecordCreated is published after a record was created for a host
+type RecordCreated struct {
+	Host docker.HostInfo
+	IP   string
+	Diff []Change
+}
+
+// RecordUpdated is published after the record of a host was pointed at a new address
+type RecordUpdated struct {
+	Host       docker.HostInfo
+	PreviousIP string
+	IP         string
+	Diff       []Change
+}
+
+// RecordRemoved is published after the records of a host were deleted
+type RecordRemoved struct {
+	Host docker.HostInfo
+	Diff []Change
+}
+
+// Change is a difference between the zone fetched before a record change and the zone
+// after it
+type Change struct {
+	Name   string // Subdomain of the record, empty for the zone TTL
+	Type   string // Record type, or TTL for the zone TTL
+	Before string // Empty for a created record
+	After  string // Empty for a deleted record
+}
+
+// RecordFailed is published when creating, updating or deleting the record of a host
+// failed. Host.Remove tells deletions apart.
+type RecordFailed struct {
+	Host docker.HostInfo
+	Err  error
+}
+
+// ReconcileCompleted is published after a reconciliation of the persisted records
+type ReconcileCompleted struct {
+	Synced  int // Records created or updated
+	InSync  int // Records that already matched
+	Errored int // Records that could not be reconciled
+}
+
+func (ContainerStarted) isEvent()   {}
+func (HostRemoved) isEvent()        {}
+func (RecordCreated) isEvent()      {}
+func (RecordUpdated) isEvent()      {}
+func (RecordRemoved) isEvent()      {}
+func (RecordFailed) isEvent()       {}
+func (ReconcileCompleted) isEvent() {}
+
+// ForHost returns the ContainerStarted or HostRemoved event for a host from the watcher
+func ForHost(info docker.HostInfo) Event {
+	if info.Remove {
+		return HostRemoved{Host: info}
+	}
+	return ContainerStarted{Host: info}
+}
+
+// Handler consumes events. Handlers ignore event types they are not interested in.
+type Handler func(ctx context.Context, event Event)
+
+// Bus delivers published events to all subscribers
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler for all events published afterwards
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish delivers event to every subscriber in the order they subscribed and returns
+// once all of them handled it. Handlers may publish further events.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(ctx, event)
+	}
+}
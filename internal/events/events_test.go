@@ -0,0 +1,57 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+func TestBus_Publish(t *testing.T) {
+	bus := NewBus()
+	ctx := context.Background()
+
+	var got []string
+	bus.Subscribe(func(ctx context.Context, event Event) {
+		got = append(got, fmt.Sprintf("first %T", event))
+		// Handlers may publish follow-up events
+		if started, ok := event.(ContainerStarted); ok {
+			bus.Publish(ctx, RecordCreated{Host: started.Host, IP: "203.0.113.1"})
+		}
+	})
+	bus.Subscribe(func(ctx context.Context, event Event) {
+		got = append(got, fmt.Sprintf("second %T", event))
+	})
+
+	bus.Publish(ctx, ContainerStarted{Host: docker.HostInfo{Hostname: "app.example.com"}})
+
+	want := []string{
+		"first events.ContainerStarted",
+		"first events.RecordCreated",
+		"second events.RecordCreated",
+		"second events.ContainerStarted",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("deliveries = %v, want %v", got, want)
+	}
+}
+
+func TestForHost(t *testing.T) {
+	tests := []struct {
+		name string
+		info docker.HostInfo
+		want string
+	}{
+		{name: "published host", info: docker.HostInfo{Hostname: "app.example.com"}, want: "events.ContainerStarted"},
+		{name: "withdrawn host", info: docker.HostInfo{Hostname: "app.example.com", Remove: true}, want: "events.HostRemoved"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprintf("%T", ForHost(tt.info)); got != tt.want {
+				t.Errorf("ForHost() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+// hostQueueInput is the number of hosts buffered before they reach the queue, covering
+// the hosts sent after shutdown started
+const hostQueueInput = 16
+
+// QueueStats describes the host queue between the watcher and the dispatcher
+type QueueStats struct {
+	Depth     int   `json:"depth"`     // Hosts currently queued
+	MaxDepth  int   `json:"max_depth"` // Most hosts queued at once
+	Received  int64 `json:"received"`  // Hosts received from the watcher
+	Coalesced int64 `json:"coalesced"` // Hosts replaced by a newer change of the same container
+	Dropped   int64 `json:"dropped"`   // Oldest hosts dropped because the queue was full
+}
+
+// HostQueue buffers the hosts found by the watcher for the dispatcher, so a stalled
+// pipeline, e.g. while the Netcup circuit breaker is open, never blocks the Docker event
+// stream. A change replaces a queued change of the same hostname and container, as only
+// the latest one matters. Beyond limit hosts, the oldest is dropped.
+type HostQueue struct {
+	in    chan docker.HostInfo
+	out   chan docker.HostInfo
+	limit int
+
+	mu      sync.Mutex
+	pending []docker.HostInfo
+	stats   QueueStats
+}
+
+// NewHostQueue creates a queue holding at most limit hosts; zero or less is unbounded
+func NewHostQueue(limit int) *HostQueue {
+	return &HostQueue{
+		in:    make(chan docker.HostInfo, hostQueueInput),
+		out:   make(chan docker.HostInfo),
+		limit: limit,
+	}
+}
+
+// In returns the channel the watcher sends hosts on
+func (q *HostQueue) In() chan<- docker.HostInfo {
+	return q.in
+}
+
+// Out returns the channel the dispatcher receives hosts from, oldest first
+func (q *HostQueue) Out() <-chan docker.HostInfo {
+	return q.out
+}
+
+// Run moves hosts from In to Out until ctx is done
+func (q *HostQueue) Run(ctx context.Context) {
+	for {
+		var out chan docker.HostInfo
+		var head docker.HostInfo
+		q.mu.Lock()
+		if len(q.pending) > 0 {
+			out, head = q.out, q.pending[0]
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case info := <-q.in:
+			q.push(info)
+		case out <- head:
+			q.mu.Lock()
+			q.pending = q.pending[1:]
+			q.stats.Depth = len(q.pending)
+			q.mu.Unlock()
+		}
+	}
+}
+
+// push queues info, replacing an older change of the same host and container
+func (q *HostQueue) push(info docker.HostInfo) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.stats.Received++
+	for i, queued := range q.pending {
+		if sameHost(queued, info) {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			q.stats.Coalesced++
+			break
+		}
+	}
+	if q.limit > 0 && len(q.pending) >= q.limit {
+		dropped := q.pending[0]
+		q.pending = q.pending[1:]
+		q.stats.Dropped++
+		log.Printf("Warning: Host queue is full (%d hosts), dropped the change of %s (%d dropped so far)", q.limit, dropped.Hostname, q.stats.Dropped)
+	}
+
+	q.pending = append(q.pending, info)
+	q.stats.Depth = len(q.pending)
+	q.stats.MaxDepth = max(q.stats.MaxDepth, q.stats.Depth)
+}
+
+// sameHost reports whether both are changes of the same hostname by the same container
+func sameHost(a, b docker.HostInfo) bool {
+	return a.Hostname == b.Hostname && a.DockerHost == b.DockerHost && a.ContainerName == b.ContainerName
+}
+
+// Drain returns the hosts still queued once Run has returned, oldest first, including
+// those sent on In meanwhile
+func (q *HostQueue) Drain() []docker.HostInfo {
+	for drained := false; !drained; {
+		select {
+		case info := <-q.in:
+			q.push(info)
+		default:
+			drained = true
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	pending := q.pending
+	q.pending = nil
+	q.stats.Depth = 0
+	return pending
+}
+
+// Stats returns the current queue statistics
+func (q *HostQueue) Stats() QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.stats
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+// runQueue runs q until the test ends and returns a function stopping it
+func runQueue(t *testing.T, q *HostQueue) (stop func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.Run(ctx)
+	}()
+	stop = func() {
+		cancel()
+		<-done
+	}
+	t.Cleanup(stop)
+	return stop
+}
+
+// waitForReceived waits until q has received n hosts
+func waitForReceived(t *testing.T, q *HostQueue, n int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for q.Stats().Received < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Queue received %d hosts, want %d", q.Stats().Received, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHostQueue_NeverBlocksSender(t *testing.T) {
+	q := NewHostQueue(0)
+	runQueue(t, q)
+
+	// Nobody reads Out, yet all sends complete
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for i := range 500 {
+			q.In() <- docker.HostInfo{Hostname: fmt.Sprintf("app%d.example.com", i)}
+		}
+	}()
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Sending to the queue blocked")
+	}
+	waitForReceived(t, q, 500)
+
+	if stats := q.Stats(); stats.Depth != 500 || stats.MaxDepth != 500 || stats.Dropped != 0 {
+		t.Errorf("Stats() = %+v, want 500 queued and nothing dropped", stats)
+	}
+	if info := <-q.Out(); info.Hostname != "app0.example.com" {
+		t.Errorf("First host = %s, want app0.example.com", info.Hostname)
+	}
+}
+
+func TestHostQueue_CoalescesAndDropsOldest(t *testing.T) {
+	q := NewHostQueue(2)
+	stop := runQueue(t, q)
+
+	q.In() <- docker.HostInfo{Hostname: "app.example.com", ContainerName: "app"}
+	q.In() <- docker.HostInfo{Hostname: "www.example.com", ContainerName: "web"}
+	// Replaces the start of app and moves behind www
+	q.In() <- docker.HostInfo{Hostname: "app.example.com", ContainerName: "app", Remove: true}
+	// Another container of the same hostname is kept apart, dropping www
+	q.In() <- docker.HostInfo{Hostname: "app.example.com", ContainerName: "app-2"}
+	waitForReceived(t, q, 4)
+	stop()
+
+	stats := q.Stats()
+	if stats.Coalesced != 1 || stats.Dropped != 1 {
+		t.Errorf("Stats() = %+v, want 1 coalesced and 1 dropped", stats)
+	}
+
+	pending := q.Drain()
+	if len(pending) != 2 {
+		t.Fatalf("Drain() = %+v, want 2 hosts", pending)
+	}
+	if pending[0].ContainerName != "app" || !pending[0].Remove || pending[1].ContainerName != "app-2" {
+		t.Errorf("Drain() = %+v, want the removal of app, then app-2", pending)
+	}
+	if stats := q.Stats(); stats.Depth != 0 {
+		t.Errorf("Depth after Drain() = %d, want 0", stats.Depth)
+	}
+}
@@ -0,0 +1,135 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+// Format identifies a supported export format
+type Format string
+
+const (
+	FormatExternalDNS Format = "external-dns"
+	FormatOctoDNS     Format = "octodns"
+)
+
+// ParseFormat validates a format name given on the command line
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatExternalDNS, FormatOctoDNS:
+		return Format(s), nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q (supported: %s, %s)", s, FormatExternalDNS, FormatOctoDNS)
+	}
+}
+
+// ExternalDNS renders records as an external-dns DNSEndpoint manifest
+func ExternalDNS(records []state.DNSRecord, ttl int) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("apiVersion: externaldns.k8s.io/v1alpha1\n")
+	buf.WriteString("kind: DNSEndpoint\n")
+	buf.WriteString("metadata:\n")
+	buf.WriteString("  name: netcup-companion\n")
+	buf.WriteString("spec:\n")
+
+	sorted := sortRecords(records)
+	if len(sorted) == 0 {
+		buf.WriteString("  endpoints: []\n")
+		return buf.Bytes()
+	}
+
+	buf.WriteString("  endpoints:\n")
+	for _, record := range sorted {
+		fmt.Fprintf(&buf, "    - dnsName: %s\n", quote(record.Hostname))
+		fmt.Fprintf(&buf, "      recordTTL: %d\n", ttl)
+		fmt.Fprintf(&buf, "      recordType: %s\n", quote(recordType(record)))
+		buf.WriteString("      targets:\n")
+		fmt.Fprintf(&buf, "        - %s\n", quote(record.IP))
+	}
+
+	return buf.Bytes()
+}
+
+// OctoDNS renders records as octoDNS YAML zone files keyed by domain
+func OctoDNS(records []state.DNSRecord, ttl int) map[string][]byte {
+	// domain -> record name -> records
+	zones := make(map[string]map[string][]state.DNSRecord)
+	for _, record := range sortRecords(records) {
+		if zones[record.Domain] == nil {
+			zones[record.Domain] = make(map[string][]state.DNSRecord)
+		}
+		name := record.Subdomain
+		if name == "@" {
+			name = ""
+		}
+		zones[record.Domain][name] = append(zones[record.Domain][name], record)
+	}
+
+	files := make(map[string][]byte, len(zones))
+	for domain, names := range zones {
+		var buf bytes.Buffer
+		buf.WriteString("---\n")
+
+		keys := make([]string, 0, len(names))
+		for name := range names {
+			keys = append(keys, name)
+		}
+		sort.Strings(keys)
+
+		for _, name := range keys {
+			fmt.Fprintf(&buf, "%s:\n", quote(name))
+			group := names[name]
+			if len(group) == 1 {
+				writeOctoDNSRecord(&buf, group[0], ttl, "  ", "  ")
+				continue
+			}
+			for _, record := range group {
+				writeOctoDNSRecord(&buf, record, ttl, "  - ", "    ")
+			}
+		}
+
+		files[domain] = buf.Bytes()
+	}
+
+	return files
+}
+
+// writeOctoDNSRecord writes a single record; first prefixes the first line (e.g. a list marker)
+func writeOctoDNSRecord(buf *bytes.Buffer, record state.DNSRecord, ttl int, first, indent string) {
+	fmt.Fprintf(buf, "%sttl: %d\n", first, ttl)
+	fmt.Fprintf(buf, "%stype: %s\n", indent, quote(recordType(record)))
+	fmt.Fprintf(buf, "%svalue: %s\n", indent, quote(record.IP))
+}
+
+func recordType(record state.DNSRecord) string {
+	if record.RecordType == "" {
+		return "A"
+	}
+	return record.RecordType
+}
+
+// sortRecords returns a copy of records ordered by hostname and type for stable, diffable output
+func sortRecords(records []state.DNSRecord) []state.DNSRecord {
+	sorted := make([]state.DNSRecord, len(records))
+	copy(sorted, records)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Hostname != sorted[j].Hostname {
+			return sorted[i].Hostname < sorted[j].Hostname
+		}
+		return sorted[i].RecordType < sorted[j].RecordType
+	})
+	return sorted
+}
+
+// quote returns s as a YAML scalar, single-quoting it when it would otherwise be ambiguous
+func quote(s string) string {
+	if s == "" || strings.ContainsAny(s, ":#&*!|>'\"%@`{}[], ") || strings.HasPrefix(s, "-") || strings.HasPrefix(s, "?") {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+	return s
+}
@@ -0,0 +1,134 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+var testRecords = []state.DNSRecord{
+	{Hostname: "www.example.com", Domain: "example.com", Subdomain: "www", IP: "203.0.113.1", RecordType: "A"},
+	{Hostname: "example.com", Domain: "example.com", Subdomain: "@", IP: "203.0.113.1", RecordType: "A"},
+	{Hostname: "api.other.de", Domain: "other.de", Subdomain: "api", IP: "198.51.100.7", RecordType: "A"},
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"external-dns", "octodns"} {
+		if _, err := ParseFormat(name); err != nil {
+			t.Errorf("ParseFormat(%q) error = %v", name, err)
+		}
+	}
+
+	if _, err := ParseFormat("bind"); err == nil {
+		t.Error("ParseFormat(\"bind\") error = nil, want error")
+	}
+}
+
+func TestExternalDNS(t *testing.T) {
+	want := `apiVersion: externaldns.k8s.io/v1alpha1
+kind: DNSEndpoint
+metadata:
+  name: netcup-companion
+spec:
+  endpoints:
+    - dnsName: api.other.de
+      recordTTL: 300
+      recordType: A
+      targets:
+        - 198.51.100.7
+    - dnsName: example.com
+      recordTTL: 300
+      recordType: A
+      targets:
+        - 203.0.113.1
+    - dnsName: www.example.com
+      recordTTL: 300
+      recordType: A
+      targets:
+        - 203.0.113.1
+`
+
+	if got := string(ExternalDNS(testRecords, 300)); got != want {
+		t.Errorf("ExternalDNS() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExternalDNS_Empty(t *testing.T) {
+	got := string(ExternalDNS(nil, 300))
+	if !strings.Contains(got, "endpoints: []") {
+		t.Errorf("ExternalDNS(nil) = %s, want empty endpoints list", got)
+	}
+}
+
+func TestOctoDNS(t *testing.T) {
+	files := OctoDNS(testRecords, 600)
+
+	if len(files) != 2 {
+		t.Fatalf("OctoDNS() returned %d zones, want 2", len(files))
+	}
+
+	wantExample := `---
+'':
+  ttl: 600
+  type: A
+  value: 203.0.113.1
+www:
+  ttl: 600
+  type: A
+  value: 203.0.113.1
+`
+	if got := string(files["example.com"]); got != wantExample {
+		t.Errorf("OctoDNS()[example.com] =\n%s\nwant\n%s", got, wantExample)
+	}
+
+	wantOther := `---
+api:
+  ttl: 600
+  type: A
+  value: 198.51.100.7
+`
+	if got := string(files["other.de"]); got != wantOther {
+		t.Errorf("OctoDNS()[other.de] =\n%s\nwant\n%s", got, wantOther)
+	}
+}
+
+func TestOctoDNS_MultipleRecordsPerName(t *testing.T) {
+	records := []state.DNSRecord{
+		{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app", IP: "203.0.113.1", RecordType: "A"},
+		{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app", IP: "2001:db8::1", RecordType: "AAAA"},
+	}
+
+	want := `---
+app:
+  - ttl: 300
+    type: A
+    value: 203.0.113.1
+  - ttl: 300
+    type: AAAA
+    value: '2001:db8::1'
+`
+	if got := string(OctoDNS(records, 300)["example.com"]); got != want {
+		t.Errorf("OctoDNS() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"app", "app"},
+		{"", "''"},
+		{"*", "'*'"},
+		{"2001:db8::1", "'2001:db8::1'"},
+		{"it's", "'it''s'"},
+		{"-dash", "'-dash'"},
+	}
+
+	for _, tt := range tests {
+		if got := quote(tt.in); got != tt.want {
+			t.Errorf("quote(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
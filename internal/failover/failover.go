@@ -0,0 +1,136 @@
+package failover
+
+import (
+	"context"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Monitor probes the primary destination and switches the active IP to the
+// secondary destination when the primary becomes unreachable (and back again
+// once it recovers).
+type Monitor struct {
+	primaryIP   string
+	secondaryIP string
+	probePort   int
+	interval    time.Duration
+	threshold   int // consecutive probe results required before switching
+
+	mu        sync.RWMutex
+	active    string
+	failures  int
+	successes int
+	onSwitch  func(from, to string)
+
+	// probe checks reachability of an address; replaceable for tests
+	probe func(ctx context.Context, addr string) error
+}
+
+func NewMonitor(primaryIP, secondaryIP string, probePort int, interval time.Duration, threshold int) *Monitor {
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	return &Monitor{
+		primaryIP:   primaryIP,
+		secondaryIP: secondaryIP,
+		probePort:   probePort,
+		interval:    interval,
+		threshold:   threshold,
+		active:      primaryIP,
+		probe:       tcpProbe,
+	}
+}
+
+// OnSwitch registers a callback invoked after the active IP changed
+func (m *Monitor) OnSwitch(fn func(from, to string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onSwitch = fn
+}
+
+// ActiveIP returns the destination records should currently point at
+func (m *Monitor) ActiveIP() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.active
+}
+
+// IsFailedOver reports whether the secondary destination is currently active
+func (m *Monitor) IsFailedOver() bool {
+	return m.ActiveIP() == m.secondaryIP
+}
+
+// Run probes the primary destination until the context is cancelled
+func (m *Monitor) Run(ctx context.Context) {
+	log.Printf("Failover monitor started: primary %s, secondary %s, probing port %d every %s",
+		m.primaryIP, m.secondaryIP, m.probePort, m.interval)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check runs a single probe against the primary and switches the active IP if needed
+func (m *Monitor) check(ctx context.Context) {
+	addr := net.JoinHostPort(m.primaryIP, strconv.Itoa(m.probePort))
+	err := m.probe(ctx, addr)
+
+	m.mu.Lock()
+	var from, to string
+	if err != nil {
+		m.successes = 0
+		m.failures++
+		log.Printf("Failover probe to %s failed (%d/%d): %v", addr, m.failures, m.threshold, err)
+		if m.active == m.primaryIP && m.failures >= m.threshold {
+			from, to = m.active, m.secondaryIP
+		}
+	} else {
+		m.failures = 0
+		if m.active == m.secondaryIP {
+			m.successes++
+			log.Printf("Failover probe to %s succeeded (%d/%d)", addr, m.successes, m.threshold)
+			if m.successes >= m.threshold {
+				from, to = m.active, m.primaryIP
+			}
+		}
+	}
+
+	if to == "" {
+		m.mu.Unlock()
+		return
+	}
+
+	m.active = to
+	m.failures = 0
+	m.successes = 0
+	onSwitch := m.onSwitch
+	m.mu.Unlock()
+
+	log.Printf("Failover: switching active destination %s -> %s", from, to)
+	if onSwitch != nil {
+		onSwitch(from, to)
+	}
+}
+
+// tcpProbe checks whether a TCP connection to addr can be established
+func tcpProbe(ctx context.Context, addr string) error {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -0,0 +1,105 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestMonitor(results *[]error) *Monitor {
+	m := NewMonitor("203.0.113.1", "198.51.100.1", 443, time.Second, 2)
+	m.probe = func(ctx context.Context, addr string) error {
+		if addr != "203.0.113.1:443" {
+			return errors.New("unexpected probe address " + addr)
+		}
+		err := (*results)[0]
+		*results = (*results)[1:]
+		return err
+	}
+	return m
+}
+
+func TestNewMonitor_Defaults(t *testing.T) {
+	m := NewMonitor("203.0.113.1", "198.51.100.1", 443, time.Second, 0)
+
+	if m.ActiveIP() != "203.0.113.1" {
+		t.Errorf("ActiveIP() = %v, want primary", m.ActiveIP())
+	}
+	if m.IsFailedOver() {
+		t.Error("IsFailedOver() = true, want false")
+	}
+	if m.threshold != 1 {
+		t.Errorf("threshold = %d, want 1 for non-positive input", m.threshold)
+	}
+}
+
+func TestCheck_FailoverAndFailback(t *testing.T) {
+	probeErr := errors.New("connection refused")
+	results := []error{probeErr, nil, probeErr, probeErr, nil, nil}
+	m := newTestMonitor(&results)
+
+	var switches [][2]string
+	m.OnSwitch(func(from, to string) {
+		switches = append(switches, [2]string{from, to})
+	})
+
+	ctx := context.Background()
+
+	// A single failure followed by a success must not trigger failover
+	m.check(ctx)
+	m.check(ctx)
+	if m.IsFailedOver() {
+		t.Fatal("Failed over after a single failure")
+	}
+
+	// Two consecutive failures reach the threshold
+	m.check(ctx)
+	m.check(ctx)
+	if !m.IsFailedOver() {
+		t.Fatal("Expected failover after consecutive failures")
+	}
+	if m.ActiveIP() != "198.51.100.1" {
+		t.Errorf("ActiveIP() = %v, want secondary", m.ActiveIP())
+	}
+
+	// Two consecutive successes fail back to the primary
+	m.check(ctx)
+	if !m.IsFailedOver() {
+		t.Fatal("Failed back after a single success")
+	}
+	m.check(ctx)
+	if m.IsFailedOver() {
+		t.Fatal("Expected failback after consecutive successes")
+	}
+
+	if len(switches) != 2 {
+		t.Fatalf("OnSwitch called %d times, want 2", len(switches))
+	}
+	if switches[0] != [2]string{"203.0.113.1", "198.51.100.1"} {
+		t.Errorf("First switch = %v, want primary -> secondary", switches[0])
+	}
+	if switches[1] != [2]string{"198.51.100.1", "203.0.113.1"} {
+		t.Errorf("Second switch = %v, want secondary -> primary", switches[1])
+	}
+}
+
+func TestRun_StopsOnContextCancel(t *testing.T) {
+	m := NewMonitor("203.0.113.1", "198.51.100.1", 443, 10*time.Millisecond, 1)
+	m.probe = func(ctx context.Context, addr string) error { return nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not return after context cancellation")
+	}
+}
@@ -0,0 +1,175 @@
+// Package forward hands hosts between companions sharing domains, so that each zone is
+// written by the single instance owning it (OWNED_ZONES). Instances POST hosts outside
+// their zones to the owner's forward API, which publishes them like its own containers.
+package forward
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+// maxRequestSize limits the body of forwarded hosts
+const maxRequestSize = 4 << 10
+
+// ErrNotOwned is returned when a host is forwarded to an instance not owning its zone
+var ErrNotOwned = errors.New("zone is not owned by this instance")
+
+// Host is a host forwarded to the instance owning its zone
+type Host struct {
+	Instance  string `json:"instance"` // Name of the forwarding instance, keeps origins apart
+	Hostname  string `json:"hostname"`
+	Domain    string `json:"domain"`
+	Subdomain string `json:"subdomain"`
+	IP        string `json:"ip,omitempty"` // Address to publish, resolved by the forwarding instance
+	Remove    bool   `json:"remove,omitempty"`
+
+	SRV    []docker.SRVRecord `json:"srv,omitempty"`     // SRV records declared below the hostname
+	DryRun bool               `json:"dry_run,omitempty"` // Only log the changes, as labeled on the container
+
+	// Origin of the host on the forwarding instance
+	DockerHost     string `json:"docker_host,omitempty"`
+	Container      string `json:"container,omitempty"`
+	ComposeProject string `json:"compose_project,omitempty"`
+	ComposeService string `json:"compose_service,omitempty"`
+
+	Tags []string `json:"tags,omitempty"` // Tags labeled on the container
+}
+
+// NewHost describes info for forwarding from instance, pointing at ip
+func NewHost(instance string, info docker.HostInfo, ip string) Host {
+	return Host{
+		Instance:       instance,
+		Hostname:       info.Hostname,
+		Domain:         info.Domain,
+		Subdomain:      info.Subdomain,
+		IP:             ip,
+		Remove:         info.Remove,
+		SRV:            info.SRV,
+		DryRun:         info.DryRun,
+		DockerHost:     info.DockerHost,
+		Container:      info.ContainerName,
+		ComposeProject: info.ComposeProject,
+		ComposeService: info.ComposeService,
+		Tags:           info.Tags,
+	}
+}
+
+// HostInfo returns the host as published by the receiving instance. The Docker host is
+// prefixed with the forwarding instance, so containers of different instances never
+// share ownership of a record.
+func (h Host) HostInfo() docker.HostInfo {
+	return docker.HostInfo{
+		Hostname:       h.Hostname,
+		Domain:         h.Domain,
+		Subdomain:      h.Subdomain,
+		IP:             h.IP,
+		Remove:         h.Remove,
+		SRV:            h.SRV,
+		DryRun:         h.DryRun,
+		DockerHost:     h.Instance + "/" + h.DockerHost,
+		ContainerName:  h.Container,
+		ComposeProject: h.ComposeProject,
+		ComposeService: h.ComposeService,
+		Tags:           h.Tags,
+	}
+}
+
+// Client sends hosts to the forward API of the owning instance
+type Client struct {
+	URL    string // Base URL of the owner's forward API, e.g. http://companion-a:8081
+	Token  string // Shared bearer token, optional
+	Client *http.Client
+}
+
+// Send forwards host to the owning instance
+func (c *Client) Send(ctx context.Context, host Host) error {
+	body, err := json.Marshal(host)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.URL, "/")+"/hosts", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to forward %s: %w", host.Hostname, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to forward %s: %s: %s", host.Hostname, resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// Hosts publishes forwarded hosts
+type Hosts interface {
+	OwnsZone(domain string) bool
+	ProcessHostInfo(ctx context.Context, info docker.HostInfo) error
+}
+
+// NewHandler returns a handler serving POST /hosts. Requests need the bearer token if
+// one is given. Hosts outside the zones of hosts are refused with 409 instead of being
+// forwarded again, so misconfigured instances cannot loop.
+func NewHandler(hosts Hosts, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /hosts", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, token) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var host Host
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&host); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if host.Instance == "" || host.Hostname == "" || host.Domain == "" || host.Subdomain == "" {
+			http.Error(w, "instance, hostname, domain and subdomain are required", http.StatusBadRequest)
+			return
+		}
+		if !hosts.OwnsZone(host.Domain) {
+			http.Error(w, fmt.Sprintf("%s: %v", host.Domain, ErrNotOwned), http.StatusConflict)
+			return
+		}
+
+		log.Printf("Received %s forwarded by %s", host.Hostname, host.Instance)
+		if err := hosts.ProcessHostInfo(r.Context(), host.HostInfo()); err != nil {
+			log.Printf("Forwarded host %s from %s failed: %v", host.Hostname, host.Instance, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+// authorized checks the bearer token of r, if one is configured
+func authorized(r *http.Request, token string) bool {
+	if token == "" {
+		return true
+	}
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
@@ -0,0 +1,82 @@
+package forward
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+type fakeHosts struct {
+	zones     map[string]bool
+	processed []docker.HostInfo
+}
+
+func (f *fakeHosts) OwnsZone(domain string) bool {
+	return f.zones[domain]
+}
+
+func (f *fakeHosts) ProcessHostInfo(ctx context.Context, info docker.HostInfo) error {
+	f.processed = append(f.processed, info)
+	return nil
+}
+
+func TestClientAndHandler(t *testing.T) {
+	hosts := &fakeHosts{zones: map[string]bool{"example.com": true}}
+	server := httptest.NewServer(NewHandler(hosts, "secret"))
+	t.Cleanup(server.Close)
+
+	info := docker.HostInfo{
+		Hostname:      "app.example.com",
+		Domain:        "example.com",
+		Subdomain:     "app",
+		DockerHost:    "local",
+		ContainerName: "app",
+	}
+	client := &Client{URL: server.URL + "/", Token: "secret"}
+	if err := client.Send(context.Background(), NewHost("host-b", info, "198.51.100.2")); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if len(hosts.processed) != 1 {
+		t.Fatalf("Processed %d hosts, want 1", len(hosts.processed))
+	}
+	got := hosts.processed[0]
+	if got.Hostname != "app.example.com" || got.IP != "198.51.100.2" || got.DockerHost != "host-b/local" || got.ContainerName != "app" {
+		t.Errorf("Processed %+v, want app.example.com -> 198.51.100.2 from host-b/local", got)
+	}
+}
+
+func TestHandler_Refusals(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		body       string
+		wantStatus int
+	}{
+		{name: "wrong token", token: "wrong", body: `{"instance":"b","hostname":"app.example.com","domain":"example.com","subdomain":"app"}`, wantStatus: http.StatusUnauthorized},
+		{name: "missing fields", token: "secret", body: `{"hostname":"app.example.com"}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid body", token: "secret", body: `not json`, wantStatus: http.StatusBadRequest},
+		{name: "zone not owned", token: "secret", body: `{"instance":"b","hostname":"app.other.org","domain":"other.org","subdomain":"app"}`, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts := &fakeHosts{zones: map[string]bool{"example.com": true}}
+			req := httptest.NewRequest(http.MethodPost, "/hosts", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			NewHandler(hosts, "secret").ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if len(hosts.processed) != 0 {
+				t.Errorf("Processed %v, want nothing", hosts.processed)
+			}
+		})
+	}
+}
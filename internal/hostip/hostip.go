@@ -0,0 +1,132 @@
+package hostip
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// settleDelay is how long the monitor waits after an interface change before detecting
+// the address, since reconnects often change addresses and routes in several steps
+const settleDelay = 2 * time.Second
+
+// errWatchUnsupported is returned by watchInterfaces on platforms without netlink
+var errWatchUnsupported = errors.New("interface monitoring is only supported on Linux")
+
+// Monitor re-detects the host IP periodically and, on Linux, as soon as an interface
+// address or route changes, and reports changes of the detected address.
+type Monitor struct {
+	interval time.Duration
+	detect   func() (string, error)
+
+	mu       sync.RWMutex
+	current  string
+	onChange func(from, to string)
+
+	// watch signals interface changes on changes until ctx is done; replaceable for tests
+	watch func(ctx context.Context, changes chan<- struct{}) error
+	// settle is the delay between an interface change and detection
+	settle time.Duration
+}
+
+// NewMonitor creates a monitor detecting the host IP with detect every interval.
+// With watch, interface changes trigger a detection immediately. A non-positive
+// interval disables polling.
+func NewMonitor(detect func() (string, error), interval time.Duration, watch bool) *Monitor {
+	m := &Monitor{
+		interval: interval,
+		detect:   detect,
+		settle:   settleDelay,
+	}
+	if watch {
+		m.watch = watchInterfaces
+	}
+	return m
+}
+
+// OnChange registers a callback invoked after the detected host IP changed
+func (m *Monitor) OnChange(fn func(from, to string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onChange = fn
+}
+
+// CurrentIP returns the last detected host IP, or "" before the first detection
+func (m *Monitor) CurrentIP() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.current
+}
+
+// Run detects the host IP until the context is cancelled
+func (m *Monitor) Run(ctx context.Context) {
+	changes := make(chan struct{}, 1)
+	if m.watch != nil {
+		go func() {
+			if err := m.watch(ctx, changes); err != nil && ctx.Err() == nil {
+				log.Printf("Warning: Host IP interface monitoring stopped, falling back to polling: %v", err)
+			}
+		}()
+	}
+
+	var tick <-chan time.Time
+	if m.interval > 0 {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	log.Printf("Host IP monitor started: polling every %s, interface monitoring %t", m.interval, m.watch != nil)
+
+	for {
+		m.check()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case <-changes:
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(m.settle):
+			}
+			// Changes signalled while settling are covered by this detection
+			select {
+			case <-changes:
+			default:
+			}
+		}
+	}
+}
+
+// check detects the host IP once and reports a change
+func (m *Monitor) check() {
+	ip, err := m.detect()
+	if err != nil {
+		log.Printf("Warning: Failed to detect host IP: %v", err)
+		return
+	}
+
+	m.mu.Lock()
+	from := m.current
+	if from == ip {
+		m.mu.Unlock()
+		return
+	}
+	m.current = ip
+	onChange := m.onChange
+	m.mu.Unlock()
+
+	// The first detection establishes the address and is no change
+	if from == "" {
+		return
+	}
+
+	log.Printf("Host IP changed %s -> %s", from, ip)
+	if onChange != nil {
+		onChange(from, ip)
+	}
+}
@@ -0,0 +1,117 @@
+package hostip
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDetector returns the configured address, counting detections
+type fakeDetector struct {
+	mu    sync.Mutex
+	ip    string
+	err   error
+	calls int
+}
+
+func (d *fakeDetector) detect() (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.calls++
+	return d.ip, d.err
+}
+
+func (d *fakeDetector) set(ip string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ip = ip
+}
+
+func TestCheck(t *testing.T) {
+	detector := &fakeDetector{ip: "203.0.113.1"}
+	m := NewMonitor(detector.detect, time.Minute, false)
+
+	var changes [][2]string
+	m.OnChange(func(from, to string) {
+		changes = append(changes, [2]string{from, to})
+	})
+
+	// The first detection only establishes the address
+	m.check()
+	if m.CurrentIP() != "203.0.113.1" || len(changes) != 0 {
+		t.Fatalf("CurrentIP() = %q, changes = %v, want 203.0.113.1 without changes", m.CurrentIP(), changes)
+	}
+
+	m.check()
+	if len(changes) != 0 {
+		t.Fatalf("changes = %v, want none for the same address", changes)
+	}
+
+	// Detection failures keep the last address
+	detector.err = errors.New("network unreachable")
+	m.check()
+	if m.CurrentIP() != "203.0.113.1" || len(changes) != 0 {
+		t.Fatalf("CurrentIP() = %q, changes = %v after failed detection", m.CurrentIP(), changes)
+	}
+	detector.err = nil
+
+	detector.set("203.0.113.2")
+	m.check()
+	if m.CurrentIP() != "203.0.113.2" {
+		t.Errorf("CurrentIP() = %q, want 203.0.113.2", m.CurrentIP())
+	}
+	if len(changes) != 1 || changes[0] != [2]string{"203.0.113.1", "203.0.113.2"} {
+		t.Errorf("changes = %v, want one change 203.0.113.1 -> 203.0.113.2", changes)
+	}
+}
+
+func TestRun_InterfaceChangeTriggersDetection(t *testing.T) {
+	detector := &fakeDetector{ip: "203.0.113.1"}
+	m := NewMonitor(detector.detect, 0, false)
+	m.settle = 10 * time.Millisecond
+
+	trigger := make(chan struct{})
+	m.watch = func(ctx context.Context, changes chan<- struct{}) error {
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-trigger:
+				signal(changes)
+			}
+		}
+	}
+
+	changed := make(chan string, 1)
+	m.OnChange(func(from, to string) {
+		changed <- to
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx)
+
+	deadline := time.After(5 * time.Second)
+	for m.CurrentIP() == "" {
+		select {
+		case <-deadline:
+			t.Fatal("initial detection did not happen")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	// Polling is disabled, so only the interface change can pick up the new address
+	detector.set("203.0.113.2")
+	trigger <- struct{}{}
+
+	select {
+	case to := <-changed:
+		if to != "203.0.113.2" {
+			t.Errorf("changed to %q, want 203.0.113.2", to)
+		}
+	case <-deadline:
+		t.Fatal("interface change did not trigger a detection")
+	}
+}
@@ -0,0 +1,71 @@
+package hostip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// rtnetlink multicast groups from linux/rtnetlink.h, missing from package syscall
+const (
+	rtmgrpIPv4IfAddr = 0x10
+	rtmgrpIPv4Route  = 0x40
+)
+
+// watchInterfaces subscribes to rtnetlink address and route notifications and signals
+// on changes whenever an IPv4 address or route is added or removed
+func watchInterfaces(ctx context.Context, changes chan<- struct{}) error {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return fmt.Errorf("failed to open netlink socket: %w", err)
+	}
+	defer syscall.Close(fd)
+
+	groups := uint32(rtmgrpIPv4IfAddr | rtmgrpIPv4Route)
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: groups}); err != nil {
+		return fmt.Errorf("failed to subscribe to netlink notifications: %w", err)
+	}
+
+	// Time out reads regularly, so cancellation is noticed without closing the socket
+	timeout := syscall.Timeval{Sec: 1}
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &timeout); err != nil {
+		return fmt.Errorf("failed to set netlink read timeout: %w", err)
+	}
+
+	buf := make([]byte, syscall.Getpagesize())
+	for ctx.Err() == nil {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			if errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR) {
+				continue
+			}
+			// Notifications were dropped, the address may have changed meanwhile
+			if errors.Is(err, syscall.ENOBUFS) {
+				signal(changes)
+				continue
+			}
+			return fmt.Errorf("failed to read netlink notification: %w", err)
+		}
+
+		messages, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return fmt.Errorf("failed to parse netlink notification: %w", err)
+		}
+		for _, message := range messages {
+			switch message.Header.Type {
+			case syscall.RTM_NEWADDR, syscall.RTM_DELADDR, syscall.RTM_NEWROUTE, syscall.RTM_DELROUTE:
+				signal(changes)
+			}
+		}
+	}
+	return nil
+}
+
+// signal notifies changes without blocking; a pending signal already covers this one
+func signal(changes chan<- struct{}) {
+	select {
+	case changes <- struct{}{}:
+	default:
+	}
+}
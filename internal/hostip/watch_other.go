@@ -0,0 +1,10 @@
+//go:build !linux
+
+package hostip
+
+import "context"
+
+// watchInterfaces is not available without netlink, the monitor keeps polling
+func watchInterfaces(ctx context.Context, changes chan<- struct{}) error {
+	return errWatchUnsupported
+}
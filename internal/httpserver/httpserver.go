@@ -0,0 +1,24 @@
+// Package httpserver serves the HTTP APIs of the companion, such as the ACME challenge
+// and forward APIs, with shared timeouts and shutdown.
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Serve serves handler on addr until ctx is done
+func Serve(ctx context.Context, addr string, handler http.Handler) error {
+	server := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
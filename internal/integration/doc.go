@@ -0,0 +1,8 @@
+// Package integration holds end-to-end tests that start real containers on the local
+// Docker daemon and run them through the watcher, the DNS manager and a fake Netcup
+// endpoint. They are excluded from regular test runs; run them with
+//
+//	go test -tags integration ./internal/integration
+//
+// Tests are skipped when no Docker daemon is reachable.
+package integration
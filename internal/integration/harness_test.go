@@ -0,0 +1,142 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+)
+
+// testImage is started for every test container; it only needs to keep running
+const testImage = "busybox:latest"
+
+// runLabel marks the containers of a test run, so watchers only see their own containers
+const runLabel = "netcup-companion.integration"
+
+// harness starts labelled containers on the local Docker daemon and removes them when
+// the test ends
+type harness struct {
+	t      *testing.T
+	client *client.Client
+	run    string // Value of runLabel on the containers of this test
+}
+
+// newHarness connects to the daemon from the environment, skipping the test if it is
+// not reachable, and makes sure the test image is present
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Skipf("Docker client unavailable: %v", err)
+	}
+	t.Cleanup(func() { cli.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := cli.Ping(ctx); err != nil {
+		t.Skipf("Docker daemon unreachable: %v", err)
+	}
+
+	h := &harness{
+		t:      t,
+		client: cli,
+		run:    fmt.Sprintf("%s-%d", strings.ReplaceAll(strings.ToLower(t.Name()), "/", "-"), time.Now().UnixNano()),
+	}
+	h.pullImage()
+	return h
+}
+
+// pullImage pulls the test image unless it is present already
+func (h *harness) pullImage() {
+	h.t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if _, err := h.client.ImageInspect(ctx, testImage); err == nil {
+		return
+	}
+
+	reader, err := h.client.ImagePull(ctx, testImage, image.PullOptions{})
+	if err != nil {
+		h.t.Fatalf("Failed to pull %s: %v", testImage, err)
+	}
+	defer reader.Close()
+	// The pull completes once the progress stream ends
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		h.t.Fatalf("Failed to pull %s: %v", testImage, err)
+	}
+}
+
+// filterLabel returns the DOCKER_FILTER_LABEL selecting the containers of this test
+func (h *harness) filterLabel() string {
+	return runLabel + "=" + h.run
+}
+
+// startContainer starts a container with the given labels and environment and returns
+// its ID. The container is removed when the test ends.
+func (h *harness) startContainer(name string, labels map[string]string, env []string) string {
+	h.t.Helper()
+	ctx := context.Background()
+
+	allLabels := map[string]string{runLabel: h.run}
+	for key, value := range labels {
+		allLabels[key] = value
+	}
+
+	created, err := h.client.ContainerCreate(ctx, &container.Config{
+		Image:  testImage,
+		Cmd:    []string{"sleep", "300"},
+		Labels: allLabels,
+		Env:    env,
+	}, nil, nil, nil, h.run+"-"+name)
+	if err != nil {
+		h.t.Fatalf("Failed to create container %s: %v", name, err)
+	}
+	h.t.Cleanup(func() {
+		h.client.ContainerRemove(context.Background(), created.ID, container.RemoveOptions{Force: true})
+	})
+
+	if err := h.client.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+		h.t.Fatalf("Failed to start container %s: %v", name, err)
+	}
+	return created.ID
+}
+
+// newWatcher creates a watcher for the containers of this test
+func (h *harness) newWatcher(opts *docker.WatcherOptions) *docker.Watcher {
+	h.t.Helper()
+
+	if opts == nil {
+		opts = &docker.WatcherOptions{}
+	}
+	watcher, err := docker.NewWatcherWithOptions(h.filterLabel(), opts)
+	if err != nil {
+		h.t.Fatalf("Failed to create watcher: %v", err)
+	}
+	h.t.Cleanup(func() { watcher.Close() })
+	return watcher
+}
+
+// waitFor polls cond until it holds or the timeout expires
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,150 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/config"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/dns"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/netcup/netcuptest"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		CustomerNumber: 12345,
+		APIKey:         "test-key",
+		APIPassword:    "test-password",
+		DefaultTTL:     "300",
+		HostIP:         "203.0.113.1",
+	}
+}
+
+// hostnames returns the sorted hostnames of hosts
+func hostnames(hosts []docker.HostInfo) []string {
+	var names []string
+	for _, info := range hosts {
+		names = append(names, info.Hostname)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestScanExistingContainers(t *testing.T) {
+	h := newHarness(t)
+	id := h.startContainer("shop", map[string]string{
+		"traefik.http.routers.shop.rule":         "Host(`Shop.example.com`) || Host(`www.example.com`)",
+		"traefik.http.routers.admin.rule":        "Host(`admin.example.com`)",
+		"traefik.http.routers.admin.netcup.skip": "true",
+		"netcup.exclude":                         "www.example.com",
+		"netcup.destination":                     "203.0.113.7",
+		"com.docker.compose.project":             "shop",
+		"com.docker.compose.service":             "web",
+	}, []string{"VIRTUAL_HOST=api.example.com"})
+	h.startContainer("unlabelled", nil, nil)
+
+	watcher := h.newWatcher(&docker.WatcherOptions{HostEnvVars: []string{"VIRTUAL_HOST"}})
+	hosts, err := watcher.ScanExistingContainers(context.Background())
+	if err != nil {
+		t.Fatalf("ScanExistingContainers() error = %v", err)
+	}
+
+	if got, want := hostnames(hosts), []string{"api.example.com", "shop.example.com"}; !slices.Equal(got, want) {
+		t.Fatalf("hosts = %v, want %v", got, want)
+	}
+	for _, info := range hosts {
+		if info.ContainerID != id || info.Domain != "example.com" || info.Destination != "203.0.113.7" {
+			t.Errorf("host %s = %+v, want container %s, domain example.com and destination 203.0.113.7", info.Hostname, info, id)
+		}
+		if info.ComposeProject != "shop" || info.ComposeService != "web" {
+			t.Errorf("host %s stack = %s, want shop/web", info.Hostname, info.Stack())
+		}
+	}
+}
+
+func TestPipeline_InitialSync(t *testing.T) {
+	h := newHarness(t)
+	h.startContainer("app", map[string]string{
+		"traefik.http.routers.app.rule": "Host(`app.example.com`)",
+	}, nil)
+	h.startContainer("api", map[string]string{
+		"traefik.http.routers.api.rule": "Host(`api.example.com`)",
+	}, nil)
+
+	server := netcuptest.NewServer(nil)
+	defer server.Close()
+	server.API.AddZone("example.com")
+	manager := dns.NewManager(testConfig(), server.NewClient(nil), nil)
+
+	watcher := h.newWatcher(nil)
+	hosts, err := watcher.ScanExistingContainers(context.Background())
+	if err != nil {
+		t.Fatalf("ScanExistingContainers() error = %v", err)
+	}
+	if err := manager.SyncHosts(context.Background(), hosts); err != nil {
+		t.Fatalf("SyncHosts() error = %v", err)
+	}
+
+	records := server.API.Records("example.com")
+	var got []string
+	for _, record := range records {
+		if record.Type == "A" && record.Destination == "203.0.113.1" {
+			got = append(got, record.Hostname)
+		}
+	}
+	sort.Strings(got)
+	if want := []string{"api", "app"}; !slices.Equal(got, want) {
+		t.Errorf("A records = %v, want %v", got, want)
+	}
+	if n := server.Requests("updateDnsRecords"); n != 1 {
+		t.Errorf("updateDnsRecords requests = %d, want a single update for the zone", n)
+	}
+}
+
+func TestPipeline_ContainerStartEvent(t *testing.T) {
+	h := newHarness(t)
+
+	server := netcuptest.NewServer(nil)
+	defer server.Close()
+	server.API.AddZone("example.com")
+	manager := dns.NewManager(testConfig(), server.NewClient(nil), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher := h.newWatcher(nil)
+	hostChan := make(chan docker.HostInfo, 10)
+	go watcher.WatchEvents(ctx, hostChan)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case info := <-hostChan:
+				if err := manager.ProcessHostInfo(ctx, info); err != nil {
+					t.Errorf("ProcessHostInfo(%s) error = %v", info.Hostname, err)
+				}
+			}
+		}
+	}()
+
+	// Give the event stream a moment to subscribe before the container starts
+	time.Sleep(500 * time.Millisecond)
+	h.startContainer("blog", map[string]string{
+		"traefik.http.routers.blog.rule": "Host(`blog.example.com`)",
+	}, nil)
+
+	waitFor(t, 30*time.Second, "the record of blog.example.com", func() bool {
+		for _, record := range server.API.Records("example.com") {
+			if record.Hostname == "blog" && record.Type == "A" && record.Destination == "203.0.113.1" {
+				return true
+			}
+		}
+		return false
+	})
+}
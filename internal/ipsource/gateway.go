@@ -0,0 +1,329 @@
+package ipsource
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	natPMPPort = 5351 // RFC 6886 3
+	// natPMPRetries bounds the requests sent, starting at 250ms and doubling (RFC 6886 3.1)
+	natPMPRetries = 4
+
+	ssdpAddress = "239.255.255.250:1900"
+	// ssdpWait is how long routers get to answer the discovery (the MX of the search)
+	ssdpWait = 2 * time.Second
+
+	// maxUPnPResponse bounds the device descriptions and SOAP responses read
+	maxUPnPResponse = 1 << 20
+)
+
+// upnpServices are the IGD services offering GetExternalIPAddress, for routers with a
+// DHCP or static uplink and for PPPoE routers
+var upnpServices = []string{
+	"urn:schemas-upnp-org:service:WANIPConnection:",
+	"urn:schemas-upnp-org:service:WANPPPConnection:",
+}
+
+// Gateway asks the home router for its external address, via NAT-PMP (RFC 6886) and,
+// if the router does not answer, UPnP IGD. Unlike echo services it needs no external
+// dependency, but the router must have NAT-PMP or UPnP enabled.
+type Gateway struct {
+	Address string       // Router address, defaults to the default gateway
+	Client  *http.Client // nil uses http.DefaultClient
+
+	natPMPPort  int    // Overridden in tests
+	ssdpAddress string // Overridden in tests
+}
+
+func (s *Gateway) IP(ctx context.Context) (string, error) {
+	gateway := s.Address
+	if gateway == "" {
+		var err error
+		if gateway, err = defaultGateway(); err != nil {
+			return "", fmt.Errorf("%s: %w", s, err)
+		}
+	}
+
+	ip, natPMPErr := s.natPMP(ctx, gateway)
+	if natPMPErr == nil {
+		return parseIPv4(s, ip)
+	}
+	ip, upnpErr := s.upnp(ctx)
+	if upnpErr == nil {
+		return parseIPv4(s, ip)
+	}
+	return "", fmt.Errorf("%s: NAT-PMP: %w; UPnP: %w", s, natPMPErr, upnpErr)
+}
+
+func (s *Gateway) String() string {
+	if s.Address == "" {
+		return "gateway"
+	}
+	return "gateway " + s.Address
+}
+
+// natPMP sends a NAT-PMP external address request to gateway
+func (s *Gateway) natPMP(ctx context.Context, gateway string) (string, error) {
+	port := s.natPMPPort
+	if port == 0 {
+		port = natPMPPort
+	}
+	conn, err := net.Dial("udp4", net.JoinHostPort(gateway, strconv.Itoa(port)))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	timeout := 250 * time.Millisecond
+	response := make([]byte, 16)
+	for range natPMPRetries {
+		if _, err := conn.Write([]byte{0, 0}); err != nil {
+			return "", err
+		}
+		deadline := time.Now().Add(timeout)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+		conn.SetReadDeadline(deadline)
+
+		n, err := conn.Read(response)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() && ctx.Err() == nil {
+				timeout *= 2
+				continue
+			}
+			return "", err
+		}
+		// Version 0, opcode 128 (response to 0), result code, epoch, address
+		if n < 12 || response[0] != 0 || response[1] != 128 {
+			return "", fmt.Errorf("malformed response from %s", gateway)
+		}
+		if code := binary.BigEndian.Uint16(response[2:4]); code != 0 {
+			return "", fmt.Errorf("%s returned result code %d", gateway, code)
+		}
+		return net.IP(response[8:12]).String(), nil
+	}
+	return "", fmt.Errorf("no response from %s", gateway)
+}
+
+// upnp discovers an Internet Gateway Device and calls GetExternalIPAddress
+func (s *Gateway) upnp(ctx context.Context) (string, error) {
+	location, err := s.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	ip, err := s.upnpExternalIP(ctx, location)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", location, err)
+	}
+	return ip, nil
+}
+
+// discover sends an SSDP search for gateway devices and returns the description URL of
+// the first one answering within ssdpWait
+func (s *Gateway) discover(ctx context.Context) (string, error) {
+	target := s.ssdpAddress
+	if target == "" {
+		target = ssdpAddress
+	}
+	addr, err := net.ResolveUDPAddr("udp4", target)
+	if err != nil {
+		return "", err
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	search := "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: " + ssdpAddress + "\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: " + strconv.Itoa(int(ssdpWait/time.Second)) + "\r\n" +
+		"ST: urn:schemas-upnp-org:device:InternetGatewayDevice:1\r\n\r\n"
+	if _, err := conn.WriteTo([]byte(search), addr); err != nil {
+		return "", err
+	}
+
+	deadline := time.Now().Add(ssdpWait)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetReadDeadline(deadline)
+
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return "", fmt.Errorf("no gateway answered the SSDP search")
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		if location := resp.Header.Get("Location"); location != "" {
+			return location, nil
+		}
+	}
+}
+
+// upnpService is a service of a UPnP device description
+type upnpService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+// upnpExternalIP reads the device description at location and asks its WAN connection
+// service for the external address
+func (s *Gateway) upnpExternalIP(ctx context.Context, location string) (string, error) {
+	base, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	body, err := s.get(ctx, location)
+	if err != nil {
+		return "", err
+	}
+	service, err := findWANService(body)
+	if err != nil {
+		return "", err
+	}
+	control, err := base.Parse(service.ControlURL)
+	if err != nil {
+		return "", err
+	}
+
+	envelope := `<?xml version="1.0"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+		`<s:Body><u:GetExternalIPAddress xmlns:u="` + service.ServiceType + `"/></s:Body></s:Envelope>`
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, control.String(), strings.NewReader(envelope))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", `"`+service.ServiceType+`#GetExternalIPAddress"`)
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GetExternalIPAddress returned %s", resp.Status)
+	}
+	return xmlElementText(io.LimitReader(resp.Body, maxUPnPResponse), "NewExternalIPAddress")
+}
+
+func (s *Gateway) get(ctx context.Context, location string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxUPnPResponse))
+}
+
+func (s *Gateway) client() *http.Client {
+	if s.Client == nil {
+		return http.DefaultClient
+	}
+	return s.Client
+}
+
+// findWANService returns the first WAN connection service of a device description,
+// whose devices nest arbitrarily deep
+func findWANService(description []byte) (upnpService, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(description))
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return upnpService{}, fmt.Errorf("device has no WANIPConnection or WANPPPConnection service")
+		}
+		if err != nil {
+			return upnpService{}, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "service" {
+			continue
+		}
+		var service upnpService
+		if err := decoder.DecodeElement(&service, &start); err != nil {
+			return upnpService{}, err
+		}
+		for _, prefix := range upnpServices {
+			if strings.HasPrefix(service.ServiceType, prefix) && service.ControlURL != "" {
+				return service, nil
+			}
+		}
+	}
+}
+
+// xmlElementText returns the text of the first element named name
+func xmlElementText(r io.Reader, name string) (string, error) {
+	decoder := xml.NewDecoder(r)
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return "", fmt.Errorf("response has no %s", name)
+		}
+		if err != nil {
+			return "", err
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == name {
+			var text string
+			if err := decoder.DecodeElement(&text, &start); err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(text), nil
+		}
+	}
+}
+
+// defaultGateway reads the IPv4 default gateway from /proc/net/route
+func defaultGateway() (string, error) {
+	data, err := os.ReadFile("/proc/net/route")
+	if err != nil {
+		return "", fmt.Errorf("cannot determine the default gateway, set IP_SOURCE_GATEWAY: %w", err)
+	}
+	return parseRouteTable(string(data))
+}
+
+// parseRouteTable returns the gateway of the default route in the format of
+// /proc/net/route, where addresses are little-endian hex
+func parseRouteTable(table string) (string, error) {
+	for _, line := range strings.Split(table, "\n")[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		raw, err := hex.DecodeString(fields[2])
+		if err != nil || len(raw) != 4 {
+			continue
+		}
+		return net.IPv4(raw[3], raw[2], raw[1], raw[0]).String(), nil
+	}
+	return "", fmt.Errorf("no default route, set IP_SOURCE_GATEWAY")
+}
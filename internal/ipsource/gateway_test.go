@@ -0,0 +1,169 @@
+package ipsource
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+    <serviceList>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:Layer3Forwarding:1</serviceType>
+        <controlURL>/ctl/L3F</controlURL>
+      </service>
+    </serviceList>
+    <deviceList>
+      <device>
+        <deviceType>urn:schemas-upnp-org:device:WANDevice:1</deviceType>
+        <deviceList>
+          <device>
+            <deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:1</deviceType>
+            <serviceList>
+              <service>
+                <serviceType>urn:schemas-upnp-org:service:WANIPConnection:1</serviceType>
+                <controlURL>/ctl/IPConn</controlURL>
+              </service>
+            </serviceList>
+          </device>
+        </deviceList>
+      </device>
+    </deviceList>
+  </device>
+</root>`
+
+const testSOAPResponse = `<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/">
+  <s:Body>
+    <u:GetExternalIPAddressResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1">
+      <NewExternalIPAddress>203.0.113.42</NewExternalIPAddress>
+    </u:GetExternalIPAddressResponse>
+  </s:Body>
+</s:Envelope>`
+
+// newIGD serves a device description and the GetExternalIPAddress action
+func newIGD(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rootDesc.xml":
+			w.Write([]byte(testDescription))
+		case "/ctl/IPConn":
+			if r.Header.Get("SOAPAction") != `"urn:schemas-upnp-org:service:WANIPConnection:1#GetExternalIPAddress"` {
+				http.Error(w, "unexpected action", http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(testSOAPResponse))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// listenUDP answers each datagram received on a local port with respond
+func listenUDP(t *testing.T, respond func([]byte) []byte) int {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if reply := respond(buf[:n]); reply != nil {
+				conn.WriteTo(reply, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// closedUDPPort returns a local port nothing listens on
+func closedUDPPort(t *testing.T) int {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	conn.Close()
+	return port
+}
+
+func TestGateway_NATPMP(t *testing.T) {
+	port := listenUDP(t, func(req []byte) []byte {
+		if len(req) != 2 || req[0] != 0 || req[1] != 0 {
+			return nil
+		}
+		return []byte{0, 128, 0, 0, 0, 0, 0, 1, 198, 51, 100, 9}
+	})
+
+	source := &Gateway{Address: "127.0.0.1", natPMPPort: port}
+	ip, err := source.IP(context.Background())
+	if err != nil || ip != "198.51.100.9" {
+		t.Errorf("IP() = %q, %v, want 198.51.100.9", ip, err)
+	}
+}
+
+func TestGateway_UPnPFallback(t *testing.T) {
+	igd := newIGD(t)
+	// NAT-PMP is refused, the SSDP search points at the device description
+	natPMP := closedUDPPort(t)
+	ssdp := listenUDP(t, func(req []byte) []byte {
+		if !strings.HasPrefix(string(req), "M-SEARCH") {
+			return nil
+		}
+		return []byte("HTTP/1.1 200 OK\r\nLOCATION: " + igd.URL + "/rootDesc.xml\r\nST: urn:schemas-upnp-org:device:InternetGatewayDevice:1\r\n\r\n")
+	})
+
+	source := &Gateway{Address: "127.0.0.1", natPMPPort: natPMP, ssdpAddress: "127.0.0.1:" + strconv.Itoa(ssdp)}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ip, err := source.IP(ctx)
+	if err != nil || ip != "203.0.113.42" {
+		t.Errorf("IP() = %q, %v, want 203.0.113.42", ip, err)
+	}
+}
+
+func TestGateway_Unreachable(t *testing.T) {
+	silent := listenUDP(t, func([]byte) []byte { return nil })
+
+	source := &Gateway{Address: "127.0.0.1", natPMPPort: silent, ssdpAddress: "127.0.0.1:" + strconv.Itoa(silent)}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_, err := source.IP(ctx)
+	if err == nil || !strings.Contains(err.Error(), "NAT-PMP") || !strings.Contains(err.Error(), "UPnP") {
+		t.Errorf("IP() error = %v, want NAT-PMP and UPnP errors", err)
+	}
+}
+
+func TestFindWANService_Missing(t *testing.T) {
+	description := `<root><device><serviceList><service><serviceType>urn:schemas-upnp-org:service:Layer3Forwarding:1</serviceType><controlURL>/ctl</controlURL></service></serviceList></device></root>`
+	if _, err := findWANService([]byte(description)); err == nil {
+		t.Error("findWANService() found a service in a description without WAN connection")
+	}
+}
+
+func TestParseRouteTable(t *testing.T) {
+	table := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n" +
+		"eth0\t0011A8C0\t00000000\t0001\t0\t0\t0\t00FFFFFF\t0\t0\t0\n" +
+		"eth0\t00000000\t0111A8C0\t0003\t0\t0\t0\t00000000\t0\t0\t0\n"
+	if got, err := parseRouteTable(table); err != nil || got != "192.168.17.1" {
+		t.Errorf("parseRouteTable() = %q, %v, want 192.168.17.1", got, err)
+	}
+	if _, err := parseRouteTable("Iface\tDestination\tGateway\n"); err == nil {
+		t.Error("parseRouteTable() succeeded without a default route")
+	}
+}
@@ -0,0 +1,138 @@
+// Package ipsource provides the sources the published host IP can be taken from, for
+// setups where the outbound interface does not carry the public address, e.g. behind
+// LTE routers or CGNAT with a port forwarding service.
+package ipsource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"strings"
+)
+
+// Source supplies the public IPv4 address records point at
+type Source interface {
+	// IP returns the current address
+	IP(ctx context.Context) (string, error)
+	// String describes the source for logs, e.g. "http https://api.ipify.org"
+	String() string
+}
+
+// parseIPv4 returns raw as IPv4 address, or an error naming the source
+func parseIPv4(source Source, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	ip := net.ParseIP(raw)
+	if ip == nil || ip.To4() == nil {
+		return "", fmt.Errorf("%s returned %q, not an IPv4 address", source, raw)
+	}
+	return ip.To4().String(), nil
+}
+
+// Static always returns the same address
+type Static struct {
+	Address string
+}
+
+func (s *Static) IP(context.Context) (string, error) {
+	return parseIPv4(s, s.Address)
+}
+
+func (s *Static) String() string {
+	return "static " + s.Address
+}
+
+// Interface returns the first global unicast IPv4 address of a network interface, e.g.
+// the PPPoE interface of a host dialing in itself
+type Interface struct {
+	Name string
+}
+
+func (s *Interface) IP(context.Context) (string, error) {
+	iface, err := net.InterfaceByName(s.Name)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", s, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", s, err)
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ip4 := ipNet.IP.To4(); ip4 != nil && ip4.IsGlobalUnicast() {
+			return ip4.String(), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no IPv4 address", s)
+}
+
+func (s *Interface) String() string {
+	return "interface " + s.Name
+}
+
+// maxHTTPResponse bounds the body read from an HTTP source
+const maxHTTPResponse = 1024
+
+// HTTP returns the body of a GET request, as served by echo services like
+// https://api.ipify.org or a router's status endpoint
+type HTTP struct {
+	URL    string
+	Client *http.Client // nil uses http.DefaultClient
+}
+
+func (s *HTTP) IP(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", s, err)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", s, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: unexpected status %s", s, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponse))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", s, err)
+	}
+	return parseIPv4(s, string(body))
+}
+
+func (s *HTTP) String() string {
+	return "http " + s.URL
+}
+
+// Command returns the output of a shell command, for setups no other source covers,
+// e.g. querying an LTE router's API with curl and jq
+type Command struct {
+	Command string
+}
+
+func (s *Command) IP(ctx context.Context) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.Command)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%s: %w: %s", s, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("%s: %w", s, err)
+	}
+	return parseIPv4(s, string(output))
+}
+
+func (s *Command) String() string {
+	return "command " + s.Command
+}
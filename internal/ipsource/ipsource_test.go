@@ -0,0 +1,57 @@
+package ipsource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSources(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ip":
+			w.Write([]byte("203.0.113.7\n"))
+		case "/html":
+			w.Write([]byte("<html>203.0.113.7</html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name    string
+		source  Source
+		want    string
+		wantErr string
+	}{
+		{name: "static", source: &Static{Address: "203.0.113.1"}, want: "203.0.113.1"},
+		{name: "static IPv6", source: &Static{Address: "2001:db8::1"}, wantErr: "not an IPv4 address"},
+		{name: "http", source: &HTTP{URL: server.URL + "/ip"}, want: "203.0.113.7"},
+		{name: "http not an address", source: &HTTP{URL: server.URL + "/html"}, wantErr: "not an IPv4 address"},
+		{name: "http status", source: &HTTP{URL: server.URL + "/missing"}, wantErr: "404"},
+		{name: "command", source: &Command{Command: "echo 203.0.113.9"}, want: "203.0.113.9"},
+		{name: "command fails", source: &Command{Command: "echo unreachable >&2; exit 3"}, wantErr: "unreachable"},
+		{name: "interface missing", source: &Interface{Name: "does-not-exist0"}, wantErr: "interface does-not-exist0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.IP(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("IP() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("IP() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
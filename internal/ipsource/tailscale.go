@@ -0,0 +1,66 @@
+package ipsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// tailscaleStatusURL is the status endpoint of the tailscaled local API. The host is
+// ignored on the socket but checked by tailscaled.
+const tailscaleStatusURL = "http://local-tailscaled.sock/localapi/v0/status"
+
+// Tailscale returns this node's Tailscale IPv4 address, asked from the local API of
+// tailscaled over its socket. Mount the socket into the container, e.g.
+// /var/run/tailscale/tailscaled.sock.
+type Tailscale struct {
+	Socket string
+}
+
+func (s *Tailscale) IP(ctx context.Context) (string, error) {
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", s.Socket)
+		},
+	}}
+	defer client.CloseIdleConnections()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tailscaleStatusURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", s, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", s, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponse))
+		return "", fmt.Errorf("%s: unexpected status %s: %s", s, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var status struct {
+		BackendState string
+		Self         struct {
+			TailscaleIPs []string
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return "", fmt.Errorf("%s: failed to parse status: %w", s, err)
+	}
+	for _, raw := range status.Self.TailscaleIPs {
+		if ip := net.ParseIP(raw); ip != nil && ip.To4() != nil {
+			return ip.To4().String(), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no IPv4 address (backend state %s)", s, status.BackendState)
+}
+
+func (s *Tailscale) String() string {
+	return "tailscale " + s.Socket
+}
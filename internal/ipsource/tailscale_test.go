@@ -0,0 +1,69 @@
+package ipsource
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// serveTailscale serves status as the local API of tailscaled on a socket
+func serveTailscale(t *testing.T, status string) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "tailscaled.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/localapi/v0/status" || r.Host != "local-tailscaled.sock" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(status))
+	})}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return socket
+}
+
+func TestTailscale(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		want    string
+		wantErr string
+	}{
+		{name: "running", status: `{"BackendState":"Running","Self":{"TailscaleIPs":["fd7a:115c:a1e0::1","100.101.102.103"]}}`, want: "100.101.102.103"},
+		{name: "logged out", status: `{"BackendState":"NeedsLogin","Self":{"TailscaleIPs":null}}`, wantErr: "backend state NeedsLogin"},
+		{name: "invalid response", status: `<html>`, wantErr: "failed to parse status"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &Tailscale{Socket: serveTailscale(t, tt.status)}
+			got, err := source.IP(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("IP() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("IP() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTailscale_MissingSocket(t *testing.T) {
+	source := &Tailscale{Socket: filepath.Join(t.TempDir(), "missing.sock")}
+	if _, err := source.IP(context.Background()); err == nil || !strings.Contains(err.Error(), "tailscale") {
+		t.Errorf("IP() error = %v, want an error naming the source", err)
+	}
+}
@@ -0,0 +1,163 @@
+// Package metrics writes Prometheus metrics about the managed records in the format read
+// by the node_exporter textfile collector, for hosts without a metrics endpoint
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/dns"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/events"
+)
+
+// Collector counts the record changes and reconciliation results published on the event
+// bus since the start
+type Collector struct {
+	mu         sync.Mutex
+	changes    map[string]int // Successful record changes by action
+	failures   int
+	lastChange time.Time
+	reconcile  *reconcileResult // Result of the last reconciliation, nil before the first
+}
+
+type reconcileResult struct {
+	at      time.Time
+	synced  int
+	inSync  int
+	errored int
+}
+
+func NewCollector() *Collector {
+	return &Collector{changes: make(map[string]int)}
+}
+
+// HandleEvent counts record changes and failures and remembers the last reconciliation
+func (c *Collector) HandleEvent(_ context.Context, event events.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch e := event.(type) {
+	case events.RecordCreated:
+		c.change("create")
+	case events.RecordUpdated:
+		c.change("update")
+	case events.RecordRemoved:
+		c.change("delete")
+	case events.RecordFailed:
+		c.failures++
+	case events.ReconcileCompleted:
+		c.reconcile = &reconcileResult{at: time.Now(), synced: e.Synced, inSync: e.InSync, errored: e.Errored}
+	}
+}
+
+// change counts a successful record change. The caller holds c.mu.
+func (c *Collector) change(action string) {
+	c.changes[action]++
+	c.lastChange = time.Now()
+}
+
+// Render writes the metrics in the Prometheus text format, combining the counted events
+// with the manager's diagnostics
+func (c *Collector) Render(w io.Writer, diagnostics dns.Diagnostics, now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var b bytes.Buffer
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	records := make(map[string]int)
+	for _, record := range diagnostics.Records {
+		records[record.Domain]++
+	}
+	metric("netcup_companion_records", "gauge", "Records managed by the companion per domain.")
+	for _, domain := range slices.Sorted(maps.Keys(records)) {
+		fmt.Fprintf(&b, "netcup_companion_records{domain=\"%s\"} %d\n", escape(domain), records[domain])
+	}
+
+	metric("netcup_companion_known_hosts", "gauge", "Hostnames processed since the start.")
+	fmt.Fprintf(&b, "netcup_companion_known_hosts %d\n", len(diagnostics.KnownHosts))
+
+	metric("netcup_companion_paused", "gauge", "Whether DNS writes are paused.")
+	fmt.Fprintf(&b, "netcup_companion_paused %d\n", boolValue(diagnostics.Paused))
+
+	metric("netcup_companion_record_changes_total", "counter", "Records created, updated or deleted since the start.")
+	for _, action := range []string{"create", "update", "delete"} {
+		fmt.Fprintf(&b, "netcup_companion_record_changes_total{action=%q} %d\n", action, c.changes[action])
+	}
+	metric("netcup_companion_record_failures_total", "counter", "Failed record changes since the start.")
+	fmt.Fprintf(&b, "netcup_companion_record_failures_total %d\n", c.failures)
+	if !c.lastChange.IsZero() {
+		metric("netcup_companion_last_change_timestamp_seconds", "gauge", "Time of the last successful record change.")
+		fmt.Fprintf(&b, "netcup_companion_last_change_timestamp_seconds %d\n", c.lastChange.Unix())
+	}
+
+	if c.reconcile != nil {
+		metric("netcup_companion_last_reconcile_timestamp_seconds", "gauge", "Time of the last reconciliation.")
+		fmt.Fprintf(&b, "netcup_companion_last_reconcile_timestamp_seconds %d\n", c.reconcile.at.Unix())
+		metric("netcup_companion_last_reconcile_records", "gauge", "Records by result of the last reconciliation.")
+		fmt.Fprintf(&b, "netcup_companion_last_reconcile_records{result=\"synced\"} %d\n", c.reconcile.synced)
+		fmt.Fprintf(&b, "netcup_companion_last_reconcile_records{result=\"in_sync\"} %d\n", c.reconcile.inSync)
+		fmt.Fprintf(&b, "netcup_companion_last_reconcile_records{result=\"errored\"} %d\n", c.reconcile.errored)
+	}
+
+	if diagnostics.Netcup != nil {
+		metric("netcup_companion_circuit_breaker_open", "gauge", "Whether the Netcup API circuit breaker is open or half-open.")
+		fmt.Fprintf(&b, "netcup_companion_circuit_breaker_open %d\n", boolValue(diagnostics.Netcup.CircuitBreaker.State != "closed"))
+	}
+
+	if diagnostics.State != nil {
+		metric("netcup_companion_state_save_errors_total", "counter", "Failed saves of the state file since the start.")
+		fmt.Fprintf(&b, "netcup_companion_state_save_errors_total %d\n", diagnostics.State.SaveErrors)
+		if diagnostics.State.LastSavedAt != nil {
+			metric("netcup_companion_state_last_save_timestamp_seconds", "gauge", "Time the state file was last saved.")
+			fmt.Fprintf(&b, "netcup_companion_state_last_save_timestamp_seconds %d\n", diagnostics.State.LastSavedAt.Unix())
+		}
+	}
+
+	metric("netcup_companion_textfile_timestamp_seconds", "gauge", "Time this file was written, to alert on a stale file.")
+	fmt.Fprintf(&b, "netcup_companion_textfile_timestamp_seconds %d\n", now.Unix())
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// WriteTextfile renders the metrics to path. The file is written to a temporary file
+// first and renamed, so the collector never reads a partial file.
+func (c *Collector) WriteTextfile(path string, diagnostics dns.Diagnostics) error {
+	var b bytes.Buffer
+	if err := c.Render(&b, diagnostics, time.Now()); err != nil {
+		return err
+	}
+
+	// The textfile collector only reads *.prom files, so it skips the temp file
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write temp metrics file: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename temp metrics file: %w", err)
+	}
+	return nil
+}
+
+// escape escapes a label value for the text format
+var escape = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace
+
+// boolValue renders a boolean as a gauge value
+func boolValue(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/dns"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/events"
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/state"
+)
+
+func TestCollector_Render(t *testing.T) {
+	collector := NewCollector()
+	host := docker.HostInfo{Hostname: "app.example.com"}
+	for _, event := range []events.Event{
+		events.RecordCreated{Host: host, IP: "203.0.113.1"},
+		events.RecordCreated{Host: host, IP: "203.0.113.1"},
+		events.RecordUpdated{Host: host, PreviousIP: "203.0.113.1", IP: "203.0.113.2"},
+		events.RecordFailed{Host: host, Err: errors.New("timeout")},
+		events.ReconcileCompleted{Synced: 1, InSync: 4, Errored: 2},
+		events.ContainerStarted{Host: host},
+	} {
+		collector.HandleEvent(context.Background(), event)
+	}
+
+	diagnostics := dns.Diagnostics{
+		KnownHosts: []string{"app.example.com", "www.example.org"},
+		Records: map[string]state.DNSRecord{
+			"app.example.com": {Hostname: "app.example.com", Domain: "example.com"},
+			"api.example.com": {Hostname: "api.example.com", Domain: "example.com"},
+			"www.example.org": {Hostname: "www.example.org", Domain: "example.org"},
+		},
+		Netcup: &netcup.Diagnostics{CircuitBreaker: netcup.CircuitBreakerStats{State: "open"}},
+	}
+
+	var b strings.Builder
+	if err := collector.Render(&b, diagnostics, time.Unix(1700000000, 0)); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"# TYPE netcup_companion_records gauge\n",
+		`netcup_companion_records{domain="example.com"} 2` + "\n",
+		`netcup_companion_records{domain="example.org"} 1` + "\n",
+		"netcup_companion_known_hosts 2\n",
+		"netcup_companion_paused 0\n",
+		`netcup_companion_record_changes_total{action="create"} 2` + "\n",
+		`netcup_companion_record_changes_total{action="update"} 1` + "\n",
+		`netcup_companion_record_changes_total{action="delete"} 0` + "\n",
+		"netcup_companion_record_failures_total 1\n",
+		`netcup_companion_last_reconcile_records{result="errored"} 2` + "\n",
+		"netcup_companion_circuit_breaker_open 1\n",
+		"netcup_companion_textfile_timestamp_seconds 1700000000\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Render() output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "netcup_companion_state_") {
+		t.Errorf("Render() output has state file metrics without a state manager:\n%s", out)
+	}
+}
+
+func TestCollector_WriteTextfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "companion.prom")
+	if err := NewCollector().WriteTextfile(path, dns.Diagnostics{}); err != nil {
+		t.Fatalf("WriteTextfile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "netcup_companion_known_hosts 0\n") {
+		t.Errorf("Textfile = %s, want the metrics", data)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("Temp file left behind: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package migration
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileName is the name of the migration file, stored next to the state file
+const FileName = "migration.json"
+
+// Migration is a planned IP change for which the zone TTLs of all managed domains
+// were lowered. The original TTLs are restored once a new IP has been published for
+// RestoreAfter.
+type Migration struct {
+	PreparedAt      time.Time         `json:"prepared_at"`
+	OldIP           string            `json:"old_ip,omitempty"`  // Host IP of migrations prepared before OldIPs was tracked
+	OldIPs          map[string]string `json:"old_ips,omitempty"` // Domain -> IP its records pointed at when prepared
+	TTL             string            `json:"ttl"`               // Lowered zone TTL
+	OriginalTTLs    map[string]string `json:"original_ttls"`     // Domain -> zone TTL before lowering
+	RestoreAfterSec int               `json:"restore_after_sec"` // Seconds the new IP must be active before restoring
+	NewIPSince      *time.Time        `json:"new_ip_since,omitempty"`
+}
+
+// Path returns the migration file location for the given state file
+func Path(stateFile string) string {
+	return filepath.Join(filepath.Dir(stateFile), FileName)
+}
+
+// Load reads a migration file. It returns an error satisfying os.IsNotExist if no
+// migration is prepared.
+func Load(path string) (*Migration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Migration
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse migration file: %w", err)
+	}
+	return &m, nil
+}
+
+// Save writes the migration file atomically
+func (m *Migration) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize migration: %w", err)
+	}
+
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp migration file: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename temp migration file: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the migration file, ignoring a missing file
+func Remove(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// RestoreAfter returns how long the new IP must be active before the TTLs are restored
+func (m *Migration) RestoreAfter() time.Duration {
+	return time.Duration(m.RestoreAfterSec) * time.Second
+}
+
+// ObserveIPs records the IPs currently published per domain. changed reports whether
+// the migration was modified and must be saved; due reports whether a new IP has been
+// active for RestoreAfter so the original TTLs can be restored. A new IP of any domain
+// counts, as a planned change may only move some of them.
+func (m *Migration) ObserveIPs(ips map[string]string, now time.Time) (changed, due bool) {
+	moved := false
+	for domain, ip := range ips {
+		if ip != m.oldIP(domain) {
+			moved = true
+			break
+		}
+	}
+
+	if !moved {
+		// Switched back or not switched yet
+		if m.NewIPSince != nil {
+			m.NewIPSince = nil
+			return true, false
+		}
+		return false, false
+	}
+
+	if m.NewIPSince == nil {
+		m.NewIPSince = &now
+		changed = true
+	}
+	return changed, now.Sub(*m.NewIPSince) >= m.RestoreAfter()
+}
+
+// oldIP returns the IP the records of domain pointed at when the migration was prepared
+func (m *Migration) oldIP(domain string) string {
+	if ip, ok := m.OldIPs[domain]; ok {
+		return ip
+	}
+	return m.OldIP
+}
@@ -0,0 +1,86 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveLoadRemove(t *testing.T) {
+	path := Path(filepath.Join(t.TempDir(), "state.json"))
+
+	if _, err := Load(path); !os.IsNotExist(err) {
+		t.Fatalf("Load() without file error = %v, want not exist", err)
+	}
+
+	m := &Migration{
+		PreparedAt:      time.Now().UTC().Truncate(time.Second),
+		OldIPs:          map[string]string{"example.com": "203.0.113.1"},
+		TTL:             "60",
+		OriginalTTLs:    map[string]string{"example.com": "86400"},
+		RestoreAfterSec: 3600,
+	}
+	if err := m.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.OldIPs["example.com"] != "203.0.113.1" || loaded.TTL != m.TTL || loaded.OriginalTTLs["example.com"] != "86400" || !loaded.PreparedAt.Equal(m.PreparedAt) {
+		t.Errorf("Load() = %+v, want %+v", loaded, m)
+	}
+	if loaded.RestoreAfter() != time.Hour {
+		t.Errorf("RestoreAfter() = %v, want 1h", loaded.RestoreAfter())
+	}
+
+	if err := Remove(path); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := Remove(path); err != nil {
+		t.Errorf("Remove() of missing file error = %v", err)
+	}
+}
+
+func TestObserveIPs(t *testing.T) {
+	start := time.Now()
+	m := &Migration{
+		OldIPs:          map[string]string{"example.com": "203.0.113.1", "other.de": "192.0.2.1"},
+		RestoreAfterSec: 3600,
+	}
+
+	steps := []struct {
+		name        string
+		ips         map[string]string
+		at          time.Duration
+		wantChanged bool
+		wantDue     bool
+	}{
+		{"old IPs still active", map[string]string{"example.com": "203.0.113.1", "other.de": "192.0.2.1"}, 0, false, false},
+		{"new IP of one domain published", map[string]string{"example.com": "198.51.100.1", "other.de": "192.0.2.1"}, time.Minute, true, false},
+		{"new IP not active long enough", map[string]string{"example.com": "198.51.100.1", "other.de": "192.0.2.1"}, 30 * time.Minute, false, false},
+		{"switched back", map[string]string{"example.com": "203.0.113.1", "other.de": "192.0.2.1"}, 40 * time.Minute, true, false},
+		{"new IP published again", map[string]string{"example.com": "198.51.100.1", "other.de": "192.0.2.1"}, 50 * time.Minute, true, false},
+		{"new IP active for restore period", map[string]string{"example.com": "198.51.100.1", "other.de": "192.0.2.1"}, 110 * time.Minute, false, true},
+	}
+
+	for _, step := range steps {
+		changed, due := m.ObserveIPs(step.ips, start.Add(step.at))
+		if changed != step.wantChanged || due != step.wantDue {
+			t.Errorf("%s: ObserveIPs() = %v, %v, want %v, %v", step.name, changed, due, step.wantChanged, step.wantDue)
+		}
+	}
+}
+
+func TestObserveIPs_LegacyOldIP(t *testing.T) {
+	m := &Migration{OldIP: "203.0.113.1", RestoreAfterSec: 3600}
+
+	if changed, _ := m.ObserveIPs(map[string]string{"example.com": "203.0.113.1"}, time.Now()); changed {
+		t.Error("ObserveIPs() with the old host IP reported a change")
+	}
+	if changed, _ := m.ObserveIPs(map[string]string{"example.com": "198.51.100.1"}, time.Now()); !changed {
+		t.Error("ObserveIPs() with a new IP reported no change")
+	}
+}
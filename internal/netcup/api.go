@@ -0,0 +1,39 @@
+package netcup
+
+import "context"
+
+// NetcupAPI is the subset of the Netcup DNS API used by the companion. It allows
+// consumers to substitute the real client, e.g. with FakeAPI in tests.
+type NetcupAPI interface {
+	// Login starts a session whose requests use ctx
+	Login(ctx context.Context) (DnsSession, error)
+}
+
+// DnsSession is an authenticated Netcup API session.
+type DnsSession interface {
+	InfoDnsZone(domainName string) (*DnsZoneData, error)
+	InfoDnsRecords(domainName string) (*[]DnsRecord, error)
+	UpdateDnsZone(domainName string, dnsZone *DnsZoneData) (*DnsZoneData, error)
+	UpdateDnsRecords(domainName string, dnsRecordSet *[]DnsRecord) (*[]DnsRecord, error)
+	Logout() error
+}
+
+var _ DnsSession = (*NetcupSession)(nil)
+
+// clientAPI adapts NetcupDnsClient to the NetcupAPI interface.
+type clientAPI struct {
+	client *NetcupDnsClient
+}
+
+// Creates a NetcupAPI backed by the given client.
+func NewNetcupAPI(client *NetcupDnsClient) NetcupAPI {
+	return &clientAPI{client: client}
+}
+
+func (a *clientAPI) Login(ctx context.Context) (DnsSession, error) {
+	session, err := a.client.LoginContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
@@ -0,0 +1,98 @@
+package netcup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// cachingAPI wraps a NetcupAPI and caches zone record sets across sessions, so
+// processing several hosts of the same domain fetches its records only once.
+type cachingAPI struct {
+	api NetcupAPI
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	records []DnsRecord
+	expires time.Time
+}
+
+// NewCachingAPI returns a NetcupAPI that caches InfoDnsRecords results per domain for
+// ttl. A domain's entry is invalidated whenever its records are updated. A ttl <= 0
+// disables caching and returns api unchanged.
+func NewCachingAPI(api NetcupAPI, ttl time.Duration) NetcupAPI {
+	if ttl <= 0 {
+		return api
+	}
+	return &cachingAPI{
+		api:     api,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+func (c *cachingAPI) Login(ctx context.Context) (DnsSession, error) {
+	session, err := c.api.Login(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &cachingSession{DnsSession: session, cache: c}, nil
+}
+
+func (c *cachingAPI) get(domainName string) ([]DnsRecord, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[domainName]
+	if !ok || !c.now().Before(entry.expires) {
+		return nil, false
+	}
+	return append([]DnsRecord(nil), entry.records...), true
+}
+
+func (c *cachingAPI) put(domainName string, records []DnsRecord) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[domainName] = cacheEntry{
+		records: append([]DnsRecord(nil), records...),
+		expires: c.now().Add(c.ttl),
+	}
+}
+
+func (c *cachingAPI) invalidate(domainName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, domainName)
+}
+
+// cachingSession serves InfoDnsRecords from the cache and invalidates it on writes
+type cachingSession struct {
+	DnsSession
+	cache *cachingAPI
+}
+
+func (s *cachingSession) InfoDnsRecords(domainName string) (*[]DnsRecord, error) {
+	if records, ok := s.cache.get(domainName); ok {
+		return &records, nil
+	}
+
+	records, err := s.DnsSession.InfoDnsRecords(domainName)
+	if err != nil {
+		return records, err
+	}
+	s.cache.put(domainName, *records)
+	return records, nil
+}
+
+func (s *cachingSession) UpdateDnsRecords(domainName string, dnsRecordSet *[]DnsRecord) (*[]DnsRecord, error) {
+	// Invalidate even on errors, the update may have been partially applied
+	defer s.cache.invalidate(domainName)
+	return s.DnsSession.UpdateDnsRecords(domainName, dnsRecordSet)
+}
@@ -0,0 +1,84 @@
+package netcup
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCachingAPI(t *testing.T) {
+	fake := NewFakeAPI()
+	fake.AddZone("example.com", DnsRecord{Hostname: "app", Type: "A", Destination: "203.0.113.1"})
+
+	now := time.Now()
+	api := NewCachingAPI(fake, time.Minute).(*cachingAPI)
+	api.now = func() time.Time { return now }
+
+	fetch := func() []DnsRecord {
+		t.Helper()
+		session, err := api.Login(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer session.Logout()
+
+		records, err := session.InfoDnsRecords("example.com")
+		if err != nil {
+			t.Fatalf("InfoDnsRecords() error = %v", err)
+		}
+		return *records
+	}
+
+	fetch()
+	fetch()
+	if got := fake.CallCount("infoDnsRecords"); got != 1 {
+		t.Errorf("infoDnsRecords calls within ttl = %d, want 1", got)
+	}
+
+	// Writes invalidate the domain
+	session, err := api.Login(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.UpdateDnsRecords("example.com", &[]DnsRecord{{Hostname: "web", Type: "A", Destination: "203.0.113.1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if records := fetch(); len(records) != 2 {
+		t.Errorf("records after update = %d, want 2", len(records))
+	}
+	if got := fake.CallCount("infoDnsRecords"); got != 2 {
+		t.Errorf("infoDnsRecords calls after update = %d, want 2", got)
+	}
+
+	// Entries expire after the ttl
+	now = now.Add(time.Minute)
+	fetch()
+	if got := fake.CallCount("infoDnsRecords"); got != 3 {
+		t.Errorf("infoDnsRecords calls after expiry = %d, want 3", got)
+	}
+}
+
+func TestCachingAPI_ReturnsCopies(t *testing.T) {
+	fake := NewFakeAPI()
+	fake.AddZone("example.com", DnsRecord{Hostname: "app", Type: "A", Destination: "203.0.113.1"})
+	api := NewCachingAPI(fake, time.Minute)
+
+	session, err := api.Login(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, _ := session.InfoDnsRecords("example.com")
+	(*records)[0].Destination = "198.51.100.1"
+
+	cached, _ := session.InfoDnsRecords("example.com")
+	if (*cached)[0].Destination != "203.0.113.1" {
+		t.Errorf("cached destination = %s, want 203.0.113.1", (*cached)[0].Destination)
+	}
+}
+
+func TestNewCachingAPI_Disabled(t *testing.T) {
+	fake := NewFakeAPI()
+	if api := NewCachingAPI(fake, 0); api != NetcupAPI(fake) {
+		t.Error("NewCachingAPI() with ttl 0 should return the wrapped API")
+	}
+}
@@ -0,0 +1,132 @@
+package netcup
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redactedFields are the JSON keys whose values never appear in debug output
+var redactedFields = map[string]bool{
+	"apipassword":  true,
+	"apisessionid": true,
+	"apikey":       true,
+}
+
+// Exchange is a single HTTP request to the Netcup API with its response, for
+// troubleshooting with DEBUG_HTTP. Credentials and session ids are redacted.
+type Exchange struct {
+	Time       time.Time       `json:"time"`
+	Action     string          `json:"action"`
+	DurationMs int64           `json:"duration_ms"`
+	HTTPStatus int             `json:"http_status,omitempty"`
+	Request    json.RawMessage `json:"request"`
+	Response   json.RawMessage `json:"response,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// exchangeHistory is a fixed-size ring of recent exchanges
+type exchangeHistory struct {
+	mu      sync.Mutex
+	size    int
+	entries []Exchange
+	next    int
+}
+
+func (h *exchangeHistory) add(exchange Exchange) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.entries) < h.size {
+		h.entries = append(h.entries, exchange)
+		return
+	}
+	h.entries[h.next] = exchange
+	h.next = (h.next + 1) % h.size
+}
+
+// list returns the exchanges oldest first; a nil history has none
+func (h *exchangeHistory) list() []Exchange {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.entries) == 0 {
+		return nil
+	}
+	entries := make([]Exchange, 0, len(h.entries))
+	entries = append(entries, h.entries[h.next:]...)
+	return append(entries, h.entries[:h.next]...)
+}
+
+// debugEnabled reports whether exchanges are logged or kept
+func (c *NetcupDnsClient) debugEnabled() bool {
+	return c.debugHTTP || c.exchanges != nil
+}
+
+// recordExchange logs an exchange with DEBUG_HTTP and keeps it in the exchange history
+func (c *NetcupDnsClient) recordExchange(action string, start time.Time, httpStatus int, request, response []byte, err error) {
+	exchange := Exchange{
+		Time:       start,
+		Action:     action,
+		DurationMs: time.Since(start).Milliseconds(),
+		HTTPStatus: httpStatus,
+		Request:    redactJSON(request),
+	}
+	if len(response) > 0 {
+		exchange.Response = redactJSON(response)
+	}
+	if err != nil {
+		exchange.Error = err.Error()
+	}
+
+	if c.debugHTTP {
+		log.Printf("Netcup HTTP %s request: %s", action, exchange.Request)
+		if err != nil {
+			log.Printf("Netcup HTTP %s failed after %dms: %v", action, exchange.DurationMs, err)
+		}
+		if exchange.Response != nil {
+			log.Printf("Netcup HTTP %s response (%d, %dms): %s", action, httpStatus, exchange.DurationMs, exchange.Response)
+		}
+	}
+	if c.exchanges != nil {
+		c.exchanges.add(exchange)
+	}
+}
+
+// redactJSON masks the values of credential and session fields at any depth. Bodies
+// that are not JSON, e.g. HTML error pages, are kept as a JSON string.
+func redactJSON(data []byte) json.RawMessage {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		quoted, _ := json.Marshal(strings.TrimSpace(string(data)))
+		return quoted
+	}
+	redacted, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return json.RawMessage(`"unserializable"`)
+	}
+	return redacted
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if redactedFields[strings.ToLower(key)] {
+				v[key] = "REDACTED"
+				continue
+			}
+			v[key] = redactValue(field)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
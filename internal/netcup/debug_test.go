@@ -0,0 +1,84 @@
+package netcup
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "login",
+			in:   `{"action":"login","param":{"customernumber":12345,"apikey":"key","apipassword":"secret"}}`,
+			want: `{"action":"login","param":{"apikey":"REDACTED","apipassword":"REDACTED","customernumber":12345}}`,
+		},
+		{
+			name: "session",
+			in:   `{"action":"infoDnsRecords","param":{"apisessionid":"abc","domainname":"example.com"}}`,
+			want: `{"action":"infoDnsRecords","param":{"apisessionid":"REDACTED","domainname":"example.com"}}`,
+		},
+		{
+			name: "response",
+			in:   `{"status":"success","responsedata":{"apisessionid":"abc","dnsrecords":[{"hostname":"www"}]}}`,
+			want: `{"responsedata":{"apisessionid":"REDACTED","dnsrecords":[{"hostname":"www"}]},"status":"success"}`,
+		},
+		{
+			name: "not json",
+			in:   "<html>Bad Gateway</html>\n",
+			want: `"\u003chtml\u003eBad Gateway\u003c/html\u003e"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(redactJSON([]byte(tt.in))); got != tt.want {
+				t.Errorf("redactJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDebugHTTP_KeepsRedactedHistory(t *testing.T) {
+	api := NewFakeAPI()
+	api.AddZone("example.com")
+	server := NewMockServer(api)
+	t.Cleanup(server.Close)
+	client := NewNetcupDnsClientWithOptions(12345, "test-key", "test-password", &NetcupDnsClientOptions{
+		ApiEndpoint:  server.URL,
+		RetryConfig:  &RetryConfig{MaxRetries: 0, BackoffMultiplier: 1},
+		DebugHTTP:    true,
+		DebugHistory: 2,
+	})
+
+	session, err := client.Login()
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := session.InfoDnsRecords("example.com"); err != nil {
+			t.Fatalf("InfoDnsRecords() error = %v", err)
+		}
+	}
+
+	exchanges := client.Diagnostics().Exchanges
+	if len(exchanges) != 2 {
+		t.Fatalf("Exchanges = %d, want the 2 most recent", len(exchanges))
+	}
+	data, err := json.Marshal(exchanges)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if strings.Contains(string(data), session.apiSessionId) {
+		t.Errorf("exchanges contain the session id: %s", data)
+	}
+	for _, exchange := range exchanges {
+		if exchange.Action != "infoDnsRecords" || exchange.HTTPStatus != 200 {
+			t.Errorf("exchange = %s %d, want infoDnsRecords 200", exchange.Action, exchange.HTTPStatus)
+		}
+	}
+}
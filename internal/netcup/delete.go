@@ -0,0 +1,48 @@
+package netcup
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrRecordIdMissing is returned when a record to delete carries no Id. Netcup
+// identifies records to delete by Id only; a record without one would be created.
+var ErrRecordIdMissing = errors.New("record has no id")
+
+// DeleteDnsRecords deletes the given records of a zone. The records must carry the Id
+// assigned by Netcup, e.g. as returned by InfoDnsRecords.
+func DeleteDnsRecords(session DnsSession, domainName string, records []DnsRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	recordSet := make([]DnsRecord, 0, len(records))
+	for _, record := range records {
+		if record.Id == "" {
+			return fmt.Errorf("cannot delete %s record %s.%s: %w", record.Type, record.Hostname, domainName, ErrRecordIdMissing)
+		}
+		record.DeleteRecord = true
+		recordSet = append(recordSet, record)
+	}
+
+	if _, err := session.UpdateDnsRecords(domainName, &recordSet); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteDnsRecord looks up all records of recordType for hostname (the subdomain, "@"
+// for the apex) via InfoDnsRecords and deletes them. It returns the deleted records,
+// or none if no record matched.
+func DeleteDnsRecord(session DnsSession, domainName, hostname, recordType string) ([]DnsRecord, error) {
+	records, err := session.InfoDnsRecords(domainName)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := FindRecords(*records, hostname, recordType)
+	if err := DeleteDnsRecords(session, domainName, matched); err != nil {
+		return nil, err
+	}
+	return matched, nil
+}
@@ -0,0 +1,73 @@
+package netcup
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDeleteDnsRecord(t *testing.T) {
+	tests := []struct {
+		name        string
+		hostname    string
+		recordType  string
+		wantDeleted int
+		wantLeft    int
+	}{
+		{name: "deletes all matching records", hostname: "app", recordType: "A", wantDeleted: 2, wantLeft: 2},
+		{name: "matches record type", hostname: "app", recordType: "AAAA", wantDeleted: 1, wantLeft: 3},
+		{name: "no matching record", hostname: "missing", recordType: "A", wantDeleted: 0, wantLeft: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := NewFakeAPI()
+			api.AddZone("example.com",
+				DnsRecord{Hostname: "app", Type: "A", Destination: "203.0.113.1"},
+				DnsRecord{Hostname: "app", Type: "A", Destination: "203.0.113.2"},
+				DnsRecord{Hostname: "app", Type: "AAAA", Destination: "2001:db8::1"},
+				DnsRecord{Hostname: "web", Type: "A", Destination: "203.0.113.1"},
+			)
+			session, err := api.Login(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			deleted, err := DeleteDnsRecord(session, "example.com", tt.hostname, tt.recordType)
+			if err != nil {
+				t.Fatalf("DeleteDnsRecord() error = %v", err)
+			}
+			if len(deleted) != tt.wantDeleted {
+				t.Errorf("deleted %d records, want %d", len(deleted), tt.wantDeleted)
+			}
+			if got := len(api.Records("example.com")); got != tt.wantLeft {
+				t.Errorf("%d records left, want %d", got, tt.wantLeft)
+			}
+
+			wantCalls := 0
+			if tt.wantDeleted > 0 {
+				wantCalls = 1
+			}
+			if got := api.CallCount("updateDnsRecords"); got != wantCalls {
+				t.Errorf("updateDnsRecords calls = %d, want %d", got, wantCalls)
+			}
+		})
+	}
+}
+
+func TestDeleteDnsRecords_MissingId(t *testing.T) {
+	api := NewFakeAPI()
+	api.AddZone("example.com", DnsRecord{Hostname: "app", Type: "A", Destination: "203.0.113.1"})
+	session, err := api.Login(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = DeleteDnsRecords(session, "example.com", []DnsRecord{{Hostname: "app", Type: "A", Destination: "203.0.113.1"}})
+	if !errors.Is(err, ErrRecordIdMissing) {
+		t.Errorf("DeleteDnsRecords() error = %v, want ErrRecordIdMissing", err)
+	}
+	if got := len(api.Records("example.com")); got != 1 {
+		t.Errorf("%d records left, want 1", got)
+	}
+}
@@ -0,0 +1,168 @@
+package netcup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// responseHistorySize is the number of API responses kept for diagnostics
+const responseHistorySize = 50
+
+// ResponseEntry summarizes a single Netcup API request for diagnostics. Request
+// payloads and response data are not kept as they contain credentials and session ids.
+type ResponseEntry struct {
+	Time            time.Time `json:"time"`
+	Action          string    `json:"action"`
+	ClientRequestId string    `json:"client_request_id"`
+	ServerRequestId string    `json:"server_request_id,omitempty"`
+	Attempts        int       `json:"attempts"`
+	DurationMs      int64     `json:"duration_ms"`
+	Status          string    `json:"status,omitempty"`
+	StatusCode      int       `json:"status_code,omitempty"`
+	ShortMessage    string    `json:"short_message,omitempty"`
+	LongMessage     string    `json:"long_message,omitempty"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// CircuitBreakerStats is a snapshot of the circuit breaker
+type CircuitBreakerStats struct {
+	State        string     `json:"state"`
+	FailureCount int        `json:"failure_count"`
+	LastFailure  *time.Time `json:"last_failure,omitempty"`
+}
+
+// Diagnostics is a snapshot of the client's health for bug reports
+type Diagnostics struct {
+	CircuitBreaker CircuitBreakerStats `json:"circuit_breaker"`
+	Responses      []ResponseEntry     `json:"responses"`           // Oldest first
+	Async          AsyncStats          `json:"async"`               // Operations Netcup completed asynchronously
+	Exchanges      []Exchange          `json:"exchanges,omitempty"` // Redacted request and response bodies with DEBUG_HTTP_HISTORY, oldest first
+}
+
+// DiagnosticsProvider is implemented by NetcupAPI implementations that can report
+// their health
+type DiagnosticsProvider interface {
+	Diagnostics() Diagnostics
+}
+
+// CircuitBreakerResetter is implemented by NetcupAPI implementations whose circuit
+// breaker can be closed manually
+type CircuitBreakerResetter interface {
+	ResetCircuitBreaker() bool
+}
+
+// responseHistory is a fixed-size ring of recent API responses
+type responseHistory struct {
+	mu      sync.Mutex
+	entries []ResponseEntry
+	next    int
+}
+
+func (h *responseHistory) add(entry ResponseEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.entries) < responseHistorySize {
+		h.entries = append(h.entries, entry)
+		return
+	}
+	h.entries[h.next] = entry
+	h.next = (h.next + 1) % responseHistorySize
+}
+
+func (h *responseHistory) list() []ResponseEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries := make([]ResponseEntry, 0, len(h.entries))
+	entries = append(entries, h.entries[h.next:]...)
+	return append(entries, h.entries[:h.next]...)
+}
+
+// recordResponse logs the outcome of a request with its request ids and latency, and
+// adds it to the response history. The status is read from a copy of the body so the
+// caller can still decode it.
+func (c *NetcupDnsClient) recordResponse(action RequestAction, requestId string, start time.Time, attempts int, buf *bytes.Buffer, err error) {
+	entry := ResponseEntry{
+		Time:            start,
+		Action:          string(action),
+		ClientRequestId: requestId,
+		Attempts:        attempts,
+		DurationMs:      time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if buf != nil {
+		var resp NetcupBaseResponseMessage
+		if json.Unmarshal(buf.Bytes(), &resp) == nil {
+			entry.ServerRequestId = resp.ServerRequestId
+			entry.Status = resp.Status
+			entry.StatusCode = resp.StatusCode
+			entry.ShortMessage = resp.ShortMessage
+			entry.LongMessage = resp.LongMessage
+		}
+	}
+	c.responses.add(entry)
+
+	outcome := entry.Status
+	if err != nil {
+		outcome = "failed: " + entry.Error
+	} else if entry.Status == string(StatusError) {
+		outcome = fmt.Sprintf("error %d: %s", entry.StatusCode, entry.ShortMessage)
+	}
+	log.Printf("Netcup %s %s in %dms after %d attempt(s) (clientRequestId %s, serverRequestId %s)",
+		action, outcome, entry.DurationMs, attempts, requestId, entry.ServerRequestId)
+}
+
+// Diagnostics returns the circuit breaker state and the most recent API responses
+func (c *NetcupDnsClient) Diagnostics() Diagnostics {
+	return Diagnostics{
+		CircuitBreaker: c.circuitBreaker.Stats(),
+		Responses:      c.responses.list(),
+		Async:          c.async.stats(),
+		Exchanges:      c.exchanges.list(),
+	}
+}
+
+// Stats returns a snapshot of the circuit breaker
+func (cb *CircuitBreaker) Stats() CircuitBreakerStats {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	stats := CircuitBreakerStats{
+		State:        cb.state.String(),
+		FailureCount: cb.failureCount,
+	}
+	if !cb.lastFailureTime.IsZero() {
+		lastFailure := cb.lastFailureTime
+		stats.LastFailure = &lastFailure
+	}
+	return stats
+}
+
+func (a *clientAPI) Diagnostics() Diagnostics {
+	return a.client.Diagnostics()
+}
+
+func (a *clientAPI) ResetCircuitBreaker() bool {
+	return a.client.circuitBreaker.Reset()
+}
+
+func (c *cachingAPI) Diagnostics() Diagnostics {
+	if provider, ok := c.api.(DiagnosticsProvider); ok {
+		return provider.Diagnostics()
+	}
+	return Diagnostics{}
+}
+
+func (c *cachingAPI) ResetCircuitBreaker() bool {
+	if resetter, ok := c.api.(CircuitBreakerResetter); ok {
+		return resetter.ResetCircuitBreaker()
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package netcup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientDiagnostics_RecordsResponses(t *testing.T) {
+	api := NewFakeAPI()
+	api.AddZone("example.com", DnsRecord{Hostname: "www", Type: "A", Destination: "203.0.113.1"})
+	client := newMockClient(t, api)
+
+	session, err := client.Login()
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if _, err := session.InfoDnsRecords("example.com"); err != nil {
+		t.Fatalf("InfoDnsRecords() error = %v", err)
+	}
+	if _, err := session.InfoDnsRecords("missing.com"); err == nil {
+		t.Fatal("InfoDnsRecords() of a missing zone succeeded")
+	}
+
+	diagnostics := NewNetcupAPI(client).(DiagnosticsProvider).Diagnostics()
+	if diagnostics.CircuitBreaker.State != "closed" {
+		t.Errorf("CircuitBreaker.State = %q, want closed", diagnostics.CircuitBreaker.State)
+	}
+
+	wantActions := []string{"login", "infoDnsRecords", "infoDnsRecords"}
+	if len(diagnostics.Responses) != len(wantActions) {
+		t.Fatalf("Responses = %+v, want %d entries", diagnostics.Responses, len(wantActions))
+	}
+	for i, action := range wantActions {
+		entry := diagnostics.Responses[i]
+		if entry.Action != action || entry.Attempts != 1 {
+			t.Errorf("Responses[%d] = %+v, want action %s with 1 attempt", i, entry, action)
+		}
+	}
+	if last := diagnostics.Responses[2]; last.Status != string(StatusError) {
+		t.Errorf("missing zone response status = %q, want %q", last.Status, StatusError)
+	}
+}
+
+func TestClientDiagnostics_RequestIds(t *testing.T) {
+	api := NewFakeAPI()
+	api.AddZone("example.com")
+	server := NewMockServer(api)
+	t.Cleanup(server.Close)
+	client := NewNetcupDnsClientWithOptions(12345, "test-key", "test-password", &NetcupDnsClientOptions{
+		ApiEndpoint:     server.URL,
+		ClientRequestId: "companion",
+		RetryConfig:     &RetryConfig{MaxRetries: 0, BackoffMultiplier: 1},
+	})
+
+	session, err := client.Login()
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if _, err := session.InfoDnsRecords("example.com"); err != nil {
+		t.Fatalf("InfoDnsRecords() error = %v", err)
+	}
+	_, err = session.InfoDnsRecords("missing.com")
+	if err == nil || !strings.Contains(err.Error(), "clientRequestId companion-") || !strings.Contains(err.Error(), "serverRequestId mock-server-request") {
+		t.Errorf("InfoDnsRecords() error = %v, want request ids", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range client.Diagnostics().Responses {
+		if !strings.HasPrefix(entry.ClientRequestId, "companion-") || seen[entry.ClientRequestId] {
+			t.Errorf("ClientRequestId = %q, want a unique id with the configured prefix", entry.ClientRequestId)
+		}
+		seen[entry.ClientRequestId] = true
+		if entry.ServerRequestId != "mock-server-request" {
+			t.Errorf("ServerRequestId = %q, want mock-server-request", entry.ServerRequestId)
+		}
+	}
+	if len(seen) != 3 {
+		t.Errorf("got %d request ids, want 3", len(seen))
+	}
+}
+
+func TestDoPostWithRetry_ErrorCarriesRequestId(t *testing.T) {
+	client := NewNetcupDnsClientWithOptions(12345, "test-key", "test-password", &NetcupDnsClientOptions{
+		ApiEndpoint: "http://127.0.0.1:1",
+		RetryConfig: &RetryConfig{MaxRetries: 0, BackoffMultiplier: 1},
+	})
+
+	_, err := client.doPostWithRetry(context.Background(), actionLogin, client.apiEndpoint, "req-1", &LoginPayload{Action: actionLogin})
+	if err == nil || !strings.Contains(err.Error(), "clientRequestId req-1") {
+		t.Errorf("doPostWithRetry() error = %v, want clientRequestId req-1", err)
+	}
+}
+
+func TestResponseHistory_KeepsMostRecent(t *testing.T) {
+	var history responseHistory
+	for i := 0; i < responseHistorySize+10; i++ {
+		history.add(ResponseEntry{Action: fmt.Sprintf("action-%d", i)})
+	}
+
+	entries := history.list()
+	if len(entries) != responseHistorySize {
+		t.Fatalf("list() returned %d entries, want %d", len(entries), responseHistorySize)
+	}
+	if entries[0].Action != "action-10" {
+		t.Errorf("oldest entry = %s, want action-10", entries[0].Action)
+	}
+	if want := fmt.Sprintf("action-%d", responseHistorySize+9); entries[len(entries)-1].Action != want {
+		t.Errorf("newest entry = %s, want %s", entries[len(entries)-1].Action, want)
+	}
+}
+
+func TestCircuitBreaker_Stats(t *testing.T) {
+	cb := NewCircuitBreaker(2, time.Minute, 1)
+	if stats := cb.Stats(); stats.State != "closed" || stats.LastFailure != nil {
+		t.Errorf("Stats() = %+v, want closed without failures", stats)
+	}
+
+	failure := errors.New("failure")
+	cb.Call(func() error { return failure })
+	cb.Call(func() error { return failure })
+
+	stats := cb.Stats()
+	if stats.State != "open" || stats.FailureCount != 2 || stats.LastFailure == nil {
+		t.Errorf("Stats() = %+v, want open with 2 failures", stats)
+	}
+}
@@ -0,0 +1,136 @@
+package netcup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// domainAPI sends the requests of some domains through the NetcupAPI of their own
+// credentials, e.g. domains managed under another API key of a reseller account
+type domainAPI struct {
+	fallback NetcupAPI
+	domains  map[string]NetcupAPI
+}
+
+// NewDomainAPI returns a NetcupAPI whose sessions send the requests of each domain in
+// domains through its API, and those of all other domains through fallback. Sessions of
+// the per-domain APIs are logged in on first use. Without domains, fallback is returned
+// unchanged.
+func NewDomainAPI(fallback NetcupAPI, domains map[string]NetcupAPI) NetcupAPI {
+	if len(domains) == 0 {
+		return fallback
+	}
+	normalized := make(map[string]NetcupAPI, len(domains))
+	for domain, api := range domains {
+		normalized[strings.ToLower(domain)] = api
+	}
+	return &domainAPI{fallback: fallback, domains: normalized}
+}
+
+func (a *domainAPI) Login(ctx context.Context) (DnsSession, error) {
+	session, err := a.fallback.Login(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &domainSession{ctx: ctx, api: a, fallback: session, sessions: make(map[NetcupAPI]DnsSession)}, nil
+}
+
+func (a *domainAPI) Diagnostics() Diagnostics {
+	if provider, ok := a.fallback.(DiagnosticsProvider); ok {
+		return provider.Diagnostics()
+	}
+	return Diagnostics{}
+}
+
+// ResetCircuitBreaker closes the circuit breakers of all credentials
+func (a *domainAPI) ResetCircuitBreaker() bool {
+	reset := false
+	for _, api := range slices.AppendSeq([]NetcupAPI{a.fallback}, maps.Values(a.domains)) {
+		if resetter, ok := api.(CircuitBreakerResetter); ok && resetter.ResetCircuitBreaker() {
+			reset = true
+		}
+	}
+	return reset
+}
+
+// domainSession dispatches each request to the session of its domain's credentials
+type domainSession struct {
+	ctx      context.Context
+	api      *domainAPI
+	fallback DnsSession
+
+	mu       sync.Mutex
+	sessions map[NetcupAPI]DnsSession // Sessions of the per-domain APIs logged in so far
+}
+
+// session returns the session for domainName, logging in with its credentials on first
+// use. Domains sharing credentials share the session.
+func (s *domainSession) session(domainName string) (DnsSession, error) {
+	api, ok := s.api.domains[strings.ToLower(domainName)]
+	if !ok {
+		return s.fallback, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[api]; ok {
+		return session, nil
+	}
+	session, err := api.Login(s.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("login with the credentials of %s failed: %w", domainName, err)
+	}
+	s.sessions[api] = session
+	return session, nil
+}
+
+func (s *domainSession) InfoDnsZone(domainName string) (*DnsZoneData, error) {
+	session, err := s.session(domainName)
+	if err != nil {
+		return nil, err
+	}
+	return session.InfoDnsZone(domainName)
+}
+
+func (s *domainSession) InfoDnsRecords(domainName string) (*[]DnsRecord, error) {
+	session, err := s.session(domainName)
+	if err != nil {
+		return nil, err
+	}
+	return session.InfoDnsRecords(domainName)
+}
+
+func (s *domainSession) UpdateDnsZone(domainName string, dnsZone *DnsZoneData) (*DnsZoneData, error) {
+	session, err := s.session(domainName)
+	if err != nil {
+		return nil, err
+	}
+	return session.UpdateDnsZone(domainName, dnsZone)
+}
+
+func (s *domainSession) UpdateDnsRecords(domainName string, dnsRecordSet *[]DnsRecord) (*[]DnsRecord, error) {
+	session, err := s.session(domainName)
+	if err != nil {
+		return nil, err
+	}
+	return session.UpdateDnsRecords(domainName, dnsRecordSet)
+}
+
+// Logout ends the sessions of all credentials used
+func (s *domainSession) Logout() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	errs := []error{s.fallback.Logout()}
+	for api, session := range s.sessions {
+		errs = append(errs, session.Logout())
+		delete(s.sessions, api)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,50 @@
+package netcup
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDomainAPI(t *testing.T) {
+	fallback := NewFakeAPI()
+	fallback.AddZone("example.com", DnsRecord{Hostname: "app", Type: "A", Destination: "203.0.113.1"})
+	reseller := NewFakeAPI()
+	reseller.AddZone("example.org", DnsRecord{Hostname: "shop", Type: "A", Destination: "203.0.113.2"})
+	reseller.AddZone("example.net")
+
+	api := NewDomainAPI(fallback, map[string]NetcupAPI{"example.org": reseller, "Example.NET": reseller})
+	session, err := api.Login(context.Background())
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	records, err := session.InfoDnsRecords("example.com")
+	if err != nil || len(*records) != 1 || (*records)[0].Hostname != "app" {
+		t.Fatalf("InfoDnsRecords(example.com) = %v, %v, want the record of the default account", records, err)
+	}
+	if got := reseller.CallCount("login"); got != 0 {
+		t.Errorf("Reseller logins before use = %d, want 0", got)
+	}
+
+	records, err = session.InfoDnsRecords("example.org")
+	if err != nil || len(*records) != 1 || (*records)[0].Hostname != "shop" {
+		t.Fatalf("InfoDnsRecords(example.org) = %v, %v, want the record of the reseller account", records, err)
+	}
+	if _, err := session.UpdateDnsRecords("example.net", &[]DnsRecord{{Hostname: "web", Type: "A", Destination: "203.0.113.3"}}); err != nil {
+		t.Fatalf("UpdateDnsRecords(example.net) error = %v", err)
+	}
+	if len(reseller.Records("example.net")) != 1 {
+		t.Errorf("Reseller records of example.net = %v, want the new record", reseller.Records("example.net"))
+	}
+
+	// Domains sharing credentials share their session
+	if got := reseller.CallCount("login"); got != 1 {
+		t.Errorf("Reseller logins = %d, want 1", got)
+	}
+	if err := session.Logout(); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if fallback.CallCount("logout") != 1 || reseller.CallCount("logout") != 1 {
+		t.Errorf("Logouts = %d default, %d reseller, want 1 each", fallback.CallCount("logout"), reseller.CallCount("logout"))
+	}
+}
@@ -0,0 +1,241 @@
+package netcup
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// FakeAPI is an in-memory NetcupAPI implementation for tests. Records submitted
+// without an Id are created, records with an Id are updated in place and records
+// marked with DeleteRecord are removed, mirroring the Netcup API semantics.
+type FakeAPI struct {
+	mu      sync.Mutex
+	zones   map[string]*DnsZoneData
+	records map[string][]DnsRecord
+	calls   []string
+	nextId  int
+
+	// LoginErr, if set, is returned by Login.
+	LoginErr error
+	// Errors maps an action name (e.g. "updateDnsRecords") to the error it should return.
+	Errors map[string]error
+}
+
+// Creates an empty fake without any zones.
+func NewFakeAPI() *FakeAPI {
+	return &FakeAPI{
+		zones:   make(map[string]*DnsZoneData),
+		records: make(map[string][]DnsRecord),
+		Errors:  make(map[string]error),
+		nextId:  1,
+	}
+}
+
+// AddZone registers a zone with default settings and the given records.
+func (f *FakeAPI) AddZone(domainName string, records ...DnsRecord) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.zones[domainName] = &DnsZoneData{
+		DomainName: domainName,
+		Ttl:        "86400",
+		Serial:     "2026010101",
+		Refresh:    "28800",
+		Retry:      "7200",
+		Expire:     "1209600",
+	}
+	f.records[domainName] = nil
+	for _, record := range records {
+		if record.Id == "" {
+			record.Id = f.newId()
+		}
+		f.records[domainName] = append(f.records[domainName], record)
+	}
+}
+
+// SetDNSSEC marks an existing zone as DNSSEC-signed or unsigned.
+func (f *FakeAPI) SetDNSSEC(domainName string, signed bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if zone, ok := f.zones[domainName]; ok {
+		zone.DnsSecStatus = signed
+	}
+}
+
+// Zone returns a copy of the zone data, or nil if the zone does not exist.
+func (f *FakeAPI) Zone(domainName string) *DnsZoneData {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	zone, ok := f.zones[domainName]
+	if !ok {
+		return nil
+	}
+	copied := *zone
+	return &copied
+}
+
+// Records returns a copy of the records of a zone.
+func (f *FakeAPI) Records(domainName string) []DnsRecord {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]DnsRecord(nil), f.records[domainName]...)
+}
+
+// CallCount returns how often the given action (e.g. "login") was invoked.
+func (f *FakeAPI) CallCount(action string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	count := 0
+	for _, call := range f.calls {
+		if call == action {
+			count++
+		}
+	}
+	return count
+}
+
+func (f *FakeAPI) Login(ctx context.Context) (DnsSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, string(actionLogin))
+	if f.LoginErr != nil {
+		return nil, f.LoginErr
+	}
+	return &fakeSession{api: f}, nil
+}
+
+// begin records a call and returns the injected error for it, if any. Callers must hold f.mu.
+func (f *FakeAPI) begin(action RequestAction) error {
+	f.calls = append(f.calls, string(action))
+	return f.Errors[string(action)]
+}
+
+// bumpSerial increments the zone serial, as Netcup does on every record change. Callers
+// must hold f.mu.
+func (f *FakeAPI) bumpSerial(domainName string) {
+	serial, _ := strconv.Atoi(f.zones[domainName].Serial)
+	f.zones[domainName].Serial = strconv.Itoa(serial + 1)
+}
+
+func (f *FakeAPI) newId() string {
+	id := strconv.Itoa(f.nextId)
+	f.nextId++
+	return id
+}
+
+func (f *FakeAPI) zoneNotFound(reqType, domainName string) error {
+	return fmt.Errorf("%s failed: (5029) 'error' 'Can not get DNS records for zone.' 'Domain %s not found'", reqType, domainName)
+}
+
+// fakeSession is the DnsSession returned by FakeAPI.Login.
+type fakeSession struct {
+	api *FakeAPI
+}
+
+func (s *fakeSession) InfoDnsZone(domainName string) (*DnsZoneData, error) {
+	f := s.api
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.begin(actionInfoDnsZone); err != nil {
+		return nil, err
+	}
+	zone, ok := f.zones[domainName]
+	if !ok {
+		return nil, f.zoneNotFound("InfoDnsZone", domainName)
+	}
+	copied := *zone
+	return &copied, nil
+}
+
+func (s *fakeSession) InfoDnsRecords(domainName string) (*[]DnsRecord, error) {
+	f := s.api
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	emptyRecs := make([]DnsRecord, 0)
+	if err := f.begin(actionInfoDnsRecords); err != nil {
+		return &emptyRecs, err
+	}
+	if _, ok := f.zones[domainName]; !ok {
+		return &emptyRecs, f.zoneNotFound("InfoDnsRecords", domainName)
+	}
+	records := append(emptyRecs, f.records[domainName]...)
+	return &records, nil
+}
+
+func (s *fakeSession) UpdateDnsZone(domainName string, dnsZone *DnsZoneData) (*DnsZoneData, error) {
+	f := s.api
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.begin(actionUpdateDnsZone); err != nil {
+		return nil, err
+	}
+	if _, ok := f.zones[domainName]; !ok {
+		return nil, f.zoneNotFound("UpdateDnsZone", domainName)
+	}
+	updated := *dnsZone
+	updated.DomainName = domainName
+	f.zones[domainName] = &updated
+	copied := updated
+	return &copied, nil
+}
+
+func (s *fakeSession) UpdateDnsRecords(domainName string, dnsRecordSet *[]DnsRecord) (*[]DnsRecord, error) {
+	f := s.api
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	emptyRecs := make([]DnsRecord, 0)
+	if err := f.begin(actionUpdateDnsRecords); err != nil {
+		return &emptyRecs, err
+	}
+	if _, ok := f.zones[domainName]; !ok {
+		return &emptyRecs, f.zoneNotFound("UpdateDnsRecords", domainName)
+	}
+
+	records := f.records[domainName]
+	for _, change := range *dnsRecordSet {
+		idx := -1
+		for i, existing := range records {
+			if change.Id != "" && existing.Id == change.Id {
+				idx = i
+				break
+			}
+		}
+
+		switch {
+		case change.DeleteRecord:
+			if idx < 0 {
+				return &emptyRecs, fmt.Errorf("UpdateDnsRecords failed: (5030) 'error' 'Record not found' 'Record %s does not exist'", change.Id)
+			}
+			records = append(records[:idx], records[idx+1:]...)
+		case idx >= 0:
+			records[idx] = change
+		default:
+			change.Id = f.newId()
+			records = append(records, change)
+		}
+	}
+	f.records[domainName] = records
+	f.bumpSerial(domainName)
+
+	result := append(emptyRecs, records...)
+	return &result, nil
+}
+
+func (s *fakeSession) Logout() error {
+	f := s.api
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.begin(actionLogout)
+}
@@ -0,0 +1,118 @@
+package netcup
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFakeAPI_RecordLifecycle(t *testing.T) {
+	api := NewFakeAPI()
+	api.AddZone("example.com", DnsRecord{Hostname: "www", Type: "A", Destination: "203.0.113.1"})
+
+	session, err := api.Login(context.Background())
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	records, err := session.InfoDnsRecords("example.com")
+	if err != nil {
+		t.Fatalf("InfoDnsRecords() error = %v", err)
+	}
+	if len(*records) != 1 || (*records)[0].Id == "" {
+		t.Fatalf("InfoDnsRecords() = %v, want one record with an Id", *records)
+	}
+	www := (*records)[0]
+
+	// Create a record without Id and update the existing one by Id
+	www.Destination = "198.51.100.1"
+	updated, err := session.UpdateDnsRecords("example.com", &[]DnsRecord{
+		{Hostname: "app", Type: "A", Destination: "203.0.113.1"},
+		www,
+	})
+	if err != nil {
+		t.Fatalf("UpdateDnsRecords() error = %v", err)
+	}
+	if len(*updated) != 2 {
+		t.Fatalf("UpdateDnsRecords() returned %d records, want 2", len(*updated))
+	}
+	if api.Records("example.com")[0].Destination != "198.51.100.1" {
+		t.Errorf("Record was not updated in place: %v", api.Records("example.com"))
+	}
+
+	// Delete by Id
+	www.DeleteRecord = true
+	if _, err := session.UpdateDnsRecords("example.com", &[]DnsRecord{www}); err != nil {
+		t.Fatalf("UpdateDnsRecords() delete error = %v", err)
+	}
+	remaining := api.Records("example.com")
+	if len(remaining) != 1 || remaining[0].Hostname != "app" {
+		t.Errorf("Records after delete = %v, want only app", remaining)
+	}
+
+	// Deleting an unknown record fails
+	if _, err := session.UpdateDnsRecords("example.com", &[]DnsRecord{{Id: "999", DeleteRecord: true}}); err == nil {
+		t.Error("UpdateDnsRecords() deleting unknown record should fail")
+	}
+
+	if err := session.Logout(); err != nil {
+		t.Errorf("Logout() error = %v", err)
+	}
+}
+
+func TestFakeAPI_UnknownZone(t *testing.T) {
+	api := NewFakeAPI()
+	session, _ := api.Login(context.Background())
+
+	if _, err := session.InfoDnsZone("missing.com"); err == nil {
+		t.Error("InfoDnsZone() for unknown zone should fail")
+	}
+	if _, err := session.InfoDnsRecords("missing.com"); err == nil {
+		t.Error("InfoDnsRecords() for unknown zone should fail")
+	}
+	if api.Zone("missing.com") != nil {
+		t.Error("Zone() for unknown zone should return nil")
+	}
+}
+
+func TestFakeAPI_ErrorInjection(t *testing.T) {
+	api := NewFakeAPI()
+	api.AddZone("example.com")
+
+	api.LoginErr = errors.New("invalid credentials")
+	if _, err := api.Login(context.Background()); err == nil {
+		t.Error("Login() should return injected error")
+	}
+
+	api.LoginErr = nil
+	api.Errors["infoDnsZone"] = errors.New("maintenance")
+	session, err := api.Login(context.Background())
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if _, err := session.InfoDnsZone("example.com"); err == nil || err.Error() != "maintenance" {
+		t.Errorf("InfoDnsZone() error = %v, want injected error", err)
+	}
+
+	if api.CallCount("login") != 2 {
+		t.Errorf("CallCount(login) = %d, want 2", api.CallCount("login"))
+	}
+	if api.CallCount("infoDnsZone") != 1 {
+		t.Errorf("CallCount(infoDnsZone) = %d, want 1", api.CallCount("infoDnsZone"))
+	}
+}
+
+func TestFakeAPI_UpdateDnsZone(t *testing.T) {
+	api := NewFakeAPI()
+	api.AddZone("example.com")
+	session, _ := api.Login(context.Background())
+
+	zone, _ := session.InfoDnsZone("example.com")
+	zone.Ttl = "300"
+	if _, err := session.UpdateDnsZone("example.com", zone); err != nil {
+		t.Fatalf("UpdateDnsZone() error = %v", err)
+	}
+	if api.Zone("example.com").Ttl != "300" {
+		t.Errorf("Ttl = %v, want 300", api.Zone("example.com").Ttl)
+	}
+}
@@ -0,0 +1,219 @@
+package netcup
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// keepAliveAPI wraps a NetcupAPI and shares a single session across all logins,
+// periodically pinging it so it does not expire while idle. The session is replaced
+// whenever a request on it fails.
+type keepAliveAPI struct {
+	api      NetcupAPI
+	interval time.Duration
+
+	// ctx is the context of the shared session, which outlives the callers; it is
+	// cancelled when RunKeepAlive returns
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu         sync.Mutex // Guards the fields below; requests run without holding it
+	session    DnsSession
+	lastDomain string // Domain the keep-alive ping queries
+	lastUsed   time.Time
+}
+
+// KeepAliveRunner is implemented by NetcupAPI implementations that keep a session alive
+type KeepAliveRunner interface {
+	RunKeepAlive(ctx context.Context)
+}
+
+// NewKeepAliveAPI returns a NetcupAPI that reuses one session and pings it every
+// interval while idle. Logout on a returned session is a no-op; the shared session is
+// logged out when RunKeepAlive returns. An interval <= 0 returns api unchanged.
+func NewKeepAliveAPI(api NetcupAPI, interval time.Duration) NetcupAPI {
+	if interval <= 0 {
+		return api
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &keepAliveAPI{api: api, interval: interval, ctx: ctx, cancel: cancel}
+}
+
+func (k *keepAliveAPI) Login(ctx context.Context) (DnsSession, error) {
+	if _, err := k.current(ctx, ""); err != nil {
+		return nil, err
+	}
+	return &sharedSession{api: k}, nil
+}
+
+// current returns the shared session, logging in if there is none. The login is
+// abandoned when either ctx or the keep-alive context is done.
+func (k *keepAliveAPI) current(ctx context.Context, domainName string) (DnsSession, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.session == nil {
+		session, err := k.login(ctx)
+		if err != nil {
+			return nil, err
+		}
+		k.session = session
+	}
+	if domainName != "" {
+		k.lastDomain = domainName
+	}
+	k.lastUsed = time.Now()
+	return k.session, nil
+}
+
+// login logs in a new shared session. The session keeps using the context it was
+// logged in with, so that context is derived from k.ctx; ctx only bounds the login.
+func (k *keepAliveAPI) login(ctx context.Context) (DnsSession, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	loginCtx, cancel := context.WithCancel(k.ctx)
+	stop := context.AfterFunc(ctx, cancel)
+	session, err := k.api.Login(loginCtx)
+	if !stop() {
+		// ctx was done during the login, which cancelled the session as well
+		if err == nil {
+			session.Logout()
+		}
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return session, nil
+}
+
+// drop discards session after a failed request, unless it was already replaced
+func (k *keepAliveAPI) drop(session DnsSession) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.session == session {
+		k.session = nil
+	}
+}
+
+// RunKeepAlive pings the shared session whenever it was idle for the interval, and
+// logs it out once ctx is done. It blocks until ctx is done.
+func (k *keepAliveAPI) RunKeepAlive(ctx context.Context) {
+	log.Printf("Netcup session keep-alive enabled, pinging idle sessions every %s", k.interval)
+
+	ticker := time.NewTicker(k.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			k.mu.Lock()
+			session := k.session
+			k.session = nil
+			k.mu.Unlock()
+			if session != nil {
+				session.Logout()
+			}
+			k.cancel()
+			return
+		case <-ticker.C:
+			k.ping()
+		}
+	}
+}
+
+// ping issues a lightweight zone query on an idle session
+func (k *keepAliveAPI) ping() {
+	k.mu.Lock()
+	session, domainName := k.session, k.lastDomain
+	idle := time.Since(k.lastUsed) >= k.interval
+	k.mu.Unlock()
+
+	if session == nil || domainName == "" || !idle {
+		return
+	}
+
+	if _, err := session.InfoDnsZone(domainName); err != nil {
+		log.Printf("Netcup session keep-alive failed, logging in again on next use: %v", err)
+		k.drop(session)
+		return
+	}
+
+	k.mu.Lock()
+	k.lastUsed = time.Now()
+	k.mu.Unlock()
+}
+
+// do runs fn on the shared session and drops the session if fn fails, as it may
+// have expired. Requests of several callers run concurrently.
+func (k *keepAliveAPI) do(domainName string, fn func(DnsSession) error) error {
+	session, err := k.current(k.ctx, domainName)
+	if err != nil {
+		return err
+	}
+	if err := fn(session); err != nil {
+		k.drop(session)
+		return err
+	}
+	return nil
+}
+
+func (k *keepAliveAPI) Diagnostics() Diagnostics {
+	if provider, ok := k.api.(DiagnosticsProvider); ok {
+		return provider.Diagnostics()
+	}
+	return Diagnostics{}
+}
+
+func (k *keepAliveAPI) ResetCircuitBreaker() bool {
+	if resetter, ok := k.api.(CircuitBreakerResetter); ok {
+		return resetter.ResetCircuitBreaker()
+	}
+	return false
+}
+
+// sharedSession is the DnsSession returned by keepAliveAPI.Login
+type sharedSession struct {
+	api *keepAliveAPI
+}
+
+func (s *sharedSession) InfoDnsZone(domainName string) (zone *DnsZoneData, err error) {
+	err = s.api.do(domainName, func(session DnsSession) error {
+		zone, err = session.InfoDnsZone(domainName)
+		return err
+	})
+	return zone, err
+}
+
+func (s *sharedSession) InfoDnsRecords(domainName string) (records *[]DnsRecord, err error) {
+	err = s.api.do(domainName, func(session DnsSession) error {
+		records, err = session.InfoDnsRecords(domainName)
+		return err
+	})
+	return records, err
+}
+
+func (s *sharedSession) UpdateDnsZone(domainName string, dnsZone *DnsZoneData) (zone *DnsZoneData, err error) {
+	err = s.api.do(domainName, func(session DnsSession) error {
+		zone, err = session.UpdateDnsZone(domainName, dnsZone)
+		return err
+	})
+	return zone, err
+}
+
+func (s *sharedSession) UpdateDnsRecords(domainName string, dnsRecordSet *[]DnsRecord) (records *[]DnsRecord, err error) {
+	err = s.api.do(domainName, func(session DnsSession) error {
+		records, err = session.UpdateDnsRecords(domainName, dnsRecordSet)
+		return err
+	})
+	return records, err
+}
+
+// Logout keeps the shared session open for later logins
+func (s *sharedSession) Logout() error {
+	return nil
+}
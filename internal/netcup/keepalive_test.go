@@ -0,0 +1,193 @@
+package netcup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewKeepAliveAPI_Disabled(t *testing.T) {
+	api := NewFakeAPI()
+	if got := NewKeepAliveAPI(api, 0); got != NetcupAPI(api) {
+		t.Error("NewKeepAliveAPI() with interval 0 should return the api unchanged")
+	}
+}
+
+func TestKeepAliveAPI_SharesSession(t *testing.T) {
+	api := NewFakeAPI()
+	api.AddZone("example.com")
+	client := NewKeepAliveAPI(api, time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		session, err := client.Login(ctx)
+		if err != nil {
+			t.Fatalf("Login() error = %v", err)
+		}
+		if _, err := session.InfoDnsRecords("example.com"); err != nil {
+			t.Fatalf("InfoDnsRecords() error = %v", err)
+		}
+		session.Logout()
+	}
+
+	if got := api.CallCount("login"); got != 1 {
+		t.Errorf("login calls = %d, want 1", got)
+	}
+	if got := api.CallCount("logout"); got != 0 {
+		t.Errorf("logout calls = %d, want 0", got)
+	}
+
+	// A failed request drops the session, the next request logs in again
+	api.Errors["infoDnsZone"] = errors.New("session expired")
+	session, _ := client.Login(ctx)
+	if _, err := session.InfoDnsZone("example.com"); err == nil {
+		t.Fatal("InfoDnsZone() error = nil, want injected error")
+	}
+	delete(api.Errors, "infoDnsZone")
+	if _, err := session.InfoDnsZone("example.com"); err != nil {
+		t.Fatalf("InfoDnsZone() after failure error = %v", err)
+	}
+	if got := api.CallCount("login"); got != 2 {
+		t.Errorf("login calls after failure = %d, want 2", got)
+	}
+}
+
+func TestKeepAliveAPI_Ping(t *testing.T) {
+	api := NewFakeAPI()
+	api.AddZone("example.com")
+	client := NewKeepAliveAPI(api, time.Minute).(*keepAliveAPI)
+
+	// Nothing to ping before the first login
+	client.ping()
+	if got := api.CallCount("infoDnsZone"); got != 0 {
+		t.Fatalf("infoDnsZone calls = %d, want 0", got)
+	}
+
+	session, err := client.Login(context.Background())
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if _, err := session.InfoDnsRecords("example.com"); err != nil {
+		t.Fatalf("InfoDnsRecords() error = %v", err)
+	}
+
+	// A recently used session is not pinged
+	client.ping()
+	if got := api.CallCount("infoDnsZone"); got != 0 {
+		t.Errorf("infoDnsZone calls for a busy session = %d, want 0", got)
+	}
+
+	client.lastUsed = time.Now().Add(-2 * time.Minute)
+	client.ping()
+	if got := api.CallCount("infoDnsZone"); got != 1 {
+		t.Errorf("infoDnsZone calls for an idle session = %d, want 1", got)
+	}
+	if got := api.CallCount("login"); got != 1 {
+		t.Errorf("login calls = %d, want 1", got)
+	}
+}
+
+func TestKeepAliveAPI_RunKeepAliveLogsOut(t *testing.T) {
+	api := NewFakeAPI()
+	client := NewKeepAliveAPI(api, time.Minute)
+	if _, err := client.Login(context.Background()); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client.(KeepAliveRunner).RunKeepAlive(ctx)
+
+	if got := api.CallCount("logout"); got != 1 {
+		t.Errorf("logout calls = %d, want 1", got)
+	}
+}
+
+// blockingAPI is a NetcupAPI whose InfoDnsRecords blocks until released, recording
+// the context sessions were logged in with
+type blockingAPI struct {
+	*FakeAPI
+	release  chan struct{}
+	started  chan struct{}
+	loginCtx context.Context
+}
+
+func (b *blockingAPI) Login(ctx context.Context) (DnsSession, error) {
+	b.loginCtx = ctx
+	session, err := b.FakeAPI.Login(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &blockingSession{DnsSession: session, api: b}, nil
+}
+
+type blockingSession struct {
+	DnsSession
+	api *blockingAPI
+}
+
+func (s *blockingSession) InfoDnsRecords(domainName string) (*[]DnsRecord, error) {
+	s.api.started <- struct{}{}
+	<-s.api.release
+	return s.DnsSession.InfoDnsRecords(domainName)
+}
+
+func TestKeepAliveAPI_ConcurrentRequests(t *testing.T) {
+	api := &blockingAPI{FakeAPI: NewFakeAPI(), release: make(chan struct{}), started: make(chan struct{})}
+	api.AddZone("example.com")
+	client := NewKeepAliveAPI(api, time.Minute)
+
+	loginCtx, cancelLogin := context.WithCancel(context.Background())
+	session, err := client.Login(loginCtx)
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	// The shared session outlives the caller that logged it in
+	cancelLogin()
+
+	const requests = 3
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		go func() {
+			_, err := session.InfoDnsRecords("example.com")
+			errs <- err
+		}()
+	}
+
+	// All requests are in flight at once instead of waiting for each other
+	for i := 0; i < requests; i++ {
+		select {
+		case <-api.started:
+		case <-time.After(time.Second):
+			t.Fatalf("only %d of %d requests started concurrently", i, requests)
+		}
+	}
+	close(api.release)
+	for i := 0; i < requests; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("InfoDnsRecords() error = %v", err)
+		}
+	}
+
+	if api.loginCtx.Err() != nil {
+		t.Error("Session context cancelled with the caller's context")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client.(KeepAliveRunner).RunKeepAlive(ctx)
+	if api.loginCtx.Err() == nil {
+		t.Error("Session context not cancelled at shutdown")
+	}
+}
+
+func TestKeepAliveAPI_LoginCancelled(t *testing.T) {
+	api := NewFakeAPI()
+	client := NewKeepAliveAPI(api, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.Login(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Login() with cancelled context error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,108 @@
+package netcup
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+)
+
+// NewMockServer starts an httptest server speaking the Netcup JSON protocol, backed
+// by the given fake. Point a client at it via NetcupDnsClientOptions.ApiEndpoint.
+// The caller must Close the returned server.
+func NewMockServer(fake *FakeAPI) *httptest.Server {
+	return httptest.NewServer(NewMockHandler(fake))
+}
+
+// NewMockHandler returns the handler behind NewMockServer, for servers that wrap it
+func NewMockHandler(fake *FakeAPI) http.Handler {
+	return &mockHandler{fake: fake}
+}
+
+type mockHandler struct {
+	fake *FakeAPI
+}
+
+// mockRequest captures the fields of any Netcup request payload the mock needs
+type mockRequest struct {
+	Action RequestAction `json:"action"`
+	Params struct {
+		ClientRequestId string        `json:"clientrequestid"`
+		DomainName      string        `json:"domainname"`
+		DnsZone         *DnsZoneData  `json:"dnszone"`
+		DnsRecordSet    *DnsRecordSet `json:"dnsrecordset"`
+	} `json:"param"`
+}
+
+type mockResponse struct {
+	NetcupBaseResponseMessage
+	ResponseData interface{} `json:"responsedata"`
+}
+
+func (h *mockHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req mockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var data interface{} = ""
+	var err error
+	session := &fakeSession{api: h.fake}
+
+	switch req.Action {
+	case actionLogin:
+		if _, err = h.fake.Login(r.Context()); err == nil {
+			data = &LoginResponseData{ApiSessionId: "mock-session"}
+		}
+	case actionLogout:
+		err = session.Logout()
+	case actionInfoDnsZone:
+		data, err = session.InfoDnsZone(req.Params.DomainName)
+	case actionInfoDnsRecords:
+		var records *[]DnsRecord
+		if records, err = session.InfoDnsRecords(req.Params.DomainName); err == nil {
+			data = &InfoDnsRecordsResponseData{DnsRecords: *records}
+		}
+	case actionUpdateDnsZone:
+		data, err = session.UpdateDnsZone(req.Params.DomainName, req.Params.DnsZone)
+	case actionUpdateDnsRecords:
+		recordSet := []DnsRecord{}
+		if req.Params.DnsRecordSet != nil {
+			recordSet = req.Params.DnsRecordSet.Content
+		}
+		var records *[]DnsRecord
+		if records, err = session.UpdateDnsRecords(req.Params.DomainName, &recordSet); err == nil {
+			data = &UpdateDnsRecordsResponseData{DnsRecords: *records}
+		}
+	default:
+		http.Error(w, "unknown action", http.StatusBadRequest)
+		return
+	}
+
+	resp := mockResponse{
+		NetcupBaseResponseMessage: NetcupBaseResponseMessage{
+			ServerRequestId: "mock-server-request",
+			ClientRequestId: req.Params.ClientRequestId,
+			Action:          string(req.Action),
+			Status:          string(StatusSuccess),
+			StatusCode:      2000,
+			ShortMessage:    "Success",
+		},
+		ResponseData: data,
+	}
+	if err != nil {
+		resp.Status = string(StatusError)
+		resp.StatusCode = 4013
+		resp.ShortMessage = "Request failed"
+		resp.LongMessage = err.Error()
+		resp.ResponseData = ""
+	}
+
+	w.Header().Set("Content-Type", netcupApiContentType)
+	json.NewEncoder(w).Encode(resp)
+}
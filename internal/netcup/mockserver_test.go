@@ -0,0 +1,117 @@
+package netcup
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func newMockClient(t *testing.T, api *FakeAPI) *NetcupDnsClient {
+	t.Helper()
+
+	server := NewMockServer(api)
+	t.Cleanup(server.Close)
+
+	return NewNetcupDnsClientWithOptions(12345, "test-key", "test-password", &NetcupDnsClientOptions{
+		ApiEndpoint: server.URL,
+		RetryConfig: &RetryConfig{MaxRetries: 0, BackoffMultiplier: 1},
+	})
+}
+
+func TestMockServer_Roundtrip(t *testing.T) {
+	api := NewFakeAPI()
+	api.AddZone("example.com", DnsRecord{Hostname: "www", Type: "A", Destination: "203.0.113.1"})
+	client := newMockClient(t, api)
+
+	session, err := client.Login()
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if session.apiSessionId != "mock-session" {
+		t.Errorf("apiSessionId = %v, want mock-session", session.apiSessionId)
+	}
+
+	zone, err := session.InfoDnsZone("example.com")
+	if err != nil {
+		t.Fatalf("InfoDnsZone() error = %v", err)
+	}
+	if zone.DomainName != "example.com" {
+		t.Errorf("DomainName = %v, want example.com", zone.DomainName)
+	}
+
+	records, err := session.InfoDnsRecords("example.com")
+	if err != nil {
+		t.Fatalf("InfoDnsRecords() error = %v", err)
+	}
+	if len(*records) != 1 || (*records)[0].Destination != "203.0.113.1" {
+		t.Fatalf("InfoDnsRecords() = %v, want www record", *records)
+	}
+
+	updated, err := session.UpdateDnsRecords("example.com", &[]DnsRecord{{Hostname: "app", Type: "A", Destination: "203.0.113.2"}})
+	if err != nil {
+		t.Fatalf("UpdateDnsRecords() error = %v", err)
+	}
+	if len(*updated) != 2 {
+		t.Errorf("UpdateDnsRecords() returned %d records, want 2", len(*updated))
+	}
+
+	zone.Ttl = "600"
+	if _, err := session.UpdateDnsZone("example.com", zone); err != nil {
+		t.Fatalf("UpdateDnsZone() error = %v", err)
+	}
+	if api.Zone("example.com").Ttl != "600" {
+		t.Errorf("Ttl = %v, want 600", api.Zone("example.com").Ttl)
+	}
+
+	if err := session.Logout(); err != nil {
+		t.Errorf("Logout() error = %v", err)
+	}
+}
+
+func TestMockServer_Errors(t *testing.T) {
+	api := NewFakeAPI()
+	api.AddZone("example.com")
+	client := newMockClient(t, api)
+
+	api.LoginErr = errors.New("invalid credentials")
+	if _, err := client.Login(); err == nil {
+		t.Error("Login() should fail when the mock rejects credentials")
+	}
+
+	api.LoginErr = nil
+	session, err := client.Login()
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	if _, err := session.InfoDnsZone("missing.com"); err == nil {
+		t.Error("InfoDnsZone() for unknown zone should fail")
+	}
+	if session.LastResponse.Status != string(StatusError) {
+		t.Errorf("LastResponse.Status = %v, want error", session.LastResponse.Status)
+	}
+}
+
+func TestNewNetcupAPI(t *testing.T) {
+	api := NewFakeAPI()
+	api.AddZone("example.com")
+	client := newMockClient(t, api)
+
+	var netcupAPI NetcupAPI = NewNetcupAPI(client)
+	session, err := netcupAPI.Login(context.Background())
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if _, err := session.InfoDnsRecords("example.com"); err != nil {
+		t.Errorf("InfoDnsRecords() error = %v", err)
+	}
+
+	api.LoginErr = errors.New("invalid credentials")
+	session, err = netcupAPI.Login(context.Background())
+	if err == nil {
+		t.Error("Login() should fail")
+	}
+	if session != nil {
+		t.Error("Login() should return a nil session interface on failure")
+	}
+}
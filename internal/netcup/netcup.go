@@ -5,14 +5,23 @@ package netcup
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"net"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -24,6 +33,9 @@ const (
 	defaultRequestTimeout = 30 * time.Second
 )
 
+// tracer creates a span for every Netcup API action, including retries
+var tracer = otel.Tracer("github.com/alex289/docker-traefik-netcup-companion/internal/netcup")
+
 // Type for action field of a request payload
 type RequestAction string
 
@@ -47,6 +59,17 @@ const (
 	actionUpdateDnsRecords RequestAction = "updateDnsRecords"
 )
 
+// newRequestId returns a unique clientRequestId for one API call, so the call can be
+// found in Netcup's logs. The configured ClientRequestId is used as prefix.
+func (c *NetcupDnsClient) newRequestId() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	if c.clientRequestId != "" {
+		return c.clientRequestId + "-" + hex.EncodeToString(b)
+	}
+	return hex.EncodeToString(b)
+}
+
 // Holder for Netcup DNS client context.
 type NetcupDnsClient struct {
 	customerNumber  int
@@ -57,6 +80,12 @@ type NetcupDnsClient struct {
 	retryConfig     *RetryConfig
 	circuitBreaker  *CircuitBreaker
 	httpClient      *http.Client
+	responses       responseHistory  // Recent responses for diagnostics
+	debugHTTP       bool             // Log request and response bodies, redacted
+	exchanges       *exchangeHistory // Recent redacted exchanges for diagnostics, nil if disabled
+	pollInterval    time.Duration    // Delay between polls of asynchronous operations
+	pollTimeout     time.Duration    // Time asynchronous operations get to complete
+	async           asyncCounters    // Asynchronous operations for diagnostics
 }
 
 // RetryConfig holds retry and backoff configuration
@@ -76,6 +105,19 @@ const (
 	StateHalfOpen
 )
 
+func (s CircuitBreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
 // CircuitBreaker implements circuit breaker pattern
 type CircuitBreaker struct {
 	mu              sync.RWMutex
@@ -86,6 +128,8 @@ type CircuitBreaker struct {
 	threshold       int           // consecutive failures to open circuit
 	timeout         time.Duration // how long to wait before half-open
 	halfOpenMaxReqs int           // max requests to allow in half-open state
+
+	onStateChange func(from, to CircuitBreakerState)
 }
 
 // ErrCircuitOpen is returned when circuit breaker is open
@@ -94,23 +138,50 @@ var ErrCircuitOpen = errors.New("circuit breaker is open")
 // ErrRateLimitExceeded is returned when rate limit is hit
 var ErrRateLimitExceeded = errors.New("rate limit exceeded")
 
+// ErrLoginRejected is returned when the API answers a login with an error, e.g. for
+// wrong credentials, as opposed to a login that did not reach the API
+var ErrLoginRejected = errors.New("login rejected")
+
+// ErrMaintenance is returned while the Netcup API is down for scheduled maintenance.
+// Such requests are retried like other errors but not counted by the circuit breaker.
+var ErrMaintenance = errors.New("Netcup API is in maintenance")
+
+// maintenanceMarkers are found in the responses of the Netcup API during maintenance
+var maintenanceMarkers = []string{"maintenance", "wartung"}
+
 // Additional optional flags for client creation
 type NetcupDnsClientOptions struct {
-	ClientRequestId string
+	ClientRequestId string // Prefix of the clientRequestId generated for every call
 	ApiEndpoint     string // useful for testing
 	RetryConfig     *RetryConfig
 	CircuitBreaker  *CircuitBreaker
 	HTTPClient      *http.Client
+	ProxyURL        *url.URL      // Proxy for API requests, credentials in the user info; nil uses HTTPS_PROXY/NO_PROXY
+	DebugHTTP       bool          // Log every request and response body with credentials and session ids redacted
+	DebugHistory    int           // Number of redacted exchanges kept for diagnostics, 0 disables
+	PollInterval    time.Duration // Delay between polls of operations Netcup reports as started or pending (default: 2s)
+	PollTimeout     time.Duration // Time such operations get to complete before failing (default: 2m)
 }
 
 // Netcup session context object to hold session information, like apiSessionId or last response.
 type NetcupSession struct {
+	ctx            context.Context // Context for all requests of the session
 	apiSessionId   string
 	apiKey         string
 	customerNumber int
 	endpoint       string
-	LastResponse   *NetcupBaseResponseMessage
 	client         *NetcupDnsClient
+
+	// LastResponse is the response of the latest request. Requests may run concurrently
+	// on a shared session, so it is only written while holding responseMu.
+	LastResponse *NetcupBaseResponseMessage
+	responseMu   sync.Mutex
+}
+
+func (s *NetcupSession) setLastResponse(br *NetcupBaseResponseMessage) {
+	s.responseMu.Lock()
+	defer s.responseMu.Unlock()
+	s.LastResponse = br
 }
 
 // DnsZoneData holds information about a DNS zone of a domain.
@@ -289,9 +360,17 @@ func NewNetcupDnsClientWithOptions(customerNumber int, apiKey string, apiPasswor
 		circuitBreaker = opts.CircuitBreaker
 	}
 
+	// The dial timeout applies to the proxy connection, and the request timeout also
+	// bounds the CONNECT handshake through the proxy
+	proxy := http.ProxyFromEnvironment
+	if opts.ProxyURL != nil {
+		proxy = http.ProxyURL(opts.ProxyURL)
+	}
+
 	httpClient := &http.Client{
 		Timeout: defaultRequestTimeout,
 		Transport: &http.Transport{
+			Proxy: proxy,
 			DialContext: (&net.Dialer{
 				Timeout:   10 * time.Second,
 				KeepAlive: 30 * time.Second,
@@ -314,6 +393,8 @@ func NewNetcupDnsClientWithOptions(customerNumber int, apiKey string, apiPasswor
 		retryConfig:    retryConfig,
 		circuitBreaker: circuitBreaker,
 		httpClient:     httpClient,
+		pollInterval:   defaultPollInterval,
+		pollTimeout:    defaultPollTimeout,
 	}
 
 	if opts.ApiEndpoint != "" {
@@ -322,6 +403,16 @@ func NewNetcupDnsClientWithOptions(customerNumber int, apiKey string, apiPasswor
 	if opts.ClientRequestId != "" {
 		client.clientRequestId = opts.ClientRequestId
 	}
+	client.debugHTTP = opts.DebugHTTP
+	if opts.DebugHistory > 0 {
+		client.exchanges = &exchangeHistory{size: opts.DebugHistory}
+	}
+	if opts.PollInterval > 0 {
+		client.pollInterval = opts.PollInterval
+	}
+	if opts.PollTimeout > 0 {
+		client.pollTimeout = opts.PollTimeout
+	}
 
 	return client
 }
@@ -332,22 +423,33 @@ func NewNetcupDnsClientWithOptions(customerNumber int, apiKey string, apiPasswor
 
 // Login to Netcup API. Returns a valid NetcupSession or error.
 func (c *NetcupDnsClient) Login() (*NetcupSession, error) {
-	if buf, err := c.doPostWithRetry(c.apiEndpoint, &LoginPayload{
+	return c.LoginContext(context.Background())
+}
+
+// LoginContext logs in to the Netcup API. All requests of the returned session use ctx,
+// so they are cancelled with it and traced as its children.
+func (c *NetcupDnsClient) LoginContext(ctx context.Context) (*NetcupSession, error) {
+	requestId := c.newRequestId()
+	if buf, err := c.doPostWithRetry(ctx, actionLogin, c.apiEndpoint, requestId, &LoginPayload{
 		Action: actionLogin,
 		Params: &LoginParams{
 			CustomerNumber:  c.customerNumber,
 			ApiKey:          c.apiKey,
 			ApiPassword:     c.apiPassword,
-			ClientRequestId: c.clientRequestId,
+			ClientRequestId: requestId,
 		},
 	}); err != nil {
 		return nil, err
 	} else {
 		lr := &LoginResponseData{}
 		if br, err := handleResponse("Login", buf, lr); err != nil {
+			if br != nil && br.Status == string(StatusError) && !errors.Is(err, ErrMaintenance) {
+				return nil, fmt.Errorf("%w: %w", ErrLoginRejected, err)
+			}
 			return nil, err
 		} else {
 			return &NetcupSession{
+				ctx:            ctx,
 				apiSessionId:   lr.ApiSessionId,
 				apiKey:         c.apiKey,
 				customerNumber: c.customerNumber,
@@ -361,14 +463,15 @@ func (c *NetcupDnsClient) Login() (*NetcupSession, error) {
 
 // Query information about DNS zone.
 func (s *NetcupSession) InfoDnsZone(domainName string) (*DnsZoneData, error) {
-	if buf, err := s.client.doPostWithRetry(s.endpoint, &InfoDnsZonePayload{
+	requestId := s.client.newRequestId()
+	if buf, err := s.client.doPostWithRetry(s.context(), actionInfoDnsZone, s.endpoint, requestId, &InfoDnsZonePayload{
 		Action: actionInfoDnsZone,
 		Params: &InfoDnsZoneParams{
 			NetcupBaseParams: NetcupBaseParams{
 				CustomerNumber:  s.customerNumber,
 				ApiKey:          s.apiKey,
 				ApiSessionId:    s.apiSessionId,
-				ClientRequestId: s.LastResponse.ClientRequestId,
+				ClientRequestId: requestId,
 			},
 			DomainName: domainName,
 		},
@@ -378,11 +481,11 @@ func (s *NetcupSession) InfoDnsZone(domainName string) (*DnsZoneData, error) {
 		respData := &DnsZoneData{}
 		if br, err := handleResponse("InfoDnsZone", buf, respData); err != nil {
 			if br != nil {
-				s.LastResponse = br
+				s.setLastResponse(br)
 			}
 			return nil, err
 		} else {
-			s.LastResponse = br
+			s.setLastResponse(br)
 			return respData, nil
 		}
 	}
@@ -390,15 +493,16 @@ func (s *NetcupSession) InfoDnsZone(domainName string) (*DnsZoneData, error) {
 
 // Query information about all DNS records.
 func (s *NetcupSession) InfoDnsRecords(domainName string) (*[]DnsRecord, error) {
+	requestId := s.client.newRequestId()
 	emptyRecs := make([]DnsRecord, 0)
-	if buf, err := s.client.doPostWithRetry(s.endpoint, &InfoDnsRecordsPayload{
+	if buf, err := s.client.doPostWithRetry(s.context(), actionInfoDnsRecords, s.endpoint, requestId, &InfoDnsRecordsPayload{
 		Action: actionInfoDnsRecords,
 		Params: &InfoDnsRecordsParams{
 			NetcupBaseParams: NetcupBaseParams{
 				CustomerNumber:  s.customerNumber,
 				ApiKey:          s.apiKey,
 				ApiSessionId:    s.apiSessionId,
-				ClientRequestId: s.LastResponse.ClientRequestId,
+				ClientRequestId: requestId,
 			},
 			DomainName: domainName,
 		},
@@ -410,11 +514,11 @@ func (s *NetcupSession) InfoDnsRecords(domainName string) (*[]DnsRecord, error)
 		}
 		if br, err := handleResponse("InfoDnsRecords", buf, respData); err != nil {
 			if br != nil {
-				s.LastResponse = br
+				s.setLastResponse(br)
 			}
 			return &emptyRecs, err
 		} else {
-			s.LastResponse = br
+			s.setLastResponse(br)
 			return &respData.DnsRecords, nil
 		}
 	}
@@ -422,14 +526,15 @@ func (s *NetcupSession) InfoDnsRecords(domainName string) (*[]DnsRecord, error)
 
 // Update data of a DNS zone, returning an updated DnsZoneData.
 func (s *NetcupSession) UpdateDnsZone(domainName string, dnsZone *DnsZoneData) (*DnsZoneData, error) {
-	if buf, err := s.client.doPostWithRetry(s.endpoint, &UpdateDnsZonePayload{
+	requestId := s.client.newRequestId()
+	if buf, err := s.client.doPostWithRetry(s.context(), actionUpdateDnsZone, s.endpoint, requestId, &UpdateDnsZonePayload{
 		Action: actionUpdateDnsZone,
 		Params: &UpdateDnsZoneParams{
 			NetcupBaseParams: NetcupBaseParams{
 				CustomerNumber:  s.customerNumber,
 				ApiKey:          s.apiKey,
 				ApiSessionId:    s.apiSessionId,
-				ClientRequestId: s.LastResponse.ClientRequestId,
+				ClientRequestId: requestId,
 			},
 			DomainName: domainName,
 			DnsZone:    dnsZone,
@@ -440,11 +545,18 @@ func (s *NetcupSession) UpdateDnsZone(domainName string, dnsZone *DnsZoneData) (
 		respData := &DnsZoneData{}
 		if br, err := handleResponse("UpdateDnsZone", buf, respData); err != nil {
 			if br != nil {
-				s.LastResponse = br
+				s.setLastResponse(br)
 			}
 			return nil, err
+		} else if inProgress(br.Status) {
+			// Pending responses carry no zone, read it once the update has finished
+			if br, err = s.awaitCompletion("UpdateDnsZone", br); err != nil {
+				s.setLastResponse(br)
+				return nil, err
+			}
+			return s.InfoDnsZone(domainName)
 		} else {
-			s.LastResponse = br
+			s.setLastResponse(br)
 			return respData, nil
 		}
 	}
@@ -452,15 +564,16 @@ func (s *NetcupSession) UpdateDnsZone(domainName string, dnsZone *DnsZoneData) (
 
 // Update set of DNS records for a given domain name, returning updated DNS records.
 func (s *NetcupSession) UpdateDnsRecords(domainName string, dnsRecordSet *[]DnsRecord) (*[]DnsRecord, error) {
+	requestId := s.client.newRequestId()
 	emptyRecs := make([]DnsRecord, 0)
-	if buf, err := s.client.doPostWithRetry(s.endpoint, &UpdateDnsRecordsPayload{
+	if buf, err := s.client.doPostWithRetry(s.context(), actionUpdateDnsRecords, s.endpoint, requestId, &UpdateDnsRecordsPayload{
 		Action: actionUpdateDnsRecords,
 		Params: &UpdateDnsRecordsParams{
 			NetcupBaseParams: NetcupBaseParams{
 				CustomerNumber:  s.customerNumber,
 				ApiKey:          s.apiKey,
 				ApiSessionId:    s.apiSessionId,
-				ClientRequestId: s.LastResponse.ClientRequestId,
+				ClientRequestId: requestId,
 			},
 			DomainName: domainName,
 			DnsRecords: &DnsRecordSet{
@@ -475,11 +588,18 @@ func (s *NetcupSession) UpdateDnsRecords(domainName string, dnsRecordSet *[]DnsR
 		}
 		if br, err := handleResponse("UpdateDnsRecords", buf, respData); err != nil {
 			if br != nil {
-				s.LastResponse = br
+				s.setLastResponse(br)
 			}
 			return &emptyRecs, err
+		} else if inProgress(br.Status) {
+			// Pending responses carry no records, read them once the update has finished
+			if br, err = s.awaitCompletion("UpdateDnsRecords", br); err != nil {
+				s.setLastResponse(br)
+				return &emptyRecs, err
+			}
+			return s.InfoDnsRecords(domainName)
 		} else {
-			s.LastResponse = br
+			s.setLastResponse(br)
 			return &respData.DnsRecords, nil
 		}
 	}
@@ -487,22 +607,31 @@ func (s *NetcupSession) UpdateDnsRecords(domainName string, dnsRecordSet *[]DnsR
 
 // Logout from active Netcup session. This may return an error (which can be ignored).
 func (s *NetcupSession) Logout() error {
+	requestId := s.client.newRequestId()
 	req := &BasePayload{
 		Action: actionLogout,
 		Params: &NetcupBaseParams{
 			CustomerNumber:  s.customerNumber,
 			ApiSessionId:    s.apiSessionId,
 			ApiKey:          s.apiKey,
-			ClientRequestId: s.LastResponse.ClientRequestId,
+			ClientRequestId: requestId,
 		},
 	}
 	// logout is always assumed successful response, but we need to check for technical errors here.
-	if _, err := s.client.doPostWithRetry(s.endpoint, req); err != nil {
+	if _, err := s.client.doPostWithRetry(s.context(), actionLogout, s.endpoint, requestId, req); err != nil {
 		return err
 	}
 	return nil
 }
 
+// context returns the session context, defaulting to the background context
+func (s *NetcupSession) context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
 // Stringer implementation for NetcupSession.
 func (s *NetcupSession) String() string {
 	return fmt.Sprintf(
@@ -593,8 +722,16 @@ func handleResponse(reqType string, buf *bytes.Buffer, respData interface{}) (*N
 		return nil, err
 	}
 	if resp.Status == string(StatusError) {
-		return &resp.NetcupBaseResponseMessage, fmt.Errorf("%s failed: (%d) '%s' '%s' '%s'",
-			reqType, resp.StatusCode, resp.Status, resp.ShortMessage, resp.LongMessage)
+		err := fmt.Errorf("%s failed: (%d) '%s' '%s' '%s' (clientRequestId %s, serverRequestId %s)",
+			reqType, resp.StatusCode, resp.Status, resp.ShortMessage, resp.LongMessage, resp.ClientRequestId, resp.ServerRequestId)
+		if isMaintenance(resp.ShortMessage + " " + resp.LongMessage) {
+			err = fmt.Errorf("%w: %w", ErrMaintenance, err)
+		}
+		return &resp.NetcupBaseResponseMessage, err
+	}
+	// Operations completed asynchronously carry their data only once finished
+	if inProgress(resp.Status) {
+		return &resp.NetcupBaseResponseMessage, nil
 	}
 	// try to convert the responseData to the target type
 	b, err := json.Marshal(resp.ResponseData)
@@ -617,9 +754,17 @@ func NewCircuitBreaker(threshold int, timeout time.Duration, halfOpenMaxReqs int
 	}
 }
 
+// OnStateChange registers a callback invoked after every state transition
+func (cb *CircuitBreaker) OnStateChange(fn func(from, to CircuitBreakerState)) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.onStateChange = fn
+}
+
 // Call executes a function with circuit breaker protection
 func (cb *CircuitBreaker) Call(fn func() error) error {
 	cb.mu.Lock()
+	from := cb.state
 
 	// Check if we should transition from open to half-open
 	if cb.state == StateOpen && time.Since(cb.lastFailureTime) > cb.timeout {
@@ -637,6 +782,7 @@ func (cb *CircuitBreaker) Call(fn func() error) error {
 	// If half-open, check if we've exceeded the request limit
 	if cb.state == StateHalfOpen && cb.successCount+cb.failureCount >= cb.halfOpenMaxReqs {
 		cb.mu.Unlock()
+		cb.notifyStateChange(from, StateHalfOpen)
 		return ErrCircuitOpen
 	}
 
@@ -646,15 +792,31 @@ func (cb *CircuitBreaker) Call(fn func() error) error {
 	err := fn()
 
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
-
 	if err != nil {
 		cb.onFailure()
-		return err
+	} else {
+		cb.onSuccess()
 	}
+	to := cb.state
+	cb.mu.Unlock()
 
-	cb.onSuccess()
-	return nil
+	cb.notifyStateChange(from, to)
+	return err
+}
+
+// notifyStateChange invokes the state change callback outside the lock
+func (cb *CircuitBreaker) notifyStateChange(from, to CircuitBreakerState) {
+	if from == to {
+		return
+	}
+
+	cb.mu.RLock()
+	fn := cb.onStateChange
+	cb.mu.RUnlock()
+
+	if fn != nil {
+		fn(from, to)
+	}
 }
 
 func (cb *CircuitBreaker) onSuccess() {
@@ -686,6 +848,20 @@ func (cb *CircuitBreaker) onFailure() {
 	}
 }
 
+// Reset closes the circuit, e.g. once the API is known to be back, without waiting
+// for the timeout. It reports whether the circuit was open or half-open.
+func (cb *CircuitBreaker) Reset() bool {
+	cb.mu.Lock()
+	from := cb.state
+	cb.state = StateClosed
+	cb.failureCount = 0
+	cb.successCount = 0
+	cb.mu.Unlock()
+
+	cb.notifyStateChange(from, StateClosed)
+	return from != StateClosed
+}
+
 // GetState returns the current state of the circuit breaker
 func (cb *CircuitBreaker) GetState() CircuitBreakerState {
 	cb.mu.RLock()
@@ -731,6 +907,11 @@ func isRateLimitError(err error, statusCode int) bool {
 	return false
 }
 
+// isMaintenance checks if a response announces scheduled maintenance
+func isMaintenance(message string) bool {
+	return containsAny(strings.ToLower(message), maintenanceMarkers)
+}
+
 // containsAny checks if a string contains any of the given substrings
 func containsAny(s string, substrs []string) bool {
 	for _, substr := range substrs {
@@ -760,13 +941,40 @@ func (rc *RetryConfig) calculateBackoff(attempt int) time.Duration {
 }
 
 // internal helper for doing HTTP post with given payload, retry logic, and circuit breaker.
-func (c *NetcupDnsClient) doPostWithRetry(endpoint string, payload interface{}) (*bytes.Buffer, error) {
+// requestId is the clientRequestId of the payload; retries are sent with the same id.
+func (c *NetcupDnsClient) doPostWithRetry(ctx context.Context, action RequestAction, endpoint, requestId string, payload interface{}) (buf *bytes.Buffer, err error) {
+	ctx, span := tracer.Start(ctx, "netcup."+string(action), trace.WithSpanKind(trace.SpanKindClient))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	start := time.Now()
+	attempts := 0
+	defer func() {
+		c.recordResponse(action, requestId, start, attempts, buf, err)
+		if err != nil {
+			err = fmt.Errorf("%w (clientRequestId %s)", err, requestId)
+		}
+	}()
+
 	var lastErr error
 
 	for attempt := 0; attempt <= c.retryConfig.MaxRetries; attempt++ {
+		attempts = attempt + 1
+		span.SetAttributes(attribute.Int("netcup.attempts", attempts))
+
 		// Use circuit breaker to protect the call
 		err := c.circuitBreaker.Call(func() error {
-			buf, err := c.doPost(endpoint, payload)
+			buf, err := c.doPost(ctx, action, endpoint, payload)
+			if errors.Is(err, ErrMaintenance) {
+				// The API answered, so maintenance must not open the circuit
+				lastErr = err
+				return nil
+			}
 			if err != nil {
 				lastErr = err
 				return err
@@ -804,8 +1012,18 @@ func (c *NetcupDnsClient) doPostWithRetry(endpoint string, payload interface{})
 			backoff = backoff * 2 // Double the backoff for rate limits
 		}
 
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int("netcup.attempt", attempt+1),
+			attribute.String("netcup.error", lastErr.Error()),
+			attribute.Int64("netcup.backoff_ms", backoff.Milliseconds()),
+		))
+
 		// Sleep before retry
-		time.Sleep(backoff)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
 	}
 
 	return nil, fmt.Errorf("max retries (%d) exceeded: %w", c.retryConfig.MaxRetries, lastErr)
@@ -821,7 +1039,7 @@ func (s *successMarker) Error() string {
 }
 
 // doPost performs the actual HTTP POST request
-func (c *NetcupDnsClient) doPost(endpoint string, payload interface{}) (*bytes.Buffer, error) {
+func (c *NetcupDnsClient) doPost(ctx context.Context, action RequestAction, endpoint string, payload interface{}) (_ *bytes.Buffer, err error) {
 	var buf bytes.Buffer
 
 	enc := json.NewEncoder(&buf)
@@ -829,7 +1047,18 @@ func (c *NetcupDnsClient) doPost(endpoint string, payload interface{}) (*bytes.B
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	// Keep the exchange for DEBUG_HTTP; the request is copied before it is sent
+	var request, response []byte
+	var httpStatus int
+	if c.debugEnabled() {
+		request = bytes.Clone(buf.Bytes())
+		start := time.Now()
+		defer func() {
+			c.recordExchange(string(action), start, httpStatus, request, response, err)
+		}()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultRequestTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, &buf)
@@ -843,12 +1072,18 @@ func (c *NetcupDnsClient) doPost(endpoint string, payload interface{}) (*bytes.B
 		return nil, err
 	}
 	defer resp.Body.Close()
+	httpStatus = resp.StatusCode
 
 	if resp.StatusCode >= 400 {
 		var b bytes.Buffer
 		if n, err := b.ReadFrom(resp.Body); err == nil && n > 0 {
+			response = b.Bytes()
 			respErr := fmt.Errorf("unexpected error code: %d, response: %s", resp.StatusCode, b.String())
 
+			if isMaintenance(b.String()) {
+				return nil, fmt.Errorf("%w: %v", ErrMaintenance, respErr)
+			}
+
 			// Check for rate limiting
 			if isRateLimitError(respErr, resp.StatusCode) {
 				return nil, fmt.Errorf("%w: %v", ErrRateLimitExceeded, respErr)
@@ -863,6 +1098,7 @@ func (c *NetcupDnsClient) doPost(endpoint string, payload interface{}) (*bytes.B
 	if _, err := buf.ReadFrom(resp.Body); err != nil {
 		return nil, err
 	}
+	response = buf.Bytes()
 
 	return &buf, nil
 }
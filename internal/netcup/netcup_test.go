@@ -1,7 +1,15 @@
 package netcup
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestNewNetcupDnsClient(t *testing.T) {
@@ -329,3 +337,259 @@ func TestNetcupBaseParams(t *testing.T) {
 		t.Errorf("ClientRequestId = %v, want client-456", params.ClientRequestId)
 	}
 }
+
+func TestCircuitBreaker_OnStateChange(t *testing.T) {
+	cb := NewCircuitBreaker(2, 10*time.Millisecond, 1)
+
+	var transitions []string
+	cb.OnStateChange(func(from, to CircuitBreakerState) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	})
+
+	failing := func() error { return errors.New("request failed") }
+	succeeding := func() error { return nil }
+
+	cb.Call(failing)
+	cb.Call(failing)
+	if cb.GetState() != StateOpen {
+		t.Fatalf("State = %v, want open", cb.GetState())
+	}
+
+	// Fail fast while open, without a transition
+	if err := cb.Call(succeeding); err != ErrCircuitOpen {
+		t.Errorf("Call() error = %v, want ErrCircuitOpen", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if err := cb.Call(succeeding); err != nil {
+		t.Fatalf("Call() error = %v after timeout", err)
+	}
+
+	want := []string{"closed->open", "open->closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("transitions[%d] = %v, want %v", i, transitions[i], want[i])
+		}
+	}
+}
+
+func TestCircuitBreaker_Reset(t *testing.T) {
+	cb := NewCircuitBreaker(1, time.Hour, 1)
+
+	var transitions []string
+	cb.OnStateChange(func(from, to CircuitBreakerState) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	})
+
+	if cb.Reset() {
+		t.Error("Reset() of a closed circuit = true, want false")
+	}
+
+	cb.Call(func() error { return errors.New("request failed") })
+	if cb.GetState() != StateOpen {
+		t.Fatalf("State = %v, want open", cb.GetState())
+	}
+
+	if !cb.Reset() {
+		t.Error("Reset() of an open circuit = false, want true")
+	}
+	if cb.GetState() != StateClosed {
+		t.Errorf("State = %v after Reset(), want closed", cb.GetState())
+	}
+	if stats := cb.Stats(); stats.FailureCount != 0 {
+		t.Errorf("FailureCount = %d after Reset(), want 0", stats.FailureCount)
+	}
+	if err := cb.Call(func() error { return nil }); err != nil {
+		t.Errorf("Call() error = %v after Reset()", err)
+	}
+
+	want := []string{"closed->open", "open->closed"}
+	if len(transitions) != len(want) || transitions[0] != want[0] || transitions[1] != want[1] {
+		t.Errorf("transitions = %v, want %v", transitions, want)
+	}
+}
+
+func TestLoginContext_CancelAbortsRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		cancel()
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewNetcupDnsClientWithOptions(12345, "key", "password", &NetcupDnsClientOptions{
+		ApiEndpoint: server.URL,
+		RetryConfig: &RetryConfig{
+			MaxRetries:        3,
+			InitialBackoff:    time.Hour,
+			MaxBackoff:        time.Hour,
+			BackoffMultiplier: 1,
+		},
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.LoginContext(ctx)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("LoginContext() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("LoginContext() did not return after the context was canceled")
+	}
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestLoginContext_Rejected(t *testing.T) {
+	tests := []struct {
+		name         string
+		handler      http.HandlerFunc
+		wantRejected bool
+	}{
+		{
+			name: "wrong credentials",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"status":"error","statuscode":4013,"shortmessage":"Validation Error.","longmessage":"Api key or password invalid.","responsedata":""}`))
+			},
+			wantRejected: true,
+		},
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "bad gateway", http.StatusBadGateway)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			client := NewNetcupDnsClientWithOptions(12345, "key", "password", &NetcupDnsClientOptions{
+				ApiEndpoint: server.URL,
+				RetryConfig: &RetryConfig{MaxRetries: 0, BackoffMultiplier: 1},
+			})
+			_, err := client.Login()
+			if err == nil {
+				t.Fatal("Login() error = nil, want error")
+			}
+			if got := errors.Is(err, ErrLoginRejected); got != tt.wantRejected {
+				t.Errorf("errors.Is(%v, ErrLoginRejected) = %v, want %v", err, got, tt.wantRejected)
+			}
+		})
+	}
+}
+
+func TestLoginContext_Maintenance(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		wantCalls int
+	}{
+		{
+			name: "error response",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"status":"error","statuscode":4001,"shortmessage":"Maintenance.","longmessage":"The API is currently in maintenance. Please try again later.","responsedata":""}`))
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "unavailable",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Wartungsarbeiten", http.StatusServiceUnavailable)
+			},
+			wantCalls: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				tt.handler(w, r)
+			}))
+			defer server.Close()
+
+			circuitBreaker := NewCircuitBreaker(1, time.Hour, 1)
+			client := NewNetcupDnsClientWithOptions(12345, "key", "password", &NetcupDnsClientOptions{
+				ApiEndpoint:    server.URL,
+				RetryConfig:    &RetryConfig{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffMultiplier: 1},
+				CircuitBreaker: circuitBreaker,
+			})
+			_, err := client.Login()
+			if !errors.Is(err, ErrMaintenance) {
+				t.Fatalf("Login() error = %v, want ErrMaintenance", err)
+			}
+			if errors.Is(err, ErrLoginRejected) {
+				t.Errorf("Login() error = %v, want no ErrLoginRejected", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Requests = %d, want %d", calls, tt.wantCalls)
+			}
+			if state := circuitBreaker.GetState(); state != StateClosed {
+				t.Errorf("Circuit breaker state = %v, want closed", state)
+			}
+		})
+	}
+}
+
+func TestNetcupDnsClient_ProxyURL(t *testing.T) {
+	api := NewFakeAPI()
+	api.AddZone("example.com")
+
+	var proxied atomic.Int32
+	var proxyAuth atomic.Value
+	handler := &mockHandler{fake: api}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A proxied plain HTTP request carries the absolute target URL
+		if r.URL.Host == "netcup.invalid" {
+			proxied.Add(1)
+		}
+		proxyAuth.Store(r.Header.Get("Proxy-Authorization"))
+		handler.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	proxyURL.User = url.UserPassword("user", "secret")
+
+	client := NewNetcupDnsClientWithOptions(12345, "key", "password", &NetcupDnsClientOptions{
+		ApiEndpoint: "http://netcup.invalid/endpoint.php?JSON",
+		RetryConfig: &RetryConfig{MaxRetries: 0, BackoffMultiplier: 1},
+		ProxyURL:    proxyURL,
+	})
+
+	session, err := client.Login()
+	if err != nil {
+		t.Fatalf("Login() through proxy error = %v", err)
+	}
+	if _, err := session.InfoDnsRecords("example.com"); err != nil {
+		t.Fatalf("InfoDnsRecords() through proxy error = %v", err)
+	}
+
+	if got := proxied.Load(); got != 2 {
+		t.Errorf("proxied requests = %d, want 2", got)
+	}
+	if auth, _ := proxyAuth.Load().(string); !strings.HasPrefix(auth, "Basic ") {
+		t.Errorf("Proxy-Authorization = %q, want basic credentials", auth)
+	}
+}
@@ -0,0 +1,182 @@
+// Package netcuptest provides a fake Netcup JSON endpoint, so packages can be tested
+// against a real NetcupDnsClient without Netcup credentials.
+package netcuptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+// Fault is a canned faulty response
+type Fault struct {
+	HTTPStatus int    // HTTP status of the response; 0 answers with 200 and a Netcup error
+	StatusCode int    // Netcup status code of the error (default: 4013)
+	Message    string // Long message of the error
+}
+
+// Server is an httptest server speaking the Netcup JSON protocol. Requests are served
+// from API unless a fault is queued for their action or the rate limit is exceeded.
+type Server struct {
+	*httptest.Server
+	API *netcup.FakeAPI
+
+	handler http.Handler
+
+	mu          sync.Mutex
+	faults      map[string][]Fault
+	requests    map[string]int
+	limit       int
+	window      time.Duration
+	windowStart time.Time
+	windowCount int
+}
+
+// NewServer starts a server backed by api, or by an empty FakeAPI if api is nil.
+// The caller must Close the returned server.
+func NewServer(api *netcup.FakeAPI) *Server {
+	if api == nil {
+		api = netcup.NewFakeAPI()
+	}
+	s := &Server{
+		API:      api,
+		handler:  netcup.NewMockHandler(api),
+		faults:   make(map[string][]Fault),
+		requests: make(map[string]int),
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// FailNext answers the next requests of action (e.g. "updateDnsRecords") with the
+// given faults, one fault per request
+func (s *Server) FailNext(action string, faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[action] = append(s.faults[action], faults...)
+}
+
+// SetRateLimit answers requests beyond the given number per window with HTTP 429.
+// A limit of 0 disables rate limiting.
+func (s *Server) SetRateLimit(requests int, window time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = requests
+	s.window = window
+	s.windowStart = time.Time{}
+	s.windowCount = 0
+}
+
+// Requests returns how many requests of action were received, including faulty
+// and rate limited ones
+func (s *Server) Requests(action string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[action]
+}
+
+// ClientOptions returns client options pointing at the server, with short backoffs
+// so retries do not slow down tests
+func (s *Server) ClientOptions() *netcup.NetcupDnsClientOptions {
+	return &netcup.NetcupDnsClientOptions{
+		ApiEndpoint: s.URL,
+		RetryConfig: &netcup.RetryConfig{
+			MaxRetries:        3,
+			InitialBackoff:    time.Millisecond,
+			MaxBackoff:        10 * time.Millisecond,
+			BackoffMultiplier: 2,
+		},
+	}
+}
+
+// NewClient returns a NetcupAPI talking to the server. nil opts uses ClientOptions.
+func (s *Server) NewClient(opts *netcup.NetcupDnsClientOptions) netcup.NetcupAPI {
+	if opts == nil {
+		opts = s.ClientOptions()
+	}
+	return netcup.NewNetcupAPI(netcup.NewNetcupDnsClientWithOptions(12345, "test-key", "test-password", opts))
+}
+
+// request captures the fields of a Netcup request the server needs
+type request struct {
+	Action string `json:"action"`
+	Params struct {
+		ClientRequestId string `json:"clientrequestid"`
+	} `json:"param"`
+}
+
+type response struct {
+	netcup.NetcupBaseResponseMessage
+	ResponseData string `json:"responsedata"`
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limited, fault, faulty := s.begin(req.Action)
+	switch {
+	case limited:
+		http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+	case faulty && fault.HTTPStatus != 0:
+		http.Error(w, fault.Message, fault.HTTPStatus)
+	case faulty:
+		statusCode := fault.StatusCode
+		if statusCode == 0 {
+			statusCode = 4013
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response{NetcupBaseResponseMessage: netcup.NetcupBaseResponseMessage{
+			ServerRequestId: "mock-server-request",
+			ClientRequestId: req.Params.ClientRequestId,
+			Action:          req.Action,
+			Status:          string(netcup.StatusError),
+			StatusCode:      statusCode,
+			ShortMessage:    "Request failed",
+			LongMessage:     fault.Message,
+		}})
+	default:
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		s.handler.ServeHTTP(w, r)
+	}
+}
+
+// begin counts a request and reports whether it is rate limited or answered with a fault
+func (s *Server) begin(action string) (limited bool, fault Fault, faulty bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests[action]++
+
+	if s.limit > 0 {
+		now := time.Now()
+		if now.Sub(s.windowStart) >= s.window {
+			s.windowStart = now
+			s.windowCount = 0
+		}
+		s.windowCount++
+		if s.windowCount > s.limit {
+			return true, Fault{}, false
+		}
+	}
+
+	if queued := s.faults[action]; len(queued) > 0 {
+		s.faults[action] = queued[1:]
+		return false, queued[0], true
+	}
+	return false, Fault{}, false
+}
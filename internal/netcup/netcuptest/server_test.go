@@ -0,0 +1,123 @@
+package netcuptest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestServer_Roundtrip(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	server.API.AddZone("example.com", netcup.DnsRecord{Hostname: "www", Type: "A", Destination: "203.0.113.1"})
+
+	session, err := server.NewClient(nil).Login(context.Background())
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	defer session.Logout()
+
+	if _, err := session.UpdateDnsRecords("example.com", &[]netcup.DnsRecord{{Hostname: "app", Type: "A", Destination: "203.0.113.2"}}); err != nil {
+		t.Fatalf("UpdateDnsRecords() error = %v", err)
+	}
+	records, err := session.InfoDnsRecords("example.com")
+	if err != nil {
+		t.Fatalf("InfoDnsRecords() error = %v", err)
+	}
+	if len(*records) != 2 {
+		t.Errorf("InfoDnsRecords() = %v, want 2 records", *records)
+	}
+	if got := server.Requests("infoDnsRecords"); got != 1 {
+		t.Errorf("Requests(infoDnsRecords) = %d, want 1", got)
+	}
+}
+
+func TestServer_FailNext(t *testing.T) {
+	tests := []struct {
+		name       string
+		faults     []Fault
+		wantErr    string
+		wantCalls  int
+		maxRetries int
+	}{
+		{
+			name:       "netcup error is not retried",
+			faults:     []Fault{{StatusCode: 5029, Message: "Domain not found"}},
+			wantErr:    "Domain not found",
+			wantCalls:  1,
+			maxRetries: 3,
+		},
+		{
+			name:       "server errors are retried",
+			faults:     []Fault{{HTTPStatus: http.StatusServiceUnavailable, Message: "maintenance"}, {HTTPStatus: http.StatusServiceUnavailable, Message: "maintenance"}},
+			wantCalls:  3,
+			maxRetries: 3,
+		},
+		{
+			name:       "retries exhausted",
+			faults:     []Fault{{HTTPStatus: http.StatusServiceUnavailable, Message: "maintenance"}, {HTTPStatus: http.StatusServiceUnavailable, Message: "maintenance"}},
+			wantErr:    "maintenance",
+			wantCalls:  2,
+			maxRetries: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(nil)
+			defer server.Close()
+			server.API.AddZone("example.com")
+
+			opts := server.ClientOptions()
+			opts.RetryConfig.MaxRetries = tt.maxRetries
+			session, err := server.NewClient(opts).Login(context.Background())
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			defer session.Logout()
+
+			server.FailNext("infoDnsRecords", tt.faults...)
+			_, err = session.InfoDnsRecords("example.com")
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("InfoDnsRecords() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("InfoDnsRecords() error = %v, want %q", err, tt.wantErr)
+			}
+			if got := server.Requests("infoDnsRecords"); got != tt.wantCalls {
+				t.Errorf("Requests(infoDnsRecords) = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	server.API.AddZone("example.com")
+	server.SetRateLimit(2, time.Hour)
+
+	opts := server.ClientOptions()
+	opts.RetryConfig.MaxRetries = 0
+	session, err := server.NewClient(opts).Login(context.Background())
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	if _, err := session.InfoDnsRecords("example.com"); err != nil {
+		t.Fatalf("InfoDnsRecords() within the limit error = %v", err)
+	}
+	if _, err := session.InfoDnsRecords("example.com"); !errors.Is(err, netcup.ErrRateLimitExceeded) {
+		t.Errorf("InfoDnsRecords() beyond the limit error = %v, want ErrRateLimitExceeded", err)
+	}
+
+	server.SetRateLimit(0, 0)
+	if _, err := session.InfoDnsRecords("example.com"); err != nil {
+		t.Errorf("InfoDnsRecords() without limit error = %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package netcup
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	actionPoll RequestAction = "poll"
+
+	// Defaults of NetcupDnsClientOptions.PollInterval and PollTimeout
+	defaultPollInterval = 2 * time.Second
+	defaultPollTimeout  = 2 * time.Minute
+
+	// pollMessageCount is the number of recent messages requested per poll, enough to
+	// find the awaited operation among concurrent ones
+	pollMessageCount = 20
+)
+
+// Parameters for Poll request
+type PollParams struct {
+	NetcupBaseParams
+	MessageCount int `json:"messagecount"`
+}
+
+// Payload for Poll request
+type PollPayload struct {
+	Action RequestAction `json:"action"`
+	Params *PollParams   `json:"param"`
+}
+
+// AsyncStats counts the operations Netcup reported as started or pending
+type AsyncStats struct {
+	Operations int64 `json:"operations"` // Operations that did not complete synchronously
+	Pending    int64 `json:"pending"`    // Operations currently being polled
+	Timeouts   int64 `json:"timeouts"`   // Operations still pending after the poll timeout
+}
+
+// asyncCounters backs AsyncStats
+type asyncCounters struct {
+	operations, pending, timeouts atomic.Int64
+}
+
+func (a *asyncCounters) stats() AsyncStats {
+	return AsyncStats{
+		Operations: a.operations.Load(),
+		Pending:    a.pending.Load(),
+		Timeouts:   a.timeouts.Load(),
+	}
+}
+
+// inProgress reports whether status marks an operation Netcup completes asynchronously
+func inProgress(status string) bool {
+	return status == string(StatusStarted) || status == string(StatusPending)
+}
+
+// Poll returns the results of the most recent asynchronous operations, newest first
+func (s *NetcupSession) Poll(messageCount int) ([]NetcupBaseResponseMessage, error) {
+	requestId := s.client.newRequestId()
+	buf, err := s.client.doPostWithRetry(s.context(), actionPoll, s.endpoint, requestId, &PollPayload{
+		Action: actionPoll,
+		Params: &PollParams{
+			NetcupBaseParams: NetcupBaseParams{
+				CustomerNumber:  s.customerNumber,
+				ApiKey:          s.apiKey,
+				ApiSessionId:    s.apiSessionId,
+				ClientRequestId: requestId,
+			},
+			MessageCount: messageCount,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []NetcupBaseResponseMessage
+	br, err := handleResponse("Poll", buf, &messages)
+	if br != nil {
+		s.setLastResponse(br)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// awaitCompletion polls until the operation br reported as started or pending has
+// finished, and returns its final result. Completed operations are returned as is.
+func (s *NetcupSession) awaitCompletion(reqType string, br *NetcupBaseResponseMessage) (*NetcupBaseResponseMessage, error) {
+	if !inProgress(br.Status) {
+		return br, nil
+	}
+
+	c := s.client
+	c.async.operations.Add(1)
+	c.async.pending.Add(1)
+	defer c.async.pending.Add(-1)
+
+	log.Printf("Netcup %s is %s, polling for completion (clientRequestId %s, serverRequestId %s)", reqType, br.Status, br.ClientRequestId, br.ServerRequestId)
+	start := time.Now()
+	deadline := start.Add(c.pollTimeout)
+	status := br.Status
+
+	for time.Now().Before(deadline) {
+		select {
+		case <-s.context().Done():
+			return br, s.context().Err()
+		case <-time.After(c.pollInterval):
+		}
+
+		messages, err := s.Poll(pollMessageCount)
+		if err != nil {
+			return br, fmt.Errorf("failed to poll %s: %w", reqType, err)
+		}
+		for i := range messages {
+			message := &messages[i]
+			if message.ServerRequestId != br.ServerRequestId {
+				continue
+			}
+			status = message.Status
+			if inProgress(message.Status) {
+				break
+			}
+			log.Printf("Netcup %s finished with status %s after %s (serverRequestId %s)", reqType, message.Status, time.Since(start).Round(time.Millisecond), br.ServerRequestId)
+			if message.Status == string(StatusError) {
+				return message, fmt.Errorf("%s failed: (%d) '%s' '%s' '%s' (clientRequestId %s, serverRequestId %s)",
+					reqType, message.StatusCode, message.Status, message.ShortMessage, message.LongMessage, br.ClientRequestId, br.ServerRequestId)
+			}
+			return message, nil
+		}
+	}
+
+	c.async.timeouts.Add(1)
+	return br, fmt.Errorf("%s still %s after %s (clientRequestId %s, serverRequestId %s)", reqType, status, c.pollTimeout, br.ClientRequestId, br.ServerRequestId)
+}
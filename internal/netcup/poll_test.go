@@ -0,0 +1,118 @@
+package netcup
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// asyncServer answers updateDnsRecords with "started" and reports the operation as
+// pending for the given number of polls before it finishes with finalStatus
+type asyncServer struct {
+	mu          sync.Mutex
+	pendingFor  int
+	finalStatus ResponseStatus
+	polls       int
+}
+
+func (s *asyncServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req mockRequest
+	json.NewDecoder(r.Body).Decode(&req)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := mockResponse{
+		NetcupBaseResponseMessage: NetcupBaseResponseMessage{
+			ServerRequestId: "server-" + string(req.Action),
+			ClientRequestId: req.Params.ClientRequestId,
+			Action:          string(req.Action),
+			Status:          string(StatusSuccess),
+			StatusCode:      2000,
+		},
+		ResponseData: "",
+	}
+	switch req.Action {
+	case actionLogin:
+		resp.ResponseData = &LoginResponseData{ApiSessionId: "session"}
+	case actionUpdateDnsRecords:
+		resp.Status = string(StatusStarted)
+	case actionPoll:
+		s.polls++
+		status := s.finalStatus
+		if s.polls <= s.pendingFor {
+			status = StatusPending
+		}
+		resp.ResponseData = []NetcupBaseResponseMessage{
+			{ServerRequestId: "server-other", Status: string(StatusSuccess)},
+			{ServerRequestId: "server-updateDnsRecords", Status: string(status), StatusCode: 4013, ShortMessage: "Update " + string(status)},
+		}
+	case actionInfoDnsRecords:
+		resp.ResponseData = &InfoDnsRecordsResponseData{DnsRecords: []DnsRecord{{Id: "1", Hostname: "app", Type: "A", Destination: "203.0.113.1"}}}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func newAsyncClient(t *testing.T, server *asyncServer, timeout time.Duration) *NetcupDnsClient {
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	return NewNetcupDnsClientWithOptions(12345, "test-key", "test-password", &NetcupDnsClientOptions{
+		ApiEndpoint:  httpServer.URL,
+		RetryConfig:  &RetryConfig{MaxRetries: 0, BackoffMultiplier: 1},
+		PollInterval: time.Millisecond,
+		PollTimeout:  timeout,
+	})
+}
+
+func TestUpdateDnsRecords_PollsPendingOperation(t *testing.T) {
+	tests := []struct {
+		name        string
+		pendingFor  int
+		finalStatus ResponseStatus
+		timeout     time.Duration
+		wantErr     string
+		wantTimeout int64
+	}{
+		{name: "success", pendingFor: 2, finalStatus: StatusSuccess, timeout: time.Second},
+		{name: "error", pendingFor: 1, finalStatus: StatusError, timeout: time.Second, wantErr: "Update error"},
+		{name: "timeout", pendingFor: 1 << 30, finalStatus: StatusSuccess, timeout: 20 * time.Millisecond, wantErr: "still pending", wantTimeout: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &asyncServer{pendingFor: tt.pendingFor, finalStatus: tt.finalStatus}
+			client := newAsyncClient(t, server, tt.timeout)
+			session, err := client.Login()
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+
+			records, err := session.UpdateDnsRecords("example.com", &[]DnsRecord{{Hostname: "app", Type: "A", Destination: "203.0.113.1"}})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("UpdateDnsRecords() error = %v, want %q", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("UpdateDnsRecords() error = %v", err)
+				}
+				// The records are read once the update has finished
+				if len(*records) != 1 || (*records)[0].Id != "1" {
+					t.Errorf("UpdateDnsRecords() = %+v, want the records read after completion", *records)
+				}
+				if server.polls != tt.pendingFor+1 {
+					t.Errorf("Polled %d times, want %d", server.polls, tt.pendingFor+1)
+				}
+			}
+
+			async := client.Diagnostics().Async
+			if async.Operations != 1 || async.Pending != 0 || async.Timeouts != tt.wantTimeout {
+				t.Errorf("Async = %+v, want 1 operation, none pending, %d timeouts", async, tt.wantTimeout)
+			}
+		})
+	}
+}
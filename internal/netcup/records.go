@@ -0,0 +1,215 @@
+package netcup
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ErrRecordNotFound is returned by FindDnsRecord when no record matches
+var ErrRecordNotFound = errors.New("record not found")
+
+// NewARecord builds an A record pointing hostname (the subdomain, "@" for the apex) at
+// an IPv4 address
+func NewARecord(hostname, ip string) (DnsRecord, error) {
+	return newRecord(DnsRecord{Hostname: hostname, Type: "A", Priority: "0", Destination: ip})
+}
+
+// NewAAAARecord builds an AAAA record pointing hostname at an IPv6 address
+func NewAAAARecord(hostname, ip string) (DnsRecord, error) {
+	return newRecord(DnsRecord{Hostname: hostname, Type: "AAAA", Priority: "0", Destination: ip})
+}
+
+// NewTXTRecord builds a TXT record of hostname holding text
+func NewTXTRecord(hostname, text string) (DnsRecord, error) {
+	return newRecord(DnsRecord{Hostname: hostname, Type: "TXT", Priority: "0", Destination: text})
+}
+
+// NewCNAMERecord builds a CNAME record making hostname an alias of target
+func NewCNAMERecord(hostname, target string) (DnsRecord, error) {
+	return newRecord(DnsRecord{Hostname: hostname, Type: "CNAME", Priority: "0", Destination: target})
+}
+
+// NewMXRecord builds an MX record delivering mail for hostname to server, preferring
+// servers with a lower priority
+func NewMXRecord(hostname, server string, priority int) (DnsRecord, error) {
+	return newRecord(DnsRecord{Hostname: hostname, Type: "MX", Priority: strconv.Itoa(priority), Destination: server})
+}
+
+func newRecord(record DnsRecord) (DnsRecord, error) {
+	if err := ValidateRecord(record); err != nil {
+		return DnsRecord{}, err
+	}
+	return record, nil
+}
+
+// ValidateRecord checks a record before it is submitted, so bad input fails with a clear
+// error instead of an opaque API response
+func ValidateRecord(record DnsRecord) error {
+	if err := validateSubdomain(record.Hostname); err != nil {
+		return err
+	}
+
+	switch record.Type {
+	case "A":
+		if ip := net.ParseIP(record.Destination); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("destination %q of %s is not a valid IPv4 address", record.Destination, record.Hostname)
+		}
+	case "AAAA":
+		if ip := net.ParseIP(record.Destination); ip == nil || ip.To4() != nil {
+			return fmt.Errorf("destination %q of %s is not a valid IPv6 address", record.Destination, record.Hostname)
+		}
+	case "CNAME":
+		if record.Hostname == "@" {
+			return fmt.Errorf("CNAME record is not allowed at the zone apex")
+		}
+		if err := validateName(strings.TrimSuffix(record.Destination, ".")); err != nil {
+			return fmt.Errorf("destination of %s is not a valid hostname: %w", record.Hostname, err)
+		}
+	case "TXT":
+		if record.Destination == "" {
+			return fmt.Errorf("TXT record %s is empty", record.Hostname)
+		}
+	case "MX":
+		if _, err := strconv.ParseUint(record.Priority, 10, 16); err != nil {
+			return fmt.Errorf("MX record %s: priority %q must be a number from 0 to 65535", record.Hostname, record.Priority)
+		}
+		if err := validateName(strings.TrimSuffix(record.Destination, ".")); err != nil {
+			return fmt.Errorf("MX record %s: mail server is not a valid hostname: %w", record.Hostname, err)
+		}
+	case "SRV":
+		if err := validateSRV(record); err != nil {
+			return fmt.Errorf("SRV record %s: %w", record.Hostname, err)
+		}
+	}
+	return nil
+}
+
+// validateSRV checks the priority and the weight, port and target of an SRV record,
+// whose numbers are 16 bit (RFC 2782)
+func validateSRV(record DnsRecord) error {
+	if _, err := strconv.ParseUint(record.Priority, 10, 16); err != nil {
+		return fmt.Errorf("priority %q must be a number from 0 to 65535", record.Priority)
+	}
+	fields := strings.Fields(record.Destination)
+	if len(fields) != 3 {
+		return fmt.Errorf("destination %q must be weight, port and target", record.Destination)
+	}
+	for i, name := range []string{"weight", "port"} {
+		if _, err := strconv.ParseUint(fields[i], 10, 16); err != nil {
+			return fmt.Errorf("%s %q must be a number from 0 to 65535", name, fields[i])
+		}
+	}
+	// A target of "." announces that the service is not available
+	if fields[2] == "." {
+		return nil
+	}
+	if err := validateName(strings.TrimSuffix(fields[2], ".")); err != nil {
+		return fmt.Errorf("target is not a valid hostname: %w", err)
+	}
+	return nil
+}
+
+// validateSubdomain checks the host part of a record: "@" for the zone apex, or
+// dot-separated labels, the first of which may be the "*" wildcard
+func validateSubdomain(subdomain string) error {
+	if subdomain == "@" || subdomain == "*" {
+		return nil
+	}
+	if err := validateName(strings.TrimPrefix(subdomain, "*.")); err != nil {
+		return fmt.Errorf("invalid subdomain %q: %w", subdomain, err)
+	}
+	return nil
+}
+
+// validateName checks a hostname against the DNS naming rules: at most 253
+// characters of dot-separated labels with 1 to 63 letters, digits, hyphens or
+// underscores, not starting or ending with a hyphen
+func validateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("name is empty")
+	}
+	if len(name) > 253 {
+		return fmt.Errorf("name is longer than 253 characters")
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 {
+			return fmt.Errorf("label %q must be 1 to 63 characters long", label)
+		}
+		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return fmt.Errorf("label %q must not start or end with a hyphen", label)
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return fmt.Errorf("label %q contains invalid character %q", label, r)
+			}
+		}
+	}
+	return nil
+}
+
+// FindRecords returns the records of recordType for hostname (the subdomain, "@" for
+// the apex), in zone order
+func FindRecords(records []DnsRecord, hostname, recordType string) []DnsRecord {
+	var matched []DnsRecord
+	for _, record := range records {
+		if record.Hostname == hostname && record.Type == recordType {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
+
+// FindDnsRecord looks up the first record of recordType for hostname via InfoDnsRecords.
+// It returns ErrRecordNotFound if no record matched.
+func FindDnsRecord(session DnsSession, domainName, hostname, recordType string) (*DnsRecord, error) {
+	records, err := session.InfoDnsRecords(domainName)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := FindRecords(*records, hostname, recordType)
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("%s record %s.%s: %w", recordType, hostname, domainName, ErrRecordNotFound)
+	}
+	return &matched[0], nil
+}
+
+// EnsureDnsRecord makes the first record of the same hostname and type match record,
+// updating it in place so its Id stays stable, or creates it if there is none. Further
+// records of the hostname and type are kept, so it suits types with one value per
+// hostname. It returns the resulting record and whether a change was submitted.
+func EnsureDnsRecord(session DnsSession, domainName string, record DnsRecord) (*DnsRecord, bool, error) {
+	if err := ValidateRecord(record); err != nil {
+		return nil, false, err
+	}
+
+	existing, err := FindDnsRecord(session, domainName, record.Hostname, record.Type)
+	switch {
+	case errors.Is(err, ErrRecordNotFound):
+		record.Id = ""
+	case err != nil:
+		return nil, false, err
+	case existing.Destination == record.Destination && existing.Priority == record.Priority:
+		return existing, false, nil
+	default:
+		record.Id = existing.Id
+	}
+
+	change := []DnsRecord{record}
+	updated, err := session.UpdateDnsRecords(domainName, &change)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Netcup returns the whole zone, including the Id of a created record
+	for _, result := range FindRecords(*updated, record.Hostname, record.Type) {
+		if result.Destination == record.Destination && (record.Id == "" || result.Id == record.Id) {
+			return &result, true, nil
+		}
+	}
+	return &record, true, nil
+}
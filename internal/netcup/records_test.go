@@ -0,0 +1,147 @@
+package netcup
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		record  DnsRecord
+		wantErr bool
+	}{
+		{name: "A record", record: DnsRecord{Hostname: "app", Type: "A", Destination: "203.0.113.7"}},
+		{name: "apex", record: DnsRecord{Hostname: "@", Type: "A", Destination: "203.0.113.7"}},
+		{name: "wildcard", record: DnsRecord{Hostname: "*", Type: "A", Destination: "203.0.113.7"}},
+		{name: "nested wildcard", record: DnsRecord{Hostname: "*.apps", Type: "A", Destination: "203.0.113.7"}},
+		{name: "nested subdomain", record: DnsRecord{Hostname: "api.v2", Type: "A", Destination: "203.0.113.7"}},
+		{name: "AAAA record", record: DnsRecord{Hostname: "app", Type: "AAAA", Destination: "2001:db8::1"}},
+		{name: "CNAME record", record: DnsRecord{Hostname: "www", Type: "CNAME", Destination: "cdn.example.net."}},
+		{name: "IPv6 in A record", record: DnsRecord{Hostname: "app", Type: "A", Destination: "2001:db8::1"}, wantErr: true},
+		{name: "hostname in A record", record: DnsRecord{Hostname: "app", Type: "A", Destination: "cdn.example.net"}, wantErr: true},
+		{name: "IPv4 in AAAA record", record: DnsRecord{Hostname: "app", Type: "AAAA", Destination: "203.0.113.7"}, wantErr: true},
+		{name: "invalid CNAME target", record: DnsRecord{Hostname: "www", Type: "CNAME", Destination: "cdn..example.net"}, wantErr: true},
+		{name: "SRV record", record: DnsRecord{Hostname: "_minecraft._tcp.mc", Type: "SRV", Priority: "0", Destination: "5 25565 mc.example.com."}},
+		{name: "SRV record without service", record: DnsRecord{Hostname: "_sip._tcp", Type: "SRV", Priority: "0", Destination: "0 0 ."}},
+		{name: "SRV priority out of range", record: DnsRecord{Hostname: "_minecraft._tcp", Type: "SRV", Priority: "65536", Destination: "5 25565 mc.example.com."}, wantErr: true},
+		{name: "SRV missing port", record: DnsRecord{Hostname: "_minecraft._tcp", Type: "SRV", Priority: "0", Destination: "5 mc.example.com."}, wantErr: true},
+		{name: "empty subdomain", record: DnsRecord{Hostname: "", Type: "A", Destination: "203.0.113.7"}, wantErr: true},
+		{name: "invalid character", record: DnsRecord{Hostname: "my app", Type: "A", Destination: "203.0.113.7"}, wantErr: true},
+		{name: "leading hyphen", record: DnsRecord{Hostname: "-app", Type: "A", Destination: "203.0.113.7"}, wantErr: true},
+		{name: "label too long", record: DnsRecord{Hostname: strings.Repeat("a", 64), Type: "A", Destination: "203.0.113.7"}, wantErr: true},
+		{name: "CNAME at apex", record: DnsRecord{Hostname: "@", Type: "CNAME", Destination: "cdn.example.net."}, wantErr: true},
+		{name: "TXT record", record: DnsRecord{Hostname: "_dmarc", Type: "TXT", Destination: "v=DMARC1; p=none"}},
+		{name: "empty TXT record", record: DnsRecord{Hostname: "_dmarc", Type: "TXT"}, wantErr: true},
+		{name: "MX record", record: DnsRecord{Hostname: "@", Type: "MX", Priority: "10", Destination: "mail.example.com."}},
+		{name: "MX priority out of range", record: DnsRecord{Hostname: "@", Type: "MX", Priority: "-1", Destination: "mail.example.com."}, wantErr: true},
+		{name: "MX address instead of hostname", record: DnsRecord{Hostname: "@", Type: "MX", Priority: "10", Destination: "mail server"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRecord(tt.record); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRecord() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecordBuilders(t *testing.T) {
+	record, err := NewMXRecord("@", "mail.example.com.", 10)
+	if err != nil {
+		t.Fatalf("NewMXRecord() error = %v", err)
+	}
+	if record != (DnsRecord{Hostname: "@", Type: "MX", Priority: "10", Destination: "mail.example.com."}) {
+		t.Errorf("NewMXRecord() = %+v", record)
+	}
+	if record, err := NewAAAARecord("app", "2001:db8::1"); err != nil || record.Type != "AAAA" || record.Priority != "0" {
+		t.Errorf("NewAAAARecord() = %+v, %v", record, err)
+	}
+
+	for name, build := range map[string]func() (DnsRecord, error){
+		"A with IPv6":      func() (DnsRecord, error) { return NewARecord("app", "2001:db8::1") },
+		"AAAA with IPv4":   func() (DnsRecord, error) { return NewAAAARecord("app", "203.0.113.7") },
+		"empty TXT":        func() (DnsRecord, error) { return NewTXTRecord("_dmarc", "") },
+		"CNAME at apex":    func() (DnsRecord, error) { return NewCNAMERecord("@", "cdn.example.net.") },
+		"MX priority":      func() (DnsRecord, error) { return NewMXRecord("@", "mail.example.com.", 65536) },
+		"invalid hostname": func() (DnsRecord, error) { return NewARecord("my app", "203.0.113.7") },
+	} {
+		if record, err := build(); err == nil {
+			t.Errorf("%s: built %+v, want error", name, record)
+		}
+	}
+}
+
+func TestFindDnsRecord(t *testing.T) {
+	api := NewFakeAPI()
+	api.AddZone("example.com",
+		DnsRecord{Hostname: "app", Type: "AAAA", Destination: "2001:db8::1"},
+		DnsRecord{Hostname: "app", Type: "A", Destination: "203.0.113.1"},
+		DnsRecord{Hostname: "app", Type: "A", Destination: "203.0.113.2"},
+	)
+	session, err := api.Login(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := FindDnsRecord(session, "example.com", "app", "A")
+	if err != nil {
+		t.Fatalf("FindDnsRecord() error = %v", err)
+	}
+	if record.Destination != "203.0.113.1" || record.Id == "" {
+		t.Errorf("FindDnsRecord() = %+v, want the first A record", record)
+	}
+
+	if _, err := FindDnsRecord(session, "example.com", "web", "A"); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("FindDnsRecord() of a missing record error = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestEnsureDnsRecord(t *testing.T) {
+	api := NewFakeAPI()
+	api.AddZone("example.com", DnsRecord{Hostname: "_heartbeat", Type: "TXT", Destination: "old"})
+	session, err := api.Login(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := api.Records("example.com")[0].Id
+
+	ensure := func(record DnsRecord, wantChanged bool) *DnsRecord {
+		t.Helper()
+		result, changed, err := EnsureDnsRecord(session, "example.com", record)
+		if err != nil {
+			t.Fatalf("EnsureDnsRecord() error = %v", err)
+		}
+		if changed != wantChanged {
+			t.Errorf("EnsureDnsRecord() changed = %v, want %v", changed, wantChanged)
+		}
+		return result
+	}
+
+	// An existing record is updated in place
+	heartbeat, _ := NewTXTRecord("_heartbeat", "new")
+	if result := ensure(heartbeat, true); result.Id != id || result.Destination != "new" {
+		t.Errorf("EnsureDnsRecord() = %+v, want record %s updated", result, id)
+	}
+	ensure(heartbeat, false)
+
+	// A missing record is created
+	mx, _ := NewMXRecord("@", "mail.example.com.", 10)
+	if result := ensure(mx, true); result.Id == "" {
+		t.Errorf("EnsureDnsRecord() = %+v, want the Id of the created record", result)
+	}
+
+	if got := len(api.Records("example.com")); got != 2 {
+		t.Errorf("%d records, want 2", got)
+	}
+	if got := api.CallCount("updateDnsRecords"); got != 2 {
+		t.Errorf("updateDnsRecords calls = %d, want 2", got)
+	}
+
+	if _, _, err := EnsureDnsRecord(session, "example.com", DnsRecord{Hostname: "app", Type: "A", Destination: "app.example.net"}); err == nil {
+		t.Error("EnsureDnsRecord() of an invalid record error = nil, want error")
+	}
+}
@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/events"
+)
+
+// HandleEvent notifies about record changes and reconciliation summaries published
+// on the event bus
+func (n *Notifier) HandleEvent(_ context.Context, event events.Event) {
+	switch e := event.(type) {
+	case events.RecordCreated:
+		n.SendSuccess(fmt.Sprintf("Created DNS: %s -> %s%s%s", e.Host.Hostname, e.IP, e.Host.StackSuffix(), formatDiff(e.Host.Subdomain, e.Diff)))
+	case events.RecordUpdated:
+		n.SendSuccess(fmt.Sprintf("Updated DNS: %s -> %s%s%s", e.Host.Hostname, e.IP, e.Host.StackSuffix(), formatDiff(e.Host.Subdomain, e.Diff)))
+	case events.RecordRemoved:
+		n.SendSuccess(fmt.Sprintf("Deleted DNS: %s%s%s", e.Host.Hostname, e.Host.StackSuffix(), formatDiff(e.Host.Subdomain, e.Diff)))
+	case events.RecordFailed:
+		verb := "update"
+		if e.Host.Remove {
+			verb = "delete"
+		}
+		n.SendError(fmt.Sprintf("Failed to %s DNS for %s%s: %v", verb, e.Host.Hostname, e.Host.StackSuffix(), e.Err))
+	case events.ReconcileCompleted:
+		summary := fmt.Sprintf("Reconciliation complete: %d synced, %d already in sync, %d errors", e.Synced, e.InSync, e.Errored)
+		if e.Errored > 0 {
+			n.SendEventError(EventReconciliation, summary)
+		} else {
+			n.SendEvent(EventReconciliation, summary)
+		}
+	}
+}
+
+// formatDiff renders the changes of a record update compactly, e.g.
+// " (A 203.0.113.1 -> 203.0.113.2, TTL 3600 -> 300, +TXT _meta.app)". Records of other
+// names than subdomain, the host's own, are named.
+func formatDiff(subdomain string, changes []events.Change) string {
+	if len(changes) == 0 {
+		return ""
+	}
+
+	parts := make([]string, len(changes))
+	for i, change := range changes {
+		name := change.Type
+		if change.Name != "" && change.Name != subdomain {
+			name += " " + change.Name
+		}
+		switch {
+		case change.Before == "":
+			parts[i] = "+" + name
+		case change.After == "":
+			parts[i] = "-" + name
+		default:
+			parts[i] = fmt.Sprintf("%s %s -> %s", name, change.Before, change.After)
+		}
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
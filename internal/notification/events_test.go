@@ -0,0 +1,59 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/events"
+)
+
+func TestNotifier_HandleEvent(t *testing.T) {
+	host := docker.HostInfo{Hostname: "app.example.com", Subdomain: "app", ComposeProject: "shop", ComposeService: "web"}
+	removed := host
+	removed.Remove = true
+
+	tests := []struct {
+		name  string
+		event events.Event
+		want  string
+	}{
+		{name: "created", event: events.RecordCreated{Host: host, IP: "203.0.113.1"}, want: "SUCCESS: Created DNS: app.example.com -> 203.0.113.1 [shop/web]"},
+		{name: "updated", event: events.RecordUpdated{Host: host, PreviousIP: "203.0.113.1", IP: "203.0.113.2"}, want: "SUCCESS: Updated DNS: app.example.com -> 203.0.113.2 [shop/web]"},
+		{name: "removed", event: events.RecordRemoved{Host: host}, want: "SUCCESS: Deleted DNS: app.example.com [shop/web]"},
+		{name: "updated with diff", event: events.RecordUpdated{Host: host, PreviousIP: "203.0.113.1", IP: "203.0.113.2", Diff: []events.Change{
+			{Name: "app", Type: "A", Before: "203.0.113.1", After: "203.0.113.2"},
+			{Name: "_meta.app", Type: "TXT", After: "container=web"},
+			{Type: "TTL", Before: "86400", After: "300"},
+		}}, want: "SUCCESS: Updated DNS: app.example.com -> 203.0.113.2 [shop/web] (A 203.0.113.1 -> 203.0.113.2, +TXT _meta.app, TTL 86400 -> 300)"},
+		{name: "removed with diff", event: events.RecordRemoved{Host: host, Diff: []events.Change{
+			{Name: "app", Type: "A", Before: "203.0.113.1"},
+			{Name: "_meta.app", Type: "TXT", Before: "container=web"},
+		}}, want: "SUCCESS: Deleted DNS: app.example.com [shop/web] (-A, -TXT _meta.app)"},
+		{name: "update failed", event: events.RecordFailed{Host: host, Err: errors.New("boom")}, want: "ERROR: Failed to update DNS for app.example.com [shop/web]: boom"},
+		{name: "delete failed", event: events.RecordFailed{Host: removed, Err: errors.New("boom")}, want: "ERROR: Failed to delete DNS for app.example.com [shop/web]: boom"},
+		{name: "reconciled", event: events.ReconcileCompleted{Synced: 2, InSync: 3}, want: "INFO: Reconciliation complete: 2 synced, 3 already in sync, 0 errors"},
+		{name: "reconciled with errors", event: events.ReconcileCompleted{Synced: 1, Errored: 1}, want: "ERROR: Reconciliation complete: 1 synced, 0 already in sync, 1 errors"},
+		{name: "container events are ignored", event: events.ContainerStarted{Host: host}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &fakeSender{}
+			n := &Notifier{sender: sender, enabled: true}
+
+			n.HandleEvent(context.Background(), tt.event)
+
+			if tt.want == "" {
+				if len(sender.messages) != 0 {
+					t.Errorf("messages = %v, want none", sender.messages)
+				}
+				return
+			}
+			if len(sender.messages) != 1 || sender.messages[0] != tt.want {
+				t.Errorf("messages = %v, want [%s]", sender.messages, tt.want)
+			}
+		})
+	}
+}
@@ -1,19 +1,75 @@
 package notification
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/nicholas-fedor/shoutrrr"
-	"github.com/nicholas-fedor/shoutrrr/pkg/router"
+	"github.com/nicholas-fedor/shoutrrr/pkg/types"
 )
 
+// EventType categorizes notifications so users can subscribe to the ones they care about
+type EventType string
+
+const (
+	EventRecord         EventType = "record"          // Per-record changes and errors
+	EventLifecycle      EventType = "lifecycle"       // Companion start and stop
+	EventDocker         EventType = "docker"          // Docker connection lost and restored
+	EventCircuitBreaker EventType = "circuit_breaker" // Netcup circuit breaker opened or closed
+	EventReconciliation EventType = "reconciliation"  // Reconciliation summaries
+	EventSummary        EventType = "summary"         // Scheduled stats summaries
+	EventUpdate         EventType = "update"          // Newer companion releases
+	EventMaintenance    EventType = "maintenance"     // Netcup API maintenance started and over
+)
+
+// EventTypes lists all supported event types
+var EventTypes = []EventType{EventRecord, EventLifecycle, EventDocker, EventCircuitBreaker, EventReconciliation, EventSummary, EventUpdate, EventMaintenance}
+
+// sender delivers a message to one or more services, returning one error per service
+type sender interface {
+	Send(message string, params *types.Params) []error
+}
+
 type Notifier struct {
-	sender  *router.ServiceRouter
-	enabled bool
+	sender   sender
+	fallback sender    // Used when every primary service fails; nil if not configured
+	spool    *spool    // Undelivered notifications; nil if not configured
+	throttle *throttle // Suppresses repeated notifications; nil if not configured
+	enabled  bool
+	events   map[EventType]bool // Subscribed event types; nil subscribes to all
+
+	// Background delivery, so a hanging service never blocks the caller
+	queue         chan string   // Messages awaiting the worker; nil delivers inline
+	queueMu       sync.RWMutex  // Guards sending on queue against Close
+	closed        bool          // Close was called, later messages are delivered inline
+	done          chan struct{} // Closed once the worker delivered the queue
+	spoolOverflow bool          // Spool messages arriving while the queue is full instead of dropping them
+	timeout       time.Duration // Bounds each delivery attempt; zero waits indefinitely
+}
+
+// NotifierOptions holds optional settings for the notifier
+type NotifierOptions struct {
+	Events       []string // Event types to send; empty sends all
+	FallbackURLs []string // Services tried when all primary services fail
+	SpoolPath    string   // File queueing undelivered notifications for retries; empty disables
+
+	// Window per severity ("error", "info", "success") in which identical notifications
+	// are sent only once; missing or zero windows disable throttling
+	Throttle map[string]time.Duration
+
+	QueueSize     int           // Notifications buffered for the background worker; 0 delivers inline
+	Timeout       time.Duration // Bounds each delivery attempt; zero waits indefinitely
+	SpoolOverflow bool          // Spool notifications arriving while the queue is full instead of dropping them
 }
 
 func NewNotifier(urls []string) *Notifier {
+	return NewNotifierWithOptions(urls, &NotifierOptions{})
+}
+
+func NewNotifierWithOptions(urls []string, opts *NotifierOptions) *Notifier {
 	if len(urls) == 0 {
 		return &Notifier{
 			enabled: false,
@@ -28,38 +84,198 @@ func NewNotifier(urls []string) *Notifier {
 		}
 	}
 
-	return &Notifier{
-		sender:  sender,
-		enabled: true,
+	n := &Notifier{
+		sender:        sender,
+		enabled:       true,
+		events:        parseEvents(opts.Events),
+		spoolOverflow: opts.SpoolOverflow,
+		timeout:       opts.Timeout,
+	}
+
+	if len(opts.FallbackURLs) > 0 {
+		if fallback, err := shoutrrr.CreateSender(opts.FallbackURLs...); err != nil {
+			log.Printf("Failed to create fallback notification sender: %v", err)
+		} else {
+			n.fallback = fallback
+		}
+	}
+
+	if len(opts.Throttle) > 0 {
+		n.throttle = newThrottle(opts.Throttle)
 	}
+
+	if opts.SpoolPath != "" {
+		if spool, err := openSpool(opts.SpoolPath); err != nil {
+			log.Printf("Failed to open notification spool: %v", err)
+		} else {
+			n.spool = spool
+		}
+	}
+
+	if opts.QueueSize > 0 {
+		n.queue = make(chan string, opts.QueueSize)
+		n.done = make(chan struct{})
+		go n.work()
+	}
+
+	return n
+}
+
+// parseEvents builds the subscription set, ignoring unknown event types
+func parseEvents(names []string) map[EventType]bool {
+	if len(names) == 0 {
+		return nil
+	}
+
+	events := make(map[EventType]bool, len(names))
+	for _, name := range names {
+		event := EventType(name)
+		known := false
+		for _, e := range EventTypes {
+			if e == event {
+				known = true
+				break
+			}
+		}
+		if !known {
+			log.Printf("Ignoring unknown notification event type %q", name)
+			continue
+		}
+		events[event] = true
+	}
+	return events
+}
+
+// Subscribed reports whether notifications of the given type are sent
+func (n *Notifier) Subscribed(event EventType) bool {
+	return n.enabled && (n.events == nil || n.events[event])
+}
+
+// SendEvent sends an informational notification of the given type
+func (n *Notifier) SendEvent(event EventType, message string) {
+	if !n.Subscribed(event) {
+		return
+	}
+	n.send(fmt.Sprintf("INFO: %s", message))
+}
+
+// SendEventError sends an error notification of the given type
+func (n *Notifier) SendEventError(event EventType, message string) {
+	if !n.Subscribed(event) {
+		return
+	}
+	n.send(fmt.Sprintf("ERROR: %s", message))
 }
 
 func (n *Notifier) SendSuccess(message string) {
-	if !n.enabled {
+	if !n.Subscribed(EventRecord) {
 		return
 	}
 	n.send(fmt.Sprintf("SUCCESS: %s", message))
 }
 
 func (n *Notifier) SendError(message string) {
-	if !n.enabled {
+	if !n.Subscribed(EventRecord) {
 		return
 	}
 	n.send(fmt.Sprintf("ERROR: %s", message))
 }
 
 func (n *Notifier) SendInfo(message string) {
-	if !n.enabled {
+	if !n.Subscribed(EventRecord) {
 		return
 	}
 	n.send(fmt.Sprintf("INFO: %s", message))
 }
 
+// send delivers a message unless it repeats a throttled notification
 func (n *Notifier) send(message string) {
-	errs := n.sender.Send(message, nil)
-	if len(errs) > 0 {
-		for _, err := range errs {
+	if n.throttle != nil && !n.throttle.allow(message, n.enqueue) {
+		return
+	}
+	n.enqueue(message)
+}
+
+// dispatch delivers a message, spooling it for later retries if neither the primary
+// nor the fallback services accept it
+func (n *Notifier) dispatch(message string) {
+	if n.deliver(message) {
+		return
+	}
+	if n.spool != nil {
+		log.Println("Notification could not be delivered, spooling it for retry")
+		n.spool.add(message, time.Now())
+	}
+}
+
+// deliver sends a message to the primary services and, if all of them fail, to the
+// fallback services. It reports whether any service accepted the message.
+func (n *Notifier) deliver(message string) bool {
+	if n.sendTo(n.sender, message) {
+		return true
+	}
+	if n.fallback != nil {
+		log.Println("All notification services failed, trying fallback services")
+		return n.sendTo(n.fallback, message)
+	}
+	return false
+}
+
+// sendTo reports whether at least one service of s accepted the message within the
+// timeout. Services cannot be cancelled, so a timed out send finishes in the background.
+func (n *Notifier) sendTo(s sender, message string) bool {
+	result := make(chan []error, 1)
+	go func() {
+		result <- s.Send(message, nil)
+	}()
+
+	var timeout <-chan time.Time
+	if n.timeout > 0 {
+		timer := time.NewTimer(n.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var errs []error
+	select {
+	case errs = <-result:
+	case <-timeout:
+		log.Printf("Notification error: not delivered within %s", n.timeout)
+		return false
+	}
+
+	delivered := false
+	for _, err := range errs {
+		if err != nil {
 			log.Printf("Notification error: %v", err)
+			continue
+		}
+		delivered = true
+	}
+	return delivered
+}
+
+// RunSpool retries spooled notifications with exponential backoff until ctx is done
+func (n *Notifier) RunSpool(ctx context.Context) {
+	if !n.enabled || n.spool == nil {
+		return
+	}
+
+	if pending := n.spool.len(); pending > 0 {
+		log.Printf("Notification spool holds %d undelivered notifications", pending)
+	}
+
+	ticker := time.NewTicker(spoolRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if delivered := n.spool.retry(time.Now(), n.deliver); delivered > 0 {
+				log.Printf("Delivered %d spooled notifications", delivered)
+			}
 		}
 	}
 }
@@ -1,7 +1,11 @@
 package notification
 
 import (
+	"errors"
+	"path/filepath"
 	"testing"
+
+	"github.com/nicholas-fedor/shoutrrr/pkg/types"
 )
 
 func TestNewNotifier(t *testing.T) {
@@ -48,3 +52,124 @@ func TestNotifier_SendWhenDisabled(t *testing.T) {
 	n.SendError("test")
 	n.SendInfo("test")
 }
+
+func TestNotifier_Subscribed(t *testing.T) {
+	tests := []struct {
+		name   string
+		urls   []string
+		events []string
+		want   map[EventType]bool
+	}{
+		{
+			name:   "disabled notifier",
+			urls:   []string{},
+			events: nil,
+			want:   map[EventType]bool{EventRecord: false, EventLifecycle: false},
+		},
+		{
+			name:   "all events by default",
+			urls:   []string{"generic://example.com"},
+			events: nil,
+			want:   map[EventType]bool{EventRecord: true, EventLifecycle: true, EventDocker: true, EventCircuitBreaker: true, EventReconciliation: true},
+		},
+		{
+			name:   "filtered events",
+			urls:   []string{"generic://example.com"},
+			events: []string{"lifecycle", "circuit_breaker", "unknown"},
+			want:   map[EventType]bool{EventRecord: false, EventLifecycle: true, EventDocker: false, EventCircuitBreaker: true, EventReconciliation: false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NewNotifierWithOptions(tt.urls, &NotifierOptions{Events: tt.events})
+			for event, want := range tt.want {
+				if got := n.Subscribed(event); got != want {
+					t.Errorf("Subscribed(%s) = %v, want %v", event, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNotifier_SendEventWhenDisabled(t *testing.T) {
+	n := NewNotifier([]string{})
+
+	// These should not panic even when disabled
+	n.SendEvent(EventLifecycle, "test")
+	n.SendEventError(EventDocker, "test")
+}
+
+// fakeSender records messages and returns the configured per-service errors
+type fakeSender struct {
+	errs     []error
+	messages []string
+}
+
+func (f *fakeSender) Send(message string, _ *types.Params) []error {
+	f.messages = append(f.messages, message)
+	return f.errs
+}
+
+func TestNotifier_SendFallbackAndSpool(t *testing.T) {
+	failure := errors.New("network down")
+
+	tests := []struct {
+		name         string
+		primaryErrs  []error
+		fallback     *fakeSender
+		wantFallback int
+		wantSpooled  int
+	}{
+		{
+			name:        "primary delivered",
+			primaryErrs: []error{nil},
+			fallback:    &fakeSender{},
+		},
+		{
+			name:        "one of several primary services delivered",
+			primaryErrs: []error{failure, nil},
+			fallback:    &fakeSender{},
+		},
+		{
+			name:         "fallback delivered",
+			primaryErrs:  []error{failure},
+			fallback:     &fakeSender{errs: []error{nil}},
+			wantFallback: 1,
+		},
+		{
+			name:         "all failed",
+			primaryErrs:  []error{failure, failure},
+			fallback:     &fakeSender{errs: []error{failure}},
+			wantFallback: 1,
+			wantSpooled:  1,
+		},
+		{
+			name:        "no fallback configured",
+			primaryErrs: []error{failure},
+			wantSpooled: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spool, err := openSpool(filepath.Join(t.TempDir(), "spool.json"))
+			if err != nil {
+				t.Fatalf("openSpool() error = %v", err)
+			}
+			n := &Notifier{sender: &fakeSender{errs: tt.primaryErrs}, spool: spool, enabled: true}
+			if tt.fallback != nil {
+				n.fallback = tt.fallback
+			}
+
+			n.SendError("Something failed")
+
+			if tt.fallback != nil && len(tt.fallback.messages) != tt.wantFallback {
+				t.Errorf("fallback messages = %d, want %d", len(tt.fallback.messages), tt.wantFallback)
+			}
+			if got := spool.len(); got != tt.wantSpooled {
+				t.Errorf("spooled = %d, want %d", got, tt.wantSpooled)
+			}
+		})
+	}
+}
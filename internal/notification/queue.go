@@ -0,0 +1,57 @@
+package notification
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// enqueue hands a message to the worker. A full queue drops or spools the message, so
+// the caller is never blocked. Without a worker, or once closed, it is delivered inline.
+func (n *Notifier) enqueue(message string) {
+	n.queueMu.RLock()
+	defer n.queueMu.RUnlock()
+
+	if n.queue == nil || n.closed {
+		n.dispatch(message)
+		return
+	}
+	select {
+	case n.queue <- message:
+	default:
+		if n.spoolOverflow && n.spool != nil {
+			log.Println("Notification queue is full, spooling notification for retry")
+			n.spool.add(message, time.Now())
+			return
+		}
+		log.Printf("Notification queue is full, dropping notification: %s", message)
+	}
+}
+
+// work delivers queued messages one at a time until the queue is closed
+func (n *Notifier) work() {
+	defer close(n.done)
+	for message := range n.queue {
+		n.dispatch(message)
+	}
+}
+
+// Close stops accepting messages into the queue and waits until the queued ones were
+// delivered or ctx is done. Messages sent afterwards are delivered inline.
+func (n *Notifier) Close(ctx context.Context) {
+	n.queueMu.Lock()
+	if n.queue == nil || n.closed {
+		n.queueMu.Unlock()
+		return
+	}
+	n.closed = true
+	pending := len(n.queue)
+	close(n.queue)
+	n.queueMu.Unlock()
+
+	select {
+	case <-n.done:
+	case <-ctx.Done():
+		log.Printf("Warning: Gave up delivering queued notifications, up to %d were not sent", pending)
+	}
+}
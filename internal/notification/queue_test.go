@@ -0,0 +1,107 @@
+package notification
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nicholas-fedor/shoutrrr/pkg/types"
+)
+
+// blockingSender blocks every send until release is closed
+type blockingSender struct {
+	release chan struct{}
+
+	mu       sync.Mutex
+	messages []string
+}
+
+func (b *blockingSender) Send(message string, _ *types.Params) []error {
+	<-b.release
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = append(b.messages, message)
+	return []error{nil}
+}
+
+func (b *blockingSender) sent() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.messages...)
+}
+
+// newQueuedNotifier returns a notifier delivering to sender from a queue of size
+func newQueuedNotifier(sender sender, size int) *Notifier {
+	n := &Notifier{sender: sender, enabled: true, queue: make(chan string, size), done: make(chan struct{})}
+	go n.work()
+	return n
+}
+
+func TestNotifier_QueueDoesNotBlock(t *testing.T) {
+	sender := &blockingSender{release: make(chan struct{})}
+	n := newQueuedNotifier(sender, 2)
+
+	sent := make(chan struct{})
+	go func() {
+		// The worker takes the first message, two are queued and the last one dropped
+		for _, message := range []string{"one", "two", "three", "four"} {
+			n.SendInfo(message)
+			time.Sleep(10 * time.Millisecond)
+		}
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SendInfo() blocked on a hanging service")
+	}
+
+	close(sender.release)
+	n.Close(context.Background())
+	if got := sender.sent(); len(got) != 3 || got[0] != "INFO: one" || got[2] != "INFO: three" {
+		t.Errorf("Delivered %v, want the first three messages", got)
+	}
+
+	// Messages after Close are delivered inline
+	n.SendInfo("late")
+	if got := sender.sent(); got[len(got)-1] != "INFO: late" {
+		t.Errorf("Delivered %v, want the late message last", got)
+	}
+}
+
+func TestNotifier_QueueOverflowSpools(t *testing.T) {
+	spool, err := openSpool(filepath.Join(t.TempDir(), "spool.json"))
+	if err != nil {
+		t.Fatalf("openSpool() error = %v", err)
+	}
+	sender := &blockingSender{release: make(chan struct{})}
+	n := &Notifier{sender: sender, enabled: true, spool: spool, spoolOverflow: true, queue: make(chan string, 1), done: make(chan struct{})}
+
+	// Without a worker the queue stays full after the first message
+	n.SendInfo("queued")
+	n.SendInfo("overflow")
+	if got := spool.len(); got != 1 {
+		t.Errorf("Spooled = %d, want the overflowing message", got)
+	}
+}
+
+func TestNotifier_SendTimeout(t *testing.T) {
+	spool, err := openSpool(filepath.Join(t.TempDir(), "spool.json"))
+	if err != nil {
+		t.Fatalf("openSpool() error = %v", err)
+	}
+	sender := &blockingSender{release: make(chan struct{})}
+	defer close(sender.release)
+	n := &Notifier{sender: sender, enabled: true, spool: spool, timeout: 50 * time.Millisecond}
+
+	start := time.Now()
+	n.SendError("Something failed")
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("SendError() took %s, want it bounded by the timeout", elapsed)
+	}
+	if got := spool.len(); got != 1 {
+		t.Errorf("Spooled = %d, want the timed out message", got)
+	}
+}
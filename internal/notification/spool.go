@@ -0,0 +1,158 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	spoolRetryInterval  = 30 * time.Second // How often spooled notifications are checked
+	spoolInitialBackoff = 30 * time.Second
+	spoolMaxBackoff     = time.Hour
+	spoolMaxEntries     = 100 // Oldest notifications are dropped beyond this
+)
+
+// spoolEntry is an undelivered notification
+type spoolEntry struct {
+	Message     string    `json:"message"`
+	QueuedAt    time.Time `json:"queued_at"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+}
+
+// spool is a disk-backed queue of notifications that could not be delivered
+type spool struct {
+	mu      sync.Mutex
+	path    string
+	entries []spoolEntry
+}
+
+var (
+	spoolsMu sync.Mutex
+	spools   = make(map[string]*spool)
+)
+
+// openSpool returns the spool stored at path. Notifiers configured with the same path
+// share one instance so they never overwrite each other's entries.
+func openSpool(path string) (*spool, error) {
+	spoolsMu.Lock()
+	defer spoolsMu.Unlock()
+
+	if s, ok := spools[path]; ok {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	s := &spool{path: path}
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read notification spool: %w", err)
+	default:
+		if err := json.Unmarshal(data, &s.entries); err != nil {
+			log.Printf("Warning: Failed to parse notification spool, starting empty: %v", err)
+		}
+	}
+
+	spools[path] = s
+	return s, nil
+}
+
+// add queues a notification for a later delivery attempt
+func (s *spool) add(message string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = append(s.entries, spoolEntry{
+		Message:     message,
+		QueuedAt:    now,
+		NextAttempt: now.Add(spoolInitialBackoff),
+	})
+	if dropped := len(s.entries) - spoolMaxEntries; dropped > 0 {
+		log.Printf("Notification spool full, dropping %d oldest notifications", dropped)
+		s.entries = s.entries[dropped:]
+	}
+	s.save()
+}
+
+// len returns the number of spooled notifications
+func (s *spool) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// retry attempts to deliver all due notifications and reschedules failed ones with
+// exponential backoff. It returns the number of delivered notifications.
+func (s *spool) retry(now time.Time, deliver func(message string) bool) int {
+	s.mu.Lock()
+	var due, pending []spoolEntry
+	for _, entry := range s.entries {
+		if now.Before(entry.NextAttempt) {
+			pending = append(pending, entry)
+		} else {
+			due = append(due, entry)
+		}
+	}
+	s.entries = pending
+	s.mu.Unlock()
+
+	if len(due) == 0 {
+		return 0
+	}
+
+	// Deliver without holding the lock so new notifications can be spooled meanwhile
+	var failed []spoolEntry
+	for _, entry := range due {
+		message := fmt.Sprintf("[delayed, queued %s] %s", entry.QueuedAt.Format(time.RFC3339), entry.Message)
+		if deliver(message) {
+			continue
+		}
+		entry.Attempts++
+		entry.NextAttempt = now.Add(spoolBackoff(entry.Attempts))
+		failed = append(failed, entry)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(failed, s.entries...)
+	s.save()
+	return len(due) - len(failed)
+}
+
+// spoolBackoff returns the delay before the next delivery attempt
+func spoolBackoff(attempts int) time.Duration {
+	backoff := spoolInitialBackoff
+	for i := 0; i < attempts && backoff < spoolMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, spoolMaxBackoff)
+}
+
+// save writes the spool to disk. The caller holds s.mu.
+func (s *spool) save() {
+	data, err := json.Marshal(s.entries)
+	if err != nil {
+		log.Printf("Warning: Failed to serialize notification spool: %v", err)
+		return
+	}
+
+	tempFile := s.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		log.Printf("Warning: Failed to write notification spool: %v", err)
+		return
+	}
+	if err := os.Rename(tempFile, s.path); err != nil {
+		os.Remove(tempFile)
+		log.Printf("Warning: Failed to write notification spool: %v", err)
+	}
+}
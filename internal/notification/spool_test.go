@@ -0,0 +1,119 @@
+package notification
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpool_PersistsAcrossOpens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool.json")
+	s, err := openSpool(path)
+	if err != nil {
+		t.Fatalf("openSpool() error = %v", err)
+	}
+	s.add("first", time.Now())
+
+	// The same path returns the shared instance
+	if same, _ := openSpool(path); same != s {
+		t.Error("openSpool() returned a new instance for the same path")
+	}
+
+	// A fresh process reads the entries back from disk
+	spoolsMu.Lock()
+	delete(spools, path)
+	spoolsMu.Unlock()
+
+	reopened, err := openSpool(path)
+	if err != nil {
+		t.Fatalf("openSpool() error = %v", err)
+	}
+	if reopened.len() != 1 || reopened.entries[0].Message != "first" {
+		t.Errorf("reopened entries = %+v, want one entry \"first\"", reopened.entries)
+	}
+}
+
+func TestSpool_DropsOldestWhenFull(t *testing.T) {
+	s, err := openSpool(filepath.Join(t.TempDir(), "spool.json"))
+	if err != nil {
+		t.Fatalf("openSpool() error = %v", err)
+	}
+
+	now := time.Now()
+	for i := 0; i < spoolMaxEntries+5; i++ {
+		s.add("message", now.Add(time.Duration(i)*time.Second))
+	}
+
+	if got := s.len(); got != spoolMaxEntries {
+		t.Fatalf("len() = %d, want %d", got, spoolMaxEntries)
+	}
+	if want := now.Add(5 * time.Second); !s.entries[0].QueuedAt.Equal(want) {
+		t.Errorf("oldest entry queued at %v, want %v", s.entries[0].QueuedAt, want)
+	}
+}
+
+func TestSpool_Retry(t *testing.T) {
+	s, err := openSpool(filepath.Join(t.TempDir(), "spool.json"))
+	if err != nil {
+		t.Fatalf("openSpool() error = %v", err)
+	}
+
+	now := time.Now()
+	s.add("Netcup login failed", now)
+
+	var delivered []string
+	deliver := func(ok bool) func(string) bool {
+		return func(message string) bool {
+			delivered = append(delivered, message)
+			return ok
+		}
+	}
+
+	// Not due yet
+	if got := s.retry(now, deliver(true)); got != 0 || len(delivered) != 0 {
+		t.Fatalf("retry() before backoff delivered %d, attempted %d", got, len(delivered))
+	}
+
+	// Due but delivery fails: rescheduled with a longer backoff
+	due := now.Add(spoolInitialBackoff)
+	if got := s.retry(due, deliver(false)); got != 0 {
+		t.Fatalf("retry() with failing delivery = %d, want 0", got)
+	}
+	if s.len() != 1 || s.entries[0].Attempts != 1 {
+		t.Fatalf("entries = %+v, want one entry with 1 attempt", s.entries)
+	}
+	if want := due.Add(spoolBackoff(1)); !s.entries[0].NextAttempt.Equal(want) {
+		t.Errorf("NextAttempt = %v, want %v", s.entries[0].NextAttempt, want)
+	}
+
+	// Delivered on the next attempt, with the original queue time in the message
+	if got := s.retry(due.Add(spoolMaxBackoff), deliver(true)); got != 1 {
+		t.Fatalf("retry() = %d, want 1", got)
+	}
+	if s.len() != 0 {
+		t.Errorf("len() = %d, want 0", s.len())
+	}
+	last := delivered[len(delivered)-1]
+	if !strings.HasPrefix(last, "[delayed, queued ") || !strings.HasSuffix(last, "Netcup login failed") {
+		t.Errorf("delivered message = %q", last)
+	}
+}
+
+func TestSpoolBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: 30 * time.Second},
+		{attempts: 1, want: time.Minute},
+		{attempts: 3, want: 4 * time.Minute},
+		{attempts: 20, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := spoolBackoff(tt.attempts); got != tt.want {
+			t.Errorf("spoolBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/events"
+)
+
+// Summary counts the record changes and errors published on the event bus between
+// summary notifications
+type Summary struct {
+	mu      sync.Mutex
+	changes int
+	errors  int
+}
+
+func NewSummary() *Summary {
+	return &Summary{}
+}
+
+// HandleEvent counts record changes and failures
+func (s *Summary) HandleEvent(_ context.Context, event events.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch event.(type) {
+	case events.RecordCreated, events.RecordUpdated, events.RecordRemoved:
+		s.changes++
+	case events.RecordFailed:
+		s.errors++
+	}
+}
+
+// Take returns the changes and errors counted since the last call and resets the counts
+func (s *Summary) Take() (changes, errors int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes, errors = s.changes, s.errors
+	s.changes, s.errors = 0, 0
+	return changes, errors
+}
+
+// SummaryReport is the content of a summary notification
+type SummaryReport struct {
+	Records  int           // Records currently managed
+	Changes  int           // Records created, updated or deleted in the period
+	Errors   int           // Failed record changes in the period
+	PublicIP string        // Published host IP, empty if unknown or per container
+	Uptime   time.Duration // Time since the companion started
+	Period   time.Duration // Time since the previous summary or the start
+}
+
+// SendSummary sends a summary notification of the period ending now
+func (n *Notifier) SendSummary(report SummaryReport) {
+	lines := []string{
+		fmt.Sprintf("Summary of the last %s:", formatDuration(report.Period)),
+		fmt.Sprintf("Records managed: %d", report.Records),
+		fmt.Sprintf("Changes: %d", report.Changes),
+		fmt.Sprintf("Errors: %d", report.Errors),
+	}
+	if report.PublicIP != "" {
+		lines = append(lines, "Public IP: "+report.PublicIP)
+	}
+	lines = append(lines, "Uptime: "+formatDuration(report.Uptime))
+
+	message := strings.Join(lines, "\n")
+	if report.Errors > 0 {
+		n.SendEventError(EventSummary, message)
+	} else {
+		n.SendEvent(EventSummary, message)
+	}
+}
+
+// formatDuration renders d in days, hours and minutes, e.g. "2d 3h 5m"
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
@@ -0,0 +1,52 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alex289/docker-traefik-netcup-companion/internal/docker"
+	"github.com/alex289/docker-traefik-netcup-companion/internal/events"
+)
+
+func TestSummary(t *testing.T) {
+	summary := NewSummary()
+	ctx := context.Background()
+	host := docker.HostInfo{Hostname: "app.example.com"}
+
+	for _, event := range []events.Event{
+		events.ContainerStarted{Host: host},
+		events.RecordCreated{Host: host, IP: "203.0.113.1"},
+		events.RecordUpdated{Host: host, IP: "203.0.113.2"},
+		events.RecordRemoved{Host: host},
+		events.RecordFailed{Host: host, Err: errors.New("boom")},
+	} {
+		summary.HandleEvent(ctx, event)
+	}
+
+	if changes, errs := summary.Take(); changes != 3 || errs != 1 {
+		t.Errorf("Take() = %d, %d, want 3, 1", changes, errs)
+	}
+	if changes, errs := summary.Take(); changes != 0 || errs != 0 {
+		t.Errorf("Take() after reset = %d, %d, want 0, 0", changes, errs)
+	}
+}
+
+func TestNotifier_SendSummary(t *testing.T) {
+	sender := &fakeSender{}
+	n := &Notifier{sender: sender, enabled: true}
+
+	n.SendSummary(SummaryReport{
+		Records:  12,
+		Changes:  3,
+		PublicIP: "203.0.113.1",
+		Uptime:   50*time.Hour + 5*time.Minute,
+		Period:   24 * time.Hour,
+	})
+
+	want := "INFO: Summary of the last 1d 0h 0m:\nRecords managed: 12\nChanges: 3\nErrors: 0\nPublic IP: 203.0.113.1\nUptime: 2d 2h 5m"
+	if len(sender.messages) != 1 || sender.messages[0] != want {
+		t.Errorf("messages = %q, want [%q]", sender.messages, want)
+	}
+}
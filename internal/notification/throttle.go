@@ -0,0 +1,73 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// throttle suppresses repeats of identical notifications within a window per severity.
+// When a window with suppressed repeats ends, a summary naming the count is sent.
+type throttle struct {
+	mu      sync.Mutex
+	windows map[string]time.Duration // Suppression window per severity
+	repeats map[string]int           // Suppressed repeats per open window, keyed by message
+
+	// schedule runs f after d; replaceable for tests
+	schedule func(d time.Duration, f func())
+}
+
+func newThrottle(windows map[string]time.Duration) *throttle {
+	return &throttle{
+		windows: windows,
+		repeats: make(map[string]int),
+		schedule: func(d time.Duration, f func()) {
+			time.AfterFunc(d, f)
+		},
+	}
+}
+
+// allow reports whether message should be sent. The first occurrence opens a window
+// for its severity, repeats within it are counted and dropped. When the window ends,
+// summarize is called with a summary if repeats were suppressed.
+func (t *throttle) allow(message string, summarize func(string)) bool {
+	severity, _, _ := strings.Cut(message, ":")
+	window := t.windows[strings.ToLower(severity)]
+	if window <= 0 {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, open := t.repeats[message]; open {
+		t.repeats[message]++
+		return false
+	}
+
+	t.repeats[message] = 0
+	t.schedule(window, func() {
+		t.mu.Lock()
+		repeats := t.repeats[message]
+		delete(t.repeats, message)
+		t.mu.Unlock()
+
+		if repeats > 0 {
+			summarize(fmt.Sprintf("%s (repeated %d more times in %s)", message, repeats, formatWindow(window)))
+		}
+	})
+	return true
+}
+
+// formatWindow renders a window without trailing zero units, e.g. 10m instead of 10m0s
+func formatWindow(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,77 @@
+package notification
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	sender := &fakeSender{}
+	n := &Notifier{sender: sender, enabled: true, throttle: newThrottle(map[string]time.Duration{"error": 10 * time.Minute})}
+
+	var windows []func()
+	n.throttle.schedule = func(d time.Duration, f func()) {
+		if d != 10*time.Minute {
+			t.Errorf("window = %s, want 10m", d)
+		}
+		windows = append(windows, f)
+	}
+
+	for i := 0; i < 4; i++ {
+		n.SendError("Failed to login to Netcup")
+	}
+	n.SendError("Failed to get DNS zone for example.com")
+	// Severities without a window are never throttled
+	n.SendInfo("Host IP changed")
+	n.SendInfo("Host IP changed")
+
+	want := []string{
+		"ERROR: Failed to login to Netcup",
+		"ERROR: Failed to get DNS zone for example.com",
+		"INFO: Host IP changed",
+		"INFO: Host IP changed",
+	}
+	assertMessages(t, sender.messages, want)
+	if len(windows) != 2 {
+		t.Fatalf("opened %d windows, want 2", len(windows))
+	}
+
+	// Closing a window summarizes its repeats; a window without repeats stays silent
+	windows[0]()
+	windows[1]()
+	want = append(want, "ERROR: Failed to login to Netcup (repeated 3 more times in 10m)")
+	assertMessages(t, sender.messages, want)
+
+	// The next occurrence opens a new window and is sent right away
+	n.SendError("Failed to login to Netcup")
+	want = append(want, "ERROR: Failed to login to Netcup")
+	assertMessages(t, sender.messages, want)
+}
+
+func assertMessages(t *testing.T, got, want []string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("messages = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("messages[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFormatWindow(t *testing.T) {
+	tests := map[time.Duration]string{
+		10 * time.Minute:               "10m",
+		time.Hour:                      "1h",
+		90 * time.Minute:               "1h30m",
+		30 * time.Second:               "30s",
+		10*time.Minute + 5*time.Second: "10m5s",
+	}
+	for d, want := range tests {
+		if got := formatWindow(d); got != want {
+			t.Errorf("formatWindow(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
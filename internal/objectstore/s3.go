@@ -0,0 +1,234 @@
+// Package objectstore reads and writes single objects in S3-compatible object storage
+// such as AWS S3 or MinIO
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRegion  = "us-east-1"
+	requestTimeout = 30 * time.Second
+
+	// maxObjectSize limits the objects read, state files are far smaller
+	maxObjectSize = 64 << 20
+)
+
+// S3Options holds optional settings for an S3 object
+type S3Options struct {
+	Endpoint   string       // Endpoint of an S3-compatible service, e.g. https://minio.example.com (default: AWS S3 of Region)
+	Region     string       // Region requests are signed for (default: us-east-1)
+	PathStyle  bool         // Address the bucket in the path instead of the host name, as MinIO expects
+	HTTPClient *http.Client // Client used for requests (default: 30s timeout)
+}
+
+// S3Object is a single object in a bucket, authenticated with AWS Signature Version 4
+type S3Object struct {
+	bucket          string
+	key             string
+	accessKeyID     string
+	secretAccessKey string
+	region          string
+	url             *url.URL
+	client          *http.Client
+}
+
+// NewS3Object creates a client for the object key in bucket
+func NewS3Object(bucket, key, accessKeyID, secretAccessKey string, opts *S3Options) (*S3Object, error) {
+	region := opts.Region
+	if region == "" {
+		region = defaultRegion
+	}
+
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	base, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || base.Host == "" || base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+
+	path := base.Path + "/" + escapeKey(key)
+	if opts.PathStyle {
+		path = base.Path + "/" + uriEncode(bucket) + "/" + escapeKey(key)
+	} else {
+		base.Host = bucket + "." + base.Host
+	}
+	objectURL, err := url.Parse(base.Scheme + "://" + base.Host + path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 object %s/%s: %w", bucket, key, err)
+	}
+
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: requestTimeout}
+	}
+	return &S3Object{
+		bucket:          bucket,
+		key:             key,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		region:          region,
+		url:             objectURL,
+		client:          client,
+	}, nil
+}
+
+// String returns the object as an s3:// URL, e.g. for logs
+func (o *S3Object) String() string {
+	return "s3://" + o.bucket + "/" + o.key
+}
+
+// Get reads the object. An object that does not exist returns an error wrapping
+// fs.ErrNotExist.
+func (o *S3Object) Get(ctx context.Context) ([]byte, error) {
+	resp, err := o.do(ctx, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%s: %w", o, fs.ErrNotExist)
+	case resp.StatusCode != http.StatusOK:
+		return nil, responseError(resp)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxObjectSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", o, err)
+	}
+	return data, nil
+}
+
+// Put replaces the object with data
+func (o *S3Object) Put(ctx context.Context, data []byte) error {
+	resp, err := o.do(ctx, http.MethodPut, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+func (o *S3Object) do(ctx context.Context, method string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, o.url.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	o.sign(req, body, time.Now())
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %w", method, o, err)
+	}
+	return resp, nil
+}
+
+// sign adds an AWS Signature Version 4 authorization covering the host and all headers
+// of req
+func (o *S3Object) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + o.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+o.secretAccessKey), date)
+	key = hmacSHA256(key, o.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		o.accessKeyID, scope, signedHeaders, signature))
+}
+
+// responseError describes a failed request with the error code S3 returned
+func responseError(resp *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("%s %s failed: %s: %s", resp.Request.Method, resp.Request.URL.Redacted(), resp.Status, strings.TrimSpace(string(message)))
+}
+
+// escapeKey encodes an object key for the request path, keeping its slashes
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEncode percent-encodes everything but the unreserved characters of RFC 3986, as
+// Signature Version 4 requires
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,214 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCloudflareEndpoint = "https://api.cloudflare.com/client/v4"
+	cloudflareRequestTimeout  = 10 * time.Second
+)
+
+// CloudflareOptions holds optional settings for the Cloudflare provider
+type CloudflareOptions struct {
+	Endpoint   string       // API base URL (default: https://api.cloudflare.com/client/v4)
+	TTL        int          // TTL of created records in seconds, 1 means automatic (default: 60)
+	HTTPClient *http.Client // Client used for API requests (default: 10s timeout)
+}
+
+// Cloudflare manages records through the Cloudflare API v4 using an API token with
+// DNS edit permission on the mirrored zones. Records are never proxied.
+type Cloudflare struct {
+	token    string
+	endpoint string
+	ttl      int
+	client   *http.Client
+
+	mu    sync.Mutex
+	zones map[string]string // Domain -> zone ID
+}
+
+var _ Provider = (*Cloudflare)(nil)
+
+// NewCloudflare creates a Cloudflare provider with default options
+func NewCloudflare(token string) *Cloudflare {
+	return NewCloudflareWithOptions(token, &CloudflareOptions{})
+}
+
+// NewCloudflareWithOptions creates a Cloudflare provider
+func NewCloudflareWithOptions(token string, opts *CloudflareOptions) *Cloudflare {
+	c := &Cloudflare{
+		token:    token,
+		endpoint: defaultCloudflareEndpoint,
+		ttl:      60,
+		client:   &http.Client{Timeout: cloudflareRequestTimeout},
+		zones:    make(map[string]string),
+	}
+	if opts.Endpoint != "" {
+		c.endpoint = strings.TrimSuffix(opts.Endpoint, "/")
+	}
+	if opts.TTL > 0 {
+		c.ttl = opts.TTL
+	}
+	if opts.HTTPClient != nil {
+		c.client = opts.HTTPClient
+	}
+	return c
+}
+
+// Name implements Provider
+func (c *Cloudflare) Name() string {
+	return "cloudflare"
+}
+
+type cloudflareRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+	Proxied bool   `json:"proxied"`
+}
+
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result json.RawMessage `json:"result"`
+}
+
+// SetRecord implements Provider. Surplus A records of the name are deleted.
+func (c *Cloudflare) SetRecord(ctx context.Context, domain, subdomain, ip string) error {
+	zoneID, err := c.zoneID(ctx, domain)
+	if err != nil {
+		return err
+	}
+
+	name := fqdn(domain, subdomain)
+	records, err := c.records(ctx, zoneID, name)
+	if err != nil {
+		return err
+	}
+
+	want := cloudflareRecord{Type: "A", Name: name, Content: ip, TTL: c.ttl}
+	if len(records) == 0 {
+		return c.do(ctx, http.MethodPost, "/zones/"+zoneID+"/dns_records", want, nil)
+	}
+
+	current := records[0]
+	if current.Content != ip || current.TTL != c.ttl || current.Proxied {
+		if err := c.do(ctx, http.MethodPut, "/zones/"+zoneID+"/dns_records/"+current.ID, want, nil); err != nil {
+			return err
+		}
+	}
+	return c.deleteRecords(ctx, zoneID, records[1:])
+}
+
+// DeleteRecord implements Provider
+func (c *Cloudflare) DeleteRecord(ctx context.Context, domain, subdomain string) error {
+	zoneID, err := c.zoneID(ctx, domain)
+	if err != nil {
+		return err
+	}
+
+	records, err := c.records(ctx, zoneID, fqdn(domain, subdomain))
+	if err != nil {
+		return err
+	}
+	return c.deleteRecords(ctx, zoneID, records)
+}
+
+func (c *Cloudflare) deleteRecords(ctx context.Context, zoneID string, records []cloudflareRecord) error {
+	for _, record := range records {
+		if err := c.do(ctx, http.MethodDelete, "/zones/"+zoneID+"/dns_records/"+record.ID, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zoneID looks up the zone of domain, caching it for the lifetime of the provider
+func (c *Cloudflare) zoneID(ctx context.Context, domain string) (string, error) {
+	c.mu.Lock()
+	id, ok := c.zones[domain]
+	c.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	var zones []struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/zones?name="+url.QueryEscape(domain), nil, &zones); err != nil {
+		return "", err
+	}
+	if len(zones) == 0 {
+		return "", fmt.Errorf("cloudflare: no zone found for %s", domain)
+	}
+
+	c.mu.Lock()
+	c.zones[domain] = zones[0].ID
+	c.mu.Unlock()
+	return zones[0].ID, nil
+}
+
+func (c *Cloudflare) records(ctx context.Context, zoneID, name string) ([]cloudflareRecord, error) {
+	var records []cloudflareRecord
+	path := "/zones/" + zoneID + "/dns_records?type=A&name=" + url.QueryEscape(name)
+	if err := c.do(ctx, http.MethodGet, path, nil, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// do sends an API request and decodes the result of a successful response into result
+func (c *Cloudflare) do(ctx context.Context, method, path string, body, result interface{}) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return fmt.Errorf("cloudflare: failed to encode request: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, &payload)
+	if err != nil {
+		return fmt.Errorf("cloudflare: failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cloudflare: %s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	var parsed cloudflareResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return fmt.Errorf("cloudflare: %s %s returned HTTP %d with an unreadable body: %w", method, path, resp.StatusCode, err)
+	}
+	if !parsed.Success || resp.StatusCode >= 300 {
+		messages := make([]string, 0, len(parsed.Errors))
+		for _, e := range parsed.Errors {
+			messages = append(messages, fmt.Sprintf("%s (code %d)", e.Message, e.Code))
+		}
+		return fmt.Errorf("cloudflare: %s %s returned HTTP %d: %s", method, path, resp.StatusCode, strings.Join(messages, "; "))
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(parsed.Result, result); err != nil {
+			return fmt.Errorf("cloudflare: failed to decode result of %s %s: %w", method, path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// cloudflareServer emulates the zone and DNS record endpoints of the Cloudflare API
+type cloudflareServer struct {
+	mu      sync.Mutex
+	records map[string]cloudflareRecord
+	nextID  int
+	calls   []string
+}
+
+func newCloudflareServer(t *testing.T) (*cloudflareServer, *Cloudflare) {
+	t.Helper()
+	s := &cloudflareServer{records: make(map[string]cloudflareRecord)}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return s, NewCloudflareWithOptions("test-token", &CloudflareOptions{Endpoint: server.URL})
+}
+
+func (s *cloudflareServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, r.Method+" "+r.URL.Path)
+
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "errors": []map[string]interface{}{{"code": 10000, "message": "Authentication error"}}})
+		return
+	}
+
+	var result interface{}
+	switch {
+	case r.URL.Path == "/zones":
+		zones := []map[string]string{}
+		if r.URL.Query().Get("name") == "example.com" {
+			zones = append(zones, map[string]string{"id": "zone1"})
+		}
+		result = zones
+	case r.URL.Path == "/zones/zone1/dns_records" && r.Method == http.MethodGet:
+		records := []cloudflareRecord{}
+		for _, record := range s.records {
+			if record.Name == r.URL.Query().Get("name") {
+				records = append(records, record)
+			}
+		}
+		result = records
+	case r.URL.Path == "/zones/zone1/dns_records" && r.Method == http.MethodPost:
+		var record cloudflareRecord
+		json.NewDecoder(r.Body).Decode(&record)
+		s.nextID++
+		record.ID = fmt.Sprintf("rec%d", s.nextID)
+		s.records[record.ID] = record
+		result = record
+	case strings.HasPrefix(r.URL.Path, "/zones/zone1/dns_records/"):
+		id := strings.TrimPrefix(r.URL.Path, "/zones/zone1/dns_records/")
+		if r.Method == http.MethodDelete {
+			delete(s.records, id)
+			result = map[string]string{"id": id}
+			break
+		}
+		var record cloudflareRecord
+		json.NewDecoder(r.Body).Decode(&record)
+		record.ID = id
+		s.records[id] = record
+		result = record
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "errors": []interface{}{}, "result": result})
+}
+
+func (s *cloudflareServer) contents() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	contents := make(map[string]string)
+	for _, record := range s.records {
+		contents[record.Name] = record.Content
+	}
+	return contents
+}
+
+func TestCloudflare_SetAndDeleteRecord(t *testing.T) {
+	server, cf := newCloudflareServer(t)
+	ctx := context.Background()
+
+	if err := cf.SetRecord(ctx, "example.com", "app", "203.0.113.7"); err != nil {
+		t.Fatalf("SetRecord() create error = %v", err)
+	}
+	if err := cf.SetRecord(ctx, "example.com", "@", "203.0.113.1"); err != nil {
+		t.Fatalf("SetRecord() apex error = %v", err)
+	}
+	if err := cf.SetRecord(ctx, "example.com", "app", "203.0.113.8"); err != nil {
+		t.Fatalf("SetRecord() update error = %v", err)
+	}
+
+	want := map[string]string{"app.example.com": "203.0.113.8", "example.com": "203.0.113.1"}
+	if got := server.contents(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("records = %v, want %v", got, want)
+	}
+
+	if err := cf.DeleteRecord(ctx, "example.com", "app"); err != nil {
+		t.Fatalf("DeleteRecord() error = %v", err)
+	}
+	if err := cf.DeleteRecord(ctx, "example.com", "missing"); err != nil {
+		t.Errorf("DeleteRecord() of missing record error = %v", err)
+	}
+	if got := server.contents(); len(got) != 1 || got["example.com"] != "203.0.113.1" {
+		t.Errorf("records after delete = %v", got)
+	}
+
+	zoneLookups := 0
+	for _, call := range server.calls {
+		if call == "GET /zones" {
+			zoneLookups++
+		}
+	}
+	if zoneLookups != 1 {
+		t.Errorf("zone looked up %d times, want 1 (cached)", zoneLookups)
+	}
+}
+
+func TestCloudflare_Unchanged(t *testing.T) {
+	server, cf := newCloudflareServer(t)
+	ctx := context.Background()
+
+	if err := cf.SetRecord(ctx, "example.com", "app", "203.0.113.7"); err != nil {
+		t.Fatalf("SetRecord() error = %v", err)
+	}
+	calls := len(server.calls)
+	if err := cf.SetRecord(ctx, "example.com", "app", "203.0.113.7"); err != nil {
+		t.Fatalf("SetRecord() error = %v", err)
+	}
+	if got := server.calls[calls:]; len(got) != 1 {
+		t.Errorf("unchanged record made calls %v, want only the lookup", got)
+	}
+}
+
+func TestCloudflare_Errors(t *testing.T) {
+	_, cf := newCloudflareServer(t)
+	ctx := context.Background()
+
+	if err := cf.SetRecord(ctx, "unknown.org", "app", "203.0.113.7"); err == nil || !strings.Contains(err.Error(), "no zone found") {
+		t.Errorf("SetRecord() in unknown zone error = %v, want no zone found", err)
+	}
+
+	cf.token = "wrong"
+	cf.zones = map[string]string{}
+	if err := cf.SetRecord(ctx, "example.com", "app", "203.0.113.7"); err == nil || !strings.Contains(err.Error(), "Authentication error") {
+		t.Errorf("SetRecord() with bad token error = %v, want authentication error", err)
+	}
+}
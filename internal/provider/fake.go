@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"context"
+	"sync"
+)
+
+// Fake is an in-memory Provider for tests
+type Fake struct {
+	mu      sync.Mutex
+	records map[string]string // FQDN -> IP
+
+	// Err, if set, is returned by every call
+	Err error
+}
+
+var _ Provider = (*Fake)(nil)
+
+// NewFake creates a fake provider without records
+func NewFake() *Fake {
+	return &Fake{records: make(map[string]string)}
+}
+
+// Name implements Provider
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+// SetRecord implements Provider
+func (f *Fake) SetRecord(ctx context.Context, domain, subdomain, ip string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	f.records[fqdn(domain, subdomain)] = ip
+	return nil
+}
+
+// DeleteRecord implements Provider
+func (f *Fake) DeleteRecord(ctx context.Context, domain, subdomain string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	delete(f.records, fqdn(domain, subdomain))
+	return nil
+}
+
+// Records returns a copy of all records keyed by FQDN
+func (f *Fake) Records() map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	records := make(map[string]string, len(f.records))
+	for name, ip := range f.records {
+		records[name] = ip
+	}
+	return records
+}
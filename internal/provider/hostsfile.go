@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+)
+
+// HostsFile manages records in a file in hosts format ("<ip> <name>" per line), as read
+// by Pi-hole's custom.list, dnsmasq's addn-hosts or the CoreDNS hosts plugin, to serve
+// internal addresses to the LAN. Lines and names of other entries are left untouched.
+// The file is rewritten in place, so it may be a single bind-mounted file.
+type HostsFile struct {
+	path string
+	mu   sync.Mutex
+}
+
+var _ Provider = (*HostsFile)(nil)
+
+// NewHostsFile creates a provider for the hosts file at path, which is created on the
+// first record if it does not exist
+func NewHostsFile(path string) *HostsFile {
+	return &HostsFile{path: path}
+}
+
+// Name implements Provider
+func (h *HostsFile) Name() string {
+	return "hosts file " + h.path
+}
+
+// SetRecord implements Provider
+func (h *HostsFile) SetRecord(ctx context.Context, domain, subdomain, ip string) error {
+	return h.update(fqdn(domain, subdomain), ip)
+}
+
+// DeleteRecord implements Provider
+func (h *HostsFile) DeleteRecord(ctx context.Context, domain, subdomain string) error {
+	return h.update(fqdn(domain, subdomain), "")
+}
+
+// update removes name from all entries and, if ip is set, appends an entry for it. The
+// file is only written when its content changes.
+func (h *HostsFile) update(name, ip string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	data, err := os.ReadFile(h.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", h.path, err)
+	}
+
+	var lines []string
+	if len(data) > 0 {
+		for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			if kept, ok := withoutName(line, name); ok {
+				lines = append(lines, kept)
+			}
+		}
+	}
+	if ip != "" {
+		lines = append(lines, ip+" "+name)
+	}
+
+	content := strings.Join(lines, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	if content == string(data) {
+		return nil
+	}
+	if err := os.WriteFile(h.path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", h.path, err)
+	}
+	return nil
+}
+
+// withoutName returns line without name among its hostnames, or false if the line is an
+// entry left without hostnames. Comments and blank lines are kept as they are.
+func withoutName(line, name string) (string, bool) {
+	entry, comment, hasComment := strings.Cut(line, "#")
+	fields := strings.Fields(entry)
+	if len(fields) < 2 {
+		return line, true
+	}
+
+	names := make([]string, 0, len(fields)-1)
+	for _, field := range fields[1:] {
+		if !strings.EqualFold(field, name) {
+			names = append(names, field)
+		}
+	}
+	switch {
+	case len(names) == len(fields)-1:
+		return line, true
+	case len(names) == 0:
+		return "", false
+	}
+
+	kept := fields[0] + " " + strings.Join(names, " ")
+	if hasComment {
+		kept += " #" + comment
+	}
+	return kept, true
+}
@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHostsFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial string
+		apply   func(h *HostsFile) error
+		want    string
+	}{
+		{
+			name: "creates file",
+			apply: func(h *HostsFile) error {
+				return h.SetRecord(context.Background(), "example.com", "app", "192.168.1.10")
+			},
+			want: "192.168.1.10 app.example.com\n",
+		},
+		{
+			name:    "replaces entry",
+			initial: "# managed\n\n192.168.1.5 app.example.com\n192.168.1.2 nas.lan\n",
+			apply: func(h *HostsFile) error {
+				return h.SetRecord(context.Background(), "example.com", "app", "192.168.1.10")
+			},
+			want: "# managed\n\n192.168.1.2 nas.lan\n192.168.1.10 app.example.com\n",
+		},
+		{
+			name:    "apex record",
+			initial: "192.168.1.2 nas.lan\n",
+			apply:   func(h *HostsFile) error { return h.SetRecord(context.Background(), "example.com", "@", "192.168.1.10") },
+			want:    "192.168.1.2 nas.lan\n192.168.1.10 example.com\n",
+		},
+		{
+			name:    "removes name from shared entry",
+			initial: "192.168.1.5 App.example.com www.example.com # web\n",
+			apply:   func(h *HostsFile) error { return h.DeleteRecord(context.Background(), "example.com", "app") },
+			want:    "192.168.1.5 www.example.com # web\n",
+		},
+		{
+			name:    "deletes entry",
+			initial: "192.168.1.5 app.example.com\n192.168.1.2 nas.lan\n",
+			apply:   func(h *HostsFile) error { return h.DeleteRecord(context.Background(), "example.com", "app") },
+			want:    "192.168.1.2 nas.lan\n",
+		},
+		{
+			name:  "delete without file",
+			apply: func(h *HostsFile) error { return h.DeleteRecord(context.Background(), "example.com", "app") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "custom.list")
+			if tt.initial != "" {
+				if err := os.WriteFile(path, []byte(tt.initial), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := tt.apply(NewHostsFile(path)); err != nil {
+				t.Fatalf("apply error = %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("file = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostsFile_Unchanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "custom.list")
+	h := NewHostsFile(path)
+	if err := h.SetRecord(context.Background(), "example.com", "app", "192.168.1.10"); err != nil {
+		t.Fatalf("SetRecord() error = %v", err)
+	}
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	// An unchanged record does not rewrite the file, which would make resolvers reload it
+	if err := h.SetRecord(context.Background(), "example.com", "app", "192.168.1.10"); err != nil {
+		t.Fatalf("SetRecord() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(past) {
+		t.Errorf("file was rewritten at %v", info.ModTime())
+	}
+}
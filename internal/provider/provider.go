@@ -0,0 +1,23 @@
+// Package provider abstracts DNS providers that mirror the records managed at Netcup,
+// e.g. a secondary zone at another registrar that keeps resolving during Netcup outages.
+package provider
+
+import "context"
+
+// Provider manages A records in zones hosted outside Netcup
+type Provider interface {
+	// Name identifies the provider in logs and notifications
+	Name() string
+	// SetRecord points the A record of subdomain ("@" for the apex) at ip, creating it if needed
+	SetRecord(ctx context.Context, domain, subdomain, ip string) error
+	// DeleteRecord removes the A records of subdomain; missing records are not an error
+	DeleteRecord(ctx context.Context, domain, subdomain string) error
+}
+
+// fqdn returns the fully qualified name of subdomain within domain
+func fqdn(domain, subdomain string) string {
+	if subdomain == "" || subdomain == "@" {
+		return domain
+	}
+	return subdomain + "." + domain
+}
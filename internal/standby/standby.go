@@ -0,0 +1,125 @@
+package standby
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Monitor watches the heartbeat of the primary companion and takes over once it has
+// been stale for the takeover period (and steps down again once the primary publishes
+// a new heartbeat).
+type Monitor struct {
+	interval    time.Duration
+	takeoverAge time.Duration // heartbeat age at which the standby takes over
+
+	mu         sync.RWMutex
+	active     bool
+	lastSeen   time.Time // latest heartbeat of the primary, or the start of the monitor
+	onTakeover func(lastSeen time.Time)
+	onStepDown func()
+
+	// heartbeat returns the time of the primary's latest heartbeat, zero if it has none
+	heartbeat func(ctx context.Context) (time.Time, error)
+}
+
+// NewMonitor returns a monitor reading the primary's heartbeat with heartbeat every
+// interval. Without any heartbeat the primary counts as seen at the start, so a
+// standby started alongside the primary does not take over right away.
+func NewMonitor(heartbeat func(ctx context.Context) (time.Time, error), interval, takeoverAge time.Duration) *Monitor {
+	return &Monitor{
+		interval:    interval,
+		takeoverAge: takeoverAge,
+		lastSeen:    time.Now(),
+		heartbeat:   heartbeat,
+	}
+}
+
+// OnTakeover registers a callback invoked when the primary's heartbeat became stale
+func (m *Monitor) OnTakeover(fn func(lastSeen time.Time)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onTakeover = fn
+}
+
+// OnStepDown registers a callback invoked when the primary published a heartbeat again
+// after a takeover
+func (m *Monitor) OnStepDown(fn func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onStepDown = fn
+}
+
+// Active reports whether the standby has taken over from the primary
+func (m *Monitor) Active() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.active
+}
+
+// LastSeen returns the time of the primary's latest heartbeat seen
+func (m *Monitor) LastSeen() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastSeen
+}
+
+// Run checks the primary's heartbeat until the context is cancelled
+func (m *Monitor) Run(ctx context.Context) {
+	log.Printf("Standby monitor started: checking the primary's heartbeat every %s, taking over after %s",
+		m.interval, m.takeoverAge)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.check(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check reads the primary's heartbeat once and takes over or steps down if needed. A
+// heartbeat that cannot be read changes nothing, as the DNS API it is read from is
+// needed for a takeover as well.
+func (m *Monitor) check(ctx context.Context, now time.Time) {
+	seen, err := m.heartbeat(ctx)
+	if err != nil {
+		log.Printf("Warning: Failed to read the primary's heartbeat: %v", err)
+		return
+	}
+
+	m.mu.Lock()
+	fresh := seen.After(m.lastSeen)
+	if fresh {
+		m.lastSeen = seen
+	}
+	stale := now.Sub(m.lastSeen) >= m.takeoverAge
+
+	var onTakeover func(time.Time)
+	var onStepDown func()
+	switch {
+	case stale && !m.active:
+		m.active = true
+		onTakeover = m.onTakeover
+		log.Printf("Standby: primary heartbeat stale since %s, taking over", m.lastSeen.Format(time.RFC3339))
+	case fresh && !stale && m.active:
+		m.active = false
+		onStepDown = m.onStepDown
+		log.Printf("Standby: primary heartbeat received at %s, stepping down", seen.Format(time.RFC3339))
+	}
+	lastSeen := m.lastSeen
+	m.mu.Unlock()
+
+	if onTakeover != nil {
+		onTakeover(lastSeen)
+	}
+	if onStepDown != nil {
+		onStepDown()
+	}
+}
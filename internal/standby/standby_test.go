@@ -0,0 +1,52 @@
+package standby
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheck_TakeoverAndStepDown(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var heartbeat time.Time
+	var heartbeatErr error
+	m := NewMonitor(func(ctx context.Context) (time.Time, error) { return heartbeat, heartbeatErr }, time.Minute, 15*time.Minute)
+	m.lastSeen = start
+
+	var takeovers, stepDowns int
+	m.OnTakeover(func(lastSeen time.Time) { takeovers++ })
+	m.OnStepDown(func() { stepDowns++ })
+	ctx := context.Background()
+
+	steps := []struct {
+		name       string
+		now        time.Time
+		heartbeat  time.Time
+		err        error
+		wantActive bool
+	}{
+		// Without any heartbeat the primary counts as seen at the start
+		{name: "no heartbeat yet", now: start.Add(10 * time.Minute)},
+		{name: "heartbeat", now: start.Add(12 * time.Minute), heartbeat: start.Add(11 * time.Minute)},
+		{name: "heartbeat fresh enough", now: start.Add(15 * time.Minute), heartbeat: start.Add(11 * time.Minute)},
+		{name: "unreadable heartbeat", now: start.Add(20 * time.Minute), err: errors.New("login failed")},
+		{name: "heartbeat stale", now: start.Add(26 * time.Minute), heartbeat: start.Add(11 * time.Minute), wantActive: true},
+		{name: "still stale", now: start.Add(40 * time.Minute), heartbeat: start.Add(11 * time.Minute), wantActive: true},
+		{name: "primary back", now: start.Add(50 * time.Minute), heartbeat: start.Add(49 * time.Minute)},
+	}
+	for _, step := range steps {
+		heartbeat, heartbeatErr = step.heartbeat, step.err
+		m.check(ctx, step.now)
+		if m.Active() != step.wantActive {
+			t.Fatalf("%s: Active() = %v, want %v", step.name, m.Active(), step.wantActive)
+		}
+	}
+
+	if takeovers != 1 || stepDowns != 1 {
+		t.Errorf("takeovers = %d, step-downs = %d, want one each", takeovers, stepDowns)
+	}
+	if want := start.Add(49 * time.Minute); !m.LastSeen().Equal(want) {
+		t.Errorf("LastSeen() = %s, want %s", m.LastSeen(), want)
+	}
+}
@@ -0,0 +1,69 @@
+package state
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Challenge is the TXT record of an ACME DNS-01 challenge
+type Challenge struct {
+	Hostname  string    `json:"hostname"` // e.g. _acme-challenge.app.example.com
+	Domain    string    `json:"domain"`
+	Subdomain string    `json:"subdomain"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"` // Presented through the challenge API, or first found in the zone
+}
+
+// TrackChallenge remembers a challenge record. A record already tracked keeps its
+// creation time.
+func (m *Manager) TrackChallenge(challenge Challenge) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.challengeIndex(challenge.Hostname, challenge.Value) >= 0 {
+		return nil
+	}
+	m.state.Challenges = append(m.state.Challenges, challenge)
+
+	if err := m.save(); err != nil {
+		m.state.Challenges = m.state.Challenges[:len(m.state.Challenges)-1]
+		return fmt.Errorf("failed to persist challenge: %w", err)
+	}
+	return nil
+}
+
+// ForgetChallenge stops tracking the challenge record of hostname with value, e.g. once
+// it was deleted
+func (m *Manager) ForgetChallenge(hostname, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.challengeIndex(hostname, value)
+	if i < 0 {
+		return nil
+	}
+	previous := slices.Clone(m.state.Challenges)
+	m.state.Challenges = slices.Delete(m.state.Challenges, i, i+1)
+
+	if err := m.save(); err != nil {
+		m.state.Challenges = previous
+		return fmt.Errorf("failed to persist challenges: %w", err)
+	}
+	return nil
+}
+
+// Challenges returns the tracked challenge records
+func (m *Manager) Challenges() []Challenge {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.state.Challenges)
+}
+
+// challengeIndex returns the position of a tracked challenge record, or -1. The caller
+// holds m.mu.
+func (m *Manager) challengeIndex(hostname, value string) int {
+	return slices.IndexFunc(m.state.Challenges, func(c Challenge) bool {
+		return c.Hostname == hostname && c.Value == value
+	})
+}
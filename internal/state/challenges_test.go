@@ -0,0 +1,49 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrackChallenge(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	manager, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	challenge := Challenge{Hostname: "_acme-challenge.app.example.com", Domain: "example.com", Subdomain: "_acme-challenge.app", Value: "token-a", CreatedAt: created}
+	if err := manager.TrackChallenge(challenge); err != nil {
+		t.Fatalf("TrackChallenge() error = %v", err)
+	}
+
+	// Tracking again keeps the creation time
+	again := challenge
+	again.CreatedAt = time.Now()
+	if err := manager.TrackChallenge(again); err != nil {
+		t.Fatalf("TrackChallenge() error = %v", err)
+	}
+	other := challenge
+	other.Value = "token-b"
+	if err := manager.TrackChallenge(other); err != nil {
+		t.Fatalf("TrackChallenge() error = %v", err)
+	}
+
+	reloaded, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenges := reloaded.Challenges()
+	if len(challenges) != 2 || !challenges[0].CreatedAt.Equal(created) {
+		t.Fatalf("Challenges() = %+v, want token-a created at %s and token-b", challenges, created)
+	}
+
+	if err := reloaded.ForgetChallenge(challenge.Hostname, "token-a"); err != nil {
+		t.Fatalf("ForgetChallenge() error = %v", err)
+	}
+	if challenges := reloaded.Challenges(); len(challenges) != 1 || challenges[0].Value != "token-b" {
+		t.Errorf("Challenges() after ForgetChallenge() = %+v, want token-b", challenges)
+	}
+}
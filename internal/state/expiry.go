@@ -0,0 +1,85 @@
+package state
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetExpiry starts the lifetime of a record for the given container. The lifetime of a
+// container's record starts only once, so restarts do not extend it; a new container
+// starts a new lifetime. A non-positive lifetime removes the expiry.
+func (m *Manager) SetExpiry(hostname, containerID string, lifetime time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.state.Records[hostname]
+	if !ok {
+		if lifetime <= 0 {
+			return nil
+		}
+		return fmt.Errorf("no persisted record for %s", hostname)
+	}
+
+	if lifetime <= 0 {
+		if record.ExpiresAt == nil {
+			return nil
+		}
+		record.ExpiresAt = nil
+		record.ContainerID = ""
+	} else {
+		if record.ExpiresAt != nil && record.ContainerID == containerID {
+			return nil
+		}
+		expiresAt := time.Now().Add(lifetime)
+		record.ExpiresAt = &expiresAt
+		record.ContainerID = containerID
+		delete(m.state.Expired, hostname)
+	}
+	m.state.Records[hostname] = record
+
+	if err := m.save(); err != nil {
+		return fmt.Errorf("failed to persist state: %w", err)
+	}
+	return nil
+}
+
+// ExpiredRecords returns the records whose lifetime ended before now
+func (m *Manager) ExpiredRecords(now time.Time) []DNSRecord {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var expired []DNSRecord
+	for _, record := range m.state.Records {
+		if record.ExpiresAt != nil && record.ExpiresAt.Before(now) {
+			expired = append(expired, record)
+		}
+	}
+	return expired
+}
+
+// MarkExpired remembers that the record of hostname expired for the given container, so
+// the container cannot publish it again, and ends the lifetime of a record still kept
+func (m *Manager) MarkExpired(hostname, containerID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.state.Expired[hostname] = containerID
+	if record, ok := m.state.Records[hostname]; ok {
+		record.ExpiresAt = nil
+		m.state.Records[hostname] = record
+	}
+
+	if err := m.save(); err != nil {
+		return fmt.Errorf("failed to persist state: %w", err)
+	}
+	return nil
+}
+
+// IsExpired reports whether the record of hostname expired for the given container
+func (m *Manager) IsExpired(hostname, containerID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	expiredFor, ok := m.state.Expired[hostname]
+	return ok && containerID != "" && expiredFor == containerID
+}
@@ -0,0 +1,118 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSetExpiry(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	m, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	if err := m.SetExpiry("app.example.com", "abc123", time.Hour); err == nil {
+		t.Error("SetExpiry() error = nil for an unknown record")
+	}
+	if err := m.SetExpiry("app.example.com", "abc123", 0); err != nil {
+		t.Errorf("SetExpiry() without lifetime error = %v, want none for an unknown record", err)
+	}
+
+	if err := m.UpdateRecord("app.example.com", "example.com", "app", "203.0.113.1", "A"); err != nil {
+		t.Fatalf("UpdateRecord() error = %v", err)
+	}
+	if err := m.SetExpiry("app.example.com", "abc123", time.Hour); err != nil {
+		t.Fatalf("SetExpiry() error = %v", err)
+	}
+	record, _ := m.GetRecord("app.example.com")
+	if record.ExpiresAt == nil {
+		t.Fatal("ExpiresAt = nil, want the end of the lifetime")
+	}
+	expiresAt := *record.ExpiresAt
+
+	// Restarts of the same container and record updates keep the lifetime
+	if err := m.SetExpiry("app.example.com", "abc123", 2*time.Hour); err != nil {
+		t.Fatalf("SetExpiry() error = %v", err)
+	}
+	if err := m.UpdateRecord("app.example.com", "example.com", "app", "203.0.113.2", "A"); err != nil {
+		t.Fatalf("UpdateRecord() error = %v", err)
+	}
+	if record, _ := m.GetRecord("app.example.com"); record.ExpiresAt == nil || !record.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("ExpiresAt = %v, want %v kept", record.ExpiresAt, expiresAt)
+	}
+
+	// The expiry survives a restart
+	reloaded, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if record, _ := reloaded.GetRecord("app.example.com"); record.ExpiresAt == nil || record.ContainerID != "abc123" {
+		t.Errorf("Reloaded record = %+v, want the expiry of abc123", record)
+	}
+
+	// A container without lifetime removes the expiry
+	if err := m.SetExpiry("app.example.com", "def456", 0); err != nil {
+		t.Fatalf("SetExpiry() error = %v", err)
+	}
+	if record, _ := m.GetRecord("app.example.com"); record.ExpiresAt != nil {
+		t.Errorf("ExpiresAt = %v, want nil", record.ExpiresAt)
+	}
+}
+
+func TestExpiredRecords(t *testing.T) {
+	m, err := NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	for _, hostname := range []string{"a.example.com", "b.example.com", "c.example.com"} {
+		if err := m.UpdateRecord(hostname, "example.com", hostname[:1], "203.0.113.1", "A"); err != nil {
+			t.Fatalf("UpdateRecord() error = %v", err)
+		}
+	}
+	m.SetExpiry("a.example.com", "abc123", time.Hour)
+	m.SetExpiry("b.example.com", "abc123", 3*time.Hour)
+
+	expired := m.ExpiredRecords(time.Now().Add(2 * time.Hour))
+	if len(expired) != 1 || expired[0].Hostname != "a.example.com" {
+		t.Errorf("ExpiredRecords() = %v, want only a.example.com", expired)
+	}
+}
+
+func TestMarkExpired(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	m, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	if err := m.MarkExpired("app.example.com", "abc123"); err != nil {
+		t.Fatalf("MarkExpired() error = %v", err)
+	}
+	if !m.IsExpired("app.example.com", "abc123") {
+		t.Error("IsExpired() = false for the container the record expired for")
+	}
+	if m.IsExpired("app.example.com", "def456") || m.IsExpired("app.example.com", "") {
+		t.Error("IsExpired() = true for another container")
+	}
+
+	reloaded, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if !reloaded.IsExpired("app.example.com", "abc123") {
+		t.Error("IsExpired() = false after reload")
+	}
+
+	// A new lifetime for another container lifts the mark
+	if err := m.UpdateRecord("app.example.com", "example.com", "app", "203.0.113.1", "A"); err != nil {
+		t.Fatalf("UpdateRecord() error = %v", err)
+	}
+	if err := m.SetExpiry("app.example.com", "def456", time.Hour); err != nil {
+		t.Fatalf("SetExpiry() error = %v", err)
+	}
+	if m.IsExpired("app.example.com", "abc123") {
+		t.Error("IsExpired() = true after a new lifetime started")
+	}
+}
@@ -0,0 +1,66 @@
+package state
+
+import (
+	"time"
+)
+
+// Metrics describes the state file and how saving it went
+type Metrics struct {
+	Records               int        `json:"records"`
+	FileSizeBytes         int64      `json:"file_size_bytes"`
+	Saves                 int        `json:"saves"`
+	SaveErrors            int        `json:"save_errors"`
+	ConsecutiveSaveErrors int        `json:"consecutive_save_errors"`
+	LastSaveDurationMs    int64      `json:"last_save_duration_ms"`
+	LastSavedAt           *time.Time `json:"last_saved_at,omitempty"`
+	LastSaveError         string     `json:"last_save_error,omitempty"`
+}
+
+// Metrics returns the record count, file size and save statistics
+func (m *Manager) Metrics() Metrics {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	metrics := m.metrics
+	metrics.Records = len(m.state.Records)
+	if m.metrics.LastSavedAt != nil {
+		savedAt := *m.metrics.LastSavedAt
+		metrics.LastSavedAt = &savedAt
+	}
+	return metrics
+}
+
+// OnSaveFailures registers a callback invoked once consecutive saves failed threshold
+// times, e.g. on a read-only volume. It fires again only after a save succeeded.
+func (m *Manager) OnSaveFailures(threshold int, fn func(failures int, err error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if threshold < 1 {
+		threshold = 1
+	}
+	m.saveFailureThreshold = threshold
+	m.onSaveFailures = fn
+}
+
+// recordSave updates the save statistics. The caller holds m.mu.
+func (m *Manager) recordSave(start time.Time, size int64, err error) {
+	m.metrics.Saves++
+	m.metrics.LastSaveDurationMs = time.Since(start).Milliseconds()
+
+	if err == nil {
+		now := time.Now()
+		m.metrics.FileSizeBytes = size
+		m.metrics.LastSavedAt = &now
+		m.metrics.LastSaveError = ""
+		m.metrics.ConsecutiveSaveErrors = 0
+		return
+	}
+
+	m.metrics.SaveErrors++
+	m.metrics.ConsecutiveSaveErrors++
+	m.metrics.LastSaveError = err.Error()
+	if m.onSaveFailures != nil && m.metrics.ConsecutiveSaveErrors == m.saveFailureThreshold {
+		m.onSaveFailures(m.metrics.ConsecutiveSaveErrors, err)
+	}
+}
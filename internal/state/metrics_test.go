@@ -0,0 +1,94 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	m, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	if err := m.UpdateRecord("app.example.com", "example.com", "app", "203.0.113.1", "A"); err != nil {
+		t.Fatalf("UpdateRecord() error = %v", err)
+	}
+
+	info, err := os.Stat(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := m.Metrics()
+	if metrics.Records != 1 || metrics.Saves != 1 || metrics.SaveErrors != 0 {
+		t.Errorf("Metrics() = %+v, want 1 record after 1 successful save", metrics)
+	}
+	if metrics.FileSizeBytes != info.Size() {
+		t.Errorf("FileSizeBytes = %d, want %d", metrics.FileSizeBytes, info.Size())
+	}
+	if metrics.LastSavedAt == nil {
+		t.Error("LastSavedAt = nil, want the time of the save")
+	}
+
+	// The size of a loaded state file is known before the first save
+	reloaded, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if got := reloaded.Metrics(); got.FileSizeBytes != info.Size() || got.Records != 1 {
+		t.Errorf("Metrics() after load = %+v, want size %d and 1 record", got, info.Size())
+	}
+}
+
+func TestOnSaveFailures(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	var calls []int
+	m.OnSaveFailures(2, func(failures int, err error) {
+		calls = append(calls, failures)
+	})
+
+	// Saving fails while the state directory is replaced by a file
+	blocker := filepath.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	validPath := m.filePath
+	m.filePath = filepath.Join(blocker, "state.json")
+
+	for i := 0; i < 3; i++ {
+		if err := m.UpdateRecord("app.example.com", "example.com", "app", "203.0.113.1", "A"); err == nil {
+			t.Fatal("UpdateRecord() error = nil, want save error")
+		}
+	}
+	if len(calls) != 1 || calls[0] != 2 {
+		t.Errorf("callback calls = %v, want a single call at 2 failures", calls)
+	}
+	metrics := m.Metrics()
+	if metrics.SaveErrors != 3 || metrics.ConsecutiveSaveErrors != 3 || metrics.LastSaveError == "" {
+		t.Errorf("Metrics() = %+v, want 3 consecutive save errors", metrics)
+	}
+
+	// A successful save resets the streak, so the next streak is reported again
+	m.filePath = validPath
+	if err := m.UpdateRecord("app.example.com", "example.com", "app", "203.0.113.1", "A"); err != nil {
+		t.Fatalf("UpdateRecord() error = %v", err)
+	}
+	if metrics := m.Metrics(); metrics.ConsecutiveSaveErrors != 0 || metrics.LastSaveError != "" || metrics.SaveErrors != 3 {
+		t.Errorf("Metrics() = %+v, want the streak reset and the total kept", metrics)
+	}
+
+	m.filePath = filepath.Join(blocker, "state.json")
+	for i := 0; i < 2; i++ {
+		m.UpdateRecord("app.example.com", "example.com", "app", "203.0.113.1", "A")
+	}
+	if len(calls) != 2 {
+		t.Errorf("callback calls = %v, want a second call for the new streak", calls)
+	}
+}
@@ -0,0 +1,127 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// currentVersion is the schema version written by this release
+const currentVersion = 2
+
+// ErrNewerVersion is returned for state files written by a newer release, which are
+// left untouched instead of being overwritten with a downgraded schema
+var ErrNewerVersion = errors.New("state file was written by a newer version")
+
+// document is a state file decoded without a schema, so migrations can rename and
+// restructure fields the State struct no longer knows
+type document map[string]any
+
+// migration upgrades a state file from version-1 to version
+type migration struct {
+	version     int
+	description string
+	migrate     func(doc document) error
+}
+
+// migrations lists the schema changes in order. Append a migration and raise
+// currentVersion whenever the format changes in a way older files cannot be read as is.
+var migrations = []migration{
+	{version: 2, description: "back-fill the owning container of records", migrate: migrateRecordOwners},
+}
+
+// schemaVersion returns the version of a state file. Files written before versioning
+// have none and use the first schema.
+func schemaVersion(data []byte) (int, error) {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, err
+	}
+	return max(header.Version, 1), nil
+}
+
+// migrate upgrades the state file data from version to currentVersion
+func migrate(data []byte, version int) ([]byte, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		if err := m.migrate(doc); err != nil {
+			return nil, fmt.Errorf("migration to version %d (%s) failed: %w", m.version, m.description, err)
+		}
+		log.Printf("Migrated state file to version %d: %s", m.version, m.description)
+	}
+	doc["version"] = currentVersion
+	return json.Marshal(doc)
+}
+
+// Migrate persists a state file schema upgraded on load, backing up the original first.
+// Only the companion itself calls it; read-only commands such as export keep the
+// upgrade in memory, leaving the file of a running companion alone.
+func (m *Manager) Migrate() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.unmigrated == nil {
+		return nil
+	}
+	return m.save()
+}
+
+// backup copies the state file before a migration to <file>.v<version>.bak
+func (m *Manager) backup(data []byte, version int) (string, error) {
+	path := fmt.Sprintf("%s.v%d.bak", m.filePath, version)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to back up state file: %w", err)
+	}
+	return path, nil
+}
+
+// migrateRecordOwners sets the Docker host and container of records persisted before
+// their origin was tracked, from the hostnames persisted per container. Hostnames
+// published by several containers keep an unknown origin.
+func migrateRecordOwners(doc document) error {
+	records, _ := doc["records"].(map[string]any)
+	containers, _ := doc["containers"].(map[string]any)
+
+	owners := make(map[string]string)
+	for key, hostnames := range containers {
+		list, _ := hostnames.([]any)
+		for _, hostname := range list {
+			name, _ := hostname.(string)
+			if _, shared := owners[name]; shared {
+				owners[name] = ""
+				continue
+			}
+			owners[name] = key
+		}
+	}
+
+	for hostname, raw := range records {
+		record, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("record %s is not an object", hostname)
+		}
+		if record["container"] != nil && record["container"] != "" {
+			continue
+		}
+		// Keys are <docker host>/<container>, and Docker hosts of forwarded hosts contain
+		// slashes themselves
+		i := strings.LastIndex(owners[hostname], "/")
+		if i < 0 {
+			continue
+		}
+		record["docker_host"] = owners[hostname][:i]
+		record["container"] = owners[hostname][i+1:]
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewManager_MigratesVersion1(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	original := []byte(`{
+  "version": 1,
+  "records": {
+    "app.example.com": {"hostname": "app.example.com", "domain": "example.com", "subdomain": "app", "ip": "203.0.113.1", "record_type": "A"},
+    "shared.example.com": {"hostname": "shared.example.com", "domain": "example.com", "subdomain": "shared", "ip": "203.0.113.1", "record_type": "A"},
+    "known.example.com": {"hostname": "known.example.com", "domain": "example.com", "subdomain": "known", "ip": "203.0.113.1", "record_type": "A", "docker_host": "local", "container": "known"}
+  },
+  "containers": {
+    "host-b/local/app": ["app.example.com", "shared.example.com"],
+    "local/web": ["shared.example.com"],
+    "local/other": ["known.example.com"]
+  }
+}`)
+	if err := os.WriteFile(stateFile, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	tests := []struct {
+		hostname       string
+		wantDockerHost string
+		wantContainer  string
+	}{
+		{hostname: "app.example.com", wantDockerHost: "host-b/local", wantContainer: "app"},
+		{hostname: "shared.example.com", wantDockerHost: "", wantContainer: ""},
+		{hostname: "known.example.com", wantDockerHost: "local", wantContainer: "known"},
+	}
+	for _, tt := range tests {
+		record, ok := manager.GetRecord(tt.hostname)
+		if !ok {
+			t.Fatalf("Record %s missing after migration", tt.hostname)
+		}
+		if record.DockerHost != tt.wantDockerHost || record.Container != tt.wantContainer {
+			t.Errorf("%s origin = %s/%s, want %s/%s", tt.hostname, record.DockerHost, record.Container, tt.wantDockerHost, tt.wantContainer)
+		}
+	}
+
+	// Loading migrates in memory only, e.g. for an export next to a running companion
+	if data, err := os.ReadFile(stateFile); err != nil || string(data) != string(original) {
+		t.Fatalf("State file changed on load: %v", err)
+	}
+	if _, err := os.Stat(stateFile + ".v1.bak"); !os.IsNotExist(err) {
+		t.Fatalf("Backup written on load: %v", err)
+	}
+
+	if err := manager.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	backup, err := os.ReadFile(stateFile + ".v1.bak")
+	if err != nil {
+		t.Fatalf("Backup missing: %v", err)
+	}
+	if string(backup) != string(original) {
+		t.Errorf("Backup differs from the original state file")
+	}
+
+	data, err := os.ReadFile(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved State
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Version != currentVersion {
+		t.Errorf("Saved version = %d, want %d", saved.Version, currentVersion)
+	}
+}
+
+func TestSave_BacksUpMigratedFile(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	original := []byte(`{"version": 1, "records": {}}`)
+	if err := os.WriteFile(stateFile, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+	manager, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	// A write of a command like tags replaces the old schema without Migrate
+	if err := manager.UpdateRecord("app.example.com", "example.com", "app", "203.0.113.1", "A"); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := os.ReadFile(stateFile + ".v1.bak")
+	if err != nil {
+		t.Fatalf("Backup missing: %v", err)
+	}
+	if string(backup) != string(original) {
+		t.Errorf("Backup differs from the original state file")
+	}
+}
+
+func TestNewManager_CurrentVersionNotMigrated(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	manager, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.UpdateRecord("app.example.com", "example.com", "app", "203.0.113.1", "A"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewManager(stateFile); err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if _, err := os.Stat(stateFile + ".v2.bak"); !os.IsNotExist(err) {
+		t.Errorf("Backup written for a current state file")
+	}
+}
+
+func TestNewManager_NewerVersion(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	newer := []byte(`{"version": 99, "records": {}}`)
+	if err := os.WriteFile(stateFile, newer, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewManager(stateFile); !errors.Is(err, ErrNewerVersion) {
+		t.Fatalf("NewManager() error = %v, want ErrNewerVersion", err)
+	}
+
+	data, err := os.ReadFile(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(newer) {
+		t.Errorf("State file of a newer version was modified")
+	}
+}
@@ -0,0 +1,27 @@
+package state
+
+import "fmt"
+
+// SetRecordID stores the Id Netcup assigned to the record of hostname, so that later
+// updates and deletions address that record instead of matching by name. Hostnames
+// without a persisted record are ignored, and the state file is only written when the
+// Id changed.
+func (m *Manager) SetRecordID(hostname, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.state.Records[hostname]
+	if !ok || record.RecordID == id {
+		return nil
+	}
+	previous := record.RecordID
+	record.RecordID = id
+	m.state.Records[hostname] = record
+
+	if err := m.save(); err != nil {
+		record.RecordID = previous
+		m.state.Records[hostname] = record
+		return fmt.Errorf("failed to persist record Id: %w", err)
+	}
+	return nil
+}
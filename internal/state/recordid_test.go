@@ -0,0 +1,42 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSetRecordID(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	manager, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown hostnames are ignored
+	if err := manager.SetRecordID("app.example.com", "101"); err != nil {
+		t.Fatalf("SetRecordID() error = %v", err)
+	}
+	if _, ok := manager.GetRecord("app.example.com"); ok {
+		t.Fatal("SetRecordID() created a record")
+	}
+
+	if err := manager.UpdateRecord("app.example.com", "example.com", "app", "203.0.113.1", "A"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.SetRecordID("app.example.com", "101"); err != nil {
+		t.Fatalf("SetRecordID() error = %v", err)
+	}
+
+	// Address changes keep the Id, and it survives a restart
+	if err := manager.UpdateRecord("app.example.com", "example.com", "app", "203.0.113.2", "A"); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, _ := reloaded.GetRecord("app.example.com")
+	if record.RecordID != "101" || record.IP != "203.0.113.2" {
+		t.Errorf("Record = %s (Id %q), want 203.0.113.2 (Id \"101\")", record.IP, record.RecordID)
+	}
+}
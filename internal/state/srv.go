@@ -0,0 +1,47 @@
+package state
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SRVRecord is an SRV record published for a host, as sent to Netcup
+type SRVRecord struct {
+	Name        string `json:"name"`        // Record name relative to the zone, e.g. _minecraft._tcp.mc
+	Priority    string `json:"priority"`    // Priority, kept apart from the data by Netcup
+	Destination string `json:"destination"` // Weight, port and target, e.g. "5 25565 mc.example.com."
+}
+
+// SetSRVRecords stores the SRV records published for hostname; none forgets them. The
+// state file is only written when the records changed.
+func (m *Manager) SetSRVRecords(hostname string, records []SRVRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous, ok := m.state.SRV[hostname]
+	if slices.Equal(previous, records) || !ok && len(records) == 0 {
+		return nil
+	}
+	if len(records) == 0 {
+		delete(m.state.SRV, hostname)
+	} else {
+		m.state.SRV[hostname] = slices.Clone(records)
+	}
+
+	if err := m.save(); err != nil {
+		if ok {
+			m.state.SRV[hostname] = previous
+		} else {
+			delete(m.state.SRV, hostname)
+		}
+		return fmt.Errorf("failed to persist SRV records: %w", err)
+	}
+	return nil
+}
+
+// SRVRecords returns the SRV records published for hostname
+func (m *Manager) SRVRecords(hostname string) []SRVRecord {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.state.SRV[hostname])
+}
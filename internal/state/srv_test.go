@@ -0,0 +1,34 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSetSRVRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	m, err := NewManager(path)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	records := []SRVRecord{{Name: "_minecraft._tcp.mc", Priority: "0", Destination: "5 25565 mc.example.com."}}
+	if err := m.SetSRVRecords("mc.example.com", records); err != nil {
+		t.Fatalf("SetSRVRecords() error = %v", err)
+	}
+
+	reloaded, err := NewManager(path)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if got := reloaded.SRVRecords("mc.example.com"); len(got) != 1 || got[0] != records[0] {
+		t.Fatalf("SRVRecords() after reload = %v, want %v", got, records)
+	}
+
+	if err := reloaded.SetSRVRecords("mc.example.com", nil); err != nil {
+		t.Fatalf("SetSRVRecords() error = %v", err)
+	}
+	if got := reloaded.SRVRecords("mc.example.com"); len(got) != 0 {
+		t.Errorf("SRVRecords() = %v, want none", got)
+	}
+}
@@ -1,11 +1,15 @@
 package state
 
 import (
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
@@ -18,13 +22,129 @@ type DNSRecord struct {
 	IP          string    `json:"ip"`
 	RecordType  string    `json:"record_type"`
 	LastUpdated time.Time `json:"last_updated"`
+	RecordID    string    `json:"record_id,omitempty"` // Id of the record at Netcup, once known
+
+	// Origin of the record
+	DockerHost     string `json:"docker_host,omitempty"`     // Docker daemon the record originates from
+	Container      string `json:"container,omitempty"`       // Name of the container publishing the record
+	ComposeProject string `json:"compose_project,omitempty"` // Compose project (stack) owning the container
+	ComposeService string `json:"compose_service,omitempty"` // Compose service of the container
+	Destination    string `json:"destination,omitempty"`     // Custom destination label of the container
+
+	// Lifetime of records from the netcup.expires-in label
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`   // Record is deleted after this time
+	ContainerID string     `json:"container_id,omitempty"` // Container the lifetime started for
+
+	// Last time a running container was seen publishing the record, by a scan or event
+	LastSeen *time.Time `json:"last_seen,omitempty"`
+
+	// Tags from the netcup.companion/tags label of the container
+	Tags []string `json:"tags,omitempty"`
+
+	// Served by the wildcard record the companion consolidated the domain into, instead
+	// of a record of its own
+	Consolidated bool `json:"consolidated,omitempty"`
+}
+
+// Seen returns when a running container last confirmed the record. Records persisted
+// before sightings were tracked fall back to LastUpdated.
+func (r DNSRecord) Seen() time.Time {
+	if r.LastSeen != nil {
+		return *r.LastSeen
+	}
+	return r.LastUpdated
+}
+
+// HasTag reports whether the record carries tag, ignoring case
+func (r DNSRecord) HasTag(tag string) bool {
+	return slices.Contains(r.Tags, strings.ToLower(tag))
+}
+
+// Origin describes the container a record is published for
+type Origin struct {
+	DockerHost     string
+	Container      string
+	ComposeProject string
+	ComposeService string
+	Destination    string
+	Tags           []string
+}
+
+// isZero reports whether the origin is unknown
+func (o Origin) isZero() bool {
+	return o.DockerHost == "" && o.Container == "" && o.ComposeProject == "" && o.ComposeService == "" && o.Destination == "" && len(o.Tags) == 0
+}
+
+// Owner identifies the container owning a record: its Compose service, so that replicas
+// of a scaled service share ownership, or else the container name. It is empty for
+// records of unknown origin.
+func (o Origin) Owner() string {
+	switch {
+	case o.ComposeProject != "":
+		return o.DockerHost + "/" + o.ComposeProject + "/" + o.ComposeService
+	case o.Container != "":
+		return o.DockerHost + "/" + o.Container
+	default:
+		return ""
+	}
+}
+
+// Origin returns the origin the record was persisted with
+func (r DNSRecord) Origin() Origin {
+	return Origin{
+		DockerHost:     r.DockerHost,
+		Container:      r.Container,
+		ComposeProject: r.ComposeProject,
+		ComposeService: r.ComposeService,
+		Destination:    r.Destination,
+		Tags:           r.Tags,
+	}
+}
+
+// PendingHost is a host change that was received but not applied before shutdown
+type PendingHost struct {
+	Hostname  string    `json:"hostname"`
+	Domain    string    `json:"domain"`
+	Subdomain string    `json:"subdomain"`
+	IP        string    `json:"ip,omitempty"`
+	Remove    bool      `json:"remove,omitempty"`
+	DryRun    bool      `json:"dry_run,omitempty"` // Container is labeled for dry runs
+	QueuedAt  time.Time `json:"queued_at"`
+
+	// Origin of the change
+	DockerHost     string `json:"docker_host,omitempty"`
+	Container      string `json:"container,omitempty"`
+	ComposeProject string `json:"compose_project,omitempty"`
+	ComposeService string `json:"compose_service,omitempty"`
+	Destination    string `json:"destination,omitempty"`
+
+	Tags []string `json:"tags,omitempty"`
 }
 
 // State represents the persisted state of DNS records
 type State struct {
 	Version   int                  `json:"version"`
 	UpdatedAt time.Time            `json:"updated_at"`
-	Records   map[string]DNSRecord `json:"records"` // key is the full hostname
+	Records   map[string]DNSRecord `json:"records"`           // key is the full hostname
+	Pending   []PendingHost        `json:"pending,omitempty"` // in the order they were received
+
+	// Hostnames published per container, keyed by Docker host and container name
+	Containers map[string][]string `json:"containers,omitempty"`
+
+	// Hostnames whose record expired, mapped to the ID of the container it expired for
+	Expired map[string]string `json:"expired,omitempty"`
+
+	// Last known status of the zones records were published in, keyed by domain
+	Zones map[string]ZoneStatus `json:"zones,omitempty"`
+
+	// SRV records published from the netcup.companion/srv label, keyed by hostname
+	SRV map[string][]SRVRecord `json:"srv,omitempty"`
+
+	// ACME challenge records, tracked until they are cleaned up
+	Challenges []Challenge `json:"challenges,omitempty"`
+
+	// Wildcard records the companion consolidated records into, keyed by domain
+	Wildcards map[string]Wildcard `json:"wildcards,omitempty"`
 }
 
 // Manager handles persistence of DNS state to disk
@@ -32,14 +152,43 @@ type Manager struct {
 	mu       sync.RWMutex
 	filePath string
 	state    *State
+
+	metrics              Metrics
+	saveFailureThreshold int
+	onSaveFailures       func(failures int, err error)
+
+	// Remote copy of the state file, uploaded after saves
+	store         Store
+	uploadMu      sync.Mutex // Guards pendingUpload and uploading
+	uploadRunMu   sync.Mutex // Serializes uploads
+	pendingUpload []byte
+	uploading     bool
+
+	// Modifications of the state file by other processes
+	written          [sha256.Size]byte // Hash of the file content last loaded or saved
+	onExternalChange func(message string, conflict bool)
+
+	// Original content of a state file migrated on load, backed up before the next save
+	unmigrated        []byte
+	unmigratedVersion int
 }
 
 func NewManager(filePath string) (*Manager, error) {
+	return NewManagerWithOptions(filePath, &ManagerOptions{})
+}
+
+// NewManagerWithOptions creates a state manager with optional settings
+func NewManagerWithOptions(filePath string, opts *ManagerOptions) (*Manager, error) {
 	m := &Manager{
 		filePath: filePath,
 		state: &State{
-			Version: 1,
-			Records: make(map[string]DNSRecord),
+			Version:    currentVersion,
+			Records:    make(map[string]DNSRecord),
+			Containers: make(map[string][]string),
+			Expired:    make(map[string]string),
+			Zones:      make(map[string]ZoneStatus),
+			SRV:        make(map[string][]SRVRecord),
+			Wildcards:  make(map[string]Wildcard),
 		},
 	}
 
@@ -49,8 +198,18 @@ func NewManager(filePath string) (*Manager, error) {
 		return nil, fmt.Errorf("failed to create state directory: %w", err)
 	}
 
+	if opts.Store != nil {
+		m.store = opts.Store
+		if err := m.restore(); err != nil {
+			return nil, err
+		}
+	}
+
 	// Try to load existing state
 	if err := m.load(); err != nil {
+		if errors.Is(err, ErrNewerVersion) {
+			return nil, err
+		}
 		if !os.IsNotExist(err) {
 			log.Printf("Warning: Failed to load existing state, starting fresh: %v", err)
 		}
@@ -64,24 +223,78 @@ func (m *Manager) load() error {
 	if err != nil {
 		return err
 	}
+	m.written = sha256.Sum256(data)
+
+	version, err := schemaVersion(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse state file: %w", err)
+	}
+	if version > currentVersion {
+		return fmt.Errorf("%w: version %d, this release supports up to %d; upgrade the companion or restore a backup", ErrNewerVersion, version, currentVersion)
+	}
+	// Migrate in memory only, so read-only commands leave the file of a running
+	// companion alone; Migrate or the next save persist the upgrade
+	original := data
+	migrated := version < currentVersion
+	if migrated {
+		log.Printf("Migrating state file from version %d to %d", version, currentVersion)
+		if data, err = migrate(data, version); err != nil {
+			return err
+		}
+	}
 
 	var state State
 	if err := json.Unmarshal(data, &state); err != nil {
 		return fmt.Errorf("failed to parse state file: %w", err)
 	}
 
-	// Initialize map if nil (for old state files)
+	// Initialize maps if nil (for old state files)
 	if state.Records == nil {
 		state.Records = make(map[string]DNSRecord)
 	}
+	if state.Containers == nil {
+		state.Containers = make(map[string][]string)
+	}
+	if state.Expired == nil {
+		state.Expired = make(map[string]string)
+	}
+	if state.Zones == nil {
+		state.Zones = make(map[string]ZoneStatus)
+	}
+	if state.SRV == nil {
+		state.SRV = make(map[string][]SRVRecord)
+	}
+	if state.Wildcards == nil {
+		state.Wildcards = make(map[string]Wildcard)
+	}
 
 	m.state = &state
+	m.metrics.FileSizeBytes = int64(len(data))
 	log.Printf("Loaded %d DNS records from state file", len(m.state.Records))
+
+	m.unmigrated, m.unmigratedVersion = nil, 0
+	if migrated {
+		m.unmigrated, m.unmigratedVersion = original, version
+	}
 	return nil
 }
 
-func (m *Manager) save() error {
-	m.state.UpdatedAt = time.Now()
+func (m *Manager) save() (err error) {
+	start := time.Now()
+	var size int64
+	defer func() { m.recordSave(start, size, err) }()
+
+	// The first save after a migration replaces the old schema, so back it up first
+	if m.unmigrated != nil {
+		backup, err := m.backup(m.unmigrated, m.unmigratedVersion)
+		if err != nil {
+			return err
+		}
+		log.Printf("Upgraded state file from version %d to %d, backup written to %s", m.unmigratedVersion, currentVersion, backup)
+		m.unmigrated, m.unmigratedVersion = nil, 0
+	}
+
+	m.state.UpdatedAt = start
 
 	data, err := json.MarshalIndent(m.state, "", "  ")
 	if err != nil {
@@ -99,20 +312,45 @@ func (m *Manager) save() error {
 		return fmt.Errorf("failed to rename temp state file: %w", err)
 	}
 
+	size = int64(len(data))
+	m.written = sha256.Sum256(data)
+	m.queueUpload(data)
 	return nil
 }
 
+// UpdateRecord persists a record, keeping the origin of an existing entry
 func (m *Manager) UpdateRecord(hostname, domain, subdomain, ip, recordType string) error {
+	return m.UpdateRecordWithOrigin(hostname, domain, subdomain, ip, recordType, Origin{})
+}
+
+// UpdateRecordWithOrigin persists a record and tags it with the container it originates
+// from. An empty origin keeps the origin of an existing entry.
+func (m *Manager) UpdateRecordWithOrigin(hostname, domain, subdomain, ip, recordType string, origin Origin) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	existing := m.state.Records[hostname]
+	if origin.isZero() {
+		origin = existing.Origin()
+	}
+
 	record := DNSRecord{
-		Hostname:    hostname,
-		Domain:      domain,
-		Subdomain:   subdomain,
-		IP:          ip,
-		RecordType:  recordType,
-		LastUpdated: time.Now(),
+		Hostname:       hostname,
+		Domain:         domain,
+		Subdomain:      subdomain,
+		IP:             ip,
+		RecordType:     recordType,
+		LastUpdated:    time.Now(),
+		RecordID:       existing.RecordID,
+		DockerHost:     origin.DockerHost,
+		Container:      origin.Container,
+		ComposeProject: origin.ComposeProject,
+		ComposeService: origin.ComposeService,
+		Destination:    origin.Destination,
+		ExpiresAt:      existing.ExpiresAt,
+		ContainerID:    existing.ContainerID,
+		LastSeen:       existing.LastSeen,
+		Tags:           origin.Tags,
 	}
 
 	m.state.Records[hostname] = record
@@ -125,6 +363,30 @@ func (m *Manager) UpdateRecord(hostname, domain, subdomain, ip, recordType strin
 	return nil
 }
 
+// SetOwner hands an existing record over to the container described by origin,
+// keeping its address
+func (m *Manager) SetOwner(hostname string, origin Origin) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.state.Records[hostname]
+	if !ok {
+		return fmt.Errorf("no persisted record for %s", hostname)
+	}
+	record.DockerHost = origin.DockerHost
+	record.Container = origin.Container
+	record.ComposeProject = origin.ComposeProject
+	record.ComposeService = origin.ComposeService
+	record.Destination = origin.Destination
+	record.Tags = origin.Tags
+	m.state.Records[hostname] = record
+
+	if err := m.save(); err != nil {
+		return fmt.Errorf("failed to persist state: %w", err)
+	}
+	return nil
+}
+
 func (m *Manager) RemoveRecord(hostname string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -139,6 +401,117 @@ func (m *Manager) RemoveRecord(hostname string) error {
 	return nil
 }
 
+// Touch marks the given records as re-confirmed by refreshing their LastUpdated timestamp,
+// which also counts as a sighting. Unknown hostnames are ignored.
+func (m *Manager) Touch(hostnames ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	touched := 0
+	for _, hostname := range hostnames {
+		if record, exists := m.state.Records[hostname]; exists {
+			record.LastUpdated = now
+			record.LastSeen = &now
+			m.state.Records[hostname] = record
+			touched++
+		}
+	}
+
+	if touched == 0 {
+		return nil
+	}
+
+	if err := m.save(); err != nil {
+		return fmt.Errorf("failed to persist state: %w", err)
+	}
+	return nil
+}
+
+// MarkSeen records that running containers still publish the given records, without
+// touching LastUpdated, so pruning is unaffected. Unknown hostnames are ignored.
+func (m *Manager) MarkSeen(hostnames ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	seen := 0
+	for _, hostname := range hostnames {
+		if record, exists := m.state.Records[hostname]; exists {
+			record.LastSeen = &now
+			m.state.Records[hostname] = record
+			seen++
+		}
+	}
+
+	if seen == 0 {
+		return nil
+	}
+
+	if err := m.save(); err != nil {
+		return fmt.Errorf("failed to persist state: %w", err)
+	}
+	return nil
+}
+
+// NotSeenFor returns the records no running container confirmed within threshold,
+// sorted by hostname
+func (m *Manager) NotSeenFor(threshold time.Duration) []DNSRecord {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cutoff := time.Now().Add(-threshold)
+	var unseen []DNSRecord
+	for _, record := range m.state.Records {
+		if record.Seen().Before(cutoff) {
+			unseen = append(unseen, record)
+		}
+	}
+	slices.SortFunc(unseen, func(a, b DNSRecord) int { return strings.Compare(a.Hostname, b.Hostname) })
+	return unseen
+}
+
+// OlderThan returns the records whose LastUpdated is older than maxAge without removing them
+func (m *Manager) OlderThan(maxAge time.Duration) []DNSRecord {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cutoff := time.Now().Add(-maxAge)
+	var stale []DNSRecord
+	for _, record := range m.state.Records {
+		if record.LastUpdated.Before(cutoff) {
+			stale = append(stale, record)
+		}
+	}
+	return stale
+}
+
+// PruneOlderThan removes records whose LastUpdated is older than maxAge and returns them
+func (m *Manager) PruneOlderThan(maxAge time.Duration) ([]DNSRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cutoff := time.Now().Add(-maxAge)
+	var pruned []DNSRecord
+	for hostname, record := range m.state.Records {
+		if record.LastUpdated.Before(cutoff) {
+			pruned = append(pruned, record)
+			delete(m.state.Records, hostname)
+		}
+	}
+
+	if len(pruned) == 0 {
+		return nil, nil
+	}
+
+	if err := m.save(); err != nil {
+		return nil, fmt.Errorf("failed to persist state after pruning: %w", err)
+	}
+
+	log.Printf("Pruned %d stale DNS records from state", len(pruned))
+	return pruned, nil
+}
+
 func (m *Manager) GetRecord(hostname string) (DNSRecord, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -159,6 +532,89 @@ func (m *Manager) GetAllRecords() map[string]DNSRecord {
 	return records
 }
 
+// AddPending appends host changes to apply on the next start
+func (m *Manager) AddPending(hosts []PendingHost) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	m.state.Pending = append(m.state.Pending, hosts...)
+	if err := m.save(); err != nil {
+		m.state.Pending = m.state.Pending[:len(m.state.Pending)-len(hosts)]
+		return fmt.Errorf("failed to persist pending hosts: %w", err)
+	}
+
+	log.Printf("Persisted %d pending host changes", len(hosts))
+	return nil
+}
+
+// TakePending returns the pending host changes and clears them from the state
+func (m *Manager) TakePending() ([]PendingHost, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pending := m.state.Pending
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	m.state.Pending = nil
+	if err := m.save(); err != nil {
+		m.state.Pending = pending
+		return nil, fmt.Errorf("failed to persist state after taking pending hosts: %w", err)
+	}
+	return pending, nil
+}
+
+// SwapContainerHosts stores the hostnames a container publishes and returns those it
+// published before but no longer does, unless another container still publishes them
+func (m *Manager) SwapContainerHosts(container string, hostnames []string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous := m.state.Containers[container]
+	current := slices.Compact(slices.Sorted(slices.Values(hostnames)))
+	if slices.Equal(previous, current) {
+		return nil, nil
+	}
+
+	var dropped []string
+	for _, hostname := range previous {
+		if !slices.Contains(current, hostname) && !m.publishedByOther(container, hostname) {
+			dropped = append(dropped, hostname)
+		}
+	}
+
+	if len(current) == 0 {
+		delete(m.state.Containers, container)
+	} else {
+		m.state.Containers[container] = current
+	}
+	if err := m.save(); err != nil {
+		if previous == nil {
+			delete(m.state.Containers, container)
+		} else {
+			m.state.Containers[container] = previous
+		}
+		return nil, fmt.Errorf("failed to persist container hosts: %w", err)
+	}
+	return dropped, nil
+}
+
+// publishedByOther reports whether a container other than container publishes hostname.
+// The caller holds m.mu.
+func (m *Manager) publishedByOther(container, hostname string) bool {
+	for other, hostnames := range m.state.Containers {
+		if other != container && slices.Contains(hostnames, hostname) {
+			return true
+		}
+	}
+	return false
+}
+
 // ReconciliationResult represents the result of reconciliation
 type ReconciliationResult struct {
 	Hostname     string
@@ -182,6 +638,34 @@ func (m *Manager) GetRecordsForReconciliation() []DNSRecord {
 	return records
 }
 
+// GetRecordsForProject returns the records owned by the given Compose project
+func (m *Manager) GetRecordsForProject(project string) []DNSRecord {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var records []DNSRecord
+	for _, record := range m.state.Records {
+		if record.ComposeProject == project {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// GetRecordsWithTag returns the records carrying tag
+func (m *Manager) GetRecordsWithTag(tag string) []DNSRecord {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var records []DNSRecord
+	for _, record := range m.state.Records {
+		if record.HasTag(tag) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
 func (m *Manager) HasRecords() bool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
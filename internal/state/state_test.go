@@ -3,6 +3,7 @@ package state
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 )
@@ -280,3 +281,337 @@ func TestUpdateExistingRecord(t *testing.T) {
 		t.Errorf("Expected 1 record, got %d", manager.RecordCount())
 	}
 }
+
+func TestUpdateRecordWithOrigin(t *testing.T) {
+	tempDir := t.TempDir()
+	stateFile := filepath.Join(tempDir, "test_state.json")
+
+	manager, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	origin := Origin{DockerHost: "tcp://a:2376", ComposeProject: "shop", ComposeService: "web"}
+	err = manager.UpdateRecordWithOrigin("test.example.com", "example.com", "test", "192.168.1.1", "A", origin)
+	if err != nil {
+		t.Fatalf("Failed to update record: %v", err)
+	}
+
+	// Updates without an origin keep the existing one
+	err = manager.UpdateRecord("test.example.com", "example.com", "test", "192.168.1.100", "A")
+	if err != nil {
+		t.Fatalf("Failed to update record: %v", err)
+	}
+
+	reloaded, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to reload manager: %v", err)
+	}
+
+	record, exists := reloaded.GetRecord("test.example.com")
+	if !exists {
+		t.Fatal("Record should exist")
+	}
+	if record.DockerHost != "tcp://a:2376" {
+		t.Errorf("Expected Docker host 'tcp://a:2376', got '%s'", record.DockerHost)
+	}
+	if record.ComposeProject != "shop" || record.ComposeService != "web" {
+		t.Errorf("Expected Compose project/service 'shop/web', got '%s/%s'", record.ComposeProject, record.ComposeService)
+	}
+	if record.IP != "192.168.1.100" {
+		t.Errorf("Expected updated IP '192.168.1.100', got '%s'", record.IP)
+	}
+}
+
+func TestTouchAndPruneOlderThan(t *testing.T) {
+	tempDir := t.TempDir()
+	stateFile := filepath.Join(tempDir, "test_state.json")
+
+	manager, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	manager.UpdateRecord("old.example.com", "example.com", "old", "192.168.1.1", "A")
+	manager.UpdateRecord("touched.example.com", "example.com", "touched", "192.168.1.1", "A")
+	manager.UpdateRecord("new.example.com", "example.com", "new", "192.168.1.1", "A")
+
+	// Age two records, then re-confirm one of them
+	for _, hostname := range []string{"old.example.com", "touched.example.com"} {
+		record := manager.state.Records[hostname]
+		record.LastUpdated = time.Now().Add(-2 * time.Hour)
+		manager.state.Records[hostname] = record
+	}
+	if err := manager.Touch("touched.example.com", "unknown.example.com"); err != nil {
+		t.Fatalf("Touch() error = %v", err)
+	}
+	if _, exists := manager.GetRecord("unknown.example.com"); exists {
+		t.Error("Touch() must not create unknown records")
+	}
+
+	if stale := manager.OlderThan(time.Hour); len(stale) != 1 || stale[0].Hostname != "old.example.com" {
+		t.Errorf("OlderThan() = %v, want only old.example.com", stale)
+	}
+	if _, exists := manager.GetRecord("old.example.com"); !exists {
+		t.Error("OlderThan() must not remove records")
+	}
+
+	pruned, err := manager.PruneOlderThan(time.Hour)
+	if err != nil {
+		t.Fatalf("PruneOlderThan() error = %v", err)
+	}
+	if len(pruned) != 1 || pruned[0].Hostname != "old.example.com" {
+		t.Fatalf("PruneOlderThan() = %v, want only old.example.com", pruned)
+	}
+
+	reloaded, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to reload manager: %v", err)
+	}
+	if reloaded.RecordCount() != 2 {
+		t.Errorf("Expected 2 records after pruning, got %d", reloaded.RecordCount())
+	}
+	if _, exists := reloaded.GetRecord("old.example.com"); exists {
+		t.Error("Pruned record should not be persisted")
+	}
+}
+
+func TestMarkSeenAndNotSeenFor(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	manager, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	manager.UpdateRecord("legacy.example.com", "example.com", "legacy", "192.168.1.1", "A")
+	manager.UpdateRecord("gone.example.com", "example.com", "gone", "192.168.1.1", "A")
+	manager.UpdateRecord("running.example.com", "example.com", "running", "192.168.1.1", "A")
+
+	// A record from before sightings were tracked falls back to LastUpdated
+	legacy := manager.state.Records["legacy.example.com"]
+	legacy.LastUpdated = time.Now().Add(-3 * time.Hour)
+	manager.state.Records["legacy.example.com"] = legacy
+
+	longAgo := time.Now().Add(-2 * time.Hour)
+	for _, hostname := range []string{"gone.example.com", "running.example.com"} {
+		record := manager.state.Records[hostname]
+		record.LastSeen = &longAgo
+		manager.state.Records[hostname] = record
+	}
+	if err := manager.MarkSeen("running.example.com", "unknown.example.com"); err != nil {
+		t.Fatalf("MarkSeen() error = %v", err)
+	}
+	if _, exists := manager.GetRecord("unknown.example.com"); exists {
+		t.Error("MarkSeen() must not create unknown records")
+	}
+
+	unseen := manager.NotSeenFor(time.Hour)
+	if len(unseen) != 2 || unseen[0].Hostname != "gone.example.com" || unseen[1].Hostname != "legacy.example.com" {
+		t.Errorf("NotSeenFor() = %v, want gone.example.com and legacy.example.com", unseen)
+	}
+
+	// Sightings are persisted and survive record updates, but do not refresh LastUpdated
+	manager.UpdateRecord("running.example.com", "example.com", "running", "192.168.1.2", "A")
+	reloaded, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to reload manager: %v", err)
+	}
+	record, _ := reloaded.GetRecord("running.example.com")
+	if record.LastSeen == nil || time.Since(*record.LastSeen) > time.Minute {
+		t.Errorf("LastSeen = %v after reload, want the sighting", record.LastSeen)
+	}
+	if stale := reloaded.OlderThan(time.Hour); len(stale) != 1 || stale[0].Hostname != "legacy.example.com" {
+		t.Errorf("OlderThan() = %v, want only legacy.example.com", stale)
+	}
+}
+
+func TestGetRecordsForProject(t *testing.T) {
+	manager, err := NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	manager.UpdateRecordWithOrigin("shop.example.com", "example.com", "shop", "192.168.1.1", "A", Origin{ComposeProject: "shop", ComposeService: "web"})
+	manager.UpdateRecordWithOrigin("api.example.com", "example.com", "api", "192.168.1.1", "A", Origin{ComposeProject: "shop", ComposeService: "api"})
+	manager.UpdateRecordWithOrigin("blog.example.com", "example.com", "blog", "192.168.1.1", "A", Origin{ComposeProject: "blog"})
+	manager.UpdateRecord("manual.example.com", "example.com", "manual", "192.168.1.1", "A")
+
+	tests := []struct {
+		project string
+		want    int
+	}{
+		{"shop", 2},
+		{"blog", 1},
+		{"", 1},
+		{"unknown", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.project, func(t *testing.T) {
+			records := manager.GetRecordsForProject(tt.project)
+			if len(records) != tt.want {
+				t.Errorf("GetRecordsForProject(%q) returned %d records, want %d", tt.project, len(records), tt.want)
+			}
+			for _, record := range records {
+				if record.ComposeProject != tt.project {
+					t.Errorf("record %s has project %q, want %q", record.Hostname, record.ComposeProject, tt.project)
+				}
+			}
+		})
+	}
+}
+
+func TestGetRecordsWithTag(t *testing.T) {
+	manager, err := NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	manager.UpdateRecordWithOrigin("shop.example.com", "example.com", "shop", "192.168.1.1", "A", Origin{Container: "shop", Tags: []string{"prod", "web"}})
+	manager.UpdateRecordWithOrigin("blog.example.com", "example.com", "blog", "192.168.1.1", "A", Origin{Container: "blog", Tags: []string{"web"}})
+	manager.UpdateRecord("manual.example.com", "example.com", "manual", "192.168.1.1", "A")
+
+	// Updates without origin keep the tags
+	manager.UpdateRecord("shop.example.com", "example.com", "shop", "192.168.1.2", "A")
+
+	tests := []struct {
+		tag  string
+		want int
+	}{
+		{"web", 2},
+		{"PROD", 1},
+		{"staging", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			records := manager.GetRecordsWithTag(tt.tag)
+			if len(records) != tt.want {
+				t.Errorf("GetRecordsWithTag(%q) returned %d records, want %d", tt.tag, len(records), tt.want)
+			}
+		})
+	}
+}
+
+func TestAddAndTakePending(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "test_state.json")
+
+	manager, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	pending := []PendingHost{
+		{Hostname: "app.example.com", Domain: "example.com", Subdomain: "app", QueuedAt: time.Now()},
+		{Hostname: "old.example.com", Domain: "example.com", Subdomain: "old", Remove: true, ComposeProject: "shop", QueuedAt: time.Now()},
+	}
+	if err := manager.AddPending(pending[:1]); err != nil {
+		t.Fatalf("AddPending() error = %v", err)
+	}
+	if err := manager.AddPending(pending[1:]); err != nil {
+		t.Fatalf("AddPending() error = %v", err)
+	}
+
+	reloaded, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to reload manager: %v", err)
+	}
+	taken, err := reloaded.TakePending()
+	if err != nil {
+		t.Fatalf("TakePending() error = %v", err)
+	}
+	if len(taken) != 2 || taken[0].Hostname != "app.example.com" || !taken[1].Remove || taken[1].ComposeProject != "shop" {
+		t.Errorf("TakePending() = %+v, want the persisted hosts in order", taken)
+	}
+
+	// Taking clears the pending hosts on disk
+	reloaded, err = NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to reload manager: %v", err)
+	}
+	if taken, err := reloaded.TakePending(); err != nil || len(taken) != 0 {
+		t.Errorf("TakePending() after take = %v, %v, want none", taken, err)
+	}
+}
+
+func TestSwapContainerHosts(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "test_state.json")
+
+	manager, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	steps := []struct {
+		container   string
+		hostnames   []string
+		wantDropped []string
+	}{
+		{container: "web", hostnames: []string{"app.example.com", "www.example.com"}},
+		{container: "api", hostnames: []string{"api.example.com", "shared.example.com"}},
+		{container: "web", hostnames: []string{"www.example.com", "app.example.com", "app.example.com"}},
+		{container: "web", hostnames: []string{"shop.example.com", "shared.example.com"}, wantDropped: []string{"app.example.com", "www.example.com"}},
+		{container: "web", hostnames: []string{"shop.example.com"}},
+		{container: "api", hostnames: nil, wantDropped: []string{"api.example.com", "shared.example.com"}},
+	}
+
+	for i, step := range steps {
+		dropped, err := manager.SwapContainerHosts(step.container, step.hostnames)
+		if err != nil {
+			t.Fatalf("step %d: SwapContainerHosts() error = %v", i, err)
+		}
+		if !slices.Equal(dropped, step.wantDropped) {
+			t.Errorf("step %d: SwapContainerHosts() = %v, want %v", i, dropped, step.wantDropped)
+		}
+	}
+
+	reloaded, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to reload manager: %v", err)
+	}
+	dropped, err := reloaded.SwapContainerHosts("web", nil)
+	if err != nil {
+		t.Fatalf("SwapContainerHosts() after reload error = %v", err)
+	}
+	if !slices.Equal(dropped, []string{"shop.example.com"}) {
+		t.Errorf("SwapContainerHosts() after reload = %v, want [shop.example.com]", dropped)
+	}
+}
+
+func TestOwnerAndSetOwner(t *testing.T) {
+	tests := []struct {
+		name   string
+		origin Origin
+		want   string
+	}{
+		{name: "unknown", origin: Origin{}, want: ""},
+		{name: "container", origin: Origin{DockerHost: "local", Container: "app"}, want: "local/app"},
+		{name: "compose service", origin: Origin{DockerHost: "local", Container: "shop-web-2", ComposeProject: "shop", ComposeService: "web"}, want: "local/shop/web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.origin.Owner(); got != tt.want {
+				t.Errorf("Owner() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	m, err := NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if err := m.SetOwner("app.example.com", Origin{Container: "b"}); err == nil {
+		t.Error("SetOwner() of unknown record error = nil, want error")
+	}
+	if err := m.UpdateRecordWithOrigin("app.example.com", "example.com", "app", "1.2.3.4", "A", Origin{Container: "a"}); err != nil {
+		t.Fatalf("UpdateRecordWithOrigin() error = %v", err)
+	}
+	if err := m.SetOwner("app.example.com", Origin{Container: "b"}); err != nil {
+		t.Fatalf("SetOwner() error = %v", err)
+	}
+
+	record, _ := m.GetRecord("app.example.com")
+	if record.Container != "b" || record.IP != "1.2.3.4" {
+		t.Errorf("record = %+v, want owner b and unchanged IP", record)
+	}
+}
@@ -0,0 +1,137 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"time"
+)
+
+// storeTimeout limits a single transfer of the state file to or from the remote store
+const storeTimeout = 30 * time.Second
+
+// Store keeps a remote copy of the state file, e.g. an object in S3-compatible storage,
+// so a companion without a persistent volume recovers its records after a restart
+type Store interface {
+	// Get returns the stored state file, or an error wrapping fs.ErrNotExist if there
+	// is none yet
+	Get(ctx context.Context) ([]byte, error)
+	// Put replaces the stored state file
+	Put(ctx context.Context, data []byte) error
+	String() string
+}
+
+// ManagerOptions holds optional settings for a state manager
+type ManagerOptions struct {
+	Store Store // Remote copy of the state file, restored when the local file is missing
+}
+
+// restore fetches the remote copy of the state file when there is no local one. Failing
+// to reach the store is an error, so a fresh state never replaces the remote copy.
+func (m *Manager) restore() error {
+	if _, err := os.Stat(m.filePath); !errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	data, err := m.store.Get(ctx)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("No state file in %s yet, starting fresh", m.store)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore state file from %s: %w", m.store, err)
+	}
+
+	tempFile := m.filePath + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write restored state file: %w", err)
+	}
+	if err := os.Rename(tempFile, m.filePath); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to write restored state file: %w", err)
+	}
+	log.Printf("Restored state file from %s", m.store)
+	return nil
+}
+
+// queueUpload schedules an upload of the saved state file. Saves during an upload
+// coalesce, only the latest state is uploaded next. The caller holds m.mu.
+func (m *Manager) queueUpload(data []byte) {
+	if m.store == nil {
+		return
+	}
+
+	m.uploadMu.Lock()
+	defer m.uploadMu.Unlock()
+	m.pendingUpload = data
+	if !m.uploading {
+		m.uploading = true
+		go m.runUploads()
+	}
+}
+
+// runUploads uploads pending state until none is left. A failed upload stays pending
+// and is retried with the next save or Flush.
+func (m *Manager) runUploads() {
+	for {
+		m.uploadMu.Lock()
+		if m.pendingUpload == nil {
+			m.uploading = false
+			m.uploadMu.Unlock()
+			return
+		}
+		m.uploadMu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		err := m.upload(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: Failed to upload state file to %s: %v", m.store, err)
+			m.uploadMu.Lock()
+			m.uploading = false
+			m.uploadMu.Unlock()
+			return
+		}
+	}
+}
+
+// upload puts the pending state into the store, keeping it pending on failure
+func (m *Manager) upload(ctx context.Context) error {
+	m.uploadRunMu.Lock()
+	defer m.uploadRunMu.Unlock()
+
+	m.uploadMu.Lock()
+	data := m.pendingUpload
+	m.pendingUpload = nil
+	m.uploadMu.Unlock()
+	if data == nil {
+		return nil
+	}
+
+	if err := m.store.Put(ctx, data); err != nil {
+		m.uploadMu.Lock()
+		if m.pendingUpload == nil {
+			m.pendingUpload = data
+		}
+		m.uploadMu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Flush uploads state not yet in the remote store, e.g. before shutdown. It waits for
+// an upload in progress.
+func (m *Manager) Flush(ctx context.Context) error {
+	if m.store == nil {
+		return nil
+	}
+	if err := m.upload(ctx); err != nil {
+		return fmt.Errorf("failed to upload state file to %s: %w", m.store, err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// memoryStore keeps the state file in memory
+type memoryStore struct {
+	mu     sync.Mutex
+	data   []byte
+	getErr error
+	putErr error
+	puts   int
+}
+
+func (s *memoryStore) Get(ctx context.Context) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.getErr != nil {
+		return nil, s.getErr
+	}
+	if s.data == nil {
+		return nil, fs.ErrNotExist
+	}
+	return s.data, nil
+}
+
+func (s *memoryStore) Put(ctx context.Context, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.puts++
+	if s.putErr != nil {
+		return s.putErr
+	}
+	s.data = data
+	return nil
+}
+
+func (s *memoryStore) String() string { return "memory" }
+
+func TestManagerStore(t *testing.T) {
+	store := &memoryStore{}
+	manager, err := NewManagerWithOptions(filepath.Join(t.TempDir(), "state.json"), &ManagerOptions{Store: store})
+	if err != nil {
+		t.Fatalf("NewManagerWithOptions() error = %v", err)
+	}
+	if err := manager.UpdateRecord("app.example.com", "example.com", "app", "192.0.2.1", "A"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	// A companion without the local file restores it from the store
+	restored, err := NewManagerWithOptions(filepath.Join(t.TempDir(), "state.json"), &ManagerOptions{Store: store})
+	if err != nil {
+		t.Fatalf("NewManagerWithOptions() error = %v", err)
+	}
+	if _, ok := restored.GetRecord("app.example.com"); !ok {
+		t.Error("Record app.example.com was not restored from the store")
+	}
+
+	// An unreachable store must not lead to a fresh state overwriting the remote copy
+	store.getErr = errors.New("connection refused")
+	if _, err := NewManagerWithOptions(filepath.Join(t.TempDir(), "state.json"), &ManagerOptions{Store: store}); err == nil {
+		t.Error("NewManagerWithOptions() succeeded with an unreachable store")
+	}
+}
+
+func TestManagerStore_FailedUpload(t *testing.T) {
+	store := &memoryStore{putErr: fmt.Errorf("access denied")}
+	manager, err := NewManagerWithOptions(filepath.Join(t.TempDir(), "state.json"), &ManagerOptions{Store: store})
+	if err != nil {
+		t.Fatalf("NewManagerWithOptions() error = %v", err)
+	}
+	if err := manager.UpdateRecord("app.example.com", "example.com", "app", "192.0.2.1", "A"); err != nil {
+		t.Fatalf("UpdateRecord() error = %v, want the local save to succeed", err)
+	}
+	if err := manager.Flush(context.Background()); err == nil {
+		t.Fatal("Flush() succeeded while the store rejects uploads")
+	}
+
+	// The state stays pending until an upload succeeds
+	store.mu.Lock()
+	store.putErr = nil
+	store.mu.Unlock()
+	if err := manager.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if data, err := store.Get(context.Background()); err != nil || len(data) == 0 {
+		t.Errorf("store.Get() = %q, %v, want the state file", data, err)
+	}
+}
@@ -0,0 +1,168 @@
+package state
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"time"
+)
+
+// ChangePolicy decides what happens when another process modifies the state file
+type ChangePolicy int
+
+const (
+	// ChangeReload replaces the state in memory with the modified file, the last writer wins
+	ChangeReload ChangePolicy = iota
+	// ChangeOverwrite writes the state in memory over the modified file
+	ChangeOverwrite
+	// ChangeWarn only reports the modification, the next save overwrites it
+	ChangeWarn
+)
+
+const (
+	// watchSettleDelay is how long the watcher waits after a modification before reading
+	// the file, since editors often write it in several steps
+	watchSettleDelay = 500 * time.Millisecond
+
+	// watchPollInterval is how often the file is checked where it cannot be watched
+	watchPollInterval = 5 * time.Second
+)
+
+// errWatchUnsupported is returned by watchFile on platforms without inotify
+var errWatchUnsupported = errors.New("file monitoring is only supported on Linux")
+
+// OnExternalChange registers a callback invoked after another process modified the
+// state file. conflict is set when the modification was discarded or could not be loaded.
+func (m *Manager) OnExternalChange(fn func(message string, conflict bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onExternalChange = fn
+}
+
+// WatchFile watches the state file for modifications by other processes, e.g. an editor
+// or the tag commands, and handles them according to policy until ctx is done. On Linux
+// inotify reports modifications right away, elsewhere the file is polled.
+func (m *Manager) WatchFile(ctx context.Context, policy ChangePolicy) {
+	changes := make(chan struct{}, 1)
+	go func() {
+		err := watchFile(ctx, m.filePath, changes)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		log.Printf("Warning: State file monitoring stopped, falling back to polling: %v", err)
+		pollFile(ctx, m.filePath, watchPollInterval, changes)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changes:
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchSettleDelay):
+		}
+		// Modifications signalled while settling are covered by this check
+		select {
+		case <-changes:
+		default:
+		}
+		m.checkFile(policy)
+	}
+}
+
+// checkFile compares the state file with the content last loaded or saved and handles
+// a modification according to policy
+func (m *Manager) checkFile(policy ChangePolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, err := os.ReadFile(m.filePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Warning: Failed to read state file to check for modifications: %v", err)
+		return
+	}
+	if err == nil && sha256.Sum256(data) == m.written {
+		return
+	}
+
+	var message string
+	var conflict bool
+	switch {
+	case err != nil && policy == ChangeWarn:
+		message, conflict = fmt.Sprintf("State file %s was deleted by another process, it is written again with the next change", m.filePath), true
+		m.written = [sha256.Size]byte{}
+	case err != nil:
+		if err := m.save(); err != nil {
+			message, conflict = fmt.Sprintf("State file %s was deleted by another process and could not be written again: %v", m.filePath, err), true
+			break
+		}
+		message, conflict = fmt.Sprintf("State file %s was deleted by another process, wrote it again", m.filePath), true
+	case policy == ChangeReload:
+		if err := m.load(); err != nil {
+			message, conflict = fmt.Sprintf("State file %s was modified by another process but could not be reloaded, keeping the state in memory: %v", m.filePath, err), true
+			break
+		}
+		message = fmt.Sprintf("State file %s was modified by another process, reloaded %d records", m.filePath, len(m.state.Records))
+	case policy == ChangeOverwrite:
+		if err := m.save(); err != nil {
+			message, conflict = fmt.Sprintf("State file %s was modified by another process and could not be overwritten: %v", m.filePath, err), true
+			break
+		}
+		message, conflict = fmt.Sprintf("State file %s was modified by another process, overwrote it with the state in memory", m.filePath), true
+	default:
+		message, conflict = fmt.Sprintf("State file %s was modified by another process, its changes are lost with the next save", m.filePath), true
+		m.written = sha256.Sum256(data)
+	}
+
+	if conflict {
+		log.Printf("Warning: %s", message)
+	} else {
+		log.Println(message)
+	}
+	if m.onExternalChange != nil {
+		m.onExternalChange(message, conflict)
+	}
+}
+
+// pollFile signals changes whenever the modification time or size of the file at path
+// changes, checking every interval until ctx is done
+func pollFile(ctx context.Context, path string, interval time.Duration, changes chan<- struct{}) {
+	stat := func() (time.Time, int64) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, -1
+		}
+		return info.ModTime(), info.Size()
+	}
+
+	modTime, size := stat()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if t, s := stat(); !t.Equal(modTime) || s != size {
+			modTime, size = t, s
+			signal(changes)
+		}
+	}
+}
+
+// signal notifies changes without blocking; a pending signal already covers this one
+func signal(changes chan<- struct{}) {
+	select {
+	case changes <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,67 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// watchFile watches the directory of the file at path with inotify and signals on
+// changes whenever the file is written, replaced or deleted. The directory is watched
+// rather than the file, as saves replace the file by renaming a temporary one over it.
+func watchFile(ctx context.Context, path string, changes chan<- struct{}) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+	// The runtime poller serves the non-blocking descriptor, so closing it on
+	// cancellation ends a pending read
+	file := os.NewFile(uintptr(fd), "inotify")
+	defer file.Close()
+	stop := context.AfterFunc(ctx, func() { file.Close() })
+	defer stop()
+
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	mask := uint32(syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_DELETE | syscall.IN_MOVED_FROM)
+	if _, err := syscall.InotifyAddWatch(fd, dir, mask); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := file.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to read inotify event: %w", err)
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			// A queue overflow may have dropped events of the file
+			if event.Mask&syscall.IN_Q_OVERFLOW != 0 || trimNull(nameBytes) == name {
+				signal(changes)
+			}
+		}
+	}
+}
+
+// trimNull returns the name of an inotify event, which is padded with null bytes
+func trimNull(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
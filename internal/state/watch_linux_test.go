@@ -0,0 +1,52 @@
+package state
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() { done <- watchFile(ctx, path, changes) }()
+	time.Sleep(50 * time.Millisecond)
+
+	// Other files of the directory are ignored
+	if err := os.WriteFile(filepath.Join(dir, "other.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+		t.Fatal("modification of another file signalled")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Replacing the file by a rename is signalled
+	if err := os.WriteFile(path+".tmp", []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("replaced file not signalled")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("watchFile() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("watchFile() did not stop on cancellation")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package state
+
+import "context"
+
+// watchFile is not available without inotify, the watcher polls the file instead
+func watchFile(ctx context.Context, path string, changes chan<- struct{}) error {
+	return errWatchUnsupported
+}
@@ -0,0 +1,159 @@
+package state
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// editStateFile rewrites the state file as another process would, with the record of
+// app.example.com pointing at ip
+func editStateFile(t *testing.T, path, ip string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(data), `"ip": "203.0.113.1"`, `"ip": "`+ip+`"`, 1)
+	if err := os.WriteFile(path, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       ChangePolicy
+		wantIP       string // IP of the record in memory after the check
+		wantFileIP   string // IP of the record in the state file after the check
+		wantConflict bool
+	}{
+		{name: "reload", policy: ChangeReload, wantIP: "203.0.113.9", wantFileIP: "203.0.113.9"},
+		{name: "overwrite", policy: ChangeOverwrite, wantIP: "203.0.113.1", wantFileIP: "203.0.113.1", wantConflict: true},
+		{name: "warn", policy: ChangeWarn, wantIP: "203.0.113.1", wantFileIP: "203.0.113.9", wantConflict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stateFile := filepath.Join(t.TempDir(), "state.json")
+			manager, err := NewManager(stateFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := manager.UpdateRecord("app.example.com", "example.com", "app", "203.0.113.1", "A"); err != nil {
+				t.Fatal(err)
+			}
+			var notified []string
+			var conflict bool
+			manager.OnExternalChange(func(message string, c bool) {
+				notified = append(notified, message)
+				conflict = c
+			})
+
+			// The companion's own saves are no modification
+			manager.checkFile(tt.policy)
+			if len(notified) != 0 {
+				t.Fatalf("own save reported as modification: %v", notified)
+			}
+
+			editStateFile(t, stateFile, "203.0.113.9")
+			manager.checkFile(tt.policy)
+			if len(notified) != 1 || conflict != tt.wantConflict {
+				t.Fatalf("notified = %v (conflict %v), want one notification (conflict %v)", notified, conflict, tt.wantConflict)
+			}
+			if record, _ := manager.GetRecord("app.example.com"); record.IP != tt.wantIP {
+				t.Errorf("record in memory = %s, want %s", record.IP, tt.wantIP)
+			}
+			reloaded, err := NewManager(stateFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if record, _ := reloaded.GetRecord("app.example.com"); record.IP != tt.wantFileIP {
+				t.Errorf("record in file = %s, want %s", record.IP, tt.wantFileIP)
+			}
+
+			// A handled modification is reported once
+			manager.checkFile(tt.policy)
+			if len(notified) != 1 {
+				t.Errorf("notified = %v, want the modification reported once", notified)
+			}
+		})
+	}
+}
+
+func TestCheckFile_Deleted(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	manager, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.UpdateRecord("app.example.com", "example.com", "app", "203.0.113.1", "A"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(stateFile); err != nil {
+		t.Fatal(err)
+	}
+
+	manager.checkFile(ChangeReload)
+	reloaded, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reloaded.GetRecord("app.example.com"); !ok {
+		t.Error("deleted state file not written again")
+	}
+}
+
+func TestCheckFile_InvalidFile(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	manager, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.UpdateRecord("app.example.com", "example.com", "app", "203.0.113.1", "A"); err != nil {
+		t.Fatal(err)
+	}
+	var conflict bool
+	manager.OnExternalChange(func(message string, c bool) { conflict = c })
+
+	if err := os.WriteFile(stateFile, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manager.checkFile(ChangeReload)
+	if !conflict {
+		t.Error("invalid state file not reported as conflict")
+	}
+	if _, ok := manager.GetRecord("app.example.com"); !ok {
+		t.Error("state in memory replaced by an invalid file")
+	}
+}
+
+func TestPollFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan struct{}, 1)
+	go pollFile(ctx, path, 10*time.Millisecond, changes)
+
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-changes:
+		t.Fatal("unmodified file signalled")
+	default:
+	}
+
+	if err := os.WriteFile(path, []byte(`{"version": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("modification not signalled")
+	}
+}
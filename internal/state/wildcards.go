@@ -0,0 +1,89 @@
+package state
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Wildcard is a wildcard record the companion consolidated the records of a domain into
+type Wildcard struct {
+	Domain    string    `json:"domain"`
+	IP        string    `json:"ip"`
+	RecordID  string    `json:"record_id,omitempty"` // Id of the wildcard record at Netcup, once known
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Consolidate records the wildcard of a domain and marks the records of the given
+// hostnames as served by it, at its address
+func (m *Manager) Consolidate(wildcard Wildcard, hostnames []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous := maps.Clone(m.state.Records)
+	previousWildcard, existed := m.state.Wildcards[wildcard.Domain]
+	m.state.Wildcards[wildcard.Domain] = wildcard
+	for _, hostname := range hostnames {
+		if record, ok := m.state.Records[hostname]; ok {
+			record.Consolidated = true
+			record.IP = wildcard.IP
+			m.state.Records[hostname] = record
+		}
+	}
+
+	if err := m.save(); err != nil {
+		m.state.Records = previous
+		if existed {
+			m.state.Wildcards[wildcard.Domain] = previousWildcard
+		} else {
+			delete(m.state.Wildcards, wildcard.Domain)
+		}
+		return fmt.Errorf("failed to persist wildcard of %s: %w", wildcard.Domain, err)
+	}
+	return nil
+}
+
+// Deconsolidate forgets the wildcard of a domain, its records get records of their own
+// again
+func (m *Manager) Deconsolidate(domain string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous := maps.Clone(m.state.Records)
+	previousWildcard := m.state.Wildcards[domain]
+	delete(m.state.Wildcards, domain)
+	for hostname, record := range m.state.Records {
+		if record.Domain == domain && record.Consolidated {
+			record.Consolidated = false
+			m.state.Records[hostname] = record
+		}
+	}
+
+	if err := m.save(); err != nil {
+		m.state.Records = previous
+		m.state.Wildcards[domain] = previousWildcard
+		return fmt.Errorf("failed to persist wildcard of %s: %w", domain, err)
+	}
+	return nil
+}
+
+// Wildcard returns the wildcard the records of a domain are consolidated into
+func (m *Manager) Wildcard(domain string) (Wildcard, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	wildcard, ok := m.state.Wildcards[domain]
+	return wildcard, ok
+}
+
+// Wildcards returns the wildcards of all consolidated domains, ordered by domain
+func (m *Manager) Wildcards() []Wildcard {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	wildcards := slices.Collect(maps.Values(m.state.Wildcards))
+	slices.SortFunc(wildcards, func(a, b Wildcard) int { return strings.Compare(a.Domain, b.Domain) })
+	return wildcards
+}
@@ -0,0 +1,56 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConsolidate(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	manager, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, hostname := range []string{"a.example.com", "b.example.com"} {
+		if err := manager.UpdateRecord(hostname, "example.com", hostname[:1], "203.0.113.1", "A"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := manager.UpdateRecord("c.example.org", "example.org", "c", "203.0.113.1", "A"); err != nil {
+		t.Fatal(err)
+	}
+
+	wildcard := Wildcard{Domain: "example.com", IP: "203.0.113.1", RecordID: "101", CreatedAt: time.Now()}
+	if err := manager.Consolidate(wildcard, []string{"a.example.com", "b.example.com"}); err != nil {
+		t.Fatalf("Consolidate() error = %v", err)
+	}
+
+	// The wildcard survives a restart
+	reloaded, err := NewManager(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := reloaded.Wildcard("example.com"); !ok || got.RecordID != "101" {
+		t.Errorf("Wildcard() = %+v, %v, want record 101", got, ok)
+	}
+	if record, _ := reloaded.GetRecord("a.example.com"); !record.Consolidated {
+		t.Error("record of a.example.com not consolidated")
+	}
+	if record, _ := reloaded.GetRecord("c.example.org"); record.Consolidated {
+		t.Error("record of another domain consolidated")
+	}
+	if wildcards := reloaded.Wildcards(); len(wildcards) != 1 {
+		t.Errorf("Wildcards() = %v, want one", wildcards)
+	}
+
+	if err := reloaded.Deconsolidate("example.com"); err != nil {
+		t.Fatalf("Deconsolidate() error = %v", err)
+	}
+	if _, ok := reloaded.Wildcard("example.com"); ok {
+		t.Error("Wildcard() still present after Deconsolidate()")
+	}
+	if record, _ := reloaded.GetRecord("b.example.com"); record.Consolidated {
+		t.Error("record of b.example.com still consolidated")
+	}
+}
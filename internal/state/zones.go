@@ -0,0 +1,41 @@
+package state
+
+import (
+	"fmt"
+	"time"
+)
+
+// ZoneStatus is the last known status of a DNS zone
+type ZoneStatus struct {
+	DNSSEC    bool      `json:"dnssec"`     // Zone is DNSSEC-signed
+	ChangedAt time.Time `json:"changed_at"` // When the status was first seen or last changed
+}
+
+// SetZoneDNSSEC records whether a zone is DNSSEC-signed. The state file is only written
+// when the status of the zone is new or changed.
+func (m *Manager) SetZoneDNSSEC(domain string, signed bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if status, ok := m.state.Zones[domain]; ok && status.DNSSEC == signed {
+		return nil
+	}
+	m.state.Zones[domain] = ZoneStatus{DNSSEC: signed, ChangedAt: time.Now()}
+
+	if err := m.save(); err != nil {
+		return fmt.Errorf("failed to persist state: %w", err)
+	}
+	return nil
+}
+
+// Zones returns the last known status of all zones, keyed by domain
+func (m *Manager) Zones() map[string]ZoneStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	zones := make(map[string]ZoneStatus, len(m.state.Zones))
+	for domain, status := range m.state.Zones {
+		zones[domain] = status
+	}
+	return zones
+}
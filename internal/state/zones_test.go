@@ -0,0 +1,42 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSetZoneDNSSEC(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	m, err := NewManager(path)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	if err := m.SetZoneDNSSEC("example.com", true); err != nil {
+		t.Fatalf("SetZoneDNSSEC() error = %v", err)
+	}
+	first := m.Zones()["example.com"]
+	if !first.DNSSEC || first.ChangedAt.IsZero() {
+		t.Fatalf("Zones()[example.com] = %+v, want DNSSEC-signed with a change time", first)
+	}
+
+	// An unchanged status keeps the time of the last change
+	if err := m.SetZoneDNSSEC("example.com", true); err != nil {
+		t.Fatalf("SetZoneDNSSEC() error = %v", err)
+	}
+	if got := m.Zones()["example.com"]; !got.ChangedAt.Equal(first.ChangedAt) {
+		t.Errorf("ChangedAt = %v, want %v", got.ChangedAt, first.ChangedAt)
+	}
+
+	if err := m.SetZoneDNSSEC("example.com", false); err != nil {
+		t.Fatalf("SetZoneDNSSEC() error = %v", err)
+	}
+
+	reloaded, err := NewManager(path)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if status, ok := reloaded.Zones()["example.com"]; !ok || status.DNSSEC {
+		t.Errorf("Zones()[example.com] after reload = %+v, want unsigned", status)
+	}
+}
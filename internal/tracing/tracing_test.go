@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEnabled(t *testing.T) {
+	tests := []struct {
+		name           string
+		endpoint       string
+		tracesEndpoint string
+		disabled       string
+		want           bool
+	}{
+		{name: "no endpoint", want: false},
+		{name: "endpoint", endpoint: "http://collector:4318", want: true},
+		{name: "traces endpoint", tracesEndpoint: "http://collector:4318/v1/traces", want: true},
+		{name: "sdk disabled", endpoint: "http://collector:4318", disabled: "true", want: false},
+		{name: "sdk disabled uppercase", endpoint: "http://collector:4318", disabled: "TRUE", want: false},
+		{name: "sdk not disabled", endpoint: "http://collector:4318", disabled: "false", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", tt.endpoint)
+			t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", tt.tracesEndpoint)
+			t.Setenv("OTEL_SDK_DISABLED", tt.disabled)
+
+			if got := Enabled(); got != tt.want {
+				t.Errorf("Enabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetup_Disabled(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	shutdown, err := Setup(context.Background())
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+// Package update checks the GitHub releases of the companion for newer versions
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultURL is the GitHub API endpoint of the latest release
+const DefaultURL = "https://api.github.com/repos/alex289/docker-traefik-netcup-companion/releases/latest"
+
+// CheckInterval is how often the latest release is checked
+const CheckInterval = 24 * time.Hour
+
+// maxHighlights limits the changelog entries included in a notification
+const maxHighlights = 5
+
+// commitLink matches the commit reference release-please appends to changelog entries,
+// e.g. " ([d338cd8](https://github.com/.../commit/d338cd8...))"
+var commitLink = regexp.MustCompile(`\s*\(\[[0-9a-f]{7,}\]\([^)]*\)\)$`)
+
+// Release is a published release
+type Release struct {
+	Version string `json:"tag_name"` // e.g. v1.6.0
+	URL     string `json:"html_url"`
+	Notes   string `json:"body"` // Changelog in Markdown
+}
+
+// Highlights returns the first entries of the changelog, without commit references
+func (r Release) Highlights() []string {
+	var highlights []string
+	for _, line := range strings.Split(r.Notes, "\n") {
+		entry, ok := strings.CutPrefix(strings.TrimSpace(line), "* ")
+		if !ok {
+			continue
+		}
+		highlights = append(highlights, commitLink.ReplaceAllString(entry, ""))
+		if len(highlights) == maxHighlights {
+			break
+		}
+	}
+	return highlights
+}
+
+// Checker looks up the latest release
+type Checker struct {
+	URL    string // Latest release endpoint, DefaultURL if empty
+	Client *http.Client
+}
+
+// Latest fetches the latest release
+func (c *Checker) Latest(ctx context.Context) (Release, error) {
+	url := c.URL
+	if url == "" {
+		url = DefaultURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Release{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Release{}, fmt.Errorf("failed to fetch the latest release: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return Release{}, fmt.Errorf("failed to fetch the latest release: %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	var release Release
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&release); err != nil {
+		return Release{}, fmt.Errorf("failed to parse the latest release: %w", err)
+	}
+	return release, nil
+}
+
+// Newer reports whether version is newer than current. Versions are compared as
+// major.minor.patch with an optional leading "v"; a version that does not parse, like
+// the "dev" of local builds, is never newer nor older.
+func Newer(version, current string) bool {
+	v, ok := parse(version)
+	if !ok {
+		return false
+	}
+	c, ok := parse(current)
+	if !ok {
+		return false
+	}
+	for i := range v {
+		if v[i] != c[i] {
+			return v[i] > c[i]
+		}
+	}
+	return false
+}
+
+// IsRelease reports whether version is a release version, unlike the "dev" of local builds
+func IsRelease(version string) bool {
+	_, ok := parse(version)
+	return ok
+}
+
+// parse splits a version into major, minor and patch, ignoring pre-release and build
+// suffixes
+func parse(version string) ([3]int, bool) {
+	var parts [3]int
+	version = strings.TrimPrefix(version, "v")
+	version, _, _ = strings.Cut(version, "-")
+	version, _, _ = strings.Cut(version, "+")
+	fields := strings.Split(version, ".")
+	if len(fields) != 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
@@ -0,0 +1,86 @@
+package update
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestNewer(t *testing.T) {
+	testCases := []struct {
+		version, current string
+		want             bool
+	}{
+		{"v1.7.0", "v1.6.0", true},
+		{"v1.6.1", "1.6.0", true},
+		{"v2.0.0", "v1.10.3", true},
+		{"v1.6.0", "v1.6.0", false},
+		{"v1.5.9", "v1.6.0", false},
+		{"v1.10.0", "v1.9.0", true},
+		{"v1.7.0", "dev", false},
+		{"nightly", "v1.6.0", false},
+		{"v1.7.0-rc.1", "v1.6.0", true},
+	}
+
+	for _, tc := range testCases {
+		if got := Newer(tc.version, tc.current); got != tc.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tc.version, tc.current, got, tc.want)
+		}
+	}
+}
+
+func TestIsRelease(t *testing.T) {
+	if !IsRelease("v1.6.0") || !IsRelease("1.6.0") {
+		t.Error("IsRelease() = false for a release version")
+	}
+	if IsRelease("dev") || IsRelease("v1.6") {
+		t.Error("IsRelease() = true for a version that is not a release")
+	}
+}
+
+func TestHighlights(t *testing.T) {
+	release := Release{Notes: `## [1.6.0](https://github.com/alex289/docker-traefik-netcup-companion/compare/v1.5.0...v1.6.0) (2026-01-02)
+
+
+### Features
+
+* Add persistent state storage ([3241d58](https://github.com/alex289/docker-traefik-netcup-companion/commit/3241d58c95aa775bdf7611894ee4b2c7d9f85e16))
+* **dns:** Improve resilience ([2b809b7](https://github.com/alex289/docker-traefik-netcup-companion/commit/2b809b7b25d648f86a9809b6fb0ac5132c6fe066))
+
+
+### Bug Fixes
+
+* Tidy go mod ([84100f3](https://github.com/alex289/docker-traefik-netcup-companion/commit/84100f3f2e85171c9ac63ba157654d6fc5062753))
+`}
+
+	want := []string{"Add persistent state storage", "**dns:** Improve resilience", "Tidy go mod"}
+	if got := release.Highlights(); !slices.Equal(got, want) {
+		t.Errorf("Highlights() = %q, want %q", got, want)
+	}
+}
+
+func TestLatest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name":"v1.7.0","html_url":"https://github.com/alex289/docker-traefik-netcup-companion/releases/tag/v1.7.0","body":"* Add update checks"}`))
+	}))
+	defer server.Close()
+
+	checker := &Checker{URL: server.URL}
+	release, err := checker.Latest(context.Background())
+	if err != nil {
+		t.Fatalf("Latest() error = %v", err)
+	}
+	if release.Version != "v1.7.0" || release.URL == "" || len(release.Highlights()) != 1 {
+		t.Errorf("Latest() = %+v, want v1.7.0 with one highlight", release)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusForbidden)
+	}))
+	defer failing.Close()
+	if _, err := (&Checker{URL: failing.URL}).Latest(context.Background()); err == nil {
+		t.Error("Latest() error = nil for a failed request, want error")
+	}
+}
@@ -0,0 +1,349 @@
+// Package zonefile converts between Netcup DNS records and RFC 1035 zone files, for
+// backups and for moving domains into or out of Netcup.
+package zonefile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+// maxTXTString is the longest character-string of a TXT record (RFC 1035 3.3)
+const maxTXTString = 255
+
+// Zone is the content of a zone file for a single domain
+type Zone struct {
+	Domain  string             // Zone apex without trailing dot, e.g. example.com
+	TTL     string             // Zone TTL in seconds ($TTL); Netcup has no per-record TTLs
+	Records []netcup.DnsRecord // Records with Netcup host names, "@" for the apex
+}
+
+// Write renders zone as a zone file. Netcup manages the SOA and apex NS records itself,
+// so the SOA timers of soa, if given, are only written as a comment.
+func Write(w io.Writer, zone *Zone, soa *netcup.DnsZoneData) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "$ORIGIN %s.\n", zone.Domain)
+	if zone.TTL != "" {
+		fmt.Fprintf(bw, "$TTL %s\n", zone.TTL)
+	}
+	if soa != nil {
+		fmt.Fprintf(bw, "; SOA managed by Netcup: serial %s, refresh %s, retry %s, expire %s\n", soa.Serial, soa.Refresh, soa.Retry, soa.Expire)
+	}
+
+	for _, record := range zone.Records {
+		fmt.Fprintf(bw, "%s\tIN\t%s\t%s\n", record.Hostname, record.Type, rdata(record))
+	}
+	return bw.Flush()
+}
+
+// rdata returns the record data of a record in zone file presentation format
+func rdata(record netcup.DnsRecord) string {
+	switch record.Type {
+	case "MX", "SRV":
+		priority := record.Priority
+		if priority == "" {
+			priority = "0"
+		}
+		return priority + " " + record.Destination
+	case "TXT":
+		return quoteTXT(record.Destination)
+	default:
+		return record.Destination
+	}
+}
+
+// quoteTXT quotes a TXT value, split into character-strings of at most 255 bytes
+func quoteTXT(value string) string {
+	var parts []string
+	for {
+		chunk := value
+		if len(chunk) > maxTXTString {
+			chunk = chunk[:maxTXTString]
+		}
+		escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(chunk)
+		parts = append(parts, `"`+escaped+`"`)
+		value = value[len(chunk):]
+		if value == "" {
+			return strings.Join(parts, " ")
+		}
+	}
+}
+
+// token is a field of a zone file line. The raw form is kept for record types whose
+// data Netcup stores verbatim, e.g. CAA.
+type token struct {
+	text string // Value with quotes and escapes removed
+	raw  string // Value as written
+}
+
+// Parse reads a zone file for domain. Relative names are resolved against $ORIGIN, which
+// defaults to domain; names outside domain are an error. SOA and apex NS records are
+// skipped, as Netcup manages them, and per-record TTLs are ignored.
+func Parse(r io.Reader, domain string) (*Zone, error) {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	zone := &Zone{Domain: domain}
+	origin := domain
+	var owner string
+
+	lines, err := logicalLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		tokens, err := tokenize(line.text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.number, err)
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(tokens[0].text) {
+		case "$ORIGIN":
+			if len(tokens) != 2 {
+				return nil, fmt.Errorf("line %d: $ORIGIN takes one name", line.number)
+			}
+			origin = absolute(tokens[1].text, origin)
+			continue
+		case "$TTL":
+			if len(tokens) != 2 {
+				return nil, fmt.Errorf("line %d: $TTL takes one value", line.number)
+			}
+			if _, err := strconv.Atoi(tokens[1].text); err != nil {
+				return nil, fmt.Errorf("line %d: invalid $TTL %q", line.number, tokens[1].text)
+			}
+			zone.TTL = tokens[1].text
+			continue
+		case "$INCLUDE", "$GENERATE":
+			return nil, fmt.Errorf("line %d: %s is not supported", line.number, tokens[0].text)
+		}
+
+		// A line starting with whitespace belongs to the previous owner
+		if !line.continued {
+			owner = absolute(tokens[0].text, origin)
+			tokens = tokens[1:]
+		} else if owner == "" {
+			return nil, fmt.Errorf("line %d: record without owner name", line.number)
+		}
+
+		recordType, data, err := splitRecord(tokens)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.number, err)
+		}
+
+		hostname, err := relative(owner, domain)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.number, err)
+		}
+		if recordType == "SOA" || recordType == "NS" && hostname == "@" {
+			continue
+		}
+
+		record, err := newRecord(hostname, recordType, data)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.number, err)
+		}
+		zone.Records = append(zone.Records, record)
+	}
+
+	return zone, nil
+}
+
+// splitRecord skips the optional TTL and class in front of the type, in either order
+func splitRecord(tokens []token) (recordType string, data []token, err error) {
+	for i := 0; i < len(tokens) && i < 3; i++ {
+		field := strings.ToUpper(tokens[i].text)
+		if _, err := strconv.Atoi(field); err == nil || field == "IN" {
+			continue
+		}
+		if i+1 >= len(tokens) {
+			return "", nil, fmt.Errorf("%s record without data", field)
+		}
+		return field, tokens[i+1:], nil
+	}
+	return "", nil, fmt.Errorf("missing record type")
+}
+
+// newRecord converts the data of a record to Netcup's model, where MX and SRV keep their
+// priority in a separate field
+func newRecord(hostname, recordType string, data []token) (netcup.DnsRecord, error) {
+	record := netcup.DnsRecord{Hostname: hostname, Type: recordType, Priority: "0"}
+
+	switch recordType {
+	case "MX", "SRV":
+		want := 2
+		if recordType == "SRV" {
+			want = 4
+		}
+		if len(data) != want {
+			return record, fmt.Errorf("%s record of %s needs %d fields, got %d", recordType, hostname, want, len(data))
+		}
+		if _, err := strconv.Atoi(data[0].text); err != nil {
+			return record, fmt.Errorf("invalid %s priority %q of %s", recordType, data[0].text, hostname)
+		}
+		record.Priority = data[0].text
+		record.Destination = joinRaw(data[1:])
+	case "TXT":
+		var value strings.Builder
+		for _, t := range data {
+			value.WriteString(t.text)
+		}
+		record.Destination = value.String()
+	default:
+		record.Destination = joinRaw(data)
+	}
+	return record, nil
+}
+
+func joinRaw(tokens []token) string {
+	fields := make([]string, len(tokens))
+	for i, t := range tokens {
+		fields[i] = t.raw
+	}
+	return strings.Join(fields, " ")
+}
+
+// absolute returns name as a lowercase absolute name without trailing dot
+func absolute(name, origin string) string {
+	name = strings.ToLower(name)
+	switch {
+	case name == "@":
+		return origin
+	case strings.HasSuffix(name, "."):
+		return strings.TrimSuffix(name, ".")
+	default:
+		return name + "." + origin
+	}
+}
+
+// relative returns the Netcup host name of the absolute name within domain
+func relative(name, domain string) (string, error) {
+	if name == domain {
+		return "@", nil
+	}
+	if host, ok := strings.CutSuffix(name, "."+domain); ok {
+		return host, nil
+	}
+	return "", fmt.Errorf("name %s is outside of %s", name, domain)
+}
+
+// line is a logical zone file line, with parentheses joined
+type line struct {
+	number    int
+	text      string
+	continued bool // Starts with whitespace, i.e. repeats the previous owner
+}
+
+// logicalLines reads r, strips comments and joins lines enclosed in parentheses
+func logicalLines(r io.Reader) ([]line, error) {
+	var lines []line
+	var current *line
+	depth := 0
+
+	scanner := bufio.NewScanner(r)
+	number := 0
+	for scanner.Scan() {
+		number++
+		text, opened, err := stripComment(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+
+		if depth > 0 {
+			current.text += " " + text
+		} else {
+			lines = append(lines, line{
+				number:    number,
+				text:      text,
+				continued: strings.TrimSpace(text) != "" && (text[0] == ' ' || text[0] == '\t'),
+			})
+			current = &lines[len(lines)-1]
+		}
+		depth += opened
+		if depth < 0 {
+			return nil, fmt.Errorf("line %d: unbalanced parentheses", number)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses at end of file")
+	}
+	return lines, nil
+}
+
+// stripComment removes a trailing comment and parentheses outside of quotes, and
+// returns the net number of opened parentheses
+func stripComment(text string) (string, int, error) {
+	var b strings.Builder
+	opened := 0
+	quoted, escaped := false, false
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == ';':
+			return b.String(), opened, nil
+		case r == '(':
+			opened++
+			r = ' '
+		case r == ')':
+			opened--
+			r = ' '
+		}
+		b.WriteRune(r)
+	}
+	if quoted {
+		return "", 0, fmt.Errorf("unterminated quoted string")
+	}
+	return b.String(), opened, nil
+}
+
+// tokenize splits a line into whitespace-separated fields, keeping quoted strings whole
+func tokenize(text string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(text) {
+		if text[i] == ' ' || text[i] == '\t' {
+			i++
+			continue
+		}
+
+		start := i
+		var value strings.Builder
+		if text[i] == '"' {
+			i++
+			for ; i < len(text) && text[i] != '"'; i++ {
+				if text[i] == '\\' && i+1 < len(text) {
+					i++
+				}
+				value.WriteByte(text[i])
+			}
+			if i >= len(text) {
+				return nil, fmt.Errorf("unterminated quoted string")
+			}
+			i++
+		} else {
+			for ; i < len(text) && text[i] != ' ' && text[i] != '\t'; i++ {
+				if text[i] == '\\' && i+1 < len(text) {
+					i++
+				}
+				value.WriteByte(text[i])
+			}
+		}
+		tokens = append(tokens, token{text: value.String(), raw: text[start:i]})
+	}
+	return tokens, nil
+}
@@ -0,0 +1,97 @@
+package zonefile
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	netcup "github.com/alex289/docker-traefik-netcup-companion/internal/netcup"
+)
+
+func TestParse(t *testing.T) {
+	input := `$ORIGIN example.com.
+$TTL 3600
+@	IN	SOA	root.example.com. hostmaster.example.com. (
+		2024010101 ; serial
+		86400 7200 1209600 300 )
+@	IN	NS	ns1.example.net.
+@	3600	IN	MX	10 mail.example.com.
+	IN	TXT	"v=spf1 mx -all" ; same owner
+app	A	203.0.113.1
+www.example.com.	IN 300	CNAME	app
+_sip._tcp	IN	SRV	10 60 5060 sip.example.com.
+@	IN	CAA	0 issue "letsencrypt.org"
+long	IN	TXT	"part one;" " part \"two\""
+sub	IN	NS	ns.other.example.
+`
+	zone, err := Parse(strings.NewReader(input), "example.com")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if zone.TTL != "3600" {
+		t.Errorf("TTL = %q, want 3600", zone.TTL)
+	}
+
+	want := []netcup.DnsRecord{
+		{Hostname: "@", Type: "MX", Priority: "10", Destination: "mail.example.com."},
+		{Hostname: "@", Type: "TXT", Priority: "0", Destination: "v=spf1 mx -all"},
+		{Hostname: "app", Type: "A", Priority: "0", Destination: "203.0.113.1"},
+		{Hostname: "www", Type: "CNAME", Priority: "0", Destination: "app"},
+		{Hostname: "_sip._tcp", Type: "SRV", Priority: "10", Destination: "60 5060 sip.example.com."},
+		{Hostname: "@", Type: "CAA", Priority: "0", Destination: `0 issue "letsencrypt.org"`},
+		{Hostname: "long", Type: "TXT", Priority: "0", Destination: `part one; part "two"`},
+		{Hostname: "sub", Type: "NS", Priority: "0", Destination: "ns.other.example."},
+	}
+	if !reflect.DeepEqual(zone.Records, want) {
+		t.Errorf("Records =\n%+v\nwant\n%+v", zone.Records, want)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := map[string]string{
+		"outside zone":       "app.example.org. IN A 203.0.113.1\n",
+		"missing type":       "app 300 IN\n",
+		"unbalanced":         "@ IN SOA a. b. ( 1 2 3\n",
+		"unterminated quote": "app IN TXT \"open\n",
+		"bad priority":       "@ IN MX high mail.example.com.\n",
+		"include":            "$INCLUDE other.zone\n",
+		"continuation first": "\tIN A 203.0.113.1\n",
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(input), "example.com"); err == nil {
+				t.Errorf("Parse(%q) error = nil, want error", input)
+			}
+		})
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	zone := &Zone{
+		Domain: "example.com",
+		TTL:    "300",
+		Records: []netcup.DnsRecord{
+			{Hostname: "@", Type: "A", Priority: "0", Destination: "203.0.113.1"},
+			{Hostname: "@", Type: "MX", Priority: "20", Destination: "mail.example.com."},
+			{Hostname: "dkim._domainkey", Type: "TXT", Priority: "0", Destination: strings.Repeat("k", 300) + `"\`},
+			{Hostname: "@", Type: "CAA", Priority: "0", Destination: `0 issue "letsencrypt.org"`},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, zone, &netcup.DnsZoneData{Serial: "2024010101", Refresh: "28800", Retry: "7200", Expire: "1209600"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "; SOA managed by Netcup: serial 2024010101") {
+		t.Errorf("Write() output misses SOA comment:\n%s", buf.String())
+	}
+
+	parsed, err := Parse(&buf, "example.com")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(parsed, zone) {
+		t.Errorf("round trip = %+v, want %+v", parsed, zone)
+	}
+}